@@ -0,0 +1,186 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// Default thresholds of --anomalies
+const (
+	DefaultAnomalyOverRequest  = 2.0
+	DefaultAnomalyUnderRequest = 10.0
+)
+
+// containerAnomaly is a container whose usage is out of line with its
+// requests or limits
+type containerAnomaly struct {
+	node      string
+	namespace string
+	pod       string
+	container *containerMetric
+	anomalies []string
+}
+
+type listAnomalies struct {
+	Containers []*listContainerAnomaly `json:"containers"`
+}
+
+type listContainerAnomaly struct {
+	Node      string               `json:"node"`
+	Namespace string               `json:"namespace"`
+	Pod       string               `json:"pod"`
+	Container string               `json:"container"`
+	CPU       *listAnomalyResource `json:"cpu"`
+	Memory    *listAnomalyResource `json:"memory"`
+	Anomalies []string             `json:"anomalies"`
+}
+
+type listAnomalyResource struct {
+	Requests    string `json:"requests"`
+	Limits      string `json:"limits"`
+	Utilization string `json:"utilization"`
+}
+
+// buildAnomalies returns the containers whose usage is over their limit,
+// over overRequest times their request, or under underRequest percent of
+// their request. Containers without utilization data are skipped, which
+// shows up as no memory usage since a running container always has some.
+func buildAnomalies(cm *clusterMetric, sortBy string, overRequest, underRequest float64) []*containerAnomaly {
+	anomalies := []*containerAnomaly{}
+	for _, nm := range cm.getSortedNodeMetrics(sortBy) {
+		for _, pm := range nm.getSortedPodMetrics(sortBy) {
+			for _, c := range pm.getSortedContainerMetrics(sortBy) {
+				if c.memory.utilization.IsZero() {
+					continue
+				}
+				found := append(
+					c.cpu.anomalies(overRequest, underRequest),
+					c.memory.anomalies(overRequest, underRequest)...)
+				if len(found) == 0 {
+					continue
+				}
+				anomalies = append(anomalies, &containerAnomaly{
+					node:      nm.name,
+					namespace: pm.namespace,
+					pod:       pm.name,
+					container: c,
+					anomalies: found,
+				})
+			}
+		}
+	}
+	return anomalies
+}
+
+// anomalies describes how the usage of the resource is out of line with
+// its request and limit. Usage is only compared with a request or limit
+// that is set.
+func (rm *resourceMetric) anomalies(overRequest, underRequest float64) []string {
+	found := []string{}
+	usage := float64(rm.utilization.MilliValue())
+	if limit := float64(rm.limit.MilliValue()); limit > 0 && usage > limit {
+		found = append(found, fmt.Sprintf("%s over limit", rm.resourceType))
+	}
+	if request := float64(rm.request.MilliValue()); request > 0 {
+		if usage > request*overRequest {
+			found = append(found, fmt.Sprintf("%s over %gx request", rm.resourceType, overRequest))
+		} else if usage < request*underRequest/100 {
+			found = append(found, fmt.Sprintf("%s under %g%% of request", rm.resourceType, underRequest))
+		}
+	}
+	return found
+}
+
+func printAnomalies(run *reportRun, cm *clusterMetric, opts Options) error {
+	ap := &anomalyPrinter{
+		run:       run,
+		anomalies: buildAnomalies(cm, opts.SortBy, opts.AnomalyOverRequest, opts.AnomalyUnderRequest),
+		opts:      opts,
+	}
+	return ap.Print(opts.OutputFormat)
+}
+
+type anomalyPrinter struct {
+	run       *reportRun
+	anomalies []*containerAnomaly
+	opts      Options
+}
+
+func (ap *anomalyPrinter) Print(outputType string) error {
+	switch outputType {
+	case JSONOutput, YAMLOutput:
+		printListOutput(ap.run, AnomalyReportKind, ap.buildListAnomalies(), outputType)
+	case TableOutput:
+		w := tabwriter.NewWriter(ap.run.output, 0, 8, 2, ' ', 0)
+		ap.printTable(w, "\t ")
+		if err := w.Flush(); err != nil {
+			ap.run.logErrorf("Error writing to table: %s", err)
+		}
+	case CSVOutput:
+		ap.printTable(ap.run.output, ",")
+	case TSVOutput:
+		ap.printTable(ap.run.output, "\t")
+	default:
+		return fmt.Errorf("Called with an unsupported output type: %s", outputType)
+	}
+	return nil
+}
+
+func (ap *anomalyPrinter) printTable(w io.Writer, separator string) {
+	headers := []string{"NODE", "NAMESPACE", "POD", "CONTAINER"}
+	for _, prefix := range []string{"CPU", "MEMORY"} {
+		headers = append(headers, prefix+" REQUESTS", prefix+" LIMITS", prefix+" UTIL")
+	}
+	headers = append(headers, "ANOMALIES")
+	_, _ = fmt.Fprintln(w, strings.Join(headers, separator))
+
+	for _, c := range ap.buildListAnomalies().Containers {
+		items := []string{c.Node, c.Namespace, c.Pod, c.Container}
+		for _, r := range []*listAnomalyResource{c.CPU, c.Memory} {
+			items = append(items, r.Requests, r.Limits, r.Utilization)
+		}
+		items = append(items, strings.Join(c.Anomalies, "; "))
+		_, _ = fmt.Fprintln(w, strings.Join(items, separator))
+	}
+}
+
+func (ap *anomalyPrinter) buildListAnomalies() *listAnomalies {
+	out := &listAnomalies{Containers: []*listContainerAnomaly{}}
+	for _, a := range ap.anomalies {
+		out.Containers = append(out.Containers, &listContainerAnomaly{
+			Node:      a.node,
+			Namespace: a.namespace,
+			Pod:       a.pod,
+			Container: a.container.displayName(),
+			CPU:       listAnomalyResourceOf(ap.run, a.container.cpu),
+			Memory:    listAnomalyResourceOf(ap.run, a.container.memory),
+			Anomalies: a.anomalies,
+		})
+	}
+	return out
+}
+
+func listAnomalyResourceOf(run *reportRun, rm *resourceMetric) *listAnomalyResource {
+	valueCalculator := rm.valueFunction(run)
+	return &listAnomalyResource{
+		Requests:    valueCalculator(rm.request),
+		Limits:      valueCalculator(rm.limit),
+		Utilization: valueCalculator(rm.utilization),
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	v1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+)
+
+func TestResourceAnomalies(t *testing.T) {
+	tests := []struct {
+		name     string
+		request  string
+		limit    string
+		usage    string
+		expected []string
+	}{
+		{"in line", "100m", "200m", "80m", []string{}},
+		{"over limit", "100m", "150m", "160m", []string{"cpu over limit"}},
+		{"over limit and request", "50m", "150m", "160m", []string{"cpu over limit", "cpu over 2x request"}},
+		{"over request without limit", "100m", "0", "250m", []string{"cpu over 2x request"}},
+		{"under request", "1", "0", "50m", []string{"cpu under 10% of request"}},
+		{"no request", "0", "0", "5", []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rm := &resourceMetric{
+				resourceType: "cpu",
+				request:      resource.MustParse(tt.request),
+				limit:        resource.MustParse(tt.limit),
+				utilization:  resource.MustParse(tt.usage),
+			}
+			assert.Equal(t, tt.expected, rm.anomalies(DefaultAnomalyOverRequest, DefaultAnomalyUnderRequest))
+		})
+	}
+}
+
+func TestBuildAnomalies(t *testing.T) {
+	run := newTestRun(nil)
+	n := node("mynode", map[string]string{}, false)
+	n.Status.Allocatable = corev1.ResourceList{
+		"cpu":    resource.MustParse("4"),
+		"memory": resource.MustParse("8Gi"),
+	}
+
+	withContainer := func(p *corev1.Pod, cpu, memory string) *corev1.Pod {
+		p.Spec.Containers = []corev1.Container{{
+			Name: "app",
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{"cpu": resource.MustParse(cpu), "memory": resource.MustParse(memory)},
+				Limits:   corev1.ResourceList{"memory": resource.MustParse(memory)},
+			},
+		}}
+		return p
+	}
+	idle := withContainer(pod("mynode", "default", "idle", nil), "1", "1Gi")
+	busy := withContainer(pod("mynode", "default", "busy", nil), "100m", "256Mi")
+	fine := withContainer(pod("mynode", "default", "fine", nil), "100m", "256Mi")
+	noMetrics := withContainer(pod("mynode", "default", "no-metrics", nil), "1", "1Gi")
+
+	usage := func(name, cpu, memory string) v1beta1.PodMetrics {
+		return v1beta1.PodMetrics{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Containers: []v1beta1.ContainerMetrics{{
+				Name:  "app",
+				Usage: corev1.ResourceList{"cpu": resource.MustParse(cpu), "memory": resource.MustParse(memory)},
+			}},
+		}
+	}
+	pmList := &v1beta1.PodMetricsList{Items: []v1beta1.PodMetrics{
+		usage("idle", "20m", "512Mi"),
+		usage("busy", "300m", "300Mi"),
+		usage("fine", "90m", "200Mi"),
+	}}
+
+	cm := buildClusterMetric(
+		&corev1.PodList{Items: []corev1.Pod{*idle, *busy, *fine, *noMetrics}}, pmList,
+		&corev1.NodeList{Items: []corev1.Node{*n}}, nil)
+
+	ap := &anomalyPrinter{run: run, anomalies: buildAnomalies(&cm, "name", DefaultAnomalyOverRequest, DefaultAnomalyUnderRequest)}
+	list := ap.buildListAnomalies()
+
+	require.Len(t, list.Containers, 2)
+	assert.Equal(t, &listContainerAnomaly{
+		Node:      "mynode",
+		Namespace: "default",
+		Pod:       "busy",
+		Container: "app",
+		CPU:       &listAnomalyResource{Requests: "100m", Limits: "0m", Utilization: "300m"},
+		Memory:    &listAnomalyResource{Requests: "256Mi", Limits: "256Mi", Utilization: "300Mi"},
+		Anomalies: []string{"cpu over 2x request", "memory over limit"},
+	}, list.Containers[0])
+	assert.Equal(t, "idle", list.Containers[1].Pod)
+	assert.Equal(t, []string{"cpu under 10% of request"}, list.Containers[1].Anomalies)
+}
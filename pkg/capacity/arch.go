@@ -0,0 +1,162 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+type listArchitectures struct {
+	ClusterTotals *listArchitecture   `json:"clusterTotals"`
+	Architectures []*listArchitecture `json:"architectures"`
+}
+
+type listArchitecture struct {
+	Name   string                    `json:"name,omitempty"`
+	Nodes  int64                     `json:"nodes"`
+	CPU    *listArchitectureResource `json:"cpu"`
+	Memory *listArchitectureResource `json:"memory"`
+}
+
+type listArchitectureResource struct {
+	Allocatable    string `json:"allocatable"`
+	Requests       string `json:"requests"`
+	RequestsPct    string `json:"requestsPercent"`
+	Available      string `json:"available"`
+	Utilization    string `json:"utilization,omitempty"`
+	UtilizationPct string `json:"utilizationPercent,omitempty"`
+}
+
+// architecture returns the kubernetes.io/arch label of the node, which the
+// kubelet sets on every node it registers
+func (nm *nodeMetric) architecture() string {
+	if arch := nm.labels[corev1.LabelArchStable]; arch != "" {
+		return arch
+	}
+	return ungroupedNodeGroup
+}
+
+// buildArchitectureMetrics groups the nodes of the cluster by architecture,
+// returning the cluster totals followed by each architecture in name order
+func buildArchitectureMetrics(cm *clusterMetric) (*nodeSetMetric, []*nodeSetMetric) {
+	totals, sets := splitNodes(cm, (*nodeMetric).architecture)
+
+	architectures := []*nodeSetMetric{}
+	for _, ns := range sets {
+		architectures = append(architectures, ns)
+	}
+	sort.Slice(architectures, func(i, j int) bool {
+		return architectures[i].name < architectures[j].name
+	})
+	return totals, architectures
+}
+
+func printArchitectures(run *reportRun, cm *clusterMetric, opts Options) error {
+	ap := &archPrinter{run: run, opts: opts}
+	ap.totals, ap.architectures = buildArchitectureMetrics(cm)
+	return ap.Print(opts.OutputFormat)
+}
+
+type archPrinter struct {
+	run           *reportRun
+	totals        *nodeSetMetric
+	architectures []*nodeSetMetric
+	opts          Options
+}
+
+func (ap *archPrinter) Print(outputType string) error {
+	switch outputType {
+	case JSONOutput, YAMLOutput:
+		printListOutput(ap.run, ArchitectureReportKind, ap.buildListArchitectures(), outputType)
+	case TableOutput:
+		w := tabwriter.NewWriter(ap.run.output, 0, 8, 2, ' ', 0)
+		ap.printTable(w, "\t ")
+		if err := w.Flush(); err != nil {
+			ap.run.logErrorf("Error writing to table: %s", err)
+		}
+	case CSVOutput:
+		ap.printTable(ap.run.output, ",")
+	case TSVOutput:
+		ap.printTable(ap.run.output, "\t")
+	default:
+		return fmt.Errorf("Called with an unsupported output type: %s", outputType)
+	}
+	return nil
+}
+
+func (ap *archPrinter) printTable(w io.Writer, separator string) {
+	headers := []string{"ARCH", "NODES"}
+	for _, prefix := range []string{"CPU", "MEMORY"} {
+		headers = append(headers, prefix+" ALLOCATABLE", prefix+" REQUESTS", prefix+" AVAILABLE")
+		if ap.opts.ShowUtil {
+			headers = append(headers, prefix+" UTIL")
+		}
+	}
+	_, _ = fmt.Fprintln(w, strings.Join(headers, separator))
+
+	list := ap.buildListArchitectures()
+	list.ClusterTotals.Name = VoidValue
+	for _, la := range append([]*listArchitecture{list.ClusterTotals}, list.Architectures...) {
+		items := []string{la.Name, fmt.Sprintf("%d", la.Nodes)}
+		for _, r := range []*listArchitectureResource{la.CPU, la.Memory} {
+			items = append(items, r.Allocatable, fmt.Sprintf("%s (%s)", r.Requests, r.RequestsPct), r.Available)
+			if ap.opts.ShowUtil {
+				items = append(items, fmt.Sprintf("%s (%s)", r.Utilization, r.UtilizationPct))
+			}
+		}
+		_, _ = fmt.Fprintln(w, strings.Join(items, separator))
+	}
+}
+
+func (ap *archPrinter) buildListArchitectures() *listArchitectures {
+	out := &listArchitectures{
+		ClusterTotals: ap.listArchitecture(ap.totals),
+		Architectures: []*listArchitecture{},
+	}
+	for _, ns := range ap.architectures {
+		out.Architectures = append(out.Architectures, ap.listArchitecture(ns))
+	}
+	return out
+}
+
+func (ap *archPrinter) listArchitecture(ns *nodeSetMetric) *listArchitecture {
+	return &listArchitecture{
+		Name:   ns.name,
+		Nodes:  ns.nodes,
+		CPU:    ap.listArchitectureResource(ns.cpu),
+		Memory: ap.listArchitectureResource(ns.memory),
+	}
+}
+
+func (ap *archPrinter) listArchitectureResource(rm *resourceMetric) *listArchitectureResource {
+	valueCalculator := rm.valueFunction(ap.run)
+	out := &listArchitectureResource{
+		Allocatable: valueCalculator(rm.allocatable),
+		Requests:    valueCalculator(rm.request),
+		RequestsPct: percentString(ap.run, rm.request, rm.allocatable),
+		Available:   valueCalculator(rm.available()),
+	}
+	if ap.opts.ShowUtil {
+		out.Utilization = valueCalculator(rm.utilization)
+		out.UtilizationPct = percentString(ap.run, rm.utilization, rm.utilBase(ap.opts.UtilPercent))
+	}
+	return out
+}
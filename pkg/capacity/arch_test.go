@@ -0,0 +1,61 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArchitectureReport(t *testing.T) {
+	run := newTestRun(nil)
+	snap := getTestSnapshot()
+	snap.Nodes.Items[0].Labels["kubernetes.io/arch"] = "arm64"
+	snap.Nodes.Items[1].Labels["kubernetes.io/arch"] = "amd64"
+	cm := buildClusterMetric(snap.Pods, nil, snap.Nodes, nil)
+
+	ap := &archPrinter{run: run}
+	ap.totals, ap.architectures = buildArchitectureMetrics(&cm)
+	require.Len(t, ap.architectures, 2)
+	assert.Equal(t, "amd64", ap.architectures[0].name)
+	assert.Equal(t, "arm64", ap.architectures[1].name)
+
+	list := ap.buildListArchitectures()
+	assert.Equal(t, int64(2), list.ClusterTotals.Nodes)
+	assert.Equal(t, &listArchitectureResource{
+		Allocatable: "1000m",
+		Requests:    "100m",
+		RequestsPct: "10%",
+		Available:   "900m",
+	}, list.Architectures[1].CPU)
+
+	var buf bytes.Buffer
+	ap.printTable(&buf, ",")
+	assert.Equal(t, strings.Join([]string{
+		"ARCH,NODES,CPU ALLOCATABLE,CPU REQUESTS,CPU AVAILABLE,MEMORY ALLOCATABLE,MEMORY REQUESTS,MEMORY AVAILABLE",
+		"*,2,2000m,200m (10%),1800m,8000Mi,256Mi (3%),7744Mi",
+		"amd64,1,1000m,100m (10%),900m,4000Mi,128Mi (3%),3872Mi",
+		"arm64,1,1000m,100m (10%),900m,4000Mi,128Mi (3%),3872Mi",
+	}, "\n")+"\n", buf.String())
+}
+
+func TestArchitectureWithoutLabel(t *testing.T) {
+	nm := &nodeMetric{labels: map[string]string{}}
+	assert.Equal(t, ungroupedNodeGroup, nm.architecture())
+}
@@ -0,0 +1,80 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSignAWSRequest checks the get-vanilla case of the AWS Signature
+// Version 4 test suite
+func TestSignAWSRequest(t *testing.T) {
+	req, err := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	require.NoError(t, err)
+	creds := &awsCredentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"}
+
+	signAWSRequest(req, nil, creds, "us-east-1", "service", time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+
+	assert.Equal(t, "20150830T123600Z", req.Header.Get("X-Amz-Date"))
+	assert.Equal(t, "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, "+
+		"SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31",
+		req.Header.Get("Authorization"))
+}
+
+func TestGetAWSSharedCredentials(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "credentials")
+	require.NoError(t, os.WriteFile(path, []byte(`[default]
+aws_access_key_id = AKIDDEFAULT
+aws_secret_access_key = default-secret
+
+# a profile with a session
+[prod]
+aws_access_key_id=AKIDPROD
+aws_secret_access_key=prod-secret
+aws_session_token=prod-token
+
+[sso]
+sso_session = corp
+`), 0o600))
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", path)
+
+	t.Setenv("AWS_PROFILE", "")
+	creds, err := getAWSSharedCredentials()
+	require.NoError(t, err)
+	assert.Equal(t, &awsCredentials{AccessKeyID: "AKIDDEFAULT", SecretAccessKey: "default-secret"}, creds)
+
+	t.Setenv("AWS_PROFILE", "prod")
+	creds, err = getAWSSharedCredentials()
+	require.NoError(t, err)
+	assert.Equal(t, &awsCredentials{AccessKeyID: "AKIDPROD", SecretAccessKey: "prod-secret", SessionToken: "prod-token"}, creds)
+
+	// Profiles without static keys are left to the other sources
+	t.Setenv("AWS_PROFILE", "sso")
+	creds, err = getAWSSharedCredentials()
+	require.NoError(t, err)
+	assert.Nil(t, creds)
+
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", filepath.Join(t.TempDir(), "missing"))
+	creds, err = getAWSSharedCredentials()
+	require.NoError(t, err)
+	assert.Nil(t, creds)
+}
@@ -0,0 +1,189 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// azurePrometheusResource is the Azure AD resource of Azure Monitor managed
+// Prometheus query endpoints
+const azurePrometheusResource = "https://prometheus.monitor.azure.com"
+
+// azureIMDSTimeout keeps the managed identity lookup from holding up runs
+// outside of Azure
+const azureIMDSTimeout = 2 * time.Second
+
+// azureTokenTimeout bounds the exchange of the workload identity token with
+// Azure AD
+const azureTokenTimeout = 10 * time.Second
+
+var (
+	azureIMDSEndpoint = "http://169.254.169.254"
+	azureCLICommand   = "az"
+)
+
+type azureToken struct {
+	AccessToken string `json:"access_token"`
+}
+
+// getAzureToken tries workload identity, managed identity, and the Azure
+// CLI in turn, the same order as DefaultAzureCredential
+func getAzureToken(ctx context.Context, run *reportRun) (string, error) {
+	failures := []string{}
+
+	if os.Getenv("AZURE_FEDERATED_TOKEN_FILE") != "" {
+		token, err := getAzureWorkloadIdentityToken(ctx)
+		if err == nil {
+			run.logDebugf("Using Azure workload identity")
+			return token, nil
+		}
+		failures = append(failures, fmt.Sprintf("workload identity: %v", err))
+	}
+
+	token, err := getAzureMSIToken(ctx)
+	if err == nil {
+		run.logDebugf("Using Azure managed identity")
+		return token, nil
+	}
+	failures = append(failures, fmt.Sprintf("managed identity: %v", err))
+
+	token, err = getAzureCLIToken(ctx)
+	if err == nil {
+		run.logDebugf("Using Azure CLI credentials")
+		return token, nil
+	}
+	failures = append(failures, fmt.Sprintf("Azure CLI: %v", err))
+
+	return "", fmt.Errorf("no Azure credentials found (%s)", strings.Join(failures, "; "))
+}
+
+// getAzureWorkloadIdentityToken exchanges the service account token that
+// AKS workload identity mounts for an Azure AD token
+func getAzureWorkloadIdentityToken(ctx context.Context) (string, error) {
+	clientID, tenantID := os.Getenv("AZURE_CLIENT_ID"), os.Getenv("AZURE_TENANT_ID")
+	if clientID == "" || tenantID == "" {
+		return "", fmt.Errorf("AZURE_CLIENT_ID and AZURE_TENANT_ID must be set")
+	}
+	assertion, err := os.ReadFile(os.Getenv("AZURE_FEDERATED_TOKEN_FILE"))
+	if err != nil {
+		return "", err
+	}
+	ctx, cancel := context.WithTimeout(ctx, azureTokenTimeout)
+	defer cancel()
+
+	authority := strings.TrimRight(firstNonEmpty(os.Getenv("AZURE_AUTHORITY_HOST"), "https://login.microsoftonline.com"), "/")
+	form := url.Values{}
+	form.Set("grant_type", "client_credentials")
+	form.Set("client_id", clientID)
+	form.Set("scope", azurePrometheusResource+"/.default")
+	form.Set("client_assertion_type", "urn:ietf:params:oauth:client-assertion-type:jwt-bearer")
+	form.Set("client_assertion", strings.TrimSpace(string(assertion)))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		fmt.Sprintf("%s/%s/oauth2/v2.0/token", authority, url.PathEscape(tenantID)), strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return doAzureTokenRequest(req)
+}
+
+// getAzureMSIToken reads a token for the managed identity from the Azure
+// instance metadata service, using AZURE_CLIENT_ID to pick a user-assigned
+// identity when it is set
+func getAzureMSIToken(ctx context.Context) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, azureIMDSTimeout)
+	defer cancel()
+
+	params := url.Values{}
+	params.Set("api-version", "2018-02-01")
+	params.Set("resource", azurePrometheusResource)
+	if clientID := os.Getenv("AZURE_CLIENT_ID"); clientID != "" {
+		params.Set("client_id", clientID)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		azureIMDSEndpoint+"/metadata/identity/oauth2/token?"+params.Encode(), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata", "true")
+	return doAzureTokenRequest(req)
+}
+
+// getAzureCLIToken asks the Azure CLI for a token of the account it is
+// logged in as
+func getAzureCLIToken(ctx context.Context) (string, error) {
+	out, err := exec.CommandContext(ctx, azureCLICommand, "account", "get-access-token",
+		"--resource", azurePrometheusResource, "--output", "json").Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+			return "", fmt.Errorf("%s", strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", err
+	}
+
+	var token struct {
+		AccessToken string `json:"accessToken"`
+	}
+	if err := json.Unmarshal(out, &token); err != nil {
+		return "", fmt.Errorf("parsing az account get-access-token output: %w", err)
+	}
+	if token.AccessToken == "" {
+		return "", fmt.Errorf("az account get-access-token returned no token")
+	}
+	return token.AccessToken, nil
+}
+
+func doAzureTokenRequest(req *http.Request) (string, error) {
+	resp, err := cloudHTTPClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		var azErr struct {
+			Error            string `json:"error"`
+			ErrorDescription string `json:"error_description"`
+		}
+		if json.Unmarshal(body, &azErr) == nil && azErr.Error != "" {
+			return "", fmt.Errorf("%s: %s", azErr.Error, azErr.ErrorDescription)
+		}
+		return "", fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var token azureToken
+	if err := json.Unmarshal(body, &token); err != nil {
+		return "", fmt.Errorf("parsing token response: %w", err)
+	}
+	if token.AccessToken == "" {
+		return "", fmt.Errorf("token response has no access_token")
+	}
+	return token.AccessToken, nil
+}
@@ -0,0 +1,135 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAzureMSIToken(t *testing.T) {
+	run := newTestRun(nil)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/metadata/identity/oauth2/token", r.URL.Path)
+		assert.Equal(t, "true", r.Header.Get("Metadata"))
+		assert.Equal(t, azurePrometheusResource, r.URL.Query().Get("resource"))
+		assert.Equal(t, "user-assigned", r.URL.Query().Get("client_id"))
+		fmt.Fprint(w, `{"access_token": "msi-token", "token_type": "Bearer"}`)
+	}))
+	defer server.Close()
+
+	endpoint := azureIMDSEndpoint
+	azureIMDSEndpoint = server.URL
+	defer func() { azureIMDSEndpoint = endpoint }()
+	t.Setenv("AZURE_CLIENT_ID", "user-assigned")
+
+	authorization, err := prometheusAuthorization(context.Background(), run, Options{PrometheusAuth: AzureMSIPrometheusAuth})
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer msi-token", authorization)
+}
+
+func TestAzureMSITokenTimeout(t *testing.T) {
+	// An IMDS that never answers, as on a cluster outside of Azure that
+	// drops the traffic
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	endpoint := azureIMDSEndpoint
+	azureIMDSEndpoint = server.URL
+	defer func() { azureIMDSEndpoint = endpoint }()
+
+	start := time.Now()
+	_, err := getAzureMSIToken(context.Background())
+	require.Error(t, err)
+	assert.Less(t, time.Since(start), azureIMDSTimeout+time.Second)
+}
+
+func TestAzureWorkloadIdentityToken(t *testing.T) {
+	run := newTestRun(nil)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/my-tenant/oauth2/v2.0/token", r.URL.Path)
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "my-client", r.Form.Get("client_id"))
+		assert.Equal(t, "https://prometheus.monitor.azure.com/.default", r.Form.Get("scope"))
+		if r.Form.Get("client_assertion") != "sa-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"error": "invalid_client", "error_description": "AADSTS700024: Client assertion is not within its valid time range."}`)
+			return
+		}
+		fmt.Fprint(w, `{"access_token": "wi-token"}`)
+	}))
+	defer server.Close()
+
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("sa-token\n"), 0o600))
+	t.Setenv("AZURE_FEDERATED_TOKEN_FILE", tokenFile)
+	t.Setenv("AZURE_CLIENT_ID", "my-client")
+	t.Setenv("AZURE_TENANT_ID", "my-tenant")
+	t.Setenv("AZURE_AUTHORITY_HOST", server.URL+"/")
+
+	token, err := getAzureToken(context.Background(), run)
+	require.NoError(t, err)
+	assert.Equal(t, "wi-token", token)
+
+	require.NoError(t, os.WriteFile(tokenFile, []byte("expired"), 0o600))
+	_, err = getAzureWorkloadIdentityToken(context.Background())
+	require.Error(t, err)
+	assert.Equal(t, "invalid_client: AADSTS700024: Client assertion is not within its valid time range.", err.Error())
+}
+
+func TestAzureCLIToken(t *testing.T) {
+	run := newTestRun(nil)
+	if runtime.GOOS == "windows" {
+		t.Skip("uses a shell script in place of az")
+	}
+	script := filepath.Join(t.TempDir(), "az")
+	require.NoError(t, os.WriteFile(script, []byte(`#!/bin/sh
+if [ "$4" != "https://prometheus.monitor.azure.com" ]; then
+  echo "ERROR: unexpected resource $4" >&2
+  exit 1
+fi
+echo '{"accessToken": "cli-token", "tokenType": "Bearer"}'
+`), 0o700))
+
+	command := azureCLICommand
+	azureCLICommand = script
+	defer func() { azureCLICommand = command }()
+
+	authorization, err := prometheusAuthorization(context.Background(), run, Options{PrometheusAuth: AzureCLIPrometheusAuth})
+	require.NoError(t, err)
+	assert.Equal(t, "Bearer cli-token", authorization)
+
+	require.NoError(t, os.WriteFile(script, []byte("#!/bin/sh\necho \"ERROR: Please run 'az login' to setup account.\" >&2\nexit 1\n"), 0o700))
+	_, err = prometheusAuthorization(context.Background(), run, Options{PrometheusAuth: AzureCLIPrometheusAuth})
+	require.Error(t, err)
+	assert.Equal(t, "getting an Azure AD token: ERROR: Please run 'az login' to setup account.", err.Error())
+}
@@ -0,0 +1,190 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"k8s.io/client-go/kubernetes"
+	v1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+)
+
+// cacheDir is where --cache-ttl keeps metrics, a var so tests can move it
+var cacheDir = func() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "kube-capacity"), nil
+}
+
+// metricsCacheEntry is the on-disk form of the metrics of one cluster and
+// query
+type metricsCacheEntry struct {
+	CreatedAt   time.Time                `json:"createdAt"`
+	PodMetrics  *v1beta1.PodMetricsList  `json:"podMetrics,omitempty"`
+	NodeMetrics *v1beta1.NodeMetricsList `json:"nodeMetrics,omitempty"`
+}
+
+// metricsCacheKey identifies the cluster and every option that changes which
+// metrics are fetched, so that cached metrics are only reused for the same
+// query. Credentials are left out, other than who is impersonated.
+func metricsCacheKey(opts Options) (string, error) {
+	kubeContext, err := clusterName(opts)
+	if err != nil {
+		return "", err
+	}
+	key, err := json.Marshal(struct {
+		Context, KubeConfig                    string
+		ImpersonateUser, ImpersonateGroup      string
+		MetricsSource                          string
+		PrometheusEndpoint, PrometheusWindow   string
+		PrometheusAggregation                  string
+		PrometheusHeaders                      []string
+		DatadogSite, DatadogScope              string
+		DatadogWindow                          time.Duration
+		CloudWatchCluster, Region              string
+		CloudWatchWindow                       time.Duration
+		CustomMetricsCPU, CustomMetricsMemory  string
+		UsageAggregation                       string
+		UsageOffset                            time.Duration
+		Namespace, NamespaceLabels, NodeLabels string
+	}{
+		kubeContext, opts.KubeConfig,
+		opts.ImpersonateUser, opts.ImpersonateGroup,
+		opts.MetricsSource,
+		opts.PrometheusEndpoint, opts.PrometheusWindow,
+		opts.PrometheusAggregation,
+		opts.PrometheusHeaders,
+		opts.DatadogSite, opts.DatadogScope,
+		opts.DatadogWindow,
+		opts.CloudWatchCluster, opts.Region,
+		opts.CloudWatchWindow,
+		opts.CustomMetricsCPU, opts.CustomMetricsMemory,
+		opts.UsageAggregation,
+		opts.usageOffset,
+		opts.Namespace, opts.NamespaceLabels, opts.NodeLabels,
+	})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// readMetricsCache returns the metrics cached under key, or nil if there are
+// none younger than ttl
+func readMetricsCache(run *reportRun, key string, ttl time.Duration) *metricsCacheEntry {
+	entry := &metricsCacheEntry{}
+	if !readCacheFile(run, key, entry) {
+		return nil
+	}
+	if time.Since(entry.CreatedAt) > ttl {
+		return nil
+	}
+	return entry
+}
+
+// writeMetricsCache stores metrics under key
+func writeMetricsCache(key string, entry *metricsCacheEntry) error {
+	return writeCacheFile(key, entry)
+}
+
+// readCacheFile decodes the file cached under key into v, and returns false
+// if there is none or it can not be read
+func readCacheFile(run *reportRun, key string, v interface{}) bool {
+	dir, err := cacheDir()
+	if err != nil {
+		return false
+	}
+	data, err := os.ReadFile(filepath.Join(dir, key+".json"))
+	if err != nil {
+		return false
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		run.logDebugf("Ignoring unreadable cache file %s: %v", key, err)
+		return false
+	}
+	return true
+}
+
+// writeCacheFile stores v under key, writing to a temporary file first so a
+// concurrent run never reads a partial entry
+func writeCacheFile(key string, v interface{}) error {
+	dir, err := cacheDir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(dir, key+".tmp*")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), filepath.Join(dir, key+".json"))
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+	}
+	return err
+}
+
+// fetchCachedMetrics gives the metrics cached by an earlier run within
+// --cache-ttl, or fetches them and caches them once the group has been
+// waited on
+func fetchCachedMetrics(run *reportRun, g *fetchGroup, clientset kubernetes.Interface, opts Options) func() (*v1beta1.PodMetricsList, *v1beta1.NodeMetricsList) {
+	uncached := opts
+	uncached.CacheTTL = 0
+
+	key, err := metricsCacheKey(opts)
+	if err != nil {
+		run.logDebugf("Not caching metrics: %v", err)
+		return fetchMetrics(run, g, clientset, uncached)
+	}
+	if entry := readMetricsCache(run, key, opts.CacheTTL); entry != nil {
+		run.logInfof("Using metrics cached %s ago", time.Since(entry.CreatedAt).Round(time.Second))
+		return func() (*v1beta1.PodMetricsList, *v1beta1.NodeMetricsList) {
+			return entry.PodMetrics, entry.NodeMetrics
+		}
+	}
+
+	liveMetrics := fetchMetrics(run, g, clientset, uncached)
+	return func() (*v1beta1.PodMetricsList, *v1beta1.NodeMetricsList) {
+		pmList, nmList := liveMetrics()
+		if pmList != nil {
+			entry := &metricsCacheEntry{CreatedAt: time.Now().UTC(), PodMetrics: pmList, NodeMetrics: nmList}
+			if err := writeMetricsCache(key, entry); err != nil {
+				run.logWarnf("Error caching metrics: %v", err)
+			}
+		}
+		return pmList, nmList
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+)
+
+func useTestCacheDir(t *testing.T) {
+	dir := t.TempDir()
+	original := cacheDir
+	cacheDir = func() (string, error) { return dir, nil }
+	t.Cleanup(func() { cacheDir = original })
+}
+
+func TestFetchCachedMetrics(t *testing.T) {
+	run := newTestRun(nil)
+	useTestCacheDir(t)
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		fmt.Fprint(w, `{"status": "success", "data": {"resultType": "vector", "result": [
+			{"metric": {"namespace": "default", "pod": "web", "container": "app", "node": "node-a"}, "value": [1767268800, "0.25"]}
+		]}}`)
+	}))
+	defer server.Close()
+
+	opts := Options{
+		KubeContext:        "prod",
+		MetricsSource:      PrometheusSource,
+		PrometheusEndpoint: server.URL,
+		PrometheusWindow:   "15m",
+		CacheTTL:           5 * time.Minute,
+	}
+	fetch := func(opts Options) (*v1beta1.PodMetricsList, *v1beta1.NodeMetricsList) {
+		g := newFetchGroup()
+		metrics := fetchMetrics(run, g, nil, opts)
+		require.Nil(t, g.wait())
+		return metrics()
+	}
+
+	pmList, nmList := fetch(opts)
+	require.Len(t, pmList.Items, 1)
+	require.Len(t, nmList.Items, 1)
+	fetched := requests
+
+	pmList, nmList = fetch(opts)
+	assert.Equal(t, fetched, requests, "second run is served from the cache")
+	require.Len(t, pmList.Items, 1)
+	assert.Equal(t, "250m", pmList.Items[0].Containers[0].Usage.Cpu().String())
+	require.Len(t, nmList.Items, 1)
+
+	opts.KubeContext = "staging"
+	fetch(opts)
+	assert.Equal(t, 2*fetched, requests, "another cluster is not served from the cache")
+
+	opts.CacheTTL = 0
+	fetch(opts)
+	assert.Equal(t, 3*fetched, requests, "the cache is off without --cache-ttl")
+}
+
+func TestReadMetricsCacheExpired(t *testing.T) {
+	run := newTestRun(nil)
+	useTestCacheDir(t)
+
+	entry := &metricsCacheEntry{CreatedAt: time.Now().Add(-10 * time.Minute), PodMetrics: &v1beta1.PodMetricsList{}}
+	require.NoError(t, writeMetricsCache("key", entry))
+
+	assert.NotNil(t, readMetricsCache(run, "key", 15*time.Minute))
+	assert.Nil(t, readMetricsCache(run, "key", 5*time.Minute))
+	assert.Nil(t, readMetricsCache(run, "missing", 15*time.Minute))
+}
+
+func TestMetricsCacheKey(t *testing.T) {
+	base := Options{KubeContext: "prod", MetricsSource: PrometheusSource, PrometheusWindow: "15m"}
+	key, err := metricsCacheKey(base)
+	require.NoError(t, err)
+
+	same := base
+	same.CacheTTL = time.Hour
+	same.ShowPods = true
+	sameKey, err := metricsCacheKey(same)
+	require.NoError(t, err)
+	assert.Equal(t, key, sameKey)
+
+	for _, change := range []func(*Options){
+		func(o *Options) { o.KubeContext = "staging" },
+		func(o *Options) { o.PrometheusWindow = "1h" },
+		func(o *Options) { o.Namespace = "default" },
+		func(o *Options) { o.PrometheusHeaders = []string{"X-Scope-OrgID: team-a"} },
+	} {
+		changed := base
+		change(&changed)
+		changedKey, err := metricsCacheKey(changed)
+		require.NoError(t, err)
+		assert.NotEqual(t, key, changedKey)
+	}
+}
@@ -17,10 +17,9 @@ package capacity
 import (
 	"context"
 	"fmt"
-	"os"
 	"strings"
+	"time"
 
-	"github.com/robscott/kube-capacity/pkg/kube"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
@@ -29,55 +28,862 @@ import (
 	metrics "k8s.io/metrics/pkg/client/clientset/versioned"
 )
 
-// FetchAndPrint gathers cluster resource data and outputs it
-func FetchAndPrint(opts Options) {
-	clientset, err := kube.NewClientSet(opts.KubeContext, opts.KubeConfig, opts.InsecureSkipTLSVerify, opts.ImpersonateUser, opts.ImpersonateGroup)
+// FetchAndPrint gathers cluster resource data and outputs it. The error
+// returned carries the exit code of kube-capacity, see ExitCode.
+func FetchAndPrint(opts Options) error {
+	return fetchAndPrint(newRun(opts), opts)
+}
+
+// fetchAndPrint gathers and prints the report of a single run
+func fetchAndPrint(run *reportRun, opts Options) error {
+	if len(opts.Contexts) > 0 || opts.AllContexts {
+		return fetchAndPrintFleet(run, opts)
+	}
+
+	cm, ferr := fetchClusterMetric(run, opts)
+	if ferr != nil {
+		return ferr
+	}
+	if opts.SnapshotIn == "" && len(opts.FromKubectlDump) == 0 {
+		updateLastRun(run, &cm, opts)
+	}
+	if opts.HistoryFile != "" {
+		if err := recordHistory(run, opts.HistoryFile, []*clusterMetric{&cm}, opts); err != nil {
+			return fmt.Errorf("Error recording history: %v", err)
+		}
+	}
+	if err := printReport(run, &cm, opts); err != nil {
+		return err
+	}
+	if err := run.closeOutput(); err != nil {
+		return err
+	}
+	if err := printAlsoOutputs(run, opts, func(run *reportRun, opts Options) error { return printReport(run, &cm, opts) }); err != nil {
+		return err
+	}
+	notifyThresholds(run, []*clusterMetric{&cm}, opts)
+	if ferr := thresholdsError(run, []*clusterMetric{&cm}, opts); ferr != nil {
+		return ferr
+	}
+	return nil
+}
+
+// fetchAndPrintFleet prints the report of --contexts or --all-contexts. The
+// clusters that could be fetched are printed before the contexts that could
+// not be are reported.
+func fetchAndPrintFleet(run *reportRun, opts Options) error {
+	fm, err := fetchFleetMetric(run, opts)
 	if err != nil {
-		fmt.Printf("Error connecting to Kubernetes: %v\n", err)
-		os.Exit(1)
+		return err
+	}
+	failed := failedContextsError(fm)
+	if failed != nil && len(fm.clusters) == 0 {
+		return failed
+	}
+	if opts.HistoryFile != "" {
+		if err := recordHistory(run, opts.HistoryFile, fm.clusters, opts); err != nil {
+			return fmt.Errorf("Error recording history: %v", err)
+		}
+	}
+	if err := printFleet(run, fm, opts); err != nil {
+		return err
+	}
+	if err := run.closeOutput(); err != nil {
+		return err
+	}
+	if err := printAlsoOutputs(run, opts, func(run *reportRun, opts Options) error { return printFleet(run, fm, opts) }); err != nil {
+		return err
+	}
+	notifyThresholds(run, fm.clusters, opts)
+	if failed != nil {
+		return failed
+	}
+	if ferr := thresholdsError(run, fm.clusters, opts); ferr != nil {
+		return ferr
+	}
+	return nil
+}
+
+// printReport prints the view the flags select, or the node report
+func printReport(run *reportRun, cm *clusterMetric, opts Options) error {
+	switch {
+	case opts.ShowQuotas:
+		return printQuotas(run, cm, opts)
+	case opts.ShowHeadroom:
+		return printHeadroom(run, cm, opts)
+	case opts.ShowKarpenter:
+		return printKarpenterPools(run, cm, opts)
+	case opts.ShowCapacityType:
+		return printCapacityTypes(run, cm, opts)
+	case opts.ShowArch:
+		return printArchitectures(run, cm, opts)
+	case opts.ShowZoneBalance:
+		return printZoneBalance(run, cm, opts)
+	case opts.ShowGPU:
+		return printGPUs(run, cm, opts)
+	case opts.SimulateRemoveNodes != "":
+		return printNodeRemoval(run, cm, opts)
+	case opts.ShowHPAHeadroom:
+		return printHPAHeadroom(run, cm, opts)
+	case opts.AllocateByPodLabel != "":
+		return printPodLabelAllocation(run, cm, opts)
+	case opts.ShowPending:
+		return printPending(run, cm, opts)
+	case opts.ShowDaemonSetOverhead:
+		return printDaemonSetOverhead(run, cm, opts)
+	case opts.ShowAnomalies:
+		return printAnomalies(run, cm, opts)
+	case opts.ShowStuckPods:
+		return printStuckPods(run, cm, opts)
+	case opts.Preemption != "":
+		return printPreemption(run, cm, opts)
+	case opts.ShowMissingResources:
+		return printMissingResources(run, cm, opts)
+	case opts.CompareOffset > 0:
+		return printCompare(run, cm, opts)
+	case opts.ShowDelta:
+		return printDelta(run, cm, opts)
+	case opts.Heatmap != "":
+		return printHeatmap(run, cm, opts)
+	case opts.Stream:
+		// Rows were printed while each node was read
+		return nil
+	default:
+		return printList(run, cm, opts)
+	}
+}
+
+// fetchClusterMetric gathers cluster resource data from the cluster or a
+// snapshot and builds the clusterMetric used by all reports
+func fetchClusterMetric(run *reportRun, opts Options) (clusterMetric, *fetchError) {
+	var clientset kubernetes.Interface
+	var snap *snapshot
+	var err error
+
+	if opts.SnapshotIn != "" {
+		snap, err = loadSnapshot(opts.SnapshotIn)
+		if err != nil {
+			return clusterMetric{}, newFetchError(1, "Error loading snapshot: %v", err)
+		}
+		run.logDebugf("Loaded snapshot %s captured at %s", opts.SnapshotIn, snap.CreatedAt.Format(time.RFC3339))
+		if opts.KubeContext != "" && opts.KubeContext != snap.Context {
+			return clusterMetric{}, newFetchError(1, "Error: snapshot was captured from context %q, not %q", snap.Context, opts.KubeContext)
+		}
+	} else if len(opts.FromKubectlDump) > 0 {
+		snap, err = loadKubectlDump(opts.FromKubectlDump)
+		if err != nil {
+			return clusterMetric{}, newFetchError(1, "Error loading kubectl output: %v", err)
+		}
+		run.logDebugf("Loaded %d nodes and %d pods from %s", len(snap.Nodes.Items), len(snap.Pods.Items), strings.Join(opts.FromKubectlDump, ", "))
+		if opts.ShowUtil && snap.PodMetrics == nil {
+			return clusterMetric{}, newFetchError(1, "Error: --util needs the output of kubectl get podmetrics -A -o json")
+		}
+	} else {
+		clientset, err = newClientSet(run, opts)
+		if err != nil {
+			return clusterMetric{}, newFetchError(1, "Error connecting to Kubernetes: %v", err)
+		}
+		if opts.CheckAccess {
+			if ferr := checkAccess(context.TODO(), run, clientset, opts); ferr != nil {
+				return clusterMetric{}, ferr
+			}
+		}
+	}
+
+	if opts.SnapshotOut != "" {
+		if snap == nil {
+			var ferr *fetchError
+			snap, ferr = captureSnapshot(run, clientset, opts)
+			if ferr != nil {
+				return clusterMetric{}, ferr
+			}
+		}
+		if err := saveSnapshot(opts.SnapshotOut, snap); err != nil {
+			return clusterMetric{}, newFetchError(1, "Error saving snapshot: %v", err)
+		}
+	}
+
+	if snap != nil {
+		// Reports rendered from a snapshot, including the one printed while
+		// capturing it, are filtered client side from the unfiltered data.
+		clientset = snap.clientset()
+		// The snapshot already holds every pod, so there is no API server
+		// to spread the pod list over.
+		opts.ListPodsByNode = false
+	}
+
+	// Nodes, pods, and live metrics do not depend on each other, so they
+	// are fetched at the same time
+	g := newFetchGroup()
+	var podsAndNodes func() (*corev1.PodList, *corev1.NodeList)
+	if opts.SpecSource == KubeStateMetricsSpecSource && snap == nil {
+		podsAndNodes = fetchKubeStateMetricsPodsAndNodes(run, g, clientset, opts)
+	} else {
+		podsAndNodes = fetchPodsAndNodes(run, g, clientset, newListFilters(opts))
+	}
+	var liveMetrics, earlierMetrics func() (*v1beta1.PodMetricsList, *v1beta1.NodeMetricsList)
+	if opts.ShowUtil && snap == nil {
+		liveMetrics = fetchMetrics(run, g, clientset, opts)
+		if opts.CompareOffset > 0 {
+			earlierOpts := opts
+			earlierOpts.usageOffset = opts.CompareOffset
+			earlierMetrics = fetchMetrics(run, g, clientset, earlierOpts)
+		}
+	}
+	var history func() *usageHistory
+	if opts.ShowSparkline && snap == nil {
+		history = fetchUsageHistory(run, g, clientset, opts)
+	}
+	var throttling func() *cpuThrottling
+	if opts.ShowThrottling && snap == nil {
+		throttling = fetchCPUThrottling(run, g, clientset, opts)
+	}
+	var prometheusDisks func() map[string]*nodeDisk
+	if opts.ShowDisk && opts.MetricsSource == PrometheusSource && snap == nil {
+		prometheusDisks = fetchPrometheusNodeFilesystems(run, g, clientset, opts)
+	}
+	if ferr := g.wait(); ferr != nil {
+		return clusterMetric{}, ferr
+	}
+	podList, nodeList := podsAndNodes()
+
+	if opts.SchedulableBy != "" {
+		sc, err := getSchedulingConstraints(run, opts.SchedulableBy)
+		if err != nil {
+			return clusterMetric{}, newFetchError(1, "Error: %v", err)
+		}
+		filterSchedulableNodes(podList, nodeList, sc)
+	}
+
+	if !opts.IncludeTerminated {
+		if excluded := excludeTerminatedPods(podList); excluded > 0 {
+			run.logInfof("Note: excluded %d succeeded or failed pods; include them with --include-terminated", excluded)
+		}
+	}
+
+	if opts.ExcludeDaemonSets {
+		excluded := excludeDaemonSetPods(podList)
+		run.logDebugf("Excluded %d DaemonSet pods", excluded)
+	}
+
+	if opts.ApplyLimitRangeDefaults {
+		if snap != nil && snap.LimitRanges == nil {
+			return clusterMetric{}, newFetchError(1, "Error: snapshot has no LimitRanges; re-capture with --apply-limitrange-defaults")
+		}
+		limitRangeList, ferr := getLimitRanges(run, clientset, opts.Namespace)
+		if ferr != nil {
+			return clusterMetric{}, ferr
+		}
+		applyLimitRangeDefaults(podList, limitRangeList)
+	}
+
+	if opts.IgnoreInitContainers {
+		ignoreInitContainers(podList)
+	}
+
+	var virtualPods *corev1.PodList
+	var virtualNodes *corev1.NodeList
+	if opts.VirtualNodes == ExcludeVirtualNodes || opts.VirtualNodes == SeparateVirtualNodes {
+		virtualPods, virtualNodes = splitVirtualNodes(podList, nodeList)
+		if opts.VirtualNodes == ExcludeVirtualNodes && len(virtualNodes.Items) > 0 {
+			run.logInfof("Note: excluded %d virtual nodes and the %d pods on them", len(virtualNodes.Items), len(virtualPods.Items))
+		}
 	}
 
-	podList, nodeList := getPodsAndNodes(clientset, opts.ExcludeTainted, opts.PodLabels, opts.NodeLabels, opts.NodeTaints, opts.NamespaceLabels, opts.Namespace)
 	var pmList *v1beta1.PodMetricsList
 	var nmList *v1beta1.NodeMetricsList
 
 	if opts.ShowUtil {
-		if opts.UsePrometheus {
+		if snap != nil {
+			pmList, nmList, err = snap.getMetrics(opts)
+			if err != nil {
+				return clusterMetric{}, newFetchError(1, "Error: %v", err)
+			}
+		} else {
+			pmList, nmList = liveMetrics()
+		}
+	}
+
+	var quotaList *corev1.ResourceQuotaList
+	if opts.ShowQuotas {
+		if snap != nil && snap.ResourceQuotas == nil {
+			return clusterMetric{}, newFetchError(1, "Error: snapshot has no resource quotas; re-capture with --quotas")
+		}
+		var ferr *fetchError
+		quotaList, ferr = getResourceQuotas(run, clientset, opts.Namespace, opts.NamespaceLabels)
+		if ferr != nil {
+			return clusterMetric{}, ferr
+		}
+	}
+
+	observedAt := time.Now()
+	if snap != nil {
+		observedAt = snap.CreatedAt
+	}
+
+	var cm clusterMetric
+	if opts.Stream {
+		sp, ferr := newStreamPrinter(run, opts)
+		if ferr != nil {
+			return clusterMetric{}, ferr
+		}
+		cm = streamClusterMetric(podList, pmList, nodeList, nmList, observedAt, sp)
+	} else {
+		cm = buildClusterMetric(podList, pmList, nodeList, nmList)
+		cm.observedAt = observedAt
+	}
+	if opts.VirtualNodes == SeparateVirtualNodes && len(virtualNodes.Items) > 0 {
+		virtual := buildClusterMetric(virtualPods, pmList, virtualNodes, nmList)
+		virtual.observedAt = observedAt
+		cm.virtualNodes = &virtual
+	}
+	if earlierMetrics != nil {
+		earlierPmList, earlierNmList := earlierMetrics()
+		earlier := buildClusterMetric(podList, earlierPmList, nodeList, earlierNmList)
+		cm.earlier = &earlier
+	}
+	if history != nil {
+		cm.addUsageHistory(history())
+	}
+	if throttling != nil {
+		cm.addCPUThrottling(throttling())
+	}
+	cm.resourceQuotas = quotaList
+
+	if opts.ShowReservedBreakdown {
+		if snap != nil {
+			run.logWarnf("kubelet configurations are not saved in snapshots, the reserved breakdown is left out")
+		} else {
+			cm.addReservedBreakdown(run, getKubeletConfigurations(run, clientset, nodeList))
+		}
+	}
+
+	if opts.ShowDisk {
+		if snap != nil {
+			run.logWarnf("node filesystems are not saved in snapshots, disk usage is left out")
+		} else if prometheusDisks != nil {
+			cm.addNodeDisk(prometheusDisks(), nodeList)
+		} else {
+			cm.addNodeDisk(getKubeletFilesystems(run, clientset, nodeList), nodeList)
+		}
+	}
+
+	if opts.ShowUtil {
+		warnStaleUsage(run, &cm, opts.MetricsMaxAge)
+		if opts.MetricsSource == PrometheusSource && snap == nil {
+			warnMissingWindowsUsage(run, &cm)
+		}
+	}
+
+	if opts.ShowPending {
+		if snap != nil && snap.PendingPods == nil {
+			return clusterMetric{}, newFetchError(1, "Error: snapshot has no pending pods; re-capture with --pending")
+		}
+		var ferr *fetchError
+		cm.pendingPods, ferr = getPendingPods(run, clientset, newListFilters(opts))
+		if ferr != nil {
+			return clusterMetric{}, ferr
+		}
+		if opts.IgnoreInitContainers {
+			ignoreInitContainers(cm.pendingPods)
+		}
+	}
+
+	if opts.DrainNode != "" {
+		if snap != nil && snap.PodDisruptionBudgets == nil {
+			return clusterMetric{}, newFetchError(1, "Error: snapshot has no PodDisruptionBudgets; re-capture with drain-check")
+		}
+		var ferr *fetchError
+		cm.podDisruptionBudgets, ferr = getPodDisruptionBudgets(run, clientset)
+		if ferr != nil {
+			return clusterMetric{}, ferr
+		}
+	}
+
+	if opts.ShowHPAHeadroom {
+		if snap != nil && snap.HorizontalPodAutoscalers == nil {
+			return clusterMetric{}, newFetchError(1, "Error: snapshot has no HorizontalPodAutoscalers; re-capture with --hpa-headroom")
+		}
+		var ferr *fetchError
+		cm.horizontalPodAutoscalers, ferr = getHorizontalPodAutoscalers(run, clientset)
+		if ferr != nil {
+			return clusterMetric{}, ferr
+		}
+	}
+
+	if opts.ShowVPA {
+		var vpaList *verticalPodAutoscalerList
+		if snap != nil {
+			if snap.VerticalPodAutoscalers == nil {
+				return clusterMetric{}, newFetchError(1, "Error: snapshot has no VerticalPodAutoscalers; re-capture with --show-vpa")
+			}
+			vpaList = snap.VerticalPodAutoscalers
+		} else {
+			var ferr *fetchError
+			vpaList, ferr = getVerticalPodAutoscalers(run, opts, opts.Namespace)
+			if ferr != nil {
+				return clusterMetric{}, ferr
+			}
+		}
+		cm.addVPARecommendations(podList, vpaList)
+	}
+
+	if opts.Filter != "" {
+		// The expression was validated along with the other flags
+		f, _ := parseFilter(opts.Filter)
+		cm.applyFilter(f, opts.UtilPercent)
+	}
+
+	if opts.ShowCost {
+		pricing, err := getPricing(opts.PricingFile)
+		if err != nil {
+			return clusterMetric{}, newFetchError(1, "Error loading pricing: %v", err)
+		}
+		if missing := cm.addCosts(pricing); len(missing) > 0 {
+			run.logWarnf("no price for %s; add them with --pricing-file", strings.Join(missing, ", "))
+		}
+	}
+
+	var npList *nodePoolList
+	if opts.ShowHeadroom || opts.ShowKarpenter {
+		if snap != nil {
+			if snap.NodePools == nil {
+				return clusterMetric{}, newFetchError(1, "Error: snapshot has no node group limits; re-capture with --headroom or --karpenter")
+			}
+			npList = snap.NodePools
+		} else {
+			var ferr *fetchError
+			npList, ferr = getNodePools(run, opts)
+			if ferr != nil {
+				return clusterMetric{}, ferr
+			}
+		}
+	}
+
+	if opts.ShowHeadroom {
+		status, ferr := getAutoscalerStatus(clientset)
+		if ferr != nil {
+			return clusterMetric{}, ferr
+		}
+		autoscalerGroups := []autoscalerNodeGroup{}
+		if status != nil {
+			autoscalerGroups = parseAutoscalerStatus(status.Data["status"])
+		}
+		if len(autoscalerGroups) == 0 && len(npList.Items) == 0 {
+			run.logWarnf("no Cluster Autoscaler node groups or Karpenter NodePools found, headroom is limited to current nodes")
+		}
+		cm.nodeGroups = buildNodeGroupMetrics(&cm, autoscalerGroups, npList)
+	}
+
+	if opts.ShowKarpenter {
+		cm.karpenterPools = buildKarpenterPoolMetrics(&cm, npList, opts.SortBy)
+		if len(cm.karpenterPools) == 0 {
+			run.logWarnf("no Karpenter NodePools or nodes with the %s label found", karpenterNodePoolLabel)
+		}
+	}
+
+	return cm, nil
+}
+
+// captureSnapshot lists nodes, pods, namespaces, and metrics without any
+// filters so that any report can later be rendered from the snapshot
+func captureSnapshot(run *reportRun, clientset kubernetes.Interface, opts Options) (*snapshot, *fetchError) {
+	kubeContext, err := clusterName(opts)
+	if err != nil {
+		return nil, newFetchError(1, "Error reading Kubernetes config: %v", err)
+	}
+
+	g := newFetchGroup()
+	podsAndNodes := fetchPodsAndNodes(run, g, clientset, listFilters{})
+
+	var nsList *corev1.NamespaceList
+	g.run(func(ctx context.Context) *fetchError {
+		start := time.Now()
+		var err error
+		nsList, err = clientset.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return newFetchError(3, "Error listing Namespaces: %v", err)
+		}
+		run.logTiming(start, "Listed %d namespaces", len(nsList.Items))
+		return nil
+	})
+
+	var liveMetrics func() (*v1beta1.PodMetricsList, *v1beta1.NodeMetricsList)
+	if opts.ShowUtil {
+		unfiltered := opts
+		unfiltered.Namespace = ""
+		unfiltered.NamespaceLabels = ""
+		unfiltered.NodeLabels = ""
+		liveMetrics = fetchMetrics(run, g, clientset, unfiltered)
+	}
+
+	if ferr := g.wait(); ferr != nil {
+		return nil, ferr
+	}
+	podList, nodeList := podsAndNodes()
+
+	snap := &snapshot{
+		CreatedAt:  time.Now().UTC(),
+		Context:    kubeContext,
+		Nodes:      nodeList,
+		Pods:       podList,
+		Namespaces: nsList,
+	}
+
+	if liveMetrics != nil {
+		snap.PodMetrics, snap.NodeMetrics = liveMetrics()
+	}
+
+	var ferr *fetchError
+	if opts.ShowQuotas {
+		if snap.ResourceQuotas, ferr = getResourceQuotas(run, clientset, "", ""); ferr != nil {
+			return nil, ferr
+		}
+	}
+
+	if opts.ApplyLimitRangeDefaults {
+		if snap.LimitRanges, ferr = getLimitRanges(run, clientset, ""); ferr != nil {
+			return nil, ferr
+		}
+	}
+
+	if opts.ShowVPA {
+		if snap.VerticalPodAutoscalers, ferr = getVerticalPodAutoscalers(run, opts, ""); ferr != nil {
+			return nil, ferr
+		}
+	}
+
+	if opts.ShowHeadroom {
+		if snap.ClusterAutoscalerStatus, ferr = getAutoscalerStatus(clientset); ferr != nil {
+			return nil, ferr
+		}
+	}
+
+	if opts.ShowHeadroom || opts.ShowKarpenter {
+		if snap.NodePools, ferr = getNodePools(run, opts); ferr != nil {
+			return nil, ferr
+		}
+	}
+
+	if opts.ShowPending {
+		if snap.PendingPods, ferr = getPendingPods(run, clientset, listFilters{}); ferr != nil {
+			return nil, ferr
+		}
+	}
+
+	if opts.DrainNode != "" {
+		if snap.PodDisruptionBudgets, ferr = getPodDisruptionBudgets(run, clientset); ferr != nil {
+			return nil, ferr
+		}
+	}
+
+	if opts.ShowHPAHeadroom {
+		if snap.HorizontalPodAutoscalers, ferr = getHorizontalPodAutoscalers(run, clientset); ferr != nil {
+			return nil, ferr
+		}
+	}
+
+	return snap, nil
+}
+
+// fetchMetrics starts fetching utilization data from Prometheus or
+// metrics-server depending on the provided options. The returned function
+// gives the results once the group has been waited on.
+func fetchMetrics(run *reportRun, g *fetchGroup, clientset kubernetes.Interface, opts Options) func() (*v1beta1.PodMetricsList, *v1beta1.NodeMetricsList) {
+	if opts.CacheTTL > 0 {
+		return fetchCachedMetrics(run, g, clientset, opts)
+	}
+
+	var pmList *v1beta1.PodMetricsList
+	var nmList *v1beta1.NodeMetricsList
+	withNodeMetrics := opts.Namespace == "" && opts.NamespaceLabels == ""
+
+	source := opts.MetricsSource
+	if opts.MetricsPlugin != "" {
+		source = pluginMetricsSource
+	}
+	switch source {
+	case PrometheusSource:
+		g.run(func(ctx context.Context) *fetchError {
 			var err error
-			pmList, nmList, err = getPrometheusMetrics(clientset, opts)
+			pmList, nmList, err = getPrometheusMetrics(run, clientset, opts)
 			if err != nil {
-				fmt.Printf("Error getting metrics from Prometheus: %v\n", err)
-				os.Exit(4)
+				return newFetchError(4, "Error getting metrics from Prometheus: %v", err)
 			}
-			if opts.Namespace != "" || opts.NamespaceLabels != "" {
+			if !withNodeMetrics {
 				nmList = nil
 			}
-		} else {
-			mClientset, err := kube.NewMetricsClientSet(opts.KubeContext, opts.KubeConfig, opts.InsecureSkipTLSVerify)
+			return nil
+		})
+	case KubeletSource:
+		g.run(func(ctx context.Context) *fetchError {
+			var err error
+			pmList, nmList, err = getKubeletMetrics(ctx, run, clientset, opts.Namespace, opts.NodeLabels)
 			if err != nil {
-				fmt.Printf("Error connecting to Metrics API: %v\n", err)
-				os.Exit(4)
+				return newFetchError(4, "Error getting metrics from kubelets: %v", err).
+					withHint("For this to work, kube-capacity needs permission to get nodes/proxy")
 			}
-
-			pmList = getPodMetrics(mClientset, opts.Namespace)
-			if opts.Namespace == "" && opts.NamespaceLabels == "" {
-				nmList = getNodeMetrics(mClientset, nodeList, opts.NodeLabels)
+			if !withNodeMetrics {
+				nmList = nil
+			}
+			return nil
+		})
+	case DatadogSource:
+		g.run(func(ctx context.Context) *fetchError {
+			var err error
+			pmList, nmList, err = getDatadogMetrics(ctx, run, opts)
+			if err != nil {
+				return newFetchError(4, "Error getting metrics from Datadog: %v", err)
 			}
+			if !withNodeMetrics {
+				nmList = nil
+			}
+			return nil
+		})
+	case CloudWatchSource:
+		g.run(func(ctx context.Context) *fetchError {
+			var err error
+			pmList, nmList, err = getCloudWatchMetrics(ctx, run, opts)
+			if err != nil {
+				return newFetchError(4, "Error getting metrics from CloudWatch: %v", err).
+					withHint("For this to work, Container Insights must be enabled and kube-capacity needs permission for cloudwatch:GetMetricData")
+			}
+			if !withNodeMetrics {
+				nmList = nil
+			}
+			return nil
+		})
+	case CustomMetricsSource:
+		g.run(func(ctx context.Context) *fetchError {
+			var err error
+			pmList, nmList, err = getCustomMetrics(ctx, run, clientset, opts)
+			if err != nil {
+				return newFetchError(4, "Error getting metrics from the custom metrics API: %v", err).
+					withHint("For this to work, a metrics adapter must serve the custom.metrics.k8s.io API with --custom-metrics-cpu and --custom-metrics-memory")
+			}
+			if !withNodeMetrics {
+				nmList = nil
+			}
+			return nil
+		})
+	case ExternalMetricsSource:
+		g.run(func(ctx context.Context) *fetchError {
+			var err error
+			pmList, nmList, err = getExternalMetrics(ctx, run, clientset, opts)
+			if err != nil {
+				return newFetchError(4, "Error getting metrics from the external metrics API: %v", err).
+					withHint("For this to work, a metrics adapter must serve the external.metrics.k8s.io API with --custom-metrics-cpu and --custom-metrics-memory")
+			}
+			if !withNodeMetrics {
+				nmList = nil
+			}
+			return nil
+		})
+	case pluginMetricsSource:
+		g.run(func(ctx context.Context) *fetchError {
+			var err error
+			pmList, nmList, err = getPluginMetrics(ctx, run, opts)
+			if err != nil {
+				return newFetchError(4, "Error getting metrics from plugin %s: %v", opts.MetricsSource, err)
+			}
+			if !withNodeMetrics {
+				nmList = nil
+			}
+			return nil
+		})
+	case AutoSource:
+		g.run(func(ctx context.Context) *fetchError {
+			var err *fetchError
+			pmList, nmList, err = getAutoMetrics(ctx, run, clientset, opts, withNodeMetrics)
+			return err
+		})
+	default:
+		mClientset, err := newMetricsClientSet(opts)
+		if err != nil {
+			g.run(func(ctx context.Context) *fetchError {
+				return newFetchError(4, "Error connecting to Metrics API: %v", err)
+			})
+			break
+		}
+
+		g.run(func(ctx context.Context) *fetchError {
+			var err *fetchError
+			pmList, err = getPodMetrics(ctx, run, mClientset, opts.Namespace)
+			return err
+		})
+		if withNodeMetrics {
+			g.run(func(ctx context.Context) *fetchError {
+				var err *fetchError
+				nmList, err = getNodeMetrics(ctx, run, mClientset, opts.NodeLabels)
+				return err
+			})
 		}
 	}
 
-	cm := buildClusterMetric(podList, pmList, nodeList, nmList)
-	printList(&cm, opts)
+	return func() (*v1beta1.PodMetricsList, *v1beta1.NodeMetricsList) {
+		return pmList, nmList
+	}
 }
 
-func getPodsAndNodes(clientset kubernetes.Interface, excludeTainted bool, podLabels, nodeLabels, nodeTaints, namespaceLabels, namespace string) (*corev1.PodList, *corev1.NodeList) {
-	nodeList, err := clientset.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{
-		LabelSelector: nodeLabels,
+// listFilters are the flags that select the nodes and pods of a report
+type listFilters struct {
+	excludeTainted   bool
+	podLabels        string
+	podFieldSelector string
+	nodeLabels       string
+	nodeName         string
+	nodeTaints       string
+	namespaceLabels  string
+	namespace        string
+
+	// podsByNode lists the pods of each node separately with a
+	// spec.nodeName field selector
+	podsByNode bool
+}
+
+func newListFilters(opts Options) listFilters {
+	return listFilters{
+		excludeTainted:   opts.ExcludeTainted,
+		podLabels:        opts.PodLabels,
+		podFieldSelector: opts.PodFieldSelector,
+		nodeLabels:       opts.NodeLabels,
+		nodeName:         opts.NodeName,
+		nodeTaints:       opts.NodeTaints,
+		namespaceLabels:  opts.NamespaceLabels,
+		namespace:        opts.Namespace,
+		podsByNode:       opts.ListPodsByNode,
+	}
+}
+
+func getPodsAndNodes(run *reportRun, clientset kubernetes.Interface, filters listFilters) (*corev1.PodList, *corev1.NodeList, *fetchError) {
+	g := newFetchGroup()
+	podsAndNodes := fetchPodsAndNodes(run, g, clientset, filters)
+	if ferr := g.wait(); ferr != nil {
+		return nil, nil, ferr
+	}
+	podList, nodeList := podsAndNodes()
+	return podList, nodeList, nil
+}
+
+// fetchPodsAndNodes starts listing nodes, pods, and the namespaces matching
+// the namespace labels. With --pods-by-node the pods of each node are listed
+// once the nodes have been filtered. The returned function filters the pods
+// once the group has been waited on.
+func fetchPodsAndNodes(run *reportRun, g *fetchGroup, clientset kubernetes.Interface, filters listFilters) func() (*corev1.PodList, *corev1.NodeList) {
+	var taintsToAdd, taintsToRemove []corev1.Taint
+	if filters.nodeTaints != "" {
+		var err error
+		taintsToAdd, taintsToRemove, err = k8taints.ParseTaints(strings.Split(filters.nodeTaints, ","))
+		if err != nil {
+			g.run(func(ctx context.Context) *fetchError {
+				return newFetchError(3, "Error parsing taint parameter: %v", err)
+			})
+		}
+	}
+
+	var nodeList *corev1.NodeList
+	var podList *corev1.PodList
+	g.run(func(ctx context.Context) *fetchError {
+		start := time.Now()
+		var err error
+		nodeList, err = listAllNodes(ctx, run, clientset, metav1.ListOptions{
+			LabelSelector: filters.nodeLabels,
+		})
+		if err != nil {
+			return newFetchError(2, "Error listing Nodes: %v", err)
+		}
+		run.logTiming(start, "Listed %d nodes", len(nodeList.Items))
+
+		filterNodes(nodeList, filters.excludeTainted, filters.nodeTaints != "", taintsToAdd, taintsToRemove)
+		if err := filterNodesByName(nodeList, filters.nodeName); err != nil {
+			return newFetchError(2, "Error parsing node name pattern: %v", err)
+		}
+
+		// Pods listed by node have to wait for the nodes
+		if filters.podsByNode {
+			start := time.Now()
+			podList, err = listPodsOnNodes(ctx, run, clientset, filters.namespace, nodeList, metav1.ListOptions{
+				LabelSelector: filters.podLabels,
+				FieldSelector: filters.podFieldSelector,
+			})
+			if err != nil {
+				return newFetchError(3, "Error listing Pods: %v", err)
+			}
+			run.logTiming(start, "Listed %d pods", len(podList.Items))
+			if err := filterPodsByFields(podList, filters.podFieldSelector); err != nil {
+				return newFetchError(3, "Error parsing pod field selector: %v", err)
+			}
+		}
+		return nil
 	})
-	if err != nil {
-		fmt.Printf("Error listing Nodes: %v\n", err)
-		os.Exit(2)
+
+	if !filters.podsByNode {
+		g.run(func(ctx context.Context) *fetchError {
+			start := time.Now()
+			var err error
+			podList, err = listAllPods(ctx, run, clientset, filters.namespace, metav1.ListOptions{
+				LabelSelector: filters.podLabels,
+				FieldSelector: filters.podFieldSelector,
+			})
+			if err != nil {
+				return newFetchError(3, "Error listing Pods: %v", err)
+			}
+			run.logTiming(start, "Listed %d pods", len(podList.Items))
+			if err := filterPodsByFields(podList, filters.podFieldSelector); err != nil {
+				return newFetchError(3, "Error parsing pod field selector: %v", err)
+			}
+			return nil
+		})
+	}
+
+	var namespaces map[string]bool
+	if filters.namespace == "" && filters.namespaceLabels != "" {
+		g.run(func(ctx context.Context) *fetchError {
+			var err *fetchError
+			namespaces, err = listNamespaceNames(ctx, run, clientset, filters.namespaceLabels)
+			return err
+		})
 	}
+
+	return func() (*corev1.PodList, *corev1.NodeList) {
+		newPodItems := []corev1.Pod{}
+
+		nodes := map[string]bool{}
+		for _, node := range nodeList.Items {
+			nodes[node.GetName()] = true
+		}
+
+		for _, pod := range podList.Items {
+			if !nodes[pod.Spec.NodeName] {
+				continue
+			}
+
+			newPodItems = append(newPodItems, pod)
+		}
+
+		podList.Items = newPodItems
+
+		if namespaces != nil {
+			newPodItems := []corev1.Pod{}
+
+			for _, pod := range podList.Items {
+				if !namespaces[pod.GetNamespace()] {
+					continue
+				}
+
+				newPodItems = append(newPodItems, pod)
+			}
+
+			podList.Items = newPodItems
+		}
+
+		return podList, nodeList
+	}
+}
+
+// filterNodes removes nodes with taints when excludeTainted is set, and
+// applies the --node-taints filter when filterTaints is set
+func filterNodes(nodeList *corev1.NodeList, excludeTainted, filterTaints bool, taintsToAdd, taintsToRemove []corev1.Taint) {
 	if excludeTainted {
 		filteredNodeList := []corev1.Node{}
 		for _, node := range nodeList.Items {
@@ -88,14 +894,7 @@ func getPodsAndNodes(clientset kubernetes.Interface, excludeTainted bool, podLab
 		nodeList.Items = filteredNodeList
 	}
 
-	if nodeTaints != "" {
-		taints := strings.Split(nodeTaints, ",")
-		taintsToAdd, taintsToRemove, error := k8taints.ParseTaints(taints)
-		if error != nil {
-			fmt.Printf("Error parsing taint parameter: %v\n", error)
-			os.Exit(3)
-		}
-
+	if filterTaints {
 		var tempAddNodeList corev1.NodeList
 		var tempRemoveNodeList corev1.NodeList
 		for _, node := range nodeList.Items {
@@ -147,83 +946,47 @@ func getPodsAndNodes(clientset kubernetes.Interface, excludeTainted bool, podLab
 			*nodeList = tempFinalNodeList
 		}
 	}
+}
 
-	podList, err := clientset.CoreV1().Pods(namespace).List(context.TODO(), metav1.ListOptions{
-		LabelSelector: podLabels,
+func listNamespaceNames(ctx context.Context, run *reportRun, clientset kubernetes.Interface, namespaceLabels string) (map[string]bool, *fetchError) {
+	start := time.Now()
+	namespaceList, err := clientset.CoreV1().Namespaces().List(ctx, metav1.ListOptions{
+		LabelSelector: namespaceLabels,
 	})
 	if err != nil {
-		fmt.Printf("Error listing Pods: %v\n", err)
-		os.Exit(3)
-	}
-
-	newPodItems := []corev1.Pod{}
-
-	nodes := map[string]bool{}
-	for _, node := range nodeList.Items {
-		nodes[node.GetName()] = true
-	}
-
-	for _, pod := range podList.Items {
-		if !nodes[pod.Spec.NodeName] {
-			continue
-		}
-
-		newPodItems = append(newPodItems, pod)
+		return nil, newFetchError(3, "Error listing Namespaces: %v", err)
 	}
+	run.logTiming(start, "Listed %d namespaces", len(namespaceList.Items))
 
-	podList.Items = newPodItems
-
-	if namespace == "" && namespaceLabels != "" {
-		namespaceList, err := clientset.CoreV1().Namespaces().List(context.TODO(), metav1.ListOptions{
-			LabelSelector: namespaceLabels,
-		})
-		if err != nil {
-			fmt.Printf("Error listing Namespaces: %v\n", err)
-			os.Exit(3)
-		}
-
-		namespaces := map[string]bool{}
-		for _, ns := range namespaceList.Items {
-			namespaces[ns.GetName()] = true
-		}
-
-		newPodItems := []corev1.Pod{}
-
-		for _, pod := range podList.Items {
-			if !namespaces[pod.GetNamespace()] {
-				continue
-			}
-
-			newPodItems = append(newPodItems, pod)
-		}
-
-		podList.Items = newPodItems
+	namespaces := map[string]bool{}
+	for _, ns := range namespaceList.Items {
+		namespaces[ns.GetName()] = true
 	}
-
-	return podList, nodeList
+	return namespaces, nil
 }
 
-func getPodMetrics(mClientset *metrics.Clientset, namespace string) *v1beta1.PodMetricsList {
-	pmList, err := mClientset.MetricsV1beta1().PodMetricses(namespace).List(context.TODO(), metav1.ListOptions{})
+func getPodMetrics(ctx context.Context, run *reportRun, mClientset metrics.Interface, namespace string) (*v1beta1.PodMetricsList, *fetchError) {
+	start := time.Now()
+	pmList, err := mClientset.MetricsV1beta1().PodMetricses(namespace).List(ctx, metav1.ListOptions{})
 	if err != nil {
-		fmt.Printf("Error getting Pod Metrics: %v\n", err)
-		fmt.Println("For this to work, metrics-server needs to be running in your cluster")
-		os.Exit(6)
+		return nil, newFetchError(6, "Error getting Pod Metrics: %v", err).
+			withHint("For this to work, metrics-server needs to be running in your cluster")
 	}
+	run.logTiming(start, "Listed %d pod metrics from metrics-server", len(pmList.Items))
 
-	return pmList
+	return pmList, nil
 }
 
-func getNodeMetrics(mClientset *metrics.Clientset, nodeList *corev1.NodeList, nodeLabels string) *v1beta1.NodeMetricsList {
-	nmList, err := mClientset.MetricsV1beta1().NodeMetricses().List(context.TODO(), metav1.ListOptions{
+func getNodeMetrics(ctx context.Context, run *reportRun, mClientset metrics.Interface, nodeLabels string) (*v1beta1.NodeMetricsList, *fetchError) {
+	start := time.Now()
+	nmList, err := mClientset.MetricsV1beta1().NodeMetricses().List(ctx, metav1.ListOptions{
 		LabelSelector: nodeLabels,
 	})
-
 	if err != nil {
-		fmt.Printf("Error getting Node Metrics: %v\n", err)
-		fmt.Println("For this to work, metrics-server needs to be running in your cluster")
-		os.Exit(7)
+		return nil, newFetchError(7, "Error getting Node Metrics: %v", err).
+			withHint("For this to work, metrics-server needs to be running in your cluster")
 	}
+	run.logTiming(start, "Listed %d node metrics from metrics-server", len(nmList.Items))
 
-	return nmList
+	return nmList, nil
 }
@@ -24,6 +24,7 @@ import (
 )
 
 func TestGetPodsAndNodes(t *testing.T) {
+	run := newTestRun(nil)
 	clientset := fake.NewSimpleClientset(
 		node("mynode", map[string]string{"hello": "world"}, false),
 		node("mynode2", map[string]string{"hello": "world", "moon": "lol"}, true),
@@ -44,7 +45,7 @@ func TestGetPodsAndNodes(t *testing.T) {
 		pod("mynode4", "default", "mypod8", map[string]string{"g": "test"}),
 	)
 
-	podList, nodeList := getPodsAndNodes(clientset, false, "", "", "", "", "")
+	podList, nodeList, _ := getPodsAndNodes(run, clientset, listFilters{})
 	assert.Equal(t, []string{"mynode", "mynode2", "mynode3", "mynode4"}, listNodes(nodeList))
 	assert.Equal(t, []string{
 		"another/mypod5",
@@ -58,7 +59,7 @@ func TestGetPodsAndNodes(t *testing.T) {
 		"other/mypod3",
 	}, listPods(podList))
 
-	podList, nodeList = getPodsAndNodes(clientset, true, "", "hello=world", "", "", "")
+	podList, nodeList, _ = getPodsAndNodes(run, clientset, listFilters{excludeTainted: true, nodeLabels: "hello=world"})
 	assert.Equal(t, []string{"mynode"}, listNodes(nodeList))
 	assert.Equal(t, []string{
 		"another/mypod5",
@@ -67,7 +68,7 @@ func TestGetPodsAndNodes(t *testing.T) {
 		"other/mypod2",
 	}, listPods(podList))
 
-	podList, nodeList = getPodsAndNodes(clientset, false, "", "hello=world", "", "", "")
+	podList, nodeList, _ = getPodsAndNodes(run, clientset, listFilters{nodeLabels: "hello=world"})
 	assert.Equal(t, []string{"mynode", "mynode2", "mynode3"}, listNodes(nodeList))
 	assert.Equal(t, []string{
 		"another/mypod5",
@@ -80,7 +81,7 @@ func TestGetPodsAndNodes(t *testing.T) {
 		"other/mypod3",
 	}, listPods(podList))
 
-	podList, nodeList = getPodsAndNodes(clientset, false, "", "moon=lol", "", "", "")
+	podList, nodeList, _ = getPodsAndNodes(run, clientset, listFilters{nodeLabels: "moon=lol"})
 
 	assert.Equal(t, []string{"mynode2"}, listNodes(nodeList))
 	assert.Equal(t, []string{
@@ -89,26 +90,26 @@ func TestGetPodsAndNodes(t *testing.T) {
 		"other/mypod3",
 	}, listPods(podList))
 
-	podList, nodeList = getPodsAndNodes(clientset, false, "a=test", "", "", "", "")
+	podList, nodeList, _ = getPodsAndNodes(run, clientset, listFilters{podLabels: "a=test"})
 	assert.Equal(t, []string{"mynode", "mynode2", "mynode3", "mynode4"}, listNodes(nodeList))
 
 	assert.Equal(t, []string{
 		"default/mypod",
 	}, listPods(podList))
 
-	podList, nodeList = getPodsAndNodes(clientset, false, "a=test,b!=test", "", "", "app=true", "")
+	podList, nodeList, _ = getPodsAndNodes(run, clientset, listFilters{podLabels: "a=test,b!=test", namespaceLabels: "app=true"})
 	assert.Equal(t, []string{"mynode", "mynode2", "mynode3", "mynode4"}, listNodes(nodeList))
 	assert.Equal(t, []string{
 		"default/mypod",
 	}, listPods(podList))
 
-	podList, nodeList = getPodsAndNodes(clientset, false, "a=test,b!=test", "", "", "", "default")
+	podList, nodeList, _ = getPodsAndNodes(run, clientset, listFilters{podLabels: "a=test,b!=test", namespace: "default"})
 	assert.Equal(t, []string{"mynode", "mynode2", "mynode3", "mynode4"}, listNodes(nodeList))
 
 	assert.Equal(t, []string{
 		"default/mypod",
 	}, listPods(podList))
-	podList, nodeList = getPodsAndNodes(clientset, false, "", "", "taintkey=taintvalue:NoSchedule-", "", "")
+	podList, nodeList, _ = getPodsAndNodes(run, clientset, listFilters{nodeTaints: "taintkey=taintvalue:NoSchedule-"})
 	assert.Equal(t, []string{"mynode", "mynode2"}, listNodes(nodeList))
 	assert.Equal(t, []string{
 		"another/mypod5",
@@ -119,7 +120,7 @@ func TestGetPodsAndNodes(t *testing.T) {
 		"other/mypod2",
 		"other/mypod3",
 	}, listPods(podList))
-	podList, nodeList = getPodsAndNodes(clientset, false, "", "", "taintkey:NoSchedule-", "", "")
+	podList, nodeList, _ = getPodsAndNodes(run, clientset, listFilters{nodeTaints: "taintkey:NoSchedule-"})
 	assert.Equal(t, []string{"mynode", "mynode2"}, listNodes(nodeList))
 	assert.Equal(t, []string{
 		"another/mypod5",
@@ -130,7 +131,7 @@ func TestGetPodsAndNodes(t *testing.T) {
 		"other/mypod2",
 		"other/mypod3",
 	}, listPods(podList))
-	podList, nodeList = getPodsAndNodes(clientset, false, "", "", "taintkey=taintvalue:NoSchedule", "", "")
+	podList, nodeList, _ = getPodsAndNodes(run, clientset, listFilters{nodeTaints: "taintkey=taintvalue:NoSchedule"})
 	assert.Equal(t, []string{"mynode3", "mynode4"}, listNodes(nodeList))
 	assert.Equal(t, []string{
 		"default/mypod7",
@@ -163,6 +164,13 @@ func node(name string, labels map[string]string, tainted bool) *corev1.Node {
 	return n
 }
 
+// nodeWithAllocatable returns a node with the given allocatable resources
+func nodeWithAllocatable(name string, labels map[string]string, allocatable corev1.ResourceList) *corev1.Node {
+	n := node(name, labels, false)
+	n.Status.Allocatable = allocatable
+	return n
+}
+
 func nodeWithTaint(name string, labels map[string]string, key, value string) *corev1.Node {
 	return &corev1.Node{
 		TypeMeta: metav1.TypeMeta{
@@ -214,3 +222,14 @@ func pod(node, namespace, name string, labels map[string]string) *corev1.Pod {
 		},
 	}
 }
+
+// podWithRequests returns a pod with a single container that requests the
+// given resources
+func podWithRequests(node, namespace, name string, labels map[string]string, requests corev1.ResourceList) *corev1.Pod {
+	p := pod(node, namespace, name, labels)
+	p.Spec.Containers = []corev1.Container{{
+		Name:      "app",
+		Resources: corev1.ResourceRequirements{Requests: requests},
+	}}
+	return p
+}
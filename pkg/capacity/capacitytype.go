@@ -0,0 +1,225 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+const (
+	capacityTypeSpot     = "spot"
+	capacityTypeOnDemand = "on-demand"
+)
+
+// spotNodeLabels are the well-known labels that mark a node as spot or
+// preemptible, with the values that do so
+var spotNodeLabels = []struct {
+	label string
+	value string
+}{
+	{"karpenter.sh/capacity-type", "spot"},
+	{"eks.amazonaws.com/capacityType", "SPOT"},
+	{"cloud.google.com/gke-spot", "true"},
+	{"cloud.google.com/gke-preemptible", "true"},
+	{"kubernetes.azure.com/scalesetpriority", "spot"},
+	{"node.kubernetes.io/lifecycle", "spot"},
+}
+
+// nodeSetMetric sums a set of nodes, such as the nodes of one capacity type
+// or architecture
+type nodeSetMetric struct {
+	name   string
+	nodes  int64
+	cpu    *resourceMetric
+	memory *resourceMetric
+}
+
+type listCapacityTypes struct {
+	ClusterTotals *listCapacityType   `json:"clusterTotals"`
+	CapacityTypes []*listCapacityType `json:"capacityTypes"`
+}
+
+type listCapacityType struct {
+	Name   string                    `json:"name,omitempty"`
+	Nodes  int64                     `json:"nodes"`
+	CPU    *listCapacityTypeResource `json:"cpu"`
+	Memory *listCapacityTypeResource `json:"memory"`
+}
+
+type listCapacityTypeResource struct {
+	Allocatable      string `json:"allocatable"`
+	AllocatableShare string `json:"allocatableShare"`
+	Requests         string `json:"requests"`
+	RequestsPct      string `json:"requestsPercent"`
+	RequestsShare    string `json:"requestsShare"`
+	Utilization      string `json:"utilization,omitempty"`
+	UtilizationShare string `json:"utilizationShare,omitempty"`
+}
+
+// capacityType returns spot for nodes that can be interrupted by their
+// cloud provider, and on-demand for every other node. Providers like GKE
+// only label spot nodes, so nodes without a spot label are on-demand.
+func (nm *nodeMetric) capacityType() string {
+	for _, l := range spotNodeLabels {
+		if nm.labels[l.label] == l.value {
+			return capacityTypeSpot
+		}
+	}
+	return capacityTypeOnDemand
+}
+
+func newNodeSetMetric(name string) *nodeSetMetric {
+	return &nodeSetMetric{
+		name:   name,
+		cpu:    &resourceMetric{resourceType: "cpu"},
+		memory: &resourceMetric{resourceType: "memory"},
+	}
+}
+
+// splitNodes groups the nodes of the cluster by the name key returns for
+// each, returning the cluster totals and each group by name
+func splitNodes(cm *clusterMetric, key func(nm *nodeMetric) string) (*nodeSetMetric, map[string]*nodeSetMetric) {
+	totals := newNodeSetMetric("")
+	sets := map[string]*nodeSetMetric{}
+	for _, nm := range cm.nodeMetrics {
+		name := key(nm)
+		if _, ok := sets[name]; !ok {
+			sets[name] = newNodeSetMetric(name)
+		}
+		for _, ns := range []*nodeSetMetric{totals, sets[name]} {
+			ns.nodes++
+			ns.cpu.addMetric(nm.cpu)
+			ns.memory.addMetric(nm.memory)
+		}
+	}
+	return totals, sets
+}
+
+// buildCapacityTypeMetrics splits the nodes of the cluster between on-demand
+// and spot, returning the cluster totals followed by each capacity type with
+// at least one node
+func buildCapacityTypeMetrics(cm *clusterMetric) (*nodeSetMetric, []*nodeSetMetric) {
+	totals, types := splitNodes(cm, (*nodeMetric).capacityType)
+
+	capacityTypes := []*nodeSetMetric{}
+	for _, name := range []string{capacityTypeOnDemand, capacityTypeSpot} {
+		if ct, ok := types[name]; ok {
+			capacityTypes = append(capacityTypes, ct)
+		}
+	}
+	return totals, capacityTypes
+}
+
+func printCapacityTypes(run *reportRun, cm *clusterMetric, opts Options) error {
+	ctp := &capacityTypePrinter{run: run, opts: opts}
+	ctp.totals, ctp.capacityTypes = buildCapacityTypeMetrics(cm)
+	return ctp.Print(opts.OutputFormat)
+}
+
+type capacityTypePrinter struct {
+	run           *reportRun
+	totals        *nodeSetMetric
+	capacityTypes []*nodeSetMetric
+	opts          Options
+}
+
+func (ctp *capacityTypePrinter) Print(outputType string) error {
+	switch outputType {
+	case JSONOutput, YAMLOutput:
+		printListOutput(ctp.run, CapacityTypeReportKind, ctp.buildListCapacityTypes(), outputType)
+	case TableOutput:
+		w := tabwriter.NewWriter(ctp.run.output, 0, 8, 2, ' ', 0)
+		ctp.printTable(w, "\t ")
+		if err := w.Flush(); err != nil {
+			ctp.run.logErrorf("Error writing to table: %s", err)
+		}
+	case CSVOutput:
+		ctp.printTable(ctp.run.output, ",")
+	case TSVOutput:
+		ctp.printTable(ctp.run.output, "\t")
+	default:
+		return fmt.Errorf("Called with an unsupported output type: %s", outputType)
+	}
+	return nil
+}
+
+// printTable prints each capacity type with its share of the allocatable,
+// requests, and utilization of the cluster
+func (ctp *capacityTypePrinter) printTable(w io.Writer, separator string) {
+	headers := []string{"CAPACITY TYPE", "NODES"}
+	for _, prefix := range []string{"CPU", "MEMORY"} {
+		headers = append(headers, prefix+" ALLOCATABLE", prefix+" REQUESTS")
+		if ctp.opts.ShowUtil {
+			headers = append(headers, prefix+" UTIL")
+		}
+	}
+	_, _ = fmt.Fprintln(w, strings.Join(headers, separator))
+
+	list := ctp.buildListCapacityTypes()
+	list.ClusterTotals.Name = VoidValue
+	for _, lc := range append([]*listCapacityType{list.ClusterTotals}, list.CapacityTypes...) {
+		items := []string{lc.Name, fmt.Sprintf("%d", lc.Nodes)}
+		for _, r := range []*listCapacityTypeResource{lc.CPU, lc.Memory} {
+			items = append(items,
+				fmt.Sprintf("%s (%s)", r.Allocatable, r.AllocatableShare),
+				fmt.Sprintf("%s (%s)", r.Requests, r.RequestsShare))
+			if ctp.opts.ShowUtil {
+				items = append(items, fmt.Sprintf("%s (%s)", r.Utilization, r.UtilizationShare))
+			}
+		}
+		_, _ = fmt.Fprintln(w, strings.Join(items, separator))
+	}
+}
+
+func (ctp *capacityTypePrinter) buildListCapacityTypes() *listCapacityTypes {
+	out := &listCapacityTypes{
+		ClusterTotals: ctp.listCapacityType(ctp.totals),
+		CapacityTypes: []*listCapacityType{},
+	}
+	for _, ct := range ctp.capacityTypes {
+		out.CapacityTypes = append(out.CapacityTypes, ctp.listCapacityType(ct))
+	}
+	return out
+}
+
+func (ctp *capacityTypePrinter) listCapacityType(ct *nodeSetMetric) *listCapacityType {
+	return &listCapacityType{
+		Name:   ct.name,
+		Nodes:  ct.nodes,
+		CPU:    ctp.listCapacityTypeResource(ct.cpu, ctp.totals.cpu),
+		Memory: ctp.listCapacityTypeResource(ct.memory, ctp.totals.memory),
+	}
+}
+
+// listCapacityTypeResource gives the share of the cluster each value is,
+// and requests as a percentage of the allocatable of the capacity type
+func (ctp *capacityTypePrinter) listCapacityTypeResource(rm, total *resourceMetric) *listCapacityTypeResource {
+	valueCalculator := rm.valueFunction(ctp.run)
+	out := &listCapacityTypeResource{
+		Allocatable:      valueCalculator(rm.allocatable),
+		AllocatableShare: percentString(ctp.run, rm.allocatable, total.allocatable),
+		Requests:         valueCalculator(rm.request),
+		RequestsPct:      percentString(ctp.run, rm.request, rm.allocatable),
+		RequestsShare:    percentString(ctp.run, rm.request, total.request),
+	}
+	if ctp.opts.ShowUtil {
+		out.Utilization = valueCalculator(rm.utilization)
+		out.UtilizationShare = percentString(ctp.run, rm.utilization, total.utilization)
+	}
+	return out
+}
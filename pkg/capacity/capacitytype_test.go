@@ -0,0 +1,76 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCapacityType(t *testing.T) {
+	for labels, expected := range map[string]string{
+		"karpenter.sh/capacity-type=spot":            capacityTypeSpot,
+		"karpenter.sh/capacity-type=on-demand":       capacityTypeOnDemand,
+		"eks.amazonaws.com/capacityType=SPOT":        capacityTypeSpot,
+		"eks.amazonaws.com/capacityType=ON_DEMAND":   capacityTypeOnDemand,
+		"cloud.google.com/gke-spot=true":             capacityTypeSpot,
+		"kubernetes.azure.com/scalesetpriority=spot": capacityTypeSpot,
+		"": capacityTypeOnDemand,
+	} {
+		nm := &nodeMetric{labels: map[string]string{}}
+		if key, value, ok := strings.Cut(labels, "="); ok {
+			nm.labels[key] = value
+		}
+		assert.Equal(t, expected, nm.capacityType(), labels)
+	}
+}
+
+func TestCapacityTypeReport(t *testing.T) {
+	run := newTestRun(nil)
+	snap := getTestSnapshot()
+	snap.Nodes.Items[1].Labels["cloud.google.com/gke-spot"] = "true"
+	cm := buildClusterMetric(snap.Pods, snap.PodMetrics, snap.Nodes, snap.NodeMetrics)
+
+	ctp := &capacityTypePrinter{run: run, opts: Options{ShowUtil: true}}
+	ctp.totals, ctp.capacityTypes = buildCapacityTypeMetrics(&cm)
+	require.Len(t, ctp.capacityTypes, 2)
+
+	list := ctp.buildListCapacityTypes()
+	spot := list.CapacityTypes[1]
+	assert.Equal(t, capacityTypeSpot, spot.Name)
+	assert.Equal(t, int64(1), spot.Nodes)
+	assert.Equal(t, &listCapacityTypeResource{
+		Allocatable:      "1000m",
+		AllocatableShare: "50%",
+		Requests:         "100m",
+		RequestsPct:      "10%",
+		RequestsShare:    "50%",
+		Utilization:      "180m",
+		UtilizationShare: "60%",
+	}, spot.CPU)
+
+	var buf bytes.Buffer
+	ctp.printTable(&buf, ",")
+	assert.Equal(t, strings.Join([]string{
+		"CAPACITY TYPE,NODES,CPU ALLOCATABLE,CPU REQUESTS,CPU UTIL,MEMORY ALLOCATABLE,MEMORY REQUESTS,MEMORY UTIL",
+		"*,2,2000m (100%),200m (100%),300m (100%),8000Mi (100%),256Mi (100%),1280Mi (100%)",
+		"on-demand,1,1000m (50%),100m (50%),120m (40%),4000Mi (50%),128Mi (50%),512Mi (40%)",
+		"spot,1,1000m (50%),100m (50%),180m (60%),4000Mi (50%),128Mi (50%),768Mi (60%)",
+	}, "\n")+"\n", buf.String())
+}
@@ -0,0 +1,238 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"math"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// Layout of the charts, in SVG user units
+const (
+	chartWidth       = 800
+	chartLabelWidth  = 200
+	chartTrackWidth  = 360
+	chartRowHeight   = 22
+	chartBarHeight   = 14
+	chartTitleHeight = 36
+	chartLegendGap   = 28
+)
+
+// Colors of the chart bars
+const (
+	chartRequestColor = "#4a7bd0"
+	chartFreeColor    = "#dde3ea"
+	chartUtilOK       = "#3c9a5f"
+	chartUtilWarn     = "#e0a030"
+	chartUtilHigh     = "#d04a4a"
+)
+
+// chartSubject is a node, or a cluster of a fleet, drawn as a row of each
+// chart
+type chartSubject struct {
+	label  string
+	cpu    *resourceMetric
+	memory *resourceMetric
+}
+
+// barChart is a titled list of horizontal bars
+type barChart struct {
+	title  string
+	legend [][2]string
+	bars   []chartBar
+}
+
+// chartBar fills fraction of a track the width of allocatable, so that the
+// rest of the track shows what is left
+type chartBar struct {
+	label    string
+	fraction float64
+	color    string
+	text     string
+}
+
+// nodeChartSubjects returns the nodes of the cluster in the order of --sort
+func nodeChartSubjects(cm *clusterMetric, sortBy string) []chartSubject {
+	subjects := []chartSubject{}
+	for _, nm := range cm.getSortedNodeMetrics(sortBy) {
+		subjects = append(subjects, chartSubject{label: nm.name, cpu: nm.cpu, memory: nm.memory})
+	}
+	return subjects
+}
+
+// fleetChartSubjects returns the totals of each cluster of the fleet
+func fleetChartSubjects(fm *fleetMetric) []chartSubject {
+	subjects := []chartSubject{}
+	for _, cm := range fm.clusters {
+		subjects = append(subjects, chartSubject{label: cm.name, cpu: cm.cpu, memory: cm.memory})
+	}
+	return subjects
+}
+
+// buildCharts returns stacked bars of requests against allocatable for CPU
+// and memory, followed by bars of utilization when it was fetched
+func buildCharts(run *reportRun, subjects []chartSubject, withUtil bool) []barChart {
+	charts := []barChart{}
+	for _, resourceName := range []string{"cpu", "memory"} {
+		chart := barChart{
+			title:  fmt.Sprintf("%s requests vs allocatable", chartResourceTitle(resourceName)),
+			legend: [][2]string{{chartRequestColor, "requested"}, {chartFreeColor, "unrequested allocatable"}},
+		}
+		for _, s := range subjects {
+			rm := s.resourceMetric(resourceName)
+			chart.bars = append(chart.bars, chartBar{
+				label:    s.label,
+				fraction: chartFraction(rm.request, rm.allocatable),
+				color:    chartRequestColor,
+				text:     fmt.Sprintf("%s / %s (%s)", run.formatQuantity(resourceName, rm.request), run.formatQuantity(resourceName, rm.allocatable), percentString(run, rm.request, rm.allocatable)),
+			})
+		}
+		charts = append(charts, chart)
+	}
+	if !withUtil {
+		return charts
+	}
+
+	for _, resourceName := range []string{"cpu", "memory"} {
+		chart := barChart{
+			title:  fmt.Sprintf("%s utilization", chartResourceTitle(resourceName)),
+			legend: [][2]string{{chartUtilOK, "under 70%"}, {chartUtilWarn, "under 90%"}, {chartUtilHigh, "90% or more"}},
+		}
+		for _, s := range subjects {
+			rm := s.resourceMetric(resourceName)
+			fraction := chartFraction(rm.utilization, rm.allocatable)
+			chart.bars = append(chart.bars, chartBar{
+				label:    s.label,
+				fraction: fraction,
+				color:    chartUtilColor(fraction),
+				text:     fmt.Sprintf("%s (%s)", run.formatQuantity(resourceName, rm.utilization), percentString(run, rm.utilization, rm.allocatable)),
+			})
+		}
+		charts = append(charts, chart)
+	}
+	return charts
+}
+
+func (s chartSubject) resourceMetric(resourceName string) *resourceMetric {
+	if resourceName == "cpu" {
+		return s.cpu
+	}
+	return s.memory
+}
+
+func chartResourceTitle(resourceName string) string {
+	if resourceName == "cpu" {
+		return "CPU"
+	}
+	return "Memory"
+}
+
+func chartFraction(actual, allocatable resource.Quantity) float64 {
+	if allocatable.MilliValue() == 0 {
+		return 0
+	}
+	return float64(actual.MilliValue()) / float64(allocatable.MilliValue())
+}
+
+func chartUtilColor(fraction float64) string {
+	switch {
+	case fraction >= 0.9:
+		return chartUtilHigh
+	case fraction >= 0.7:
+		return chartUtilWarn
+	}
+	return chartUtilOK
+}
+
+// chartsHeight is the height of the charts stacked on top of each other
+func chartsHeight(charts []barChart) int {
+	height := 0
+	for _, chart := range charts {
+		height += chart.height()
+	}
+	return height
+}
+
+func (c barChart) height() int {
+	return chartTitleHeight + len(c.bars)*chartRowHeight + chartLegendGap
+}
+
+// printCharts prints the charts of -o svg
+func printCharts(run *reportRun, subjects []chartSubject, opts Options) {
+	writeSVG(run.output, buildCharts(run, subjects, opts.ShowUtil))
+}
+
+// writeSVG draws the charts stacked on top of each other as one SVG
+// document, which can be opened on its own or inlined in HTML
+func writeSVG(w io.Writer, charts []barChart) {
+	height := chartsHeight(charts)
+	_, _ = fmt.Fprintf(w, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="12">`+"\n",
+		chartWidth, height, chartWidth, height)
+	_, _ = fmt.Fprintf(w, `<rect width="%d" height="%d" fill="#ffffff"/>`+"\n", chartWidth, height)
+
+	y := 0
+	for _, chart := range charts {
+		_, _ = fmt.Fprintf(w, `<text x="8" y="%d" font-size="15" font-weight="bold">%s</text>`+"\n", y+24, html.EscapeString(chart.title))
+		y += chartTitleHeight
+		for _, bar := range chart.bars {
+			writeSVGBar(w, bar, y)
+			y += chartRowHeight
+		}
+		x := chartLabelWidth
+		for _, item := range chart.legend {
+			_, _ = fmt.Fprintf(w, `<rect x="%d" y="%d" width="10" height="10" fill="%s"/>`+"\n", x, y+4, item[0])
+			_, _ = fmt.Fprintf(w, `<text x="%d" y="%d" font-size="11" fill="#555555">%s</text>`+"\n", x+14, y+13, html.EscapeString(item[1]))
+			x += 20 + 7*len(item[1])
+		}
+		y += chartLegendGap
+	}
+	_, _ = fmt.Fprintln(w, "</svg>")
+}
+
+func writeSVGBar(w io.Writer, bar chartBar, y int) {
+	barY := y + (chartRowHeight-chartBarHeight)/2
+	_, _ = fmt.Fprintf(w, `<text x="%d" y="%d" text-anchor="end">%s</text>`+"\n",
+		chartLabelWidth-8, barY+chartBarHeight-3, html.EscapeString(truncateChartLabel(bar.label)))
+	_, _ = fmt.Fprintf(w, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s"/>`+"\n",
+		chartLabelWidth, barY, chartTrackWidth, chartBarHeight, chartFreeColor)
+
+	width := int(math.Round(math.Min(bar.fraction, 1) * chartTrackWidth))
+	if width > 0 {
+		_, _ = fmt.Fprintf(w, `<rect x="%d" y="%d" width="%d" height="%d" fill="%s"/>`+"\n",
+			chartLabelWidth, barY, width, chartBarHeight, bar.color)
+	}
+	if bar.fraction > 1 {
+		// Bars past allocatable are cut off at the end of the track and
+		// marked, the text gives the actual value
+		_, _ = fmt.Fprintf(w, `<rect x="%d" y="%d" width="3" height="%d" fill="%s"/>`+"\n",
+			chartLabelWidth+chartTrackWidth-3, barY, chartBarHeight, chartUtilHigh)
+	}
+	_, _ = fmt.Fprintf(w, `<text x="%d" y="%d">%s</text>`+"\n",
+		chartLabelWidth+chartTrackWidth+8, barY+chartBarHeight-3, html.EscapeString(bar.text))
+}
+
+// truncateChartLabel shortens names that would run into the bars
+func truncateChartLabel(label string) string {
+	const maxRunes = 28
+	runes := []rune(label)
+	if len(runes) <= maxRunes {
+		return label
+	}
+	return string(runes[:maxRunes-1]) + "…"
+}
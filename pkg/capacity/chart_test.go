@@ -0,0 +1,102 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func chartTestClusterMetric() *clusterMetric {
+	metric := func(resourceType, allocatable, request, utilization string) *resourceMetric {
+		return &resourceMetric{
+			resourceType: resourceType,
+			allocatable:  resource.MustParse(allocatable),
+			request:      resource.MustParse(request),
+			utilization:  resource.MustParse(utilization),
+		}
+	}
+	return &clusterMetric{
+		cpu:    metric("cpu", "4", "3", "1"),
+		memory: metric("memory", "8Gi", "9Gi", "6Gi"),
+		nodeMetrics: map[string]*nodeMetric{
+			"node-a": {name: "node-a", cpu: metric("cpu", "2", "1", "1900m"), memory: metric("memory", "4Gi", "5Gi", "3Gi")},
+			"node-b": {name: "node-b", cpu: metric("cpu", "2", "2", "100m"), memory: metric("memory", "4Gi", "4Gi", "3Gi")},
+		},
+	}
+}
+
+func TestBuildCharts(t *testing.T) {
+	run := newTestRun(nil)
+	subjects := nodeChartSubjects(chartTestClusterMetric(), "name")
+
+	charts := buildCharts(run, subjects, false)
+	require.Len(t, charts, 2)
+	assert.Equal(t, "CPU requests vs allocatable", charts[0].title)
+	assert.Equal(t, chartBar{label: "node-a", fraction: 0.5, color: chartRequestColor, text: "1000m / 2000m (50%)"}, charts[0].bars[0])
+	assert.Equal(t, 1.25, charts[1].bars[0].fraction)
+
+	charts = buildCharts(run, subjects, true)
+	require.Len(t, charts, 4)
+	assert.Equal(t, "CPU utilization", charts[2].title)
+	assert.Equal(t, chartUtilHigh, charts[2].bars[0].color)
+	assert.Equal(t, chartUtilOK, charts[2].bars[1].color)
+	assert.Equal(t, chartUtilWarn, charts[3].bars[0].color)
+}
+
+func TestWriteSVG(t *testing.T) {
+	run := newTestRun(nil)
+	var buf bytes.Buffer
+	writeSVG(&buf, buildCharts(run, nodeChartSubjects(chartTestClusterMetric(), "name"), true))
+
+	// The document must be well formed XML for browsers to render it
+	decoder := xml.NewDecoder(strings.NewReader(buf.String()))
+	for {
+		_, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+	}
+	assert.True(t, strings.HasPrefix(buf.String(), `<svg xmlns="http://www.w3.org/2000/svg"`))
+	assert.Contains(t, buf.String(), "<text x=\"568\" y=\"51\">1000m / 2000m (50%)</text>")
+	assert.Equal(t, 1, strings.Count(buf.String(), `width="3"`), "bars past allocatable are marked")
+	assert.Equal(t, "abcdefghijklmnopqrstuvwxyz0…", truncateChartLabel("abcdefghijklmnopqrstuvwxyz0123"))
+}
+
+func TestHTMLReport(t *testing.T) {
+	var buf bytes.Buffer
+	run := newTestRun(&buf)
+
+	snap := getTestSnapshot()
+	cm := buildClusterMetric(snap.Pods, nil, snap.Nodes, nil)
+	hp := &htmlPrinter{run: run, opts: Options{ShowCharts: true, SortBy: "name"}}
+	hp.Print(&cm)
+
+	report := buf.String()
+	assert.True(t, strings.HasPrefix(report, "<!DOCTYPE html>"))
+	assert.Contains(t, report, "<svg")
+	assert.Contains(t, report, "<tr><th>NODE</th><th>CPU REQUESTS</th><th>CPU LIMITS</th><th>MEMORY REQUESTS</th><th>MEMORY LIMITS</th></tr>")
+	assert.Contains(t, report, "<tr><td>mynode</td><td>100m (10%)</td>")
+	assert.True(t, htmlTotalsRow([]string{"prod", VoidValue, "1000m (50%)"}))
+	assert.False(t, htmlTotalsRow([]string{"mynode", "100m (10%)"}))
+}
@@ -0,0 +1,111 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"fmt"
+
+	"github.com/robscott/kube-capacity/pkg/kube"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	metrics "k8s.io/metrics/pkg/client/clientset/versioned"
+)
+
+// newClientSet returns the clientset set in opts, or one connected to the
+// cluster of the kubeconfig context when none is
+func newClientSet(run *reportRun, opts Options) (kubernetes.Interface, error) {
+	if opts.Clientset != nil {
+		return opts.Clientset, nil
+	}
+	if kube.InCluster(opts.KubeContext, opts.KubeConfig) {
+		run.logDebugf("No kubeconfig found, connecting with the service account of the pod")
+	}
+	return kube.NewClientSet(opts.KubeContext, opts.KubeConfig, opts.InsecureSkipTLSVerify, opts.ImpersonateUser, opts.ImpersonateGroup, kubeRateLimit(opts))
+}
+
+// newMetricsClientSet returns the metrics clientset set in opts, or one
+// connected to the cluster of the kubeconfig context when none is. With a
+// clientset set in opts but no metrics clientset, the kubeconfig could
+// point at another cluster, so that is an error instead.
+func newMetricsClientSet(opts Options) (metrics.Interface, error) {
+	if opts.MetricsClientset != nil {
+		return opts.MetricsClientset, nil
+	}
+	if opts.Clientset != nil {
+		return nil, fmt.Errorf("kube-capacity was given a clientset without a metrics clientset to read metrics-server with")
+	}
+	return kube.NewMetricsClientSet(opts.KubeContext, opts.KubeConfig, opts.InsecureSkipTLSVerify, kubeRateLimit(opts))
+}
+
+// newDynamicClient returns the dynamic client set in opts, or one connected
+// to the cluster of the kubeconfig context when none is. As with the
+// metrics clientset, a clientset set in opts needs a dynamic client with it.
+func newDynamicClient(opts Options) (dynamic.Interface, error) {
+	if opts.DynamicClient != nil {
+		return opts.DynamicClient, nil
+	}
+	if opts.Clientset != nil {
+		return nil, fmt.Errorf("kube-capacity was given a clientset without a dynamic client to list VerticalPodAutoscalers and NodePools with")
+	}
+	return kube.NewDynamicClient(opts.KubeContext, opts.KubeConfig, opts.InsecureSkipTLSVerify, kubeRateLimit(opts))
+}
+
+// kubeRateLimit is the rate limit of --kube-api-qps and --kube-api-burst
+// the clients of a report are created with
+func kubeRateLimit(opts Options) kube.RateLimit {
+	return kube.RateLimit{QPS: opts.KubeAPIQPS, Burst: opts.KubeAPIBurst}
+}
+
+// ValidateInjectedClients returns an error when opts has a clientset but not
+// the metrics clientset or dynamic client the report needs, which would
+// otherwise be missing partway through fetching. Reports rendered from
+// files do not use any client.
+func ValidateInjectedClients(opts Options) error {
+	if opts.Clientset == nil || opts.SnapshotIn != "" || len(opts.FromKubectlDump) > 0 {
+		return nil
+	}
+	if opts.MetricsClientset == nil && usesMetricsSource(opts, MetricsServerSource) {
+		return fmt.Errorf("utilization is read from metrics-server, but kube-capacity was given a clientset without a metrics clientset")
+	}
+	if opts.DynamicClient == nil {
+		for _, f := range []struct {
+			name string
+			used bool
+		}{{"show-vpa", opts.ShowVPA}, {"headroom", opts.ShowHeadroom}, {"karpenter", opts.ShowKarpenter}} {
+			if f.used {
+				return fmt.Errorf("--%s needs a dynamic client, but kube-capacity was given a clientset without one", f.name)
+			}
+		}
+	}
+	return nil
+}
+
+// clusterName returns the name the cluster is recorded under in snapshots,
+// history, caches, and uploads. That is ClusterName when it is set, or else
+// the API server host of a clientset set in opts, or else the kube context.
+func clusterName(opts Options) (string, error) {
+	if opts.ClusterName != "" {
+		return opts.ClusterName, nil
+	}
+	if opts.Clientset != nil {
+		if rc := opts.Clientset.Discovery().RESTClient(); rc != nil {
+			if host := rc.Get().URL().Host; host != "" {
+				return host, nil
+			}
+		}
+		return "", fmt.Errorf("no name was given for the cluster of the clients kube-capacity was given")
+	}
+	return kube.GetContextName(opts.KubeContext, opts.KubeConfig)
+}
@@ -0,0 +1,102 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+	metricsfake "k8s.io/metrics/pkg/client/clientset/versioned/fake"
+)
+
+func TestInjectedClients(t *testing.T) {
+	run := newTestRun(nil)
+	opts := Options{
+		KubeConfig:       "/nonexistent/kubeconfig",
+		Clientset:        fake.NewSimpleClientset(),
+		MetricsClientset: metricsfake.NewSimpleClientset(),
+		DynamicClient:    dynamicfake.NewSimpleDynamicClient(runtime.NewScheme()),
+	}
+
+	clientset, err := newClientSet(run, opts)
+	require.NoError(t, err)
+	assert.Same(t, opts.Clientset, clientset)
+
+	mClientset, err := newMetricsClientSet(opts)
+	require.NoError(t, err)
+	assert.Same(t, opts.MetricsClientset, mClientset)
+
+	dynamicClient, err := newDynamicClient(opts)
+	require.NoError(t, err)
+	assert.Same(t, opts.DynamicClient, dynamicClient)
+
+	// Without injected clients, they are connected with the kubeconfig
+	_, err = newClientSet(run, Options{KubeConfig: opts.KubeConfig})
+	assert.Error(t, err)
+}
+
+func TestInjectedClientsetOnly(t *testing.T) {
+	opts := Options{
+		KubeConfig:    "/nonexistent/kubeconfig",
+		Clientset:     fake.NewSimpleClientset(),
+		MetricsSource: MetricsServerSource,
+	}
+	require.NoError(t, ValidateInjectedClients(opts))
+
+	// The other clients are not connected with the kubeconfig, which may be
+	// of another cluster
+	_, err := newMetricsClientSet(opts)
+	assert.Error(t, err)
+	_, err = newDynamicClient(opts)
+	assert.Error(t, err)
+
+	opts.ShowUtil = true
+	assert.EqualError(t, ValidateInjectedClients(opts),
+		"utilization is read from metrics-server, but kube-capacity was given a clientset without a metrics clientset")
+	opts.MetricsClientset = metricsfake.NewSimpleClientset()
+	require.NoError(t, ValidateInjectedClients(opts))
+
+	opts.ShowKarpenter = true
+	assert.EqualError(t, ValidateInjectedClients(opts),
+		"--karpenter needs a dynamic client, but kube-capacity was given a clientset without one")
+	opts.SnapshotIn = "snapshot.json"
+	assert.NoError(t, ValidateInjectedClients(opts), "reports rendered from files use no client")
+}
+
+func TestClusterName(t *testing.T) {
+	name, err := clusterName(Options{KubeContext: "staging"})
+	require.NoError(t, err)
+	assert.Equal(t, "staging", name)
+
+	name, err = clusterName(Options{Clientset: fake.NewSimpleClientset(), ClusterName: "prod"})
+	require.NoError(t, err)
+	assert.Equal(t, "prod", name)
+
+	clientset, err := kubernetes.NewForConfig(&rest.Config{Host: "https://10.0.0.1:6443"})
+	require.NoError(t, err)
+	name, err = clusterName(Options{Clientset: clientset})
+	require.NoError(t, err)
+	assert.Equal(t, "10.0.0.1:6443", name)
+
+	// The fake clientset has no API server to name the cluster by
+	_, err = clusterName(Options{Clientset: fake.NewSimpleClientset()})
+	assert.Error(t, err)
+}
@@ -0,0 +1,30 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"net/http"
+	"time"
+)
+
+// cloudRequestTimeout bounds every request to a cloud API, so that an
+// endpoint that stops responding fails the request instead of blocking the
+// run, and with --interval every run after it
+const cloudRequestTimeout = 2 * time.Minute
+
+// cloudHTTPClient sends the requests to cloud storage, token, and metadata
+// endpoints. Credential and metadata lookups set shorter deadlines on their
+// contexts as well.
+var cloudHTTPClient = &http.Client{Timeout: cloudRequestTimeout}
@@ -0,0 +1,297 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	v1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+)
+
+// DefaultCloudWatchWindow is how long CloudWatch metrics are averaged over
+const DefaultCloudWatchWindow = 15 * time.Minute
+
+// Container Insights publishes metrics every minute, with CPU in millicores
+const (
+	cloudWatchPeriod     = 60
+	cloudWatchMilliCores = 1000
+)
+
+// eksClusterARN matches the context names `aws eks update-kubeconfig` writes
+var eksClusterARN = regexp.MustCompile(`^arn:aws[a-z-]*:eks:([a-z0-9-]+):[0-9]+:cluster/(.+)$`)
+
+var cloudWatchEndpoint = func(region string) string {
+	if strings.HasPrefix(region, "cn-") {
+		return fmt.Sprintf("https://monitoring.%s.amazonaws.com.cn", region)
+	}
+	return fmt.Sprintf("https://monitoring.%s.amazonaws.com", region)
+}
+
+// cloudWatchQuery is a SEARCH of Container Insights metrics, labelled with
+// the dimensions buildPodMetricsList and buildNodeMetricsList read
+type cloudWatchQuery struct {
+	id         string
+	dimensions string
+	metric     string
+	label      string
+}
+
+// Pod metrics with a FullPodName dimension are only published with
+// Container Insights enhanced observability
+var cloudWatchQueries = []cloudWatchQuery{
+	{id: "podcpu", dimensions: "ClusterName,FullPodName,Namespace,PodName", metric: "pod_cpu_usage_total", label: "${PROP('Dim.Namespace')}/${PROP('Dim.FullPodName')}"},
+	{id: "podmem", dimensions: "ClusterName,FullPodName,Namespace,PodName", metric: "pod_memory_working_set", label: "${PROP('Dim.Namespace')}/${PROP('Dim.FullPodName')}"},
+	{id: "nodecpu", dimensions: "ClusterName,InstanceId,NodeName", metric: "node_cpu_usage_total", label: "${PROP('Dim.NodeName')}"},
+	{id: "nodemem", dimensions: "ClusterName,InstanceId,NodeName", metric: "node_memory_working_set", label: "${PROP('Dim.NodeName')}"},
+}
+
+func (q cloudWatchQuery) expression(cluster, aggregation string) string {
+	stat := "Average"
+	if aggregation == MaxUsageAggregation {
+		stat = "Maximum"
+	}
+	return fmt.Sprintf(`SEARCH('{ContainerInsights,%s} MetricName="%s" ClusterName="%s"', '%s', %d)`,
+		q.dimensions, q.metric, cluster, stat, cloudWatchPeriod)
+}
+
+type cloudWatchResponse struct {
+	Results   []cloudWatchResult  `xml:"GetMetricDataResult>MetricDataResults>member"`
+	Messages  []cloudWatchMessage `xml:"GetMetricDataResult>Messages>member"`
+	NextToken string              `xml:"GetMetricDataResult>NextToken"`
+}
+
+type cloudWatchResult struct {
+	ID         string              `xml:"Id"`
+	Label      string              `xml:"Label"`
+	Timestamps []time.Time         `xml:"Timestamps>member"`
+	Values     []float64           `xml:"Values>member"`
+	Messages   []cloudWatchMessage `xml:"Messages>member"`
+}
+
+type cloudWatchMessage struct {
+	Code  string `xml:"Code"`
+	Value string `xml:"Value"`
+}
+
+// cloudWatchSeries is the average and highest of the datapoints of one
+// series
+type cloudWatchSeries struct {
+	sum    float64
+	max    float64
+	count  int
+	newest time.Time
+}
+
+// resolveCloudWatchTarget returns the EKS cluster and AWS region to read
+// metrics for. When --cloudwatch-cluster or --region are not set they come
+// from AWS_REGION or the ARN that names the kube context.
+func resolveCloudWatchTarget(opts Options) (string, string, error) {
+	cluster := opts.CloudWatchCluster
+	region := firstNonEmpty(opts.Region, os.Getenv("AWS_REGION"), os.Getenv("AWS_DEFAULT_REGION"))
+
+	if cluster == "" || region == "" {
+		contextName, err := clusterName(opts)
+		if err == nil {
+			if match := eksClusterARN.FindStringSubmatch(contextName); match != nil {
+				region = firstNonEmpty(region, match[1])
+				cluster = firstNonEmpty(cluster, match[2])
+			}
+		}
+	}
+
+	if cluster == "" {
+		return "", "", fmt.Errorf("could not tell which EKS cluster this is, set --cloudwatch-cluster")
+	}
+	if region == "" {
+		return "", "", fmt.Errorf("could not tell which AWS region %s is in, set --region", cluster)
+	}
+	return cluster, region, nil
+}
+
+// getCloudWatchMetrics reads pod and node utilization from CloudWatch
+// Container Insights, averaged over --cloudwatch-window
+func getCloudWatchMetrics(ctx context.Context, run *reportRun, opts Options) (*v1beta1.PodMetricsList, *v1beta1.NodeMetricsList, error) {
+	cluster, region, err := resolveCloudWatchTarget(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	creds, err := getAWSCredentials(ctx, region)
+	if err != nil {
+		return nil, nil, err
+	}
+	run.logDebugf("Using AWS credentials from %s", creds.source)
+
+	end := time.Now().Truncate(time.Minute)
+	start := end.Add(-opts.CloudWatchWindow)
+	series, err := queryCloudWatch(ctx, run, creds, region, cluster, opts.UsageAggregation, start, end)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cpuResp := cloudWatchPrometheusResponse(series["podcpu"], "pod", 1.0/cloudWatchMilliCores, opts.UsageAggregation)
+	memResp := cloudWatchPrometheusResponse(series["podmem"], "pod", 1, opts.UsageAggregation)
+	nodeCPUResp := cloudWatchPrometheusResponse(series["nodecpu"], "node", 1.0/cloudWatchMilliCores, opts.UsageAggregation)
+	nodeMemResp := cloudWatchPrometheusResponse(series["nodemem"], "node", 1, opts.UsageAggregation)
+
+	if len(nodeCPUResp.Data.Result) == 0 && len(nodeMemResp.Data.Result) == 0 {
+		return nil, nil, fmt.Errorf("no Container Insights metrics found for cluster %s in %s", cluster, region)
+	}
+
+	pmList := buildPodMetricsList(run, cpuResp, memResp)
+	nmList := buildNodeMetricsList(run, nodeCPUResp, nodeMemResp)
+
+	sample := metav1.NewTime(prometheusEvalTime(nodeCPUResp))
+	for i := range pmList.Items {
+		pmList.Items[i].Timestamp = sample
+		pmList.Items[i].Window.Duration = opts.CloudWatchWindow
+	}
+	for i := range nmList.Items {
+		nmList.Items[i].Timestamp = sample
+		nmList.Items[i].Window.Duration = opts.CloudWatchWindow
+	}
+
+	return pmList, nmList, nil
+}
+
+// queryCloudWatch runs every Container Insights query with GetMetricData,
+// following NextToken, and returns the series of each query by label
+func queryCloudWatch(ctx context.Context, run *reportRun, creds *awsCredentials, region, cluster, aggregation string, start, end time.Time) (map[string]map[string]*cloudWatchSeries, error) {
+	params := url.Values{}
+	params.Set("Action", "GetMetricData")
+	params.Set("Version", "2010-08-01")
+	params.Set("StartTime", start.UTC().Format(time.RFC3339))
+	params.Set("EndTime", end.UTC().Format(time.RFC3339))
+	params.Set("ScanBy", "TimestampDescending")
+	for i, q := range cloudWatchQueries {
+		prefix := fmt.Sprintf("MetricDataQueries.member.%d.", i+1)
+		params.Set(prefix+"Id", q.id)
+		params.Set(prefix+"Expression", q.expression(cluster, aggregation))
+		params.Set(prefix+"Label", q.label)
+		params.Set(prefix+"ReturnData", "true")
+	}
+
+	series := map[string]map[string]*cloudWatchSeries{}
+	endpoint := cloudWatchEndpoint(region)
+	run.logDebugf("Querying CloudWatch at %s for cluster %s", endpoint, cluster)
+	queryStart := time.Now()
+	for {
+		body := []byte(params.Encode())
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint+"/", bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+		signAWSRequest(req, body, creds, region, "monitoring", time.Now())
+
+		respBody, err := doAWSRequest(req)
+		if err != nil {
+			return nil, fmt.Errorf("CloudWatch GetMetricData: %w", err)
+		}
+		var resp cloudWatchResponse
+		if err := xml.Unmarshal(respBody, &resp); err != nil {
+			return nil, fmt.Errorf("parsing CloudWatch response: %w", err)
+		}
+
+		for _, msg := range resp.Messages {
+			run.logWarnf("CloudWatch: %s: %s", msg.Code, msg.Value)
+		}
+		for _, result := range resp.Results {
+			for _, msg := range result.Messages {
+				run.logWarnf("CloudWatch %s: %s: %s", result.ID, msg.Code, msg.Value)
+			}
+			if series[result.ID] == nil {
+				series[result.ID] = map[string]*cloudWatchSeries{}
+			}
+			s := series[result.ID][result.Label]
+			if s == nil {
+				s = &cloudWatchSeries{}
+				series[result.ID][result.Label] = s
+			}
+			for i, value := range result.Values {
+				s.sum += value
+				if s.count == 0 || value > s.max {
+					s.max = value
+				}
+				s.count++
+				if i < len(result.Timestamps) && result.Timestamps[i].After(s.newest) {
+					s.newest = result.Timestamps[i]
+				}
+			}
+		}
+
+		if resp.NextToken == "" {
+			break
+		}
+		params.Set("NextToken", resp.NextToken)
+	}
+
+	count := 0
+	for _, bySeries := range series {
+		count += len(bySeries)
+	}
+	run.logTiming(queryStart, "Received %d series from CloudWatch", count)
+
+	return series, nil
+}
+
+// cloudWatchPrometheusResponse converts the series of one query to the
+// shape of a Prometheus instant query, with each value the average of the
+// series or its highest with the max aggregation. Pod series are labelled
+// namespace/pod and have no container, so their usage is counted for the
+// whole pod.
+func cloudWatchPrometheusResponse(series map[string]*cloudWatchSeries, kind string, scale float64, aggregation string) *prometheusResponse {
+	resp := &prometheusResponse{Status: "success"}
+	resp.Data.ResultType = "vector"
+
+	for label, s := range series {
+		if s.count == 0 {
+			continue
+		}
+		metric := map[string]string{}
+		if kind == "pod" {
+			namespace, pod, ok := strings.Cut(label, "/")
+			if !ok {
+				continue
+			}
+			metric["namespace"] = namespace
+			metric["pod"] = pod
+			metric["container"] = ""
+		} else {
+			metric["node"] = label
+		}
+
+		value := s.sum / float64(s.count) * scale
+		if aggregation == MaxUsageAggregation {
+			value = s.max * scale
+		}
+		resp.Data.Result = append(resp.Data.Result, prometheusResult{
+			Metric: metric,
+			Value:  []interface{}{float64(s.newest.UnixNano()) / float64(time.Second), strconv.FormatFloat(value, 'f', -1, 64)},
+		})
+	}
+
+	return resp
+}
@@ -0,0 +1,164 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func cloudWatchResultXML(id, label string, values ...float64) string {
+	timestamps, points := "", ""
+	for i, v := range values {
+		timestamps += fmt.Sprintf("<member>2026-01-01T12:%02d:00Z</member>", 10-i)
+		points += fmt.Sprintf("<member>%g</member>", v)
+	}
+	return fmt.Sprintf(`<member><Id>%s</Id><Label>%s</Label><Timestamps>%s</Timestamps><Values>%s</Values><StatusCode>Complete</StatusCode></member>`,
+		id, label, timestamps, points)
+}
+
+func TestGetCloudWatchMetrics(t *testing.T) {
+	run := newTestRun(nil)
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKIDTEST/"))
+		assert.Contains(t, r.Header.Get("Authorization"), "/us-west-2/monitoring/aws4_request")
+		assert.Equal(t, "session", r.Header.Get("X-Amz-Security-Token"))
+
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "GetMetricData", r.Form.Get("Action"))
+		assert.Equal(t, `SEARCH('{ContainerInsights,ClusterName,InstanceId,NodeName} MetricName="node_cpu_usage_total" ClusterName="prod"', 'Average', 60)`,
+			r.Form.Get("MetricDataQueries.member.3.Expression"))
+
+		// The second page continues the node CPU series of the first
+		if r.Form.Get("NextToken") == "" {
+			fmt.Fprintf(w, `<GetMetricDataResponse><GetMetricDataResult><MetricDataResults>%s%s%s</MetricDataResults><NextToken>page2</NextToken></GetMetricDataResult></GetMetricDataResponse>`,
+				cloudWatchResultXML("podcpu", "default/web-1", 200, 300),
+				cloudWatchResultXML("podmem", "default/web-1", 104857600),
+				cloudWatchResultXML("nodecpu", "node-a", 1000))
+			return
+		}
+		fmt.Fprintf(w, `<GetMetricDataResponse><GetMetricDataResult><MetricDataResults>%s%s</MetricDataResults></GetMetricDataResult></GetMetricDataResponse>`,
+			cloudWatchResultXML("nodecpu", "node-a", 2000),
+			cloudWatchResultXML("nodemem", "node-a", 2147483648))
+	}))
+	defer server.Close()
+
+	endpoint := cloudWatchEndpoint
+	cloudWatchEndpoint = func(string) string { return server.URL }
+	defer func() { cloudWatchEndpoint = endpoint }()
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDTEST")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_SESSION_TOKEN", "session")
+
+	opts := Options{CloudWatchCluster: "prod", Region: "us-west-2", CloudWatchWindow: 15 * time.Minute}
+	pmList, nmList, err := getCloudWatchMetrics(context.Background(), run, opts)
+	require.NoError(t, err)
+	assert.Equal(t, 2, requests)
+
+	require.Len(t, pmList.Items, 1)
+	pm := pmList.Items[0]
+	assert.Equal(t, "default", pm.Namespace)
+	assert.Equal(t, "web-1", pm.Name)
+	require.Len(t, pm.Containers, 1)
+	assert.Equal(t, "", pm.Containers[0].Name)
+	assert.Equal(t, "250m", pm.Containers[0].Usage.Cpu().String())
+	assert.Equal(t, "100Mi", pm.Containers[0].Usage.Memory().String())
+
+	require.Len(t, nmList.Items, 1)
+	nm := nmList.Items[0]
+	assert.Equal(t, "node-a", nm.Name)
+	assert.Equal(t, "1500m", nm.Usage.Cpu().String())
+	assert.Equal(t, "2Gi", nm.Usage.Memory().String())
+	assert.Equal(t, time.Date(2026, 1, 1, 12, 10, 0, 0, time.UTC), nm.Timestamp.UTC())
+	assert.Equal(t, 15*time.Minute, nm.Window.Duration)
+}
+
+func TestGetCloudWatchMetricsError(t *testing.T) {
+	run := newTestRun(nil)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `<ErrorResponse><Error><Type>Sender</Type><Code>AccessDenied</Code><Message>not authorized to perform: cloudwatch:GetMetricData</Message></Error></ErrorResponse>`)
+	}))
+	defer server.Close()
+
+	endpoint := cloudWatchEndpoint
+	cloudWatchEndpoint = func(string) string { return server.URL }
+	defer func() { cloudWatchEndpoint = endpoint }()
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDTEST")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+
+	opts := Options{CloudWatchCluster: "prod", Region: "us-west-2", CloudWatchWindow: time.Minute}
+	_, _, err := getCloudWatchMetrics(context.Background(), run, opts)
+	require.Error(t, err)
+	assert.Equal(t, "CloudWatch GetMetricData: AccessDenied: not authorized to perform: cloudwatch:GetMetricData", err.Error())
+}
+
+func TestCloudWatchMaxUsageAggregation(t *testing.T) {
+	query := cloudWatchQueries[2]
+	assert.Equal(t, `SEARCH('{ContainerInsights,ClusterName,InstanceId,NodeName} MetricName="node_cpu_usage_total" ClusterName="prod"', 'Maximum', 60)`,
+		query.expression("prod", MaxUsageAggregation))
+
+	series := map[string]*cloudWatchSeries{"node-a": {sum: 600, max: 450, count: 2}}
+	assert.Equal(t, "0.3", cloudWatchPrometheusResponse(series, "node", 1.0/cloudWatchMilliCores, AvgUsageAggregation).Data.Result[0].Value[1])
+	assert.Equal(t, "0.45", cloudWatchPrometheusResponse(series, "node", 1.0/cloudWatchMilliCores, MaxUsageAggregation).Data.Result[0].Value[1])
+}
+
+func TestEKSClusterARN(t *testing.T) {
+	match := eksClusterARN.FindStringSubmatch("arn:aws:eks:eu-west-1:123456789012:cluster/prod")
+	require.NotNil(t, match)
+	assert.Equal(t, []string{"eu-west-1", "prod"}, match[1:])
+
+	match = eksClusterARN.FindStringSubmatch("arn:aws-cn:eks:cn-north-1:123456789012:cluster/china")
+	require.NotNil(t, match)
+	assert.Equal(t, []string{"cn-north-1", "china"}, match[1:])
+
+	assert.Nil(t, eksClusterARN.FindStringSubmatch("kind-kind"))
+}
+
+func TestBuildClusterMetricPodLevelUsage(t *testing.T) {
+	run := newTestRun(nil)
+	podList := &corev1.PodList{Items: []corev1.Pod{*podWithRequests("node-a", "default", "a", nil, corev1.ResourceList{"cpu": resource.MustParse("500m")})}}
+	nodeList := &corev1.NodeList{Items: []corev1.Node{*nodeWithAllocatable("node-a", nil, corev1.ResourceList{"cpu": resource.MustParse("2")})}}
+	cpuResp := cloudWatchPrometheusResponse(map[string]*cloudWatchSeries{
+		"default/a": {sum: 600, count: 2},
+	}, "pod", 1.0/cloudWatchMilliCores, AvgUsageAggregation)
+	pmList := buildPodMetricsList(run, cpuResp, &prometheusResponse{})
+
+	cm := buildClusterMetric(podList, pmList, nodeList, nil)
+
+	// Usage of a whole pod is counted for the pod and its node, but not for
+	// any of its containers
+	pm := cm.nodeMetrics["node-a"].podMetrics[podKey("default", "a")]
+	require.NotNil(t, pm)
+	assert.Equal(t, "300m", pm.cpu.utilization.String())
+	assert.Equal(t, "300m", cm.nodeMetrics["node-a"].cpu.utilization.String())
+	for _, container := range pm.containerMetrics {
+		assert.True(t, container.cpu.utilization.IsZero())
+	}
+}
@@ -0,0 +1,266 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// Facets of a resource that --columns can select
+const (
+	requestsFacet  = "requests"
+	limitsFacet    = "limits"
+	utilFacet      = "util"
+	availableFacet = "available"
+)
+
+// columnResource is a resource --columns can select and the facets it has
+type columnResource struct {
+	name   string
+	title  string
+	facets []string
+}
+
+// columnResources are in the order SupportedColumns lists them. Limits of
+// extended resources like GPUs always equal their requests, and utilization
+// is only read for CPU and memory.
+var columnResources = []columnResource{
+	{"cpu", "CPU", []string{requestsFacet, limitsFacet, utilFacet, availableFacet}},
+	{"memory", "MEMORY", []string{requestsFacet, limitsFacet, utilFacet, availableFacet}},
+	{"gpu", "GPU", []string{requestsFacet, limitsFacet, availableFacet}},
+	{"ephemeral-storage", "EPHEMERAL STORAGE", []string{requestsFacet, limitsFacet, availableFacet}},
+	{"pods", "PODS", []string{requestsFacet, availableFacet}},
+}
+
+// tableColumn is one resource column of the table selected with --columns
+type tableColumn struct {
+	resource *columnResource
+	facet    string
+}
+
+// SupportedColumns returns the columns --columns can select, each resource
+// on its own selecting its requests and limits, or for pods the pods
+// scheduled and available
+func SupportedColumns() []string {
+	columns := []string{}
+	for _, cr := range columnResources {
+		columns = append(columns, cr.name)
+		for _, facet := range cr.facets {
+			columns = append(columns, cr.name+"."+facet)
+		}
+	}
+	return columns
+}
+
+// ValidateColumns returns an error for --columns that can not be parsed
+func ValidateColumns(columns []string) error {
+	_, err := parseColumns(columns, false)
+	return err
+}
+
+// ColumnsNeedUtil reports whether --columns selects a utilization column
+func ColumnsNeedUtil(columns []string) bool {
+	for _, column := range columns {
+		if strings.HasSuffix(column, "."+utilFacet) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseColumns parses columns in the form <resource>.<facet>, like
+// gpu.requests or pods.available, in the order given. A resource on its own
+// selects its requests and limits, and its utilization with --util.
+func parseColumns(columns []string, showUtil bool) ([]tableColumn, error) {
+	parsed := []tableColumn{}
+	seen := map[string]bool{}
+	for _, column := range columns {
+		name, facet, hasFacet := strings.Cut(column, ".")
+		if name == "mem" {
+			name = "memory"
+		}
+		cr := findColumnResource(name)
+		if cr == nil {
+			return nil, fmt.Errorf("unsupported column %q, expected one of %v", column, SupportedColumns())
+		}
+
+		facets := []string{facet}
+		if !hasFacet {
+			facets = cr.defaultFacets(showUtil)
+		} else if !cr.hasFacet(facet) {
+			return nil, fmt.Errorf("unsupported column %q, %s supports %s", column, cr.name, strings.Join(cr.facets, ", "))
+		}
+
+		for _, facet := range facets {
+			key := cr.name + "." + facet
+			if seen[key] {
+				return nil, fmt.Errorf("column %s is selected more than once", key)
+			}
+			seen[key] = true
+			parsed = append(parsed, tableColumn{resource: cr, facet: facet})
+		}
+	}
+	return parsed, nil
+}
+
+func findColumnResource(name string) *columnResource {
+	for i := range columnResources {
+		if columnResources[i].name == name {
+			return &columnResources[i]
+		}
+	}
+	return nil
+}
+
+func (cr *columnResource) hasFacet(facet string) bool {
+	for _, f := range cr.facets {
+		if f == facet {
+			return true
+		}
+	}
+	return false
+}
+
+func (cr *columnResource) defaultFacets(showUtil bool) []string {
+	if cr.name == "pods" {
+		return []string{requestsFacet, availableFacet}
+	}
+	facets := []string{requestsFacet, limitsFacet}
+	if showUtil && cr.hasFacet(utilFacet) {
+		facets = append(facets, utilFacet)
+	}
+	return facets
+}
+
+func (tc tableColumn) header() string {
+	return tc.resource.title + " " + strings.ToUpper(tc.facet)
+}
+
+// columns returns the columns selected with --columns, nil when the
+// default CPU and memory columns are shown
+func (tp *tablePrinter) columns() []tableColumn {
+	if tp.selectedColumns == nil && len(tp.opts.Columns) > 0 {
+		// Columns were validated along with the other flags
+		tp.selectedColumns, _ = parseColumns(tp.opts.Columns, tp.opts.ShowUtil)
+	}
+	return tp.selectedColumns
+}
+
+// columnValues formats the selected columns of a row from its resources by
+// name, which are only gathered with --columns. The available facet is only
+// shown for nodes and totals, which have allocatable of their own.
+func (tp *tablePrinter) columnValues(rowResources func() map[string]*resourceMetric, node bool) []string {
+	if len(tp.columns()) == 0 {
+		return nil
+	}
+	resources := rowResources()
+	values := []string{}
+	for _, column := range tp.columns() {
+		rm := resources[column.resource.name]
+		if rm == nil {
+			values = append(values, VoidValue)
+			continue
+		}
+		switch column.facet {
+		case requestsFacet:
+			values = append(values, tp.requestString(rm))
+		case limitsFacet:
+			values = append(values, tp.limitString(rm))
+		case utilFacet:
+			values = append(values, tp.utilString(rm))
+		case availableFacet:
+			if !node {
+				values = append(values, VoidValue)
+				continue
+			}
+			values = append(values, tp.run.formatQuantityDifference(rm.resourceType, rm.allocatable, rm.request))
+		}
+	}
+	return values
+}
+
+// columnResources returns the resources of the cluster that --columns can
+// select, summing GPUs and ephemeral storage from its nodes
+func (cm *clusterMetric) columnResources() map[string]*resourceMetric {
+	gpu := &resourceMetric{resourceType: "gpu"}
+	ephemeralStorage := &resourceMetric{resourceType: "ephemeral-storage"}
+	for _, nm := range cm.nodeMetrics {
+		gpu.addMetric(nm.gpuResourceMetric())
+		if nm.ephemeralStorage != nil {
+			ephemeralStorage.addMetric(nm.ephemeralStorage)
+		}
+	}
+	return map[string]*resourceMetric{
+		"cpu":               cm.cpu,
+		"memory":            cm.memory,
+		"gpu":               gpu,
+		"ephemeral-storage": ephemeralStorage,
+		"pods":              cm.podCount.resourceMetric(),
+	}
+}
+
+func (nm *nodeMetric) columnResources() map[string]*resourceMetric {
+	return map[string]*resourceMetric{
+		"cpu":               nm.cpu,
+		"memory":            nm.memory,
+		"gpu":               nm.gpuResourceMetric(),
+		"ephemeral-storage": nm.ephemeralStorage,
+		"pods":              nm.podCount.resourceMetric(),
+	}
+}
+
+func (pm *podMetric) columnResources() map[string]*resourceMetric {
+	return map[string]*resourceMetric{
+		"cpu":               pm.cpu,
+		"memory":            pm.memory,
+		"gpu":               pm.gpu,
+		"ephemeral-storage": pm.ephemeralStorage,
+	}
+}
+
+func (cm *containerMetric) columnResources() map[string]*resourceMetric {
+	return map[string]*resourceMetric{
+		"cpu":               cm.cpu,
+		"memory":            cm.memory,
+		"gpu":               cm.gpu,
+		"ephemeral-storage": cm.ephemeralStorage,
+	}
+}
+
+// gpuResourceMetric returns the nvidia.com/gpu of the node as a resource
+// metric, with limits equal to requests as Kubernetes requires of extended
+// resources
+func (nm *nodeMetric) gpuResourceMetric() *resourceMetric {
+	rm := &resourceMetric{resourceType: "gpu"}
+	if gpu := nm.gpus[nvidiaGPUResource]; gpu != nil {
+		rm.allocatable = gpu.allocatable.DeepCopy()
+		rm.request = gpu.request.DeepCopy()
+		rm.limit = gpu.request.DeepCopy()
+	}
+	return rm
+}
+
+// resourceMetric returns the pod count as a resource metric, with the pods
+// scheduled as requests
+func (pc *podCount) resourceMetric() *resourceMetric {
+	return &resourceMetric{
+		resourceType: "pods",
+		allocatable:  *resource.NewQuantity(pc.allocatable, resource.DecimalSI),
+		request:      *resource.NewQuantity(pc.current, resource.DecimalSI),
+	}
+}
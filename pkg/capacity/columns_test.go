@@ -0,0 +1,109 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestParseColumns(t *testing.T) {
+	headers := func(columns []tableColumn) []string {
+		out := []string{}
+		for _, column := range columns {
+			out = append(out, column.header())
+		}
+		return out
+	}
+
+	columns, err := parseColumns([]string{"cpu", "mem.util", "pods"}, true)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"CPU REQUESTS", "CPU LIMITS", "CPU UTIL", "MEMORY UTIL", "PODS REQUESTS", "PODS AVAILABLE",
+	}, headers(columns))
+
+	columns, err = parseColumns([]string{"ephemeral-storage", "gpu.available"}, false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"EPHEMERAL STORAGE REQUESTS", "EPHEMERAL STORAGE LIMITS", "GPU AVAILABLE",
+	}, headers(columns))
+
+	for _, invalid := range [][]string{
+		{"disk"},
+		{"cpu.usage"},
+		{"gpu.util"},
+		{"pods.limits"},
+		{"cpu", "cpu.requests"},
+	} {
+		assert.Error(t, ValidateColumns(invalid), invalid)
+	}
+
+	assert.True(t, ColumnsNeedUtil([]string{"gpu", "memory.util"}))
+	assert.False(t, ColumnsNeedUtil([]string{"cpu", "pods.available"}))
+}
+
+func TestColumnsTable(t *testing.T) {
+	run := newTestRun(nil)
+	n := node("gpu-node", map[string]string{}, false)
+	n.Status.Allocatable = corev1.ResourceList{
+		"cpu":               resource.MustParse("4"),
+		"memory":            resource.MustParse("8Gi"),
+		"pods":              resource.MustParse("110"),
+		"ephemeral-storage": resource.MustParse("100Gi"),
+		nvidiaGPUResource:   resource.MustParse("4"),
+	}
+	p := pod("gpu-node", "default", "train", nil)
+	p.Spec.Containers = []corev1.Container{{
+		Name: "trainer",
+		Resources: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{
+				"cpu":               resource.MustParse("500m"),
+				"ephemeral-storage": resource.MustParse("10Gi"),
+				nvidiaGPUResource:   resource.MustParse("1"),
+			},
+			Limits: corev1.ResourceList{
+				"ephemeral-storage": resource.MustParse("20Gi"),
+				nvidiaGPUResource:   resource.MustParse("1"),
+			},
+		},
+	}}
+	cm := buildClusterMetric(&corev1.PodList{Items: []corev1.Pod{*p}}, nil, &corev1.NodeList{Items: []corev1.Node{*n}}, nil)
+
+	tp := &tablePrinter{run: run, cm: &cm, opts: Options{
+		ShowPods: true,
+		Columns:  []string{"gpu", "ephemeral-storage.requests", "pods.available"},
+	}}
+	assert.True(t, tp.hasVisibleColumns())
+	assert.Equal(t, []string{
+		"NODE", "NAMESPACE", "POD", "GPU REQUESTS", "GPU LIMITS", "EPHEMERAL STORAGE REQUESTS", "PODS AVAILABLE",
+	}, tp.getLineItems(tp.headers()))
+	assert.Len(t, tp.getLineItems(&tableLine{}), 7)
+
+	nm := cm.nodeMetrics["gpu-node"]
+	assert.Equal(t, []string{"1 (25%)", "1 (25%)", "10240Mi (10%)", "109"}, tp.columnValues(nm.columnResources, true))
+	assert.Equal(t, []string{"1 (25%)", "1 (25%)", "10240Mi (10%)", "109"}, tp.columnValues(cm.columnResources, true))
+
+	pm := nm.podMetrics["default/train"]
+	assert.Equal(t, []string{"1 (25%)", "1 (25%)", "10240Mi (10%)", VoidValue}, tp.columnValues(pm.columnResources, false))
+
+	// The default columns are left as they were without --columns
+	tp = &tablePrinter{run: run, cm: &cm, opts: Options{}}
+	assert.Nil(t, tp.columnValues(nm.columnResources, true))
+	assert.Equal(t, []string{"NODE", "CPU REQUESTS", "CPU LIMITS", "MEMORY REQUESTS", "MEMORY LIMITS"}, tp.getLineItems(tp.headers()))
+}
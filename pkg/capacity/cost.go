@@ -0,0 +1,205 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// bundledPricing maps common AWS, GCP, and Azure instance types to their
+// hourly on-demand price
+//
+//go:embed pricing.csv
+var bundledPricing string
+
+// hoursPerMonth is the average number of hours in a month used by cloud
+// providers for monthly estimates
+const hoursPerMonth = 730
+
+// instanceTypeLabels are the well-known labels that hold the instance type
+// of a node, in order of precedence
+var instanceTypeLabels = []string{
+	"node.kubernetes.io/instance-type",
+	"beta.kubernetes.io/instance-type",
+}
+
+// nodeCost is the hourly price of a node along with the part of it paying
+// for capacity that no pod requests
+type nodeCost struct {
+	hourly float64
+	idle   float64
+}
+
+type listCost struct {
+	Hourly     string `json:"hourly"`
+	IdleHourly string `json:"idleHourly"`
+	Monthly    string `json:"monthly"`
+}
+
+// getPricing returns the bundled pricing table, with the prices in the given
+// file added or overriding bundled ones
+func getPricing(path string) (map[string]float64, error) {
+	pricing, err := parsePricing(strings.NewReader(bundledPricing))
+	if err != nil {
+		return nil, fmt.Errorf("reading bundled pricing: %w", err)
+	}
+	if path == "" {
+		return pricing, nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	custom, err := parsePricing(f)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	for instanceType, price := range custom {
+		pricing[instanceType] = price
+	}
+	return pricing, nil
+}
+
+// parsePricing reads "instance_type,hourly_price" rows. Lines starting with
+// # and a header row are skipped.
+func parsePricing(r io.Reader) (map[string]float64, error) {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = 2
+	reader.TrimLeadingSpace = true
+
+	pricing := map[string]float64{}
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		price, err := strconv.ParseFloat(record[1], 64)
+		if err != nil {
+			if line == 1 {
+				continue
+			}
+			return nil, fmt.Errorf("invalid price %q for %s", record[1], record[0])
+		}
+		pricing[record[0]] = price
+	}
+	return pricing, nil
+}
+
+// addCosts prices every node by its instance type and sums the prices of
+// the nodes that could be priced for the cluster. It returns the instance
+// types without a price.
+func (cm *clusterMetric) addCosts(pricing map[string]float64) []string {
+	cm.cost = &nodeCost{}
+	missing := map[string]bool{}
+	for _, nm := range cm.nodeMetrics {
+		instanceType := nodeInstanceType(nm.labels)
+		price, ok := pricing[instanceType]
+		if !ok {
+			if instanceType == "" {
+				instanceType = fmt.Sprintf("%s (no instance type label)", nm.name)
+			}
+			missing[instanceType] = true
+			continue
+		}
+		nm.cost = newNodeCost(price, nm)
+		cm.cost.add(nm.cost)
+	}
+
+	missingTypes := []string{}
+	for instanceType := range missing {
+		missingTypes = append(missingTypes, instanceType)
+	}
+	sort.Strings(missingTypes)
+	return missingTypes
+}
+
+func nodeInstanceType(labels map[string]string) string {
+	for _, label := range instanceTypeLabels {
+		if value, ok := labels[label]; ok {
+			return value
+		}
+	}
+	return ""
+}
+
+// newNodeCost splits the price of a node evenly between CPU and memory and
+// counts the share of each that is not requested as idle
+func newNodeCost(hourly float64, nm *nodeMetric) *nodeCost {
+	requested := (requestedFraction(nm.cpu) + requestedFraction(nm.memory)) / 2
+	return &nodeCost{
+		hourly: hourly,
+		idle:   hourly * (1 - requested),
+	}
+}
+
+func requestedFraction(rm *resourceMetric) float64 {
+	if rm.allocatable.MilliValue() == 0 {
+		return 1
+	}
+	return math.Min(float64(rm.request.MilliValue())/float64(rm.allocatable.MilliValue()), 1)
+}
+
+func (c *nodeCost) add(other *nodeCost) {
+	c.hourly += other.hourly
+	c.idle += other.idle
+}
+
+func (c *nodeCost) hourlyString() string {
+	if c == nil {
+		return VoidValue
+	}
+	return strconv.FormatFloat(c.hourly, 'f', 3, 64)
+}
+
+func (c *nodeCost) idleString() string {
+	if c == nil {
+		return VoidValue
+	}
+	return strconv.FormatFloat(c.idle, 'f', 3, 64)
+}
+
+func (c *nodeCost) monthlyString() string {
+	if c == nil {
+		return VoidValue
+	}
+	return strconv.FormatFloat(c.hourly*hoursPerMonth, 'f', 2, 64)
+}
+
+func (c *nodeCost) listCost() *listCost {
+	if c == nil {
+		return nil
+	}
+	return &listCost{
+		Hourly:     c.hourlyString(),
+		IdleHourly: c.idleString(),
+		Monthly:    c.monthlyString(),
+	}
+}
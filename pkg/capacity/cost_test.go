@@ -0,0 +1,84 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePricing(t *testing.T) {
+	pricing, err := parsePricing(strings.NewReader("# prices\ninstance_type,hourly_price\nm5.large, 0.096\ncustom,1.5\n"))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]float64{"m5.large": 0.096, "custom": 1.5}, pricing)
+
+	_, err = parsePricing(strings.NewReader("m5.large,0.096\ncustom,free\n"))
+	assert.EqualError(t, err, `invalid price "free" for custom`)
+
+	_, err = parsePricing(strings.NewReader("m5.large,0.096,extra\n"))
+	assert.Error(t, err)
+}
+
+func TestGetPricing(t *testing.T) {
+	pricing, err := getPricing("")
+	require.NoError(t, err)
+	assert.Equal(t, 0.096, pricing["m5.large"])
+	assert.Equal(t, 0.067006, pricing["e2-standard-2"])
+	assert.Equal(t, 0.096, pricing["Standard_D2s_v3"])
+
+	path := filepath.Join(t.TempDir(), "pricing.csv")
+	require.NoError(t, os.WriteFile(path, []byte("m5.large,0.05\non-prem,0.2\n"), 0o600))
+	pricing, err = getPricing(path)
+	require.NoError(t, err)
+	assert.Equal(t, 0.05, pricing["m5.large"])
+	assert.Equal(t, 0.2, pricing["on-prem"])
+	assert.Equal(t, 0.192, pricing["m5.xlarge"])
+}
+
+func TestAddCosts(t *testing.T) {
+	run := newTestRun(nil)
+	snap := getTestSnapshot()
+	snap.Nodes.Items[0].Labels["node.kubernetes.io/instance-type"] = "m5.large"
+	cm := buildClusterMetric(snap.Pods, nil, snap.Nodes, nil)
+
+	missing := cm.addCosts(map[string]float64{"m5.large": 0.1})
+	assert.Equal(t, []string{"mynode2 (no instance type label)"}, missing)
+
+	// mynode has 10% of its CPU and 3.2% of its memory requested
+	nm := cm.nodeMetrics["mynode"]
+	assert.Equal(t, "0.100", nm.cost.hourlyString())
+	assert.Equal(t, "0.093", nm.cost.idleString())
+	assert.Equal(t, "73.00", nm.cost.monthlyString())
+
+	assert.Nil(t, cm.nodeMetrics["mynode2"].cost)
+	assert.Equal(t, VoidValue, cm.nodeMetrics["mynode2"].cost.hourlyString())
+	assert.Equal(t, "0.100", cm.cost.hourlyString())
+
+	tp := &tablePrinter{run: run, cm: &cm, opts: Options{ShowCost: true}}
+	assert.Equal(t, []string{
+		"NODE", "CPU REQUESTS", "CPU LIMITS", "MEMORY REQUESTS", "MEMORY LIMITS",
+		"COST/HOUR", "IDLE COST/HOUR", "COST/MONTH",
+	}, tp.getLineItems(&headerStrings))
+
+	lp := &listPrinter{run: run, cm: &cm, opts: Options{ShowCost: true}}
+	list := lp.buildListClusterMetrics()
+	assert.Equal(t, &listCost{Hourly: "0.100", IdleHourly: "0.093", Monthly: "73.00"}, list.ClusterTotals.Cost)
+	assert.Nil(t, list.Nodes[1].Cost)
+}
@@ -17,88 +17,221 @@ package capacity
 import (
 	"fmt"
 	"io"
-	"os"
 	"strings"
 )
 
 type csvPrinter struct {
-	cm   *clusterMetric
-	file io.Writer
-	opts Options
+	run     *reportRun
+	cm      *clusterMetric
+	file    io.Writer
+	opts    Options
+	cluster string
 }
 
 type csvLine struct {
+	cluster                  string
 	node                     string
 	namespace                string
 	pod                      string
 	container                string
 	cpuCapacity              string
+	cpuNodeCapacity          string
+	cpuReserved              string
+	cpuKube                  string
+	cpuSystem                string
 	cpuRequests              string
 	cpuRequestsPercentage    string
+	cpuOverhead              string
+	cpuAvailable             string
 	cpuLimits                string
 	cpuLimitsPercentage      string
 	cpuUtil                  string
 	cpuUtilPercentage        string
+	cpuHistory               string
+	cpuVPATarget             string
+	cpuVPALowerBound         string
+	cpuVPAUpperBound         string
+	cpuOvercommit            string
+	cpuThrottle              string
 	memoryCapacity           string
+	memoryNodeCapacity       string
+	memoryReserved           string
+	memoryKube               string
+	memorySystem             string
+	memoryEviction           string
 	memoryRequests           string
 	memoryRequestsPercentage string
+	memoryOverhead           string
+	memoryAvailable          string
 	memoryLimits             string
 	memoryLimitsPercentage   string
 	memoryUtil               string
 	memoryUtilPercentage     string
+	memoryHistory            string
+	memoryVPATarget          string
+	memoryVPALowerBound      string
+	memoryVPAUpperBound      string
+	memoryOvercommit         string
+	restarts                 string
+	lastOOMKill              string
+	utilAge                  string
+	resize                   string
 	podCountCurrent          string
 	podCountAllocatable      string
+	costHourly               string
+	costIdle                 string
+	costMonthly              string
+	status                   string
+	roles                    string
+	age                      string
+	nodefsUsed               string
+	nodefsUsedPercentage     string
+	imagefsUsed              string
+	imagefsUsedPercentage    string
+	diskStatus               string
+	taints                   string
 	labels                   string
 }
 
 var csvHeaderStrings = csvLine{
+	cluster:                  "CLUSTER",
 	node:                     "NODE",
 	namespace:                "NAMESPACE",
 	pod:                      "POD",
 	container:                "CONTAINER",
 	cpuCapacity:              "CPU CAPACITY (milli)",
+	cpuNodeCapacity:          "CPU NODE CAPACITY (milli)",
+	cpuReserved:              "CPU RESERVED (milli)",
+	cpuKube:                  "CPU KUBE RESERVED (milli)",
+	cpuSystem:                "CPU SYSTEM RESERVED (milli)",
 	cpuRequests:              "CPU REQUESTS",
 	cpuRequestsPercentage:    "CPU REQUESTS %%",
+	cpuOverhead:              "CPU OVERHEAD",
+	cpuAvailable:             "CPU AVAILABLE",
 	cpuLimits:                "CPU LIMITS",
 	cpuLimitsPercentage:      "CPU LIMITS %%",
 	cpuUtil:                  "CPU UTIL",
 	cpuUtilPercentage:        "CPU UTIL %%",
+	cpuHistory:               "CPU HISTORY",
+	cpuVPATarget:             "CPU VPA TARGET",
+	cpuVPALowerBound:         "CPU VPA LOWER BOUND",
+	cpuVPAUpperBound:         "CPU VPA UPPER BOUND",
+	cpuOvercommit:            "CPU OVERCOMMIT",
+	cpuThrottle:              "CPU THROTTLE %%",
 	memoryCapacity:           "MEMORY CAPACITY (Mi)",
+	memoryNodeCapacity:       "MEMORY NODE CAPACITY (Mi)",
+	memoryReserved:           "MEMORY RESERVED (Mi)",
+	memoryKube:               "MEMORY KUBE RESERVED (Mi)",
+	memorySystem:             "MEMORY SYSTEM RESERVED (Mi)",
+	memoryEviction:           "MEMORY EVICTION (Mi)",
 	memoryRequests:           "MEMORY REQUESTS",
 	memoryRequestsPercentage: "MEMORY REQUESTS %%",
+	memoryOverhead:           "MEMORY OVERHEAD",
+	memoryAvailable:          "MEMORY AVAILABLE",
 	memoryLimits:             "MEMORY LIMITS",
 	memoryLimitsPercentage:   "MEMORY LIMITS %%",
 	memoryUtil:               "MEMORY UTIL",
 	memoryUtilPercentage:     "MEMORY UTIL %%",
+	memoryHistory:            "MEMORY HISTORY",
+	memoryVPATarget:          "MEMORY VPA TARGET",
+	memoryVPALowerBound:      "MEMORY VPA LOWER BOUND",
+	memoryVPAUpperBound:      "MEMORY VPA UPPER BOUND",
+	memoryOvercommit:         "MEMORY OVERCOMMIT",
+	restarts:                 "RESTARTS",
+	lastOOMKill:              "LAST OOMKILL",
+	utilAge:                  "UTIL AGE (seconds)",
+	resize:                   "RESIZE",
 	podCountCurrent:          "POD COUNT CURRENT",
 	podCountAllocatable:      "POD COUNT ALLOCATABLE",
+	costHourly:               "COST PER HOUR",
+	costIdle:                 "IDLE COST PER HOUR",
+	costMonthly:              "COST PER MONTH",
+	status:                   "STATUS",
+	roles:                    "ROLES",
+	age:                      "AGE",
+	nodefsUsed:               "NODEFS USED (Mi)",
+	nodefsUsedPercentage:     "NODEFS USED %%",
+	imagefsUsed:              "IMAGEFS USED (Mi)",
+	imagefsUsedPercentage:    "IMAGEFS USED %%",
+	diskStatus:               "DISK STATUS",
+	taints:                   "TAINTS",
 	labels:                   "LABELS",
 }
 
+// csvHeaders returns the header line with the units of the capacity
+// columns
+func csvHeaders(run *reportRun) *csvLine {
+	headers := csvHeaderStrings
+	headers.cpuCapacity = fmt.Sprintf("CPU CAPACITY (%s)", run.csvUnitName("cpu"))
+	headers.memoryCapacity = fmt.Sprintf("MEMORY CAPACITY (%s)", run.csvUnitName("memory"))
+	headers.cpuNodeCapacity = fmt.Sprintf("CPU NODE CAPACITY (%s)", run.csvUnitName("cpu"))
+	headers.cpuReserved = fmt.Sprintf("CPU RESERVED (%s)", run.csvUnitName("cpu"))
+	headers.memoryNodeCapacity = fmt.Sprintf("MEMORY NODE CAPACITY (%s)", run.csvUnitName("memory"))
+	headers.memoryReserved = fmt.Sprintf("MEMORY RESERVED (%s)", run.csvUnitName("memory"))
+	headers.cpuKube = fmt.Sprintf("CPU KUBE RESERVED (%s)", run.csvUnitName("cpu"))
+	headers.cpuSystem = fmt.Sprintf("CPU SYSTEM RESERVED (%s)", run.csvUnitName("cpu"))
+	headers.memoryKube = fmt.Sprintf("MEMORY KUBE RESERVED (%s)", run.csvUnitName("memory"))
+	headers.memorySystem = fmt.Sprintf("MEMORY SYSTEM RESERVED (%s)", run.csvUnitName("memory"))
+	headers.memoryEviction = fmt.Sprintf("MEMORY EVICTION (%s)", run.csvUnitName("memory"))
+	headers.nodefsUsed = fmt.Sprintf("NODEFS USED (%s)", run.csvUnitName("memory"))
+	headers.imagefsUsed = fmt.Sprintf("IMAGEFS USED (%s)", run.csvUnitName("memory"))
+	return &headers
+}
+
 func (cp *csvPrinter) Print(outputType string) {
 
-	cp.file = os.Stdout
+	cp.file = cp.run.output
 
-	sortedNodeMetrics := cp.cm.getSortedNodeMetrics(cp.opts.SortBy)
+	cp.printLine(csvHeaders(cp.run))
+	cp.printClusterRows()
+}
 
-	cp.printLine(&csvHeaderStrings)
+// PrintFleet prints fleet-wide totals followed by the rows for each
+// cluster, with a leading CLUSTER column
+func (cp *csvPrinter) PrintFleet(fm *fleetMetric) {
+	cp.file = cp.run.output
+
+	cp.cluster = VoidValue
+	cp.printLine(csvHeaders(cp.run))
+	cp.cm = fm.total
+	cp.printClusterLine()
+
+	for _, cm := range fm.clusters {
+		cp.cluster = cm.name
+		cp.cm = cm
+		cp.printClusterRows()
+	}
+}
 
-	if len(sortedNodeMetrics) > 1 {
+func (cp *csvPrinter) printClusterRows() {
+	sortedNodeMetrics := cp.cm.getSortedNodeMetrics(cp.opts.SortBy)
+
+	if len(sortedNodeMetrics) > 1 || cp.cluster != "" {
 		cp.printClusterLine()
+		if cp.opts.ShowTaints {
+			cp.printTaintedLine()
+		}
 	}
 
 	for _, nm := range sortedNodeMetrics {
-		cp.printNodeLine(nm.name, nm)
-
-		if cp.opts.ShowPods || cp.opts.ShowContainers {
-			podMetrics := nm.getSortedPodMetrics(cp.opts.SortBy)
-			for _, pm := range podMetrics {
-				cp.printPodLine(nm.name, pm)
-				if cp.opts.ShowContainers {
-					containerMetrics := pm.getSortedContainerMetrics(cp.opts.SortBy)
-					for _, containerMetric := range containerMetrics {
-						cp.printContainerLine(nm.name, pm, containerMetric)
-					}
+		cp.printNodeRows(nm)
+	}
+	cp.printVirtualRows()
+}
+
+// printNodeRows prints a node followed by its pods and containers when they
+// are shown
+func (cp *csvPrinter) printNodeRows(nm *nodeMetric) {
+	cp.printNodeLine(nm.name, nm)
+
+	if cp.opts.ShowPods || cp.opts.ShowContainers {
+		podMetrics := nm.getSortedPodMetrics(cp.opts.SortBy)
+		for _, pm := range podMetrics {
+			cp.printPodLine(nm.name, pm)
+			if cp.opts.ShowContainers {
+				containerMetrics := pm.getSortedContainerMetrics(cp.opts.SortBy)
+				for _, containerMetric := range containerMetrics {
+					cp.printContainerLine(nm.name, pm, containerMetric)
 				}
 			}
 		}
@@ -117,7 +250,17 @@ func (cp *csvPrinter) printLine(cl *csvLine) {
 }
 
 func (cp *csvPrinter) getLineItems(cl *csvLine) []string {
-	lineItems := []string{CSVStringTerminator + cl.node + CSVStringTerminator}
+	lineItems := []string{}
+
+	if cp.cluster != "" {
+		cluster := cl.cluster
+		if cluster == "" {
+			cluster = cp.cluster
+		}
+		lineItems = append(lineItems, CSVStringTerminator+cluster+CSVStringTerminator)
+	}
+
+	lineItems = append(lineItems, CSVStringTerminator+cl.node+CSVStringTerminator)
 
 	if cp.opts.ShowContainers || cp.opts.ShowPods {
 		if cp.opts.Namespace == "" {
@@ -131,10 +274,22 @@ func (cp *csvPrinter) getLineItems(cl *csvLine) []string {
 	}
 
 	lineItems = append(lineItems, cl.cpuCapacity)
+	if cp.opts.ShowCapacity {
+		lineItems = append(lineItems, cl.cpuNodeCapacity, cl.cpuReserved)
+	}
+	if cp.opts.ShowReservedBreakdown {
+		lineItems = append(lineItems, cl.cpuKube, cl.cpuSystem)
+	}
 	if !cp.opts.HideRequests {
 		lineItems = append(lineItems, cl.cpuRequests)
 		lineItems = append(lineItems, cl.cpuRequestsPercentage)
 	}
+	if cp.opts.ShowOverhead {
+		lineItems = append(lineItems, cl.cpuOverhead)
+	}
+	if cp.opts.AvailableFormat {
+		lineItems = append(lineItems, cl.cpuAvailable)
+	}
 	if !cp.opts.HideLimits {
 		lineItems = append(lineItems, cl.cpuLimits)
 		lineItems = append(lineItems, cl.cpuLimitsPercentage)
@@ -145,11 +300,41 @@ func (cp *csvPrinter) getLineItems(cl *csvLine) []string {
 		lineItems = append(lineItems, cl.cpuUtilPercentage)
 	}
 
+	if cp.opts.ShowSparkline {
+		lineItems = append(lineItems, cl.cpuHistory)
+	}
+
+	if cp.opts.ShowVPA {
+		lineItems = append(lineItems, cl.cpuVPATarget)
+		lineItems = append(lineItems, cl.cpuVPALowerBound)
+		lineItems = append(lineItems, cl.cpuVPAUpperBound)
+	}
+
+	if cp.opts.ShowOvercommit {
+		lineItems = append(lineItems, cl.cpuOvercommit)
+	}
+
+	if cp.opts.ShowThrottling {
+		lineItems = append(lineItems, cl.cpuThrottle)
+	}
+
 	lineItems = append(lineItems, cl.memoryCapacity)
+	if cp.opts.ShowCapacity {
+		lineItems = append(lineItems, cl.memoryNodeCapacity, cl.memoryReserved)
+	}
+	if cp.opts.ShowReservedBreakdown {
+		lineItems = append(lineItems, cl.memoryKube, cl.memorySystem, cl.memoryEviction)
+	}
 	if !cp.opts.HideRequests {
 		lineItems = append(lineItems, cl.memoryRequests)
 		lineItems = append(lineItems, cl.memoryRequestsPercentage)
 	}
+	if cp.opts.ShowOverhead {
+		lineItems = append(lineItems, cl.memoryOverhead)
+	}
+	if cp.opts.AvailableFormat {
+		lineItems = append(lineItems, cl.memoryAvailable)
+	}
 	if !cp.opts.HideLimits {
 		lineItems = append(lineItems, cl.memoryLimits)
 		lineItems = append(lineItems, cl.memoryLimitsPercentage)
@@ -160,11 +345,53 @@ func (cp *csvPrinter) getLineItems(cl *csvLine) []string {
 		lineItems = append(lineItems, cl.memoryUtilPercentage)
 	}
 
+	if cp.opts.ShowSparkline {
+		lineItems = append(lineItems, cl.memoryHistory)
+	}
+
+	if cp.opts.ShowVPA {
+		lineItems = append(lineItems, cl.memoryVPATarget)
+		lineItems = append(lineItems, cl.memoryVPALowerBound)
+		lineItems = append(lineItems, cl.memoryVPAUpperBound)
+	}
+
+	if cp.opts.ShowOvercommit {
+		lineItems = append(lineItems, cl.memoryOvercommit)
+	}
+
+	if cp.opts.ShowRestarts {
+		lineItems = append(lineItems, cl.restarts, cl.lastOOMKill)
+	}
+
+	if cp.opts.ShowMetricsAge {
+		lineItems = append(lineItems, cl.utilAge)
+	}
+
+	if cp.opts.ShowResize {
+		lineItems = append(lineItems, cl.resize)
+	}
+
 	if cp.opts.ShowPodCount {
 		lineItems = append(lineItems, cl.podCountCurrent)
 		lineItems = append(lineItems, cl.podCountAllocatable)
 	}
 
+	if cp.opts.ShowCost {
+		lineItems = append(lineItems, cl.costHourly, cl.costIdle, cl.costMonthly)
+	}
+
+	if cp.opts.ShowNodeStatus {
+		lineItems = append(lineItems, cl.status, cl.roles, cl.age)
+	}
+
+	if cp.opts.ShowDisk {
+		lineItems = append(lineItems, cl.nodefsUsed, cl.nodefsUsedPercentage, cl.imagefsUsed, cl.imagefsUsedPercentage, cl.diskStatus)
+	}
+
+	if cp.opts.ShowTaints {
+		lineItems = append(lineItems, cl.taints)
+	}
+
 	if cp.opts.ShowLabels {
 		lineItems = append(lineItems, cl.labels)
 	}
@@ -173,53 +400,147 @@ func (cp *csvPrinter) getLineItems(cl *csvLine) []string {
 }
 
 func (cp *csvPrinter) printClusterLine() {
+	cp.printTotalsLine(VoidValue, cp.cm, VoidValue)
+}
+
+// printTaintedLine prints the totals of nodes with NoSchedule or NoExecute
+// taints
+func (cp *csvPrinter) printTaintedLine() {
+	if tainted := buildTaintedClusterMetric(cp.cm); tainted != nil {
+		cp.printTotalsLine(taintedTotalsName, tainted, tainted.taintedNodesString())
+	}
+}
+
+func (cp *csvPrinter) printTotalsLine(name string, cm *clusterMetric, taints string) {
+	nodefs, imagefs := cm.disk.filesystems()
 	cp.printLine(&csvLine{
-		node:                     VoidValue,
+		node:                     name,
 		namespace:                VoidValue,
 		pod:                      VoidValue,
 		container:                VoidValue,
-		cpuCapacity:              cp.cm.cpu.capacityString(),
-		cpuRequests:              cp.cm.cpu.requestActualString(),
-		cpuRequestsPercentage:    cp.cm.cpu.requestPercentageString(),
-		cpuLimits:                cp.cm.cpu.limitActualString(),
-		cpuLimitsPercentage:      cp.cm.cpu.limitPercentageString(),
-		cpuUtil:                  cp.cm.cpu.utilActualString(),
-		cpuUtilPercentage:        cp.cm.cpu.utilPercentageString(cp.opts.UtilPercent),
-		memoryCapacity:           cp.cm.memory.capacityString(),
-		memoryRequests:           cp.cm.memory.requestActualString(),
-		memoryRequestsPercentage: cp.cm.memory.requestPercentageString(),
-		memoryLimits:             cp.cm.memory.limitActualString(),
-		memoryLimitsPercentage:   cp.cm.memory.limitPercentageString(),
-		memoryUtil:               cp.cm.memory.utilActualString(),
-		memoryUtilPercentage:     cp.cm.memory.utilPercentageString(cp.opts.UtilPercent),
-		podCountCurrent:          cp.cm.podCount.podCountCurrentString(),
-		podCountAllocatable:      cp.cm.podCount.podCountAllocatableString(),
+		cpuCapacity:              cm.cpu.capacityString(cp.run),
+		cpuNodeCapacity:          cm.cpu.nodeCapacityCSVString(cp.run),
+		cpuReserved:              cm.cpu.reservedCSVString(cp.run),
+		cpuKube:                  cm.cpu.kubeReservedCSVString(cp.run),
+		cpuSystem:                cm.cpu.systemReservedCSVString(cp.run),
+		cpuRequests:              cm.cpu.requestActualString(cp.run),
+		cpuRequestsPercentage:    cm.cpu.requestPercentageString(cp.run),
+		cpuOverhead:              cm.cpu.overheadCSVString(cp.run),
+		cpuAvailable:             cm.cpu.availableString(cp.run),
+		cpuLimits:                cm.cpu.limitActualString(cp.run),
+		cpuLimitsPercentage:      cm.cpu.limitPercentageString(cp.run),
+		cpuUtil:                  cm.cpu.utilActualString(cp.run),
+		cpuUtilPercentage:        cm.cpu.utilPercentageString(cp.run, cp.opts.UtilPercent),
+		cpuHistory:               cm.cpu.historyCSVString(cp.run),
+		cpuVPATarget:             VoidValue,
+		cpuVPALowerBound:         VoidValue,
+		cpuVPAUpperBound:         VoidValue,
+		cpuOvercommit:            cm.cpu.overcommitCSVString(),
+		cpuThrottle:              VoidValue,
+		memoryCapacity:           cm.memory.capacityString(cp.run),
+		memoryNodeCapacity:       cm.memory.nodeCapacityCSVString(cp.run),
+		memoryReserved:           cm.memory.reservedCSVString(cp.run),
+		memoryKube:               cm.memory.kubeReservedCSVString(cp.run),
+		memorySystem:             cm.memory.systemReservedCSVString(cp.run),
+		memoryEviction:           cm.memory.evictionThresholdCSVString(cp.run),
+		memoryRequests:           cm.memory.requestActualString(cp.run),
+		memoryRequestsPercentage: cm.memory.requestPercentageString(cp.run),
+		memoryOverhead:           cm.memory.overheadCSVString(cp.run),
+		memoryAvailable:          cm.memory.availableString(cp.run),
+		memoryLimits:             cm.memory.limitActualString(cp.run),
+		memoryLimitsPercentage:   cm.memory.limitPercentageString(cp.run),
+		memoryUtil:               cm.memory.utilActualString(cp.run),
+		memoryUtilPercentage:     cm.memory.utilPercentageString(cp.run, cp.opts.UtilPercent),
+		memoryHistory:            cm.memory.historyCSVString(cp.run),
+		memoryVPATarget:          VoidValue,
+		memoryVPALowerBound:      VoidValue,
+		memoryVPAUpperBound:      VoidValue,
+		memoryOvercommit:         cm.memory.overcommitCSVString(),
+		restarts:                 VoidValue,
+		lastOOMKill:              VoidValue,
+		utilAge:                  cm.oldestUsage().ageSecondsString(cm.observedAt),
+		resize:                   pendingResizesString(cm.pendingResizes),
+		podCountCurrent:          cm.podCount.podCountCurrentString(),
+		podCountAllocatable:      cm.podCount.podCountAllocatableString(),
+		costHourly:               cm.cost.hourlyString(),
+		costIdle:                 cm.cost.idleString(),
+		costMonthly:              cm.cost.monthlyString(),
+		status:                   fmt.Sprintf("%q", cm.nodeStatus.statusString()),
+		roles:                    VoidValue,
+		age:                      VoidValue,
+		nodefsUsed:               nodefs.usedCSVString(cp.run),
+		nodefsUsedPercentage:     nodefs.usedPercentageString(cp.run),
+		imagefsUsed:              imagefs.usedCSVString(cp.run),
+		imagefsUsedPercentage:    imagefs.usedPercentageString(cp.run),
+		diskStatus:               fmt.Sprintf("%q", cm.disk.statusString()),
+		taints:                   taints,
 		labels:                   VoidValue,
 	})
 }
 
 func (cp *csvPrinter) printNodeLine(nodeName string, nm *nodeMetric) {
+	nodefs, imagefs := nm.disk.filesystems()
 	cp.printLine(&csvLine{
 		node:                     nodeName,
 		namespace:                VoidValue,
 		pod:                      VoidValue,
 		container:                VoidValue,
-		cpuCapacity:              nm.cpu.capacityString(),
-		cpuRequests:              nm.cpu.requestActualString(),
-		cpuRequestsPercentage:    nm.cpu.requestPercentageString(),
-		cpuLimits:                nm.cpu.limitActualString(),
-		cpuLimitsPercentage:      nm.cpu.limitPercentageString(),
-		cpuUtil:                  nm.cpu.utilActualString(),
-		cpuUtilPercentage:        nm.cpu.utilPercentageString(cp.opts.UtilPercent),
-		memoryCapacity:           nm.memory.capacityString(),
-		memoryRequests:           nm.memory.requestActualString(),
-		memoryRequestsPercentage: nm.memory.requestPercentageString(),
-		memoryLimits:             nm.memory.limitActualString(),
-		memoryLimitsPercentage:   nm.memory.limitPercentageString(),
-		memoryUtil:               nm.memory.utilActualString(),
-		memoryUtilPercentage:     nm.memory.utilPercentageString(cp.opts.UtilPercent),
+		cpuCapacity:              nm.cpu.capacityString(cp.run),
+		cpuNodeCapacity:          nm.cpu.nodeCapacityCSVString(cp.run),
+		cpuReserved:              nm.cpu.reservedCSVString(cp.run),
+		cpuKube:                  nm.cpu.kubeReservedCSVString(cp.run),
+		cpuSystem:                nm.cpu.systemReservedCSVString(cp.run),
+		cpuRequests:              nm.cpu.requestActualString(cp.run),
+		cpuRequestsPercentage:    nm.cpu.requestPercentageString(cp.run),
+		cpuOverhead:              nm.cpu.overheadCSVString(cp.run),
+		cpuAvailable:             nm.cpu.availableString(cp.run),
+		cpuLimits:                nm.cpu.limitActualString(cp.run),
+		cpuLimitsPercentage:      nm.cpu.limitPercentageString(cp.run),
+		cpuUtil:                  nm.cpu.utilActualString(cp.run),
+		cpuUtilPercentage:        nm.cpu.utilPercentageString(cp.run, cp.opts.UtilPercent),
+		cpuHistory:               nm.cpu.historyCSVString(cp.run),
+		cpuVPATarget:             VoidValue,
+		cpuVPALowerBound:         VoidValue,
+		cpuVPAUpperBound:         VoidValue,
+		cpuOvercommit:            nm.cpu.overcommitCSVString(),
+		cpuThrottle:              VoidValue,
+		memoryCapacity:           nm.memory.capacityString(cp.run),
+		memoryNodeCapacity:       nm.memory.nodeCapacityCSVString(cp.run),
+		memoryReserved:           nm.memory.reservedCSVString(cp.run),
+		memoryKube:               nm.memory.kubeReservedCSVString(cp.run),
+		memorySystem:             nm.memory.systemReservedCSVString(cp.run),
+		memoryEviction:           nm.memory.evictionThresholdCSVString(cp.run),
+		memoryRequests:           nm.memory.requestActualString(cp.run),
+		memoryRequestsPercentage: nm.memory.requestPercentageString(cp.run),
+		memoryOverhead:           nm.memory.overheadCSVString(cp.run),
+		memoryAvailable:          nm.memory.availableString(cp.run),
+		memoryLimits:             nm.memory.limitActualString(cp.run),
+		memoryLimitsPercentage:   nm.memory.limitPercentageString(cp.run),
+		memoryUtil:               nm.memory.utilActualString(cp.run),
+		memoryUtilPercentage:     nm.memory.utilPercentageString(cp.run, cp.opts.UtilPercent),
+		memoryHistory:            nm.memory.historyCSVString(cp.run),
+		memoryVPATarget:          VoidValue,
+		memoryVPALowerBound:      VoidValue,
+		memoryVPAUpperBound:      VoidValue,
+		memoryOvercommit:         nm.memory.overcommitCSVString(),
+		restarts:                 VoidValue,
+		lastOOMKill:              VoidValue,
+		utilAge:                  nm.usage.ageSecondsString(cp.cm.observedAt),
+		resize:                   pendingResizesString(nm.pendingResizes),
 		podCountCurrent:          nm.podCount.podCountCurrentString(),
 		podCountAllocatable:      nm.podCount.podCountAllocatableString(),
+		costHourly:               nm.cost.hourlyString(),
+		costIdle:                 nm.cost.idleString(),
+		costMonthly:              nm.cost.monthlyString(),
+		status:                   fmt.Sprintf("%q", nm.statusString()),
+		roles:                    fmt.Sprintf("%q", nm.rolesString()),
+		age:                      nm.ageString(cp.cm.observedAt),
+		nodefsUsed:               nodefs.usedCSVString(cp.run),
+		nodefsUsedPercentage:     nodefs.usedPercentageString(cp.run),
+		imagefsUsed:              imagefs.usedCSVString(cp.run),
+		imagefsUsedPercentage:    imagefs.usedPercentageString(cp.run),
+		diskStatus:               nm.disk.statusString(),
+		taints:                   fmt.Sprintf("%q", nm.taintsString()),
 		labels:                   fmt.Sprintf("%q", nodeLabelsString(nm.labels)), // quote the labels to avoid CSV parsing issues
 	})
 }
@@ -230,20 +551,34 @@ func (cp *csvPrinter) printPodLine(nodeName string, pm *podMetric) {
 		namespace:                pm.namespace,
 		pod:                      pm.name,
 		container:                VoidValue,
-		cpuCapacity:              pm.cpu.capacityString(),
-		cpuRequests:              pm.cpu.requestActualString(),
-		cpuRequestsPercentage:    pm.cpu.requestPercentageString(),
-		cpuLimits:                pm.cpu.limitActualString(),
-		cpuLimitsPercentage:      pm.cpu.limitPercentageString(),
-		cpuUtil:                  pm.cpu.utilActualString(),
-		cpuUtilPercentage:        pm.cpu.utilPercentageString(cp.opts.UtilPercent),
-		memoryCapacity:           pm.memory.capacityString(),
-		memoryRequests:           pm.memory.requestActualString(),
-		memoryRequestsPercentage: pm.memory.requestPercentageString(),
-		memoryLimits:             pm.memory.limitActualString(),
-		memoryLimitsPercentage:   pm.memory.limitPercentageString(),
-		memoryUtil:               pm.memory.utilActualString(),
-		memoryUtilPercentage:     pm.memory.utilPercentageString(cp.opts.UtilPercent),
+		cpuCapacity:              pm.cpu.capacityString(cp.run),
+		cpuRequests:              pm.cpu.requestActualString(cp.run),
+		cpuRequestsPercentage:    pm.cpu.requestPercentageString(cp.run),
+		cpuOverhead:              pm.cpu.overheadCSVString(cp.run),
+		cpuLimits:                pm.cpu.limitActualString(cp.run),
+		cpuLimitsPercentage:      pm.cpu.limitPercentageString(cp.run),
+		cpuUtil:                  pm.cpu.utilActualString(cp.run),
+		cpuUtilPercentage:        pm.cpu.utilPercentageString(cp.run, cp.opts.UtilPercent),
+		cpuHistory:               pm.cpu.historyCSVString(cp.run),
+		cpuVPATarget:             VoidValue,
+		cpuVPALowerBound:         VoidValue,
+		cpuVPAUpperBound:         VoidValue,
+		cpuThrottle:              throttleCSVString(pm.cpuThrottled),
+		memoryCapacity:           pm.memory.capacityString(cp.run),
+		memoryRequests:           pm.memory.requestActualString(cp.run),
+		memoryRequestsPercentage: pm.memory.requestPercentageString(cp.run),
+		memoryOverhead:           pm.memory.overheadCSVString(cp.run),
+		memoryLimits:             pm.memory.limitActualString(cp.run),
+		memoryLimitsPercentage:   pm.memory.limitPercentageString(cp.run),
+		memoryUtil:               pm.memory.utilActualString(cp.run),
+		memoryUtilPercentage:     pm.memory.utilPercentageString(cp.run, cp.opts.UtilPercent),
+		memoryHistory:            pm.memory.historyCSVString(cp.run),
+		memoryVPATarget:          VoidValue,
+		memoryVPALowerBound:      VoidValue,
+		memoryVPAUpperBound:      VoidValue,
+		restarts:                 restartsString(pm.restarts),
+		lastOOMKill:              oomKillTimestamp(pm.lastOOMKill),
+		resize:                   resizeString(pm.resize),
 	})
 }
 
@@ -252,20 +587,34 @@ func (cp *csvPrinter) printContainerLine(nodeName string, pm *podMetric, cm *con
 		node:                     nodeName,
 		namespace:                pm.namespace,
 		pod:                      pm.name,
-		container:                cm.name,
-		cpuCapacity:              cm.cpu.capacityString(),
-		cpuRequests:              cm.cpu.requestActualString(),
-		cpuRequestsPercentage:    cm.cpu.requestPercentageString(),
-		cpuLimits:                cm.cpu.limitActualString(),
-		cpuLimitsPercentage:      cm.cpu.limitPercentageString(),
-		cpuUtil:                  cm.cpu.utilActualString(),
-		cpuUtilPercentage:        cm.cpu.utilPercentageString(cp.opts.UtilPercent),
-		memoryCapacity:           cm.memory.capacityString(),
-		memoryRequests:           cm.memory.requestActualString(),
-		memoryRequestsPercentage: cm.memory.requestPercentageString(),
-		memoryLimits:             cm.memory.limitActualString(),
-		memoryLimitsPercentage:   cm.memory.limitPercentageString(),
-		memoryUtil:               cm.memory.utilActualString(),
-		memoryUtilPercentage:     cm.memory.utilPercentageString(cp.opts.UtilPercent),
+		container:                cm.displayName(),
+		cpuCapacity:              cm.cpu.capacityString(cp.run),
+		cpuRequests:              cm.cpu.requestActualString(cp.run),
+		cpuRequestsPercentage:    cm.cpu.requestPercentageString(cp.run),
+		cpuOverhead:              VoidValue,
+		cpuLimits:                cm.cpu.limitActualString(cp.run),
+		cpuLimitsPercentage:      cm.cpu.limitPercentageString(cp.run),
+		cpuUtil:                  cm.cpu.utilActualString(cp.run),
+		cpuUtilPercentage:        cm.cpu.utilPercentageString(cp.run, cp.opts.UtilPercent),
+		cpuHistory:               cm.cpu.historyCSVString(cp.run),
+		cpuVPATarget:             cm.cpu.vpaTargetString(cp.run),
+		cpuVPALowerBound:         cm.cpu.vpaLowerBoundString(cp.run),
+		cpuVPAUpperBound:         cm.cpu.vpaUpperBoundString(cp.run),
+		cpuThrottle:              throttleCSVString(cm.cpuThrottled),
+		memoryCapacity:           cm.memory.capacityString(cp.run),
+		memoryRequests:           cm.memory.requestActualString(cp.run),
+		memoryRequestsPercentage: cm.memory.requestPercentageString(cp.run),
+		memoryOverhead:           VoidValue,
+		memoryLimits:             cm.memory.limitActualString(cp.run),
+		memoryLimitsPercentage:   cm.memory.limitPercentageString(cp.run),
+		memoryUtil:               cm.memory.utilActualString(cp.run),
+		memoryUtilPercentage:     cm.memory.utilPercentageString(cp.run, cp.opts.UtilPercent),
+		memoryHistory:            cm.memory.historyCSVString(cp.run),
+		memoryVPATarget:          cm.memory.vpaTargetString(cp.run),
+		memoryVPALowerBound:      cm.memory.vpaLowerBoundString(cp.run),
+		memoryVPAUpperBound:      cm.memory.vpaUpperBoundString(cp.run),
+		restarts:                 restartsString(cm.restarts),
+		lastOOMKill:              oomKillTimestamp(cm.lastOOMKill),
+		resize:                   resizeString(cm.resize),
 	})
 }
@@ -0,0 +1,268 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	v1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+)
+
+const (
+	// DefaultCustomMetricsCPU is the CPU usage metric prometheus-adapter
+	// serves with its default rules, the rate of
+	// container_cpu_usage_seconds_total in cores
+	DefaultCustomMetricsCPU = "cpu_usage"
+	// DefaultCustomMetricsMemory is the working set metric
+	// prometheus-adapter serves with its default rules
+	DefaultCustomMetricsMemory = "memory_working_set_bytes"
+
+	customMetricsAPI   = "/apis/custom.metrics.k8s.io/v1beta1"
+	externalMetricsAPI = "/apis/external.metrics.k8s.io/v1beta1"
+)
+
+// customMetricValueList is the MetricValueList of custom.metrics.k8s.io,
+// with only the fields read here
+type customMetricValueList struct {
+	Items []struct {
+		DescribedObject struct {
+			Kind      string `json:"kind"`
+			Namespace string `json:"namespace"`
+			Name      string `json:"name"`
+		} `json:"describedObject"`
+		Timestamp     metav1.Time       `json:"timestamp"`
+		WindowSeconds *int64            `json:"windowSeconds"`
+		Value         resource.Quantity `json:"value"`
+	} `json:"items"`
+}
+
+// externalMetricValueList is the ExternalMetricValueList of
+// external.metrics.k8s.io, with only the fields read here
+type externalMetricValueList struct {
+	Items []struct {
+		MetricLabels  map[string]string `json:"metricLabels"`
+		Timestamp     metav1.Time       `json:"timestamp"`
+		WindowSeconds *int64            `json:"window"`
+		Value         resource.Quantity `json:"value"`
+	} `json:"items"`
+}
+
+// customMetricsSample is the newest timestamp and the window of the values
+// read from a metrics API
+type customMetricsSample struct {
+	timestamp time.Time
+	window    time.Duration
+}
+
+func (s *customMetricsSample) observe(timestamp metav1.Time, windowSeconds *int64) {
+	if timestamp.After(s.timestamp) {
+		s.timestamp = timestamp.Time
+	}
+	if windowSeconds != nil {
+		s.window = time.Duration(*windowSeconds) * time.Second
+	}
+}
+
+// getCustomMetrics reads pod and node utilization from the
+// custom.metrics.k8s.io API, as served by prometheus-adapter. Usage is read
+// for whole pods, so container rows are left without utilization. Nodes are
+// only read when the adapter has a rule for them, and their usage is summed
+// from their pods otherwise.
+func getCustomMetrics(ctx context.Context, run *reportRun, clientset kubernetes.Interface, opts Options) (*v1beta1.PodMetricsList, *v1beta1.NodeMetricsList, error) {
+	start := time.Now()
+	namespace := "*"
+	if opts.Namespace != "" {
+		namespace = opts.Namespace
+	}
+
+	sample := &customMetricsSample{}
+	responses := []*prometheusResponse{}
+	for _, metric := range []string{opts.CustomMetricsCPU, opts.CustomMetricsMemory} {
+		path := fmt.Sprintf("%s/namespaces/%s/pods/*/%s", customMetricsAPI, url.PathEscape(namespace), url.PathEscape(metric))
+		body, err := clientset.CoreV1().RESTClient().Get().AbsPath(path).DoRaw(ctx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("reading %s from the custom metrics API: %w", metric, err)
+		}
+		resp, err := parseCustomMetrics(body, metric == opts.CustomMetricsCPU, sample)
+		if err != nil {
+			return nil, nil, fmt.Errorf("parsing %s from the custom metrics API: %w", metric, err)
+		}
+		responses = append(responses, resp)
+	}
+	pmList := buildPodMetricsList(run, responses[0], responses[1])
+
+	var nmList *v1beta1.NodeMetricsList
+	nodeResponses := []*prometheusResponse{}
+	for _, metric := range []string{opts.CustomMetricsCPU, opts.CustomMetricsMemory} {
+		path := fmt.Sprintf("%s/nodes/*/%s", customMetricsAPI, url.PathEscape(metric))
+		body, err := clientset.CoreV1().RESTClient().Get().AbsPath(path).DoRaw(ctx)
+		if apierrors.IsNotFound(err) {
+			run.logDebugf("The custom metrics API has no %s for nodes, summing the usage of their pods", metric)
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("reading %s of nodes from the custom metrics API: %w", metric, err)
+		}
+		resp, err := parseCustomMetrics(body, metric == opts.CustomMetricsCPU, sample)
+		if err != nil {
+			return nil, nil, fmt.Errorf("parsing %s of nodes from the custom metrics API: %w", metric, err)
+		}
+		nodeResponses = append(nodeResponses, resp)
+	}
+	if len(nodeResponses) == 2 {
+		nmList = buildNodeMetricsList(run, nodeResponses[0], nodeResponses[1])
+	}
+
+	sample.apply(pmList, nmList)
+	run.logTiming(start, "Read %d pod metrics from the custom metrics API", len(pmList.Items))
+	return pmList, nmList, nil
+}
+
+// getExternalMetrics reads pod and node utilization from the
+// external.metrics.k8s.io API. Each value is matched to a pod by its
+// namespace and pod labels, or to a node by its node label, so the metrics
+// must keep those labels and not be namespaced by the adapter for pods of
+// every namespace to be returned.
+func getExternalMetrics(ctx context.Context, run *reportRun, clientset kubernetes.Interface, opts Options) (*v1beta1.PodMetricsList, *v1beta1.NodeMetricsList, error) {
+	start := time.Now()
+	namespace := "default"
+	if opts.Namespace != "" {
+		namespace = opts.Namespace
+	}
+
+	sample := &customMetricsSample{}
+	pods := []*prometheusResponse{}
+	nodes := []*prometheusResponse{}
+	for _, metric := range []string{opts.CustomMetricsCPU, opts.CustomMetricsMemory} {
+		path := fmt.Sprintf("%s/namespaces/%s/%s", externalMetricsAPI, url.PathEscape(namespace), url.PathEscape(metric))
+		body, err := clientset.CoreV1().RESTClient().Get().AbsPath(path).DoRaw(ctx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("reading %s from the external metrics API: %w", metric, err)
+		}
+		podResp, nodeResp, err := parseExternalMetrics(body, metric == opts.CustomMetricsCPU, sample)
+		if err != nil {
+			return nil, nil, fmt.Errorf("parsing %s from the external metrics API: %w", metric, err)
+		}
+		pods = append(pods, podResp)
+		nodes = append(nodes, nodeResp)
+	}
+
+	pmList := buildPodMetricsList(run, pods[0], pods[1])
+	var nmList *v1beta1.NodeMetricsList
+	if len(nodes[0].Data.Result) > 0 || len(nodes[1].Data.Result) > 0 {
+		nmList = buildNodeMetricsList(run, nodes[0], nodes[1])
+	}
+
+	sample.apply(pmList, nmList)
+	run.logTiming(start, "Read %d pod metrics from the external metrics API", len(pmList.Items))
+	return pmList, nmList, nil
+}
+
+// parseCustomMetrics converts a MetricValueList of pods or nodes to the
+// shape of a Prometheus instant query, so that it is grouped into pod and
+// node metrics like Prometheus results. CPU values are in cores.
+func parseCustomMetrics(body []byte, cpu bool, sample *customMetricsSample) (*prometheusResponse, error) {
+	var list customMetricValueList
+	if err := json.Unmarshal(body, &list); err != nil {
+		return nil, err
+	}
+
+	resp := &prometheusResponse{Status: "success"}
+	for _, item := range list.Items {
+		metric := map[string]string{}
+		switch item.DescribedObject.Kind {
+		case "Pod":
+			metric["namespace"] = item.DescribedObject.Namespace
+			metric["pod"] = item.DescribedObject.Name
+			metric["container"] = ""
+		case "Node":
+			metric["node"] = item.DescribedObject.Name
+		default:
+			continue
+		}
+		sample.observe(item.Timestamp, item.WindowSeconds)
+		resp.Data.Result = append(resp.Data.Result, customMetricsResult(metric, item.Value, cpu))
+	}
+	return resp, nil
+}
+
+// parseExternalMetrics splits an ExternalMetricValueList into the values
+// of pods and those of nodes, each in the shape of a Prometheus instant
+// query. A container label is kept, so container rows get utilization when
+// the metric is per container.
+func parseExternalMetrics(body []byte, cpu bool, sample *customMetricsSample) (*prometheusResponse, *prometheusResponse, error) {
+	var list externalMetricValueList
+	if err := json.Unmarshal(body, &list); err != nil {
+		return nil, nil, err
+	}
+
+	pods := &prometheusResponse{Status: "success"}
+	nodes := &prometheusResponse{Status: "success"}
+	for _, item := range list.Items {
+		labels := item.MetricLabels
+		switch {
+		case labels["namespace"] != "" && labels["pod"] != "":
+			metric := map[string]string{
+				"namespace": labels["namespace"],
+				"pod":       labels["pod"],
+				"container": labels["container"],
+			}
+			pods.Data.Result = append(pods.Data.Result, customMetricsResult(metric, item.Value, cpu))
+		case labels["node"] != "":
+			nodes.Data.Result = append(nodes.Data.Result, customMetricsResult(map[string]string{"node": labels["node"]}, item.Value, cpu))
+		default:
+			continue
+		}
+		sample.observe(item.Timestamp, item.WindowSeconds)
+	}
+	return pods, nodes, nil
+}
+
+func customMetricsResult(metric map[string]string, value resource.Quantity, cpu bool) prometheusResult {
+	v := strconv.FormatInt(value.Value(), 10)
+	if cpu {
+		v = strconv.FormatFloat(float64(value.MilliValue())/1000, 'f', -1, 64)
+	}
+	return prometheusResult{Metric: metric, Value: []interface{}{0.0, v}}
+}
+
+// apply sets the timestamp and window of the metrics to those read from
+// the API, or the current time when it returned none
+func (s *customMetricsSample) apply(pmList *v1beta1.PodMetricsList, nmList *v1beta1.NodeMetricsList) {
+	timestamp := s.timestamp
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+	for i := range pmList.Items {
+		pmList.Items[i].Timestamp = metav1.NewTime(timestamp)
+		pmList.Items[i].Window.Duration = s.window
+	}
+	if nmList == nil {
+		return
+	}
+	for i := range nmList.Items {
+		nmList.Items[i].Timestamp = metav1.NewTime(timestamp)
+		nmList.Items[i].Window.Duration = s.window
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestParseCustomMetrics(t *testing.T) {
+	run := newTestRun(nil)
+	cpuBody := []byte(`{"kind":"MetricValueList","apiVersion":"custom.metrics.k8s.io/v1beta1","items":[
+		{"describedObject":{"kind":"Pod","namespace":"default","name":"web","apiVersion":"/v1"},"metricName":"cpu_usage","timestamp":"2026-03-01T12:00:00Z","windowSeconds":60,"value":"250m"},
+		{"describedObject":{"kind":"Service","namespace":"default","name":"web","apiVersion":"/v1"},"metricName":"cpu_usage","timestamp":"2026-03-01T12:00:00Z","value":"1"}
+	]}`)
+	memoryBody := []byte(`{"kind":"MetricValueList","apiVersion":"custom.metrics.k8s.io/v1beta1","items":[
+		{"describedObject":{"kind":"Pod","namespace":"default","name":"web","apiVersion":"/v1"},"metricName":"memory_working_set_bytes","timestamp":"2026-03-01T12:00:30Z","value":"128Mi"}
+	]}`)
+
+	sample := &customMetricsSample{}
+	cpuResp, err := parseCustomMetrics(cpuBody, true, sample)
+	require.NoError(t, err)
+	memoryResp, err := parseCustomMetrics(memoryBody, false, sample)
+	require.NoError(t, err)
+	require.Len(t, cpuResp.Data.Result, 1)
+
+	pmList := buildPodMetricsList(run, cpuResp, memoryResp)
+	sample.apply(pmList, nil)
+	require.Len(t, pmList.Items, 1)
+	pm := pmList.Items[0]
+	assert.Equal(t, "default", pm.Namespace)
+	assert.Equal(t, "web", pm.Name)
+	assert.Equal(t, time.Date(2026, 3, 1, 12, 0, 30, 0, time.UTC), pm.Timestamp.UTC())
+	assert.Equal(t, time.Minute, pm.Window.Duration)
+	require.Len(t, pm.Containers, 1)
+	assert.Equal(t, "", pm.Containers[0].Name)
+	assert.Equal(t, int64(250), pm.Containers[0].Usage.Cpu().MilliValue())
+	memory := resource.MustParse("128Mi")
+	assert.Equal(t, memory.Value(), pm.Containers[0].Usage.Memory().Value())
+
+	_, err = parseCustomMetrics([]byte("not json"), true, sample)
+	assert.Error(t, err)
+}
+
+func TestParseExternalMetrics(t *testing.T) {
+	run := newTestRun(nil)
+	body := []byte(`{"kind":"ExternalMetricValueList","apiVersion":"external.metrics.k8s.io/v1beta1","items":[
+		{"metricName":"cpu_usage","metricLabels":{"namespace":"default","pod":"web","container":"app"},"timestamp":"2026-03-01T12:00:00Z","window":30,"value":"1500m"},
+		{"metricName":"cpu_usage","metricLabels":{"node":"mynode"},"timestamp":"2026-03-01T12:00:00Z","value":"2"},
+		{"metricName":"cpu_usage","metricLabels":{"job":"kubelet"},"timestamp":"2026-03-01T12:00:00Z","value":"9"}
+	]}`)
+
+	sample := &customMetricsSample{}
+	pods, nodes, err := parseExternalMetrics(body, true, sample)
+	require.NoError(t, err)
+	require.Len(t, pods.Data.Result, 1)
+	require.Len(t, nodes.Data.Result, 1)
+	assert.Equal(t, map[string]string{"namespace": "default", "pod": "web", "container": "app"}, pods.Data.Result[0].Metric)
+	assert.Equal(t, "1.5", pods.Data.Result[0].Value[1])
+	assert.Equal(t, 30*time.Second, sample.window)
+
+	nmList := buildNodeMetricsList(run, nodes, &prometheusResponse{})
+	require.Len(t, nmList.Items, 1)
+	assert.Equal(t, "mynode", nmList.Items[0].Name)
+	cpu := nmList.Items[0].Usage[corev1.ResourceCPU]
+	assert.Equal(t, int64(2000), cpu.MilliValue())
+}
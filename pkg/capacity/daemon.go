@@ -0,0 +1,144 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"time"
+)
+
+// MinInterval is the shortest --interval, which keeps a daemon from
+// listing every pod in the cluster more than once a minute
+const MinInterval = time.Minute
+
+// uploadTimeFormat names the folder each run is uploaded to, so that runs
+// sort by time in a bucket listing
+const uploadTimeFormat = "20060102T150405Z"
+
+// RunDaemon runs FetchAndPrint every --interval, or once when only
+// --upload is set, and uploads the snapshot and report of each run to the
+// --upload bucket. A run that fails to reach the cluster stops the daemon
+// with the error of a single run, leaving restarts to the pod or job running
+// it.
+func RunDaemon(opts Options) error {
+	run := newRun(opts)
+
+	var dest *uploadDestination
+	if opts.Upload != "" {
+		var err error
+		dest, err = parseUploadDestination(opts.Upload)
+		if err != nil {
+			return fmt.Errorf("Error: %v", err)
+		}
+	}
+
+	for {
+		start := time.Now()
+		var err error
+		if dest == nil {
+			err = fetchAndPrint(run.withOutput(opts), opts)
+		} else {
+			err = runAndUpload(run, dest, start, opts)
+		}
+		if err != nil {
+			return err
+		}
+		if opts.Interval <= 0 {
+			return nil
+		}
+
+		next := start.Add(opts.Interval)
+		run.logInfof("Next run at %s", next.Format(time.RFC3339))
+		time.Sleep(time.Until(next))
+	}
+}
+
+// runAndUpload prints the report and saves the snapshot of one run to a
+// temporary directory, and uploads both under
+// <prefix>/<context>/<time of the run>/
+func runAndUpload(run *reportRun, dest *uploadDestination, start time.Time, opts Options) error {
+	dir, err := os.MkdirTemp("", "kube-capacity-")
+	if err != nil {
+		return fmt.Errorf("Error creating temporary directory: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := []string{reportFileName(opts.OutputFormat)}
+	opts.OutputFile = filepath.Join(dir, files[0])
+	// kube-state-metrics pods and nodes can not be captured in a snapshot
+	if opts.SpecSource != KubeStateMetricsSpecSource {
+		files = append(files, "snapshot.json.gz")
+		opts.SnapshotOut = filepath.Join(dir, files[1])
+	}
+	if err := fetchAndPrint(run.withOutput(opts), opts); err != nil {
+		return err
+	}
+
+	contextName, err := clusterName(opts)
+	if err != nil {
+		return fmt.Errorf("Error reading Kubernetes config: %v", err)
+	}
+	folder := uploadFolder(contextName, start)
+	for _, name := range files {
+		body, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			run.logErrorf("Error reading %s: %v", name, err)
+			continue
+		}
+		if err := dest.upload(path.Join(folder, name), body, opts); err != nil {
+			// The next run uploads again, so a bucket that is briefly
+			// unreachable does not stop the daemon
+			run.logErrorf("Error uploading %s to %s: %v", name, dest, err)
+			continue
+		}
+		run.logInfof("Uploaded %s to %s", name, dest.key(path.Join(folder, name)))
+	}
+	return nil
+}
+
+// uploadFolderChars are the characters of a context name that are replaced
+// in upload folders. EKS contexts are named by cluster ARN, whose "/" would
+// add a folder and whose ":" not every tool accepts in an object key.
+var uploadFolderChars = regexp.MustCompile(`[^a-zA-Z0-9._-]`)
+
+// uploadFolder is the folder under the --upload prefix the files of a run
+// are uploaded to
+func uploadFolder(contextName string, start time.Time) string {
+	return path.Join(uploadFolderChars.ReplaceAllString(contextName, "_"), start.UTC().Format(uploadTimeFormat))
+}
+
+// reportFileName returns the name of the uploaded report with the
+// extension of the output format
+func reportFileName(outputFormat string) string {
+	switch outputFormat {
+	case CSVOutput:
+		return "report.csv"
+	case TSVOutput:
+		return "report.tsv"
+	case JSONOutput:
+		return "report.json"
+	case YAMLOutput:
+		return "report.yaml"
+	case HTMLOutput:
+		return "report.html"
+	case SVGOutput:
+		return "report.svg"
+	}
+	return "report.txt"
+}
@@ -0,0 +1,238 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// daemonSetNodeMetric splits the requests and utilization of a node between
+// DaemonSet pods and all other workloads
+type daemonSetNodeMetric struct {
+	name          string
+	daemonSetPods int64
+	cpu           *daemonSetSplit
+	memory        *daemonSetSplit
+}
+
+type daemonSetSplit struct {
+	daemonSet *resourceMetric
+	workload  *resourceMetric
+}
+
+type listDaemonSetOverhead struct {
+	Nodes         []*listDaemonSetNode `json:"nodes"`
+	ClusterTotals *listDaemonSetNode   `json:"clusterTotals"`
+}
+
+type listDaemonSetNode struct {
+	Name          string                 `json:"name,omitempty"`
+	DaemonSetPods int64                  `json:"daemonSetPods"`
+	CPU           *listDaemonSetResource `json:"cpu"`
+	Memory        *listDaemonSetResource `json:"memory"`
+}
+
+type listDaemonSetResource struct {
+	Allocatable          string `json:"allocatable"`
+	DaemonSetRequests    string `json:"daemonSetRequests"`
+	DaemonSetRequestsPct string `json:"daemonSetRequestsPercent"`
+	WorkloadRequests     string `json:"workloadRequests"`
+	WorkloadRequestsPct  string `json:"workloadRequestsPercent"`
+	Usable               string `json:"usable"`
+	DaemonSetUtilization string `json:"daemonSetUtilization,omitempty"`
+	WorkloadUtilization  string `json:"workloadUtilization,omitempty"`
+}
+
+// isDaemonSetPod returns true for pods controlled by a DaemonSet
+func isDaemonSetPod(pod *corev1.Pod) bool {
+	owner := metav1.GetControllerOf(pod)
+	return owner != nil && owner.Kind == "DaemonSet"
+}
+
+// excludeDaemonSetPods removes DaemonSet pods from the list so that they
+// count toward no requests, limits, or pod counts, and returns how many
+// were removed
+func excludeDaemonSetPods(podList *corev1.PodList) int {
+	pods := []corev1.Pod{}
+	for i := range podList.Items {
+		if !isDaemonSetPod(&podList.Items[i]) {
+			pods = append(pods, podList.Items[i])
+		}
+	}
+	excluded := len(podList.Items) - len(pods)
+	podList.Items = pods
+	return excluded
+}
+
+func newDaemonSetNodeMetric(name string, cpu, memory *resourceMetric) *daemonSetNodeMetric {
+	return &daemonSetNodeMetric{
+		name:   name,
+		cpu:    newDaemonSetSplit(cpu),
+		memory: newDaemonSetSplit(memory),
+	}
+}
+
+func newDaemonSetSplit(rm *resourceMetric) *daemonSetSplit {
+	return &daemonSetSplit{
+		daemonSet: &resourceMetric{resourceType: rm.resourceType, allocatable: rm.allocatable},
+		workload:  &resourceMetric{resourceType: rm.resourceType, allocatable: rm.allocatable},
+	}
+}
+
+// buildDaemonSetMetrics splits each node between DaemonSet pods and other
+// workloads, returning the cluster totals followed by each node
+func buildDaemonSetMetrics(cm *clusterMetric, sortBy string) (*daemonSetNodeMetric, []*daemonSetNodeMetric) {
+	totals := newDaemonSetNodeMetric("", &resourceMetric{resourceType: "cpu"}, &resourceMetric{resourceType: "memory"})
+
+	nodes := []*daemonSetNodeMetric{}
+	for _, nm := range cm.getSortedNodeMetrics(sortBy) {
+		dm := newDaemonSetNodeMetric(nm.name, nm.cpu, nm.memory)
+		for _, pm := range nm.podMetrics {
+			if pm.daemonSet {
+				dm.daemonSetPods++
+			}
+			dm.cpu.add(pm.cpu, pm.daemonSet)
+			dm.memory.add(pm.memory, pm.daemonSet)
+		}
+
+		totals.daemonSetPods += dm.daemonSetPods
+		totals.cpu.addSplit(dm.cpu)
+		totals.memory.addSplit(dm.memory)
+		nodes = append(nodes, dm)
+	}
+
+	return totals, nodes
+}
+
+func (s *daemonSetSplit) add(rm *resourceMetric, daemonSet bool) {
+	target := s.workload
+	if daemonSet {
+		target = s.daemonSet
+	}
+	target.request.Add(rm.request)
+	target.utilization.Add(rm.utilization)
+}
+
+func (s *daemonSetSplit) addSplit(other *daemonSetSplit) {
+	s.daemonSet.addMetric(other.daemonSet)
+	s.workload.addMetric(other.workload)
+}
+
+func printDaemonSetOverhead(run *reportRun, cm *clusterMetric, opts Options) error {
+	dp := &daemonSetPrinter{run: run, opts: opts}
+	dp.totals, dp.nodes = buildDaemonSetMetrics(cm, opts.SortBy)
+	return dp.Print(opts.OutputFormat)
+}
+
+type daemonSetPrinter struct {
+	run    *reportRun
+	totals *daemonSetNodeMetric
+	nodes  []*daemonSetNodeMetric
+	opts   Options
+}
+
+func (dp *daemonSetPrinter) Print(outputType string) error {
+	switch outputType {
+	case JSONOutput, YAMLOutput:
+		printListOutput(dp.run, DaemonSetOverheadReportKind, dp.buildListDaemonSetOverhead(), outputType)
+	case TableOutput:
+		w := tabwriter.NewWriter(dp.run.output, 0, 8, 2, ' ', 0)
+		dp.printTable(w, "\t ")
+		if err := w.Flush(); err != nil {
+			dp.run.logErrorf("Error writing to table: %s", err)
+		}
+	case CSVOutput:
+		dp.printTable(dp.run.output, ",")
+	case TSVOutput:
+		dp.printTable(dp.run.output, "\t")
+	default:
+		return fmt.Errorf("Called with an unsupported output type: %s", outputType)
+	}
+	return nil
+}
+
+func (dp *daemonSetPrinter) printTable(w io.Writer, separator string) {
+	headers := []string{"NODE", "DAEMONSET PODS"}
+	for _, prefix := range []string{"CPU", "MEMORY"} {
+		headers = append(headers, prefix+" DAEMONSET", prefix+" WORKLOADS", prefix+" USABLE")
+		if dp.opts.ShowUtil {
+			headers = append(headers, prefix+" DAEMONSET UTIL", prefix+" WORKLOAD UTIL")
+		}
+	}
+	_, _ = fmt.Fprintln(w, strings.Join(headers, separator))
+
+	list := dp.buildListDaemonSetOverhead()
+	list.ClusterTotals.Name = VoidValue
+	for _, ln := range append([]*listDaemonSetNode{list.ClusterTotals}, list.Nodes...) {
+		items := []string{ln.Name, fmt.Sprintf("%d", ln.DaemonSetPods)}
+		for _, r := range []*listDaemonSetResource{ln.CPU, ln.Memory} {
+			items = append(items,
+				fmt.Sprintf("%s (%s)", r.DaemonSetRequests, r.DaemonSetRequestsPct),
+				fmt.Sprintf("%s (%s)", r.WorkloadRequests, r.WorkloadRequestsPct),
+				r.Usable)
+			if dp.opts.ShowUtil {
+				items = append(items, r.DaemonSetUtilization, r.WorkloadUtilization)
+			}
+		}
+		_, _ = fmt.Fprintln(w, strings.Join(items, separator))
+	}
+}
+
+func (dp *daemonSetPrinter) buildListDaemonSetOverhead() *listDaemonSetOverhead {
+	out := &listDaemonSetOverhead{
+		Nodes:         []*listDaemonSetNode{},
+		ClusterTotals: dp.listDaemonSetNode(dp.totals),
+	}
+	for _, dm := range dp.nodes {
+		out.Nodes = append(out.Nodes, dp.listDaemonSetNode(dm))
+	}
+	return out
+}
+
+func (dp *daemonSetPrinter) listDaemonSetNode(dm *daemonSetNodeMetric) *listDaemonSetNode {
+	return &listDaemonSetNode{
+		Name:          dm.name,
+		DaemonSetPods: dm.daemonSetPods,
+		CPU:           dp.listDaemonSetResource(dm.cpu),
+		Memory:        dp.listDaemonSetResource(dm.memory),
+	}
+}
+
+func (dp *daemonSetPrinter) listDaemonSetResource(s *daemonSetSplit) *listDaemonSetResource {
+	valueCalculator := s.daemonSet.valueFunction(dp.run)
+
+	usable := s.daemonSet.allocatable.DeepCopy()
+	usable.Sub(s.daemonSet.request)
+
+	out := &listDaemonSetResource{
+		Allocatable:          valueCalculator(s.daemonSet.allocatable),
+		DaemonSetRequests:    valueCalculator(s.daemonSet.request),
+		DaemonSetRequestsPct: percentString(dp.run, s.daemonSet.request, s.daemonSet.allocatable),
+		WorkloadRequests:     valueCalculator(s.workload.request),
+		WorkloadRequestsPct:  percentString(dp.run, s.workload.request, s.workload.allocatable),
+		Usable:               valueCalculator(usable),
+	}
+	if dp.opts.ShowUtil {
+		out.DaemonSetUtilization = valueCalculator(s.daemonSet.utilization)
+		out.WorkloadUtilization = valueCalculator(s.workload.utilization)
+	}
+	return out
+}
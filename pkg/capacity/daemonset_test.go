@@ -0,0 +1,101 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDaemonSetOverhead(t *testing.T) {
+	run := newTestRun(nil)
+	n := node("mynode", map[string]string{}, false)
+	n.Status.Allocatable = corev1.ResourceList{
+		"cpu":    resource.MustParse("2"),
+		"memory": resource.MustParse("4Gi"),
+	}
+
+	requests := func(p *corev1.Pod, cpu, memory string) {
+		p.Spec.Containers = []corev1.Container{{
+			Name: "app",
+			Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{
+				"cpu":    resource.MustParse(cpu),
+				"memory": resource.MustParse(memory),
+			}},
+		}}
+	}
+
+	controller := true
+	agent := pod("mynode", "kube-system", "agent", map[string]string{})
+	agent.OwnerReferences = []metav1.OwnerReference{{Kind: "DaemonSet", Name: "agent", Controller: &controller}}
+	requests(agent, "200m", "512Mi")
+
+	web := pod("mynode", "default", "web", map[string]string{})
+	web.OwnerReferences = []metav1.OwnerReference{{Kind: "ReplicaSet", Name: "web", Controller: &controller}}
+	requests(web, "600m", "1Gi")
+
+	assert.True(t, isDaemonSetPod(agent))
+	assert.False(t, isDaemonSetPod(web))
+
+	cm := buildClusterMetric(
+		&corev1.PodList{Items: []corev1.Pod{*agent, *web}}, nil,
+		&corev1.NodeList{Items: []corev1.Node{*n}}, nil)
+
+	dp := &daemonSetPrinter{run: run}
+	dp.totals, dp.nodes = buildDaemonSetMetrics(&cm, "name")
+	list := dp.buildListDaemonSetOverhead()
+
+	expected := &listDaemonSetNode{
+		Name:          "mynode",
+		DaemonSetPods: 1,
+		CPU: &listDaemonSetResource{
+			Allocatable:          "2000m",
+			DaemonSetRequests:    "200m",
+			DaemonSetRequestsPct: "10%",
+			WorkloadRequests:     "600m",
+			WorkloadRequestsPct:  "30%",
+			Usable:               "1800m",
+		},
+		Memory: &listDaemonSetResource{
+			Allocatable:          "4096Mi",
+			DaemonSetRequests:    "512Mi",
+			DaemonSetRequestsPct: "12%",
+			WorkloadRequests:     "1024Mi",
+			WorkloadRequestsPct:  "25%",
+			Usable:               "3584Mi",
+		},
+	}
+	assert.Equal(t, []*listDaemonSetNode{expected}, list.Nodes)
+
+	expected.Name = ""
+	assert.Equal(t, expected, list.ClusterTotals)
+}
+
+func TestExcludeDaemonSetPods(t *testing.T) {
+	controller := true
+	agent := pod("mynode", "kube-system", "agent", map[string]string{})
+	agent.OwnerReferences = []metav1.OwnerReference{{Kind: "DaemonSet", Name: "agent", Controller: &controller}}
+	web := pod("mynode", "default", "web", map[string]string{})
+	web.OwnerReferences = []metav1.OwnerReference{{Kind: "ReplicaSet", Name: "web", Controller: &controller}}
+	standalone := pod("mynode", "default", "standalone", map[string]string{})
+
+	podList := &corev1.PodList{Items: []corev1.Pod{*agent, *web, *standalone}}
+	assert.Equal(t, 1, excludeDaemonSetPods(podList))
+	assert.Equal(t, []string{"default/web", "default/standalone"}, listPods(podList))
+}
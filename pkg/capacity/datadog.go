@@ -0,0 +1,272 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	v1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+)
+
+// DefaultDatadogSite is the Datadog site queried when neither --datadog-site
+// nor DD_SITE is set
+const DefaultDatadogSite = "datadoghq.com"
+
+// DefaultDatadogWindow is how long Datadog metrics are averaged over
+const DefaultDatadogWindow = 15 * time.Minute
+
+// Datadog reports kubernetes.cpu.usage.total in nanocores
+const datadogNanoCores = 1e9
+
+// datadogTags maps the tags of the Datadog Agent's Kubernetes metrics to the
+// Prometheus labels that buildPodMetricsList and buildNodeMetricsList read
+var datadogTags = map[string]string{
+	"kube_namespace":      "namespace",
+	"pod_name":            "pod",
+	"kube_container_name": "container",
+	"kube_node":           "node",
+}
+
+type datadogResponse struct {
+	Status string          `json:"status"`
+	Error  string          `json:"error"`
+	Series []datadogSeries `json:"series"`
+}
+
+type datadogSeries struct {
+	TagSet []string `json:"tag_set"`
+	// Each point is [milliseconds, value], the value is null for gaps
+	Pointlist [][]*float64 `json:"pointlist"`
+}
+
+func datadogContainerQuery(metric, scope, aggregation string) string {
+	return fmt.Sprintf("sum:%s{%s} by {kube_namespace,pod_name,kube_container_name}%s", metric, scope, datadogRollup(aggregation))
+}
+
+func datadogNodeQuery(metric, scope, aggregation string) string {
+	return fmt.Sprintf("sum:%s{%s} by {kube_node}%s", metric, scope, datadogRollup(aggregation))
+}
+
+// datadogRollup keeps the peaks of long windows, which Datadog otherwise
+// averages into fewer points
+func datadogRollup(aggregation string) string {
+	if aggregation == MaxUsageAggregation {
+		return ".rollup(max)"
+	}
+	return ""
+}
+
+// datadogConfig is how to reach the Datadog API, from flags or the
+// environment variables the Datadog tools use
+type datadogConfig struct {
+	baseURL string
+	apiKey  string
+	appKey  string
+}
+
+func newDatadogConfig(opts Options) (*datadogConfig, error) {
+	cfg := &datadogConfig{
+		apiKey: firstNonEmpty(opts.DatadogAPIKey, os.Getenv("DD_API_KEY")),
+		appKey: firstNonEmpty(opts.DatadogAppKey, os.Getenv("DD_APP_KEY")),
+	}
+	if cfg.apiKey == "" || cfg.appKey == "" {
+		return nil, fmt.Errorf("an API key and an application key are needed, set them with --datadog-api-key and --datadog-app-key or DD_API_KEY and DD_APP_KEY")
+	}
+
+	site := firstNonEmpty(opts.DatadogSite, os.Getenv("DD_SITE"), DefaultDatadogSite)
+	if strings.HasPrefix(site, "http://") || strings.HasPrefix(site, "https://") {
+		cfg.baseURL = strings.TrimRight(site, "/")
+	} else {
+		cfg.baseURL = "https://api." + site
+	}
+	return cfg, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// getDatadogMetrics reads container and node utilization from the Datadog
+// metrics API, averaged over --datadog-window
+func getDatadogMetrics(ctx context.Context, run *reportRun, opts Options) (*v1beta1.PodMetricsList, *v1beta1.NodeMetricsList, error) {
+	cfg, err := newDatadogConfig(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	scope := opts.DatadogScope
+	if scope == "" {
+		scope = "*"
+	}
+	to := time.Now()
+	from := to.Add(-opts.DatadogWindow)
+
+	queryFn := func(query string) (*prometheusResponse, error) {
+		resp, err := queryDatadog(ctx, run, cfg, query, from, to)
+		if err != nil {
+			return nil, err
+		}
+		return resp.prometheusResponse(opts.UsageAggregation), nil
+	}
+
+	cpuResp, err := queryFn(datadogContainerQuery("kubernetes.cpu.usage.total", scope, opts.UsageAggregation))
+	if err != nil {
+		return nil, nil, fmt.Errorf("querying container CPU: %w", err)
+	}
+	memResp, err := queryFn(datadogContainerQuery("kubernetes.memory.working_set", scope, opts.UsageAggregation))
+	if err != nil {
+		return nil, nil, fmt.Errorf("querying container memory: %w", err)
+	}
+	nodeCPUResp, err := queryFn(datadogNodeQuery("kubernetes.cpu.usage.total", scope, opts.UsageAggregation))
+	if err != nil {
+		return nil, nil, fmt.Errorf("querying node CPU: %w", err)
+	}
+	nodeMemResp, err := queryFn(datadogNodeQuery("kubernetes.memory.working_set", scope, opts.UsageAggregation))
+	if err != nil {
+		return nil, nil, fmt.Errorf("querying node memory: %w", err)
+	}
+
+	// CPU is converted from nanocores to the cores Prometheus reports
+	for _, resp := range []*prometheusResponse{cpuResp, nodeCPUResp} {
+		for i := range resp.Data.Result {
+			if v, err := parseValue(resp.Data.Result[i].Value); err == nil {
+				resp.Data.Result[i].Value[1] = strconv.FormatFloat(v/datadogNanoCores, 'f', -1, 64)
+			}
+		}
+	}
+
+	pmList := buildPodMetricsList(run, cpuResp, memResp)
+	nmList := buildNodeMetricsList(run, nodeCPUResp, nodeMemResp)
+
+	// Every series was averaged over the same window, so the newest point
+	// of any of them stands in for the sample timestamp
+	sample := metav1.NewTime(prometheusEvalTime(nodeCPUResp))
+	for i := range pmList.Items {
+		pmList.Items[i].Timestamp = sample
+		pmList.Items[i].Window.Duration = opts.DatadogWindow
+	}
+	for i := range nmList.Items {
+		nmList.Items[i].Timestamp = sample
+		nmList.Items[i].Window.Duration = opts.DatadogWindow
+	}
+
+	return pmList, nmList, nil
+}
+
+func queryDatadog(ctx context.Context, run *reportRun, cfg *datadogConfig, query string, from, to time.Time) (*datadogResponse, error) {
+	params := url.Values{}
+	params.Set("from", strconv.FormatInt(from.Unix(), 10))
+	params.Set("to", strconv.FormatInt(to.Unix(), 10))
+	params.Set("query", query)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, cfg.baseURL+"/api/v1/query?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("DD-API-KEY", cfg.apiKey)
+	req.Header.Set("DD-APPLICATION-KEY", cfg.appKey)
+	req.Header.Set("Accept", "application/json")
+
+	run.logDebugf("Querying Datadog at %s: %s", cfg.baseURL, query)
+	start := time.Now()
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("HTTP request to Datadog: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading Datadog response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Datadog returned HTTP %d: %s", resp.StatusCode, string(body))
+	}
+
+	var ddResp datadogResponse
+	if err := json.Unmarshal(body, &ddResp); err != nil {
+		return nil, fmt.Errorf("parsing Datadog response: %w", err)
+	}
+	if ddResp.Status != "ok" {
+		return nil, fmt.Errorf("Datadog query failed with status %s: %s", ddResp.Status, ddResp.Error)
+	}
+	run.logTiming(start, "Received %d series from Datadog", len(ddResp.Series))
+
+	return &ddResp, nil
+}
+
+// prometheusResponse converts the series to the shape of a Prometheus
+// instant query, with each value the average of the series' points, or the
+// highest with the max aggregation, and stamped with the time of its newest
+// point. Series without points are left out.
+func (r *datadogResponse) prometheusResponse(aggregation string) *prometheusResponse {
+	resp := &prometheusResponse{Status: "success"}
+	resp.Data.ResultType = "vector"
+
+	for _, series := range r.Series {
+		var sum, max, newest float64
+		count := 0
+		for _, point := range series.Pointlist {
+			if len(point) < 2 || point[0] == nil || point[1] == nil {
+				continue
+			}
+			sum += *point[1]
+			if count == 0 || *point[1] > max {
+				max = *point[1]
+			}
+			count++
+			if *point[0] > newest {
+				newest = *point[0]
+			}
+		}
+		if count == 0 {
+			continue
+		}
+
+		metric := map[string]string{}
+		for _, tag := range series.TagSet {
+			name, value, ok := strings.Cut(tag, ":")
+			if label, known := datadogTags[name]; ok && known {
+				metric[label] = value
+			}
+		}
+
+		value := sum / float64(count)
+		if aggregation == MaxUsageAggregation {
+			value = max
+		}
+		resp.Data.Result = append(resp.Data.Result, prometheusResult{
+			Metric: metric,
+			Value:  []interface{}{newest / 1000, strconv.FormatFloat(value, 'f', -1, 64)},
+		})
+	}
+
+	return resp
+}
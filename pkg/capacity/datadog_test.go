@@ -0,0 +1,141 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewDatadogConfig(t *testing.T) {
+	t.Setenv("DD_API_KEY", "env-api")
+	t.Setenv("DD_APP_KEY", "env-app")
+	t.Setenv("DD_SITE", "")
+
+	cfg, err := newDatadogConfig(Options{DatadogAPIKey: "flag-api"})
+	require.NoError(t, err)
+	assert.Equal(t, &datadogConfig{baseURL: "https://api.datadoghq.com", apiKey: "flag-api", appKey: "env-app"}, cfg)
+
+	t.Setenv("DD_SITE", "datadoghq.eu")
+	cfg, err = newDatadogConfig(Options{})
+	require.NoError(t, err)
+	assert.Equal(t, "https://api.datadoghq.eu", cfg.baseURL)
+
+	cfg, err = newDatadogConfig(Options{DatadogSite: "http://localhost:8126/"})
+	require.NoError(t, err)
+	assert.Equal(t, "http://localhost:8126", cfg.baseURL)
+
+	t.Setenv("DD_APP_KEY", "")
+	_, err = newDatadogConfig(Options{})
+	assert.Error(t, err)
+}
+
+func TestGetDatadogMetrics(t *testing.T) {
+	run := newTestRun(nil)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/query", r.URL.Path)
+		assert.Equal(t, "api", r.Header.Get("DD-API-KEY"))
+		assert.Equal(t, "app", r.Header.Get("DD-APPLICATION-KEY"))
+
+		query := r.URL.Query().Get("query")
+		assert.Contains(t, query, "{kube_cluster_name:prod}")
+		value := 104857600.0
+		if strings.Contains(query, "cpu") {
+			value = 250000000
+		}
+
+		if strings.HasSuffix(query, "by {kube_node}") {
+			fmt.Fprintf(w, `{"status": "ok", "series": [{
+				"tag_set": ["kube_node:node-a"],
+				"pointlist": [[1767268740000, %[1]g], [1767268800000, null], [1767268770000, %[1]g]]
+			}]}`, value*4)
+			return
+		}
+		fmt.Fprintf(w, `{"status": "ok", "series": [{
+			"tag_set": ["kube_namespace:default", "pod_name:web", "kube_container_name:app"],
+			"pointlist": [[1767268740000, %[1]g], [1767268770000, %[2]g]]
+		}, {
+			"tag_set": ["kube_namespace:default", "pod_name:web", "kube_container_name:idle"],
+			"pointlist": []
+		}]}`, value/2, value*1.5)
+	}))
+	defer server.Close()
+
+	opts := Options{
+		DatadogAPIKey: "api",
+		DatadogAppKey: "app",
+		DatadogSite:   server.URL,
+		DatadogScope:  "kube_cluster_name:prod",
+		DatadogWindow: 10 * time.Minute,
+	}
+	pmList, nmList, err := getDatadogMetrics(context.Background(), run, opts)
+	require.NoError(t, err)
+
+	require.Len(t, pmList.Items, 1)
+	pm := pmList.Items[0]
+	assert.Equal(t, "default", pm.Namespace)
+	assert.Equal(t, "web", pm.Name)
+	require.Len(t, pm.Containers, 1)
+	assert.Equal(t, "app", pm.Containers[0].Name)
+	assert.Equal(t, "250m", pm.Containers[0].Usage.Cpu().String())
+	assert.Equal(t, "100Mi", pm.Containers[0].Usage.Memory().String())
+	assert.Equal(t, 10*time.Minute, pm.Window.Duration)
+
+	require.Len(t, nmList.Items, 1)
+	nm := nmList.Items[0]
+	assert.Equal(t, "node-a", nm.Name)
+	assert.Equal(t, "1", nm.Usage.Cpu().String())
+	assert.Equal(t, "400Mi", nm.Usage.Memory().String())
+	// Points without a value are skipped when finding the newest
+	assert.Equal(t, time.UnixMilli(1767268770000), nm.Timestamp.Time)
+}
+
+func TestDatadogMaxUsageAggregation(t *testing.T) {
+	assert.Equal(t, "sum:kubernetes.cpu.usage.total{*} by {kube_node}.rollup(max)",
+		datadogNodeQuery("kubernetes.cpu.usage.total", "*", MaxUsageAggregation))
+	assert.Equal(t, "sum:kubernetes.cpu.usage.total{*} by {kube_node}",
+		datadogNodeQuery("kubernetes.cpu.usage.total", "*", AvgUsageAggregation))
+
+	var resp datadogResponse
+	require.NoError(t, json.Unmarshal([]byte(`{"status": "ok", "series": [{
+		"tag_set": ["kube_node:node-a"],
+		"pointlist": [[1767268740000, 1], [1767268770000, 4], [1767268800000, 1]]
+	}]}`), &resp))
+	assert.Equal(t, "2", resp.prometheusResponse(AvgUsageAggregation).Data.Result[0].Value[1])
+	assert.Equal(t, "4", resp.prometheusResponse(MaxUsageAggregation).Data.Result[0].Value[1])
+}
+
+func TestGetDatadogMetricsError(t *testing.T) {
+	run := newTestRun(nil)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `{"errors": ["Forbidden"]}`)
+	}))
+	defer server.Close()
+
+	opts := Options{DatadogAPIKey: "api", DatadogAppKey: "app", DatadogSite: server.URL, DatadogWindow: time.Minute}
+	_, _, err := getDatadogMetrics(context.Background(), run, opts)
+	require.Error(t, err)
+	assert.Equal(t, `querying container CPU: Datadog returned HTTP 403: {"errors": ["Forbidden"]}`, err.Error())
+}
@@ -0,0 +1,183 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// lastRun is the on-disk form of the last report of a cluster, with only
+// the requests, limits, and utilization of the cluster, each node, and each
+// namespace that --show-delta compares
+type lastRun struct {
+	CreatedAt   time.Time                  `json:"createdAt"`
+	Utilization bool                       `json:"utilization"`
+	Cluster     *lastRunMetrics            `json:"cluster"`
+	Nodes       map[string]*lastRunMetrics `json:"nodes"`
+	Namespaces  map[string]*lastRunMetrics `json:"namespaces"`
+}
+
+type lastRunMetrics struct {
+	CPU    *lastRunResource `json:"cpu"`
+	Memory *lastRunResource `json:"memory"`
+}
+
+type lastRunResource struct {
+	Requests    resource.Quantity `json:"requests"`
+	Limits      resource.Quantity `json:"limits"`
+	Utilization resource.Quantity `json:"utilization"`
+}
+
+// lastRunKey identifies the cluster and the filters of a report, so that a
+// report is only compared with an earlier one of the same nodes and pods
+func lastRunKey(opts Options) (string, error) {
+	kubeContext, err := clusterName(opts)
+	if err != nil {
+		return "", err
+	}
+	key, err := json.Marshal(struct {
+		Context, KubeConfig                    string
+		ImpersonateUser, ImpersonateGroup      string
+		Namespace, NamespaceLabels, NodeLabels string
+		PodLabels, PodFieldSelector, NodeName  string
+		NodeTaints                             string
+		ExcludeTainted                         bool
+	}{
+		kubeContext, opts.KubeConfig,
+		opts.ImpersonateUser, opts.ImpersonateGroup,
+		opts.Namespace, opts.NamespaceLabels, opts.NodeLabels,
+		opts.PodLabels, opts.PodFieldSelector, opts.NodeName,
+		opts.NodeTaints,
+		opts.ExcludeTainted,
+	})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(key)
+	return "last-run-" + hex.EncodeToString(sum[:]), nil
+}
+
+// updateLastRun caches the report of a live cluster for the next run with
+// --show-delta, after reading the one cached by the run before it when
+// --show-delta is set
+func updateLastRun(run *reportRun, cm *clusterMetric, opts Options) {
+	key, err := lastRunKey(opts)
+	if err != nil {
+		run.logDebugf("Not caching the report: %v", err)
+		return
+	}
+	if opts.ShowDelta {
+		previous := &lastRun{}
+		if readCacheFile(run, key, previous) {
+			cm.previous = previous
+		}
+	}
+	if err := writeCacheFile(key, newLastRun(cm, opts.ShowUtil)); err != nil {
+		run.logDebugf("Error caching the report: %v", err)
+	}
+}
+
+func newLastRun(cm *clusterMetric, util bool) *lastRun {
+	run := &lastRun{
+		CreatedAt:   cm.observedAt.UTC(),
+		Utilization: util,
+		Cluster:     newLastRunMetrics(cm.cpu, cm.memory),
+		Nodes:       map[string]*lastRunMetrics{},
+		Namespaces:  map[string]*lastRunMetrics{},
+	}
+	for name, nm := range cm.nodeMetrics {
+		run.Nodes[name] = newLastRunMetrics(nm.cpu, nm.memory)
+	}
+	for name, nsm := range cm.getNamespaceMetrics() {
+		run.Namespaces[name] = newLastRunMetrics(nsm.cpu, nsm.memory)
+	}
+	return run
+}
+
+func newLastRunMetrics(cpu, memory *resourceMetric) *lastRunMetrics {
+	toResource := func(rm *resourceMetric) *lastRunResource {
+		return &lastRunResource{Requests: rm.request, Limits: rm.limit, Utilization: rm.utilization}
+	}
+	return &lastRunMetrics{CPU: toResource(cpu), Memory: toResource(memory)}
+}
+
+func (m *lastRunMetrics) metricPair() *metricPair {
+	toMetric := func(resourceType string, r *lastRunResource) *resourceMetric {
+		rm := &resourceMetric{resourceType: resourceType}
+		if r != nil {
+			rm.request, rm.limit, rm.utilization = r.Requests, r.Limits, r.Utilization
+		}
+		return rm
+	}
+	return &metricPair{cpu: toMetric("cpu", m.CPU), memory: toMetric("memory", m.Memory)}
+}
+
+// printDelta prints the requests, limits, and utilization of each node and
+// namespace next to how much they changed since the last run. Without a
+// last run, everything is shown as added.
+func printDelta(run *reportRun, cm *clusterMetric, opts Options) error {
+	previous := cm.previous
+	if previous == nil {
+		run.logInfof("No earlier run of this cluster with the same filters was found, showing every node and namespace as added")
+		previous = &lastRun{Cluster: &lastRunMetrics{}}
+	} else {
+		run.logInfof("Showing changes since the run %s ago", cm.observedAt.Sub(previous.CreatedAt).Round(time.Second))
+	}
+	if opts.ShowUtil && !previous.Utilization && cm.previous != nil {
+		run.logWarnf("the last run had no utilization to compare with, run with --util for it to be compared next time")
+		opts.ShowUtil = false
+	}
+
+	return newDeltaPrinter(run, cm, previous, opts).Print(opts.OutputFormat)
+}
+
+func newDeltaPrinter(run *reportRun, cm *clusterMetric, previous *lastRun, opts Options) *diffPrinter {
+	nodes := map[string][2]*metricPair{}
+	for name, m := range previous.Nodes {
+		nodes[name] = [2]*metricPair{m.metricPair(), nil}
+	}
+	for name, nm := range cm.nodeMetrics {
+		p := nodes[name]
+		p[1] = &metricPair{cpu: nm.cpu, memory: nm.memory}
+		nodes[name] = p
+	}
+
+	namespaces := map[string][2]*metricPair{}
+	for name, m := range previous.Namespaces {
+		namespaces[name] = [2]*metricPair{m.metricPair(), nil}
+	}
+	for name, nsm := range cm.getNamespaceMetrics() {
+		p := namespaces[name]
+		p[1] = &metricPair{cpu: nsm.cpu, memory: nsm.memory}
+		namespaces[name] = p
+	}
+
+	return &diffPrinter{
+		run: run,
+		cluster: &diffRow{
+			name:   VoidValue,
+			before: previous.Cluster.metricPair(),
+			after:  &metricPair{cpu: cm.cpu, memory: cm.memory},
+		},
+		nodes:      buildDiffRows(nodes),
+		namespaces: buildDiffRows(namespaces),
+		opts:       opts,
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShowDelta(t *testing.T) {
+	run := newTestRun(nil)
+	useTestCacheDir(t)
+	opts := Options{KubeContext: "prod", ShowDelta: true}
+
+	snap := getTestSnapshot()
+	first := buildClusterMetric(snap.Pods, nil, snap.Nodes, nil)
+	updateLastRun(run, &first, opts)
+	assert.Nil(t, first.previous, "nothing is cached before the first run")
+
+	// mypod2 in the other namespace is deleted between the runs
+	snap.Pods.Items = snap.Pods.Items[:1]
+	second := buildClusterMetric(snap.Pods, nil, snap.Nodes, nil)
+	updateLastRun(run, &second, opts)
+	require.NotNil(t, second.previous)
+
+	dp := newDeltaPrinter(run, &second, second.previous, opts)
+	assert.Equal(t, []string{"*", "100m (-100m)", "0m (+0m)", "128Mi (-128Mi)", "0Mi (+0Mi)"}, dp.lineItems(dp.cluster))
+	require.Len(t, dp.nodes, 2)
+	assert.Equal(t, []string{"mynode2", "0m (-100m)", "0m (+0m)", "0Mi (-128Mi)", "0Mi (+0Mi)"}, dp.lineItems(dp.nodes[1]))
+	require.Len(t, dp.namespaces, 2)
+	assert.Equal(t, []string{"default", "100m (+0m)", "0m (+0m)", "128Mi (+0Mi)", "0Mi (+0Mi)"}, dp.lineItems(dp.namespaces[0]))
+	assert.Equal(t, []string{"other (removed)", "0m (-100m)", "0m (+0m)", "0Mi (-128Mi)", "0Mi (+0Mi)"}, dp.lineItems(dp.namespaces[1]))
+
+	// Reports with other filters or of other clusters are kept apart
+	for _, other := range []Options{
+		{KubeContext: "prod", ShowDelta: true, Namespace: "default"},
+		{KubeContext: "staging", ShowDelta: true},
+	} {
+		third := buildClusterMetric(snap.Pods, nil, snap.Nodes, nil)
+		updateLastRun(run, &third, other)
+		assert.Nil(t, third.previous)
+	}
+}
+
+func TestShowDeltaWithoutLastRun(t *testing.T) {
+	run := newTestRun(nil)
+	snap := getTestSnapshot()
+	cm := buildClusterMetric(snap.Pods, nil, snap.Nodes, nil)
+
+	dp := newDeltaPrinter(run, &cm, &lastRun{Cluster: &lastRunMetrics{}}, Options{HideLimits: true})
+	assert.Equal(t, []string{"*", "200m (+200m)", "256Mi (+256Mi)"}, dp.lineItems(dp.cluster))
+	for _, row := range append(dp.nodes, dp.namespaces...) {
+		assert.Equal(t, "added", row.status)
+	}
+}
@@ -0,0 +1,376 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+type diffRow struct {
+	name   string
+	status string
+	before *metricPair
+	after  *metricPair
+}
+
+type metricPair struct {
+	cpu    *resourceMetric
+	memory *resourceMetric
+}
+
+type listDiff struct {
+	ClusterTotals *listDiffRow   `json:"clusterTotals"`
+	Nodes         []*listDiffRow `json:"nodes"`
+	Namespaces    []*listDiffRow `json:"namespaces"`
+}
+
+type listDiffRow struct {
+	Name   string            `json:"name"`
+	Status string            `json:"status,omitempty"`
+	CPU    *listDiffResource `json:"cpu"`
+	Memory *listDiffResource `json:"memory"`
+}
+
+type listDiffResource struct {
+	Requests    *listDiffValue `json:"requests,omitempty"`
+	Limits      *listDiffValue `json:"limits,omitempty"`
+	Utilization *listDiffValue `json:"utilization,omitempty"`
+}
+
+type listDiffValue struct {
+	Before string `json:"before"`
+	After  string `json:"after"`
+	Delta  string `json:"delta"`
+}
+
+// The prefixes of the sources diff compares, which name either a snapshot
+// file written with --snapshot-out or a kube context
+const (
+	FileDiffSource    = "file:"
+	ContextDiffSource = "context:"
+)
+
+// ParseDiffSource returns whether a source of diff is a snapshot file, and
+// the path of the file or the name of the context. Sources must say which
+// they are, so that a context is never read from a file of the same name.
+func ParseDiffSource(source string) (snapshot bool, name string, err error) {
+	switch {
+	case strings.HasPrefix(source, FileDiffSource):
+		snapshot, name = true, strings.TrimPrefix(source, FileDiffSource)
+	case strings.HasPrefix(source, ContextDiffSource):
+		name = strings.TrimPrefix(source, ContextDiffSource)
+	default:
+		return false, "", fmt.Errorf("%q must be %s<snapshot> or %s<kube context>", source, FileDiffSource, ContextDiffSource)
+	}
+	if name == "" {
+		return false, "", fmt.Errorf("%q does not name a snapshot or kube context", source)
+	}
+	return snapshot, name, nil
+}
+
+// FetchAndPrintDiff compares two reports and prints per-node and
+// per-namespace deltas. Each source is either file: and a snapshot file
+// written with --snapshot-out or context: and the name of a kube context.
+func FetchAndPrintDiff(before, after string, opts Options) error {
+	run := newRun(opts)
+
+	sources := [2]Options{}
+	for i, source := range []string{before, after} {
+		snapshot, name, err := ParseDiffSource(source)
+		if err != nil {
+			return fmt.Errorf("Error: %v", err)
+		}
+		sources[i] = diffSourceOptions(snapshot, name, opts)
+	}
+	beforeCM, ferr := fetchClusterMetric(run, sources[0])
+	if ferr != nil {
+		return ferr
+	}
+	afterCM, ferr := fetchClusterMetric(run, sources[1])
+	if ferr != nil {
+		return ferr
+	}
+
+	dp := &diffPrinter{
+		run:        run,
+		cluster:    diffClusterRow(&beforeCM, &afterCM),
+		nodes:      diffNodeRows(&beforeCM, &afterCM),
+		namespaces: diffNamespaceRows(&beforeCM, &afterCM),
+		opts:       opts,
+	}
+	if err := dp.Print(opts.OutputFormat); err != nil {
+		return err
+	}
+	return run.closeOutput()
+}
+
+// printCompare prints the utilization of each node and namespace next to
+// how much it changed since --compare-offset ago. Requests and limits are
+// left out since only utilization is known for that long ago.
+func printCompare(run *reportRun, cm *clusterMetric, opts Options) error {
+	return newCompareDiffPrinter(run, cm, opts).Print(opts.OutputFormat)
+}
+
+func newCompareDiffPrinter(run *reportRun, cm *clusterMetric, opts Options) *diffPrinter {
+	opts.HideRequests = true
+	opts.HideLimits = true
+	return &diffPrinter{
+		run:        run,
+		cluster:    diffClusterRow(cm.earlier, cm),
+		nodes:      diffNodeRows(cm.earlier, cm),
+		namespaces: diffNamespaceRows(cm.earlier, cm),
+		opts:       opts,
+	}
+}
+
+func diffSourceOptions(snapshot bool, name string, opts Options) Options {
+	opts.SnapshotOut = ""
+	opts.ShowQuotas = false
+	opts.ShowVPA = false
+	opts.ShowHeadroom = false
+	opts.ShowKarpenter = false
+	opts.ShowCapacityType = false
+	opts.ShowArch = false
+	opts.ShowZoneBalance = false
+	opts.ShowGPU = false
+	opts.SimulateRemoveNodes = ""
+	opts.ShowHPAHeadroom = false
+	opts.AllocateByPodLabel = ""
+	opts.ShowPending = false
+	opts.ShowDaemonSetOverhead = false
+	opts.ShowAnomalies = false
+	opts.ShowStuckPods = false
+	opts.Preemption = ""
+	opts.ShowMissingResources = false
+	opts.CompareOffset = 0
+	opts.ShowDelta = false
+	opts.Stream = false
+	opts.FromKubectlDump = nil
+	if snapshot {
+		opts.SnapshotIn = name
+		opts.KubeContext = ""
+	} else {
+		opts.SnapshotIn = ""
+		opts.KubeContext = name
+	}
+	return opts
+}
+
+func diffClusterRow(before, after *clusterMetric) *diffRow {
+	return &diffRow{
+		name:   VoidValue,
+		before: &metricPair{cpu: before.cpu, memory: before.memory},
+		after:  &metricPair{cpu: after.cpu, memory: after.memory},
+	}
+}
+
+func diffNodeRows(before, after *clusterMetric) []*diffRow {
+	pairs := map[string][2]*metricPair{}
+	for name, nm := range before.nodeMetrics {
+		p := pairs[name]
+		p[0] = &metricPair{cpu: nm.cpu, memory: nm.memory}
+		pairs[name] = p
+	}
+	for name, nm := range after.nodeMetrics {
+		p := pairs[name]
+		p[1] = &metricPair{cpu: nm.cpu, memory: nm.memory}
+		pairs[name] = p
+	}
+	return buildDiffRows(pairs)
+}
+
+func diffNamespaceRows(before, after *clusterMetric) []*diffRow {
+	pairs := map[string][2]*metricPair{}
+	for name, nsm := range before.getNamespaceMetrics() {
+		p := pairs[name]
+		p[0] = &metricPair{cpu: nsm.cpu, memory: nsm.memory}
+		pairs[name] = p
+	}
+	for name, nsm := range after.getNamespaceMetrics() {
+		p := pairs[name]
+		p[1] = &metricPair{cpu: nsm.cpu, memory: nsm.memory}
+		pairs[name] = p
+	}
+	return buildDiffRows(pairs)
+}
+
+func buildDiffRows(pairs map[string][2]*metricPair) []*diffRow {
+	rows := []*diffRow{}
+	for name, p := range pairs {
+		row := &diffRow{name: name, before: p[0], after: p[1]}
+		if row.before == nil {
+			row.status = "added"
+			row.before = emptyMetricPair()
+		}
+		if row.after == nil {
+			row.status = "removed"
+			row.after = emptyMetricPair()
+		}
+		rows = append(rows, row)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		return rows[i].name < rows[j].name
+	})
+	return rows
+}
+
+func emptyMetricPair() *metricPair {
+	return &metricPair{
+		cpu:    &resourceMetric{resourceType: "cpu"},
+		memory: &resourceMetric{resourceType: "memory"},
+	}
+}
+
+type diffPrinter struct {
+	run        *reportRun
+	cluster    *diffRow
+	nodes      []*diffRow
+	namespaces []*diffRow
+	opts       Options
+}
+
+func (dp *diffPrinter) Print(outputType string) error {
+	switch outputType {
+	case JSONOutput, YAMLOutput:
+		dp.printList(outputType)
+	case TableOutput:
+		w := tabwriter.NewWriter(dp.run.output, 0, 8, 2, ' ', 0)
+		dp.printTable(w, "\t ")
+		if err := w.Flush(); err != nil {
+			dp.run.logErrorf("Error writing to table: %s", err)
+		}
+	case CSVOutput:
+		dp.printTable(dp.run.output, ",")
+	case TSVOutput:
+		dp.printTable(dp.run.output, "\t")
+	default:
+		return fmt.Errorf("Called with an unsupported output type: %s", outputType)
+	}
+	return nil
+}
+
+func (dp *diffPrinter) printTable(w io.Writer, separator string) {
+	printRows := func(header string, rows []*diffRow) {
+		_, _ = fmt.Fprintln(w, strings.Join(dp.headers(header), separator))
+		for _, row := range rows {
+			_, _ = fmt.Fprintln(w, strings.Join(dp.lineItems(row), separator))
+		}
+	}
+
+	printRows("NODE", append([]*diffRow{dp.cluster}, dp.nodes...))
+	_, _ = fmt.Fprintln(w)
+	printRows("NAMESPACE", dp.namespaces)
+}
+
+func (dp *diffPrinter) headers(name string) []string {
+	headers := []string{name}
+	for _, prefix := range []string{"CPU", "MEMORY"} {
+		if !dp.opts.HideRequests {
+			headers = append(headers, prefix+" REQUESTS")
+		}
+		if !dp.opts.HideLimits {
+			headers = append(headers, prefix+" LIMITS")
+		}
+		if dp.opts.ShowUtil {
+			headers = append(headers, prefix+" UTIL")
+		}
+	}
+	return headers
+}
+
+func (dp *diffPrinter) lineItems(row *diffRow) []string {
+	name := row.name
+	if row.status != "" {
+		name = fmt.Sprintf("%s (%s)", row.name, row.status)
+	}
+	items := []string{name}
+	for _, r := range dp.resourceDiffs(row) {
+		if r.Requests != nil {
+			items = append(items, r.Requests.String())
+		}
+		if r.Limits != nil {
+			items = append(items, r.Limits.String())
+		}
+		if r.Utilization != nil {
+			items = append(items, r.Utilization.String())
+		}
+	}
+	return items
+}
+
+func (dp *diffPrinter) resourceDiffs(row *diffRow) []*listDiffResource {
+	return []*listDiffResource{
+		dp.resourceDiff(row.before.cpu, row.after.cpu),
+		dp.resourceDiff(row.before.memory, row.after.memory),
+	}
+}
+
+func (dp *diffPrinter) resourceDiff(before, after *resourceMetric) *listDiffResource {
+	out := &listDiffResource{}
+	if !dp.opts.HideRequests {
+		out.Requests = diffValue(dp.run, after.resourceType, before.request, after.request)
+	}
+	if !dp.opts.HideLimits {
+		out.Limits = diffValue(dp.run, after.resourceType, before.limit, after.limit)
+	}
+	if dp.opts.ShowUtil {
+		out.Utilization = diffValue(dp.run, after.resourceType, before.utilization, after.utilization)
+	}
+	return out
+}
+
+func (dp *diffPrinter) printList(outputType string) {
+	toList := func(row *diffRow) *listDiffRow {
+		diffs := dp.resourceDiffs(row)
+		return &listDiffRow{Name: row.name, Status: row.status, CPU: diffs[0], Memory: diffs[1]}
+	}
+
+	out := listDiff{ClusterTotals: toList(dp.cluster)}
+	for _, row := range dp.nodes {
+		out.Nodes = append(out.Nodes, toList(row))
+	}
+	for _, row := range dp.namespaces {
+		out.Namespaces = append(out.Namespaces, toList(row))
+	}
+
+	printListOutput(dp.run, CapacityDiffReportKind, out, outputType)
+}
+
+// String formats the value as "after (+delta)"
+func (v *listDiffValue) String() string {
+	return fmt.Sprintf("%s (%s)", v.After, v.Delta)
+}
+
+func diffValue(run *reportRun, resourceType string, before, after resource.Quantity) *listDiffValue {
+	rm := resourceMetric{resourceType: resourceType}
+	valueCalculator := rm.valueFunction(run)
+
+	delta := run.formatQuantityDifference(resourceType, after, before)
+	if !strings.HasPrefix(delta, "-") {
+		delta = "+" + delta
+	}
+
+	return &listDiffValue{
+		Before: valueCalculator(before),
+		After:  valueCalculator(after),
+		Delta:  delta,
+	}
+}
@@ -0,0 +1,116 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestDiffRows(t *testing.T) {
+	run := newTestRun(nil)
+	before := getTestSnapshot()
+	after := getTestSnapshot()
+
+	// scale up default/mypod and replace mynode2 with mynode3
+	after.Pods.Items[0].Spec.Containers[0].Resources.Requests["cpu"] = resource.MustParse("300m")
+	after.Nodes.Items[1].Name = "mynode3"
+	after.Pods.Items[1].Spec.NodeName = "mynode3"
+
+	beforeCM := buildClusterMetric(before.Pods, nil, before.Nodes, nil)
+	afterCM := buildClusterMetric(after.Pods, nil, after.Nodes, nil)
+
+	dp := &diffPrinter{
+		run:        run,
+		cluster:    diffClusterRow(&beforeCM, &afterCM),
+		nodes:      diffNodeRows(&beforeCM, &afterCM),
+		namespaces: diffNamespaceRows(&beforeCM, &afterCM),
+	}
+
+	assert.Equal(t, []string{"*", "400m (+200m)", "0m (+0m)", "256Mi (+0Mi)", "0Mi (+0Mi)"}, dp.lineItems(dp.cluster))
+
+	assert.Len(t, dp.nodes, 3)
+	assert.Equal(t, []string{"mynode", "300m (+200m)", "0m (+0m)", "128Mi (+0Mi)", "0Mi (+0Mi)"}, dp.lineItems(dp.nodes[0]))
+	assert.Equal(t, []string{"mynode2 (removed)", "0m (-100m)", "0m (+0m)", "0Mi (-128Mi)", "0Mi (+0Mi)"}, dp.lineItems(dp.nodes[1]))
+	assert.Equal(t, []string{"mynode3 (added)", "100m (+100m)", "0m (+0m)", "128Mi (+128Mi)", "0Mi (+0Mi)"}, dp.lineItems(dp.nodes[2]))
+
+	assert.Len(t, dp.namespaces, 2)
+	assert.Equal(t, []string{"default", "300m (+200m)", "0m (+0m)", "128Mi (+0Mi)", "0Mi (+0Mi)"}, dp.lineItems(dp.namespaces[0]))
+	assert.Equal(t, []string{"other", "100m (+0m)", "0m (+0m)", "128Mi (+0Mi)", "0Mi (+0Mi)"}, dp.lineItems(dp.namespaces[1]))
+}
+
+func TestCompareDiffPrinter(t *testing.T) {
+	run := newTestRun(nil)
+	snap := getTestSnapshot()
+	cm := buildClusterMetric(snap.Pods, snap.PodMetrics, snap.Nodes, snap.NodeMetrics)
+	earlier := buildClusterMetric(snap.Pods, nil, snap.Nodes, nil)
+	cm.earlier = &earlier
+
+	dp := newCompareDiffPrinter(run, &cm, Options{ShowUtil: true})
+
+	assert.Equal(t, []string{"NODE", "CPU UTIL", "MEMORY UTIL"}, dp.headers("NODE"))
+	now := dp.resourceDiff(earlier.cpu, cm.cpu).Utilization
+	assert.Equal(t, "0m", now.Before)
+	assert.Equal(t, "+"+now.After, now.Delta)
+	assert.Len(t, dp.nodes, 2)
+	assert.Len(t, dp.namespaces, 2)
+	for _, row := range append(dp.nodes, dp.namespaces...) {
+		assert.Empty(t, row.status, row.name)
+	}
+}
+
+func TestGetNamespaceMetrics(t *testing.T) {
+	snap := getTestSnapshot()
+	snap.Pods.Items = append(snap.Pods.Items, *pod("mynode2", "default", "mypod3", nil))
+	snap.Pods.Items[2].Spec.Containers = []corev1.Container{{
+		Name: "app",
+		Resources: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{"cpu": resource.MustParse("50m")},
+		},
+	}}
+	cm := buildClusterMetric(snap.Pods, snap.PodMetrics, snap.Nodes, snap.NodeMetrics)
+
+	nsMetrics := cm.getNamespaceMetrics()
+	assert.Len(t, nsMetrics, 2)
+	assert.Equal(t, int64(2), nsMetrics["default"].podCount)
+	assert.Equal(t, int64(150), nsMetrics["default"].cpu.request.MilliValue())
+	assert.Equal(t, int64(50), nsMetrics["default"].cpu.utilization.MilliValue())
+	assert.Equal(t, int64(2000), nsMetrics["default"].cpu.allocatable.MilliValue())
+}
+
+func TestParseDiffSource(t *testing.T) {
+	snapshot, name, err := ParseDiffSource("file:before.json.gz")
+	require.NoError(t, err)
+	assert.True(t, snapshot)
+	assert.Equal(t, "before.json.gz", name)
+
+	snapshot, name, err = ParseDiffSource("context:arn:aws:eks:us-east-1:123:cluster/prod")
+	require.NoError(t, err)
+	assert.False(t, snapshot)
+	assert.Equal(t, "arn:aws:eks:us-east-1:123:cluster/prod", name)
+
+	for _, source := range []string{"staging", "before.json.gz", "file:", "context:"} {
+		_, _, err := ParseDiffSource(source)
+		assert.Error(t, err, source)
+	}
+
+	opts := diffSourceOptions(false, "staging", Options{KubeContext: "prod", SnapshotIn: "old.json"})
+	assert.Equal(t, "staging", opts.KubeContext)
+	assert.Empty(t, opts.SnapshotIn)
+}
@@ -0,0 +1,505 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	nodefsSignal  = "nodefs.available"
+	imagefsSignal = "imagefs.available"
+
+	// nearEvictionMargin is how close, as a fraction of its capacity, the
+	// available space of a filesystem gets to the eviction threshold before
+	// the node is flagged
+	nearEvictionMargin = 0.05
+
+	diskOK           = "OK"
+	diskNearEviction = "NearEviction"
+	diskPressure     = "DiskPressure"
+	diskUnknown      = "Unknown"
+)
+
+// defaultDiskEvictionHard are the hard eviction thresholds of a kubelet that
+// does not configure any
+var defaultDiskEvictionHard = map[string]string{
+	nodefsSignal:  "10%",
+	imagefsSignal: "15%",
+}
+
+// nodeFilesystemQuery reads the root filesystem of each node from
+// node_exporter, which is the nodefs of most kubelets
+const nodeFilesystemQuery = `max by (instance) (%s{mountpoint="/",fstype!~"tmpfs|overlay"})`
+
+// nodeDisk is the usage of the filesystems a kubelet evicts pods to
+// reclaim: nodefs holds logs and emptyDir volumes, and imagefs holds images
+// and writable container layers. Either is nil when it could not be read.
+type nodeDisk struct {
+	nodefs  *filesystemUsage
+	imagefs *filesystemUsage
+
+	// pressure is set when the node reports the DiskPressure condition
+	pressure bool
+}
+
+type filesystemUsage struct {
+	capacity  resource.Quantity
+	available resource.Quantity
+
+	// threshold is the available space below which the kubelet evicts pods
+	threshold resource.Quantity
+}
+
+// diskCount adds up the filesystems of the nodes of a cluster and counts
+// the nodes in each disk status
+type diskCount struct {
+	nodefs       *filesystemUsage
+	imagefs      *filesystemUsage
+	nodes        int64
+	ok           int64
+	nearEviction int64
+	pressure     int64
+}
+
+type listNodeDisk struct {
+	Nodefs  *listFilesystem `json:"nodefs,omitempty"`
+	Imagefs *listFilesystem `json:"imagefs,omitempty"`
+	Status  string          `json:"status"`
+}
+
+type listFilesystem struct {
+	Capacity          string `json:"capacity"`
+	Used              string `json:"used"`
+	UsedPct           string `json:"usedPercent"`
+	Available         string `json:"available"`
+	EvictionThreshold string `json:"evictionThreshold"`
+}
+
+type listDiskCount struct {
+	Nodefs       *listFilesystem `json:"nodefs,omitempty"`
+	Imagefs      *listFilesystem `json:"imagefs,omitempty"`
+	Nodes        int64           `json:"nodes"`
+	OK           int64           `json:"ok"`
+	NearEviction int64           `json:"nearEviction"`
+	DiskPressure int64           `json:"diskPressure"`
+}
+
+// kubeletFsStats is a filesystem of a node in the kubelet summary API
+// response
+type kubeletFsStats struct {
+	AvailableBytes *uint64 `json:"availableBytes"`
+	CapacityBytes  *uint64 `json:"capacityBytes"`
+}
+
+// fetchPrometheusNodeFilesystems reads the nodefs of every node from
+// node_exporter while the nodes are listed
+func fetchPrometheusNodeFilesystems(run *reportRun, g *fetchGroup, clientset kubernetes.Interface, opts Options) func() map[string]*nodeDisk {
+	var disks map[string]*nodeDisk
+	g.run(func(ctx context.Context) *fetchError {
+		var err error
+		disks, err = getPrometheusNodeFilesystems(run, clientset, opts)
+		if err != nil {
+			return newFetchError(4, "Error getting node filesystems from Prometheus: %v", err)
+		}
+		return nil
+	})
+	return func() map[string]*nodeDisk {
+		return disks
+	}
+}
+
+// getPrometheusNodeFilesystems returns the root filesystem of each node by
+// node name, with the default eviction thresholds of the kubelet. Images
+// are not told apart from the rest of the disk, so imagefs is left out.
+func getPrometheusNodeFilesystems(run *reportRun, clientset kubernetes.Interface, opts Options) (map[string]*nodeDisk, error) {
+	endpoint, err := resolvePrometheusEndpoint(run, clientset, opts)
+	if err != nil {
+		return nil, err
+	}
+	promHTTP, err := newPrometheusHTTP(context.TODO(), run, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	sizeResp, err := queryPrometheus(run, clientset, endpoint, promHTTP, nodeFilesystemSeries("node_filesystem_size_bytes"))
+	if err != nil {
+		return nil, fmt.Errorf("querying node filesystem size: %w", err)
+	}
+	availResp, err := queryPrometheus(run, clientset, endpoint, promHTTP, nodeFilesystemSeries("node_filesystem_avail_bytes"))
+	if err != nil {
+		return nil, fmt.Errorf("querying node filesystem available: %w", err)
+	}
+	// node_exporter is labeled with the address it was scraped at, unless
+	// the scrape config relabels it with the node name
+	names, err := queryPrometheusNodeNames(run, clientset, endpoint, promHTTP)
+	if err != nil {
+		return nil, err
+	}
+	names.resolve(sizeResp, availResp)
+
+	size := bytesByNode(sizeResp)
+	available := bytesByNode(availResp)
+	disks := map[string]*nodeDisk{}
+	for name, capacity := range size {
+		avail, ok := available[name]
+		if !ok {
+			continue
+		}
+		fu := &filesystemUsage{capacity: capacity, available: avail}
+		if fu.threshold, err = diskEvictionThreshold(nil, nodefsSignal, capacity); err != nil {
+			return nil, err
+		}
+		disks[name] = &nodeDisk{nodefs: fu}
+	}
+	return disks, nil
+}
+
+func bytesByNode(resp *prometheusResponse) map[string]resource.Quantity {
+	out := map[string]resource.Quantity{}
+	for _, r := range resp.Data.Result {
+		value, err := parseValue(r.Value)
+		if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+			continue
+		}
+		out[r.Metric["node"]] = *resource.NewQuantity(int64(value), resource.BinarySI)
+	}
+	return out
+}
+
+// nodeFilesystemSeries labels the root filesystem series of a node_exporter
+// metric with the instance they were scraped from as the node
+func nodeFilesystemSeries(metric string) string {
+	return fmt.Sprintf(`label_replace(%s, "node", "$1", "instance", "(.*)")`, fmt.Sprintf(nodeFilesystemQuery, metric))
+}
+
+// getKubeletFilesystems reads the nodefs and imagefs of each node from the
+// /stats/summary endpoint of its kubelet through the API server's node
+// proxy, and their eviction thresholds from its /configz endpoint. Nodes
+// whose summary can not be read are left out, and nodes whose configuration
+// can not be read use the default thresholds.
+func getKubeletFilesystems(run *reportRun, clientset kubernetes.Interface, nodeList *corev1.NodeList) map[string]*nodeDisk {
+	start := time.Now()
+	ctx := context.TODO()
+
+	disks := make([]*nodeDisk, len(nodeList.Items))
+	errs := make([]error, len(nodeList.Items))
+	sem := make(chan struct{}, kubeletConcurrency)
+	var wg sync.WaitGroup
+	for i := range nodeList.Items {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			disks[i], errs[i] = getKubeletFilesystem(ctx, run, clientset, nodeList.Items[i].Name)
+		}(i)
+	}
+	wg.Wait()
+
+	byNode := map[string]*nodeDisk{}
+	missing := []string{}
+	for i, nd := range disks {
+		nodeName := nodeList.Items[i].Name
+		if errs[i] != nil {
+			run.logDebugf("Error reading kubelet stats summary on %s: %v", nodeName, errs[i])
+			missing = append(missing, nodeName)
+			continue
+		}
+		byNode[nodeName] = nd
+	}
+
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		run.logWarnf("could not read the kubelet stats summary of %s, their disk usage is left out; kube-capacity needs permission to get nodes/proxy", strings.Join(missing, ", "))
+	}
+	run.logTiming(start, "Read the filesystems of %d kubelets", len(byNode))
+	return byNode
+}
+
+func getKubeletFilesystem(ctx context.Context, run *reportRun, clientset kubernetes.Interface, nodeName string) (*nodeDisk, error) {
+	body, err := clientset.CoreV1().RESTClient().Get().
+		Resource("nodes").
+		Name(nodeName).
+		SubResource("proxy").
+		Suffix("stats/summary").
+		DoRaw(ctx)
+	if err != nil {
+		return nil, err
+	}
+	summary := &kubeletSummary{}
+	if err := json.Unmarshal(body, summary); err != nil {
+		return nil, fmt.Errorf("parsing stats summary: %w", err)
+	}
+
+	config, err := getKubeletConfigz(ctx, clientset, nodeName)
+	if err != nil {
+		run.logDebugf("Error reading kubelet configuration on %s, using the default eviction thresholds: %v", nodeName, err)
+	}
+	nd := &nodeDisk{}
+	if nd.nodefs, err = summary.Node.Fs.filesystemUsage(config, nodefsSignal); err != nil {
+		return nil, err
+	}
+	if summary.Node.Runtime != nil {
+		if nd.imagefs, err = summary.Node.Runtime.ImageFs.filesystemUsage(config, imagefsSignal); err != nil {
+			return nil, err
+		}
+	}
+	return nd, nil
+}
+
+// filesystemUsage returns the usage of a filesystem of a stats summary, or
+// nil when the kubelet did not report its capacity
+func (fs *kubeletFsStats) filesystemUsage(config *kubeletConfiguration, signal string) (*filesystemUsage, error) {
+	if fs == nil || fs.CapacityBytes == nil || fs.AvailableBytes == nil {
+		return nil, nil
+	}
+	usage := &filesystemUsage{
+		capacity:  *resource.NewQuantity(int64(*fs.CapacityBytes), resource.BinarySI),
+		available: *resource.NewQuantity(int64(*fs.AvailableBytes), resource.BinarySI),
+	}
+	var err error
+	if usage.threshold, err = diskEvictionThreshold(config, signal, usage.capacity); err != nil {
+		return nil, err
+	}
+	return usage, nil
+}
+
+// diskEvictionThreshold returns the hard eviction threshold of a filesystem
+// signal. A kubelet that configures evictionHard only evicts on the signals
+// it lists.
+func diskEvictionThreshold(config *kubeletConfiguration, signal string, capacity resource.Quantity) (resource.Quantity, error) {
+	evictionHard := defaultDiskEvictionHard
+	if config != nil && config.EvictionHard != nil {
+		evictionHard = config.EvictionHard
+	}
+	threshold, ok := evictionHard[signal]
+	if !ok {
+		return resource.Quantity{}, nil
+	}
+	return parseEvictionThreshold(signal, threshold, capacity)
+}
+
+// addNodeDisk sets the filesystems of every node and adds them to the
+// cluster totals, with the DiskPressure condition of each node
+func (cm *clusterMetric) addNodeDisk(disks map[string]*nodeDisk, nodeList *corev1.NodeList) {
+	cm.disk = &diskCount{}
+	for i := range nodeList.Items {
+		node := &nodeList.Items[i]
+		nm, ok := cm.nodeMetrics[node.Name]
+		if !ok {
+			continue
+		}
+		nd, ok := disks[node.Name]
+		if !ok {
+			nd = &nodeDisk{}
+		}
+		nd.pressure = nodeDiskPressure(node)
+		nm.disk = nd
+		cm.disk.addNode(nd)
+	}
+}
+
+func nodeDiskPressure(node *corev1.Node) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == corev1.NodeDiskPressure {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+func (fu *filesystemUsage) used() resource.Quantity {
+	used := fu.capacity.DeepCopy()
+	used.Sub(fu.available)
+	return used
+}
+
+// nearEviction returns whether the available space is within the margin of
+// the eviction threshold
+func (fu *filesystemUsage) nearEviction() bool {
+	if fu == nil || fu.threshold.IsZero() {
+		return false
+	}
+	margin := float64(fu.capacity.Value()) * nearEvictionMargin
+	return float64(fu.available.Value()) < float64(fu.threshold.Value())+margin
+}
+
+func (fu *filesystemUsage) add(other *filesystemUsage) {
+	fu.capacity.Add(other.capacity)
+	fu.available.Add(other.available)
+	fu.threshold.Add(other.threshold)
+}
+
+// status returns DiskPressure when the kubelet reports it, NearEviction
+// when nodefs or imagefs is about to reach its eviction threshold, and OK
+// otherwise
+func (nd *nodeDisk) status() string {
+	switch {
+	case nd.pressure:
+		return diskPressure
+	case nd.nodefs.nearEviction() || nd.imagefs.nearEviction():
+		return diskNearEviction
+	case nd.nodefs == nil && nd.imagefs == nil:
+		return diskUnknown
+	default:
+		return diskOK
+	}
+}
+
+func (dc *diskCount) addNode(nd *nodeDisk) {
+	dc.nodes++
+	switch nd.status() {
+	case diskOK:
+		dc.ok++
+	case diskNearEviction:
+		dc.nearEviction++
+	case diskPressure:
+		dc.pressure++
+	}
+	dc.nodefs = addFilesystem(dc.nodefs, nd.nodefs)
+	dc.imagefs = addFilesystem(dc.imagefs, nd.imagefs)
+}
+
+func (dc *diskCount) add(other *diskCount) {
+	dc.nodes += other.nodes
+	dc.ok += other.ok
+	dc.nearEviction += other.nearEviction
+	dc.pressure += other.pressure
+	dc.nodefs = addFilesystem(dc.nodefs, other.nodefs)
+	dc.imagefs = addFilesystem(dc.imagefs, other.imagefs)
+}
+
+func addFilesystem(total, fu *filesystemUsage) *filesystemUsage {
+	if fu == nil {
+		return total
+	}
+	if total == nil {
+		total = &filesystemUsage{}
+	}
+	total.add(fu)
+	return total
+}
+
+// usedString formats the used space and its percent of the capacity,
+// example: "48128Mi (47%)"
+func (fu *filesystemUsage) usedString(run *reportRun) string {
+	if fu == nil {
+		return VoidValue
+	}
+	return fmt.Sprintf("%s (%s)", run.formatQuantity("ephemeral-storage", fu.used()), percentString(run, fu.used(), fu.capacity))
+}
+
+func (fu *filesystemUsage) usedCSVString(run *reportRun) string {
+	if fu == nil {
+		return VoidValue
+	}
+	return run.resourceCSVString("memory", fu.used())
+}
+
+func (fu *filesystemUsage) usedPercentageString(run *reportRun) string {
+	if fu == nil {
+		return VoidValue
+	}
+	return resourceCSVPercentageString(run, fu.used(), fu.capacity)
+}
+
+func (fu *filesystemUsage) listFilesystem(run *reportRun) *listFilesystem {
+	if fu == nil {
+		return nil
+	}
+	return &listFilesystem{
+		Capacity:          run.formatQuantity("ephemeral-storage", fu.capacity),
+		Used:              run.formatQuantity("ephemeral-storage", fu.used()),
+		UsedPct:           percentString(run, fu.used(), fu.capacity),
+		Available:         run.formatQuantity("ephemeral-storage", fu.available),
+		EvictionThreshold: run.formatQuantity("ephemeral-storage", fu.threshold),
+	}
+}
+
+// filesystems returns the nodefs and imagefs of a node, which are nil when
+// disks are not shown
+func (nd *nodeDisk) filesystems() (*filesystemUsage, *filesystemUsage) {
+	if nd == nil {
+		return nil, nil
+	}
+	return nd.nodefs, nd.imagefs
+}
+
+func (nd *nodeDisk) statusString() string {
+	if nd == nil {
+		return VoidValue
+	}
+	return nd.status()
+}
+
+func (nd *nodeDisk) listNodeDisk(run *reportRun) *listNodeDisk {
+	if nd == nil {
+		return nil
+	}
+	return &listNodeDisk{
+		Nodefs:  nd.nodefs.listFilesystem(run),
+		Imagefs: nd.imagefs.listFilesystem(run),
+		Status:  nd.status(),
+	}
+}
+
+// statusString summarizes the disks of the nodes of a cluster, example:
+// "4/6 OK, 1 NearEviction, 1 DiskPressure"
+func (dc *diskCount) statusString() string {
+	if dc == nil {
+		return VoidValue
+	}
+	status := fmt.Sprintf("%d/%d %s", dc.ok, dc.nodes, diskOK)
+	if dc.nearEviction > 0 {
+		status += fmt.Sprintf(", %d %s", dc.nearEviction, diskNearEviction)
+	}
+	if dc.pressure > 0 {
+		status += fmt.Sprintf(", %d %s", dc.pressure, diskPressure)
+	}
+	return status
+}
+
+func (dc *diskCount) filesystems() (*filesystemUsage, *filesystemUsage) {
+	if dc == nil {
+		return nil, nil
+	}
+	return dc.nodefs, dc.imagefs
+}
+
+func (dc *diskCount) listDiskCount(run *reportRun) *listDiskCount {
+	if dc == nil {
+		return nil
+	}
+	return &listDiskCount{
+		Nodefs:       dc.nodefs.listFilesystem(run),
+		Imagefs:      dc.imagefs.listFilesystem(run),
+		Nodes:        dc.nodes,
+		OK:           dc.ok,
+		NearEviction: dc.nearEviction,
+		DiskPressure: dc.pressure,
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestKubeletFilesystemUsage(t *testing.T) {
+	summary := &kubeletSummary{}
+	require.NoError(t, json.Unmarshal([]byte(`{"node":{
+		"fs":{"availableBytes":10737418240,"capacityBytes":107374182400,"usedBytes":90194313216},
+		"runtime":{"imageFs":{"availableBytes":32212254720,"capacityBytes":107374182400,"usedBytes":21474836480}}}}`), summary))
+
+	nodefs, err := summary.Node.Fs.filesystemUsage(nil, nodefsSignal)
+	require.NoError(t, err)
+	used := nodefs.used()
+	assert.Equal(t, "90Gi", used.String())
+	assert.Equal(t, "10Gi", nodefs.threshold.String())
+
+	// A kubelet that sets evictionHard only evicts on the signals it lists
+	config := &kubeletConfiguration{EvictionHard: map[string]string{"memory.available": "100Mi", "nodefs.available": "5Gi"}}
+	nodefs, err = summary.Node.Fs.filesystemUsage(config, nodefsSignal)
+	require.NoError(t, err)
+	assert.Equal(t, "5Gi", nodefs.threshold.String())
+	imagefs, err := summary.Node.Runtime.ImageFs.filesystemUsage(config, imagefsSignal)
+	require.NoError(t, err)
+	assert.True(t, imagefs.threshold.IsZero())
+
+	config.EvictionHard["nodefs.available"] = "110%"
+	_, err = summary.Node.Fs.filesystemUsage(config, nodefsSignal)
+	assert.EqualError(t, err, `evictionHard: invalid nodefs.available "110%"`)
+
+	missing, err := (*kubeletFsStats)(nil).filesystemUsage(nil, imagefsSignal)
+	require.NoError(t, err)
+	assert.Nil(t, missing)
+}
+
+func TestAddNodeDisk(t *testing.T) {
+	run := newTestRun(nil)
+	snap := getTestSnapshot()
+	snap.Nodes.Items[1].Status.Conditions = []corev1.NodeCondition{
+		{Type: corev1.NodeDiskPressure, Status: corev1.ConditionTrue},
+	}
+	cm := buildClusterMetric(snap.Pods, nil, snap.Nodes, nil)
+
+	filesystem := func(capacity, available string) *filesystemUsage {
+		fu := &filesystemUsage{capacity: resource.MustParse(capacity), available: resource.MustParse(available)}
+		fu.threshold, _ = diskEvictionThreshold(nil, nodefsSignal, fu.capacity)
+		return fu
+	}
+	// 12Gi available is within 5% of the 10Gi threshold
+	cm.addNodeDisk(map[string]*nodeDisk{
+		"mynode": {nodefs: filesystem("100Gi", "12Gi"), imagefs: filesystem("100Gi", "60Gi")},
+	}, snap.Nodes)
+
+	mynode, mynode2 := cm.nodeMetrics["mynode"].disk, cm.nodeMetrics["mynode2"].disk
+	assert.Equal(t, diskNearEviction, mynode.status())
+	assert.Equal(t, "90112Mi (88%)", mynode.nodefs.usedString(run))
+	assert.Equal(t, diskPressure, mynode2.status())
+	assert.Equal(t, VoidValue, mynode2.nodefs.usedString(run))
+
+	assert.Equal(t, "0/2 OK, 1 NearEviction, 1 DiskPressure", cm.disk.statusString())
+	list := cm.disk.listDiskCount(run)
+	assert.Equal(t, "88%", list.Nodefs.UsedPct)
+	assert.Equal(t, int64(1), list.DiskPressure)
+
+	// Without the DiskPressure condition or usage, the disk is unknown
+	snap.Nodes.Items[1].Status.Conditions = nil
+	cm.addNodeDisk(map[string]*nodeDisk{
+		"mynode": {nodefs: filesystem("100Gi", "50Gi")},
+	}, snap.Nodes)
+	assert.Equal(t, diskUnknown, cm.nodeMetrics["mynode2"].disk.status())
+	assert.Equal(t, "1/2 OK", cm.disk.statusString())
+}
+
+func TestNodeFilesystemSeries(t *testing.T) {
+	assert.Equal(t,
+		`label_replace(max by (instance) (node_filesystem_avail_bytes{mountpoint="/",fstype!~"tmpfs|overlay"}), "node", "$1", "instance", "(.*)")`,
+		nodeFilesystemSeries("node_filesystem_avail_bytes"))
+}
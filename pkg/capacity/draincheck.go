@@ -0,0 +1,338 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// drainRescheduled marks pods that fit on another node
+	drainRescheduled = "rescheduled"
+	// drainPending marks pods that would be evicted without room on any
+	// other node
+	drainPending = "pending"
+	// drainBlocked marks pods a PodDisruptionBudget would not let be evicted
+	drainBlocked = "blocked"
+	// drainDeleted marks pods without a controller, which are deleted
+	// rather than recreated
+	drainDeleted = "deleted"
+)
+
+// drainPod is a pod evicted from a drained node and what became of it
+type drainPod struct {
+	pm      *podMetric
+	outcome string
+	// node is the node the pod was rescheduled on
+	node string
+	// budgets are the PodDisruptionBudgets covering the pod
+	budgets []string
+}
+
+// podDisruptionBudget tracks the disruptions a budget still allows while
+// pods are evicted
+type podDisruptionBudget struct {
+	name     string
+	pdb      *policyv1.PodDisruptionBudget
+	selector labels.Selector
+	allowed  int32
+}
+
+type listDrainCheck struct {
+	Node        string          `json:"node"`
+	Drainable   bool            `json:"drainable"`
+	Rescheduled int64           `json:"rescheduled"`
+	Pending     int64           `json:"pending"`
+	Blocked     int64           `json:"blocked"`
+	Deleted     int64           `json:"deleted"`
+	Pods        []*listDrainPod `json:"pods"`
+}
+
+type listDrainPod struct {
+	Namespace            string   `json:"namespace"`
+	Name                 string   `json:"name"`
+	CPU                  string   `json:"cpu"`
+	Memory               string   `json:"memory"`
+	Outcome              string   `json:"outcome"`
+	Node                 string   `json:"node,omitempty"`
+	PodDisruptionBudgets []string `json:"podDisruptionBudgets,omitempty"`
+}
+
+// FetchAndPrintDrainCheck simulates draining opts.DrainNode and prints
+// whether its pods could be rescheduled on the remaining nodes
+func FetchAndPrintDrainCheck(opts Options) error {
+	run := newRun(opts)
+
+	// Every pod counts against a node's capacity, so only node filters apply
+	opts.Namespace = ""
+	opts.NamespaceLabels = ""
+	opts.PodLabels = ""
+	opts.PodFieldSelector = ""
+	opts.ShowUtil = false
+	opts.ShowQuotas = false
+	opts.ShowVPA = false
+	opts.ShowHeadroom = false
+	opts.ShowKarpenter = false
+	opts.ShowCapacityType = false
+	opts.ShowArch = false
+	opts.ShowZoneBalance = false
+	opts.ShowGPU = false
+	opts.SimulateRemoveNodes = ""
+	opts.ShowHPAHeadroom = false
+	opts.AllocateByPodLabel = ""
+	opts.ShowPending = false
+	opts.ShowDaemonSetOverhead = false
+	opts.ShowAnomalies = false
+	opts.ShowStuckPods = false
+	opts.Preemption = ""
+	opts.ShowMissingResources = false
+	opts.Stream = false
+	cm, ferr := fetchClusterMetric(run, opts)
+	if ferr != nil {
+		return ferr
+	}
+
+	if _, ok := cm.nodeMetrics[opts.DrainNode]; !ok {
+		return fmt.Errorf("Error: node %s not found", opts.DrainNode)
+	}
+
+	dp := &drainPrinter{run: run, node: opts.DrainNode, opts: opts}
+	dp.pods = simulateDrain(run, &cm, opts.DrainNode)
+	if err := dp.Print(opts.OutputFormat); err != nil {
+		return err
+	}
+	return run.closeOutput()
+}
+
+func getPodDisruptionBudgets(run *reportRun, clientset kubernetes.Interface) (*policyv1.PodDisruptionBudgetList, *fetchError) {
+	start := time.Now()
+	pdbList, err := clientset.PolicyV1().PodDisruptionBudgets("").List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, newFetchError(3, "Error listing PodDisruptionBudgets: %v", err)
+	}
+	run.logTiming(start, "Listed %d PodDisruptionBudgets", len(pdbList.Items))
+	return pdbList, nil
+}
+
+// simulateDrain evicts the pods of the named nodes, largest first as in
+// fit, and places each on the first remaining node, by name, it is allowed
+// on and has the requests and a pod slot left for. DaemonSet and static
+// pods stay on their node. A pod is blocked when a PodDisruptionBudget
+// covering it allows no more disruptions; a rescheduled pod gives its
+// disruption back once its replacement is running, while a pending one
+// keeps it.
+func simulateDrain(run *reportRun, cm *clusterMetric, names ...string) []*drainPod {
+	drained := map[string]bool{}
+	for _, name := range names {
+		drained[name] = true
+	}
+	nodes := []*fitNode{}
+	for _, fn := range newFitNodes(cm) {
+		if !drained[fn.nm.name] {
+			nodes = append(nodes, fn)
+		}
+	}
+
+	budgets := []*podDisruptionBudget{}
+	if cm.podDisruptionBudgets != nil {
+		for i := range cm.podDisruptionBudgets.Items {
+			pdb := &cm.podDisruptionBudgets.Items[i]
+			selector, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
+			if err != nil {
+				run.logWarnf("ignoring PodDisruptionBudget %s with an invalid selector: %v", podKey(pdb.Namespace, pdb.Name), err)
+				continue
+			}
+			budgets = append(budgets, &podDisruptionBudget{
+				name:     podKey(pdb.Namespace, pdb.Name),
+				pdb:      pdb,
+				selector: selector,
+				allowed:  pdb.Status.DisruptionsAllowed,
+			})
+		}
+	}
+
+	evicted := []*podMetric{}
+	for _, name := range names {
+		for _, pm := range cm.nodeMetrics[name].podMetrics {
+			if !pm.daemonSet && !pm.mirror {
+				evicted = append(evicted, pm)
+			}
+		}
+	}
+	sort.Slice(evicted, func(i, j int) bool {
+		if c := evicted[i].cpu.request.Cmp(evicted[j].cpu.request); c != 0 {
+			return c > 0
+		}
+		if c := evicted[i].memory.request.Cmp(evicted[j].memory.request); c != 0 {
+			return c > 0
+		}
+		return podKey(evicted[i].namespace, evicted[i].name) < podKey(evicted[j].namespace, evicted[j].name)
+	})
+
+	pods := []*drainPod{}
+	for _, pm := range evicted {
+		dp := &drainPod{pm: pm}
+		covering := []*podDisruptionBudget{}
+		for _, b := range budgets {
+			if b.pdb.Namespace == pm.namespace && b.selector.Matches(labels.Set(pm.labels)) {
+				covering = append(covering, b)
+				dp.budgets = append(dp.budgets, b.name)
+			}
+		}
+		pods = append(pods, dp)
+
+		blocked := false
+		for _, b := range covering {
+			if b.allowed < 1 {
+				blocked = true
+			}
+		}
+		if blocked {
+			dp.outcome = drainBlocked
+			continue
+		}
+
+		if pm.unmanaged {
+			dp.outcome = drainDeleted
+			continue
+		}
+
+		w := &fitWorkload{
+			name:                  podKey(pm.namespace, pm.name),
+			cpu:                   pm.cpu.request,
+			memory:                pm.memory.request,
+			replicas:              1,
+			schedulingConstraints: pm.scheduling,
+		}
+		for _, fn := range nodes {
+			if fn.fits(w) {
+				fn.cpu.Sub(w.cpu)
+				fn.memory.Sub(w.memory)
+				fn.pods--
+				dp.node = fn.nm.name
+				break
+			}
+		}
+		if dp.node != "" {
+			dp.outcome = drainRescheduled
+			continue
+		}
+
+		dp.outcome = drainPending
+		for _, b := range covering {
+			b.allowed--
+		}
+	}
+	return pods
+}
+
+type drainPrinter struct {
+	run  *reportRun
+	node string
+	pods []*drainPod
+	opts Options
+}
+
+func (dp *drainPrinter) Print(outputType string) error {
+	switch outputType {
+	case JSONOutput, YAMLOutput:
+		printListOutput(dp.run, DrainCheckReportKind, dp.buildListDrainCheck(), outputType)
+	case TableOutput:
+		w := tabwriter.NewWriter(dp.run.output, 0, 8, 2, ' ', 0)
+		dp.printTable(w, "\t ")
+		if err := w.Flush(); err != nil {
+			dp.run.logErrorf("Error writing to table: %s", err)
+		}
+	case CSVOutput:
+		dp.printTable(dp.run.output, ",")
+	case TSVOutput:
+		dp.printTable(dp.run.output, "\t")
+	default:
+		return fmt.Errorf("Called with an unsupported output type: %s", outputType)
+	}
+	return nil
+}
+
+// printTable prints whether the drain succeeds, followed by what becomes
+// of each evicted pod
+func (dp *drainPrinter) printTable(w io.Writer, separator string) {
+	list := dp.buildListDrainCheck()
+
+	drain := "fails"
+	if list.Drainable {
+		drain = "succeeds"
+	}
+	_, _ = fmt.Fprintln(w, strings.Join([]string{"NODE", "PODS", "RESCHEDULED", "PENDING", "BLOCKED", "DELETED", "DRAIN"}, separator))
+	_, _ = fmt.Fprintln(w, strings.Join([]string{
+		list.Node,
+		fmt.Sprintf("%d", len(list.Pods)),
+		fmt.Sprintf("%d", list.Rescheduled),
+		fmt.Sprintf("%d", list.Pending),
+		fmt.Sprintf("%d", list.Blocked),
+		fmt.Sprintf("%d", list.Deleted),
+		drain,
+	}, separator))
+
+	_, _ = fmt.Fprintln(w)
+	_, _ = fmt.Fprintln(w, strings.Join([]string{"NAMESPACE", "POD", "CPU REQUESTS", "MEMORY REQUESTS", "OUTCOME", "NODE", "POD DISRUPTION BUDGETS"}, separator))
+	for _, lp := range list.Pods {
+		_, _ = fmt.Fprintln(w, strings.Join([]string{
+			lp.Namespace, lp.Name, lp.CPU, lp.Memory, lp.Outcome, voidIfEmpty(lp.Node), voidIfEmpty(strings.Join(lp.PodDisruptionBudgets, " ")),
+		}, separator))
+	}
+}
+
+// buildListDrainCheck lists every evicted pod, largest first. The drain
+// succeeds when no pod is left pending or blocked.
+func (dp *drainPrinter) buildListDrainCheck() *listDrainCheck {
+	cpu := resourceMetric{resourceType: "cpu"}
+	memory := resourceMetric{resourceType: "memory"}
+
+	out := &listDrainCheck{Node: dp.node, Pods: []*listDrainPod{}}
+	for _, p := range dp.pods {
+		switch p.outcome {
+		case drainRescheduled:
+			out.Rescheduled++
+		case drainPending:
+			out.Pending++
+		case drainBlocked:
+			out.Blocked++
+		case drainDeleted:
+			out.Deleted++
+		}
+		out.Pods = append(out.Pods, &listDrainPod{
+			Namespace:            p.pm.namespace,
+			Name:                 p.pm.name,
+			CPU:                  cpu.valueFunction(dp.run)(p.pm.cpu.request),
+			Memory:               memory.valueFunction(dp.run)(p.pm.memory.request),
+			Outcome:              p.outcome,
+			Node:                 p.node,
+			PodDisruptionBudgets: p.budgets,
+		})
+	}
+	out.Drainable = out.Pending == 0 && out.Blocked == 0
+	return out
+}
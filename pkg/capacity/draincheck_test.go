@@ -0,0 +1,141 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func drainTestClusterMetric(allowed int32) *clusterMetric {
+	web := map[string]string{"app": "web"}
+	allocatable := func(cpu string) corev1.ResourceList {
+		return corev1.ResourceList{
+			"cpu":    resource.MustParse(cpu),
+			"memory": resource.MustParse("4000Mi"),
+			"pods":   resource.MustParse("110"),
+		}
+	}
+	requests := func(cpu string) corev1.ResourceList {
+		return corev1.ResourceList{"cpu": resource.MustParse(cpu)}
+	}
+	owned := func(p *corev1.Pod, kind string) corev1.Pod {
+		p.OwnerReferences = controllerRef(kind, "owner")
+		return *p
+	}
+
+	nodes := &corev1.NodeList{Items: []corev1.Node{
+		*nodeWithAllocatable("a", nil, allocatable("4000m")),
+		*nodeWithAllocatable("b", nil, allocatable("1000m")),
+		*nodeWithAllocatable("c", nil, allocatable("1000m")),
+	}}
+	pods := &corev1.PodList{Items: []corev1.Pod{
+		owned(podWithRequests("a", "default", "web-1", web, requests("600m")), "ReplicaSet"),
+		owned(podWithRequests("a", "default", "web-2", web, requests("600m")), "ReplicaSet"),
+		owned(podWithRequests("a", "default", "web-3", web, requests("500m")), "ReplicaSet"),
+		*podWithRequests("a", "default", "bare", nil, requests("100m")),
+		owned(podWithRequests("a", "default", "logs", nil, requests("100m")), "DaemonSet"),
+		owned(podWithRequests("b", "default", "filler", nil, requests("300m")), "ReplicaSet"),
+		owned(podWithRequests("c", "default", "filler2", nil, requests("900m")), "ReplicaSet"),
+	}}
+	cm := buildClusterMetric(pods, nil, nodes, nil)
+	cm.podDisruptionBudgets = &policyv1.PodDisruptionBudgetList{Items: []policyv1.PodDisruptionBudget{{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web"},
+		Spec:       policyv1.PodDisruptionBudgetSpec{Selector: &metav1.LabelSelector{MatchLabels: web}},
+		Status:     policyv1.PodDisruptionBudgetStatus{DisruptionsAllowed: allowed},
+	}}}
+	return &cm
+}
+
+func TestSimulateDrain(t *testing.T) {
+	run := newTestRun(nil)
+	cm := drainTestClusterMetric(1)
+
+	// web-1 fits on b, web-2 fits nowhere and uses up the disruption the
+	// budget allows, which blocks web-3. The DaemonSet pod stays.
+	pods := simulateDrain(run, cm, "a")
+	require.Len(t, pods, 4)
+	outcomes := []string{}
+	for _, p := range pods {
+		outcomes = append(outcomes, p.pm.name+"="+p.outcome+":"+p.node)
+	}
+	assert.Equal(t, []string{
+		"web-1=rescheduled:b",
+		"web-2=pending:",
+		"web-3=blocked:",
+		"bare=deleted:",
+	}, outcomes)
+	assert.Equal(t, []string{"default/web"}, pods[0].budgets)
+
+	dp := &drainPrinter{run: run, node: "a", pods: pods}
+	list := dp.buildListDrainCheck()
+	assert.False(t, list.Drainable)
+	assert.Equal(t, int64(1), list.Rescheduled)
+	assert.Equal(t, int64(1), list.Pending)
+	assert.Equal(t, int64(1), list.Blocked)
+	assert.Equal(t, int64(1), list.Deleted)
+
+	var buf bytes.Buffer
+	dp.printTable(&buf, ",")
+	assert.Equal(t, strings.Join([]string{
+		"NODE,PODS,RESCHEDULED,PENDING,BLOCKED,DELETED,DRAIN",
+		"a,4,1,1,1,1,fails",
+		"",
+		"NAMESPACE,POD,CPU REQUESTS,MEMORY REQUESTS,OUTCOME,NODE,POD DISRUPTION BUDGETS",
+		"default,web-1,600m,0Mi,rescheduled,b,default/web",
+		"default,web-2,600m,0Mi,pending,*,default/web",
+		"default,web-3,500m,0Mi,blocked,*,default/web",
+		"default,bare,100m,0Mi,deleted,*,*",
+	}, "\n")+"\n", buf.String())
+}
+
+func TestSimulateDrainWithoutDisruptionsAllowed(t *testing.T) {
+	run := newTestRun(nil)
+	cm := drainTestClusterMetric(0)
+
+	pods := simulateDrain(run, cm, "a")
+	for _, p := range pods[:3] {
+		assert.Equal(t, drainBlocked, p.outcome)
+	}
+}
+
+func TestSimulateDrainSucceeds(t *testing.T) {
+	run := newTestRun(nil)
+	cm := drainTestClusterMetric(3)
+	cm.podDisruptionBudgets = nil
+	for _, nm := range cm.nodeMetrics {
+		delete(nm.podMetrics, podKey("default", "web-2"))
+		delete(nm.podMetrics, podKey("default", "bare"))
+	}
+
+	// web-1 takes b, which leaves no room for web-3 there either
+	pods := simulateDrain(run, cm, "a")
+	require.Len(t, pods, 2)
+	assert.Equal(t, drainRescheduled, pods[0].outcome)
+	assert.Equal(t, drainPending, pods[1].outcome)
+
+	cm.nodeMetrics["c"].cpu.request = resource.MustParse("0")
+	pods = simulateDrain(run, cm, "a")
+	assert.Equal(t, "c", pods[1].node)
+	assert.True(t, (&drainPrinter{run: run, pods: pods}).buildListDrainCheck().Drainable)
+}
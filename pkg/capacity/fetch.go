@@ -0,0 +1,91 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// fetchGroup runs independent API calls at the same time. The first call to
+// fail cancels the context shared by the others, and its error is returned
+// once every call has returned.
+type fetchGroup struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	once   sync.Once
+	err    *fetchError
+}
+
+// fetchError is a failed API call, or any other failure of a run such as a
+// breached --fail-on threshold, along with the exit code it is reported with
+type fetchError struct {
+	code     int
+	messages []string
+}
+
+func newFetchError(code int, format string, args ...interface{}) *fetchError {
+	return &fetchError{code: code, messages: []string{fmt.Sprintf(format, args...)}}
+}
+
+// withHint adds a line explaining how to fix the error
+func (e *fetchError) withHint(hint string) *fetchError {
+	e.messages = append(e.messages, hint)
+	return e
+}
+
+func (e *fetchError) Error() string {
+	return strings.Join(e.messages, "\n")
+}
+
+// ExitCode returns the exit code kube-capacity exits with for an error
+// returned by a report, 1 unless the error carries its own code
+func ExitCode(err error) int {
+	var ferr *fetchError
+	if errors.As(err, &ferr) {
+		return ferr.code
+	}
+	return 1
+}
+
+func newFetchGroup() *fetchGroup {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &fetchGroup{ctx: ctx, cancel: cancel}
+}
+
+// run starts fetch in its own goroutine
+func (g *fetchGroup) run(fetch func(ctx context.Context) *fetchError) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		if err := fetch(g.ctx); err != nil {
+			g.once.Do(func() {
+				g.err = err
+				g.cancel()
+			})
+		}
+	}()
+}
+
+// wait blocks until every fetch has returned and gives the first error
+func (g *fetchGroup) wait() *fetchError {
+	g.wg.Wait()
+	g.cancel()
+	return g.err
+}
@@ -0,0 +1,77 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFetchGroupConcurrent(t *testing.T) {
+	g := newFetchGroup()
+
+	// Neither fetch returns until both have started
+	var barrier sync.WaitGroup
+	barrier.Add(2)
+	for i := 0; i < 2; i++ {
+		g.run(func(ctx context.Context) *fetchError {
+			barrier.Done()
+			barrier.Wait()
+			return nil
+		})
+	}
+
+	done := make(chan *fetchError)
+	go func() {
+		done <- g.wait()
+	}()
+
+	select {
+	case err := <-done:
+		assert.Nil(t, err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("fetches did not run at the same time")
+	}
+}
+
+func TestFetchGroupCancelsOnError(t *testing.T) {
+	g := newFetchGroup()
+
+	cancelled := make(chan bool, 1)
+	g.run(func(ctx context.Context) *fetchError {
+		<-ctx.Done()
+		cancelled <- true
+		return newFetchError(3, "Error listing Pods: %v", ctx.Err())
+	})
+	g.run(func(ctx context.Context) *fetchError {
+		return newFetchError(2, "Error listing Nodes: %s", "forbidden").withHint("check RBAC")
+	})
+
+	err := g.wait()
+	assert.True(t, <-cancelled)
+	assert.Equal(t, &fetchError{code: 2, messages: []string{"Error listing Nodes: forbidden", "check RBAC"}}, err)
+}
+
+func TestExitCode(t *testing.T) {
+	assert.Equal(t, 1, ExitCode(errors.New("Error: boom")))
+	assert.Equal(t, 2, ExitCode(newFetchError(2, "Error listing Nodes: %s", "forbidden")))
+	assert.Equal(t, 4, ExitCode(fmt.Errorf("context prod: %w", newFetchError(4, "Error getting metrics"))))
+}
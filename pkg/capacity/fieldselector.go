@@ -0,0 +1,89 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/fields"
+)
+
+// podSelectableFields returns the fields of a pod that the API server
+// accepts in a pod field selector
+func podSelectableFields(pod *corev1.Pod) fields.Set {
+	return fields.Set{
+		"metadata.name":            pod.Name,
+		"metadata.namespace":       pod.Namespace,
+		"spec.nodeName":            pod.Spec.NodeName,
+		"spec.restartPolicy":       string(pod.Spec.RestartPolicy),
+		"spec.schedulerName":       pod.Spec.SchedulerName,
+		"spec.serviceAccountName":  pod.Spec.ServiceAccountName,
+		"spec.hostNetwork":         strconv.FormatBool(pod.Spec.HostNetwork),
+		"status.phase":             string(pod.Status.Phase),
+		"status.podIP":             pod.Status.PodIP,
+		"status.nominatedNodeName": pod.Status.NominatedNodeName,
+	}
+}
+
+// ValidatePodFieldSelector returns an error for a --pod-field-selector that
+// can not be parsed or selects on a field pods can not be selected by
+func ValidatePodFieldSelector(selector string) error {
+	_, err := parsePodFieldSelector(selector)
+	return err
+}
+
+func parsePodFieldSelector(selector string) (fields.Selector, error) {
+	parsed, err := fields.ParseSelector(selector)
+	if err != nil {
+		return nil, err
+	}
+	supported := podSelectableFields(&corev1.Pod{})
+	for _, r := range parsed.Requirements() {
+		if !supported.Has(r.Field) {
+			names := []string{}
+			for name := range supported {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			return nil, fmt.Errorf("pods can not be selected by %s, only by %v", r.Field, names)
+		}
+	}
+	return parsed, nil
+}
+
+// filterPodsByFields removes the pods that do not match a field selector.
+// The API server already applies it to the pods it lists, but the fake
+// clientset used to read snapshots ignores field selectors.
+func filterPodsByFields(podList *corev1.PodList, selector string) error {
+	if selector == "" {
+		return nil
+	}
+	parsed, err := parsePodFieldSelector(selector)
+	if err != nil {
+		return err
+	}
+
+	pods := []corev1.Pod{}
+	for i := range podList.Items {
+		if parsed.Matches(podSelectableFields(&podList.Items[i])) {
+			pods = append(pods, podList.Items[i])
+		}
+	}
+	podList.Items = pods
+	return nil
+}
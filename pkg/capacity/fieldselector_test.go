@@ -0,0 +1,89 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestValidatePodFieldSelector(t *testing.T) {
+	assert.NoError(t, ValidatePodFieldSelector("spec.nodeName=node-12"))
+	assert.NoError(t, ValidatePodFieldSelector("status.phase=Running,metadata.namespace!=kube-system"))
+	assert.Error(t, ValidatePodFieldSelector("spec.nodeName"))
+	assert.Error(t, ValidatePodFieldSelector("metadata.labels.app=web"))
+}
+
+func TestGetPodsAndNodesWithFieldSelector(t *testing.T) {
+	run := newTestRun(nil)
+	clientset := fake.NewSimpleClientset(
+		node("mynode", map[string]string{}, false),
+		node("mynode2", map[string]string{}, false),
+		pod("mynode", "default", "mypod", map[string]string{}),
+		pod("mynode2", "default", "mypod2", map[string]string{}),
+		pod("mynode2", "other", "mypod3", map[string]string{}),
+	)
+
+	fieldSelectors := []string{}
+	clientset.PrependReactor("list", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		restrictions := action.(k8stesting.ListAction).GetListRestrictions()
+		fieldSelectors = append(fieldSelectors, restrictions.Fields.String())
+		return false, nil, nil
+	})
+
+	// The fake clientset ignores field selectors like snapshots do, so the
+	// pods are filtered client side as well
+	podList, _, _ := getPodsAndNodes(run, clientset, listFilters{podFieldSelector: "metadata.namespace=default"})
+	assert.Equal(t, []string{"metadata.namespace=default"}, fieldSelectors)
+	assert.Equal(t, []string{"default/mypod", "default/mypod2"}, listPods(podList))
+}
+
+func TestListPodsOnNodesKeepsFieldSelector(t *testing.T) {
+	run := newTestRun(nil)
+
+	clientset := fake.NewSimpleClientset(
+		node("mynode", map[string]string{}, false),
+		pod("mynode", "default", "mypod", map[string]string{}),
+	)
+
+	fieldSelectors := []string{}
+	clientset.PrependReactor("list", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		restrictions := action.(k8stesting.ListAction).GetListRestrictions()
+		fieldSelectors = append(fieldSelectors, restrictions.Fields.String())
+		return false, nil, nil
+	})
+
+	_, _, _ = getPodsAndNodes(run, clientset, listFilters{podFieldSelector: "status.phase=Running", podsByNode: true})
+	require.Len(t, fieldSelectors, 1)
+	assert.Contains(t, fieldSelectors[0], "status.phase=Running")
+	assert.Contains(t, fieldSelectors[0], "spec.nodeName=mynode")
+}
+
+func TestFilterPodsByFields(t *testing.T) {
+	running := pod("mynode", "default", "running", map[string]string{})
+	running.Status.Phase = corev1.PodRunning
+	pending := pod("mynode", "default", "pending", map[string]string{})
+	pending.Status.Phase = corev1.PodPending
+	podList := &corev1.PodList{Items: []corev1.Pod{*running, *pending}}
+
+	require.NoError(t, filterPodsByFields(podList, "status.phase=Running"))
+	assert.Equal(t, []string{"default/running"}, listPods(podList))
+}
@@ -0,0 +1,505 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// filterOperators are checked in order so ">=" is not read as ">"
+var filterOperators = []string{"||", "&&", "==", "!=", "=~", "!~", ">=", "<=", ">", "<", "!", "(", ")"}
+
+// filterExpr is a --filter expression, or a part of one, evaluated
+// against a row of the report
+type filterExpr interface {
+	eval(row *filterRow) bool
+}
+
+// filterRow is the node of a row and, for pod and container rows, its pod
+// and container
+type filterRow struct {
+	node        *nodeMetric
+	pod         *podMetric
+	container   *containerMetric
+	utilPercent string
+}
+
+type filterOr struct{ left, right filterExpr }
+
+func (f *filterOr) eval(row *filterRow) bool { return f.left.eval(row) || f.right.eval(row) }
+
+type filterAnd struct{ left, right filterExpr }
+
+func (f *filterAnd) eval(row *filterRow) bool { return f.left.eval(row) && f.right.eval(row) }
+
+type filterNot struct{ expr filterExpr }
+
+func (f *filterNot) eval(row *filterRow) bool { return !f.expr.eval(row) }
+
+// filterComparison compares a field of a node, pod, or container with a
+// value. Comparisons of a pod or container field are false on rows without
+// one.
+type filterComparison struct {
+	scope    string
+	field    string
+	resource string
+	metric   string
+	operator string
+
+	text     string
+	pattern  *regexp.Regexp
+	number   float64
+	quantity *resource.Quantity
+}
+
+// ValidateFilter returns an error for a --filter expression that can not
+// be parsed or that compares utilization without --util
+func ValidateFilter(opts Options) error {
+	f, err := parseFilter(opts.Filter)
+	if err != nil {
+		return err
+	}
+	if !opts.ShowUtil && filterUsesUtil(f) {
+		return fmt.Errorf("comparing utilization requires --util")
+	}
+	return nil
+}
+
+func filterUsesUtil(f filterExpr) bool {
+	switch f := f.(type) {
+	case *filterOr:
+		return filterUsesUtil(f.left) || filterUsesUtil(f.right)
+	case *filterAnd:
+		return filterUsesUtil(f.left) || filterUsesUtil(f.right)
+	case *filterNot:
+		return filterUsesUtil(f.expr)
+	case *filterComparison:
+		return f.metric == "util"
+	}
+	return false
+}
+
+// filterToken is a token of a --filter expression and where it starts
+type filterToken struct {
+	text     string
+	quoted   bool
+	operator bool
+	pos      int
+}
+
+// lexFilter splits an expression into operators, quoted strings, and
+// words, which are fields or values like 80, 80%, or 256Mi
+func lexFilter(expression string) ([]filterToken, error) {
+	tokens := []filterToken{}
+	for i := 0; i < len(expression); {
+		c := expression[i]
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '"' || c == '\'':
+			end := strings.IndexByte(expression[i+1:], c)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated string at position %d", i+1)
+			}
+			tokens = append(tokens, filterToken{text: expression[i+1 : i+1+end], quoted: true, pos: i})
+			i += end + 2
+		default:
+			operator := ""
+			for _, op := range filterOperators {
+				if strings.HasPrefix(expression[i:], op) {
+					operator = op
+					break
+				}
+			}
+			if operator != "" {
+				tokens = append(tokens, filterToken{text: operator, operator: true, pos: i})
+				i += len(operator)
+				continue
+			}
+			start := i
+			for i < len(expression) && isFilterWordChar(rune(expression[i])) {
+				i++
+			}
+			if i == start {
+				return nil, fmt.Errorf("unexpected %q at position %d", expression[i], i+1)
+			}
+			tokens = append(tokens, filterToken{text: expression[start:i], pos: start})
+		}
+	}
+	return tokens, nil
+}
+
+func isFilterWordChar(c rune) bool {
+	return unicode.IsLetter(c) || unicode.IsDigit(c) || strings.ContainsRune("._-%", c)
+}
+
+// filterParser is a recursive descent parser of the grammar
+//
+//	or         = and { "||" and }
+//	and        = unary { "&&" unary }
+//	unary      = "!" unary | "(" or ")" | comparison
+//	comparison = field operator value
+type filterParser struct {
+	tokens []filterToken
+	pos    int
+}
+
+// parseFilter parses a --filter expression like
+// node.cpu.util > 80 || pod.mem.requests == 0
+func parseFilter(expression string) (filterExpr, error) {
+	tokens, err := lexFilter(expression)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("empty filter")
+	}
+	p := &filterParser{tokens: tokens}
+	f, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, p.unexpected()
+	}
+	return f, nil
+}
+
+func (p *filterParser) peek() *filterToken {
+	if p.pos >= len(p.tokens) {
+		return nil
+	}
+	return &p.tokens[p.pos]
+}
+
+// accept consumes the next token if it is the given unquoted operator
+func (p *filterParser) accept(operator string) bool {
+	if t := p.peek(); t != nil && t.operator && t.text == operator {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *filterParser) unexpected() error {
+	t := p.peek()
+	if t == nil {
+		return fmt.Errorf("unexpected end of filter")
+	}
+	return fmt.Errorf("unexpected %q at position %d", t.text, t.pos+1)
+}
+
+func (p *filterParser) parseOr() (filterExpr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("||") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &filterOr{left, right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseAnd() (filterExpr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("&&") {
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &filterAnd{left, right}
+	}
+	return left, nil
+}
+
+func (p *filterParser) parseUnary() (filterExpr, error) {
+	if p.accept("!") {
+		expr, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &filterNot{expr}, nil
+	}
+	if p.accept("(") {
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.accept(")") {
+			return nil, p.unexpected()
+		}
+		return expr, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *filterParser) parseComparison() (filterExpr, error) {
+	field := p.peek()
+	if field == nil || field.quoted || field.operator {
+		return nil, p.unexpected()
+	}
+	p.pos++
+	c, err := parseFilterField(field.text)
+	if err != nil {
+		return nil, fmt.Errorf("%w at position %d", err, field.pos+1)
+	}
+
+	operator := p.peek()
+	if operator == nil || !operator.operator || !isFilterComparison(operator.text) {
+		return nil, p.unexpected()
+	}
+	p.pos++
+	c.operator = operator.text
+
+	value := p.peek()
+	if value == nil || value.operator {
+		return nil, p.unexpected()
+	}
+	p.pos++
+	if err := c.setValue(value); err != nil {
+		return nil, fmt.Errorf("%w at position %d", err, value.pos+1)
+	}
+	return c, nil
+}
+
+func isFilterComparison(operator string) bool {
+	switch operator {
+	case "==", "!=", "=~", "!~", ">=", "<=", ">", "<":
+		return true
+	}
+	return false
+}
+
+// parseFilterField parses fields in the form <node|pod|container>.name,
+// pod.namespace, node.pods, or
+// <node|pod|container>.<cpu|mem|memory>.<requests|limits|util|allocatable>
+func parseFilterField(field string) (*filterComparison, error) {
+	invalid := fmt.Errorf("unsupported field %q, expected a field like node.name or pod.cpu.requests", field)
+	parts := strings.Split(field, ".")
+	c := &filterComparison{scope: parts[0]}
+	switch c.scope {
+	case "node", "pod", "container":
+	default:
+		return nil, invalid
+	}
+
+	switch {
+	case len(parts) == 2 && parts[1] == "name":
+		c.field = "name"
+	case len(parts) == 2 && parts[1] == "namespace" && c.scope != "node":
+		c.field = "namespace"
+	case len(parts) == 2 && parts[1] == "pods" && c.scope == "node":
+		c.field = "pods"
+	case len(parts) == 3:
+		switch parts[1] {
+		case "cpu":
+			c.resource = "cpu"
+		case "mem", "memory":
+			c.resource = "memory"
+		default:
+			return nil, invalid
+		}
+		switch parts[2] {
+		case "request", "requests":
+			c.metric = "requests"
+		case "limit", "limits":
+			c.metric = "limits"
+		case "util", "allocatable":
+			c.metric = parts[2]
+		default:
+			return nil, invalid
+		}
+		c.field = c.resource + "." + c.metric
+	default:
+		return nil, invalid
+	}
+	return c, nil
+}
+
+// setValue parses the value a field is compared with. Names are compared
+// with strings, or matched against a regular expression with =~ and !~.
+// Resources are compared with a percentage of allocatable like 80 or 80%,
+// or with a quantity like 500m or 4Gi, and allocatable only with
+// quantities.
+func (c *filterComparison) setValue(value *filterToken) error {
+	textual := c.field == "name" || c.field == "namespace"
+	matches := c.operator == "=~" || c.operator == "!~"
+	switch {
+	case textual && matches:
+		re, err := regexp.Compile(value.text)
+		if err != nil {
+			return fmt.Errorf("invalid regular expression %q: %v", value.text, err)
+		}
+		c.pattern = re
+		return nil
+	case textual:
+		if c.operator != "==" && c.operator != "!=" {
+			return fmt.Errorf("%s.%s can only be compared with ==, !=, =~, or !~", c.scope, c.field)
+		}
+		c.text = value.text
+		return nil
+	case matches:
+		return fmt.Errorf("%s.%s can not be matched against a regular expression", c.scope, c.field)
+	case value.quoted:
+		return fmt.Errorf("%s.%s can not be compared with a string", c.scope, c.field)
+	}
+
+	if c.field == "pods" {
+		number, err := strconv.ParseFloat(value.text, 64)
+		if err != nil {
+			return fmt.Errorf("node.pods can only be compared with a number")
+		}
+		c.number = number
+		return nil
+	}
+	if c.metric != "allocatable" {
+		if number, err := strconv.ParseFloat(strings.TrimSuffix(value.text, "%"), 64); err == nil {
+			c.number = number
+			return nil
+		}
+	}
+	q, err := resource.ParseQuantity(value.text)
+	if err != nil {
+		return fmt.Errorf("invalid value %q, expected a percentage like 80%% or a quantity like 256Mi", value.text)
+	}
+	c.quantity = &q
+	return nil
+}
+
+func (c *filterComparison) eval(row *filterRow) bool {
+	var name, namespace string
+	var cpu, memory *resourceMetric
+	switch c.scope {
+	case "node":
+		name, cpu, memory = row.node.name, row.node.cpu, row.node.memory
+	case "pod":
+		if row.pod == nil {
+			return false
+		}
+		name, namespace, cpu, memory = row.pod.name, row.pod.namespace, row.pod.cpu, row.pod.memory
+	case "container":
+		if row.container == nil {
+			return false
+		}
+		name, namespace, cpu, memory = row.container.name, row.pod.namespace, row.container.cpu, row.container.memory
+	}
+
+	switch c.field {
+	case "name", "namespace":
+		value := name
+		if c.field == "namespace" {
+			value = namespace
+		}
+		switch c.operator {
+		case "=~":
+			return c.pattern.MatchString(value)
+		case "!~":
+			return !c.pattern.MatchString(value)
+		case "==":
+			return value == c.text
+		default:
+			return value != c.text
+		}
+	case "pods":
+		return compareFilterValue(float64(row.node.podCount.current), c.operator, c.number)
+	}
+
+	rm := cpu
+	if c.resource == "memory" {
+		rm = memory
+	}
+	actual, base := rm.request, rm.allocatable
+	switch c.metric {
+	case "limits":
+		actual = rm.limit
+	case "util":
+		actual, base = rm.utilization, rm.utilBase(row.utilPercent)
+	case "allocatable":
+		actual = rm.allocatable
+	}
+	if c.quantity != nil {
+		return compareFilterValue(float64(actual.MilliValue()), c.operator, float64(c.quantity.MilliValue()))
+	}
+	percent := float64(0)
+	if base.MilliValue() > 0 {
+		percent = float64(actual.MilliValue()) / float64(base.MilliValue()) * 100
+	}
+	return compareFilterValue(percent, c.operator, c.number)
+}
+
+func compareFilterValue(actual float64, operator string, value float64) bool {
+	switch operator {
+	case "==":
+		return actual == value
+	case "!=":
+		return actual != value
+	default:
+		return compareThreshold(actual, operator, value)
+	}
+}
+
+// applyFilter removes the rows that do not match a --filter expression.
+// A pod matching on its own keeps all of its containers, and a node is kept
+// when it matches on its own or any of its pods or containers do. Nodes
+// keep their own totals, and the cluster totals are summed from the nodes
+// that are kept.
+func (cm *clusterMetric) applyFilter(f filterExpr, utilPercent string) {
+	cm.cpu = &resourceMetric{resourceType: "cpu"}
+	cm.memory = &resourceMetric{resourceType: "memory"}
+	cm.podCount = &podCount{}
+	cm.nodeStatus = &nodeStatusCount{}
+	cm.pendingResizes = 0
+
+	for name, nm := range cm.nodeMetrics {
+		keep := f.eval(&filterRow{node: nm, utilPercent: utilPercent})
+		for key, pm := range nm.podMetrics {
+			if f.eval(&filterRow{node: nm, pod: pm, utilPercent: utilPercent}) {
+				keep = true
+				continue
+			}
+			for containerName, c := range pm.containerMetrics {
+				if !f.eval(&filterRow{node: nm, pod: pm, container: c, utilPercent: utilPercent}) {
+					delete(pm.containerMetrics, containerName)
+				}
+			}
+			if len(pm.containerMetrics) == 0 {
+				delete(nm.podMetrics, key)
+				continue
+			}
+			keep = true
+		}
+		if !keep {
+			delete(cm.nodeMetrics, name)
+			continue
+		}
+		cm.addNodeMetric(nm)
+		cm.nodeStatus.addNode(nm)
+		cm.podCount.current += nm.podCount.current
+		cm.podCount.allocatable += nm.podCount.allocatable
+	}
+}
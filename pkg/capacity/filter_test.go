@@ -0,0 +1,113 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestParseFilter(t *testing.T) {
+	for _, expression := range []string{
+		"node.cpu.util > 80 || pod.mem.request == 0",
+		"!(pod.namespace == 'kube-system') && pod.cpu.limits >= 500m",
+		`node.name =~ "^gpu-" && node.pods < 10`,
+		"container.memory.requests>256Mi",
+		"node.cpu.allocatable <= 4",
+	} {
+		_, err := parseFilter(expression)
+		assert.NoError(t, err, expression)
+	}
+
+	for expression, message := range map[string]string{
+		"":                          "empty filter",
+		"node.cpu.util >":           "unexpected end of filter",
+		"node.gpu.util > 80":        `unsupported field "node.gpu.util", expected a field like node.name or pod.cpu.requests at position 1`,
+		"node.namespace == 'x'":     `unsupported field "node.namespace", expected a field like node.name or pod.cpu.requests at position 1`,
+		"(pod.cpu.requests > 1":     "unexpected end of filter",
+		"pod.name > 'a'":            "pod.name can only be compared with ==, !=, =~, or !~ at position 12",
+		"pod.cpu.requests =~ 'a'":   "pod.cpu.requests can not be matched against a regular expression at position 21",
+		"pod.cpu.requests > lots":   `invalid value "lots", expected a percentage like 80% or a quantity like 256Mi at position 20`,
+		"pod.name == 'a' pod":       `unexpected "pod" at position 17`,
+		"pod.name == 'a":            "unterminated string at position 13",
+		"pod.name = 'a'":            `unexpected '=' at position 10`,
+		"node.pods > 10%":           "node.pods can only be compared with a number at position 13",
+		"node.cpu.allocatable > 5%": `invalid value "5%", expected a percentage like 80% or a quantity like 256Mi at position 24`,
+	} {
+		_, err := parseFilter(expression)
+		if assert.Error(t, err, expression) {
+			assert.Equal(t, message, err.Error(), expression)
+		}
+	}
+
+	assert.Error(t, ValidateFilter(Options{Filter: "node.cpu.util > 80"}))
+	assert.NoError(t, ValidateFilter(Options{Filter: "node.cpu.util > 80", ShowUtil: true}))
+}
+
+func TestApplyFilter(t *testing.T) {
+	filtered := func(expression string) (*clusterMetric, map[string][]string) {
+		snap := getTestSnapshot()
+		idle := pod("mynode", "default", "idle", nil)
+		idle.Spec.Containers = []corev1.Container{{Name: "sleep"}}
+		snap.Pods.Items = append(snap.Pods.Items, *idle)
+
+		cm := buildClusterMetric(snap.Pods, snap.PodMetrics, snap.Nodes, snap.NodeMetrics)
+		f, err := parseFilter(expression)
+		require.NoError(t, err)
+		cm.applyFilter(f, "")
+
+		rows := map[string][]string{}
+		for name, nm := range cm.nodeMetrics {
+			rows[name] = []string{}
+			for _, pm := range nm.podMetrics {
+				for _, c := range pm.containerMetrics {
+					rows[name] = append(rows[name], pm.name+"/"+c.name)
+				}
+			}
+			sort.Strings(rows[name])
+		}
+		return &cm, rows
+	}
+
+	// mynode2 is kept with all of its pods for its utilization, and mynode
+	// for the pod without a memory request
+	cm, rows := filtered("node.cpu.util > 15 || pod.mem.request == 0")
+	assert.Equal(t, map[string][]string{
+		"mynode":  {"idle/sleep"},
+		"mynode2": {"mypod2/app"},
+	}, rows)
+	assert.Equal(t, int64(3), cm.podCount.current)
+	assert.Equal(t, "2", cm.cpu.allocatable.String())
+
+	// Cluster totals are summed from the nodes that are kept
+	cm, rows = filtered(`node.name =~ "2$"`)
+	assert.Equal(t, map[string][]string{"mynode2": {"mypod2/app"}}, rows)
+	assert.Equal(t, "1", cm.cpu.allocatable.String())
+	assert.Equal(t, "100m", cm.cpu.request.String())
+	assert.Equal(t, int64(110), cm.podCount.allocatable)
+
+	_, rows = filtered("container.name == sleep")
+	assert.Equal(t, map[string][]string{"mynode": {"idle/sleep"}}, rows)
+
+	_, rows = filtered("pod.namespace != default && pod.cpu.requests >= 100m")
+	assert.Equal(t, map[string][]string{"mynode2": {"mypod2/app"}}, rows)
+
+	_, rows = filtered("node.cpu.allocatable > 4")
+	assert.Empty(t, rows)
+}
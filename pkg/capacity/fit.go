@@ -0,0 +1,468 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/kubernetes/scheme"
+	resourcehelper "k8s.io/kubectl/pkg/util/resource"
+)
+
+// fitWorkload is a set of identical replicas to place
+type fitWorkload struct {
+	name     string
+	cpu      resource.Quantity
+	memory   resource.Quantity
+	replicas int64
+	schedulingConstraints
+}
+
+// fitNode tracks the capacity left on a node as replicas are placed
+type fitNode struct {
+	nm         *nodeMetric
+	cpu        resource.Quantity
+	memory     resource.Quantity
+	pods       int64
+	placements map[string]int64
+}
+
+type listFit struct {
+	Workloads []*listFitWorkload `json:"workloads"`
+	Nodes     []*listFitNode     `json:"nodes"`
+}
+
+type listFitWorkload struct {
+	Name        string `json:"name"`
+	Replicas    int64  `json:"replicas"`
+	Fit         int64  `json:"fit"`
+	CPU         string `json:"cpu"`
+	Memory      string `json:"memory"`
+	CPUTotal    string `json:"cpuTotal"`
+	MemoryTotal string `json:"memoryTotal"`
+}
+
+type listFitNode struct {
+	Name            string              `json:"name"`
+	Placements      []*listFitPlacement `json:"placements"`
+	CPUAvailable    string              `json:"cpuAvailable"`
+	MemoryAvailable string              `json:"memoryAvailable"`
+}
+
+type listFitPlacement struct {
+	Workload string `json:"workload"`
+	Replicas int64  `json:"replicas"`
+}
+
+// FetchAndPrintFit simulates placing the given workloads on the nodes of the
+// cluster, or a snapshot, and prints how many replicas fit and where. It
+// fails with thresholdExitCode when any replicas do not fit, so that it can
+// gate a deploy.
+func FetchAndPrintFit(opts Options) error {
+	run := newRun(opts)
+
+	workloads, err := getFitWorkloads(run, opts)
+	if err != nil {
+		return fmt.Errorf("Error: %v", err)
+	}
+
+	// Every pod counts against a node's capacity, so only node filters apply
+	opts.Namespace = ""
+	opts.NamespaceLabels = ""
+	opts.PodLabels = ""
+	opts.PodFieldSelector = ""
+	opts.ShowUtil = false
+	opts.ShowQuotas = false
+	opts.ShowVPA = false
+	opts.ShowHeadroom = false
+	opts.ShowKarpenter = false
+	opts.ShowCapacityType = false
+	opts.ShowArch = false
+	opts.ShowZoneBalance = false
+	opts.ShowGPU = false
+	opts.SimulateRemoveNodes = ""
+	opts.ShowHPAHeadroom = false
+	opts.AllocateByPodLabel = ""
+	opts.ShowPending = false
+	opts.ShowDaemonSetOverhead = false
+	opts.ShowAnomalies = false
+	opts.ShowStuckPods = false
+	opts.Preemption = ""
+	opts.ShowMissingResources = false
+	opts.Stream = false
+	cm, ferr := fetchClusterMetric(run, opts)
+	if ferr != nil {
+		return ferr
+	}
+
+	fp := &fitPrinter{run: run, workloads: workloads, opts: opts}
+	fp.nodes, fp.fit = placeWorkloads(&cm, workloads)
+	if err := fp.Print(opts.OutputFormat); err != nil {
+		return err
+	}
+	if err := run.closeOutput(); err != nil {
+		return err
+	}
+	if ferr := fp.unschedulableError(); ferr != nil {
+		return ferr
+	}
+	return nil
+}
+
+// getFitWorkloads returns a single workload from --cpu and --memory, or the
+// workloads in --from-file or --from-dir
+func getFitWorkloads(run *reportRun, opts Options) ([]*fitWorkload, error) {
+	if opts.FitFromFile == "" && opts.FitFromDir == "" {
+		w := &fitWorkload{name: VoidValue, replicas: opts.FitReplicas}
+		for _, r := range []struct {
+			value    string
+			quantity *resource.Quantity
+		}{{opts.FitCPU, &w.cpu}, {opts.FitMemory, &w.memory}} {
+			if r.value == "" {
+				continue
+			}
+			q, err := resource.ParseQuantity(r.value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid quantity %q: %w", r.value, err)
+			}
+			*r.quantity = q
+		}
+		return []*fitWorkload{w}, nil
+	}
+
+	var workloads []*fitWorkload
+	var err error
+	switch {
+	case opts.FitFromDir != "":
+		workloads, err = readFitDir(run, opts.FitFromDir)
+	case opts.FitFromFile == "-":
+		workloads, err = parseFitWorkloads(run, run.stdin)
+		if err != nil {
+			err = fmt.Errorf("reading stdin: %w", err)
+		}
+	default:
+		workloads, err = readFitFile(run, opts.FitFromFile)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(workloads) == 0 {
+		return nil, fmt.Errorf("no workloads found")
+	}
+	if opts.FitReplicas > 0 {
+		for _, w := range workloads {
+			w.replicas = opts.FitReplicas
+		}
+	}
+	return workloads, nil
+}
+
+func readFitFile(run *reportRun, path string) ([]*fitWorkload, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	workloads, err := parseFitWorkloads(run, f)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	return workloads, nil
+}
+
+// readFitDir reads the workloads of every .yaml, .yml, and .json file in
+// dir and the directories under it, in the order of their paths
+func readFitDir(run *reportRun, dir string) ([]*fitWorkload, error) {
+	workloads := []*fitWorkload{}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".yaml", ".yml", ".json":
+		default:
+			return nil
+		}
+		fileWorkloads, err := readFitFile(run, path)
+		if err != nil {
+			return err
+		}
+		workloads = append(workloads, fileWorkloads...)
+		return nil
+	})
+	return workloads, err
+}
+
+// parseFitWorkloads reads the Pods, Deployments, StatefulSets, ReplicaSets,
+// and Jobs from a YAML or JSON manifest with one or more documents, such as
+// the output of helm template. Other kinds, including custom resources, are
+// skipped.
+func parseFitWorkloads(run *reportRun, r io.Reader) ([]*fitWorkload, error) {
+	workloads := []*fitWorkload{}
+	decoder := utilyaml.NewYAMLOrJSONDecoder(r, 4096)
+	for {
+		var ext runtime.RawExtension
+		if err := decoder.Decode(&ext); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, err
+		}
+		doc := bytes.TrimSpace(ext.Raw)
+		if len(doc) == 0 || bytes.Equal(doc, []byte("null")) {
+			continue
+		}
+
+		obj, gvk, err := scheme.Codecs.UniversalDeserializer().Decode(doc, nil, nil)
+		if runtime.IsNotRegisteredError(err) {
+			run.logDebugf("Skipping a document of a kind that is not a workload: %v", err)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var name string
+		var replicas *int32
+		var spec corev1.PodSpec
+		switch o := obj.(type) {
+		case *corev1.Pod:
+			name, spec = o.Name, o.Spec
+		case *appsv1.Deployment:
+			name, replicas, spec = o.Name, o.Spec.Replicas, o.Spec.Template.Spec
+		case *appsv1.StatefulSet:
+			name, replicas, spec = o.Name, o.Spec.Replicas, o.Spec.Template.Spec
+		case *appsv1.ReplicaSet:
+			name, replicas, spec = o.Name, o.Spec.Replicas, o.Spec.Template.Spec
+		case *batchv1.Job:
+			name, replicas, spec = o.Name, o.Spec.Parallelism, o.Spec.Template.Spec
+		default:
+			run.logDebugf("Skipping %s %s, it is not a workload", gvk.Kind, fitDocumentName(obj))
+			continue
+		}
+
+		req, _ := resourcehelper.PodRequestsAndLimits(&corev1.Pod{Spec: spec})
+		w := &fitWorkload{
+			name:                  fmt.Sprintf("%s/%s", strings.ToLower(gvk.Kind), name),
+			cpu:                   req["cpu"],
+			memory:                req["memory"],
+			replicas:              1,
+			schedulingConstraints: newSchedulingConstraints(spec),
+		}
+		if replicas != nil {
+			w.replicas = int64(*replicas)
+		}
+		workloads = append(workloads, w)
+	}
+
+	return workloads, nil
+}
+
+func fitDocumentName(obj runtime.Object) string {
+	if o, ok := obj.(metav1.Object); ok {
+		return o.GetName()
+	}
+	return ""
+}
+
+// total returns the requests of all replicas of a workload
+func (w *fitWorkload) total(q resource.Quantity) resource.Quantity {
+	total := q.DeepCopy()
+	total.Mul(w.replicas)
+	return total
+}
+
+// placeWorkloads places every replica with first-fit-decreasing: replicas
+// are placed largest first on the first node, by name, with enough CPU,
+// memory, and pod slots left after existing requests. It returns the nodes
+// with their remaining capacity and the number of replicas placed for each
+// workload.
+func placeWorkloads(cm *clusterMetric, workloads []*fitWorkload) ([]*fitNode, map[string]int64) {
+	nodes := newFitNodes(cm)
+
+	sorted := make([]*fitWorkload, len(workloads))
+	copy(sorted, workloads)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if c := sorted[i].cpu.Cmp(sorted[j].cpu); c != 0 {
+			return c > 0
+		}
+		return sorted[i].memory.Cmp(sorted[j].memory) > 0
+	})
+
+	fit := map[string]int64{}
+	for _, w := range sorted {
+		for i := int64(0); i < w.replicas; i++ {
+			for _, fn := range nodes {
+				if fn.fits(w) {
+					fn.cpu.Sub(w.cpu)
+					fn.memory.Sub(w.memory)
+					fn.pods--
+					fn.placements[w.name]++
+					fit[w.name]++
+					break
+				}
+			}
+		}
+	}
+
+	return nodes, fit
+}
+
+// newFitNodes returns every node, by name, with the capacity left after
+// existing requests
+func newFitNodes(cm *clusterMetric) []*fitNode {
+	nodes := []*fitNode{}
+	for _, nm := range cm.getSortedNodeMetrics("name") {
+		fn := &fitNode{
+			nm:         nm,
+			cpu:        nm.cpu.allocatable.DeepCopy(),
+			memory:     nm.memory.allocatable.DeepCopy(),
+			pods:       nm.podCount.allocatable - nm.podCount.current,
+			placements: map[string]int64{},
+		}
+		fn.cpu.Sub(nm.cpu.request)
+		fn.memory.Sub(nm.memory.request)
+		nodes = append(nodes, fn)
+	}
+	return nodes
+}
+
+func (fn *fitNode) fits(w *fitWorkload) bool {
+	if fn.pods < 1 || fn.cpu.Cmp(w.cpu) < 0 || fn.memory.Cmp(w.memory) < 0 {
+		return false
+	}
+	return w.allows(fn.nm.name, fn.nm.labels, fn.nm.taints, fn.nm.unschedulable)
+}
+
+type fitPrinter struct {
+	run       *reportRun
+	workloads []*fitWorkload
+	nodes     []*fitNode
+	fit       map[string]int64
+	opts      Options
+}
+
+func (fp *fitPrinter) Print(outputType string) error {
+	switch outputType {
+	case JSONOutput, YAMLOutput:
+		printListOutput(fp.run, FitReportKind, fp.buildListFit(), outputType)
+	case TableOutput:
+		w := tabwriter.NewWriter(fp.run.output, 0, 8, 2, ' ', 0)
+		fp.printTable(w, "\t ")
+		if err := w.Flush(); err != nil {
+			fp.run.logErrorf("Error writing to table: %s", err)
+		}
+	case CSVOutput:
+		fp.printTable(fp.run.output, ",")
+	case TSVOutput:
+		fp.printTable(fp.run.output, "\t")
+	default:
+		return fmt.Errorf("Called with an unsupported output type: %s", outputType)
+	}
+	return nil
+}
+
+func (fp *fitPrinter) printTable(w io.Writer, separator string) {
+	list := fp.buildListFit()
+
+	_, _ = fmt.Fprintln(w, strings.Join([]string{"WORKLOAD", "REPLICAS", "FIT", "CPU REQUESTS", "MEMORY REQUESTS", "CPU TOTAL", "MEMORY TOTAL"}, separator))
+	for _, lw := range list.Workloads {
+		_, _ = fmt.Fprintln(w, strings.Join([]string{
+			lw.Name, fmt.Sprintf("%d", lw.Replicas), fmt.Sprintf("%d", lw.Fit), lw.CPU, lw.Memory, lw.CPUTotal, lw.MemoryTotal,
+		}, separator))
+	}
+
+	_, _ = fmt.Fprintln(w)
+	_, _ = fmt.Fprintln(w, strings.Join([]string{"NODE", "WORKLOAD", "REPLICAS", "CPU AVAILABLE", "MEMORY AVAILABLE"}, separator))
+	for _, ln := range list.Nodes {
+		for _, p := range ln.Placements {
+			_, _ = fmt.Fprintln(w, strings.Join([]string{
+				ln.Name, p.Workload, fmt.Sprintf("%d", p.Replicas), ln.CPUAvailable, ln.MemoryAvailable,
+			}, separator))
+		}
+	}
+}
+
+// buildListFit lists every workload and the nodes that replicas were placed
+// on, with the capacity left on each node after placement
+func (fp *fitPrinter) buildListFit() *listFit {
+	cpu := resourceMetric{resourceType: "cpu"}
+	memory := resourceMetric{resourceType: "memory"}
+
+	out := &listFit{Workloads: []*listFitWorkload{}, Nodes: []*listFitNode{}}
+	for _, w := range fp.workloads {
+		out.Workloads = append(out.Workloads, &listFitWorkload{
+			Name:        w.name,
+			Replicas:    w.replicas,
+			Fit:         fp.fit[w.name],
+			CPU:         cpu.valueFunction(fp.run)(w.cpu),
+			Memory:      memory.valueFunction(fp.run)(w.memory),
+			CPUTotal:    cpu.valueFunction(fp.run)(w.total(w.cpu)),
+			MemoryTotal: memory.valueFunction(fp.run)(w.total(w.memory)),
+		})
+	}
+
+	for _, fn := range fp.nodes {
+		if len(fn.placements) == 0 {
+			continue
+		}
+		ln := &listFitNode{
+			Name:            fn.nm.name,
+			Placements:      []*listFitPlacement{},
+			CPUAvailable:    cpu.valueFunction(fp.run)(fn.cpu),
+			MemoryAvailable: memory.valueFunction(fp.run)(fn.memory),
+		}
+		for _, w := range fp.workloads {
+			if n := fn.placements[w.name]; n > 0 {
+				ln.Placements = append(ln.Placements, &listFitPlacement{Workload: w.name, Replicas: n})
+			}
+		}
+		out.Nodes = append(out.Nodes, ln)
+	}
+
+	return out
+}
+
+// unschedulableError returns every workload with replicas that do not fit
+// as an error with thresholdExitCode, or nil if there are none
+func (fp *fitPrinter) unschedulableError() *fetchError {
+	var err *fetchError
+	for _, w := range fp.workloads {
+		if fit := fp.fit[w.name]; fit < w.replicas {
+			if err == nil {
+				err = &fetchError{code: thresholdExitCode}
+			}
+			err.messages = append(err.messages, fmt.Sprintf("Does not fit: %d of %d replicas of %s can be scheduled", fit, w.replicas, w.name))
+		}
+	}
+	return err
+}
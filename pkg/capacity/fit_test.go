@@ -0,0 +1,193 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const fitManifest = `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: 3
+  selector:
+    matchLabels:
+      app: web
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      containers:
+      - name: app
+        resources:
+          requests:
+            cpu: 300m
+            memory: 1Gi
+      - name: sidecar
+        resources:
+          requests:
+            cpu: 100m
+---
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: migrate
+spec:
+  parallelism: 2
+  template:
+    spec:
+      tolerations:
+      - key: hello
+        operator: Exists
+      containers:
+      - name: migrate
+        resources:
+          requests:
+            cpu: 500m
+---
+apiVersion: v1
+kind: Pod
+metadata:
+  name: debug
+spec:
+  nodeSelector:
+    hello: world
+  containers:
+  - name: debug
+`
+
+func TestParseFitWorkloads(t *testing.T) {
+	run := newTestRun(nil)
+	workloads, err := parseFitWorkloads(run, strings.NewReader(fitManifest))
+	require.NoError(t, err)
+	require.Len(t, workloads, 3)
+
+	assert.Equal(t, "deployment/web", workloads[0].name)
+	assert.Equal(t, int64(3), workloads[0].replicas)
+	assert.Equal(t, int64(400), workloads[0].cpu.MilliValue())
+	assert.Equal(t, "1Gi", workloads[0].memory.String())
+
+	assert.Equal(t, "job/migrate", workloads[1].name)
+	assert.Equal(t, int64(2), workloads[1].replicas)
+	assert.Len(t, workloads[1].tolerations, 1)
+
+	assert.Equal(t, "pod/debug", workloads[2].name)
+	assert.Equal(t, int64(1), workloads[2].replicas)
+	assert.Equal(t, map[string]string{"hello": "world"}, workloads[2].nodeSelector)
+
+	// Other kinds in the output of helm template are skipped
+	workloads, err = parseFitWorkloads(run, strings.NewReader(`
+# Source: web/templates/service.yaml
+apiVersion: v1
+kind: Service
+metadata:
+  name: web
+---
+# Source: web/templates/servicemonitor.yaml
+apiVersion: monitoring.coreos.com/v1
+kind: ServiceMonitor
+metadata:
+  name: web
+`))
+	require.NoError(t, err)
+	assert.Empty(t, workloads)
+}
+
+func TestGetFitWorkloads(t *testing.T) {
+	run := newTestRun(nil)
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "web", "templates"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "web", "templates", "workloads.yaml"), []byte(fitManifest), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "service.yml"), []byte("apiVersion: v1\nkind: Service\nmetadata:\n  name: web\n"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "README.md"), []byte("# Manifests\n"), 0o600))
+
+	workloads, err := getFitWorkloads(run, Options{FitFromDir: dir, FitReplicas: 4})
+	require.NoError(t, err)
+	names := []string{}
+	for _, w := range workloads {
+		names = append(names, w.name)
+		assert.Equal(t, int64(4), w.replicas)
+	}
+	assert.Equal(t, []string{"deployment/web", "job/migrate", "pod/debug"}, names)
+
+	_, err = getFitWorkloads(run, Options{FitFromFile: filepath.Join(dir, "service.yml")})
+	assert.EqualError(t, err, "no workloads found")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "broken.json"), []byte("{"), 0o600))
+	_, err = getFitWorkloads(run, Options{FitFromDir: dir})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "reading "+filepath.Join(dir, "broken.json"))
+}
+
+func TestPlaceWorkloads(t *testing.T) {
+	run := newTestRun(nil)
+	snap := getTestSnapshot()
+	snap.Nodes.Items = append(snap.Nodes.Items, *node("mynode3", map[string]string{}, false))
+	snap.Nodes.Items[2].Spec.Unschedulable = true
+	snap.Nodes.Items[2].Status.Allocatable = snap.Nodes.Items[0].Status.Allocatable
+	cm := buildClusterMetric(snap.Pods, nil, snap.Nodes, nil)
+
+	// mynode and mynode2 each have 900m CPU and 3872Mi memory free, mynode2
+	// is tainted, and mynode3 is cordoned
+	small := &fitWorkload{name: "small", cpu: resource.MustParse("200m"), memory: resource.MustParse("1Gi"), replicas: 5}
+	large := &fitWorkload{
+		name:     "large",
+		cpu:      resource.MustParse("600m"),
+		replicas: 3,
+		schedulingConstraints: schedulingConstraints{
+			tolerations: []corev1.Toleration{{Operator: corev1.TolerationOpExists}},
+		},
+	}
+	workloads := []*fitWorkload{small, large}
+
+	nodes, fit := placeWorkloads(&cm, workloads)
+	assert.Equal(t, map[string]int64{"large": 2, "small": 1}, fit)
+
+	fp := &fitPrinter{run: run, workloads: workloads, nodes: nodes, fit: fit}
+	list := fp.buildListFit()
+	assert.Equal(t, []*listFitWorkload{
+		{Name: "small", Replicas: 5, Fit: 1, CPU: "200m", Memory: "1024Mi", CPUTotal: "1000m", MemoryTotal: "5120Mi"},
+		{Name: "large", Replicas: 3, Fit: 2, CPU: "600m", Memory: "0Mi", CPUTotal: "1800m", MemoryTotal: "0Mi"},
+	}, list.Workloads)
+	assert.Equal(t, []*listFitNode{
+		{
+			Name: "mynode",
+			Placements: []*listFitPlacement{
+				{Workload: "small", Replicas: 1},
+				{Workload: "large", Replicas: 1},
+			},
+			CPUAvailable:    "100m",
+			MemoryAvailable: "2848Mi",
+		},
+		{
+			Name:            "mynode2",
+			Placements:      []*listFitPlacement{{Workload: "large", Replicas: 1}},
+			CPUAvailable:    "300m",
+			MemoryAvailable: "3872Mi",
+		},
+	}, list.Nodes)
+}
@@ -0,0 +1,142 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/robscott/kube-capacity/pkg/kube"
+)
+
+// fleetMetric holds the metrics for several clusters along with the
+// fleet-wide totals
+type fleetMetric struct {
+	clusters []*clusterMetric
+	total    *clusterMetric
+
+	// failed holds the error of each context that could not be fetched
+	failed map[string]*fetchError
+}
+
+// fetchFleetMetric fetches the cluster metrics for every context in
+// parallel. A context that fails is left out of the clusters and totals
+// instead of stopping the others.
+func fetchFleetMetric(run *reportRun, opts Options) (*fleetMetric, error) {
+	contexts := opts.Contexts
+	if opts.AllContexts {
+		var err error
+		contexts, err = kube.GetContextNames(opts.KubeConfig)
+		if err != nil {
+			return nil, fmt.Errorf("Error reading Kubernetes config: %v", err)
+		}
+	}
+
+	clusters := make([]*clusterMetric, len(contexts))
+	errs := make([]*fetchError, len(contexts))
+	var wg sync.WaitGroup
+	for i, kubeContext := range contexts {
+		wg.Add(1)
+		go func(i int, kubeContext string) {
+			defer wg.Done()
+			contextOpts := opts
+			contextOpts.KubeContext = kubeContext
+			cm, ferr := fetchClusterMetric(run, contextOpts)
+			if ferr != nil {
+				errs[i] = ferr
+				return
+			}
+			cm.name = kubeContext
+			clusters[i] = &cm
+		}(i, kubeContext)
+	}
+	wg.Wait()
+
+	fetched := []*clusterMetric{}
+	failed := map[string]*fetchError{}
+	for i, kubeContext := range contexts {
+		if errs[i] != nil {
+			failed[kubeContext] = errs[i]
+		} else {
+			fetched = append(fetched, clusters[i])
+		}
+	}
+
+	fm := buildFleetMetric(fetched)
+	fm.failed = failed
+	return fm, nil
+}
+
+// failedContextsError returns the error of each context that could not be
+// fetched, in name order, with the exit code of the first one, or nil if
+// every context was fetched
+func failedContextsError(fm *fleetMetric) *fetchError {
+	if len(fm.failed) == 0 {
+		return nil
+	}
+	contexts := make([]string, 0, len(fm.failed))
+	for kubeContext := range fm.failed {
+		contexts = append(contexts, kubeContext)
+	}
+	sort.Strings(contexts)
+
+	err := newFetchError(fm.failed[contexts[0]].code, "Error: could not fetch %d of %d contexts", len(contexts), len(contexts)+len(fm.clusters))
+	for _, kubeContext := range contexts {
+		for _, message := range fm.failed[kubeContext].messages {
+			err.messages = append(err.messages, kubeContext+": "+message)
+		}
+	}
+	return err
+}
+
+func buildFleetMetric(clusters []*clusterMetric) *fleetMetric {
+	sort.Slice(clusters, func(i, j int) bool {
+		return clusters[i].name < clusters[j].name
+	})
+
+	total := &clusterMetric{
+		cpu:         &resourceMetric{resourceType: "cpu"},
+		memory:      &resourceMetric{resourceType: "memory"},
+		nodeMetrics: map[string]*nodeMetric{},
+		podCount:    &podCount{},
+		nodeStatus:  &nodeStatusCount{},
+	}
+	for _, cm := range clusters {
+		total.cpu.addMetric(cm.cpu)
+		total.memory.addMetric(cm.memory)
+		total.podCount.current += cm.podCount.current
+		total.podCount.allocatable += cm.podCount.allocatable
+		total.pendingResizes += cm.pendingResizes
+		total.nodeStatus.add(cm.nodeStatus)
+		if cm.cost != nil {
+			if total.cost == nil {
+				total.cost = &nodeCost{}
+			}
+			total.cost.add(cm.cost)
+		}
+		if cm.disk != nil {
+			if total.disk == nil {
+				total.disk = &diskCount{}
+			}
+			total.disk.add(cm.disk)
+		}
+	}
+
+	return &fleetMetric{
+		clusters: clusters,
+		total:    total,
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildFleetMetric(t *testing.T) {
+	run := newTestRun(nil)
+	snap := getTestSnapshot()
+	prod := buildClusterMetric(snap.Pods, snap.PodMetrics, snap.Nodes, snap.NodeMetrics)
+	prod.name = "prod"
+	staging := getTestClusterMetric()
+	staging.name = "staging"
+
+	fm := buildFleetMetric([]*clusterMetric{&staging, &prod})
+
+	assert.Equal(t, "prod", fm.clusters[0].name)
+	assert.Equal(t, "staging", fm.clusters[1].name)
+	assert.Equal(t, int64(3000), fm.total.cpu.allocatable.MilliValue())
+	assert.Equal(t, int64(850), fm.total.cpu.request.MilliValue())
+	assert.Equal(t, int64(363), fm.total.cpu.utilization.MilliValue())
+	assert.Equal(t, int64(3), fm.total.podCount.current)
+	assert.Equal(t, int64(330), fm.total.podCount.allocatable)
+
+	lp := listPrinter{run: run, opts: Options{}}
+	totals := lp.buildListClusterTotals(fm.total)
+	assert.Equal(t, "850m", totals.CPU.Requests)
+	assert.Equal(t, "28%", totals.CPU.RequestsPct)
+}
+
+func TestGetLineItemsWithCluster(t *testing.T) {
+	run := newTestRun(nil)
+	tp := &tablePrinter{run: run, cluster: "prod"}
+
+	assert.Equal(t, []string{"CLUSTER", "NODE", "CPU REQUESTS", "CPU LIMITS", "MEMORY REQUESTS", "MEMORY LIMITS"},
+		tp.getLineItems(&headerStrings))
+	assert.Equal(t, []string{"prod", "mynode", "100m", "", "", ""},
+		tp.getLineItems(&tableLine{node: "mynode", cpuRequests: "100m"}))
+	assert.Equal(t, []string{"", "", "", "", "", ""},
+		tp.getLineItems(&tableLine{}))
+}
+
+func TestFetchFleetMetricWithFailedContext(t *testing.T) {
+	run := newTestRun(nil)
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	require.NoError(t, saveSnapshot(path, getTestSnapshot()))
+
+	fm, err := fetchFleetMetric(run, Options{Contexts: []string{"staging", "example"}, SnapshotIn: path})
+	require.NoError(t, err)
+
+	require.Len(t, fm.clusters, 1)
+	assert.Equal(t, "example", fm.clusters[0].name)
+	assert.Equal(t, int64(2000), fm.total.cpu.allocatable.MilliValue())
+	require.Len(t, fm.failed, 1)
+	assert.Equal(t, 1, fm.failed["staging"].code)
+	assert.EqualError(t, fm.failed["staging"], `Error: snapshot was captured from context "example", not "staging"`)
+
+	ferr := failedContextsError(fm)
+	require.NotNil(t, ferr)
+	assert.Equal(t, 1, ExitCode(ferr))
+	assert.EqualError(t, ferr, "Error: could not fetch 1 of 2 contexts\n"+
+		`staging: Error: snapshot was captured from context "example", not "staging"`)
+}
@@ -0,0 +1,624 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/robscott/kube-capacity/pkg/kube"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// DefaultForecastWindow is how much history trends are fitted to
+	DefaultForecastWindow = "30d"
+	// DefaultForecastHorizon is how far ahead trends are projected
+	DefaultForecastHorizon = "60d"
+
+	// forecastPoints is how many evenly spaced points of history are read
+	// for each series
+	forecastPoints = 120
+)
+
+// DefaultForecastThresholds are the percentages of allocatable forecasts
+// estimate requests will reach
+var DefaultForecastThresholds = []float64{80, 100}
+
+// Statuses of a forecast threshold
+const (
+	forecastCrossed  = "crossed"
+	forecastExpected = "expected"
+	forecastBeyond   = "beyond-horizon"
+	forecastFlat     = "not-rising"
+)
+
+const (
+	forecastRequestsQuery    = `sum by (node, resource) (kube_pod_container_resource_requests{resource=~"cpu|memory"} * on (namespace, pod) group_left () (max by (namespace, pod) (kube_pod_status_phase{phase=~"Pending|Running"}) == 1))`
+	forecastAllocatableQuery = `sum by (node, resource) (kube_node_status_allocatable{resource=~"cpu|memory"})`
+)
+
+// ValidateForecast checks the window, horizon, and thresholds of forecast
+func ValidateForecast(opts Options) error {
+	window, err := parsePromQLDuration(opts.ForecastWindow)
+	if err != nil || window <= 0 {
+		return fmt.Errorf("--window must be a duration greater than 0, such as 30d")
+	}
+	if window < forecastPoints*time.Minute {
+		return fmt.Errorf("--window must be at least %s", PromQLDuration(forecastPoints*time.Minute))
+	}
+	horizon, err := parsePromQLDuration(opts.ForecastHorizon)
+	if err != nil || horizon <= 0 {
+		return fmt.Errorf("--horizon must be a duration greater than 0, such as 60d")
+	}
+	if len(opts.ForecastThresholds) == 0 {
+		return fmt.Errorf("--threshold needs at least one percentage")
+	}
+	for _, threshold := range opts.ForecastThresholds {
+		if threshold <= 0 {
+			return fmt.Errorf("--threshold must be greater than 0, got %v", threshold)
+		}
+	}
+	return nil
+}
+
+// forecastSeries is the requests, allocatable, and usage of one resource
+// of a node at evenly spaced points over the window, NaN where it has none
+type forecastSeries struct {
+	requests, allocatable, usage []float64
+}
+
+// forecastHistory is the history of a cluster by node and resource, along
+// with the node pool of each node
+type forecastHistory struct {
+	cluster   string
+	start     time.Time
+	step      time.Duration
+	nodes     map[string]map[string]*forecastSeries
+	nodePools map[string]string
+}
+
+// forecastTrend is a straight line fitted to a series, with the value at
+// the end of the window and its change per day
+type forecastTrend struct {
+	current float64
+	perDay  float64
+}
+
+// forecastRow is the forecast of one resource of a cluster or node pool
+type forecastRow struct {
+	cluster      string
+	nodePool     string
+	resourceType string
+
+	// allocatable, requests, and usage are the latest values, usage is NaN
+	// when Prometheus has none
+	allocatable float64
+	requests    float64
+	usage       float64
+
+	// requestsTrend and usageTrend are nil with fewer than two points
+	requestsTrend *forecastTrend
+	usageTrend    *forecastTrend
+
+	thresholds []*forecastThreshold
+}
+
+// forecastThreshold is when requests are expected to reach a percentage of
+// the current allocatable
+type forecastThreshold struct {
+	percent float64
+	status  string
+	at      time.Time
+}
+
+// FetchAndPrintForecast fits trends to the history of requests and usage in
+// Prometheus and prints when requests are expected to reach each threshold
+func FetchAndPrintForecast(opts Options) error {
+	run := newRun(opts)
+
+	window, _ := parsePromQLDuration(opts.ForecastWindow)
+	horizon, _ := parsePromQLDuration(opts.ForecastHorizon)
+
+	contexts := opts.Contexts
+	if opts.AllContexts {
+		var err error
+		contexts, err = kube.GetContextNames(opts.KubeConfig)
+		if err != nil {
+			return fmt.Errorf("Error reading Kubernetes config: %v", err)
+		}
+	}
+	if len(contexts) == 0 {
+		contexts = []string{opts.KubeContext}
+	}
+
+	now := time.Now().Truncate(time.Second)
+	rows := []*forecastRow{}
+	for _, kubeContext := range contexts {
+		contextOpts := opts
+		contextOpts.KubeContext = kubeContext
+		history, err := getForecastHistory(context.TODO(), run, contextOpts, window, now)
+		if err != nil {
+			return newFetchError(4, "Error getting history from Prometheus: %v", err)
+		}
+		rows = append(rows, buildForecastRows(history, horizon, opts.ForecastThresholds)...)
+	}
+
+	fp := &forecastPrinter{run: run, rows: rows, opts: opts, horizon: horizon}
+	if err := fp.Print(opts.OutputFormat); err != nil {
+		return err
+	}
+	return run.closeOutput()
+}
+
+func getForecastHistory(ctx context.Context, run *reportRun, opts Options, window time.Duration, end time.Time) (*forecastHistory, error) {
+	cluster, err := clusterName(opts)
+	if err != nil {
+		return nil, fmt.Errorf("reading Kubernetes config: %w", err)
+	}
+	clientset, err := newClientSet(run, opts)
+	if err != nil {
+		return nil, err
+	}
+	endpoint, err := resolvePrometheusEndpoint(run, clientset, opts)
+	if err != nil {
+		return nil, err
+	}
+	promHTTP, err := newPrometheusHTTP(ctx, run, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	step := window / (forecastPoints - 1)
+	start := end.Add(-step * (forecastPoints - 1))
+	history := &forecastHistory{
+		cluster:   cluster,
+		start:     start,
+		step:      step,
+		nodes:     map[string]map[string]*forecastSeries{},
+		nodePools: map[string]string{},
+	}
+
+	nodeCPU, nodeMemory := run.promLabels.nodeUsage()
+	queries := []struct {
+		name     string
+		query    string
+		resource string
+		dest     func(*forecastSeries) *[]float64
+	}{
+		{"requests", forecastRequestsQuery, "", func(fs *forecastSeries) *[]float64 { return &fs.requests }},
+		{"allocatable", forecastAllocatableQuery, "", func(fs *forecastSeries) *[]float64 { return &fs.allocatable }},
+		{"node CPU", `sum by (node) (` + nodeCPU + `)`, "cpu", func(fs *forecastSeries) *[]float64 { return &fs.usage }},
+		{"node memory", `sum by (node) (` + nodeMemory + `)`, "memory", func(fs *forecastSeries) *[]float64 { return &fs.usage }},
+	}
+	nodeNames, err := getPrometheusNodeNames(run, clientset, endpoint, promHTTP)
+	if err != nil {
+		return nil, err
+	}
+	for _, q := range queries {
+		resp, err := queryPrometheusRange(run, clientset, endpoint, promHTTP, q.query, start, end, step)
+		if err != nil {
+			return nil, fmt.Errorf("querying %s history: %w", q.name, err)
+		}
+		nodeNames.resolve(resp)
+		history.addRangeResult(resp, q.resource, q.dest)
+	}
+	if len(history.nodes) == 0 {
+		return nil, fmt.Errorf("no kube_node_status_allocatable series found, is kube-state-metrics scraped by this Prometheus?")
+	}
+
+	// Nodes that are gone are matched to their pool through kube_node_labels,
+	// which only has the labels kube-state-metrics is allowed to export
+	resp, err := queryPrometheus(run, clientset, endpoint, promHTTP, fmt.Sprintf("last_over_time(kube_node_labels[%s])", PromQLDuration(window)))
+	if err != nil {
+		return nil, fmt.Errorf("querying node labels: %w", err)
+	}
+	for _, r := range resp.Data.Result {
+		if _, pool := nodeGroupName(kubeStateMetricsLabels(r.Metric)); pool != ungroupedNodeGroup {
+			history.nodePools[r.Metric["node"]] = pool
+		}
+	}
+	nodeList, err := listAllNodes(ctx, run, clientset, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("listing nodes: %w", err)
+	}
+	for _, node := range nodeList.Items {
+		if _, pool := nodeGroupName(node.Labels); pool != ungroupedNodeGroup {
+			history.nodePools[node.Name] = pool
+		}
+	}
+	return history, nil
+}
+
+var kubeStateMetricsLabelChars = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+// kubeStateMetricsLabels returns the node labels that kube_node_labels has
+// as label_<name> for each of the node group labels
+func kubeStateMetricsLabels(metric map[string]string) map[string]string {
+	labels := map[string]string{}
+	for _, label := range nodeGroupLabels {
+		if value := metric["label_"+kubeStateMetricsLabelChars.ReplaceAllString(label, "_")]; value != "" {
+			labels[label] = value
+		}
+	}
+	return labels
+}
+
+// addRangeResult places the values of each series at the point its
+// timestamp falls on. Series without a resource label are of the given
+// resource.
+func (fh *forecastHistory) addRangeResult(resp *prometheusResponse, resourceType string, dest func(*forecastSeries) *[]float64) {
+	for _, r := range resp.Data.Result {
+		rt := r.Metric["resource"]
+		if rt == "" {
+			rt = resourceType
+		}
+		if rt != "cpu" && rt != "memory" {
+			continue
+		}
+		node := r.Metric["node"]
+		if fh.nodes[node] == nil {
+			fh.nodes[node] = map[string]*forecastSeries{}
+		}
+		fs := fh.nodes[node][rt]
+		if fs == nil {
+			fs = &forecastSeries{}
+			fh.nodes[node][rt] = fs
+		}
+		points := dest(fs)
+		if *points == nil {
+			*points = newForecastPoints()
+		}
+		for _, v := range r.Values {
+			seconds, ok := v[0].(float64)
+			if !ok {
+				continue
+			}
+			value, err := parseValue(v)
+			if err != nil {
+				continue
+			}
+			offset := time.Unix(0, int64(seconds*float64(time.Second))).Sub(fh.start)
+			i := int(math.Round(float64(offset) / float64(fh.step)))
+			if i < 0 || i >= forecastPoints {
+				continue
+			}
+			if math.IsNaN((*points)[i]) {
+				(*points)[i] = 0
+			}
+			(*points)[i] += value
+		}
+	}
+}
+
+func newForecastPoints() []float64 {
+	points := make([]float64, forecastPoints)
+	for i := range points {
+		points[i] = math.NaN()
+	}
+	return points
+}
+
+// buildForecastRows sums the nodes of the cluster and of each node pool and
+// fits a trend to each. Requests of pods waiting for a node only count
+// towards the cluster.
+func buildForecastRows(fh *forecastHistory, horizon time.Duration, thresholds []float64) []*forecastRow {
+	scopes := map[string]map[string]*forecastSeries{VoidValue: {}}
+	for node, resources := range fh.nodes {
+		names := []string{VoidValue}
+		if node != "" {
+			pool, ok := fh.nodePools[node]
+			if !ok {
+				pool = ungroupedNodeGroup
+			}
+			names = append(names, pool)
+		}
+		for _, name := range names {
+			if scopes[name] == nil {
+				scopes[name] = map[string]*forecastSeries{}
+			}
+			for resourceType, fs := range resources {
+				total := scopes[name][resourceType]
+				if total == nil {
+					total = &forecastSeries{requests: newForecastPoints(), allocatable: newForecastPoints(), usage: newForecastPoints()}
+					scopes[name][resourceType] = total
+				}
+				addHistory(total.requests, fs.requests)
+				addHistory(total.allocatable, fs.allocatable)
+				addHistory(total.usage, fs.usage)
+			}
+		}
+	}
+
+	names := []string{}
+	for name := range scopes {
+		if name != VoidValue {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	names = append([]string{VoidValue}, names...)
+
+	end := fh.start.Add(fh.step * (forecastPoints - 1))
+	rows := []*forecastRow{}
+	for _, name := range names {
+		for _, resourceType := range []string{"cpu", "memory"} {
+			fs := scopes[name][resourceType]
+			if fs == nil {
+				continue
+			}
+			row := &forecastRow{
+				cluster:       fh.cluster,
+				nodePool:      name,
+				resourceType:  resourceType,
+				allocatable:   latestPoint(fs.allocatable),
+				requests:      latestPoint(fs.requests),
+				usage:         latestPoint(fs.usage),
+				requestsTrend: fitForecastTrend(fs.requests, fh.step),
+				usageTrend:    fitForecastTrend(fs.usage, fh.step),
+			}
+			if math.IsNaN(row.allocatable) {
+				continue
+			}
+			if math.IsNaN(row.requests) {
+				row.requests = 0
+			}
+			for _, percent := range thresholds {
+				row.thresholds = append(row.thresholds, row.forecastThreshold(percent, end, horizon))
+			}
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+// forecastThreshold estimates when requests reach percent of the current
+// allocatable by following their trend
+func (row *forecastRow) forecastThreshold(percent float64, end time.Time, horizon time.Duration) *forecastThreshold {
+	ft := &forecastThreshold{percent: percent}
+	target := row.allocatable * percent / 100
+	switch {
+	case row.requests >= target:
+		ft.status = forecastCrossed
+	case row.requestsTrend == nil || row.requestsTrend.perDay <= 0:
+		ft.status = forecastFlat
+	default:
+		days := math.Max(0, (target-row.requestsTrend.current)/row.requestsTrend.perDay)
+		at := end.Add(time.Duration(days * float64(24*time.Hour)))
+		if at.After(end.Add(horizon)) {
+			ft.status = forecastBeyond
+		} else {
+			ft.status = forecastExpected
+			ft.at = at
+		}
+	}
+	return ft
+}
+
+// fitForecastTrend fits a least squares line to the points of a series,
+// returning nil when it has fewer than two
+func fitForecastTrend(points []float64, step time.Duration) *forecastTrend {
+	stepDays := step.Hours() / 24
+	var n, sumX, sumY, sumXY, sumXX float64
+	for i, y := range points {
+		if math.IsNaN(y) {
+			continue
+		}
+		// Days before the end of the window
+		x := -float64(len(points)-1-i) * stepDays
+		n++
+		sumX += x
+		sumY += y
+		sumXY += x * y
+		sumXX += x * x
+	}
+	denominator := n*sumXX - sumX*sumX
+	if n < 2 || denominator == 0 {
+		return nil
+	}
+	slope := (n*sumXY - sumX*sumY) / denominator
+	return &forecastTrend{
+		current: (sumY - slope*sumX) / n,
+		perDay:  slope,
+	}
+}
+
+func latestPoint(points []float64) float64 {
+	for i := len(points) - 1; i >= 0; i-- {
+		if !math.IsNaN(points[i]) {
+			return points[i]
+		}
+	}
+	return math.NaN()
+}
+
+// projected returns the value the trend reaches after the horizon, never
+// below zero
+func (ft *forecastTrend) projected(horizon time.Duration) float64 {
+	return math.Max(0, ft.current+ft.perDay*horizon.Hours()/24)
+}
+
+type forecastPrinter struct {
+	run     *reportRun
+	rows    []*forecastRow
+	opts    Options
+	horizon time.Duration
+}
+
+type listForecast struct {
+	Window  string              `json:"window"`
+	Horizon string              `json:"horizon"`
+	Rows    []*listForecastItem `json:"forecasts"`
+}
+
+type listForecastItem struct {
+	Cluster              string                   `json:"cluster"`
+	NodePool             string                   `json:"nodePool"`
+	Resource             string                   `json:"resource"`
+	Allocatable          string                   `json:"allocatable"`
+	Requests             string                   `json:"requests"`
+	RequestsPerDay       string                   `json:"requestsPerDay,omitempty"`
+	ProjectedRequests    string                   `json:"projectedRequests,omitempty"`
+	Utilization          string                   `json:"utilization,omitempty"`
+	ProjectedUtilization string                   `json:"projectedUtilization,omitempty"`
+	Thresholds           []*listForecastThreshold `json:"thresholds"`
+}
+
+type listForecastThreshold struct {
+	Percent float64 `json:"percent"`
+	Status  string  `json:"status"`
+	Date    string  `json:"date,omitempty"`
+}
+
+func (fp *forecastPrinter) Print(outputType string) error {
+	switch outputType {
+	case JSONOutput, YAMLOutput:
+		printListOutput(fp.run, ForecastReportKind, fp.buildListForecast(), outputType)
+	case TableOutput:
+		w := tabwriter.NewWriter(fp.run.output, 0, 8, 2, ' ', 0)
+		fp.printTable(w, "\t ")
+		if err := w.Flush(); err != nil {
+			fp.run.logErrorf("Error writing to table: %s", err)
+		}
+	case CSVOutput:
+		fp.printTable(fp.run.output, ",")
+	case TSVOutput:
+		fp.printTable(fp.run.output, "\t")
+	default:
+		return fmt.Errorf("Called with an unsupported output type: %s", outputType)
+	}
+	return nil
+}
+
+func (fp *forecastPrinter) printTable(w io.Writer, separator string) {
+	horizon := PromQLDuration(fp.horizon)
+	if fp.opts.ForecastHorizon != "" {
+		horizon = fp.opts.ForecastHorizon
+	}
+	headers := []string{"CLUSTER", "NODEPOOL", "RESOURCE", "ALLOCATABLE", "REQUESTS", "PER DAY", "REQUESTS IN " + horizon, "UTIL IN " + horizon}
+	for _, percent := range fp.opts.ForecastThresholds {
+		headers = append(headers, "REACHES "+fp.run.formatNumber(percent)+"%")
+	}
+	_, _ = fmt.Fprintln(w, strings.Join(headers, separator))
+
+	for _, row := range fp.rows {
+		items := []string{
+			row.cluster,
+			row.nodePool,
+			row.resourceType,
+			row.quantityString(fp.run, row.allocatable),
+			row.percentString(fp.run, row.requests),
+			row.perDayString(fp.run),
+			row.projectedString(fp.run, row.requestsTrend, fp.horizon),
+			row.projectedString(fp.run, row.usageTrend, fp.horizon),
+		}
+		for _, ft := range row.thresholds {
+			items = append(items, ft.describe(horizon))
+		}
+		_, _ = fmt.Fprintln(w, strings.Join(items, separator))
+	}
+}
+
+func (fp *forecastPrinter) buildListForecast() *listForecast {
+	out := &listForecast{
+		Window:  fp.opts.ForecastWindow,
+		Horizon: fp.opts.ForecastHorizon,
+		Rows:    []*listForecastItem{},
+	}
+	for _, row := range fp.rows {
+		item := &listForecastItem{
+			Cluster:     row.cluster,
+			NodePool:    row.nodePool,
+			Resource:    row.resourceType,
+			Allocatable: row.quantityString(fp.run, row.allocatable),
+			Requests:    row.quantityString(fp.run, row.requests),
+		}
+		if row.requestsTrend != nil {
+			item.RequestsPerDay = row.perDayString(fp.run)
+			item.ProjectedRequests = row.quantityString(fp.run, row.requestsTrend.projected(fp.horizon))
+		}
+		if !math.IsNaN(row.usage) {
+			item.Utilization = row.quantityString(fp.run, row.usage)
+		}
+		if row.usageTrend != nil {
+			item.ProjectedUtilization = row.quantityString(fp.run, row.usageTrend.projected(fp.horizon))
+		}
+		for _, ft := range row.thresholds {
+			lt := &listForecastThreshold{Percent: ft.percent, Status: ft.status}
+			if ft.status == forecastExpected {
+				lt.Date = ft.at.Format(time.DateOnly)
+			}
+			item.Thresholds = append(item.Thresholds, lt)
+		}
+		out.Rows = append(out.Rows, item)
+	}
+	return out
+}
+
+func (row *forecastRow) quantityString(run *reportRun, v float64) string {
+	rm := &resourceMetric{resourceType: row.resourceType}
+	return run.formatQuantity(row.resourceType, rm.historyQuantity(v))
+}
+
+// percentString returns a value with its percentage of allocatable,
+// example: "41200m (64%)"
+func (row *forecastRow) percentString(run *reportRun, v float64) string {
+	percent := 0.0
+	if row.allocatable > 0 {
+		percent = v / row.allocatable * 100
+	}
+	return fmt.Sprintf("%s (%s%%)", row.quantityString(run, v), run.formatPercent(percent))
+}
+
+// perDayString returns the change in requests per day with its sign,
+// example: "+310m"
+func (row *forecastRow) perDayString(run *reportRun) string {
+	if row.requestsTrend == nil {
+		return VoidValue
+	}
+	sign := "+"
+	if row.requestsTrend.perDay < 0 {
+		sign = "-"
+	}
+	return sign + row.quantityString(run, math.Abs(row.requestsTrend.perDay))
+}
+
+func (row *forecastRow) projectedString(run *reportRun, ft *forecastTrend, horizon time.Duration) string {
+	if ft == nil {
+		return VoidValue
+	}
+	return row.percentString(run, ft.projected(horizon))
+}
+
+// describe returns when the threshold is reached, example: "2026-11-05"
+func (ft *forecastThreshold) describe(horizon string) string {
+	switch ft.status {
+	case forecastExpected:
+		return ft.at.Format(time.DateOnly)
+	case forecastCrossed:
+		return "now"
+	case forecastBeyond:
+		return "after " + horizon
+	default:
+		return "never"
+	}
+}
@@ -0,0 +1,164 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateForecast(t *testing.T) {
+	opts := Options{ForecastWindow: "30d", ForecastHorizon: "60d", ForecastThresholds: []float64{80, 100}}
+	assert.NoError(t, ValidateForecast(opts))
+
+	for _, tc := range []struct {
+		window, horizon string
+		thresholds      []float64
+	}{
+		{"30x", "60d", []float64{80}},
+		{"30m", "60d", []float64{80}},
+		{"30d", "0d", []float64{80}},
+		{"30d", "60d", nil},
+		{"30d", "60d", []float64{80, 0}},
+	} {
+		err := ValidateForecast(Options{ForecastWindow: tc.window, ForecastHorizon: tc.horizon, ForecastThresholds: tc.thresholds})
+		assert.Error(t, err, "%+v", tc)
+	}
+}
+
+func TestFitForecastTrend(t *testing.T) {
+	points := newForecastPoints()
+	for i := range points {
+		points[i] = 10 + 0.5*float64(i)
+	}
+	// Gaps are skipped
+	points[3] = math.NaN()
+	points[forecastPoints-1] = math.NaN()
+
+	ft := fitForecastTrend(points, 12*time.Hour)
+	require.NotNil(t, ft)
+	assert.InDelta(t, 1, ft.perDay, 1e-9)
+	assert.InDelta(t, 10+0.5*float64(forecastPoints-1), ft.current, 1e-9)
+	assert.InDelta(t, 10+0.5*float64(forecastPoints-1)+30, ft.projected(30*24*time.Hour), 1e-9)
+
+	single := newForecastPoints()
+	single[5] = 1
+	assert.Nil(t, fitForecastTrend(single, time.Hour))
+	assert.Nil(t, fitForecastTrend(nil, time.Hour))
+}
+
+func TestForecastAddRangeResult(t *testing.T) {
+	start := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	fh := &forecastHistory{start: start, step: time.Hour, nodes: map[string]map[string]*forecastSeries{}}
+	at := func(hours int) float64 {
+		return float64(start.Add(time.Duration(hours) * time.Hour).Unix())
+	}
+	fh.addRangeResult(&prometheusResponse{Data: prometheusData{Result: []prometheusResult{
+		{Metric: map[string]string{"node": "a", "resource": "cpu"}, Values: [][]interface{}{{at(0), "2"}, {at(1), "3"}}},
+		{Metric: map[string]string{"node": "a", "resource": "nvidia_com_gpu"}, Values: [][]interface{}{{at(0), "1"}}},
+		{Metric: map[string]string{"node": "", "resource": "memory"}, Values: [][]interface{}{{at(-1), "9"}, {at(2), "4"}}},
+	}}}, "", func(fs *forecastSeries) *[]float64 { return &fs.requests })
+	fh.addRangeResult(&prometheusResponse{Data: prometheusData{Result: []prometheusResult{
+		{Metric: map[string]string{"node": "a"}, Values: [][]interface{}{{at(1), "0.5"}}},
+	}}}, "cpu", func(fs *forecastSeries) *[]float64 { return &fs.usage })
+
+	require.Len(t, fh.nodes, 2)
+	cpu := fh.nodes["a"]["cpu"]
+	assert.Equal(t, []float64{2, 3}, cpu.requests[:2])
+	assert.True(t, math.IsNaN(cpu.requests[2]))
+	assert.Equal(t, 0.5, cpu.usage[1])
+	assert.Nil(t, cpu.allocatable)
+	assert.NotContains(t, fh.nodes["a"], "nvidia_com_gpu")
+	assert.Equal(t, 4.0, fh.nodes[""]["memory"].requests[2])
+}
+
+func TestKubeStateMetricsLabels(t *testing.T) {
+	_, pool := nodeGroupName(kubeStateMetricsLabels(map[string]string{
+		"node":                                "a",
+		"label_cloud_google_com_gke_nodepool": "default-pool",
+	}))
+	assert.Equal(t, "default-pool", pool)
+
+	_, pool = nodeGroupName(kubeStateMetricsLabels(map[string]string{"node": "b"}))
+	assert.Equal(t, ungroupedNodeGroup, pool)
+}
+
+func TestForecastReport(t *testing.T) {
+	run := newTestRun(nil)
+	start := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+	series := func(f func(i int) float64) []float64 {
+		points := newForecastPoints()
+		for i := range points {
+			points[i] = f(i)
+		}
+		return points
+	}
+
+	// Requests on node a grow by 100m a day, those on node b are flat, and
+	// a pending pod counts only towards the cluster
+	fh := &forecastHistory{
+		cluster: "prod",
+		start:   start,
+		step:    24 * time.Hour,
+		nodes: map[string]map[string]*forecastSeries{
+			"a": {"cpu": {
+				requests:    series(func(i int) float64 { return 2 + 0.1*float64(i) }),
+				allocatable: series(func(i int) float64 { return 20 }),
+				usage:       series(func(i int) float64 { return 1 + 0.05*float64(i) }),
+			}},
+			"b": {"cpu": {
+				requests:    series(func(i int) float64 { return 4 }),
+				allocatable: series(func(i int) float64 { return 8 }),
+			}},
+			"": {"cpu": {
+				requests: series(func(i int) float64 { return 1 }),
+			}},
+		},
+		nodePools: map[string]string{"a": "general"},
+	}
+
+	horizon := 60 * 24 * time.Hour
+	opts := Options{ForecastWindow: "119d", ForecastHorizon: "60d", ForecastThresholds: []float64{80, 100}}
+	fp := &forecastPrinter{run: run, rows: buildForecastRows(fh, horizon, opts.ForecastThresholds), opts: opts, horizon: horizon}
+
+	var buf bytes.Buffer
+	fp.printTable(&buf, "\t")
+	assert.Equal(t, strings.Join([]string{
+		"CLUSTER\tNODEPOOL\tRESOURCE\tALLOCATABLE\tREQUESTS\tPER DAY\tREQUESTS IN 60d\tUTIL IN 60d\tREACHES 80%\tREACHES 100%",
+		"prod\t*\tcpu\t28000m\t18900m (67%)\t+100m\t24900m (88%)\t9950m (35%)\t2026-11-02\tafter 60d",
+		"prod\t<none>\tcpu\t8000m\t4000m (50%)\t+0m\t4000m (50%)\t*\tnever\tnever",
+		"prod\tgeneral\tcpu\t20000m\t13900m (69%)\t+100m\t19900m (99%)\t9950m (49%)\t2026-10-19\tafter 60d",
+	}, "\n")+"\n", buf.String())
+
+	list := fp.buildListForecast()
+	require.Len(t, list.Rows, 3)
+	assert.Equal(t, "60d", list.Horizon)
+	assert.Equal(t, []*listForecastThreshold{
+		{Percent: 80, Status: forecastExpected, Date: "2026-11-02"},
+		{Percent: 100, Status: forecastBeyond},
+	}, list.Rows[0].Thresholds)
+	assert.Empty(t, list.Rows[1].ProjectedUtilization)
+
+	// Requests already over a threshold have crossed it
+	row := &forecastRow{allocatable: 10, requests: 9, requestsTrend: &forecastTrend{current: 9, perDay: -1}}
+	assert.Equal(t, forecastCrossed, row.forecastThreshold(80, start, horizon).status)
+	assert.Equal(t, forecastFlat, row.forecastThreshold(100, start, horizon).status)
+}
@@ -0,0 +1,293 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const (
+	// nvidiaResourcePrefix starts the extended resources the NVIDIA device
+	// plugin advertises, like nvidia.com/gpu and nvidia.com/mig-1g.5gb
+	nvidiaResourcePrefix = "nvidia.com/"
+	nvidiaGPUResource    = corev1.ResourceName("nvidia.com/gpu")
+	nvidiaSharedResource = corev1.ResourceName("nvidia.com/gpu.shared")
+	nvidiaMIGPrefix      = "nvidia.com/mig-"
+
+	// Labels set by NVIDIA GPU feature discovery
+	nvidiaGPUCountLabel        = "nvidia.com/gpu.count"
+	nvidiaGPUProductLabel      = "nvidia.com/gpu.product"
+	nvidiaGPUReplicasLabel     = "nvidia.com/gpu.replicas"
+	nvidiaSharingStrategyLabel = "nvidia.com/gpu.sharing-strategy"
+	nvidiaMIGStrategyLabel     = "nvidia.com/mig.strategy"
+)
+
+// How the GPUs of a node are shared between pods
+const (
+	gpuSharingNone        = "none"
+	gpuSharingMIG         = "mig"
+	gpuSharingTimeSlicing = "time-slicing"
+	gpuSharingMPS         = "mps"
+)
+
+// gpuMetric is the slices of one NVIDIA extended resource a node advertises
+// and its pods request
+type gpuMetric struct {
+	allocatable resource.Quantity
+	request     resource.Quantity
+}
+
+type listGPUs struct {
+	ClusterTotals *listGPUNode   `json:"clusterTotals"`
+	Nodes         []*listGPUNode `json:"nodes"`
+}
+
+type listGPUNode struct {
+	Name      string             `json:"name,omitempty"`
+	Product   string             `json:"product,omitempty"`
+	GPUs      int64              `json:"gpus,omitempty"`
+	Sharing   string             `json:"sharing,omitempty"`
+	Replicas  int64              `json:"replicas,omitempty"`
+	Resources []*listGPUResource `json:"resources"`
+}
+
+type listGPUResource struct {
+	Resource     string `json:"resource"`
+	Advertised   int64  `json:"advertised"`
+	Requested    int64  `json:"requested"`
+	RequestedPct string `json:"requestedPercent"`
+	Available    int64  `json:"available"`
+}
+
+func isGPUResource(name corev1.ResourceName) bool {
+	return strings.HasPrefix(string(name), nvidiaResourcePrefix)
+}
+
+// nodeGPUs returns the NVIDIA extended resources the node advertises, nil
+// when it has none
+func nodeGPUs(node *corev1.Node) map[corev1.ResourceName]*gpuMetric {
+	var gpus map[corev1.ResourceName]*gpuMetric
+	for name, q := range node.Status.Allocatable {
+		if !isGPUResource(name) {
+			continue
+		}
+		if gpus == nil {
+			gpus = map[corev1.ResourceName]*gpuMetric{}
+		}
+		gpus[name] = &gpuMetric{allocatable: q.DeepCopy()}
+	}
+	return gpus
+}
+
+// addGPURequests adds the NVIDIA extended resources a pod requests to its
+// node
+func (nm *nodeMetric) addGPURequests(req corev1.ResourceList) {
+	for name, q := range req {
+		if !isGPUResource(name) {
+			continue
+		}
+		if nm.gpus == nil {
+			nm.gpus = map[corev1.ResourceName]*gpuMetric{}
+		}
+		if nm.gpus[name] == nil {
+			nm.gpus[name] = &gpuMetric{}
+		}
+		nm.gpus[name].request.Add(q)
+	}
+}
+
+// gpuSharing returns how the GPUs of the node are shared, and the slices
+// each GPU is split into with time-slicing or MPS. MIG is recognized by its
+// nvidia.com/mig-* resources, or by the single strategy which advertises
+// MIG devices as nvidia.com/gpu.
+func (nm *nodeMetric) gpuSharing() (string, int64) {
+	for name := range nm.gpus {
+		if strings.HasPrefix(string(name), nvidiaMIGPrefix) {
+			return gpuSharingMIG, 0
+		}
+	}
+	if nm.labels[nvidiaMIGStrategyLabel] == "single" {
+		return gpuSharingMIG, 0
+	}
+
+	replicas, _ := strconv.ParseInt(nm.labels[nvidiaGPUReplicasLabel], 10, 64)
+	switch nm.labels[nvidiaSharingStrategyLabel] {
+	case gpuSharingTimeSlicing:
+		return gpuSharingTimeSlicing, replicas
+	case gpuSharingMPS:
+		return gpuSharingMPS, replicas
+	}
+	if replicas > 1 || nm.gpus[nvidiaSharedResource] != nil {
+		return gpuSharingTimeSlicing, replicas
+	}
+	return gpuSharingNone, 0
+}
+
+// physicalGPUs returns the number of GPUs in the node from the label GPU
+// feature discovery sets, or from the advertised GPUs when they are not
+// shared or the slices each is split into is known. It returns 0 when the
+// number can not be told.
+func (nm *nodeMetric) physicalGPUs() int64 {
+	if count, err := strconv.ParseInt(nm.labels[nvidiaGPUCountLabel], 10, 64); err == nil {
+		return count
+	}
+
+	var advertised int64
+	for _, name := range []corev1.ResourceName{nvidiaGPUResource, nvidiaSharedResource} {
+		if g := nm.gpus[name]; g != nil {
+			advertised += g.allocatable.Value()
+		}
+	}
+	switch sharing, replicas := nm.gpuSharing(); sharing {
+	case gpuSharingNone:
+		return advertised
+	case gpuSharingTimeSlicing, gpuSharingMPS:
+		if replicas > 0 {
+			return advertised / replicas
+		}
+	}
+	return 0
+}
+
+func printGPUs(run *reportRun, cm *clusterMetric, opts Options) error {
+	gp := &gpuPrinter{run: run, cm: cm, opts: opts}
+	return gp.Print(opts.OutputFormat)
+}
+
+type gpuPrinter struct {
+	run  *reportRun
+	cm   *clusterMetric
+	opts Options
+}
+
+func (gp *gpuPrinter) Print(outputType string) error {
+	switch outputType {
+	case JSONOutput, YAMLOutput:
+		printListOutput(gp.run, GPUReportKind, gp.buildListGPUs(), outputType)
+	case TableOutput:
+		w := tabwriter.NewWriter(gp.run.output, 0, 8, 2, ' ', 0)
+		gp.printTable(w, "\t ")
+		if err := w.Flush(); err != nil {
+			gp.run.logErrorf("Error writing to table: %s", err)
+		}
+	case CSVOutput:
+		gp.printTable(gp.run.output, ",")
+	case TSVOutput:
+		gp.printTable(gp.run.output, "\t")
+	default:
+		return fmt.Errorf("Called with an unsupported output type: %s", outputType)
+	}
+	return nil
+}
+
+// printTable prints one row for each GPU resource of each node, with the
+// cluster totals first
+func (gp *gpuPrinter) printTable(w io.Writer, separator string) {
+	_, _ = fmt.Fprintln(w, strings.Join([]string{
+		"NODE", "PRODUCT", "GPUS", "SHARING", "RESOURCE", "ADVERTISED", "REQUESTED", "AVAILABLE",
+	}, separator))
+
+	list := gp.buildListGPUs()
+	list.ClusterTotals.Name = VoidValue
+	list.ClusterTotals.Product = VoidValue
+	list.ClusterTotals.Sharing = VoidValue
+	for _, ln := range append([]*listGPUNode{list.ClusterTotals}, list.Nodes...) {
+		gpus := VoidValue
+		if ln.GPUs > 0 {
+			gpus = fmt.Sprintf("%d", ln.GPUs)
+		}
+		sharing := ln.Sharing
+		if ln.Replicas > 0 {
+			sharing = fmt.Sprintf("%s (%dx)", ln.Sharing, ln.Replicas)
+		}
+		for _, lr := range ln.Resources {
+			_, _ = fmt.Fprintln(w, strings.Join([]string{
+				ln.Name,
+				voidIfEmpty(ln.Product),
+				gpus,
+				sharing,
+				lr.Resource,
+				fmt.Sprintf("%d", lr.Advertised),
+				fmt.Sprintf("%d (%s)", lr.Requested, lr.RequestedPct),
+				fmt.Sprintf("%d", lr.Available),
+			}, separator))
+		}
+	}
+}
+
+// buildListGPUs lists the nodes with NVIDIA GPUs in name order. The cluster
+// totals sum each resource across nodes, and the GPUs of the nodes whose
+// count is known.
+func (gp *gpuPrinter) buildListGPUs() *listGPUs {
+	totals := map[corev1.ResourceName]*gpuMetric{}
+	out := &listGPUs{
+		ClusterTotals: &listGPUNode{Resources: []*listGPUResource{}},
+		Nodes:         []*listGPUNode{},
+	}
+	for _, nm := range gp.cm.getSortedNodeMetrics("name") {
+		if len(nm.gpus) == 0 {
+			continue
+		}
+		ln := &listGPUNode{
+			Name:      nm.name,
+			Product:   nm.labels[nvidiaGPUProductLabel],
+			GPUs:      nm.physicalGPUs(),
+			Resources: listGPUResources(gp.run, nm.gpus),
+		}
+		ln.Sharing, ln.Replicas = nm.gpuSharing()
+		out.Nodes = append(out.Nodes, ln)
+
+		out.ClusterTotals.GPUs += ln.GPUs
+		for name, g := range nm.gpus {
+			if totals[name] == nil {
+				totals[name] = &gpuMetric{}
+			}
+			totals[name].allocatable.Add(g.allocatable)
+			totals[name].request.Add(g.request)
+		}
+	}
+	out.ClusterTotals.Resources = listGPUResources(gp.run, totals)
+	return out
+}
+
+// listGPUResources lists GPU resources in name order
+func listGPUResources(run *reportRun, gpus map[corev1.ResourceName]*gpuMetric) []*listGPUResource {
+	names := []string{}
+	for name := range gpus {
+		names = append(names, string(name))
+	}
+	sort.Strings(names)
+
+	out := []*listGPUResource{}
+	for _, name := range names {
+		g := gpus[corev1.ResourceName(name)]
+		out = append(out, &listGPUResource{
+			Resource:     name,
+			Advertised:   g.allocatable.Value(),
+			Requested:    g.request.Value(),
+			RequestedPct: percentString(run, g.request, g.allocatable),
+			Available:    g.allocatable.Value() - g.request.Value(),
+		})
+	}
+	return out
+}
@@ -0,0 +1,102 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func gpuTestClusterMetric() *clusterMetric {
+	nodes := &corev1.NodeList{Items: []corev1.Node{
+		*nodeWithAllocatable("cpu-node", map[string]string{}, corev1.ResourceList{"cpu": resource.MustParse("4")}),
+		*nodeWithAllocatable("mig-node", map[string]string{
+			nvidiaGPUProductLabel: "NVIDIA-A100-SXM4-40GB",
+			nvidiaGPUCountLabel:   "1",
+		}, corev1.ResourceList{
+			"nvidia.com/mig-1g.5gb":  resource.MustParse("4"),
+			"nvidia.com/mig-3g.20gb": resource.MustParse("1"),
+		}),
+		*nodeWithAllocatable("shared-node", map[string]string{
+			nvidiaSharingStrategyLabel: gpuSharingTimeSlicing,
+			nvidiaGPUReplicasLabel:     "4",
+		}, corev1.ResourceList{nvidiaGPUResource: resource.MustParse("4")}),
+		*nodeWithAllocatable("whole-node", map[string]string{}, corev1.ResourceList{nvidiaGPUResource: resource.MustParse("2")}),
+	}}
+	pods := &corev1.PodList{Items: []corev1.Pod{
+		*podWithRequests("mig-node", "default", "inference", nil, corev1.ResourceList{"nvidia.com/mig-1g.5gb": resource.MustParse("3")}),
+		*podWithRequests("shared-node", "default", "notebook", nil, corev1.ResourceList{nvidiaGPUResource: resource.MustParse("4")}),
+		*podWithRequests("whole-node", "default", "training", nil, corev1.ResourceList{nvidiaGPUResource: resource.MustParse("1")}),
+		*podWithRequests("cpu-node", "default", "web", nil, corev1.ResourceList{"cpu": resource.MustParse("1")}),
+	}}
+	cm := buildClusterMetric(pods, nil, nodes, nil)
+	return &cm
+}
+
+func TestGPUSharing(t *testing.T) {
+	cm := gpuTestClusterMetric()
+
+	for _, tc := range []struct {
+		node     string
+		sharing  string
+		replicas int64
+		gpus     int64
+	}{
+		{"mig-node", gpuSharingMIG, 0, 1},
+		{"shared-node", gpuSharingTimeSlicing, 4, 1},
+		{"whole-node", gpuSharingNone, 0, 2},
+	} {
+		nm := cm.nodeMetrics[tc.node]
+		sharing, replicas := nm.gpuSharing()
+		assert.Equal(t, tc.sharing, sharing, tc.node)
+		assert.Equal(t, tc.replicas, replicas, tc.node)
+		assert.Equal(t, tc.gpus, nm.physicalGPUs(), tc.node)
+	}
+
+	// The single MIG strategy advertises MIG devices as nvidia.com/gpu, and
+	// MPS without its replicas leaves the number of GPUs unknown
+	nm := &nodeMetric{labels: map[string]string{nvidiaMIGStrategyLabel: "single"}}
+	sharing, _ := nm.gpuSharing()
+	assert.Equal(t, gpuSharingMIG, sharing)
+
+	nm = &nodeMetric{
+		labels: map[string]string{nvidiaSharingStrategyLabel: gpuSharingMPS},
+		gpus:   map[corev1.ResourceName]*gpuMetric{nvidiaGPUResource: {allocatable: resource.MustParse("8")}},
+	}
+	assert.Equal(t, int64(0), nm.physicalGPUs())
+}
+
+func TestGPUReport(t *testing.T) {
+	run := newTestRun(nil)
+	gp := &gpuPrinter{run: run, cm: gpuTestClusterMetric()}
+
+	var buf bytes.Buffer
+	gp.printTable(&buf, ",")
+	assert.Equal(t, strings.Join([]string{
+		"NODE,PRODUCT,GPUS,SHARING,RESOURCE,ADVERTISED,REQUESTED,AVAILABLE",
+		"*,*,4,*,nvidia.com/gpu,6,5 (83%),1",
+		"*,*,4,*,nvidia.com/mig-1g.5gb,4,3 (75%),1",
+		"*,*,4,*,nvidia.com/mig-3g.20gb,1,0 (0%),1",
+		"mig-node,NVIDIA-A100-SXM4-40GB,1,mig,nvidia.com/mig-1g.5gb,4,3 (75%),1",
+		"mig-node,NVIDIA-A100-SXM4-40GB,1,mig,nvidia.com/mig-3g.20gb,1,0 (0%),1",
+		"shared-node,*,1,time-slicing (4x),nvidia.com/gpu,4,4 (100%),0",
+		"whole-node,*,2,none,nvidia.com/gpu,2,1 (50%),1",
+	}, "\n")+"\n", buf.String())
+}
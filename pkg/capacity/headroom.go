@@ -0,0 +1,475 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	autoscalerStatusNamespace = "kube-system"
+	autoscalerStatusName      = "cluster-autoscaler-status"
+
+	karpenterNodePoolLabel = "karpenter.sh/nodepool"
+
+	headroomSourceAutoscaler = "cluster-autoscaler"
+	headroomSourceKarpenter  = "karpenter"
+	headroomSourceStatic     = "static"
+
+	// ungroupedNodeGroup collects nodes without any node group label
+	ungroupedNodeGroup = "<none>"
+)
+
+// nodeGroupLabels are the well-known labels that identify the node group or
+// pool a node belongs to, in order of precedence
+var nodeGroupLabels = []string{
+	karpenterNodePoolLabel,
+	"eks.amazonaws.com/nodegroup",
+	"alpha.eksctl.io/nodegroup-name",
+	"cloud.google.com/gke-nodepool",
+	"kubernetes.azure.com/agentpool",
+	"agentpool",
+	"kops.k8s.io/instancegroup",
+}
+
+var nodePoolResource = schema.GroupVersionResource{
+	Group:    "karpenter.sh",
+	Version:  "v1",
+	Resource: "nodepools",
+}
+
+// nodePool holds the fields of a karpenter.sh/v1 NodePool used by the
+// report, avoiding a dependency on the Karpenter API
+type nodePool struct {
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              struct {
+		Limits     corev1.ResourceList `json:"limits,omitempty"`
+		Disruption struct {
+			ConsolidationPolicy string `json:"consolidationPolicy,omitempty"`
+		} `json:"disruption"`
+	} `json:"spec"`
+}
+
+type nodePoolList struct {
+	Items []nodePool `json:"items"`
+}
+
+// autoscalerNodeGroup is a node group as reported in the Cluster Autoscaler
+// status ConfigMap
+type autoscalerNodeGroup struct {
+	name    string
+	minSize int64
+	maxSize int64
+}
+
+type nodeGroupMetric struct {
+	name     string
+	source   string
+	nodes    int64
+	minNodes *int64
+	maxNodes *int64
+	cpu      *headroomMetric
+	memory   *headroomMetric
+}
+
+// headroomMetric holds the capacity of a single resource in a node group.
+// max is nil when the group has no upper bound or it can not be estimated.
+type headroomMetric struct {
+	resourceType string
+	request      resource.Quantity
+	allocatable  resource.Quantity
+	max          *resource.Quantity
+}
+
+type listNodeGroups struct {
+	ClusterTotals *listNodeGroup   `json:"clusterTotals"`
+	NodeGroups    []*listNodeGroup `json:"nodeGroups"`
+}
+
+type listNodeGroup struct {
+	Name     string                `json:"name"`
+	Source   string                `json:"source,omitempty"`
+	Nodes    int64                 `json:"nodes"`
+	MinNodes *int64                `json:"minNodes,omitempty"`
+	MaxNodes *int64                `json:"maxNodes,omitempty"`
+	CPU      *listHeadroomResource `json:"cpu"`
+	Memory   *listHeadroomResource `json:"memory"`
+}
+
+type listHeadroomResource struct {
+	Requests    string `json:"requests"`
+	Allocatable string `json:"allocatable"`
+	Max         string `json:"max,omitempty"`
+	Headroom    string `json:"headroom,omitempty"`
+}
+
+// getAutoscalerStatus returns nil when the Cluster Autoscaler is not
+// running in the cluster
+func getAutoscalerStatus(clientset kubernetes.Interface) (*corev1.ConfigMap, *fetchError) {
+	cm, err := clientset.CoreV1().ConfigMaps(autoscalerStatusNamespace).Get(context.TODO(), autoscalerStatusName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, newFetchError(3, "Error getting Cluster Autoscaler status: %v", err)
+	}
+	return cm, nil
+}
+
+func getNodePools(run *reportRun, opts Options) (*nodePoolList, *fetchError) {
+	dynamicClient, err := newDynamicClient(opts)
+	if err != nil {
+		return nil, newFetchError(1, "Error connecting to Kubernetes: %v", err)
+	}
+	return listNodePools(run, dynamicClient)
+}
+
+// listNodePools returns an empty list when Karpenter is not installed in the
+// cluster
+func listNodePools(run *reportRun, dynamicClient dynamic.Interface) (*nodePoolList, *fetchError) {
+	npList := &nodePoolList{Items: []nodePool{}}
+
+	start := time.Now()
+	uList, err := dynamicClient.Resource(nodePoolResource).List(context.TODO(), metav1.ListOptions{})
+	if apierrors.IsNotFound(err) {
+		return npList, nil
+	}
+	if err != nil {
+		return nil, newFetchError(3, "Error listing NodePools: %v", err)
+	}
+	run.logTiming(start, "Listed %d Karpenter NodePools", len(uList.Items))
+
+	for _, item := range uList.Items {
+		var np nodePool
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(item.Object, &np); err != nil {
+			return nil, newFetchError(3, "Error parsing NodePool %s: %v", item.GetName(), err)
+		}
+		npList.Items = append(npList.Items, np)
+	}
+	return npList, nil
+}
+
+var (
+	autoscalerNameRegexp    = regexp.MustCompile(`^\s*(?:-\s*)?[Nn]ame:\s*(\S+)`)
+	autoscalerMinSizeRegexp = regexp.MustCompile(`minSize[=:]\s*(\d+)`)
+	autoscalerMaxSizeRegexp = regexp.MustCompile(`maxSize[=:]\s*(\d+)`)
+)
+
+// parseAutoscalerStatus reads the node groups and their sizes from the
+// Cluster Autoscaler status. Both the human readable format and the YAML
+// format used by newer releases are supported.
+func parseAutoscalerStatus(status string) []autoscalerNodeGroup {
+	groups := []autoscalerNodeGroup{}
+	var current *autoscalerNodeGroup
+
+	scanner := bufio.NewScanner(strings.NewReader(status))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if m := autoscalerNameRegexp.FindStringSubmatch(line); m != nil {
+			groups = append(groups, autoscalerNodeGroup{name: m[1], minSize: -1, maxSize: -1})
+			current = &groups[len(groups)-1]
+		}
+		if current == nil {
+			continue
+		}
+		if m := autoscalerMinSizeRegexp.FindStringSubmatch(line); m != nil {
+			current.minSize, _ = strconv.ParseInt(m[1], 10, 64)
+		}
+		if m := autoscalerMaxSizeRegexp.FindStringSubmatch(line); m != nil {
+			current.maxSize, _ = strconv.ParseInt(m[1], 10, 64)
+		}
+	}
+
+	sized := []autoscalerNodeGroup{}
+	for _, g := range groups {
+		if g.maxSize >= 0 {
+			sized = append(sized, g)
+		}
+	}
+	return sized
+}
+
+// nodeGroupName returns the value of the first node group label set on the
+// node
+func nodeGroupName(labels map[string]string) (string, string) {
+	for _, label := range nodeGroupLabels {
+		if value, ok := labels[label]; ok && value != "" {
+			return label, value
+		}
+	}
+	return "", ungroupedNodeGroup
+}
+
+// buildNodeGroupMetrics groups nodes by their node group label and
+// estimates how far each group can grow. Cluster Autoscaler node groups are
+// matched to the node group whose name is the longest part of the
+// autoscaler's name, since cloud providers embed the pool name in the name
+// of the underlying instance group. Their maximum capacity assumes new nodes
+// match the average of the group's current nodes.
+func buildNodeGroupMetrics(cm *clusterMetric, autoscalerGroups []autoscalerNodeGroup, npList *nodePoolList) []*nodeGroupMetric {
+	groups := map[string]*nodeGroupMetric{}
+	getGroup := func(name string) *nodeGroupMetric {
+		if _, ok := groups[name]; !ok {
+			groups[name] = &nodeGroupMetric{
+				name:   name,
+				source: headroomSourceStatic,
+				cpu:    &headroomMetric{resourceType: "cpu"},
+				memory: &headroomMetric{resourceType: "memory"},
+			}
+		}
+		return groups[name]
+	}
+
+	karpenterGroups := map[string]bool{}
+	for _, nm := range cm.nodeMetrics {
+		label, name := nodeGroupName(nm.labels)
+		if label == karpenterNodePoolLabel {
+			karpenterGroups[name] = true
+		}
+		g := getGroup(name)
+		g.nodes++
+		g.cpu.request.Add(nm.cpu.request)
+		g.cpu.allocatable.Add(nm.cpu.allocatable)
+		g.memory.request.Add(nm.memory.request)
+		g.memory.allocatable.Add(nm.memory.allocatable)
+	}
+
+	for _, ag := range autoscalerGroups {
+		name := ag.name
+		for groupName := range groups {
+			if groupName == ungroupedNodeGroup || karpenterGroups[groupName] {
+				continue
+			}
+			if strings.Contains(ag.name, groupName) && (name == ag.name || len(groupName) > len(name)) {
+				name = groupName
+			}
+		}
+
+		g := getGroup(name)
+		g.source = headroomSourceAutoscaler
+		if g.minNodes == nil {
+			g.minNodes, g.maxNodes = new(int64), new(int64)
+		}
+		if ag.minSize > 0 {
+			*g.minNodes += ag.minSize
+		}
+		*g.maxNodes += ag.maxSize
+	}
+
+	if npList != nil {
+		for _, np := range npList.Items {
+			g := getGroup(np.Name)
+			g.source = headroomSourceKarpenter
+			g.cpu.max = nodePoolLimit(np.Spec.Limits, corev1.ResourceCPU)
+			g.memory.max = nodePoolLimit(np.Spec.Limits, corev1.ResourceMemory)
+		}
+	}
+
+	nodeGroups := []*nodeGroupMetric{}
+	for _, g := range groups {
+		switch g.source {
+		case headroomSourceStatic:
+			g.cpu.max = quantityPtr(g.cpu.allocatable)
+			g.memory.max = quantityPtr(g.memory.allocatable)
+		case headroomSourceAutoscaler:
+			if g.nodes > 0 {
+				g.cpu.max = scaleNodeGroup(g.cpu.allocatable, g.nodes, *g.maxNodes)
+				g.memory.max = scaleNodeGroup(g.memory.allocatable, g.nodes, *g.maxNodes)
+			}
+		}
+		nodeGroups = append(nodeGroups, g)
+	}
+
+	sort.Slice(nodeGroups, func(i, j int) bool {
+		return nodeGroups[i].name < nodeGroups[j].name
+	})
+
+	return nodeGroups
+}
+
+func nodePoolLimit(limits corev1.ResourceList, name corev1.ResourceName) *resource.Quantity {
+	if limit, ok := limits[name]; ok {
+		return &limit
+	}
+	return nil
+}
+
+func quantityPtr(q resource.Quantity) *resource.Quantity {
+	return &q
+}
+
+// scaleNodeGroup estimates the allocatable capacity of a group of nodes
+// scaled from its current size to maxNodes
+func scaleNodeGroup(allocatable resource.Quantity, nodes, maxNodes int64) *resource.Quantity {
+	if allocatable.Format == resource.DecimalSI {
+		return resource.NewMilliQuantity(allocatable.MilliValue()/nodes*maxNodes, allocatable.Format)
+	}
+	return resource.NewQuantity(allocatable.Value()/nodes*maxNodes, allocatable.Format)
+}
+
+// getNodeGroupTotals sums all node groups. The maximum is only known when it
+// is known for every group.
+func getNodeGroupTotals(nodeGroups []*nodeGroupMetric) *nodeGroupMetric {
+	totals := &nodeGroupMetric{
+		name:   VoidValue,
+		cpu:    &headroomMetric{resourceType: "cpu", max: &resource.Quantity{}},
+		memory: &headroomMetric{resourceType: "memory", max: &resource.Quantity{}},
+	}
+	for _, g := range nodeGroups {
+		totals.nodes += g.nodes
+		for _, pair := range [][2]*headroomMetric{{totals.cpu, g.cpu}, {totals.memory, g.memory}} {
+			total, hm := pair[0], pair[1]
+			total.request.Add(hm.request)
+			total.allocatable.Add(hm.allocatable)
+			if total.max != nil && hm.max != nil {
+				total.max.Add(*hm.max)
+			} else {
+				total.max = nil
+			}
+		}
+	}
+	return totals
+}
+
+func (hm *headroomMetric) headroom() *resource.Quantity {
+	if hm.max == nil {
+		return nil
+	}
+	headroom := hm.max.DeepCopy()
+	headroom.Sub(hm.request)
+	return &headroom
+}
+
+func (hm *headroomMetric) listHeadroomResource(run *reportRun) *listHeadroomResource {
+	rm := resourceMetric{resourceType: hm.resourceType}
+	valueCalculator := rm.valueFunction(run)
+	out := &listHeadroomResource{
+		Requests:    valueCalculator(hm.request),
+		Allocatable: valueCalculator(hm.allocatable),
+	}
+	if hm.max != nil {
+		out.Max = valueCalculator(*hm.max)
+		out.Headroom = valueCalculator(*hm.headroom())
+	}
+	return out
+}
+
+func (g *nodeGroupMetric) listNodeGroup(run *reportRun) *listNodeGroup {
+	return &listNodeGroup{
+		Name:     g.name,
+		Source:   g.source,
+		Nodes:    g.nodes,
+		MinNodes: g.minNodes,
+		MaxNodes: g.maxNodes,
+		CPU:      g.cpu.listHeadroomResource(run),
+		Memory:   g.memory.listHeadroomResource(run),
+	}
+}
+
+// nodesString formats the node count as "current (min-max)" for groups
+// managed by the Cluster Autoscaler
+func (lg *listNodeGroup) nodesString() string {
+	if lg.MaxNodes == nil {
+		return fmt.Sprintf("%d", lg.Nodes)
+	}
+	return fmt.Sprintf("%d (%d-%d)", lg.Nodes, *lg.MinNodes, *lg.MaxNodes)
+}
+
+func printHeadroom(run *reportRun, cm *clusterMetric, opts Options) error {
+	hp := &headroomPrinter{run: run, nodeGroups: cm.nodeGroups}
+	return hp.Print(opts.OutputFormat)
+}
+
+type headroomPrinter struct {
+	run        *reportRun
+	nodeGroups []*nodeGroupMetric
+}
+
+func (hp *headroomPrinter) Print(outputType string) error {
+	switch outputType {
+	case JSONOutput, YAMLOutput:
+		printListOutput(hp.run, HeadroomReportKind, hp.buildListNodeGroups(), outputType)
+	case TableOutput:
+		w := tabwriter.NewWriter(hp.run.output, 0, 8, 2, ' ', 0)
+		hp.printTable(w, "\t ")
+		if err := w.Flush(); err != nil {
+			hp.run.logErrorf("Error writing to table: %s", err)
+		}
+	case CSVOutput:
+		hp.printTable(hp.run.output, ",")
+	case TSVOutput:
+		hp.printTable(hp.run.output, "\t")
+	default:
+		return fmt.Errorf("Called with an unsupported output type: %s", outputType)
+	}
+	return nil
+}
+
+func (hp *headroomPrinter) printTable(w io.Writer, separator string) {
+	headers := []string{"NODE GROUP", "SOURCE", "NODES"}
+	for _, prefix := range []string{"CPU", "MEMORY"} {
+		headers = append(headers, prefix+" REQUESTS", prefix+" ALLOCATABLE", prefix+" MAX", prefix+" HEADROOM")
+	}
+	_, _ = fmt.Fprintln(w, strings.Join(headers, separator))
+
+	list := hp.buildListNodeGroups()
+	for _, lg := range append([]*listNodeGroup{list.ClusterTotals}, list.NodeGroups...) {
+		source := lg.Source
+		if source == "" {
+			source = VoidValue
+		}
+		items := []string{lg.Name, source, lg.nodesString()}
+		for _, r := range []*listHeadroomResource{lg.CPU, lg.Memory} {
+			items = append(items, r.Requests, r.Allocatable, voidIfEmpty(r.Max), voidIfEmpty(r.Headroom))
+		}
+		_, _ = fmt.Fprintln(w, strings.Join(items, separator))
+	}
+}
+
+func voidIfEmpty(value string) string {
+	if value == "" {
+		return VoidValue
+	}
+	return value
+}
+
+func (hp *headroomPrinter) buildListNodeGroups() *listNodeGroups {
+	out := &listNodeGroups{
+		ClusterTotals: getNodeGroupTotals(hp.nodeGroups).listNodeGroup(hp.run),
+		NodeGroups:    []*listNodeGroup{},
+	}
+	for _, g := range hp.nodeGroups {
+		out.NodeGroups = append(out.NodeGroups, g.listNodeGroup(hp.run))
+	}
+	return out
+}
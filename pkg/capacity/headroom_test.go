@@ -0,0 +1,168 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+func TestParseAutoscalerStatus(t *testing.T) {
+	legacy := `Cluster-autoscaler status at 2026-01-12 10:04:11.52 +0000 UTC:
+Cluster-wide:
+  Health:      Healthy (ready=3 unready=0 notStarted=0 longNotStarted=0 registered=3 longUnregistered=0)
+               LastProbeTime:      2026-01-12 10:04:11.51 +0000 UTC
+NodeGroups:
+  Name:        eks-workers-a2c4
+  Health:      Healthy (ready=2 unready=0 notStarted=0 longNotStarted=0 registered=2 longUnregistered=0 cloudProviderTarget=2 (minSize=1, maxSize=5))
+  ScaleUp:     NoActivity (ready=2 cloudProviderTarget=2)
+  Name:        eks-batch-9f
+  Health:      Healthy (ready=0 unready=0 notStarted=0 longNotStarted=0 registered=0 longUnregistered=0 cloudProviderTarget=0 (minSize=0, maxSize=3))
+`
+	expected := []autoscalerNodeGroup{
+		{name: "eks-workers-a2c4", minSize: 1, maxSize: 5},
+		{name: "eks-batch-9f", minSize: 0, maxSize: 3},
+	}
+	assert.Equal(t, expected, parseAutoscalerStatus(legacy))
+
+	yamlStatus := `time: "2026-01-12 10:04:11.52 +0000 UTC"
+autoscalerStatus: Running
+clusterWide:
+  health:
+    status: Healthy
+nodeGroups:
+- name: eks-workers-a2c4
+  health:
+    status: Healthy
+    cloudProviderTarget: 2
+    minSize: 1
+    maxSize: 5
+- name: eks-batch-9f
+  health:
+    status: Healthy
+    cloudProviderTarget: 0
+    minSize: 0
+    maxSize: 3
+`
+	assert.Equal(t, expected, parseAutoscalerStatus(yamlStatus))
+	assert.Empty(t, parseAutoscalerStatus(""))
+}
+
+func TestListNodePools(t *testing.T) {
+	run := newTestRun(nil)
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{nodePoolResource: "NodePoolList"},
+		&unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "karpenter.sh/v1",
+			"kind":       "NodePool",
+			"metadata":   map[string]interface{}{"name": "spot"},
+			"spec": map[string]interface{}{
+				"limits": map[string]interface{}{"cpu": "10", "memory": "40Gi"},
+			},
+		}},
+	)
+
+	npList, ferr := listNodePools(run, dynamicClient)
+	require.Nil(t, ferr)
+	require.Len(t, npList.Items, 1)
+	assert.Equal(t, "spot", npList.Items[0].Name)
+	assert.Equal(t, "10", npList.Items[0].Spec.Limits.Cpu().String())
+	assert.Equal(t, "40Gi", npList.Items[0].Spec.Limits.Memory().String())
+}
+
+func TestBuildNodeGroupMetrics(t *testing.T) {
+	run := newTestRun(nil)
+	nodeList := &corev1.NodeList{Items: []corev1.Node{
+		*node("worker-1", map[string]string{"eks.amazonaws.com/nodegroup": "workers"}, false),
+		*node("worker-2", map[string]string{"eks.amazonaws.com/nodegroup": "workers"}, false),
+		*node("spot-1", map[string]string{karpenterNodePoolLabel: "spot"}, false),
+		*node("static-1", map[string]string{}, false),
+	}}
+	for i := range nodeList.Items {
+		nodeList.Items[i].Status.Allocatable = corev1.ResourceList{
+			"cpu":    resource.MustParse("1000m"),
+			"memory": resource.MustParse("4000Mi"),
+		}
+	}
+
+	p := pod("worker-1", "default", "web", map[string]string{})
+	p.Spec.Containers = []corev1.Container{{
+		Name: "app",
+		Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{
+			"cpu":    resource.MustParse("100m"),
+			"memory": resource.MustParse("128Mi"),
+		}},
+	}}
+	cm := buildClusterMetric(&corev1.PodList{Items: []corev1.Pod{*p}}, nil, nodeList, nil)
+
+	autoscalerGroups := []autoscalerNodeGroup{
+		{name: "eks-workers-a2c4", minSize: 1, maxSize: 5},
+		{name: "eks-batch-9f", minSize: 0, maxSize: 3},
+	}
+	npList := &nodePoolList{Items: []nodePool{{}, {}}}
+	npList.Items[0].Name = "spot"
+	npList.Items[0].Spec.Limits = corev1.ResourceList{"cpu": resource.MustParse("10")}
+	npList.Items[1].Name = "gpu"
+	npList.Items[1].Spec.Limits = corev1.ResourceList{"cpu": resource.MustParse("8"), "memory": resource.MustParse("64Gi")}
+
+	hp := &headroomPrinter{run: run, nodeGroups: buildNodeGroupMetrics(&cm, autoscalerGroups, npList)}
+	list := hp.buildListNodeGroups()
+
+	one, three, five, zero := int64(1), int64(3), int64(5), int64(0)
+	assert.Equal(t, []*listNodeGroup{
+		{
+			Name: ungroupedNodeGroup, Source: headroomSourceStatic, Nodes: 1,
+			CPU:    &listHeadroomResource{Requests: "0m", Allocatable: "1000m", Max: "1000m", Headroom: "1000m"},
+			Memory: &listHeadroomResource{Requests: "0Mi", Allocatable: "4000Mi", Max: "4000Mi", Headroom: "4000Mi"},
+		},
+		{
+			Name: "eks-batch-9f", Source: headroomSourceAutoscaler, Nodes: 0, MinNodes: &zero, MaxNodes: &three,
+			CPU:    &listHeadroomResource{Requests: "0m", Allocatable: "0m"},
+			Memory: &listHeadroomResource{Requests: "0Mi", Allocatable: "0Mi"},
+		},
+		{
+			Name: "gpu", Source: headroomSourceKarpenter, Nodes: 0,
+			CPU:    &listHeadroomResource{Requests: "0m", Allocatable: "0m", Max: "8000m", Headroom: "8000m"},
+			Memory: &listHeadroomResource{Requests: "0Mi", Allocatable: "0Mi", Max: "65536Mi", Headroom: "65536Mi"},
+		},
+		{
+			Name: "spot", Source: headroomSourceKarpenter, Nodes: 1,
+			CPU:    &listHeadroomResource{Requests: "0m", Allocatable: "1000m", Max: "10000m", Headroom: "10000m"},
+			Memory: &listHeadroomResource{Requests: "0Mi", Allocatable: "4000Mi"},
+		},
+		{
+			Name: "workers", Source: headroomSourceAutoscaler, Nodes: 2, MinNodes: &one, MaxNodes: &five,
+			CPU:    &listHeadroomResource{Requests: "100m", Allocatable: "2000m", Max: "5000m", Headroom: "4900m"},
+			Memory: &listHeadroomResource{Requests: "128Mi", Allocatable: "8000Mi", Max: "20000Mi", Headroom: "19872Mi"},
+		},
+	}, list.NodeGroups)
+
+	assert.Equal(t, &listNodeGroup{
+		Name:   VoidValue,
+		Nodes:  4,
+		CPU:    &listHeadroomResource{Requests: "100m", Allocatable: "4000m"},
+		Memory: &listHeadroomResource{Requests: "128Mi", Allocatable: "16000Mi"},
+	}, list.ClusterTotals)
+	assert.Equal(t, "2 (1-5)", list.NodeGroups[4].nodesString())
+	assert.Equal(t, "1", list.NodeGroups[3].nodesString())
+}
@@ -0,0 +1,239 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strings"
+)
+
+// heatmapColumns is how many nodes a row of the heatmap holds, each drawn
+// two characters wide so that rows fit in 100 columns
+const heatmapColumns = 40
+
+// heatmapHottest is how many of the nodes with the highest values are
+// listed under the heatmap
+const heatmapHottest = 5
+
+// heatmapNoData marks nodes without allocatable, or without a request or
+// limit for utilization relative to them
+const heatmapNoData = '·'
+
+const ansiReset = "\x1b[0m"
+
+// heatmapLevel is the cell drawn for values below a percentage. The glyphs
+// tell levels apart when colors are not shown.
+type heatmapLevel struct {
+	below float64
+	glyph rune
+	color string
+	label string
+}
+
+var heatmapLevels = []heatmapLevel{
+	{25, '░', "\x1b[34m", "<25%"},
+	{50, '▒', "\x1b[32m", "<50%"},
+	{75, '▓', "\x1b[33m", "<75%"},
+	{100, '█', "\x1b[31m", "<100%"},
+	{math.Inf(1), '!', "\x1b[35m", ">=100%"},
+}
+
+// heatmapMetric is the value the cells of --heatmap are colored by, like
+// cpu.requests or memory.util
+type heatmapMetric struct {
+	resource string
+	metric   string
+}
+
+// heatmapCell is one node of the heatmap
+type heatmapCell struct {
+	name    string
+	percent float64
+	noData  bool
+}
+
+// ValidateHeatmap returns an error for a --heatmap metric that can not be
+// parsed
+func ValidateHeatmap(metric string) error {
+	_, err := parseHeatmapMetric(metric)
+	return err
+}
+
+// HeatmapNeedsUtil reports whether a --heatmap metric is colored by
+// utilization
+func HeatmapNeedsUtil(metric string) bool {
+	hm, err := parseHeatmapMetric(metric)
+	return err == nil && hm.metric == "util"
+}
+
+// parseHeatmapMetric parses metrics in the form
+// <cpu|memory>.<requests|limits|util>, the same fields as --fail-on
+func parseHeatmapMetric(metric string) (*heatmapMetric, error) {
+	invalid := fmt.Errorf("invalid heatmap metric %q, expected one of cpu.requests, cpu.limits, cpu.util, memory.requests, memory.limits, or memory.util", metric)
+
+	resourceName, field, found := strings.Cut(metric, ".")
+	if !found {
+		return nil, invalid
+	}
+	hm := &heatmapMetric{}
+	switch resourceName {
+	case "cpu":
+		hm.resource = "cpu"
+	case "mem", "memory":
+		hm.resource = "memory"
+	default:
+		return nil, invalid
+	}
+	switch field {
+	case "requests", "limits", "util":
+		hm.metric = field
+	default:
+		return nil, invalid
+	}
+	return hm, nil
+}
+
+// title describes the percentage the cells are colored by
+func (hm *heatmapMetric) title(utilPercent string) string {
+	base := "allocatable"
+	if hm.metric == "util" && (utilPercent == "request" || utilPercent == "limit") {
+		base = utilPercent + "s"
+	}
+	return fmt.Sprintf("%s %s %% of %s", strings.ToUpper(chartResourceTitle(hm.resource)), strings.ToUpper(hm.metric), strings.ToUpper(base))
+}
+
+// printHeatmap prints the nodes of the cluster as a grid of cells colored
+// by the --heatmap metric, in the order of --sort
+func printHeatmap(run *reportRun, cm *clusterMetric, opts Options) error {
+	hm, err := parseHeatmapMetric(opts.Heatmap)
+	if err != nil {
+		return fmt.Errorf("Error: %v", err)
+	}
+	cells := heatmapCells(cm, hm, opts)
+	writeHeatmap(run.output, hm.title(opts.UtilPercent), cells, opts.SortBy, heatmapColors(run, opts))
+	return nil
+}
+
+// heatmapColors reports whether cells are colored, which is only done on a
+// terminal that does not set NO_COLOR
+func heatmapColors(run *reportRun, opts Options) bool {
+	if opts.OutputFile != "" || os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	f, ok := run.stdout.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func heatmapCells(cm *clusterMetric, hm *heatmapMetric, opts Options) []heatmapCell {
+	cells := []heatmapCell{}
+	for _, nm := range cm.getSortedNodeMetrics(opts.SortBy) {
+		rm := nm.cpu
+		if hm.resource == "memory" {
+			rm = nm.memory
+		}
+		actual, base := rm.request, rm.allocatable
+		switch hm.metric {
+		case "limits":
+			actual = rm.limit
+		case "util":
+			actual, base = rm.utilization, rm.utilBase(opts.UtilPercent)
+		}
+
+		cell := heatmapCell{name: nm.name}
+		if base.MilliValue() == 0 {
+			cell.noData = true
+		} else {
+			cell.percent = float64(actual.MilliValue()) / float64(base.MilliValue()) * 100
+		}
+		cells = append(cells, cell)
+	}
+	return cells
+}
+
+func heatmapLevelOf(percent float64) heatmapLevel {
+	for _, level := range heatmapLevels {
+		if percent < level.below {
+			return level
+		}
+	}
+	return heatmapLevels[len(heatmapLevels)-1]
+}
+
+// writeHeatmap draws the cells in rows of heatmapColumns, each row labeled
+// with the position of its first node, followed by a legend, the spread of
+// values, and the nodes with the highest values
+func writeHeatmap(w io.Writer, title string, cells []heatmapCell, sortBy string, colors bool) {
+	_, _ = fmt.Fprintf(w, "%s, %d NODES BY %s\n", title, len(cells), strings.ToUpper(sortBy))
+
+	labelWidth := len(fmt.Sprint(len(cells)))
+	for start := 0; start < len(cells); start += heatmapColumns {
+		var row strings.Builder
+		for _, cell := range cells[start:min(start+heatmapColumns, len(cells))] {
+			row.WriteString(heatmapCellString(cell, colors))
+		}
+		_, _ = fmt.Fprintf(w, "%*d %s\n", labelWidth, start+1, row.String())
+	}
+
+	legend := []string{}
+	for _, level := range heatmapLevels {
+		legend = append(legend, heatmapGlyph(level, colors)+" "+level.label)
+	}
+	legend = append(legend, string([]rune{heatmapNoData, heatmapNoData})+" no data")
+	_, _ = fmt.Fprintf(w, "\n%s\n", strings.Join(legend, "  "))
+
+	values := []heatmapCell{}
+	for _, cell := range cells {
+		if !cell.noData {
+			values = append(values, cell)
+		}
+	}
+	if len(values) == 0 {
+		return
+	}
+	sort.SliceStable(values, func(i, j int) bool {
+		return values[i].percent > values[j].percent
+	})
+	_, _ = fmt.Fprintf(w, "min %.0f%%, median %.0f%%, max %.0f%%\n",
+		values[len(values)-1].percent, values[len(values)/2].percent, values[0].percent)
+
+	hottest := []string{}
+	for _, cell := range values[:min(heatmapHottest, len(values))] {
+		hottest = append(hottest, fmt.Sprintf("%s %.0f%%", cell.name, cell.percent))
+	}
+	_, _ = fmt.Fprintf(w, "highest: %s\n", strings.Join(hottest, ", "))
+}
+
+func heatmapCellString(cell heatmapCell, colors bool) string {
+	if cell.noData {
+		return string([]rune{heatmapNoData, heatmapNoData})
+	}
+	return heatmapGlyph(heatmapLevelOf(cell.percent), colors)
+}
+
+func heatmapGlyph(level heatmapLevel, colors bool) string {
+	glyph := string([]rune{level.glyph, level.glyph})
+	if !colors {
+		return glyph
+	}
+	return level.color + glyph + ansiReset
+}
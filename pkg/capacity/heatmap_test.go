@@ -0,0 +1,82 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseHeatmapMetric(t *testing.T) {
+	hm, err := parseHeatmapMetric("mem.util")
+	require.NoError(t, err)
+	assert.Equal(t, &heatmapMetric{resource: "memory", metric: "util"}, hm)
+
+	for _, metric := range []string{"cpu", "disk.requests", "cpu.available", ""} {
+		assert.Error(t, ValidateHeatmap(metric), metric)
+	}
+
+	assert.True(t, HeatmapNeedsUtil("cpu.util"))
+	assert.False(t, HeatmapNeedsUtil("cpu.requests"))
+	assert.False(t, HeatmapNeedsUtil("invalid"))
+}
+
+func TestHeatmapCells(t *testing.T) {
+	snap := getTestSnapshot()
+	cm := buildClusterMetric(snap.Pods, snap.PodMetrics, snap.Nodes, snap.NodeMetrics)
+
+	cells := heatmapCells(&cm, &heatmapMetric{resource: "cpu", metric: "util"}, Options{SortBy: "name"})
+	require.Len(t, cells, 2)
+	assert.Equal(t, "mynode", cells[0].name)
+	assert.InDelta(t, 12, cells[0].percent, 0.01)
+	assert.InDelta(t, 18, cells[1].percent, 0.01)
+	assert.False(t, cells[1].noData)
+
+	// Pods without limits leave nothing to take utilization relative to
+	cells = heatmapCells(&cm, &heatmapMetric{resource: "cpu", metric: "util"}, Options{SortBy: "name", UtilPercent: "limit"})
+	assert.True(t, cells[0].noData)
+	assert.True(t, cells[1].noData)
+}
+
+func TestWriteHeatmap(t *testing.T) {
+	cells := []heatmapCell{}
+	for i := 0; i < heatmapColumns+2; i++ {
+		cells = append(cells, heatmapCell{name: fmt.Sprintf("node-%02d", i), percent: float64(i * 3)})
+	}
+	cells[1].noData = true
+
+	var buf bytes.Buffer
+	writeHeatmap(&buf, "CPU REQUESTS % OF ALLOCATABLE", cells, "name", false)
+	lines := strings.Split(buf.String(), "\n")
+
+	assert.Equal(t, "CPU REQUESTS % OF ALLOCATABLE, 42 NODES BY NAME", lines[0])
+	assert.True(t, strings.HasPrefix(lines[1], " 1 ░░··░░"), lines[1])
+	assert.Equal(t, heatmapColumns*2, len([]rune(strings.TrimPrefix(lines[1], " 1 "))))
+	assert.Equal(t, "41 !!!!", lines[2])
+	assert.Equal(t, "░░ <25%  ▒▒ <50%  ▓▓ <75%  ██ <100%  !! >=100%  ·· no data", lines[4])
+	assert.Equal(t, "min 0%, median 63%, max 123%", lines[5])
+	assert.Equal(t, "highest: node-41 123%, node-40 120%, node-39 117%, node-38 114%, node-37 111%", lines[6])
+}
+
+func TestWriteHeatmapColors(t *testing.T) {
+	var buf bytes.Buffer
+	writeHeatmap(&buf, "MEMORY UTIL % OF ALLOCATABLE", []heatmapCell{{name: "a", percent: 80}}, "name", true)
+	assert.Contains(t, buf.String(), " 1 \x1b[31m██"+ansiReset+"\n")
+}
@@ -0,0 +1,532 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// The kinds of rows --history-file records for each run
+const (
+	ClusterHistoryKind   = "cluster"
+	NodeHistoryKind      = "node"
+	NamespaceHistoryKind = "namespace"
+)
+
+// SupportedHistoryKinds returns the kinds of rows the history subcommand
+// can filter on
+func SupportedHistoryKinds() []string {
+	return []string{ClusterHistoryKind, NodeHistoryKind, NamespaceHistoryKind}
+}
+
+// historyRow is the figures of the cluster, a node, or a namespace in one run
+type historyRow struct {
+	observedAt time.Time
+	cluster    string
+	kind       string
+	name       string
+	cpu        historyResource
+	memory     historyResource
+	pods       int64
+}
+
+// historyResource is in millicores for CPU and bytes for memory. util is
+// nil for runs without utilization.
+type historyResource struct {
+	allocatable int64
+	requests    int64
+	limits      int64
+	util        *int64
+}
+
+// historyRecord is a historyRow as one line of --history-file. CPU
+// is in millicores and memory in bytes, and utilization is left out for runs
+// without --util.
+type historyRecord struct {
+	ObservedAt        time.Time `json:"observedAt"`
+	Cluster           string    `json:"cluster"`
+	Kind              string    `json:"kind"`
+	Name              string    `json:"name"`
+	CPUAllocatable    int64     `json:"cpuAllocatable"`
+	CPURequests       int64     `json:"cpuRequests"`
+	CPULimits         int64     `json:"cpuLimits"`
+	CPUUtil           *int64    `json:"cpuUtil,omitempty"`
+	MemoryAllocatable int64     `json:"memoryAllocatable"`
+	MemoryRequests    int64     `json:"memoryRequests"`
+	MemoryLimits      int64     `json:"memoryLimits"`
+	MemoryUtil        *int64    `json:"memoryUtil,omitempty"`
+	Pods              int64     `json:"pods"`
+}
+
+func newHistoryRecord(r historyRow) historyRecord {
+	return historyRecord{
+		ObservedAt:        r.observedAt.UTC().Truncate(time.Second),
+		Cluster:           r.cluster,
+		Kind:              r.kind,
+		Name:              r.name,
+		CPUAllocatable:    r.cpu.allocatable,
+		CPURequests:       r.cpu.requests,
+		CPULimits:         r.cpu.limits,
+		CPUUtil:           r.cpu.util,
+		MemoryAllocatable: r.memory.allocatable,
+		MemoryRequests:    r.memory.requests,
+		MemoryLimits:      r.memory.limits,
+		MemoryUtil:        r.memory.util,
+		Pods:              r.pods,
+	}
+}
+
+func (hr historyRecord) row() historyRow {
+	return historyRow{
+		observedAt: hr.ObservedAt.UTC(),
+		cluster:    hr.Cluster,
+		kind:       hr.Kind,
+		name:       hr.Name,
+		cpu:        historyResource{allocatable: hr.CPUAllocatable, requests: hr.CPURequests, limits: hr.CPULimits, util: hr.CPUUtil},
+		memory:     historyResource{allocatable: hr.MemoryAllocatable, requests: hr.MemoryRequests, limits: hr.MemoryLimits, util: hr.MemoryUtil},
+		pods:       hr.Pods,
+	}
+}
+
+// recordHistory appends the figures of the cluster, its nodes, and its
+// namespaces to --history-file, one JSON object per line. The rows
+// of a run are appended in a single write, so runs that overlap, such as
+// from cron, do not interleave their lines. Runs older than
+// --history-retention are dropped afterwards.
+func recordHistory(run *reportRun, path string, clusters []*clusterMetric, opts Options) error {
+	rows := []historyRow{}
+	for _, cm := range clusters {
+		name := cm.name
+		if name == "" {
+			var err error
+			name, err = clusterName(opts)
+			if err != nil {
+				return fmt.Errorf("reading Kubernetes config: %w", err)
+			}
+		}
+		rows = append(rows, buildHistoryRows(cm, name, opts.ShowUtil)...)
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, r := range rows {
+		if err := enc.Encode(newHistoryRecord(r)); err != nil {
+			return err
+		}
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		_ = f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	run.logDebugf("Recorded %d rows in %s", len(rows), path)
+
+	if opts.HistoryRetention > 0 {
+		return pruneHistory(run, path, time.Now().Add(-opts.HistoryRetention))
+	}
+	return nil
+}
+
+// pruneHistory rewrites the file without the rows recorded before cutoff.
+// Runs are appended in time order, so the file is only read in full and
+// rewritten when its first row is older than cutoff, which is at most once
+// per run as old runs fall out of the retention window.
+func pruneHistory(run *reportRun, path string, cutoff time.Time) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var kept bytes.Buffer
+	pruned := 0
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var hr historyRecord
+		if err := json.Unmarshal(scanner.Bytes(), &hr); err != nil {
+			return fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		if !hr.ObservedAt.Before(cutoff) {
+			if pruned == 0 {
+				return nil
+			}
+			kept.Write(scanner.Bytes())
+			kept.WriteByte('\n')
+			continue
+		}
+		pruned++
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if pruned == 0 {
+		return nil
+	}
+
+	// Write to a file in the same directory and rename it over the history,
+	// so a run that is interrupted never leaves it half written
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	if err := tmp.Chmod(info.Mode().Perm()); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if _, err := tmp.Write(kept.Bytes()); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	run.logDebugf("Dropped %d rows recorded before %s from %s", pruned, cutoff.Format(time.RFC3339), path)
+	return nil
+}
+
+// buildHistoryRows returns a row for the cluster, each node, and each
+// namespace, with utilization only when it was fetched
+func buildHistoryRows(cm *clusterMetric, cluster string, withUtil bool) []historyRow {
+	observedAt := cm.observedAt
+	if observedAt.IsZero() {
+		observedAt = time.Now()
+	}
+	row := func(kind, name string, cpu, memory *resourceMetric, pods int64) historyRow {
+		return historyRow{
+			observedAt: observedAt,
+			cluster:    cluster,
+			kind:       kind,
+			name:       name,
+			cpu:        historyResourceOf(cpu, withUtil),
+			memory:     historyResourceOf(memory, withUtil),
+			pods:       pods,
+		}
+	}
+
+	rows := []historyRow{row(ClusterHistoryKind, cluster, cm.cpu, cm.memory, cm.podCount.current)}
+	for _, nm := range cm.getSortedNodeMetrics("name") {
+		rows = append(rows, row(NodeHistoryKind, nm.name, nm.cpu, nm.memory, int64(len(nm.podMetrics))))
+	}
+	namespaces := cm.getNamespaceMetrics()
+	names := make([]string, 0, len(namespaces))
+	for name := range namespaces {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		nsm := namespaces[name]
+		rows = append(rows, row(NamespaceHistoryKind, name, nsm.cpu, nsm.memory, nsm.podCount))
+	}
+	return rows
+}
+
+func historyResourceOf(rm *resourceMetric, withUtil bool) historyResource {
+	hr := historyResource{
+		allocatable: historyValue(rm.resourceType, rm.allocatable),
+		requests:    historyValue(rm.resourceType, rm.request),
+		limits:      historyValue(rm.resourceType, rm.limit),
+	}
+	if withUtil {
+		util := historyValue(rm.resourceType, rm.utilization)
+		hr.util = &util
+	}
+	return hr
+}
+
+func historyValue(resourceType string, q resource.Quantity) int64 {
+	if resourceType == "cpu" {
+		return q.MilliValue()
+	}
+	return q.Value()
+}
+
+func historyQuantityOf(resourceType string, v int64) resource.Quantity {
+	if resourceType == "cpu" {
+		return *resource.NewMilliQuantity(v, resource.DecimalSI)
+	}
+	return *resource.NewQuantity(v, resource.BinarySI)
+}
+
+// readHistory returns the rows recorded since the given time, filtered by
+// kind and name when they are set, ordered by series and then time
+func readHistory(path string, since time.Time, kind, name string) ([]historyRow, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	rows := []historyRow{}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var hr historyRecord
+		if err := json.Unmarshal(scanner.Bytes(), &hr); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, line, err)
+		}
+		r := hr.row()
+		if r.observedAt.Before(since) || (kind != "" && r.kind != kind) || (name != "" && r.name != name) {
+			continue
+		}
+		rows = append(rows, r)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		a, b := rows[i], rows[j]
+		if a.cluster != b.cluster {
+			return a.cluster < b.cluster
+		}
+		if a.kind != b.kind {
+			return a.kind < b.kind
+		}
+		if a.name != b.name {
+			return a.name < b.name
+		}
+		return a.observedAt.Before(b.observedAt)
+	})
+	return rows, nil
+}
+
+// historySeries is the rows of one cluster, node, or namespace in time order
+type historySeries struct {
+	cluster string
+	kind    string
+	name    string
+	rows    []historyRow
+}
+
+// groupHistory splits rows ordered by series into one series each
+func groupHistory(rows []historyRow) []*historySeries {
+	series := []*historySeries{}
+	var current *historySeries
+	for _, r := range rows {
+		if current == nil || current.cluster != r.cluster || current.kind != r.kind || current.name != r.name {
+			current = &historySeries{cluster: r.cluster, kind: r.kind, name: r.name}
+			series = append(series, current)
+		}
+		current.rows = append(current.rows, r)
+	}
+	return series
+}
+
+// PrintHistory prints the trends recorded in --history-file
+func PrintHistory(opts Options) error {
+	run := newRun(opts)
+
+	since := time.Time{}
+	if opts.HistorySince > 0 {
+		since = time.Now().Add(-opts.HistorySince)
+	}
+	rows, err := readHistory(opts.HistoryFile, since, opts.HistoryKind, opts.HistoryName)
+	if err != nil {
+		return fmt.Errorf("Error reading history: %v", err)
+	}
+
+	hp := &historyPrinter{run: run, series: groupHistory(rows), rows: rows}
+	if err := hp.Print(opts.OutputFormat); err != nil {
+		return err
+	}
+	return run.closeOutput()
+}
+
+type historyPrinter struct {
+	run    *reportRun
+	series []*historySeries
+	rows   []historyRow
+}
+
+type listHistory struct {
+	Series []*listHistorySeries `json:"series"`
+}
+
+type listHistorySeries struct {
+	Cluster string              `json:"cluster"`
+	Kind    string              `json:"kind"`
+	Name    string              `json:"name"`
+	Points  []*listHistoryPoint `json:"points"`
+}
+
+type listHistoryPoint struct {
+	ObservedAt string               `json:"observedAt"`
+	CPU        *listHistoryResource `json:"cpu"`
+	Memory     *listHistoryResource `json:"memory"`
+	Pods       int64                `json:"pods"`
+}
+
+type listHistoryResource struct {
+	Allocatable string `json:"allocatable"`
+	Requests    string `json:"requests"`
+	Limits      string `json:"limits"`
+	Utilization string `json:"utilization,omitempty"`
+}
+
+func (hp *historyPrinter) Print(outputType string) error {
+	switch outputType {
+	case JSONOutput, YAMLOutput:
+		printListOutput(hp.run, HistoryReportKind, hp.buildListHistory(), outputType)
+	case TableOutput:
+		w := tabwriter.NewWriter(hp.run.output, 0, 8, 2, ' ', 0)
+		hp.printTable(w)
+		if err := w.Flush(); err != nil {
+			hp.run.logErrorf("Error writing to table: %s", err)
+		}
+	case CSVOutput:
+		hp.printRows(hp.run.output, ",")
+	case TSVOutput:
+		hp.printRows(hp.run.output, "\t")
+	default:
+		return fmt.Errorf("Called with an unsupported output type: %s", outputType)
+	}
+	return nil
+}
+
+// printTable prints a line for each series with its latest figures and a
+// sparkline of the last runs
+func (hp *historyPrinter) printTable(w io.Writer) {
+	headers := []string{"CLUSTER", "KIND", "NAME", "RUNS", "SINCE", "CPU REQUESTS", "CPU UTIL", "MEMORY REQUESTS", "MEMORY UTIL"}
+	_, _ = fmt.Fprintln(w, strings.Join(headers, "\t "))
+
+	for _, s := range hp.series {
+		items := []string{s.cluster, s.kind, s.name, fmt.Sprintf("%d", len(s.rows)), s.rows[0].observedAt.Format(time.RFC3339)}
+		for _, resourceType := range []string{"cpu", "memory"} {
+			items = append(items,
+				historyTrendString(hp.run, s.rows, resourceType, func(hr historyResource) *int64 { return &hr.requests }),
+				historyTrendString(hp.run, s.rows, resourceType, func(hr historyResource) *int64 { return hr.util }))
+		}
+		_, _ = fmt.Fprintln(w, strings.Join(items, "\t "))
+	}
+}
+
+// historyTrendString returns the latest value followed by a sparkline of up
+// to the last sparklinePoints runs, example: "560m ▁▂▃▅▇"
+func historyTrendString(run *reportRun, rows []historyRow, resourceType string, value func(historyResource) *int64) string {
+	if len(rows) > sparklinePoints {
+		rows = rows[len(rows)-sparklinePoints:]
+	}
+	points := make([]float64, len(rows))
+	var latest *int64
+	for i, r := range rows {
+		hr := r.cpu
+		if resourceType == "memory" {
+			hr = r.memory
+		}
+		points[i] = math.NaN()
+		if v := value(hr); v != nil {
+			points[i] = float64(*v)
+			latest = v
+		}
+	}
+	if latest == nil {
+		return VoidValue
+	}
+	rm := &resourceMetric{resourceType: resourceType, history: points}
+	return run.formatQuantity(resourceType, historyQuantityOf(resourceType, *latest)) + " " + rm.sparklineString()
+}
+
+// printRows prints every recorded row, for plotting in other tools
+func (hp *historyPrinter) printRows(w io.Writer, separator string) {
+	headers := []string{"OBSERVED AT", "CLUSTER", "KIND", "NAME"}
+	for _, prefix := range []string{"CPU", "MEMORY"} {
+		headers = append(headers, prefix+" ALLOCATABLE", prefix+" REQUESTS", prefix+" LIMITS", prefix+" UTIL")
+	}
+	headers = append(headers, "PODS")
+	_, _ = fmt.Fprintln(w, strings.Join(headers, separator))
+
+	for _, r := range hp.rows {
+		items := []string{r.observedAt.Format(time.RFC3339), r.cluster, r.kind, r.name}
+		for _, hr := range []*listHistoryResource{listHistoryResourceOf(hp.run, "cpu", r.cpu), listHistoryResourceOf(hp.run, "memory", r.memory)} {
+			util := hr.Utilization
+			if util == "" {
+				util = VoidValue
+			}
+			items = append(items, hr.Allocatable, hr.Requests, hr.Limits, util)
+		}
+		items = append(items, fmt.Sprintf("%d", r.pods))
+		_, _ = fmt.Fprintln(w, strings.Join(items, separator))
+	}
+}
+
+func (hp *historyPrinter) buildListHistory() *listHistory {
+	out := &listHistory{Series: []*listHistorySeries{}}
+	for _, s := range hp.series {
+		ls := &listHistorySeries{Cluster: s.cluster, Kind: s.kind, Name: s.name}
+		for _, r := range s.rows {
+			ls.Points = append(ls.Points, &listHistoryPoint{
+				ObservedAt: r.observedAt.Format(time.RFC3339),
+				CPU:        listHistoryResourceOf(hp.run, "cpu", r.cpu),
+				Memory:     listHistoryResourceOf(hp.run, "memory", r.memory),
+				Pods:       r.pods,
+			})
+		}
+		out.Series = append(out.Series, ls)
+	}
+	return out
+}
+
+func listHistoryResourceOf(run *reportRun, resourceType string, hr historyResource) *listHistoryResource {
+	format := func(v int64) string {
+		return run.formatQuantity(resourceType, historyQuantityOf(resourceType, v))
+	}
+	out := &listHistoryResource{
+		Allocatable: format(hr.allocatable),
+		Requests:    format(hr.requests),
+		Limits:      format(hr.limits),
+	}
+	if hr.util != nil {
+		out.Utilization = format(*hr.util)
+	}
+	return out
+}
@@ -0,0 +1,126 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestRecordAndReadHistory(t *testing.T) {
+	run := newTestRun(nil)
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+
+	web := pod("mynode", "default", "web", nil)
+	web.Spec.Containers = []corev1.Container{{
+		Name: "app",
+		Resources: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{"cpu": resource.MustParse("250m"), "memory": resource.MustParse("256Mi")},
+		},
+	}}
+	nodeList := &corev1.NodeList{Items: []corev1.Node{*node("mynode", nil, false)}}
+
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		cm := buildClusterMetric(&corev1.PodList{Items: []corev1.Pod{*web}}, nil, nodeList, nil)
+		cm.name = "prod"
+		cm.observedAt = start.Add(time.Duration(i) * time.Hour)
+		require.NoError(t, recordHistory(run, path, []*clusterMetric{&cm}, Options{}))
+		web.Spec.Containers[0].Resources.Requests["cpu"] = resource.MustParse("500m")
+	}
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, 9, strings.Count(string(data), "\n"), "a line for the cluster, the node, and the namespace of each run")
+	assert.NotContains(t, string(data), "cpuUtil", "utilization is left out without --util")
+
+	rows, err := readHistory(path, start.Add(30*time.Minute), NamespaceHistoryKind, "")
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	assert.Equal(t, "default", rows[0].name)
+	assert.Equal(t, start.Add(time.Hour), rows[0].observedAt)
+	assert.Equal(t, int64(500), rows[0].cpu.requests)
+	assert.Equal(t, int64(256*1024*1024), rows[0].memory.requests)
+	assert.Nil(t, rows[0].cpu.util)
+
+	rows, err = readHistory(path, time.Time{}, "", "")
+	require.NoError(t, err)
+	series := groupHistory(rows)
+	require.Len(t, series, 3)
+	assert.Equal(t, []string{ClusterHistoryKind, NamespaceHistoryKind, NodeHistoryKind},
+		[]string{series[0].kind, series[1].kind, series[2].kind})
+	assert.Len(t, series[0].rows, 3)
+}
+
+func TestHistoryTrendString(t *testing.T) {
+	run := newTestRun(nil)
+	util := int64(300)
+	rows := []historyRow{
+		{cpu: historyResource{requests: 250}},
+		{cpu: historyResource{requests: 500, util: &util}},
+		{cpu: historyResource{requests: 750}},
+	}
+
+	requests := historyTrendString(run, rows, "cpu", func(hr historyResource) *int64 { return &hr.requests })
+	assert.Equal(t, "750m ▁▅█", requests)
+	utilization := historyTrendString(run, rows, "cpu", func(hr historyResource) *int64 { return hr.util })
+	assert.Equal(t, "300m  ▁ ", utilization)
+	assert.Equal(t, VoidValue, historyTrendString(run, rows, "memory", func(hr historyResource) *int64 { return hr.util }))
+}
+
+func TestPruneHistory(t *testing.T) {
+	run := newTestRun(nil)
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	nodeList := &corev1.NodeList{Items: []corev1.Node{*node("mynode", nil, false)}}
+
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		cm := buildClusterMetric(&corev1.PodList{}, nil, nodeList, nil)
+		cm.name = "prod"
+		cm.observedAt = start.Add(time.Duration(i) * 24 * time.Hour)
+		require.NoError(t, recordHistory(run, path, []*clusterMetric{&cm}, Options{}))
+	}
+
+	// Nothing is older than the cutoff, so the file is left as it is
+	before, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NoError(t, pruneHistory(run, path, start))
+	after, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, before, after)
+
+	require.NoError(t, pruneHistory(run, path, start.Add(36*time.Hour)))
+	rows, err := readHistory(path, time.Time{}, ClusterHistoryKind, "")
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.Equal(t, start.Add(48*time.Hour), rows[0].observedAt)
+
+	// Runs are dropped as they are recorded once they are past the retention
+	cm := buildClusterMetric(&corev1.PodList{}, nil, nodeList, nil)
+	cm.name = "prod"
+	require.NoError(t, recordHistory(run, path, []*clusterMetric{&cm}, Options{HistoryRetention: time.Hour}))
+	rows, err = readHistory(path, time.Time{}, ClusterHistoryKind, "")
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	assert.True(t, rows[0].observedAt.After(start.Add(48*time.Hour)))
+}
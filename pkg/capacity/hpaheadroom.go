@@ -0,0 +1,267 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// hpaExceedsCapacity marks HPAs whose maxReplicas would not all fit on
+	// the capacity left on the nodes
+	hpaExceedsCapacity = "exceeds capacity"
+	// hpaNoPods marks HPAs whose target runs no pods to take requests from
+	hpaNoPods = "no pods"
+)
+
+// hpaHeadroom is an HPA and the replicas of its target that would fit if it
+// scaled to maxReplicas
+type hpaHeadroom struct {
+	hpa *autoscalingv2.HorizontalPodAutoscaler
+	// pm is the largest pod of the target, nil when it runs none
+	pm  *podMetric
+	fit int64
+}
+
+type listHPAHeadroom struct {
+	ClusterTotals            *listHPA   `json:"clusterTotals"`
+	HorizontalPodAutoscalers []*listHPA `json:"horizontalPodAutoscalers"`
+}
+
+type listHPA struct {
+	Namespace   string `json:"namespace,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Target      string `json:"target,omitempty"`
+	Replicas    int64  `json:"replicas"`
+	MaxReplicas int64  `json:"maxReplicas"`
+	Additional  int64  `json:"additional"`
+	Fit         int64  `json:"fit"`
+	CPU         string `json:"cpu"`
+	Memory      string `json:"memory"`
+	Status      string `json:"status,omitempty"`
+}
+
+func getHorizontalPodAutoscalers(run *reportRun, clientset kubernetes.Interface) (*autoscalingv2.HorizontalPodAutoscalerList, *fetchError) {
+	start := time.Now()
+	hpaList, err := clientset.AutoscalingV2().HorizontalPodAutoscalers("").List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, newFetchError(3, "Error listing HorizontalPodAutoscalers: %v", err)
+	}
+	run.logTiming(start, "Listed %d HorizontalPodAutoscalers", len(hpaList.Items))
+	return hpaList, nil
+}
+
+// additional returns the replicas the HPA could still add to its target
+func (h *hpaHeadroom) additional() int64 {
+	if n := int64(h.hpa.Spec.MaxReplicas - h.hpa.Status.CurrentReplicas); n > 0 {
+		return n
+	}
+	return 0
+}
+
+// workload returns the replicas the HPA could still add, with the requests
+// and scheduling constraints of the largest pod of its target
+func (h *hpaHeadroom) workload() *fitWorkload {
+	return &fitWorkload{
+		name:                  podKey(h.hpa.Namespace, h.hpa.Name),
+		cpu:                   h.pm.cpu.request,
+		memory:                h.pm.memory.request,
+		replicas:              h.additional(),
+		schedulingConstraints: h.pm.scheduling,
+	}
+}
+
+// buildHPAHeadroom places the replicas each HPA could add on the capacity
+// left on the nodes, on its own as if no other HPA scaled. It returns the
+// HPAs in namespace and name order and the replicas that fit when every HPA
+// scales to maxReplicas at once.
+func buildHPAHeadroom(cm *clusterMetric) ([]*hpaHeadroom, map[string]int64) {
+	headroom := []*hpaHeadroom{}
+	if cm.horizontalPodAutoscalers == nil {
+		return headroom, map[string]int64{}
+	}
+
+	for i := range cm.horizontalPodAutoscalers.Items {
+		headroom = append(headroom, &hpaHeadroom{hpa: &cm.horizontalPodAutoscalers.Items[i]})
+	}
+	sort.Slice(headroom, func(i, j int) bool {
+		return podKey(headroom[i].hpa.Namespace, headroom[i].hpa.Name) < podKey(headroom[j].hpa.Namespace, headroom[j].hpa.Name)
+	})
+
+	workloads := []*fitWorkload{}
+	for _, h := range headroom {
+		ref := h.hpa.Spec.ScaleTargetRef
+		for _, nm := range cm.nodeMetrics {
+			for _, pm := range nm.podMetrics {
+				if pm.namespace != h.hpa.Namespace || pm.controllerKind != ref.Kind || pm.controllerName != ref.Name {
+					continue
+				}
+				if h.pm == nil || largerPod(pm, h.pm) {
+					h.pm = pm
+				}
+			}
+		}
+		if h.pm == nil {
+			continue
+		}
+
+		w := h.workload()
+		_, fit := placeWorkloads(cm, []*fitWorkload{w})
+		h.fit = fit[w.name]
+		workloads = append(workloads, w)
+	}
+
+	_, fit := placeWorkloads(cm, workloads)
+	return headroom, fit
+}
+
+// largerPod orders pods by CPU and then memory requests, and then by name
+// so that the pod chosen does not depend on map order
+func largerPod(a, b *podMetric) bool {
+	if c := a.cpu.request.Cmp(b.cpu.request); c != 0 {
+		return c > 0
+	}
+	if c := a.memory.request.Cmp(b.memory.request); c != 0 {
+		return c > 0
+	}
+	return a.name < b.name
+}
+
+func printHPAHeadroom(run *reportRun, cm *clusterMetric, opts Options) error {
+	hp := &hpaHeadroomPrinter{run: run, opts: opts}
+	hp.headroom, hp.fit = buildHPAHeadroom(cm)
+	return hp.Print(opts.OutputFormat)
+}
+
+type hpaHeadroomPrinter struct {
+	run      *reportRun
+	headroom []*hpaHeadroom
+	// fit is the replicas of each HPA that fit when all of them scale to
+	// maxReplicas at once
+	fit  map[string]int64
+	opts Options
+}
+
+func (hp *hpaHeadroomPrinter) Print(outputType string) error {
+	switch outputType {
+	case JSONOutput, YAMLOutput:
+		printListOutput(hp.run, HPAHeadroomReportKind, hp.buildListHPAHeadroom(), outputType)
+	case TableOutput:
+		w := tabwriter.NewWriter(hp.run.output, 0, 8, 2, ' ', 0)
+		hp.printTable(w, "\t ")
+		if err := w.Flush(); err != nil {
+			hp.run.logErrorf("Error writing to table: %s", err)
+		}
+	case CSVOutput:
+		hp.printTable(hp.run.output, ",")
+	case TSVOutput:
+		hp.printTable(hp.run.output, "\t")
+	default:
+		return fmt.Errorf("Called with an unsupported output type: %s", outputType)
+	}
+	return nil
+}
+
+// printTable prints every HPA scaling to maxReplicas at once, followed by
+// each HPA scaling on its own
+func (hp *hpaHeadroomPrinter) printTable(w io.Writer, separator string) {
+	_, _ = fmt.Fprintln(w, strings.Join([]string{
+		"NAMESPACE", "HPA", "TARGET", "REPLICAS", "MAX REPLICAS", "ADDITIONAL", "FIT", "CPU NEEDED", "MEMORY NEEDED", "STATUS",
+	}, separator))
+
+	list := hp.buildListHPAHeadroom()
+	list.ClusterTotals.Namespace = VoidValue
+	list.ClusterTotals.Name = VoidValue
+	list.ClusterTotals.Target = VoidValue
+	for _, lh := range append([]*listHPA{list.ClusterTotals}, list.HorizontalPodAutoscalers...) {
+		_, _ = fmt.Fprintln(w, strings.Join([]string{
+			lh.Namespace,
+			lh.Name,
+			lh.Target,
+			fmt.Sprintf("%d", lh.Replicas),
+			fmt.Sprintf("%d", lh.MaxReplicas),
+			fmt.Sprintf("%d", lh.Additional),
+			fmt.Sprintf("%d", lh.Fit),
+			lh.CPU,
+			lh.Memory,
+			lh.Status,
+		}, separator))
+	}
+}
+
+// buildListHPAHeadroom lists the requests the replicas each HPA could add
+// would need. The cluster totals are every HPA scaling at once, which can
+// exceed capacity even when each HPA fits on its own.
+func (hp *hpaHeadroomPrinter) buildListHPAHeadroom() *listHPAHeadroom {
+	cpu := &resourceMetric{resourceType: "cpu"}
+	memory := &resourceMetric{resourceType: "memory"}
+
+	out := &listHPAHeadroom{HorizontalPodAutoscalers: []*listHPA{}}
+	totals := &listHPA{}
+	for _, h := range hp.headroom {
+		ref := h.hpa.Spec.ScaleTargetRef
+		lh := &listHPA{
+			Namespace:   h.hpa.Namespace,
+			Name:        h.hpa.Name,
+			Target:      ref.Kind + "/" + ref.Name,
+			Replicas:    int64(h.hpa.Status.CurrentReplicas),
+			MaxReplicas: int64(h.hpa.Spec.MaxReplicas),
+			Additional:  h.additional(),
+			Fit:         h.fit,
+			CPU:         VoidValue,
+			Memory:      VoidValue,
+		}
+		totals.Replicas += lh.Replicas
+		totals.MaxReplicas += lh.MaxReplicas
+		out.HorizontalPodAutoscalers = append(out.HorizontalPodAutoscalers, lh)
+
+		if h.pm == nil {
+			lh.Status = hpaNoPods
+			continue
+		}
+		needed := []*resourceMetric{
+			{resourceType: "cpu", request: multiplyQuantity("cpu", h.pm.cpu.request, lh.Additional)},
+			{resourceType: "memory", request: multiplyQuantity("memory", h.pm.memory.request, lh.Additional)},
+		}
+		lh.CPU = needed[0].valueFunction(hp.run)(needed[0].request)
+		lh.Memory = needed[1].valueFunction(hp.run)(needed[1].request)
+		if lh.Fit < lh.Additional {
+			lh.Status = hpaExceedsCapacity
+		}
+
+		cpu.request.Add(needed[0].request)
+		memory.request.Add(needed[1].request)
+		totals.Additional += lh.Additional
+		totals.Fit += hp.fit[podKey(h.hpa.Namespace, h.hpa.Name)]
+	}
+
+	totals.CPU = cpu.valueFunction(hp.run)(cpu.request)
+	totals.Memory = memory.valueFunction(hp.run)(memory.request)
+	if totals.Fit < totals.Additional {
+		totals.Status = hpaExceedsCapacity
+	}
+	out.ClusterTotals = totals
+	return out
+}
@@ -0,0 +1,121 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func hpaTestHPA(name, kind, target string, current, max int32) autoscalingv2.HorizontalPodAutoscaler {
+	return autoscalingv2.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+		Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{Kind: kind, Name: target},
+			MaxReplicas:    max,
+		},
+		Status: autoscalingv2.HorizontalPodAutoscalerStatus{CurrentReplicas: current},
+	}
+}
+
+func hpaTestClusterMetric() *clusterMetric {
+	allocatable := func(cpu string) corev1.ResourceList {
+		return corev1.ResourceList{
+			"cpu":    resource.MustParse(cpu),
+			"memory": resource.MustParse("4000Mi"),
+			"pods":   resource.MustParse("110"),
+		}
+	}
+	owned := func(p *corev1.Pod, kind, name string) corev1.Pod {
+		p.OwnerReferences = controllerRef(kind, name)
+		return *p
+	}
+
+	nodes := &corev1.NodeList{Items: []corev1.Node{
+		*nodeWithAllocatable("a", nil, allocatable("4000m")),
+		*nodeWithAllocatable("b", nil, allocatable("1000m")),
+	}}
+	hash := map[string]string{"pod-template-hash": "7d4b9c"}
+	pods := &corev1.PodList{Items: []corev1.Pod{
+		owned(podWithRequests("a", "default", "web-1", hash, corev1.ResourceList{"cpu": resource.MustParse("600m")}), "ReplicaSet", "web-7d4b9c"),
+		owned(podWithRequests("a", "default", "web-2", hash, corev1.ResourceList{"cpu": resource.MustParse("500m")}), "ReplicaSet", "web-7d4b9c"),
+		owned(podWithRequests("b", "default", "worker-0", nil, corev1.ResourceList{"cpu": resource.MustParse("300m")}), "StatefulSet", "worker"),
+	}}
+
+	cm := buildClusterMetric(pods, nil, nodes, nil)
+	cm.horizontalPodAutoscalers = &autoscalingv2.HorizontalPodAutoscalerList{Items: []autoscalingv2.HorizontalPodAutoscaler{
+		hpaTestHPA("worker", "StatefulSet", "worker", 1, 4),
+		hpaTestHPA("web", "Deployment", "web", 2, 8),
+		hpaTestHPA("gone", "Deployment", "gone", 0, 3),
+	}}
+	return &cm
+}
+
+func TestBuildHPAHeadroom(t *testing.T) {
+	cm := hpaTestClusterMetric()
+
+	headroom, fit := buildHPAHeadroom(cm)
+	require.Len(t, headroom, 3)
+	assert.Equal(t, "gone", headroom[0].hpa.Name)
+	assert.Nil(t, headroom[0].pm)
+
+	// web takes the 600m of its largest pod, four of which fit in the 2900m
+	// left on a and one in the 700m left on b
+	assert.Equal(t, "web-1", headroom[1].pm.name)
+	assert.Equal(t, int64(5), headroom[1].fit)
+	assert.Equal(t, int64(3), headroom[2].fit)
+
+	// Scaling together, web leaves room for only one worker
+	assert.Equal(t, map[string]int64{"default/web": 5, "default/worker": 1}, fit)
+}
+
+func TestHPAHeadroomReport(t *testing.T) {
+	run := newTestRun(nil)
+	hp := &hpaHeadroomPrinter{run: run}
+	hp.headroom, hp.fit = buildHPAHeadroom(hpaTestClusterMetric())
+
+	var buf bytes.Buffer
+	hp.printTable(&buf, ",")
+	assert.Equal(t, strings.Join([]string{
+		"NAMESPACE,HPA,TARGET,REPLICAS,MAX REPLICAS,ADDITIONAL,FIT,CPU NEEDED,MEMORY NEEDED,STATUS",
+		"*,*,*,3,15,9,6,4500m,0Mi,exceeds capacity",
+		"default,gone,Deployment/gone,0,3,3,0,*,*,no pods",
+		"default,web,Deployment/web,2,8,6,5,3600m,0Mi,exceeds capacity",
+		"default,worker,StatefulSet/worker,1,4,3,3,900m,0Mi,",
+	}, "\n")+"\n", buf.String())
+}
+
+func TestHPAHeadroomAtMaxReplicas(t *testing.T) {
+	run := newTestRun(nil)
+	cm := hpaTestClusterMetric()
+	cm.horizontalPodAutoscalers.Items = []autoscalingv2.HorizontalPodAutoscaler{
+		hpaTestHPA("web", "Deployment", "web", 8, 8),
+	}
+
+	hp := &hpaHeadroomPrinter{run: run}
+	hp.headroom, hp.fit = buildHPAHeadroom(cm)
+	list := hp.buildListHPAHeadroom()
+	assert.Equal(t, int64(0), list.HorizontalPodAutoscalers[0].Additional)
+	assert.Empty(t, list.HorizontalPodAutoscalers[0].Status)
+	assert.Empty(t, list.ClusterTotals.Status)
+}
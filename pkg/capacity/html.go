@@ -0,0 +1,139 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strings"
+	"time"
+)
+
+// htmlStyle keeps the report readable without loading anything from the
+// network, so that it can be opened offline or attached to a ticket
+const htmlStyle = `body { font-family: sans-serif; margin: 24px; color: #222222; }
+h1 { font-size: 20px; }
+p.meta { color: #666666; font-size: 13px; }
+table { border-collapse: collapse; font-size: 13px; margin-top: 16px; }
+th, td { border-bottom: 1px solid #dde3ea; padding: 4px 10px; text-align: left; white-space: nowrap; }
+th { background: #f2f4f7; }
+tr.total td { font-weight: bold; }
+svg { display: block; margin-top: 16px; }`
+
+// htmlPrinter prints the node report as a standalone HTML page, with the
+// table rows of the table printer and the charts of -o svg when --charts is
+// set
+type htmlPrinter struct {
+	run  *reportRun
+	opts Options
+}
+
+// htmlRows collects the lines written by a tablePrinter as cells. Every
+// line reaches Write in a single call, so it can be split on the separator
+// of the table printer.
+type htmlRows struct {
+	rows [][]string
+}
+
+func (hr *htmlRows) Write(p []byte) (int, error) {
+	line := strings.TrimSuffix(string(p), "\n")
+	cells := strings.Split(line, "\t ")
+	if strings.TrimSpace(strings.Join(cells, "")) != "" {
+		hr.rows = append(hr.rows, cells)
+	}
+	return len(p), nil
+}
+
+// Print prints the report of a single cluster
+func (hp *htmlPrinter) Print(cm *clusterMetric) {
+	rows := &htmlRows{}
+	tp := &tablePrinter{run: hp.run, cm: cm, w: rows, opts: hp.opts}
+	tp.printLine(tp.headers())
+	tp.printClusterRows()
+
+	var charts []barChart
+	if hp.opts.ShowCharts {
+		charts = buildCharts(hp.run, nodeChartSubjects(cm, hp.opts.SortBy), hp.opts.ShowUtil)
+	}
+	hp.write(hp.run.output, rows.rows, charts, cm.observedAt)
+}
+
+// PrintFleet prints the report of several clusters, charting the totals of
+// each cluster
+func (hp *htmlPrinter) PrintFleet(fm *fleetMetric) {
+	rows := &htmlRows{}
+	tp := &tablePrinter{run: hp.run, w: rows, opts: hp.opts, cluster: VoidValue}
+	tp.printLine(tp.headers())
+	tp.cm = fm.total
+	tp.printClusterLine()
+	for _, cm := range fm.clusters {
+		tp.cluster = cm.name
+		tp.cm = cm
+		tp.printClusterRows()
+	}
+
+	var charts []barChart
+	if hp.opts.ShowCharts {
+		charts = buildCharts(hp.run, fleetChartSubjects(fm), hp.opts.ShowUtil)
+	}
+	hp.write(hp.run.output, rows.rows, charts, fm.total.observedAt)
+}
+
+func (hp *htmlPrinter) write(w io.Writer, rows [][]string, charts []barChart, observedAt time.Time) {
+	if observedAt.IsZero() {
+		observedAt = time.Now()
+	}
+	_, _ = fmt.Fprintln(w, "<!DOCTYPE html>")
+	_, _ = fmt.Fprintln(w, `<html lang="en">`)
+	_, _ = fmt.Fprintln(w, `<head><meta charset="utf-8"><title>kube-capacity report</title>`)
+	_, _ = fmt.Fprintf(w, "<style>\n%s\n</style></head>\n<body>\n", htmlStyle)
+	_, _ = fmt.Fprintln(w, "<h1>kube-capacity report</h1>")
+	_, _ = fmt.Fprintf(w, "<p class=\"meta\">Generated at %s</p>\n", observedAt.UTC().Format(time.RFC3339))
+	if len(charts) > 0 {
+		writeSVG(w, charts)
+	}
+
+	_, _ = fmt.Fprintln(w, "<table>")
+	for i, row := range rows {
+		tag, class := "td", ""
+		switch {
+		case i == 0:
+			tag = "th"
+		case htmlTotalsRow(row):
+			class = ` class="total"`
+		}
+		_, _ = fmt.Fprintf(w, "<tr%s>", class)
+		for _, cell := range row {
+			_, _ = fmt.Fprintf(w, "<%s>%s</%s>", tag, html.EscapeString(cell), tag)
+		}
+		_, _ = fmt.Fprintln(w, "</tr>")
+	}
+	_, _ = fmt.Fprintln(w, "</table>")
+	_, _ = fmt.Fprintln(w, "</body>")
+	_, _ = fmt.Fprintln(w, "</html>")
+}
+
+// htmlTotalsRow reports whether a row is the totals of the cluster, the
+// fleet, the tainted nodes, or the virtual nodes, which have a * in place of
+// the node name
+func htmlTotalsRow(row []string) bool {
+	for _, cell := range row[:min(2, len(row))] {
+		if cell == VoidValue || cell == taintedTotalsName || cell == virtualTotalsName {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,395 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const (
+	// karpenterDoNotDisruptAnnotation keeps Karpenter from evicting a pod,
+	// and so from consolidating its node
+	karpenterDoNotDisruptAnnotation = "karpenter.sh/do-not-disrupt"
+
+	// consolidateEmpty marks nodes running nothing but DaemonSet pods
+	consolidateEmpty = "empty"
+	// consolidateOntoPeers marks nodes whose pods fit in the requests left
+	// on the other nodes of their NodePool
+	consolidateOntoPeers = "fits on peers"
+)
+
+// karpenterPoolMetric is a Karpenter NodePool with the nodes it launched.
+// The limits are nil when the NodePool has none or could not be found.
+type karpenterPoolMetric struct {
+	name                string
+	consolidationPolicy string
+	cpu                 *resourceMetric
+	memory              *resourceMetric
+	cpuLimit            *resource.Quantity
+	memoryLimit         *resource.Quantity
+	nodes               []*karpenterNodeMetric
+}
+
+type karpenterNodeMetric struct {
+	nm *nodeMetric
+
+	// consolidation is empty unless the node is a consolidation candidate
+	consolidation string
+}
+
+type listKarpenterPools struct {
+	NodePools []*listKarpenterPool `json:"nodePools"`
+}
+
+type listKarpenterPool struct {
+	Name                    string                 `json:"name"`
+	ConsolidationPolicy     string                 `json:"consolidationPolicy,omitempty"`
+	ConsolidationCandidates int64                  `json:"consolidationCandidates"`
+	CPU                     *listKarpenterResource `json:"cpu"`
+	Memory                  *listKarpenterResource `json:"memory"`
+	Nodes                   []*listKarpenterNode   `json:"nodes"`
+}
+
+type listKarpenterNode struct {
+	Name          string                 `json:"name"`
+	Consolidation string                 `json:"consolidation,omitempty"`
+	CPU           *listKarpenterResource `json:"cpu"`
+	Memory        *listKarpenterResource `json:"memory"`
+}
+
+type listKarpenterResource struct {
+	Requests       string `json:"requests"`
+	RequestsPct    string `json:"requestsPercent"`
+	Capacity       string `json:"capacity"`
+	Limit          string `json:"limit,omitempty"`
+	LimitPct       string `json:"limitPercent,omitempty"`
+	Utilization    string `json:"utilization,omitempty"`
+	UtilizationPct string `json:"utilizationPercent,omitempty"`
+}
+
+// buildKarpenterPoolMetrics groups the nodes launched by Karpenter by their
+// NodePool, in name order, and flags the nodes that could be consolidated.
+// NodePools without nodes are included, nodes Karpenter did not launch are
+// not.
+func buildKarpenterPoolMetrics(cm *clusterMetric, npList *nodePoolList, sortBy string) []*karpenterPoolMetric {
+	pools := map[string]*karpenterPoolMetric{}
+	getPool := func(name string) *karpenterPoolMetric {
+		if _, ok := pools[name]; !ok {
+			pools[name] = &karpenterPoolMetric{
+				name:   name,
+				cpu:    &resourceMetric{resourceType: "cpu"},
+				memory: &resourceMetric{resourceType: "memory"},
+				nodes:  []*karpenterNodeMetric{},
+			}
+		}
+		return pools[name]
+	}
+
+	if npList != nil {
+		for _, np := range npList.Items {
+			pool := getPool(np.Name)
+			pool.consolidationPolicy = np.Spec.Disruption.ConsolidationPolicy
+			pool.cpuLimit = nodePoolLimit(np.Spec.Limits, corev1.ResourceCPU)
+			pool.memoryLimit = nodePoolLimit(np.Spec.Limits, corev1.ResourceMemory)
+		}
+	}
+
+	for _, nm := range cm.getSortedNodeMetrics(sortBy) {
+		name, ok := nm.labels[karpenterNodePoolLabel]
+		if !ok || name == "" {
+			continue
+		}
+		pool := getPool(name)
+		pool.cpu.addMetric(nm.cpu)
+		pool.memory.addMetric(nm.memory)
+		pool.nodes = append(pool.nodes, &karpenterNodeMetric{nm: nm})
+	}
+
+	poolMetrics := []*karpenterPoolMetric{}
+	for _, pool := range pools {
+		pool.markConsolidationCandidates()
+		poolMetrics = append(poolMetrics, pool)
+	}
+	sort.Slice(poolMetrics, func(i, j int) bool {
+		return poolMetrics[i].name < poolMetrics[j].name
+	})
+	return poolMetrics
+}
+
+// markConsolidationCandidates flags nodes running nothing but DaemonSet
+// pods, and nodes whose other pods all fit, by requests, on the other nodes
+// of the pool. Nodes are tried from the least requested up, and pods are
+// placed first-fit-decreasing on schedulable peers by name. Space taken on a
+// peer is not offered again, and a peer taking pods is not removed itself,
+// so that the candidates can all be removed together. Node selectors,
+// affinity, and topology spread constraints are not considered, and nodes
+// with a pod annotated karpenter.sh/do-not-disrupt are never candidates.
+func (pool *karpenterPoolMetric) markConsolidationCandidates() {
+	left := map[string]*fitNode{}
+	for _, kn := range pool.nodes {
+		fn := &fitNode{
+			nm:     kn.nm,
+			cpu:    kn.nm.cpu.available(),
+			memory: kn.nm.memory.available(),
+			pods:   kn.nm.podCount.allocatable - kn.nm.podCount.current,
+		}
+		left[kn.nm.name] = fn
+	}
+
+	candidates := make([]*karpenterNodeMetric, len(pool.nodes))
+	copy(candidates, pool.nodes)
+	sort.SliceStable(candidates, func(i, j int) bool {
+		ci, cj := movableRequests(candidates[i].nm), movableRequests(candidates[j].nm)
+		if c := ci.Cmp(cj); c != 0 {
+			return c < 0
+		}
+		return candidates[i].nm.name < candidates[j].nm.name
+	})
+
+	removed := map[string]bool{}
+	receiving := map[string]bool{}
+	for _, kn := range candidates {
+		if receiving[kn.nm.name] {
+			continue
+		}
+		pods, ok := movablePods(kn.nm)
+		if !ok {
+			continue
+		}
+		if len(pods) == 0 {
+			kn.consolidation = consolidateEmpty
+			removed[kn.nm.name] = true
+			continue
+		}
+
+		peers := []*fitNode{}
+		for _, peer := range pool.nodes {
+			name := peer.nm.name
+			if name == kn.nm.name || removed[name] || peer.nm.unschedulable {
+				continue
+			}
+			fn := *left[name]
+			fn.cpu = fn.cpu.DeepCopy()
+			fn.memory = fn.memory.DeepCopy()
+			peers = append(peers, &fn)
+		}
+		sort.Slice(peers, func(i, j int) bool {
+			return peers[i].nm.name < peers[j].nm.name
+		})
+
+		targets := map[string]bool{}
+		fits := true
+		for _, pm := range pods {
+			fn := firstFitPeer(peers, pm)
+			if fn == nil {
+				fits = false
+				break
+			}
+			fn.cpu.Sub(pm.cpu.request)
+			fn.memory.Sub(pm.memory.request)
+			fn.pods--
+			targets[fn.nm.name] = true
+		}
+		if !fits {
+			continue
+		}
+
+		for _, fn := range peers {
+			left[fn.nm.name] = fn
+		}
+		for name := range targets {
+			receiving[name] = true
+		}
+		kn.consolidation = consolidateOntoPeers
+		removed[kn.nm.name] = true
+	}
+}
+
+// movablePods returns the pods Karpenter would move off a node, largest
+// first, and false when a pod may not be disrupted
+func movablePods(nm *nodeMetric) ([]*podMetric, bool) {
+	pods := []*podMetric{}
+	for _, pm := range nm.podMetrics {
+		if pm.daemonSet {
+			continue
+		}
+		if pm.doNotDisrupt {
+			return nil, false
+		}
+		pods = append(pods, pm)
+	}
+	sort.Slice(pods, func(i, j int) bool {
+		if c := pods[i].cpu.request.Cmp(pods[j].cpu.request); c != 0 {
+			return c > 0
+		}
+		if c := pods[i].memory.request.Cmp(pods[j].memory.request); c != 0 {
+			return c > 0
+		}
+		return podKey(pods[i].namespace, pods[i].name) < podKey(pods[j].namespace, pods[j].name)
+	})
+	return pods, true
+}
+
+// movableRequests is the CPU requested by the pods on a node other than
+// DaemonSet pods
+func movableRequests(nm *nodeMetric) resource.Quantity {
+	requests := resource.Quantity{}
+	for _, pm := range nm.podMetrics {
+		if !pm.daemonSet {
+			requests.Add(pm.cpu.request)
+		}
+	}
+	return requests
+}
+
+// firstFitPeer returns the first peer with the requests of the pod and a pod
+// slot left, or nil when none has
+func firstFitPeer(peers []*fitNode, pm *podMetric) *fitNode {
+	for _, fn := range peers {
+		if fn.pods >= 1 && fn.cpu.Cmp(pm.cpu.request) >= 0 && fn.memory.Cmp(pm.memory.request) >= 0 {
+			return fn
+		}
+	}
+	return nil
+}
+
+func printKarpenterPools(run *reportRun, cm *clusterMetric, opts Options) error {
+	kp := &karpenterPrinter{run: run, pools: cm.karpenterPools, opts: opts}
+	return kp.Print(opts.OutputFormat)
+}
+
+type karpenterPrinter struct {
+	run   *reportRun
+	pools []*karpenterPoolMetric
+	opts  Options
+}
+
+func (kp *karpenterPrinter) Print(outputType string) error {
+	switch outputType {
+	case JSONOutput, YAMLOutput:
+		printListOutput(kp.run, KarpenterReportKind, kp.buildListKarpenterPools(), outputType)
+	case TableOutput:
+		w := tabwriter.NewWriter(kp.run.output, 0, 8, 2, ' ', 0)
+		kp.printTable(w, "\t ")
+		if err := w.Flush(); err != nil {
+			kp.run.logErrorf("Error writing to table: %s", err)
+		}
+	case CSVOutput:
+		kp.printTable(kp.run.output, ",")
+	case TSVOutput:
+		kp.printTable(kp.run.output, "\t")
+	default:
+		return fmt.Errorf("Called with an unsupported output type: %s", outputType)
+	}
+	return nil
+}
+
+// printTable prints each NodePool followed by its nodes, with the
+// consolidation policy on NodePool rows and hints on node rows
+func (kp *karpenterPrinter) printTable(w io.Writer, separator string) {
+	headers := []string{"NODEPOOL", "NODE"}
+	for _, prefix := range []string{"CPU", "MEMORY"} {
+		headers = append(headers, prefix+" REQUESTS", prefix+" CAPACITY", prefix+" LIMIT")
+		if kp.opts.ShowUtil {
+			headers = append(headers, prefix+" UTIL")
+		}
+	}
+	headers = append(headers, "CONSOLIDATION")
+	_, _ = fmt.Fprintln(w, strings.Join(headers, separator))
+
+	for _, lp := range kp.buildListKarpenterPools().NodePools {
+		policy := lp.ConsolidationPolicy
+		if policy == "" {
+			policy = VoidValue
+		}
+		_, _ = fmt.Fprintln(w, strings.Join(kp.rowItems(lp.Name, VoidValue, lp.CPU, lp.Memory, policy), separator))
+		for _, ln := range lp.Nodes {
+			_, _ = fmt.Fprintln(w, strings.Join(kp.rowItems(lp.Name, ln.Name, ln.CPU, ln.Memory, ln.Consolidation), separator))
+		}
+	}
+}
+
+func (kp *karpenterPrinter) rowItems(pool, node string, cpu, memory *listKarpenterResource, consolidation string) []string {
+	items := []string{pool, node}
+	for _, r := range []*listKarpenterResource{cpu, memory} {
+		limit := VoidValue
+		if r.Limit != "" {
+			limit = fmt.Sprintf("%s (%s)", r.Limit, r.LimitPct)
+		}
+		items = append(items, fmt.Sprintf("%s (%s)", r.Requests, r.RequestsPct), r.Capacity, limit)
+		if kp.opts.ShowUtil {
+			items = append(items, fmt.Sprintf("%s (%s)", r.Utilization, r.UtilizationPct))
+		}
+	}
+	return append(items, consolidation)
+}
+
+func (kp *karpenterPrinter) buildListKarpenterPools() *listKarpenterPools {
+	out := &listKarpenterPools{NodePools: []*listKarpenterPool{}}
+	for _, pool := range kp.pools {
+		lp := &listKarpenterPool{
+			Name:                pool.name,
+			ConsolidationPolicy: pool.consolidationPolicy,
+			CPU:                 kp.listKarpenterResource(pool.cpu, pool.cpuLimit),
+			Memory:              kp.listKarpenterResource(pool.memory, pool.memoryLimit),
+			Nodes:               []*listKarpenterNode{},
+		}
+		for _, kn := range pool.nodes {
+			if kn.consolidation != "" {
+				lp.ConsolidationCandidates++
+			}
+			lp.Nodes = append(lp.Nodes, &listKarpenterNode{
+				Name:          kn.nm.name,
+				Consolidation: kn.consolidation,
+				CPU:           kp.listKarpenterResource(kn.nm.cpu, nil),
+				Memory:        kp.listKarpenterResource(kn.nm.memory, nil),
+			})
+		}
+		out.NodePools = append(out.NodePools, lp)
+	}
+	return out
+}
+
+// listKarpenterResource compares capacity rather than allocatable to the
+// limit, as Karpenter does when deciding whether a NodePool can grow
+func (kp *karpenterPrinter) listKarpenterResource(rm *resourceMetric, limit *resource.Quantity) *listKarpenterResource {
+	valueCalculator := rm.valueFunction(kp.run)
+	capacity := rm.capacity
+	if capacity.IsZero() {
+		capacity = rm.allocatable
+	}
+
+	out := &listKarpenterResource{
+		Requests:    valueCalculator(rm.request),
+		RequestsPct: percentString(kp.run, rm.request, rm.allocatable),
+		Capacity:    valueCalculator(capacity),
+	}
+	if limit != nil {
+		out.Limit = valueCalculator(*limit)
+		out.LimitPct = percentString(kp.run, capacity, *limit)
+	}
+	if kp.opts.ShowUtil {
+		out.Utilization = valueCalculator(rm.utilization)
+		out.UtilizationPct = percentString(kp.run, rm.utilization, rm.utilBase(kp.opts.UtilPercent))
+	}
+	return out
+}
@@ -0,0 +1,174 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func karpenterTestCluster(t *testing.T, annotate func(pods []corev1.Pod)) clusterMetric {
+	t.Helper()
+	nodes := []corev1.Node{
+		*node("node-a", map[string]string{karpenterNodePoolLabel: "default"}, false),
+		*node("node-b", map[string]string{karpenterNodePoolLabel: "default"}, false),
+		*node("node-c", map[string]string{karpenterNodePoolLabel: "default"}, false),
+		*node("node-d", map[string]string{karpenterNodePoolLabel: "spot"}, false),
+		*node("static", map[string]string{"eks.amazonaws.com/nodegroup": "system"}, false),
+	}
+	for i := range nodes {
+		nodes[i].Status.Allocatable = corev1.ResourceList{
+			"cpu":    resource.MustParse("4"),
+			"memory": resource.MustParse("16Gi"),
+			"pods":   resource.MustParse("110"),
+		}
+	}
+
+	newPod := func(nodeName, name, cpu, memory string, daemonSet bool) corev1.Pod {
+		p := pod(nodeName, "default", name, map[string]string{})
+		p.Spec.Containers = []corev1.Container{{
+			Name: "app",
+			Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{
+				"cpu":    resource.MustParse(cpu),
+				"memory": resource.MustParse(memory),
+			}},
+		}}
+		if daemonSet {
+			p.OwnerReferences = controllerRef("DaemonSet", "agent")
+		}
+		return *p
+	}
+	pods := []corev1.Pod{
+		newPod("node-a", "web-a", "3", "8Gi", false),
+		newPod("node-b", "web-b", "1", "2Gi", false),
+		newPod("node-c", "web-c", "400m", "512Mi", false),
+		newPod("node-c", "agent-c", "200m", "128Mi", true),
+		newPod("node-d", "agent-d", "200m", "128Mi", true),
+		newPod("static", "web-static", "100m", "128Mi", false),
+	}
+	if annotate != nil {
+		annotate(pods)
+	}
+
+	return buildClusterMetric(&corev1.PodList{Items: pods}, nil, &corev1.NodeList{Items: nodes}, nil)
+}
+
+func karpenterTestNodePools() *nodePoolList {
+	pools := []nodePool{
+		{ObjectMeta: metav1.ObjectMeta{Name: "default"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "gpu"}},
+	}
+	pools[0].Spec.Limits = corev1.ResourceList{"cpu": resource.MustParse("100")}
+	pools[0].Spec.Disruption.ConsolidationPolicy = "WhenEmptyOrUnderutilized"
+	return &nodePoolList{Items: pools}
+}
+
+func TestBuildKarpenterPoolMetrics(t *testing.T) {
+	run := newTestRun(nil)
+	cm := karpenterTestCluster(t, nil)
+	pools := buildKarpenterPoolMetrics(&cm, karpenterTestNodePools(), "name")
+
+	require.Len(t, pools, 3)
+	assert.Equal(t, "default", pools[0].name)
+	assert.Equal(t, "gpu", pools[1].name)
+	assert.Empty(t, pools[1].nodes)
+	assert.Equal(t, "spot", pools[2].name)
+	assert.Nil(t, pools[2].cpuLimit)
+
+	consolidation := map[string]string{}
+	for _, pool := range pools {
+		for _, kn := range pool.nodes {
+			consolidation[kn.nm.name] = kn.consolidation
+		}
+	}
+	assert.Equal(t, map[string]string{
+		"node-a": "",
+		"node-b": "",
+		"node-c": consolidateOntoPeers,
+		"node-d": consolidateEmpty,
+	}, consolidation)
+
+	kp := &karpenterPrinter{run: run, pools: pools}
+	list := kp.buildListKarpenterPools()
+	assert.Equal(t, "WhenEmptyOrUnderutilized", list.NodePools[0].ConsolidationPolicy)
+	assert.Equal(t, int64(1), list.NodePools[0].ConsolidationCandidates)
+	assert.Equal(t, &listKarpenterResource{
+		Requests:    "4600m",
+		RequestsPct: "38%",
+		Capacity:    "12000m",
+		Limit:       "100000m",
+		LimitPct:    "12%",
+	}, list.NodePools[0].CPU)
+}
+
+func TestKarpenterDoNotDisrupt(t *testing.T) {
+	cm := karpenterTestCluster(t, func(pods []corev1.Pod) {
+		pods[2].Annotations = map[string]string{karpenterDoNotDisruptAnnotation: "true"}
+	})
+	pools := buildKarpenterPoolMetrics(&cm, nil, "name")
+
+	// node-b takes the room on node-a that node-c would have used
+	require.Len(t, pools, 2)
+	assert.Equal(t, "", pools[0].nodes[0].consolidation)
+	assert.Equal(t, consolidateOntoPeers, pools[0].nodes[1].consolidation)
+	assert.Equal(t, "", pools[0].nodes[2].consolidation)
+}
+
+func TestKarpenterConsolidationCountsRoomOnce(t *testing.T) {
+	nm := func(name string, requests string) *nodeMetric {
+		return &nodeMetric{
+			name:       name,
+			cpu:        &resourceMetric{resourceType: "cpu", allocatable: resource.MustParse("1"), request: resource.MustParse(requests)},
+			memory:     &resourceMetric{resourceType: "memory", allocatable: resource.MustParse("1Gi")},
+			podCount:   &podCount{allocatable: 110, current: 1},
+			podMetrics: map[string]*podMetric{"default/" + name: {name: name, namespace: "default", cpu: &resourceMetric{request: resource.MustParse(requests)}, memory: &resourceMetric{}}},
+		}
+	}
+	// Each of the two small nodes fits on the large one, but not both
+	pool := &karpenterPoolMetric{nodes: []*karpenterNodeMetric{
+		{nm: nm("large", "500m")},
+		{nm: nm("small-1", "300m")},
+		{nm: nm("small-2", "400m")},
+	}}
+	pool.markConsolidationCandidates()
+
+	assert.Equal(t, "", pool.nodes[0].consolidation)
+	assert.Equal(t, consolidateOntoPeers, pool.nodes[1].consolidation)
+	assert.Equal(t, "", pool.nodes[2].consolidation)
+}
+
+func TestKarpenterTable(t *testing.T) {
+	run := newTestRun(nil)
+	cm := karpenterTestCluster(t, nil)
+	kp := &karpenterPrinter{run: run, pools: buildKarpenterPoolMetrics(&cm, karpenterTestNodePools(), "name")}
+
+	var buf bytes.Buffer
+	kp.printTable(&buf, ",")
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+
+	assert.Equal(t, "NODEPOOL,NODE,CPU REQUESTS,CPU CAPACITY,CPU LIMIT,MEMORY REQUESTS,MEMORY CAPACITY,MEMORY LIMIT,CONSOLIDATION", lines[0])
+	assert.Equal(t, "default,*,4600m (38%),12000m,100000m (12%),10880Mi (22%),49152Mi,*,WhenEmptyOrUnderutilized", lines[1])
+	assert.Equal(t, "default,node-c,600m (15%),4000m,*,640Mi (3%),16384Mi,*,fits on peers", lines[4])
+	assert.Equal(t, "gpu,*,0m (0%),0m,*,0Mi (0%),0Mi,*,*", lines[5])
+	assert.Equal(t, "spot,node-d,200m (5%),4000m,*,128Mi (0%),16384Mi,*,empty", lines[7])
+	assert.Len(t, lines, 8)
+}
@@ -0,0 +1,159 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	v1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+)
+
+// kubectlObject holds enough of anything printed by kubectl get -o json to
+// tell what it is. Lists printed by kubectl are of kind List with a kind on
+// every item, while lists read from the API directly are typed and leave it
+// off their items.
+type kubectlObject struct {
+	Kind  string            `json:"kind"`
+	Items []json.RawMessage `json:"items"`
+}
+
+// loadKubectlDump builds a snapshot from files written by kubectl get -o
+// json, so that a report can be rendered from the output of kubectl alone.
+// Each file may hold a list or a single object of any kind that is read,
+// in any order. Pending pods are taken from the pods that have not been
+// scheduled, and the snapshot is dated by the newest file.
+func loadKubectlDump(paths []string) (*snapshot, error) {
+	snap := &snapshot{
+		Kind:  snapshotKind,
+		Nodes: &corev1.NodeList{},
+		Pods:  &corev1.PodList{},
+	}
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if info.ModTime().After(snap.CreatedAt) {
+			snap.CreatedAt = info.ModTime()
+		}
+		err = readDecompressed(path, func(r io.Reader) error {
+			return snap.addKubectlOutput(r)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	if len(snap.Nodes.Items) == 0 {
+		return nil, fmt.Errorf("no nodes found, include the output of kubectl get nodes -o json")
+	}
+
+	snap.PendingPods = &corev1.PodList{}
+	for _, pod := range snap.Pods.Items {
+		if pod.Status.Phase == corev1.PodPending && pod.Spec.NodeName == "" {
+			snap.PendingPods.Items = append(snap.PendingPods.Items, pod)
+		}
+	}
+	return snap, nil
+}
+
+// addKubectlOutput adds every object in the output of one kubectl command
+func (s *snapshot) addKubectlOutput(r io.Reader) error {
+	dec := json.NewDecoder(r)
+	for {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("decoding kubectl output: %w", err)
+		}
+
+		obj := kubectlObject{}
+		if err := json.Unmarshal(raw, &obj); err != nil {
+			return fmt.Errorf("decoding kubectl output: %w", err)
+		}
+		if !strings.HasSuffix(obj.Kind, "List") {
+			if err := s.addKubectlObject(obj.Kind, raw); err != nil {
+				return err
+			}
+			continue
+		}
+		itemKind := strings.TrimSuffix(obj.Kind, "List")
+		for _, item := range obj.Items {
+			kind := itemKind
+			if kind == "" {
+				itemObj := kubectlObject{}
+				if err := json.Unmarshal(item, &itemObj); err != nil {
+					return fmt.Errorf("decoding kubectl output: %w", err)
+				}
+				kind = itemObj.Kind
+			}
+			if err := s.addKubectlObject(kind, item); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// addKubectlObject decodes a single object by kind into the snapshot
+func (s *snapshot) addKubectlObject(kind string, raw json.RawMessage) error {
+	var err error
+	switch kind {
+	case "Node":
+		node := corev1.Node{}
+		if err = json.Unmarshal(raw, &node); err == nil {
+			s.Nodes.Items = append(s.Nodes.Items, node)
+		}
+	case "Pod":
+		pod := corev1.Pod{}
+		if err = json.Unmarshal(raw, &pod); err == nil {
+			s.Pods.Items = append(s.Pods.Items, pod)
+		}
+	case "Namespace":
+		ns := corev1.Namespace{}
+		if err = json.Unmarshal(raw, &ns); err == nil {
+			if s.Namespaces == nil {
+				s.Namespaces = &corev1.NamespaceList{}
+			}
+			s.Namespaces.Items = append(s.Namespaces.Items, ns)
+		}
+	case "PodMetrics":
+		pm := v1beta1.PodMetrics{}
+		if err = json.Unmarshal(raw, &pm); err == nil {
+			if s.PodMetrics == nil {
+				s.PodMetrics = &v1beta1.PodMetricsList{}
+			}
+			s.PodMetrics.Items = append(s.PodMetrics.Items, pm)
+		}
+	case "NodeMetrics":
+		nm := v1beta1.NodeMetrics{}
+		if err = json.Unmarshal(raw, &nm); err == nil {
+			if s.NodeMetrics == nil {
+				s.NodeMetrics = &v1beta1.NodeMetricsList{}
+			}
+			s.NodeMetrics.Items = append(s.NodeMetrics.Items, nm)
+		}
+	default:
+		return fmt.Errorf("can not read %q objects, only Node, Pod, Namespace, PodMetrics, and NodeMetrics", kind)
+	}
+	if err != nil {
+		return fmt.Errorf("decoding %s: %w", kind, err)
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadKubectlDump(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, contents string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(contents), 0o600))
+		return path
+	}
+
+	// kubectl get prints a List with a kind on every item, while typed
+	// lists from the API leave it off
+	nodes := write("nodes.json", `{"apiVersion":"v1","kind":"List","items":[
+		{"apiVersion":"v1","kind":"Node","metadata":{"name":"mynode"},
+		 "status":{"allocatable":{"cpu":"1000m","memory":"4000Mi"}}}]}`)
+	pods := write("pods.json", `{"apiVersion":"v1","kind":"PodList","items":[
+		{"metadata":{"name":"mypod","namespace":"default"},"spec":{"nodeName":"mynode"}},
+		{"metadata":{"name":"waiting","namespace":"default"},"status":{"phase":"Pending"}}]}`)
+	metrics := write("podmetrics.json", `{"apiVersion":"metrics.k8s.io/v1beta1","kind":"PodMetrics",
+		"metadata":{"name":"mypod","namespace":"default"},
+		"containers":[{"name":"app","usage":{"cpu":"10m","memory":"20Mi"}}]}`)
+
+	snap, err := loadKubectlDump([]string{pods, nodes, metrics})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"mynode"}, listNodes(snap.Nodes))
+	assert.Equal(t, []string{"default/mypod", "default/waiting"}, listPods(snap.Pods))
+	assert.Equal(t, []string{"default/waiting"}, listPods(snap.PendingPods))
+	require.NotNil(t, snap.PodMetrics)
+	assert.Len(t, snap.PodMetrics.Items, 1)
+	assert.Nil(t, snap.NodeMetrics)
+
+	_, err = loadKubectlDump([]string{pods})
+	assert.EqualError(t, err, "no nodes found, include the output of kubectl get nodes -o json")
+
+	deployments := write("deployments.json", `{"kind":"List","items":[{"kind":"Deployment"}]}`)
+	_, err = loadKubectlDump([]string{nodes, deployments})
+	assert.EqualError(t, err, deployments+`: can not read "Deployment" objects, only Node, Pod, Namespace, PodMetrics, and NodeMetrics`)
+}
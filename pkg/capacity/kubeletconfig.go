@@ -0,0 +1,297 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// kubeletConfigMapNamespace and kubeletConfigMapName are where kubeadm
+	// stores the kubelet configuration shared by the nodes of a cluster
+	kubeletConfigMapNamespace = "kube-system"
+	kubeletConfigMapName      = "kubelet-config"
+	kubeletConfigMapKey       = "kubelet"
+)
+
+// defaultMemoryEvictionHard is the memory.available hard eviction threshold
+// of a kubelet that does not configure one
+var defaultMemoryEvictionHard = resource.MustParse("100Mi")
+
+// kubeletConfiguration holds the fields of a KubeletConfiguration that make
+// up the difference between the capacity and allocatable of a node
+type kubeletConfiguration struct {
+	KubeReserved       map[string]string `json:"kubeReserved,omitempty"`
+	SystemReserved     map[string]string `json:"systemReserved,omitempty"`
+	ReservedSystemCPUs string            `json:"reservedSystemCPUs,omitempty"`
+	EvictionHard       map[string]string `json:"evictionHard,omitempty"`
+}
+
+// kubeletConfigz is the response of the /configz endpoint of a kubelet
+type kubeletConfigz struct {
+	KubeletConfig *kubeletConfiguration `json:"kubeletconfig"`
+}
+
+// reservedBreakdown splits what a node reserves into the kube-reserved and
+// system-reserved settings of its kubelet and the hard eviction threshold
+type reservedBreakdown struct {
+	kube     resource.Quantity
+	system   resource.Quantity
+	eviction resource.Quantity
+}
+
+func (rb *reservedBreakdown) add(other *reservedBreakdown) {
+	rb.kube.Add(other.kube)
+	rb.system.Add(other.system)
+	rb.eviction.Add(other.eviction)
+}
+
+// getKubeletConfigurations reads the configuration of the kubelet of each
+// node from its /configz endpoint through the API server's node proxy.
+// Nodes whose kubelet can not be read fall back to the kubelet-config
+// ConfigMap of kubeadm, and are left out when there is none.
+func getKubeletConfigurations(run *reportRun, clientset kubernetes.Interface, nodeList *corev1.NodeList) map[string]*kubeletConfiguration {
+	start := time.Now()
+	ctx := context.TODO()
+
+	configs := make([]*kubeletConfiguration, len(nodeList.Items))
+	errs := make([]error, len(nodeList.Items))
+	sem := make(chan struct{}, kubeletConcurrency)
+	var wg sync.WaitGroup
+	for i := range nodeList.Items {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			configs[i], errs[i] = getKubeletConfigz(ctx, clientset, nodeList.Items[i].Name)
+		}(i)
+	}
+	wg.Wait()
+
+	var shared *kubeletConfiguration
+	sharedLoaded := false
+	byNode := map[string]*kubeletConfiguration{}
+	missing := []string{}
+	for i, config := range configs {
+		nodeName := nodeList.Items[i].Name
+		if errs[i] != nil {
+			run.logDebugf("Error reading kubelet configuration on %s: %v", nodeName, errs[i])
+			if !sharedLoaded {
+				shared = getKubeletConfigMap(ctx, run, clientset)
+				sharedLoaded = true
+			}
+			config = shared
+		}
+		if config == nil {
+			missing = append(missing, nodeName)
+			continue
+		}
+		byNode[nodeName] = config
+	}
+
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		run.logWarnf("could not read the kubelet configuration of %s, their reserved breakdown is left out; kube-capacity needs permission to get nodes/proxy", strings.Join(missing, ", "))
+	}
+	run.logTiming(start, "Read the configuration of %d kubelets", len(byNode))
+	return byNode
+}
+
+func getKubeletConfigz(ctx context.Context, clientset kubernetes.Interface, nodeName string) (*kubeletConfiguration, error) {
+	body, err := clientset.CoreV1().RESTClient().Get().
+		Resource("nodes").
+		Name(nodeName).
+		SubResource("proxy").
+		Suffix("configz").
+		DoRaw(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return parseKubeletConfigz(body)
+}
+
+func parseKubeletConfigz(body []byte) (*kubeletConfiguration, error) {
+	configz := &kubeletConfigz{}
+	if err := json.Unmarshal(body, configz); err != nil {
+		return nil, fmt.Errorf("parsing configz: %w", err)
+	}
+	if configz.KubeletConfig == nil {
+		return nil, fmt.Errorf("configz has no kubeletconfig")
+	}
+	return configz.KubeletConfig, nil
+}
+
+// getKubeletConfigMap returns the kubelet configuration kubeadm shares
+// between nodes, or nil when the cluster has none that can be read
+func getKubeletConfigMap(ctx context.Context, run *reportRun, clientset kubernetes.Interface) *kubeletConfiguration {
+	cm, err := clientset.CoreV1().ConfigMaps(kubeletConfigMapNamespace).Get(ctx, kubeletConfigMapName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		run.logDebugf("Error getting ConfigMap %s/%s: %v", kubeletConfigMapNamespace, kubeletConfigMapName, err)
+		return nil
+	}
+	config := &kubeletConfiguration{}
+	if err := yaml.Unmarshal([]byte(cm.Data[kubeletConfigMapKey]), config); err != nil {
+		run.logWarnf("could not parse ConfigMap %s/%s: %v", kubeletConfigMapNamespace, kubeletConfigMapName, err)
+		return nil
+	}
+	run.logDebugf("Using kubelet configuration from ConfigMap %s/%s", kubeletConfigMapNamespace, kubeletConfigMapName)
+	return config
+}
+
+// addReservedBreakdown sets the reserved breakdown of every node with a
+// kubelet configuration and adds it to the cluster totals. Totals only
+// include the nodes whose kubelet configuration was read.
+func (cm *clusterMetric) addReservedBreakdown(run *reportRun, configs map[string]*kubeletConfiguration) {
+	for name, nm := range cm.nodeMetrics {
+		config, ok := configs[name]
+		if !ok {
+			continue
+		}
+		cpu, err := config.cpuBreakdown()
+		if err != nil {
+			run.logWarnf("invalid kubelet configuration on %s: %v", name, err)
+			continue
+		}
+		memory, err := config.memoryBreakdown(nm.memory.capacity)
+		if err != nil {
+			run.logWarnf("invalid kubelet configuration on %s: %v", name, err)
+			continue
+		}
+		nm.cpu.breakdown = cpu
+		nm.memory.breakdown = memory
+		cm.cpu.addBreakdown(cpu)
+		cm.memory.addBreakdown(memory)
+	}
+}
+
+// cpuBreakdown returns the CPU reserved by the kubelet. reservedSystemCPUs
+// replaces both kube-reserved and system-reserved CPU when it is set, and
+// CPU has no eviction threshold.
+func (kc *kubeletConfiguration) cpuBreakdown() (*reservedBreakdown, error) {
+	if kc.ReservedSystemCPUs != "" {
+		cpus, err := countCPUSet(kc.ReservedSystemCPUs)
+		if err != nil {
+			return nil, fmt.Errorf("reservedSystemCPUs: %w", err)
+		}
+		return &reservedBreakdown{system: *resource.NewQuantity(cpus, resource.DecimalSI)}, nil
+	}
+
+	rb := &reservedBreakdown{}
+	var err error
+	if rb.kube, err = parseReservation(kc.KubeReserved, "cpu"); err != nil {
+		return nil, fmt.Errorf("kubeReserved: %w", err)
+	}
+	if rb.system, err = parseReservation(kc.SystemReserved, "cpu"); err != nil {
+		return nil, fmt.Errorf("systemReserved: %w", err)
+	}
+	return rb, nil
+}
+
+// memoryBreakdown returns the memory reserved by the kubelet, with a
+// memory.available threshold in percent taken of capacity
+func (kc *kubeletConfiguration) memoryBreakdown(capacity resource.Quantity) (*reservedBreakdown, error) {
+	rb := &reservedBreakdown{}
+	var err error
+	if rb.kube, err = parseReservation(kc.KubeReserved, "memory"); err != nil {
+		return nil, fmt.Errorf("kubeReserved: %w", err)
+	}
+	if rb.system, err = parseReservation(kc.SystemReserved, "memory"); err != nil {
+		return nil, fmt.Errorf("systemReserved: %w", err)
+	}
+
+	if kc.EvictionHard == nil {
+		rb.eviction = defaultMemoryEvictionHard.DeepCopy()
+		return rb, nil
+	}
+	threshold, ok := kc.EvictionHard["memory.available"]
+	if !ok {
+		return rb, nil
+	}
+	if rb.eviction, err = parseEvictionThreshold("memory.available", threshold, capacity); err != nil {
+		return nil, err
+	}
+	return rb, nil
+}
+
+// parseEvictionThreshold returns the quantity a hard eviction threshold
+// stands for, with a threshold in percent taken of capacity
+func parseEvictionThreshold(signal, threshold string, capacity resource.Quantity) (resource.Quantity, error) {
+	if percent, isPercent := strings.CutSuffix(threshold, "%"); isPercent {
+		value, err := strconv.ParseFloat(percent, 64)
+		if err != nil || value < 0 || value > 100 {
+			return resource.Quantity{}, fmt.Errorf("evictionHard: invalid %s %q", signal, threshold)
+		}
+		return *resource.NewQuantity(int64(float64(capacity.Value())*value/100), resource.BinarySI), nil
+	}
+	q, err := resource.ParseQuantity(threshold)
+	if err != nil {
+		return resource.Quantity{}, fmt.Errorf("evictionHard: invalid %s %q", signal, threshold)
+	}
+	return q, nil
+}
+
+func parseReservation(reservations map[string]string, resourceName string) (resource.Quantity, error) {
+	value, ok := reservations[resourceName]
+	if !ok {
+		return resource.Quantity{}, nil
+	}
+	q, err := resource.ParseQuantity(value)
+	if err != nil {
+		return resource.Quantity{}, fmt.Errorf("invalid %s %q", resourceName, value)
+	}
+	return q, nil
+}
+
+// countCPUSet returns the number of CPUs in a cpuset like "0-1,4"
+func countCPUSet(cpuset string) (int64, error) {
+	var count int64
+	for _, part := range strings.Split(cpuset, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		first, last, isRange := strings.Cut(part, "-")
+		from, err := strconv.ParseInt(first, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid cpuset %q", cpuset)
+		}
+		to := from
+		if isRange {
+			if to, err = strconv.ParseInt(last, 10, 64); err != nil || to < from {
+				return 0, fmt.Errorf("invalid cpuset %q", cpuset)
+			}
+		}
+		count += to - from + 1
+	}
+	return count, nil
+}
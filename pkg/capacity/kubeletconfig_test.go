@@ -0,0 +1,201 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestParseKubeletConfigz(t *testing.T) {
+	config, err := parseKubeletConfigz([]byte(`{"kubeletconfig":{
+		"kubeReserved":{"cpu":"60m","memory":"512Mi"},
+		"systemReserved":{"cpu":"30m","memory":"764Mi"},
+		"evictionHard":{"memory.available":"100Mi","nodefs.available":"10%"}}}`))
+	require.NoError(t, err)
+	assert.Equal(t, "60m", config.KubeReserved["cpu"])
+	assert.Equal(t, "764Mi", config.SystemReserved["memory"])
+	assert.Equal(t, "100Mi", config.EvictionHard["memory.available"])
+
+	_, err = parseKubeletConfigz([]byte(`{}`))
+	assert.Error(t, err)
+}
+
+func TestKubeletConfigurationBreakdown(t *testing.T) {
+	capacity := resource.MustParse("16000Mi")
+
+	tests := []struct {
+		name           string
+		config         kubeletConfiguration
+		cpuKube        string
+		cpuSystem      string
+		memoryKube     string
+		memorySystem   string
+		memoryEviction string
+	}{
+		{
+			name: "reservations",
+			config: kubeletConfiguration{
+				KubeReserved:   map[string]string{"cpu": "60m", "memory": "512Mi"},
+				SystemReserved: map[string]string{"cpu": "30m", "memory": "764Mi"},
+				EvictionHard:   map[string]string{"memory.available": "100Mi"},
+			},
+			cpuKube: "60m", cpuSystem: "30m", memoryKube: "512Mi", memorySystem: "764Mi", memoryEviction: "100Mi",
+		},
+		{
+			name:    "default eviction threshold",
+			config:  kubeletConfiguration{},
+			cpuKube: "0", cpuSystem: "0", memoryKube: "0", memorySystem: "0", memoryEviction: "100Mi",
+		},
+		{
+			name: "eviction threshold in percent",
+			config: kubeletConfiguration{
+				EvictionHard: map[string]string{"memory.available": "5%"},
+			},
+			cpuKube: "0", cpuSystem: "0", memoryKube: "0", memorySystem: "0", memoryEviction: "800Mi",
+		},
+		{
+			name: "no memory eviction threshold",
+			config: kubeletConfiguration{
+				EvictionHard: map[string]string{"nodefs.available": "10%"},
+			},
+			cpuKube: "0", cpuSystem: "0", memoryKube: "0", memorySystem: "0", memoryEviction: "0",
+		},
+		{
+			name: "reserved system CPUs",
+			config: kubeletConfiguration{
+				KubeReserved:       map[string]string{"cpu": "500m"},
+				ReservedSystemCPUs: "0-1,4",
+			},
+			cpuKube: "0", cpuSystem: "3", memoryKube: "0", memorySystem: "0", memoryEviction: "100Mi",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cpu, err := test.config.cpuBreakdown()
+			require.NoError(t, err)
+			memory, err := test.config.memoryBreakdown(capacity)
+			require.NoError(t, err)
+
+			milliValue := func(s string) int64 {
+				q := resource.MustParse(s)
+				return q.MilliValue()
+			}
+			value := func(s string) int64 {
+				q := resource.MustParse(s)
+				return q.Value()
+			}
+			assert.Equal(t, milliValue(test.cpuKube), cpu.kube.MilliValue())
+			assert.Equal(t, milliValue(test.cpuSystem), cpu.system.MilliValue())
+			assert.True(t, cpu.eviction.IsZero())
+			assert.Equal(t, value(test.memoryKube), memory.kube.Value())
+			assert.Equal(t, value(test.memorySystem), memory.system.Value())
+			assert.Equal(t, value(test.memoryEviction), memory.eviction.Value())
+		})
+	}
+}
+
+func TestKubeletConfigurationBreakdownErrors(t *testing.T) {
+	_, err := (&kubeletConfiguration{ReservedSystemCPUs: "2-1"}).cpuBreakdown()
+	assert.EqualError(t, err, `reservedSystemCPUs: invalid cpuset "2-1"`)
+
+	_, err = (&kubeletConfiguration{KubeReserved: map[string]string{"cpu": "lots"}}).cpuBreakdown()
+	assert.EqualError(t, err, `kubeReserved: invalid cpu "lots"`)
+
+	_, err = (&kubeletConfiguration{EvictionHard: map[string]string{"memory.available": "150%"}}).memoryBreakdown(resource.MustParse("1Gi"))
+	assert.EqualError(t, err, `evictionHard: invalid memory.available "150%"`)
+}
+
+func TestGetKubeletConfigMap(t *testing.T) {
+	run := newTestRun(nil)
+	clientset := fake.NewSimpleClientset()
+	assert.Nil(t, getKubeletConfigMap(context.TODO(), run, clientset))
+
+	clientset = fake.NewSimpleClientset(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: kubeletConfigMapName, Namespace: kubeletConfigMapNamespace},
+		Data: map[string]string{kubeletConfigMapKey: `apiVersion: kubelet.config.k8s.io/v1beta1
+kind: KubeletConfiguration
+kubeReserved:
+  cpu: 100m
+  memory: 256Mi
+evictionHard:
+  memory.available: 200Mi
+`},
+	})
+	config := getKubeletConfigMap(context.TODO(), run, clientset)
+	require.NotNil(t, config)
+	assert.Equal(t, "100m", config.KubeReserved["cpu"])
+	assert.Equal(t, "200Mi", config.EvictionHard["memory.available"])
+}
+
+func TestAddReservedBreakdown(t *testing.T) {
+	run := newTestRun(nil)
+	withCapacity := func(n *corev1.Node) corev1.Node {
+		n.Status.Capacity = corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("4"),
+			corev1.ResourceMemory: resource.MustParse("15930Mi"),
+		}
+		n.Status.Allocatable = corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("3910m"),
+			corev1.ResourceMemory: resource.MustParse("14554Mi"),
+		}
+		return *n
+	}
+	cm := buildClusterMetric(&corev1.PodList{}, nil, &corev1.NodeList{Items: []corev1.Node{
+		withCapacity(node("node-1", nil, false)),
+		withCapacity(node("node-2", nil, false)),
+		withCapacity(node("unreadable", nil, false)),
+	}}, nil)
+
+	config := &kubeletConfiguration{
+		KubeReserved:   map[string]string{"cpu": "60m", "memory": "512Mi"},
+		SystemReserved: map[string]string{"cpu": "30m", "memory": "764Mi"},
+	}
+	cm.addReservedBreakdown(run, map[string]*kubeletConfiguration{"node-1": config, "node-2": config})
+
+	nm := cm.nodeMetrics["node-1"]
+	assert.Equal(t, "60m", nm.cpu.kubeReservedString(run))
+	assert.Equal(t, "30m", nm.cpu.systemReservedString(run))
+	assert.Equal(t, "100Mi", nm.memory.evictionThresholdString(run))
+	assert.Equal(t, "764", nm.memory.systemReservedCSVString(run))
+	assert.Equal(t, VoidValue, cm.nodeMetrics["unreadable"].cpu.kubeReservedString(run))
+
+	assert.Equal(t, "120m", cm.cpu.kubeReservedString(run))
+	assert.Equal(t, "1024Mi", cm.memory.kubeReservedString(run))
+	assert.Equal(t, "200Mi", cm.memory.evictionThresholdString(run))
+
+	tp := &tablePrinter{run: run, cm: &cm, opts: Options{ShowCapacity: true, ShowReservedBreakdown: true, HideRequests: true, HideLimits: true}}
+	assert.Equal(t, []string{
+		"NODE", "CPU CAPACITY", "CPU ALLOCATABLE", "CPU RESERVED", "CPU KUBE RESERVED", "CPU SYSTEM RESERVED",
+		"MEMORY CAPACITY", "MEMORY ALLOCATABLE", "MEMORY RESERVED", "MEMORY KUBE RESERVED", "MEMORY SYSTEM RESERVED", "MEMORY EVICTION",
+	}, tp.getLineItems(tp.headers()))
+}
+
+func TestCountCPUSet(t *testing.T) {
+	count, err := countCPUSet("0-3, 8,10-11")
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), count)
+
+	_, err = countCPUSet("a-b")
+	assert.Error(t, err)
+}
@@ -0,0 +1,289 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	v1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+)
+
+// kubeletRateWindow is the time between the two scrapes of a kubelet's
+// resource metrics that CPU usage is calculated over, the same as the
+// default resolution of metrics-server
+const kubeletRateWindow = 15 * time.Second
+
+// kubeletSample is one value of a kubelet resource metric
+type kubeletSample struct {
+	value     float64
+	timestamp time.Time
+}
+
+type kubeletContainerKey struct {
+	namespace string
+	pod       string
+	container string
+}
+
+type kubeletContainerSamples struct {
+	cpu    *kubeletSample
+	memory *kubeletSample
+}
+
+// kubeletResourceMetrics is one scrape of the /metrics/resource endpoint of
+// a kubelet. CPU is a counter of seconds used and memory is the working set.
+type kubeletResourceMetrics struct {
+	nodeCPU    *kubeletSample
+	nodeMemory *kubeletSample
+	containers map[kubeletContainerKey]*kubeletContainerSamples
+}
+
+// scrapeKubeletResourceMetrics reads the resource metrics of a kubelet
+// through the API server's node proxy
+func scrapeKubeletResourceMetrics(ctx context.Context, clientset kubernetes.Interface, nodeName string) (*kubeletResourceMetrics, error) {
+	body, err := clientset.CoreV1().RESTClient().Get().
+		Resource("nodes").
+		Name(nodeName).
+		SubResource("proxy").
+		Suffix("metrics", "resource").
+		DoRaw(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return parseKubeletResourceMetrics(body, time.Now())
+}
+
+// parseKubeletResourceMetrics parses the Prometheus text format served by
+// the kubelet, samples without a timestamp are given scrapedAt
+func parseKubeletResourceMetrics(body []byte, scrapedAt time.Time) (*kubeletResourceMetrics, error) {
+	metrics := &kubeletResourceMetrics{
+		containers: map[kubeletContainerKey]*kubeletContainerSamples{},
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		name, labels, sample, err := parsePrometheusLine(line, scrapedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		switch name {
+		case "node_cpu_usage_seconds_total":
+			metrics.nodeCPU = sample
+		case "node_memory_working_set_bytes":
+			metrics.nodeMemory = sample
+		case "container_cpu_usage_seconds_total", "container_memory_working_set_bytes":
+			key := kubeletContainerKey{namespace: labels["namespace"], pod: labels["pod"], container: labels["container"]}
+			if key.pod == "" || key.container == "" {
+				continue
+			}
+			if metrics.containers[key] == nil {
+				metrics.containers[key] = &kubeletContainerSamples{}
+			}
+			if name == "container_cpu_usage_seconds_total" {
+				metrics.containers[key].cpu = sample
+			} else {
+				metrics.containers[key].memory = sample
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return metrics, nil
+}
+
+// parsePrometheusLine parses a line like
+// name{label="value",...} value [timestamp in milliseconds]
+func parsePrometheusLine(line string, scrapedAt time.Time) (string, map[string]string, *kubeletSample, error) {
+	labels := map[string]string{}
+
+	end := strings.IndexAny(line, "{ ")
+	if end < 0 {
+		return "", nil, nil, fmt.Errorf("invalid metric line %q", line)
+	}
+	name := line[:end]
+	rest := line[end:]
+
+	if strings.HasPrefix(rest, "{") {
+		rest = rest[1:]
+		for {
+			rest = strings.TrimLeft(rest, ", ")
+			if strings.HasPrefix(rest, "}") {
+				rest = rest[1:]
+				break
+			}
+			eq := strings.Index(rest, "=")
+			if eq < 0 || len(rest) < eq+2 || rest[eq+1] != '"' {
+				return "", nil, nil, fmt.Errorf("invalid labels in metric line %q", line)
+			}
+			key := strings.TrimSpace(rest[:eq])
+			value, remaining, err := unquoteLabelValue(rest[eq+1:])
+			if err != nil {
+				return "", nil, nil, fmt.Errorf("invalid labels in metric line %q: %w", line, err)
+			}
+			labels[key] = value
+			rest = remaining
+		}
+	}
+
+	fields := strings.Fields(rest)
+	if len(fields) == 0 {
+		return "", nil, nil, fmt.Errorf("missing value in metric line %q", line)
+	}
+	value, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("invalid value in metric line %q: %w", line, err)
+	}
+
+	sample := &kubeletSample{value: value, timestamp: scrapedAt}
+	if len(fields) > 1 {
+		millis, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return "", nil, nil, fmt.Errorf("invalid timestamp in metric line %q: %w", line, err)
+		}
+		sample.timestamp = time.UnixMilli(millis)
+	}
+
+	return name, labels, sample, nil
+}
+
+// unquoteLabelValue reads a quoted label value from the start of s and
+// returns it along with the rest of s
+func unquoteLabelValue(s string) (string, string, error) {
+	var value strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if i+1 == len(s) {
+				return "", "", fmt.Errorf("unterminated label value")
+			}
+			i++
+			if s[i] == 'n' {
+				value.WriteByte('\n')
+			} else {
+				value.WriteByte(s[i])
+			}
+		case '"':
+			return value.String(), s[i+1:], nil
+		default:
+			value.WriteByte(s[i])
+		}
+	}
+	return "", "", fmt.Errorf("unterminated label value")
+}
+
+// kubeletResourceUsage calculates the usage of a node and its pods from two
+// scrapes of its kubelet. CPU is the rate between the scrapes and memory is
+// the working set of the second. Containers that restarted or were not
+// measured again between the scrapes are left without CPU usage.
+func kubeletResourceUsage(nodeName string, first, second *kubeletResourceMetrics) *kubeletNodeUsage {
+	usage := &kubeletNodeUsage{
+		node: v1beta1.NodeMetrics{ObjectMeta: metav1.ObjectMeta{Name: nodeName}},
+	}
+	usage.node.Usage, usage.node.Timestamp, usage.node.Window = kubeletSampleUsage(
+		first.nodeCPU, second.nodeCPU, second.nodeMemory)
+
+	keys := []kubeletContainerKey{}
+	for key := range second.containers {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.namespace != b.namespace {
+			return a.namespace < b.namespace
+		}
+		if a.pod != b.pod {
+			return a.pod < b.pod
+		}
+		return a.container < b.container
+	})
+
+	for _, key := range keys {
+		var firstCPU *kubeletSample
+		if samples := first.containers[key]; samples != nil {
+			firstCPU = samples.cpu
+		}
+		samples := second.containers[key]
+		containerUsage, timestamp, window := kubeletSampleUsage(firstCPU, samples.cpu, samples.memory)
+
+		// Keys are sorted, so the containers of a pod are next to each other
+		last := len(usage.pods) - 1
+		if last < 0 || usage.pods[last].Namespace != key.namespace || usage.pods[last].Name != key.pod {
+			usage.pods = append(usage.pods, v1beta1.PodMetrics{
+				ObjectMeta: metav1.ObjectMeta{Name: key.pod, Namespace: key.namespace},
+			})
+			last++
+		}
+		pm := &usage.pods[last]
+		// The oldest container sets the timestamp of the pod
+		if !timestamp.IsZero() && (pm.Timestamp.IsZero() || timestamp.Before(&pm.Timestamp)) {
+			pm.Timestamp = timestamp
+			pm.Window = window
+		}
+		pm.Containers = append(pm.Containers, v1beta1.ContainerMetrics{
+			Name:  key.container,
+			Usage: containerUsage,
+		})
+	}
+
+	return usage
+}
+
+// kubeletSampleUsage converts a pair of CPU counter samples and a memory
+// sample to usage, along with the time and window they were measured over
+func kubeletSampleUsage(firstCPU, secondCPU, memory *kubeletSample) (corev1.ResourceList, metav1.Time, metav1.Duration) {
+	usage := corev1.ResourceList{}
+	var timestamp metav1.Time
+	var window metav1.Duration
+
+	if firstCPU != nil && secondCPU != nil {
+		elapsed := secondCPU.timestamp.Sub(firstCPU.timestamp)
+		used := secondCPU.value - firstCPU.value
+		if elapsed > 0 && used >= 0 {
+			cores := used / elapsed.Seconds()
+			usage[corev1.ResourceCPU] = *resource.NewMilliQuantity(int64(math.Round(cores*1000)), resource.DecimalSI)
+			timestamp = metav1.NewTime(secondCPU.timestamp)
+			window = metav1.Duration{Duration: elapsed}
+		}
+	}
+
+	if memory != nil {
+		usage[corev1.ResourceMemory] = *resource.NewQuantity(int64(math.Round(memory.value)), resource.BinarySI)
+		if timestamp.IsZero() || memory.timestamp.Before(timestamp.Time) {
+			timestamp = metav1.NewTime(memory.timestamp)
+		}
+	}
+
+	return usage, timestamp, window
+}
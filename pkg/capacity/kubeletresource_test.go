@@ -0,0 +1,115 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testKubeletResourceMetrics1 = `# HELP node_cpu_usage_seconds_total [ALPHA] Cumulative cpu time consumed by the node in core-seconds
+# TYPE node_cpu_usage_seconds_total counter
+node_cpu_usage_seconds_total 1000 1767268800000
+# HELP node_memory_working_set_bytes [ALPHA] Current working set of the node in bytes
+# TYPE node_memory_working_set_bytes gauge
+node_memory_working_set_bytes 1.073741824e+09 1767268800000
+container_cpu_usage_seconds_total{container="app",namespace="default",pod="web"} 100 1767268800000
+container_memory_working_set_bytes{container="app",namespace="default",pod="web"} 5.24288e+07 1767268800000
+container_cpu_usage_seconds_total{container="sidecar",namespace="default",pod="web"} 50 1767268800000
+container_cpu_usage_seconds_total{container="db",namespace="data",pod="pg"} 10 1767268800000
+scrape_error 0
+`
+
+const testKubeletResourceMetrics2 = `node_cpu_usage_seconds_total 1030 1767268815000
+node_memory_working_set_bytes 2.147483648e+09 1767268814000
+container_cpu_usage_seconds_total{container="app",namespace="default",pod="web"} 103 1767268815000
+container_memory_working_set_bytes{container="app",namespace="default",pod="web"} 1.048576e+08 1767268815000
+container_cpu_usage_seconds_total{container="sidecar",namespace="default",pod="web"} 2 1767268815000
+container_cpu_usage_seconds_total{container="db",namespace="data",pod="pg"} 25 1767268815000
+container_memory_working_set_bytes{container="new",namespace="data",pod="pg"} 1.048576e+06 1767268812000
+`
+
+func TestParseKubeletResourceMetrics(t *testing.T) {
+	scrapedAt := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	metrics, err := parseKubeletResourceMetrics([]byte(testKubeletResourceMetrics1), scrapedAt)
+	require.NoError(t, err)
+
+	assert.Equal(t, &kubeletSample{value: 1000, timestamp: time.UnixMilli(1767268800000)}, metrics.nodeCPU)
+	assert.Equal(t, float64(1073741824), metrics.nodeMemory.value)
+	assert.Len(t, metrics.containers, 3)
+
+	app := metrics.containers[kubeletContainerKey{namespace: "default", pod: "web", container: "app"}]
+	require.NotNil(t, app)
+	assert.Equal(t, float64(100), app.cpu.value)
+	assert.Equal(t, float64(52428800), app.memory.value)
+
+	// Samples without a timestamp are given the time of the scrape
+	metrics, err = parseKubeletResourceMetrics([]byte("node_cpu_usage_seconds_total 5\n"), scrapedAt)
+	require.NoError(t, err)
+	assert.Equal(t, scrapedAt, metrics.nodeCPU.timestamp)
+
+	_, err = parseKubeletResourceMetrics([]byte("node_cpu_usage_seconds_total abc\n"), scrapedAt)
+	assert.Error(t, err)
+}
+
+func TestParsePrometheusLineLabels(t *testing.T) {
+	name, labels, sample, err := parsePrometheusLine(`metric{a="x,y}",b="say \"hi\"\\",} 1.5`, time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, "metric", name)
+	assert.Equal(t, map[string]string{"a": "x,y}", "b": `say "hi"\`}, labels)
+	assert.Equal(t, 1.5, sample.value)
+
+	_, _, _, err = parsePrometheusLine(`metric{a="x} 1`, time.Time{})
+	assert.Error(t, err)
+}
+
+func TestKubeletResourceUsage(t *testing.T) {
+	first, err := parseKubeletResourceMetrics([]byte(testKubeletResourceMetrics1), time.Time{})
+	require.NoError(t, err)
+	second, err := parseKubeletResourceMetrics([]byte(testKubeletResourceMetrics2), time.Time{})
+	require.NoError(t, err)
+
+	usage := kubeletResourceUsage("node-a", first, second)
+
+	assert.Equal(t, "node-a", usage.node.Name)
+	assert.Equal(t, "2", usage.node.Usage.Cpu().String())
+	assert.Equal(t, "2Gi", usage.node.Usage.Memory().String())
+	// The older of the CPU and memory samples is used
+	assert.Equal(t, time.UnixMilli(1767268814000), usage.node.Timestamp.Time)
+	assert.Equal(t, 15*time.Second, usage.node.Window.Duration)
+
+	require.Len(t, usage.pods, 2)
+	pg := usage.pods[0]
+	assert.Equal(t, "data", pg.Namespace)
+	assert.Equal(t, "pg", pg.Name)
+	require.Len(t, pg.Containers, 2)
+	assert.Equal(t, "1", pg.Containers[0].Usage.Cpu().String())
+	// A container that was not scraped before only reports memory
+	_, ok := pg.Containers[1].Usage["cpu"]
+	assert.False(t, ok)
+	assert.Equal(t, "1Mi", pg.Containers[1].Usage.Memory().String())
+	assert.Equal(t, time.UnixMilli(1767268812000), pg.Timestamp.Time)
+
+	web := usage.pods[1]
+	require.Len(t, web.Containers, 2)
+	assert.Equal(t, "app", web.Containers[0].Name)
+	assert.Equal(t, "200m", web.Containers[0].Usage.Cpu().String())
+	assert.Equal(t, "100Mi", web.Containers[0].Usage.Memory().String())
+	// A restarted container resets its counter, so its CPU is left out
+	assert.Empty(t, web.Containers[1].Usage)
+}
@@ -0,0 +1,277 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// APISpecSource lists pods and nodes from the API server
+	APISpecSource = "api"
+	// KubeStateMetricsSpecSource reads the requests, limits, and allocatable
+	// that kube-state-metrics exports from Prometheus
+	KubeStateMetricsSpecSource = "kube-state-metrics"
+)
+
+// SupportedSpecSources returns the sources requests, limits, and
+// allocatable can be read from
+func SupportedSpecSources() []string {
+	return []string{APISpecSource, KubeStateMetricsSpecSource}
+}
+
+// The series are grouped by their identifying labels, which also collapses
+// the copies scraped from HA replicas of kube-state-metrics or Prometheus
+func ksmPodInfoQuery(namespace string) string {
+	return fmt.Sprintf(`max by (namespace, pod, node) (kube_pod_info{node!=""%s})`, ksmNamespaceMatcher(namespace))
+}
+
+func ksmPodPhaseQuery(namespace string) string {
+	return fmt.Sprintf(`max by (namespace, pod, phase) (kube_pod_status_phase{phase!=""%s}) == 1`, ksmNamespaceMatcher(namespace))
+}
+
+func ksmContainerResourceQuery(metric, namespace string) string {
+	return fmt.Sprintf(`max by (namespace, pod, container, resource) (%s{resource=~"cpu|memory"%s})`, metric, ksmNamespaceMatcher(namespace))
+}
+
+const ksmNodeAllocatableQuery = `max by (node, resource) (kube_node_status_allocatable{resource=~"cpu|memory|pods"})`
+
+const ksmNodeCapacityQuery = `max by (node, resource) (kube_node_status_capacity{resource=~"cpu|memory|pods"})`
+
+func ksmNamespaceMatcher(namespace string) string {
+	if namespace == "" {
+		return ""
+	}
+	return fmt.Sprintf(`,namespace=%q`, namespace)
+}
+
+// fetchKubeStateMetricsPodsAndNodes starts the queries of
+// --spec-source=kube-state-metrics. The returned function gives pods and
+// nodes built from the series once the group has been waited on, with only
+// the fields the reports read.
+func fetchKubeStateMetricsPodsAndNodes(run *reportRun, g *fetchGroup, clientset kubernetes.Interface, opts Options) func() (*corev1.PodList, *corev1.NodeList) {
+	var podList *corev1.PodList
+	var nodeList *corev1.NodeList
+	g.run(func(ctx context.Context) *fetchError {
+		start := time.Now()
+		var err error
+		podList, nodeList, err = getKubeStateMetricsPodsAndNodes(run, clientset, opts)
+		if err != nil {
+			return newFetchError(3, "Error reading pods and nodes from kube-state-metrics: %v", err)
+		}
+		run.logTiming(start, "Read %d pods and %d nodes from kube-state-metrics", len(podList.Items), len(nodeList.Items))
+		if err := filterNodesByName(nodeList, opts.NodeName); err != nil {
+			return newFetchError(2, "Error parsing node name pattern: %v", err)
+		}
+		return nil
+	})
+	return func() (*corev1.PodList, *corev1.NodeList) {
+		// Pods on nodes kube-state-metrics has no allocatable for are left
+		// out, the same as pods on nodes that were filtered out
+		nodes := map[string]bool{}
+		for _, node := range nodeList.Items {
+			nodes[node.Name] = true
+		}
+		pods := []corev1.Pod{}
+		for _, pod := range podList.Items {
+			if nodes[pod.Spec.NodeName] {
+				pods = append(pods, pod)
+			}
+		}
+		podList.Items = pods
+		return podList, nodeList
+	}
+}
+
+func getKubeStateMetricsPodsAndNodes(run *reportRun, clientset kubernetes.Interface, opts Options) (*corev1.PodList, *corev1.NodeList, error) {
+	endpoint, err := resolvePrometheusEndpoint(run, clientset, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	promHTTP, err := newPrometheusHTTP(context.TODO(), run, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	queries := []struct {
+		name  string
+		query string
+	}{
+		{"pod info", ksmPodInfoQuery(opts.Namespace)},
+		{"pod phases", ksmPodPhaseQuery(opts.Namespace)},
+		{"container requests", ksmContainerResourceQuery("kube_pod_container_resource_requests", opts.Namespace)},
+		{"container limits", ksmContainerResourceQuery("kube_pod_container_resource_limits", opts.Namespace)},
+		{"node allocatable", ksmNodeAllocatableQuery},
+	}
+	resps := make([]*prometheusResponse, len(queries))
+	for i, q := range queries {
+		resps[i], err = queryPrometheus(run, clientset, endpoint, promHTTP, q.query)
+		if err != nil {
+			return nil, nil, fmt.Errorf("querying %s: %w", q.name, err)
+		}
+	}
+	if len(resps[4].Data.Result) == 0 {
+		return nil, nil, fmt.Errorf("no kube_node_status_allocatable series found, is kube-state-metrics scraped by this Prometheus?")
+	}
+
+	// Capacity is only read for --show-capacity, which saves a query on
+	// every other run
+	var capacity *prometheusResponse
+	if opts.ShowCapacity {
+		capacity, err = queryPrometheus(run, clientset, endpoint, promHTTP, ksmNodeCapacityQuery)
+		if err != nil {
+			return nil, nil, fmt.Errorf("querying node capacity: %w", err)
+		}
+	}
+
+	podList := buildKubeStateMetricsPodList(resps[0], resps[1], resps[2], resps[3])
+	nodeList := buildKubeStateMetricsNodeList(resps[4], capacity)
+	return podList, nodeList, nil
+}
+
+// buildKubeStateMetricsPodList builds a pod for each kube_pod_info series
+// with a container for each container that has requests or limits
+func buildKubeStateMetricsPodList(info, phases, requests, limits *prometheusResponse) *corev1.PodList {
+	pods := map[string]*corev1.Pod{}
+	for _, r := range info.Data.Result {
+		key := podKey(r.Metric["namespace"], r.Metric["pod"])
+		pods[key] = &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      r.Metric["pod"],
+				Namespace: r.Metric["namespace"],
+			},
+			Spec: corev1.PodSpec{NodeName: r.Metric["node"]},
+		}
+	}
+
+	for _, r := range phases.Data.Result {
+		if pod, ok := pods[podKey(r.Metric["namespace"], r.Metric["pod"])]; ok {
+			pod.Status.Phase = corev1.PodPhase(r.Metric["phase"])
+		}
+	}
+
+	// Containers are kept apart until every series is read, keyed by
+	// containerKey, so that the pod specs are only filled in once
+	containers := map[string]*corev1.Container{}
+	container := func(m map[string]string) *corev1.Container {
+		if _, ok := pods[podKey(m["namespace"], m["pod"])]; !ok {
+			return nil
+		}
+		key := containerKey(m["namespace"], m["pod"], m["container"])
+		if _, ok := containers[key]; !ok {
+			containers[key] = &corev1.Container{Name: m["container"]}
+		}
+		return containers[key]
+	}
+	for _, r := range requests.Data.Result {
+		if c := container(r.Metric); c != nil {
+			setKubeStateMetricsQuantity(&c.Resources.Requests, r)
+		}
+	}
+	for _, r := range limits.Data.Result {
+		if c := container(r.Metric); c != nil {
+			setKubeStateMetricsQuantity(&c.Resources.Limits, r)
+		}
+	}
+
+	keys := make([]string, 0, len(containers))
+	for key := range containers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		c := containers[key]
+		pod := pods[strings.TrimSuffix(key, "/"+c.Name)]
+		pod.Spec.Containers = append(pod.Spec.Containers, *c)
+	}
+
+	podList := &corev1.PodList{}
+	for _, pod := range pods {
+		podList.Items = append(podList.Items, *pod)
+	}
+	sort.Slice(podList.Items, func(i, j int) bool {
+		return podKey(podList.Items[i].Namespace, podList.Items[i].Name) < podKey(podList.Items[j].Namespace, podList.Items[j].Name)
+	})
+	return podList
+}
+
+// buildKubeStateMetricsNodeList builds a node with its allocatable for each
+// node in kube_node_status_allocatable, and its capacity when capacity is
+// not nil
+func buildKubeStateMetricsNodeList(allocatable, capacity *prometheusResponse) *corev1.NodeList {
+	nodes := map[string]*corev1.Node{}
+	for _, r := range allocatable.Data.Result {
+		name := r.Metric["node"]
+		node, ok := nodes[name]
+		if !ok {
+			node = &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: name}}
+			nodes[name] = node
+		}
+		setKubeStateMetricsQuantity(&node.Status.Allocatable, r)
+	}
+	if capacity != nil {
+		for _, r := range capacity.Data.Result {
+			if node, ok := nodes[r.Metric["node"]]; ok {
+				setKubeStateMetricsQuantity(&node.Status.Capacity, r)
+			}
+		}
+	}
+
+	nodeList := &corev1.NodeList{}
+	for _, node := range nodes {
+		nodeList.Items = append(nodeList.Items, *node)
+	}
+	sort.Slice(nodeList.Items, func(i, j int) bool {
+		return nodeList.Items[i].Name < nodeList.Items[j].Name
+	})
+	return nodeList
+}
+
+// setKubeStateMetricsQuantity sets the resource of a series in list.
+// kube-state-metrics exports CPU in cores and memory in bytes.
+func setKubeStateMetricsQuantity(list *corev1.ResourceList, r prometheusResult) {
+	val, err := parseValue(r.Value)
+	if err != nil || math.IsNaN(val) {
+		return
+	}
+
+	name := corev1.ResourceName(r.Metric["resource"])
+	var q *resource.Quantity
+	switch name {
+	case corev1.ResourceCPU:
+		q = resource.NewMilliQuantity(int64(math.Round(val*1000)), resource.DecimalSI)
+	case corev1.ResourceMemory:
+		q = resource.NewQuantity(int64(math.Round(val)), resource.BinarySI)
+	case corev1.ResourcePods:
+		q = resource.NewQuantity(int64(math.Round(val)), resource.DecimalSI)
+	default:
+		return
+	}
+
+	if *list == nil {
+		*list = corev1.ResourceList{}
+	}
+	(*list)[name] = *q
+}
@@ -0,0 +1,98 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestKubeStateMetricsQueries(t *testing.T) {
+	assert.Equal(t,
+		`max by (namespace, pod, container, resource) (kube_pod_container_resource_requests{resource=~"cpu|memory",namespace="default"})`,
+		ksmContainerResourceQuery("kube_pod_container_resource_requests", "default"))
+	assert.Equal(t,
+		`max by (namespace, pod, node) (kube_pod_info{node!=""})`,
+		ksmPodInfoQuery(""))
+}
+
+func TestBuildKubeStateMetricsLists(t *testing.T) {
+	resp := func(body string) *prometheusResponse {
+		var r prometheusResponse
+		require.NoError(t, json.Unmarshal([]byte(`{"status":"success","data":{"resultType":"vector","result":`+body+`}}`), &r))
+		return &r
+	}
+
+	info := resp(`[
+		{"metric":{"namespace":"default","pod":"web","node":"node-1"},"value":[1700000000,"1"]},
+		{"metric":{"namespace":"default","pod":"job","node":"node-1"},"value":[1700000000,"1"]}
+	]`)
+	phases := resp(`[
+		{"metric":{"namespace":"default","pod":"web","phase":"Running"},"value":[1700000000,"1"]},
+		{"metric":{"namespace":"default","pod":"job","phase":"Succeeded"},"value":[1700000000,"1"]}
+	]`)
+	requests := resp(`[
+		{"metric":{"namespace":"default","pod":"web","container":"proxy","resource":"cpu"},"value":[1700000000,"0.05"]},
+		{"metric":{"namespace":"default","pod":"web","container":"app","resource":"cpu"},"value":[1700000000,"0.25"]},
+		{"metric":{"namespace":"default","pod":"web","container":"app","resource":"memory"},"value":[1700000000,"268435456"]},
+		{"metric":{"namespace":"default","pod":"gone","container":"app","resource":"cpu"},"value":[1700000000,"1"]}
+	]`)
+	limits := resp(`[
+		{"metric":{"namespace":"default","pod":"web","container":"app","resource":"memory"},"value":[1700000000,"536870912"]}
+	]`)
+	allocatable := resp(`[
+		{"metric":{"node":"node-1","resource":"cpu"},"value":[1700000000,"3.92"]},
+		{"metric":{"node":"node-1","resource":"memory"},"value":[1700000000,"16106127360"]},
+		{"metric":{"node":"node-1","resource":"pods"},"value":[1700000000,"110"]}
+	]`)
+
+	podList := buildKubeStateMetricsPodList(info, phases, requests, limits)
+	require.Len(t, podList.Items, 2)
+	job, web := podList.Items[0], podList.Items[1]
+	assert.Equal(t, "job", job.Name)
+	assert.Equal(t, corev1.PodSucceeded, job.Status.Phase)
+	assert.Empty(t, job.Spec.Containers)
+
+	assert.Equal(t, "node-1", web.Spec.NodeName)
+	assert.Equal(t, corev1.PodRunning, web.Status.Phase)
+	require.Len(t, web.Spec.Containers, 2)
+	app := web.Spec.Containers[0]
+	assert.Equal(t, "app", app.Name)
+	assert.Equal(t, "250m", app.Resources.Requests.Cpu().String())
+	assert.Equal(t, "256Mi", app.Resources.Requests.Memory().String())
+	assert.Equal(t, "512Mi", app.Resources.Limits.Memory().String())
+	assert.Equal(t, "proxy", web.Spec.Containers[1].Name)
+	assert.Nil(t, web.Spec.Containers[1].Resources.Limits)
+
+	nodeList := buildKubeStateMetricsNodeList(allocatable, nil)
+	require.Len(t, nodeList.Items, 1)
+	assert.Equal(t, "node-1", nodeList.Items[0].Name)
+	assert.Equal(t, int64(3920), nodeList.Items[0].Status.Allocatable.Cpu().MilliValue())
+	assert.Equal(t, "15Gi", nodeList.Items[0].Status.Allocatable.Memory().String())
+	assert.Equal(t, int64(110), nodeList.Items[0].Status.Allocatable.Pods().Value())
+	assert.Nil(t, nodeList.Items[0].Status.Capacity)
+
+	capacity := resp(`[
+		{"metric":{"node":"node-1","resource":"cpu"},"value":[1700000000,"4"]},
+		{"metric":{"node":"gone","resource":"cpu"},"value":[1700000000,"8"]}
+	]`)
+	nodeList = buildKubeStateMetricsNodeList(allocatable, capacity)
+	require.Len(t, nodeList.Items, 1)
+	assert.Equal(t, int64(4000), nodeList.Items[0].Status.Capacity.Cpu().MilliValue())
+}
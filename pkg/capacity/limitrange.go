@@ -0,0 +1,80 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"context"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+func getLimitRanges(run *reportRun, clientset kubernetes.Interface, namespace string) (*corev1.LimitRangeList, *fetchError) {
+	start := time.Now()
+	limitRangeList, err := clientset.CoreV1().LimitRanges(namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, newFetchError(3, "Error listing LimitRanges: %v", err)
+	}
+	run.logTiming(start, "Listed %d LimitRanges", len(limitRangeList.Items))
+	return limitRangeList, nil
+}
+
+// applyLimitRangeDefaults sets the default requests and limits from each
+// namespace's LimitRanges on containers that do not specify them, the same
+// way the LimitRanger admission plugin does for newly created pods
+func applyLimitRangeDefaults(podList *corev1.PodList, limitRangeList *corev1.LimitRangeList) {
+	limitRanges := map[string][]corev1.LimitRange{}
+	for _, lr := range limitRangeList.Items {
+		limitRanges[lr.Namespace] = append(limitRanges[lr.Namespace], lr)
+	}
+
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		for _, lr := range limitRanges[pod.Namespace] {
+			for _, item := range lr.Spec.Limits {
+				if item.Type != corev1.LimitTypeContainer {
+					continue
+				}
+				for j := range pod.Spec.InitContainers {
+					applyContainerDefaults(&pod.Spec.InitContainers[j].Resources, item)
+				}
+				for j := range pod.Spec.Containers {
+					applyContainerDefaults(&pod.Spec.Containers[j].Resources, item)
+				}
+			}
+		}
+	}
+}
+
+func applyContainerDefaults(resources *corev1.ResourceRequirements, item corev1.LimitRangeItem) {
+	for name, value := range item.Default {
+		if _, ok := resources.Limits[name]; !ok {
+			if resources.Limits == nil {
+				resources.Limits = corev1.ResourceList{}
+			}
+			resources.Limits[name] = value.DeepCopy()
+		}
+	}
+	for name, value := range item.DefaultRequest {
+		if _, ok := resources.Requests[name]; !ok {
+			if resources.Requests == nil {
+				resources.Requests = corev1.ResourceList{}
+			}
+			resources.Requests[name] = value.DeepCopy()
+		}
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestApplyLimitRangeDefaults(t *testing.T) {
+	snap := getTestSnapshot()
+	// mypod sets its own requests, mypod3 sets nothing, and mypod4 only
+	// sets a CPU limit
+	snap.Pods.Items = append(snap.Pods.Items,
+		*pod("mynode", "default", "mypod3", nil),
+		*pod("mynode2", "default", "mypod4", nil),
+	)
+	snap.Pods.Items[2].Spec.Containers = []corev1.Container{{Name: "app"}}
+	snap.Pods.Items[3].Spec.Containers = []corev1.Container{{
+		Name: "app",
+		Resources: corev1.ResourceRequirements{
+			Limits: corev1.ResourceList{"cpu": resource.MustParse("400m")},
+		},
+	}}
+
+	limitRangeList := &corev1.LimitRangeList{Items: []corev1.LimitRange{
+		limitRange("default", "pods", corev1.LimitRangeItem{
+			Type: corev1.LimitTypePod,
+			Max:  corev1.ResourceList{"cpu": resource.MustParse("2")},
+		}),
+		limitRange("default", "containers", corev1.LimitRangeItem{
+			Type:           corev1.LimitTypeContainer,
+			Default:        corev1.ResourceList{"cpu": resource.MustParse("500m"), "memory": resource.MustParse("512Mi")},
+			DefaultRequest: corev1.ResourceList{"cpu": resource.MustParse("250m"), "memory": resource.MustParse("256Mi")},
+		}),
+	}}
+
+	applyLimitRangeDefaults(snap.Pods, limitRangeList)
+
+	mypod := snap.Pods.Items[0].Spec.Containers[0].Resources
+	assert.Equal(t, "100m", mypod.Requests.Cpu().String())
+	assert.Equal(t, "128Mi", mypod.Requests.Memory().String())
+	assert.Equal(t, "500m", mypod.Limits.Cpu().String())
+
+	mypod3 := snap.Pods.Items[2].Spec.Containers[0].Resources
+	assert.Equal(t, "250m", mypod3.Requests.Cpu().String())
+	assert.Equal(t, "256Mi", mypod3.Requests.Memory().String())
+	assert.Equal(t, "500m", mypod3.Limits.Cpu().String())
+	assert.Equal(t, "512Mi", mypod3.Limits.Memory().String())
+
+	mypod4 := snap.Pods.Items[3].Spec.Containers[0].Resources
+	assert.Equal(t, "400m", mypod4.Limits.Cpu().String())
+	assert.Equal(t, "250m", mypod4.Requests.Cpu().String())
+
+	// pods in namespaces without a LimitRange are left alone
+	mypod2 := snap.Pods.Items[1].Spec.Containers[0].Resources
+	assert.Empty(t, mypod2.Limits)
+
+	cm := buildClusterMetric(snap.Pods, nil, snap.Nodes, nil)
+	assert.Equal(t, int64(700), cm.cpu.request.MilliValue())
+	assert.Equal(t, int64(1400), cm.cpu.limit.MilliValue())
+}
+
+func TestGetLimitRangesFromSnapshot(t *testing.T) {
+	run := newTestRun(nil)
+	snap := getTestSnapshot()
+	snap.LimitRanges = &corev1.LimitRangeList{Items: []corev1.LimitRange{
+		limitRange("default", "containers", corev1.LimitRangeItem{Type: corev1.LimitTypeContainer}),
+		limitRange("other", "containers", corev1.LimitRangeItem{Type: corev1.LimitTypeContainer}),
+	}}
+
+	limitRangeList, ferr := getLimitRanges(run, snap.clientset(), "")
+	assert.Nil(t, ferr)
+	assert.Len(t, limitRangeList.Items, 2)
+
+	limitRangeList, ferr = getLimitRanges(run, snap.clientset(), "other")
+	assert.Nil(t, ferr)
+	assert.Len(t, limitRangeList.Items, 1)
+}
+
+func limitRange(namespace, name string, item corev1.LimitRangeItem) corev1.LimitRange {
+	return corev1.LimitRange{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec:       corev1.LimitRangeSpec{Limits: []corev1.LimitRangeItem{item}},
+	}
+}
@@ -17,105 +17,336 @@ package capacity
 import (
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"sigs.k8s.io/yaml"
 )
 
 type listNodeMetric struct {
-	Name     string              `json:"name"`
-	Labels   map[string]string   `json:"labels,omitempty"`
-	CPU      *listResourceOutput `json:"cpu,omitempty"`
-	Memory   *listResourceOutput `json:"memory,omitempty"`
-	Pods     []*listPod          `json:"pods,omitempty"`
-	PodCount string              `json:"podCount,omitempty"`
+	Name           string              `json:"name"`
+	Labels         map[string]string   `json:"labels,omitempty"`
+	OS             string              `json:"os,omitempty"`
+	Status         *listNodeStatus     `json:"status,omitempty"`
+	Taints         []string            `json:"taints,omitempty"`
+	CPU            *listResourceOutput `json:"cpu,omitempty"`
+	Memory         *listResourceOutput `json:"memory,omitempty"`
+	Pods           []*listPod          `json:"pods,omitempty"`
+	PodCount       string              `json:"podCount,omitempty"`
+	Overcommit     *listOvercommit     `json:"overcommit,omitempty"`
+	Cost           *listCost           `json:"cost,omitempty"`
+	PendingResizes string              `json:"pendingResizes,omitempty"`
+	UsageSample    *listUsageSample    `json:"usageSample,omitempty"`
+	Disk           *listNodeDisk       `json:"disk,omitempty"`
 }
 
 type listPod struct {
-	Name       string              `json:"name"`
-	Namespace  string              `json:"namespace"`
-	CPU        *listResourceOutput `json:"cpu"`
-	Memory     *listResourceOutput `json:"memory"`
-	Containers []listContainer     `json:"containers,omitempty"`
+	Name        string              `json:"name"`
+	Namespace   string              `json:"namespace"`
+	CPU         *listResourceOutput `json:"cpu"`
+	Memory      *listResourceOutput `json:"memory"`
+	Containers  []listContainer     `json:"containers,omitempty"`
+	Resize      string              `json:"resize,omitempty"`
+	Throttle    string              `json:"cpuThrottle,omitempty"`
+	Restarts    *int32              `json:"restarts,omitempty"`
+	LastOOMKill string              `json:"lastOOMKill,omitempty"`
 }
 
 type listContainer struct {
-	Name   string              `json:"name"`
-	CPU    *listResourceOutput `json:"cpu"`
-	Memory *listResourceOutput `json:"memory"`
+	Name        string              `json:"name"`
+	CPU         *listResourceOutput `json:"cpu"`
+	Memory      *listResourceOutput `json:"memory"`
+	VPA         *listVPA            `json:"vpa,omitempty"`
+	Resize      string              `json:"resize,omitempty"`
+	Sidecar     bool                `json:"sidecar,omitempty"`
+	Throttle    string              `json:"cpuThrottle,omitempty"`
+	Restarts    *int32              `json:"restarts,omitempty"`
+	LastOOMKill string              `json:"lastOOMKill,omitempty"`
+}
+
+type listVPA struct {
+	CPU    *listVPARange `json:"cpu,omitempty"`
+	Memory *listVPARange `json:"memory,omitempty"`
+}
+
+type listVPARange struct {
+	Target     string `json:"target"`
+	LowerBound string `json:"lowerBound"`
+	UpperBound string `json:"upperBound"`
 }
 
 type listResourceOutput struct {
+	Capacity       string `json:"capacity,omitempty"`
+	Allocatable    string `json:"allocatable,omitempty"`
+	Reserved       string `json:"reserved,omitempty"`
 	Requests       string `json:"requests,omitempty"`
 	RequestsPct    string `json:"requestsPercent,omitempty"`
+	Overhead       string `json:"overhead,omitempty"`
+	Available      string `json:"available,omitempty"`
 	Limits         string `json:"limits,omitempty"`
 	LimitsPct      string `json:"limitsPercent,omitempty"`
 	Utilization    string `json:"utilization,omitempty"`
 	UtilizationPct string `json:"utilizationPercent,omitempty"`
+
+	// History is only set with --sparkline, with null for points without
+	// usage
+	History []*string `json:"history,omitempty"`
+
+	// ReservedBreakdown is only set with --reserved-breakdown, for nodes
+	// whose kubelet configuration could be read
+	ReservedBreakdown *listReservedBreakdown `json:"reservedBreakdown,omitempty"`
+}
+
+type listReservedBreakdown struct {
+	KubeReserved      string `json:"kubeReserved"`
+	SystemReserved    string `json:"systemReserved"`
+	EvictionThreshold string `json:"evictionThreshold"`
 }
 
 type listClusterMetrics struct {
 	Nodes         []*listNodeMetric  `json:"nodes"`
 	ClusterTotals *listClusterTotals `json:"clusterTotals"`
+
+	// VirtualNodes is only set with --virtual-nodes=separate
+	VirtualNodes *listVirtualNodes `json:"virtualNodes,omitempty"`
+}
+
+type listFleetMetrics struct {
+	Clusters    []*listCluster     `json:"clusters"`
+	FleetTotals *listClusterTotals `json:"fleetTotals"`
+}
+
+type listCluster struct {
+	Name string `json:"name"`
+	listClusterMetrics
 }
 
 type listClusterTotals struct {
-	CPU      *listResourceOutput `json:"cpu"`
-	Memory   *listResourceOutput `json:"memory"`
-	PodCount string              `json:"podCount,omitempty"`
+	CPU            *listResourceOutput  `json:"cpu"`
+	Memory         *listResourceOutput  `json:"memory"`
+	PodCount       string               `json:"podCount,omitempty"`
+	Overcommit     *listOvercommit      `json:"overcommit,omitempty"`
+	Cost           *listCost            `json:"cost,omitempty"`
+	PendingResizes string               `json:"pendingResizes,omitempty"`
+	NodeStatus     *listNodeStatusCount `json:"nodeStatus,omitempty"`
+	Tainted        *listTaintedCapacity `json:"tainted,omitempty"`
+	UsageSample    *listUsageSample     `json:"usageSample,omitempty"`
+	Disk           *listDiskCount       `json:"disk,omitempty"`
+}
+
+// listOvercommit holds the sum of limits divided by allocatable
+type listOvercommit struct {
+	CPU    string `json:"cpu"`
+	Memory string `json:"memory"`
 }
 
 type listPrinter struct {
+	run  *reportRun
 	cm   *clusterMetric
 	opts Options
 }
 
 func (lp listPrinter) Print(outputType string) {
-	listOutput := lp.buildListClusterMetrics()
+	printListOutput(lp.run, ClusterCapacityReportKind, lp.buildListClusterMetrics(), outputType)
+}
+
+// PrintFleet prints every cluster in the fleet along with fleet-wide totals
+func (lp listPrinter) PrintFleet(fm *fleetMetric, outputType string) {
+	fleet := listFleetMetrics{
+		FleetTotals: lp.buildListClusterTotals(fm.total),
+	}
+	for _, cm := range fm.clusters {
+		lp.cm = cm
+		fleet.Clusters = append(fleet.Clusters, &listCluster{
+			Name:               cm.name,
+			listClusterMetrics: lp.buildListClusterMetrics(),
+		})
+	}
+	printListOutput(lp.run, FleetCapacityReportKind, fleet, outputType)
+}
+
+// ReportAPIVersion is the apiVersion of every JSON and YAML report. Fields
+// are only ever added within a version, a field that is renamed or removed
+// or that changes meaning gets a new version.
+const ReportAPIVersion = "kubecapacity.io/v1"
+
+// The kind of each JSON and YAML report
+const (
+	ClusterCapacityReportKind    = "ClusterCapacityReport"
+	FleetCapacityReportKind      = "FleetCapacityReport"
+	CapacityDiffReportKind       = "CapacityDiffReport"
+	FitReportKind                = "FitReport"
+	DrainCheckReportKind         = "DrainCheckReport"
+	RecommendationReportKind     = "RecommendationReport"
+	NamespaceSlackReportKind     = "NamespaceSlackReport"
+	PendingPodsReportKind        = "PendingPodsReport"
+	DaemonSetOverheadReportKind  = "DaemonSetOverheadReport"
+	QuotaReportKind              = "QuotaReport"
+	HeadroomReportKind           = "HeadroomReport"
+	KarpenterReportKind          = "KarpenterReport"
+	CapacityTypeReportKind       = "CapacityTypeReport"
+	ArchitectureReportKind       = "ArchitectureReport"
+	ZoneBalanceReportKind        = "ZoneBalanceReport"
+	GPUReportKind                = "GPUReport"
+	NodeRemovalReportKind        = "NodeRemovalReport"
+	HPAHeadroomReportKind        = "HPAHeadroomReport"
+	ScaleSimulationReportKind    = "ScaleSimulationReport"
+	NamespaceCostReportKind      = "NamespaceCostReport"
+	PodLabelAllocationReportKind = "PodLabelAllocationReport"
+	AnomalyReportKind            = "AnomalyReport"
+	StuckPodsReportKind          = "StuckPodsReport"
+	PreemptionReportKind         = "PreemptionReport"
+	MissingResourcesReportKind   = "MissingResourcesReport"
+	HistoryReportKind            = "HistoryReport"
+	VerifyReportKind             = "VerifyReport"
+	ForecastReportKind           = "ForecastReport"
+	SeriesReportKind             = "SeriesReport"
+)
+
+// listTypeMeta identifies the schema of a report
+type listTypeMeta struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+}
+
+// withTypeMeta marshals a report with its apiVersion and kind as the first
+// fields. The fields of the report are spliced in after them rather than
+// nested so that they keep their order and paths like .nodes still work.
+func withTypeMeta(kind string, listOutput interface{}) (json.RawMessage, error) {
+	header, err := json.Marshal(listTypeMeta{APIVersion: ReportAPIVersion, Kind: kind})
+	if err != nil {
+		return nil, err
+	}
+	body, err := json.Marshal(listOutput)
+	if err != nil {
+		return nil, err
+	}
+	if len(body) < 2 || body[0] != '{' {
+		return nil, fmt.Errorf("%s is not a JSON object", kind)
+	}
+	if string(body) == "{}" {
+		return header, nil
+	}
+	report := append(header[:len(header)-1], ',')
+	return append(report, body[1:]...), nil
+}
 
-	jsonRaw, err := json.MarshalIndent(listOutput, "", "  ")
+func printListOutput(run *reportRun, kind string, listOutput interface{}, outputType string) {
+	report, err := withTypeMeta(kind, listOutput)
+	if err != nil {
+		run.logErrorf("Error Marshalling JSON: %v", err)
+		return
+	}
+	jsonRaw, err := json.MarshalIndent(report, "", "  ")
 	if err != nil {
-		fmt.Println("Error Marshalling JSON")
-		fmt.Println(err)
+		run.logErrorf("Error Marshalling JSON: %v", err)
 	} else {
 		if outputType == JSONOutput {
-			fmt.Printf("%s", jsonRaw)
+			fmt.Fprintf(run.output, "%s", jsonRaw)
 		} else {
 			// This is a strange approach, but the k8s YAML package
 			// already marshalls to JSON before converting to YAML,
 			// this just allows us to follow the same code path.
 			yamlRaw, err := yaml.JSONToYAML(jsonRaw)
 			if err != nil {
-				fmt.Println("Error Converting JSON to Yaml")
-				fmt.Println(err)
+				run.logErrorf("Error Converting JSON to Yaml: %v", err)
 			} else {
-				fmt.Printf("%s", yamlRaw)
+				fmt.Fprintf(run.output, "%s", yamlRaw)
 			}
 		}
 	}
 }
 
-func (lp *listPrinter) buildListClusterMetrics() listClusterMetrics {
-	var response listClusterMetrics
-
-	response.ClusterTotals = &listClusterTotals{
-		CPU:    lp.buildListResourceOutput(lp.cm.cpu),
-		Memory: lp.buildListResourceOutput(lp.cm.memory),
+func (lp *listPrinter) buildListClusterTotals(cm *clusterMetric) *listClusterTotals {
+	totals := &listClusterTotals{
+		CPU:    lp.buildListNodeResourceOutput(cm.cpu),
+		Memory: lp.buildListNodeResourceOutput(cm.memory),
 	}
 
 	if lp.opts.ShowPodCount {
-		response.ClusterTotals.PodCount = lp.cm.podCount.podCountString()
+		totals.PodCount = cm.podCount.podCountString()
+	}
+
+	if lp.opts.ShowOvercommit {
+		totals.Overcommit = buildListOvercommit(cm.cpu, cm.memory)
+	}
+
+	if lp.opts.ShowCost {
+		totals.Cost = cm.cost.listCost()
+	}
+
+	if lp.opts.ShowResize {
+		totals.PendingResizes = pendingResizesString(cm.pendingResizes)
+	}
+
+	if lp.opts.ShowNodeStatus {
+		totals.NodeStatus = cm.nodeStatus.listNodeStatusCount()
+	}
+
+	if lp.opts.ShowDisk {
+		totals.Disk = cm.disk.listDiskCount(lp.run)
+	}
+
+	if lp.opts.ShowMetricsAge {
+		totals.UsageSample = cm.oldestUsage().listUsageSample(cm.observedAt)
+	}
+
+	if lp.opts.ShowTaints {
+		if tainted := buildTaintedClusterMetric(cm); tainted != nil {
+			totals.Tainted = &listTaintedCapacity{
+				Nodes:  int64(len(tainted.nodeMetrics)),
+				CPU:    lp.buildListNodeResourceOutput(tainted.cpu),
+				Memory: lp.buildListNodeResourceOutput(tainted.memory),
+			}
+		}
 	}
 
+	return totals
+}
+
+func (lp *listPrinter) buildListClusterMetrics() listClusterMetrics {
+	var response listClusterMetrics
+
+	response.ClusterTotals = lp.buildListClusterTotals(lp.cm)
+
 	for _, nodeMetric := range lp.cm.getSortedNodeMetrics(lp.opts.SortBy) {
 		var node listNodeMetric
 		node.Name = nodeMetric.name
-		node.CPU = lp.buildListResourceOutput(nodeMetric.cpu)
-		node.Memory = lp.buildListResourceOutput(nodeMetric.memory)
+		node.OS = nodeMetric.operatingSystem()
+		node.CPU = lp.buildListNodeResourceOutput(nodeMetric.cpu)
+		node.Memory = lp.buildListNodeResourceOutput(nodeMetric.memory)
 
 		if lp.opts.ShowPodCount {
 			node.PodCount = nodeMetric.podCount.podCountString()
 		}
 
+		if lp.opts.ShowOvercommit {
+			node.Overcommit = buildListOvercommit(nodeMetric.cpu, nodeMetric.memory)
+		}
+
+		if lp.opts.ShowCost {
+			node.Cost = nodeMetric.cost.listCost()
+		}
+
+		if lp.opts.ShowResize {
+			node.PendingResizes = pendingResizesString(nodeMetric.pendingResizes)
+		}
+
+		if lp.opts.ShowNodeStatus {
+			node.Status = nodeMetric.listNodeStatus(lp.cm.observedAt)
+		}
+
+		if lp.opts.ShowDisk {
+			node.Disk = nodeMetric.disk.listNodeDisk(lp.run)
+		}
+
+		if lp.opts.ShowMetricsAge {
+			node.UsageSample = nodeMetric.usage.listUsageSample(lp.cm.observedAt)
+		}
+
+		if lp.opts.ShowTaints {
+			node.Taints = nodeMetric.listTaints()
+		}
+
 		if lp.opts.ShowLabels {
 			node.Labels = nodeMetric.labels
 		}
@@ -127,13 +358,25 @@ func (lp *listPrinter) buildListClusterMetrics() listClusterMetrics {
 				pod.Namespace = podMetric.namespace
 				pod.CPU = lp.buildListResourceOutput(podMetric.cpu)
 				pod.Memory = lp.buildListResourceOutput(podMetric.memory)
+				lp.addListOverhead(pod.CPU, podMetric.cpu)
+				lp.addListOverhead(pod.Memory, podMetric.memory)
+				pod.Resize = lp.buildListResize(podMetric.resize)
+				pod.Throttle = lp.buildListThrottle(podMetric.cpuThrottled)
+				pod.Restarts = lp.buildListRestarts(podMetric.restarts)
+				pod.LastOOMKill = lp.buildListOOMKill(podMetric.lastOOMKill)
 
 				if lp.opts.ShowContainers {
 					for _, containerMetric := range podMetric.getSortedContainerMetrics(lp.opts.SortBy) {
 						pod.Containers = append(pod.Containers, listContainer{
-							Name:   containerMetric.name,
-							Memory: lp.buildListResourceOutput(containerMetric.memory),
-							CPU:    lp.buildListResourceOutput(containerMetric.cpu),
+							Name:        containerMetric.name,
+							Sidecar:     containerMetric.sidecar,
+							Memory:      lp.buildListResourceOutput(containerMetric.memory),
+							CPU:         lp.buildListResourceOutput(containerMetric.cpu),
+							VPA:         lp.buildListVPA(containerMetric),
+							Resize:      lp.buildListResize(containerMetric.resize),
+							Throttle:    lp.buildListThrottle(containerMetric.cpuThrottled),
+							Restarts:    lp.buildListRestarts(containerMetric.restarts),
+							LastOOMKill: lp.buildListOOMKill(containerMetric.lastOOMKill),
 						})
 					}
 				}
@@ -143,13 +386,14 @@ func (lp *listPrinter) buildListClusterMetrics() listClusterMetrics {
 		response.Nodes = append(response.Nodes, &node)
 	}
 
+	response.VirtualNodes = lp.buildListVirtualNodes()
 	return response
 }
 
 func (lp *listPrinter) buildListResourceOutput(item *resourceMetric) *listResourceOutput {
-	valueCalculator := item.valueFunction()
-	percentCalculator := item.percentFunction()
-	utilPercentCalculator := item.utilPercentFunction(lp.opts.UtilPercent)
+	valueCalculator := item.valueFunction(lp.run)
+	percentCalculator := item.percentFunction(lp.run)
+	utilPercentCalculator := item.utilPercentFunction(lp.run, lp.opts.UtilPercent)
 
 	out := listResourceOutput{}
 
@@ -167,5 +411,104 @@ func (lp *listPrinter) buildListResourceOutput(item *resourceMetric) *listResour
 		out.Utilization = valueCalculator(item.utilization)
 		out.UtilizationPct = utilPercentCalculator(item.utilization)
 	}
+
+	if lp.opts.ShowSparkline {
+		out.History = item.listHistory(lp.run)
+	}
 	return &out
 }
+
+// buildListNodeResourceOutput adds the capacity left after requests, and
+// the capacity and reservations of nodes, to the output for nodes and
+// cluster totals
+func (lp *listPrinter) buildListNodeResourceOutput(item *resourceMetric) *listResourceOutput {
+	out := lp.buildListResourceOutput(item)
+	if lp.opts.AvailableFormat {
+		out.Available = item.valueFunction(lp.run)(item.available())
+	}
+	if lp.opts.ShowCapacity && !item.capacity.IsZero() {
+		out.Capacity = item.valueFunction(lp.run)(item.capacity)
+		out.Allocatable = item.valueFunction(lp.run)(item.allocatable)
+		out.Reserved = item.valueFunction(lp.run)(item.reserved())
+	}
+	if lp.opts.ShowReservedBreakdown && item.breakdown != nil {
+		out.ReservedBreakdown = &listReservedBreakdown{
+			KubeReserved:      item.valueFunction(lp.run)(item.breakdown.kube),
+			SystemReserved:    item.valueFunction(lp.run)(item.breakdown.system),
+			EvictionThreshold: item.valueFunction(lp.run)(item.breakdown.eviction),
+		}
+	}
+	lp.addListOverhead(out, item)
+	return out
+}
+
+// addListOverhead adds the pod overhead included in requests to the output
+// for pods, nodes, and cluster totals
+func (lp *listPrinter) addListOverhead(out *listResourceOutput, item *resourceMetric) {
+	if lp.opts.ShowOverhead {
+		out.Overhead = item.overheadString(lp.run)
+	}
+}
+
+func (lp *listPrinter) buildListVPA(cm *containerMetric) *listVPA {
+	if !lp.opts.ShowVPA || (cm.cpu.vpa == nil && cm.memory.vpa == nil) {
+		return nil
+	}
+	return &listVPA{
+		CPU:    buildListVPARange(lp.run, cm.cpu),
+		Memory: buildListVPARange(lp.run, cm.memory),
+	}
+}
+
+func buildListVPARange(run *reportRun, item *resourceMetric) *listVPARange {
+	if item.vpa == nil {
+		return nil
+	}
+	valueCalculator := item.valueFunction(run)
+	return &listVPARange{
+		Target:     valueCalculator(item.vpa.target),
+		LowerBound: valueCalculator(item.vpa.lowerBound),
+		UpperBound: valueCalculator(item.vpa.upperBound),
+	}
+}
+
+// buildListResize returns the status of a pending in-place resize
+func (lp *listPrinter) buildListResize(status string) string {
+	if !lp.opts.ShowResize {
+		return ""
+	}
+	return status
+}
+
+// buildListThrottle returns the share of CFS periods throttled, empty when
+// it is not shown or not known
+func (lp *listPrinter) buildListThrottle(throttled *float64) string {
+	if !lp.opts.ShowThrottling || throttled == nil {
+		return ""
+	}
+	return throttleString(throttled)
+}
+
+// buildListRestarts returns the restart count, nil when it is not shown
+func (lp *listPrinter) buildListRestarts(restarts int32) *int32 {
+	if !lp.opts.ShowRestarts {
+		return nil
+	}
+	return &restarts
+}
+
+// buildListOOMKill returns when the last OOMKill happened, empty when it is
+// not shown or there was none
+func (lp *listPrinter) buildListOOMKill(lastOOMKill *time.Time) string {
+	if !lp.opts.ShowRestarts || lastOOMKill == nil {
+		return ""
+	}
+	return oomKillTimestamp(lastOOMKill)
+}
+
+func buildListOvercommit(cpu, memory *resourceMetric) *listOvercommit {
+	return &listOvercommit{
+		CPU:    cpu.overcommitString(),
+		Memory: memory.overcommitString(),
+	}
+}
@@ -26,10 +26,12 @@ import (
 )
 
 func TestBuildListClusterMetricsNoOptions(t *testing.T) {
+	run := newTestRun(nil)
 	cm := getTestClusterMetric()
 
 	lp := listPrinter{
-		cm: &cm,
+		run: run,
+		cm:  &cm,
 	}
 
 	lcm := lp.buildListClusterMetrics()
@@ -68,10 +70,12 @@ func TestBuildListClusterMetricsNoOptions(t *testing.T) {
 }
 
 func TestBuildListClusterMetricsAllOptions(t *testing.T) {
+	run := newTestRun(nil)
 	cm := getTestClusterMetric()
 
 	lp := listPrinter{
-		cm: &cm,
+		run: run,
+		cm:  &cm,
 		opts: Options{
 			ShowUtil:       true,
 			ShowPods:       true,
@@ -291,3 +295,46 @@ func getTestClusterMetric() clusterMetric {
 		},
 	)
 }
+
+func TestBuildListClusterMetricsAvailable(t *testing.T) {
+	run := newTestRun(nil)
+	cm := getTestClusterMetric()
+
+	lp := listPrinter{
+		run:  run,
+		cm:   &cm,
+		opts: Options{AvailableFormat: true, ShowPods: true},
+	}
+
+	lcm := lp.buildListClusterMetrics()
+
+	assert.Equal(t, "350m", lcm.ClusterTotals.CPU.Available)
+	assert.Equal(t, "3590Mi", lcm.ClusterTotals.Memory.Available)
+	assert.Equal(t, "350m", lcm.Nodes[0].CPU.Available)
+	// pods have no capacity of their own
+	assert.Equal(t, "", lcm.Nodes[0].Pods[0].CPU.Available)
+
+	assert.Equal(t, "350", cm.cpu.availableString(run))
+	assert.Equal(t, "3590", cm.memory.availableString(run))
+}
+
+func TestWithTypeMeta(t *testing.T) {
+	report, err := withTypeMeta(ClusterCapacityReportKind, &listClusterMetrics{
+		Nodes: []*listNodeMetric{{Name: "mynode"}},
+	})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"apiVersion": "kubecapacity.io/v1",
+		"kind": "ClusterCapacityReport",
+		"nodes": [{"name": "mynode"}],
+		"clusterTotals": null
+	}`, string(report))
+	assert.Regexp(t, `^\{"apiVersion":"kubecapacity.io/v1","kind":"ClusterCapacityReport",`, string(report))
+
+	report, err = withTypeMeta(PendingPodsReportKind, struct{}{})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"apiVersion":"kubecapacity.io/v1","kind":"PendingPodsReport"}`, string(report))
+
+	_, err = withTypeMeta(ClusterCapacityReportKind, []string{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,73 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"fmt"
+	"time"
+)
+
+// logLevel orders messages written to stderr, stdout is only used for
+// report data
+type logLevel int
+
+const (
+	levelDebug logLevel = iota
+	levelInfo
+	levelWarn
+	levelError
+)
+
+// newLogThreshold returns the lowest level written: debug messages are
+// shown with verbose, and only errors with quiet
+func newLogThreshold(verbose, quiet bool) logLevel {
+	if quiet {
+		return levelError
+	}
+	if verbose {
+		return levelDebug
+	}
+	return levelInfo
+}
+
+func (r *reportRun) logf(level logLevel, prefix, format string, args ...interface{}) {
+	if level < r.logThreshold {
+		return
+	}
+	_, _ = fmt.Fprintf(r.logOutput, prefix+format+"\n", args...)
+}
+
+// logDebugf describes API calls, Prometheus queries, and timings
+func (r *reportRun) logDebugf(format string, args ...interface{}) {
+	r.logf(levelDebug, "Debug: ", format, args...)
+}
+
+func (r *reportRun) logInfof(format string, args ...interface{}) {
+	r.logf(levelInfo, "", format, args...)
+}
+
+func (r *reportRun) logWarnf(format string, args ...interface{}) {
+	r.logf(levelWarn, "Warning: ", format, args...)
+}
+
+// logErrorf is never hidden, messages carry their own "Error" prefix
+func (r *reportRun) logErrorf(format string, args ...interface{}) {
+	r.logf(levelError, "", format, args...)
+}
+
+// logTiming logs how long an operation took since start
+func (r *reportRun) logTiming(start time.Time, format string, args ...interface{}) {
+	r.logDebugf("%s in %s", fmt.Sprintf(format, args...), time.Since(start).Round(time.Millisecond))
+}
@@ -0,0 +1,46 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogLevels(t *testing.T) {
+	var buf bytes.Buffer
+	logAll := func(verbose, quiet bool) string {
+		buf.Reset()
+		run := newRun(Options{Verbose: verbose, Quiet: quiet, ErrOut: &buf})
+		run.logDebugf("listed %d nodes", 3)
+		run.logInfof("Discovered Prometheus at %s", "monitoring/prometheus:9090")
+		run.logWarnf("no price for %s", "m5.large")
+		run.logErrorf("Error listing Pods: %v", "forbidden")
+		return buf.String()
+	}
+
+	assert.Equal(t, "Discovered Prometheus at monitoring/prometheus:9090\n"+
+		"Warning: no price for m5.large\n"+
+		"Error listing Pods: forbidden\n", logAll(false, false))
+
+	assert.Equal(t, "Debug: listed 3 nodes\n"+
+		"Discovered Prometheus at monitoring/prometheus:9090\n"+
+		"Warning: no price for m5.large\n"+
+		"Error listing Pods: forbidden\n", logAll(true, false))
+
+	assert.Equal(t, "Error listing Pods: forbidden\n", logAll(false, true))
+}
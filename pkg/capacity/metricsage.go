@@ -0,0 +1,129 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/duration"
+)
+
+// DefaultMetricsMaxAge is how old usage data can be before a warning is
+// logged
+const DefaultMetricsMaxAge = 5 * time.Minute
+
+// usageSample is when the usage of a node was measured and the window it
+// was averaged over
+type usageSample struct {
+	timestamp time.Time
+	window    time.Duration
+}
+
+type listUsageSample struct {
+	Timestamp string `json:"timestamp"`
+	Window    string `json:"window,omitempty"`
+	Age       string `json:"age"`
+}
+
+// observeUsage records a usage sample for the node, keeping the oldest when
+// its usage is summed from several pods. Samples without a timestamp are
+// ignored.
+func (nm *nodeMetric) observeUsage(timestamp time.Time, window time.Duration) {
+	if timestamp.IsZero() {
+		return
+	}
+	if nm.usage == nil || timestamp.Before(nm.usage.timestamp) {
+		nm.usage = &usageSample{timestamp: timestamp, window: window}
+	}
+}
+
+// oldestUsage returns the oldest usage sample of any node in the cluster, or
+// nil when no node has usage
+func (cm *clusterMetric) oldestUsage() *usageSample {
+	var oldest *usageSample
+	for _, nm := range cm.nodeMetrics {
+		if nm.usage != nil && (oldest == nil || nm.usage.timestamp.Before(oldest.timestamp)) {
+			oldest = nm.usage
+		}
+	}
+	return oldest
+}
+
+// age is how old the sample was when the report was observed
+func (s *usageSample) age(observedAt time.Time) time.Duration {
+	if observedAt.IsZero() {
+		observedAt = time.Now()
+	}
+	if age := observedAt.Sub(s.timestamp); age > 0 {
+		return age
+	}
+	return 0
+}
+
+func (s *usageSample) ageString(observedAt time.Time) string {
+	if s == nil {
+		return VoidValue
+	}
+	return duration.HumanDuration(s.age(observedAt))
+}
+
+// ageSecondsString is the age in whole seconds for CSV and TSV output
+func (s *usageSample) ageSecondsString(observedAt time.Time) string {
+	if s == nil {
+		return VoidValue
+	}
+	return fmt.Sprintf("%d", int64(s.age(observedAt).Seconds()))
+}
+
+func (s *usageSample) listUsageSample(observedAt time.Time) *listUsageSample {
+	if s == nil {
+		return nil
+	}
+	ls := &listUsageSample{
+		Timestamp: s.timestamp.UTC().Format(time.RFC3339),
+		Age:       s.ageString(observedAt),
+	}
+	if s.window > 0 {
+		ls.Window = s.window.String()
+	}
+	return ls
+}
+
+// warnStaleUsage logs a warning naming every node with usage older than
+// maxAge, 0 disables the warning
+func warnStaleUsage(run *reportRun, cm *clusterMetric, maxAge time.Duration) {
+	if maxAge <= 0 {
+		return
+	}
+
+	stale := []string{}
+	for _, nm := range cm.nodeMetrics {
+		if nm.usage != nil && nm.usage.age(cm.observedAt) > maxAge {
+			stale = append(stale, nm.name)
+		}
+	}
+	if len(stale) == 0 {
+		return
+	}
+	sort.Strings(stale)
+
+	for i, name := range stale {
+		stale[i] = fmt.Sprintf("%s (%s)", name, cm.nodeMetrics[name].usage.ageString(cm.observedAt))
+	}
+	run.logWarnf("usage is older than %s for %s; check that metrics are being collected", maxAge, strings.Join(stale, ", "))
+}
@@ -0,0 +1,135 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	v1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+)
+
+var usageObservedAt = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func usagePodMetrics(name string, age time.Duration) v1beta1.PodMetrics {
+	return v1beta1.PodMetrics{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Timestamp:  metav1.NewTime(usageObservedAt.Add(-age)),
+		Window:     metav1.Duration{Duration: 30 * time.Second},
+		Containers: []v1beta1.ContainerMetrics{{
+			Name:  "app",
+			Usage: corev1.ResourceList{"cpu": resource.MustParse("100m")},
+		}},
+	}
+}
+
+func TestBuildClusterMetricUsageFromNodes(t *testing.T) {
+	podList := &corev1.PodList{Items: []corev1.Pod{*podWithRequests("node-a", "default", "a", nil, corev1.ResourceList{"cpu": resource.MustParse("500m")})}}
+	nodeList := &corev1.NodeList{Items: []corev1.Node{
+		*nodeWithAllocatable("node-a", nil, corev1.ResourceList{"cpu": resource.MustParse("2")}),
+		*nodeWithAllocatable("node-b", nil, corev1.ResourceList{"cpu": resource.MustParse("2")}),
+	}}
+	pmList := &v1beta1.PodMetricsList{Items: []v1beta1.PodMetrics{usagePodMetrics("a", time.Hour)}}
+	nmList := &v1beta1.NodeMetricsList{Items: []v1beta1.NodeMetrics{{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-a"},
+		Timestamp:  metav1.NewTime(usageObservedAt.Add(-45 * time.Second)),
+		Window:     metav1.Duration{Duration: 20 * time.Second},
+		Usage:      corev1.ResourceList{"cpu": resource.MustParse("1")},
+	}}}
+
+	cm := buildClusterMetric(podList, pmList, nodeList, nmList)
+	cm.observedAt = usageObservedAt
+
+	// Node usage comes from node metrics, so pod timestamps are ignored
+	usage := cm.nodeMetrics["node-a"].usage
+	assert.Equal(t, usageObservedAt.Add(-45*time.Second), usage.timestamp)
+	assert.Equal(t, 20*time.Second, usage.window)
+	assert.Equal(t, "45s", usage.ageString(cm.observedAt))
+	assert.Equal(t, "45", usage.ageSecondsString(cm.observedAt))
+
+	assert.Nil(t, cm.nodeMetrics["node-b"].usage)
+	assert.Equal(t, VoidValue, cm.nodeMetrics["node-b"].usage.ageString(cm.observedAt))
+	assert.Equal(t, usage, cm.oldestUsage())
+}
+
+func TestBuildClusterMetricUsageFromPods(t *testing.T) {
+	podList := &corev1.PodList{Items: []corev1.Pod{
+		*podWithRequests("node-a", "default", "a", nil, corev1.ResourceList{"cpu": resource.MustParse("500m")}),
+		*podWithRequests("node-a", "default", "b", nil, corev1.ResourceList{"cpu": resource.MustParse("500m")}),
+		*podWithRequests("node-b", "default", "c", nil, corev1.ResourceList{"cpu": resource.MustParse("500m")}),
+	}}
+	nodeList := &corev1.NodeList{Items: []corev1.Node{
+		*nodeWithAllocatable("node-a", nil, corev1.ResourceList{"cpu": resource.MustParse("2")}),
+		*nodeWithAllocatable("node-b", nil, corev1.ResourceList{"cpu": resource.MustParse("2")}),
+	}}
+	pmList := &v1beta1.PodMetricsList{Items: []v1beta1.PodMetrics{
+		usagePodMetrics("a", time.Minute),
+		usagePodMetrics("b", 10*time.Minute),
+		usagePodMetrics("c", 30*time.Second),
+	}}
+
+	cm := buildClusterMetric(podList, pmList, nodeList, nil)
+	cm.observedAt = usageObservedAt
+
+	// The oldest pod on a node sets the age of its summed usage
+	assert.Equal(t, "10m", cm.nodeMetrics["node-a"].usage.ageString(cm.observedAt))
+	assert.Equal(t, "30s", cm.nodeMetrics["node-b"].usage.ageString(cm.observedAt))
+	assert.Equal(t, "10m", cm.oldestUsage().ageString(cm.observedAt))
+
+	assert.Equal(t, &listUsageSample{
+		Timestamp: "2026-01-01T11:50:00Z",
+		Window:    "30s",
+		Age:       "10m",
+	}, cm.oldestUsage().listUsageSample(cm.observedAt))
+}
+
+func TestWarnStaleUsage(t *testing.T) {
+	var buf bytes.Buffer
+	run := newTestRun(nil)
+	run.logOutput = &buf
+
+	cm := &clusterMetric{
+		observedAt: usageObservedAt,
+		nodeMetrics: map[string]*nodeMetric{
+			"node-c": {name: "node-c", usage: &usageSample{timestamp: usageObservedAt.Add(-20 * time.Minute)}},
+			"node-a": {name: "node-a", usage: &usageSample{timestamp: usageObservedAt.Add(-6 * time.Minute)}},
+			"node-b": {name: "node-b", usage: &usageSample{timestamp: usageObservedAt.Add(-time.Minute)}},
+			"node-d": {name: "node-d"},
+		},
+	}
+
+	warnStaleUsage(run, cm, 5*time.Minute)
+	assert.Equal(t, "Warning: usage is older than 5m0s for node-a (6m), node-c (20m); check that metrics are being collected\n", buf.String())
+
+	buf.Reset()
+	warnStaleUsage(run, cm, time.Hour)
+	assert.Empty(t, buf.String())
+
+	warnStaleUsage(run, cm, 0)
+	assert.Empty(t, buf.String())
+}
+
+func TestPrometheusEvalTime(t *testing.T) {
+	resp := &prometheusResponse{}
+	resp.Data.Result = append(resp.Data.Result, prometheusResult{Value: []interface{}{1767268800.5, "1"}})
+
+	assert.Equal(t, time.Unix(1767268800, 5e8), prometheusEvalTime(resp))
+}
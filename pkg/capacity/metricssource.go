@@ -0,0 +1,358 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	v1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+)
+
+const (
+	// MetricsServerSource reads utilization from the metrics.k8s.io API
+	MetricsServerSource = "metrics-server"
+	// PrometheusSource reads utilization from Prometheus
+	PrometheusSource = "prometheus"
+	// KubeletSource reads utilization from the resource metrics of each
+	// kubelet, or its summary API when resource metrics are not served
+	KubeletSource = "kubelet"
+	// DatadogSource reads utilization from the Datadog metrics API
+	DatadogSource = "datadog"
+	// CloudWatchSource reads utilization from CloudWatch Container Insights
+	CloudWatchSource = "cloudwatch"
+	// CustomMetricsSource reads utilization from the custom.metrics.k8s.io
+	// API, such as one served by prometheus-adapter
+	CustomMetricsSource = "custom-metrics"
+	// ExternalMetricsSource reads utilization from the
+	// external.metrics.k8s.io API
+	ExternalMetricsSource = "external-metrics"
+	// AutoSource tries metrics-server, then Prometheus, then the kubelets
+	AutoSource = "auto"
+)
+
+// SupportedMetricsSources returns the sources utilization can be read from
+func SupportedMetricsSources() []string {
+	return []string{MetricsServerSource, PrometheusSource, KubeletSource, DatadogSource, CloudWatchSource, CustomMetricsSource, ExternalMetricsSource, AutoSource}
+}
+
+const (
+	// AvgUsageAggregation averages usage over the window
+	AvgUsageAggregation = "avg"
+	// MaxUsageAggregation takes the highest usage in the window, to size
+	// for peaks rather than typical load
+	MaxUsageAggregation = "max"
+)
+
+// SupportedUsageAggregations returns how usage can be aggregated over the
+// window of --usage-window
+func SupportedUsageAggregations() []string {
+	return []string{AvgUsageAggregation, MaxUsageAggregation}
+}
+
+// WindowedMetricsSources returns the sources that keep a history of usage,
+// which --usage-aggregation and --usage-window apply to
+func WindowedMetricsSources() []string {
+	return []string{PrometheusSource, DatadogSource, CloudWatchSource}
+}
+
+// PromQLDuration formats d as a PromQL duration, such as 24h or 90s
+func PromQLDuration(d time.Duration) string {
+	switch {
+	case d%time.Hour == 0:
+		return fmt.Sprintf("%dh", d/time.Hour)
+	case d%time.Minute == 0:
+		return fmt.Sprintf("%dm", d/time.Minute)
+	default:
+		return fmt.Sprintf("%ds", d/time.Second)
+	}
+}
+
+// kubeletConcurrency is how many kubelets are read at the same time
+const kubeletConcurrency = 16
+
+// getAutoMetrics reads utilization from the first source that works, in the
+// order metrics-server, Prometheus, kubelet
+func getAutoMetrics(ctx context.Context, run *reportRun, clientset kubernetes.Interface, opts Options, withNodeMetrics bool) (*v1beta1.PodMetricsList, *v1beta1.NodeMetricsList, *fetchError) {
+	failures := []string{}
+
+	mClientset, err := newMetricsClientSet(opts)
+	if err != nil {
+		failures = append(failures, fmt.Sprintf("%s: %v", MetricsServerSource, err))
+	} else {
+		pmList, ferr := getPodMetrics(ctx, run, mClientset, opts.Namespace)
+		var nmList *v1beta1.NodeMetricsList
+		if ferr == nil && withNodeMetrics {
+			nmList, ferr = getNodeMetrics(ctx, run, mClientset, opts.NodeLabels)
+		}
+		if ferr == nil {
+			run.logInfof("Using %s for utilization", MetricsServerSource)
+			return pmList, nmList, nil
+		}
+		failures = append(failures, fmt.Sprintf("%s: %s", MetricsServerSource, ferr.messages[0]))
+	}
+	run.logDebugf("%s", failures[len(failures)-1])
+	if ctx.Err() != nil {
+		return nil, nil, newFetchError(4, "Error getting metrics: %v", ctx.Err())
+	}
+
+	pmList, nmList, err := getPrometheusMetrics(run, clientset, opts)
+	if err == nil {
+		run.logInfof("Using %s for utilization", PrometheusSource)
+		if !withNodeMetrics {
+			nmList = nil
+		}
+		return pmList, nmList, nil
+	}
+	failures = append(failures, fmt.Sprintf("%s: %v", PrometheusSource, err))
+	run.logDebugf("%s", failures[len(failures)-1])
+	if ctx.Err() != nil {
+		return nil, nil, newFetchError(4, "Error getting metrics: %v", ctx.Err())
+	}
+
+	pmList, nmList, err = getKubeletMetrics(ctx, run, clientset, opts.Namespace, opts.NodeLabels)
+	if err == nil {
+		run.logInfof("Using %s for utilization", KubeletSource)
+		if !withNodeMetrics {
+			nmList = nil
+		}
+		return pmList, nmList, nil
+	}
+	failures = append(failures, fmt.Sprintf("%s: %v", KubeletSource, err))
+
+	ferr := newFetchError(4, "Error getting metrics: no metrics source is available")
+	for _, failure := range failures {
+		ferr.withHint("  " + failure)
+	}
+	return nil, nil, ferr
+}
+
+// kubeletSummary is the part of the kubelet summary API response that
+// utilization and node filesystems are read from
+type kubeletSummary struct {
+	Node kubeletNodeStats  `json:"node"`
+	Pods []kubeletPodStats `json:"pods"`
+}
+
+type kubeletNodeStats struct {
+	CPU     *kubeletCPUStats    `json:"cpu"`
+	Memory  *kubeletMemoryStats `json:"memory"`
+	Fs      *kubeletFsStats     `json:"fs"`
+	Runtime *struct {
+		ImageFs *kubeletFsStats `json:"imageFs"`
+	} `json:"runtime"`
+}
+
+type kubeletPodStats struct {
+	PodRef struct {
+		Name      string `json:"name"`
+		Namespace string `json:"namespace"`
+	} `json:"podRef"`
+	Containers []kubeletContainerStats `json:"containers"`
+}
+
+type kubeletContainerStats struct {
+	Name   string              `json:"name"`
+	CPU    *kubeletCPUStats    `json:"cpu"`
+	Memory *kubeletMemoryStats `json:"memory"`
+}
+
+type kubeletCPUStats struct {
+	Time           metav1.Time `json:"time"`
+	UsageNanoCores *uint64     `json:"usageNanoCores"`
+}
+
+type kubeletMemoryStats struct {
+	Time            metav1.Time `json:"time"`
+	WorkingSetBytes *uint64     `json:"workingSetBytes"`
+}
+
+// kubeletNodeUsage is the utilization read from the kubelet of one node
+type kubeletNodeUsage struct {
+	node v1beta1.NodeMetrics
+	pods []v1beta1.PodMetrics
+}
+
+// getKubeletMetrics reads the utilization of every node from its kubelet
+// through the API server's node proxy, which needs permission to get
+// nodes/proxy. Nodes whose kubelet can not be read are left without
+// utilization.
+func getKubeletMetrics(ctx context.Context, run *reportRun, clientset kubernetes.Interface, namespace, nodeLabels string) (*v1beta1.PodMetricsList, *v1beta1.NodeMetricsList, error) {
+	start := time.Now()
+	nodeList, err := listAllNodes(ctx, run, clientset, metav1.ListOptions{LabelSelector: nodeLabels})
+	if err != nil {
+		return nil, nil, fmt.Errorf("listing Nodes: %w", err)
+	}
+
+	usages := make([]*kubeletNodeUsage, len(nodeList.Items))
+	errs := make([]error, len(nodeList.Items))
+	sem := make(chan struct{}, kubeletConcurrency)
+	var wg sync.WaitGroup
+	for i := range nodeList.Items {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			usages[i], errs[i] = getKubeletNodeUsage(ctx, run, clientset, nodeList.Items[i].Name, sem)
+		}(i)
+	}
+	wg.Wait()
+
+	pmList := &v1beta1.PodMetricsList{}
+	nmList := &v1beta1.NodeMetricsList{}
+	failed := []string{}
+	for i, usage := range usages {
+		nodeName := nodeList.Items[i].Name
+		if errs[i] != nil {
+			run.logDebugf("Error reading kubelet on %s: %v", nodeName, errs[i])
+			failed = append(failed, nodeName)
+			continue
+		}
+		nmList.Items = append(nmList.Items, usage.node)
+		for _, pm := range usage.pods {
+			if namespace == "" || pm.Namespace == namespace {
+				pmList.Items = append(pmList.Items, pm)
+			}
+		}
+	}
+
+	if len(failed) > 0 && len(failed) == len(nodeList.Items) {
+		return nil, nil, fmt.Errorf("reading kubelet on %s: %w", failed[0], errs[0])
+	}
+	if len(failed) > 0 {
+		sort.Strings(failed)
+		run.logWarnf("could not read the kubelet on %s, their utilization is left out", strings.Join(failed, ", "))
+	}
+	run.logTiming(start, "Read %d pod metrics from %d kubelets", len(pmList.Items), len(nmList.Items))
+
+	return pmList, nmList, nil
+}
+
+// getKubeletNodeUsage scrapes the resource metrics of a kubelet twice,
+// kubeletRateWindow apart, to calculate CPU usage. Kubelets that do not
+// serve resource metrics are read through the summary API instead. sem is
+// only held while a kubelet is being read so that every node waits out the
+// window at the same time.
+func getKubeletNodeUsage(ctx context.Context, run *reportRun, clientset kubernetes.Interface, nodeName string, sem chan struct{}) (*kubeletNodeUsage, error) {
+	sem <- struct{}{}
+	first, err := scrapeKubeletResourceMetrics(ctx, clientset, nodeName)
+	if apierrors.IsNotFound(err) {
+		run.logDebugf("Kubelet on %s does not serve resource metrics, reading its summary API", nodeName)
+		summary, err := getKubeletSummary(ctx, clientset, nodeName)
+		<-sem
+		if err != nil {
+			return nil, err
+		}
+		return summary.usage(nodeName), nil
+	}
+	<-sem
+	if err != nil {
+		return nil, err
+	}
+
+	select {
+	case <-time.After(kubeletRateWindow):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	sem <- struct{}{}
+	second, err := scrapeKubeletResourceMetrics(ctx, clientset, nodeName)
+	<-sem
+	if err != nil {
+		return nil, err
+	}
+	return kubeletResourceUsage(nodeName, first, second), nil
+}
+
+func getKubeletSummary(ctx context.Context, clientset kubernetes.Interface, nodeName string) (*kubeletSummary, error) {
+	body, err := clientset.CoreV1().RESTClient().Get().
+		Resource("nodes").
+		Name(nodeName).
+		SubResource("proxy").
+		Suffix("stats", "summary").
+		DoRaw(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &kubeletSummary{}
+	if err := json.Unmarshal(body, summary); err != nil {
+		return nil, fmt.Errorf("parsing summary: %w", err)
+	}
+	return summary, nil
+}
+
+func (s *kubeletSummary) usage(nodeName string) *kubeletNodeUsage {
+	usage := &kubeletNodeUsage{node: s.nodeMetrics(nodeName)}
+	for _, pod := range s.Pods {
+		usage.pods = append(usage.pods, pod.podMetrics())
+	}
+	return usage
+}
+
+func (s *kubeletSummary) nodeMetrics(nodeName string) v1beta1.NodeMetrics {
+	usage, timestamp := kubeletUsage(s.Node.CPU, s.Node.Memory)
+	return v1beta1.NodeMetrics{
+		ObjectMeta: metav1.ObjectMeta{Name: nodeName},
+		Timestamp:  timestamp,
+		Usage:      usage,
+	}
+}
+
+func (p *kubeletPodStats) podMetrics() v1beta1.PodMetrics {
+	pm := v1beta1.PodMetrics{
+		ObjectMeta: metav1.ObjectMeta{Name: p.PodRef.Name, Namespace: p.PodRef.Namespace},
+	}
+	for _, container := range p.Containers {
+		usage, timestamp := kubeletUsage(container.CPU, container.Memory)
+		// The oldest container sets the timestamp of the pod
+		if !timestamp.IsZero() && (pm.Timestamp.IsZero() || timestamp.Before(&pm.Timestamp)) {
+			pm.Timestamp = timestamp
+		}
+		pm.Containers = append(pm.Containers, v1beta1.ContainerMetrics{
+			Name:  container.Name,
+			Usage: usage,
+		})
+	}
+	return pm
+}
+
+// kubeletUsage converts kubelet stats to the usage metrics-server reports,
+// CPU in cores and memory as the working set
+func kubeletUsage(cpu *kubeletCPUStats, memory *kubeletMemoryStats) (corev1.ResourceList, metav1.Time) {
+	usage := corev1.ResourceList{}
+	var timestamp metav1.Time
+	if cpu != nil && cpu.UsageNanoCores != nil {
+		usage[corev1.ResourceCPU] = *resource.NewMilliQuantity(int64(*cpu.UsageNanoCores/1e6), resource.DecimalSI)
+		timestamp = cpu.Time
+	}
+	if memory != nil && memory.WorkingSetBytes != nil {
+		usage[corev1.ResourceMemory] = *resource.NewQuantity(int64(*memory.WorkingSetBytes), resource.BinarySI)
+		if timestamp.IsZero() || (!memory.Time.IsZero() && memory.Time.Before(&timestamp)) {
+			timestamp = memory.Time
+		}
+	}
+	return usage, timestamp
+}
@@ -0,0 +1,87 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testKubeletSummary = `{
+  "node": {
+    "nodeName": "node-a",
+    "cpu": {"time": "2026-01-01T12:00:10Z", "usageNanoCores": 1250000000, "usageCoreNanoSeconds": 99},
+    "memory": {"time": "2026-01-01T12:00:05Z", "workingSetBytes": 2147483648, "usageBytes": 4294967296}
+  },
+  "pods": [{
+    "podRef": {"name": "web", "namespace": "default", "uid": "1"},
+    "containers": [
+      {
+        "name": "app",
+        "cpu": {"time": "2026-01-01T12:00:08Z", "usageNanoCores": 250000000},
+        "memory": {"time": "2026-01-01T12:00:08Z", "workingSetBytes": 104857600}
+      },
+      {
+        "name": "sidecar",
+        "cpu": {"time": "2026-01-01T12:00:02Z", "usageNanoCores": 1500000},
+        "memory": {"time": "2026-01-01T12:00:02Z"}
+      }
+    ]
+  }]
+}`
+
+func TestPromQLDuration(t *testing.T) {
+	assert.Equal(t, "24h", PromQLDuration(24*time.Hour))
+	assert.Equal(t, "90m", PromQLDuration(90*time.Minute))
+	assert.Equal(t, "45s", PromQLDuration(45*time.Second))
+}
+
+func TestKubeletSummaryMetrics(t *testing.T) {
+	summary := &kubeletSummary{}
+	require.NoError(t, json.Unmarshal([]byte(testKubeletSummary), summary))
+
+	nm := summary.nodeMetrics("node-a")
+	assert.Equal(t, "node-a", nm.Name)
+	assert.Equal(t, "1250m", nm.Usage.Cpu().String())
+	assert.Equal(t, "2Gi", nm.Usage.Memory().String())
+	// The older of the CPU and memory samples is used
+	assert.Equal(t, time.Date(2026, 1, 1, 12, 0, 5, 0, time.UTC), nm.Timestamp.UTC())
+
+	require.Len(t, summary.Pods, 1)
+	pm := summary.Pods[0].podMetrics()
+	assert.Equal(t, "default", pm.Namespace)
+	assert.Equal(t, "web", pm.Name)
+	assert.Equal(t, time.Date(2026, 1, 1, 12, 0, 2, 0, time.UTC), pm.Timestamp.UTC())
+
+	require.Len(t, pm.Containers, 2)
+	assert.Equal(t, "app", pm.Containers[0].Name)
+	assert.Equal(t, "250m", pm.Containers[0].Usage.Cpu().String())
+	assert.Equal(t, "100Mi", pm.Containers[0].Usage.Memory().String())
+
+	// A container without a working set only reports CPU
+	assert.Equal(t, "1m", pm.Containers[1].Usage.Cpu().String())
+	_, ok := pm.Containers[1].Usage["memory"]
+	assert.False(t, ok)
+}
+
+func TestKubeletUsageEmpty(t *testing.T) {
+	usage, timestamp := kubeletUsage(nil, nil)
+	assert.Empty(t, usage)
+	assert.True(t, timestamp.IsZero())
+}
@@ -0,0 +1,243 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// missingResources counts the pods of a workload with a container that
+// sets no CPU or memory request or no CPU or memory limit, and how many
+// containers miss each of them
+type missingResources struct {
+	namespace      string
+	workload       string
+	pods           int
+	containers     int
+	cpuRequests    int
+	memoryRequests int
+	cpuLimits      int
+	memoryLimits   int
+}
+
+type listMissingResources struct {
+	Workloads []*listMissingResourcesItem `json:"workloads"`
+	Totals    *listMissingResourcesItem   `json:"totals"`
+}
+
+type listMissingResourcesItem struct {
+	Namespace   string             `json:"namespace,omitempty"`
+	Workload    string             `json:"workload,omitempty"`
+	Pods        int                `json:"pods"`
+	ClusterPods int                `json:"clusterPods,omitempty"`
+	PodsPercent string             `json:"podsPercent"`
+	Containers  int                `json:"containers"`
+	Missing     *listMissingCounts `json:"missing"`
+}
+
+type listMissingCounts struct {
+	CPURequests    int `json:"cpuRequests"`
+	MemoryRequests int `json:"memoryRequests"`
+	CPULimits      int `json:"cpuLimits"`
+	MemoryLimits   int `json:"memoryLimits"`
+}
+
+// workloadName names the workload controlling a pod as Kind/name, with
+// pods that have no controller named on their own
+func (pm *podMetric) workloadName() string {
+	if pm.controllerKind == "" {
+		return "Pod/" + pm.name
+	}
+	return pm.controllerKind + "/" + pm.controllerName
+}
+
+// buildMissingResources groups the pods with a container missing a request
+// or limit by namespace and workload, most affected pods first. It returns
+// the workloads, their totals, and the number of pods in the cluster.
+func buildMissingResources(cm *clusterMetric) ([]*missingResources, *missingResources, int) {
+	byWorkload := map[string]*missingResources{}
+	total := &missingResources{namespace: VoidValue, workload: VoidValue}
+	clusterPods := 0
+	for _, nm := range cm.nodeMetrics {
+		for _, pm := range nm.podMetrics {
+			clusterPods++
+			counts := &missingResources{}
+			for _, c := range pm.containerMetrics {
+				missing := []*int{}
+				if c.cpu.request.IsZero() {
+					missing = append(missing, &counts.cpuRequests)
+				}
+				if c.memory.request.IsZero() {
+					missing = append(missing, &counts.memoryRequests)
+				}
+				if c.cpu.limit.IsZero() {
+					missing = append(missing, &counts.cpuLimits)
+				}
+				if c.memory.limit.IsZero() {
+					missing = append(missing, &counts.memoryLimits)
+				}
+				for _, count := range missing {
+					*count++
+				}
+				if len(missing) > 0 {
+					counts.containers++
+				}
+			}
+			if counts.containers == 0 {
+				continue
+			}
+			counts.pods = 1
+
+			key := pm.namespace + "/" + pm.workloadName()
+			mr, ok := byWorkload[key]
+			if !ok {
+				mr = &missingResources{namespace: pm.namespace, workload: pm.workloadName()}
+				byWorkload[key] = mr
+			}
+			mr.add(counts)
+			total.add(counts)
+		}
+	}
+
+	workloads := []*missingResources{}
+	for _, mr := range byWorkload {
+		workloads = append(workloads, mr)
+	}
+	sort.Slice(workloads, func(i, j int) bool {
+		a, b := workloads[i], workloads[j]
+		if a.pods != b.pods {
+			return a.pods > b.pods
+		}
+		if a.containers != b.containers {
+			return a.containers > b.containers
+		}
+		if a.namespace != b.namespace {
+			return a.namespace < b.namespace
+		}
+		return a.workload < b.workload
+	})
+	return workloads, total, clusterPods
+}
+
+func (mr *missingResources) add(other *missingResources) {
+	mr.pods += other.pods
+	mr.containers += other.containers
+	mr.cpuRequests += other.cpuRequests
+	mr.memoryRequests += other.memoryRequests
+	mr.cpuLimits += other.cpuLimits
+	mr.memoryLimits += other.memoryLimits
+}
+
+func printMissingResources(run *reportRun, cm *clusterMetric, opts Options) error {
+	workloads, total, clusterPods := buildMissingResources(cm)
+	mp := &missingResourcesPrinter{run: run, workloads: workloads, total: total, clusterPods: clusterPods}
+	return mp.Print(opts.OutputFormat)
+}
+
+type missingResourcesPrinter struct {
+	run         *reportRun
+	workloads   []*missingResources
+	total       *missingResources
+	clusterPods int
+}
+
+func (mp *missingResourcesPrinter) Print(outputType string) error {
+	switch outputType {
+	case JSONOutput, YAMLOutput:
+		printListOutput(mp.run, MissingResourcesReportKind, mp.buildListMissingResources(), outputType)
+	case TableOutput:
+		w := tabwriter.NewWriter(mp.run.output, 0, 8, 2, ' ', 0)
+		mp.printTable(w, "\t ")
+		if err := w.Flush(); err != nil {
+			mp.run.logErrorf("Error writing to table: %s", err)
+		}
+	case CSVOutput:
+		mp.printTable(mp.run.output, ",")
+	case TSVOutput:
+		mp.printTable(mp.run.output, "\t")
+	default:
+		return fmt.Errorf("Called with an unsupported output type: %s", outputType)
+	}
+	return nil
+}
+
+// printTable prints the totals first, with the pods affected out of every
+// pod in the cluster, followed by a row for each workload
+func (mp *missingResourcesPrinter) printTable(w io.Writer, separator string) {
+	_, _ = fmt.Fprintln(w, strings.Join([]string{
+		"NAMESPACE", "WORKLOAD", "PODS", "CONTAINERS",
+		"NO CPU REQUEST", "NO MEMORY REQUEST", "NO CPU LIMIT", "NO MEMORY LIMIT",
+	}, separator))
+
+	list := mp.buildListMissingResources()
+	totals := list.Totals
+	_, _ = fmt.Fprintln(w, strings.Join([]string{
+		VoidValue,
+		fmt.Sprintf("%s (%d of %d pods)", VoidValue, totals.Pods, totals.ClusterPods),
+		fmt.Sprintf("%d (%s)", totals.Pods, totals.PodsPercent),
+		fmt.Sprintf("%d", totals.Containers),
+		fmt.Sprintf("%d", totals.Missing.CPURequests),
+		fmt.Sprintf("%d", totals.Missing.MemoryRequests),
+		fmt.Sprintf("%d", totals.Missing.CPULimits),
+		fmt.Sprintf("%d", totals.Missing.MemoryLimits),
+	}, separator))
+	for _, lw := range list.Workloads {
+		_, _ = fmt.Fprintln(w, strings.Join([]string{
+			lw.Namespace,
+			lw.Workload,
+			fmt.Sprintf("%d (%s)", lw.Pods, lw.PodsPercent),
+			fmt.Sprintf("%d", lw.Containers),
+			fmt.Sprintf("%d", lw.Missing.CPURequests),
+			fmt.Sprintf("%d", lw.Missing.MemoryRequests),
+			fmt.Sprintf("%d", lw.Missing.CPULimits),
+			fmt.Sprintf("%d", lw.Missing.MemoryLimits),
+		}, separator))
+	}
+}
+
+func (mp *missingResourcesPrinter) buildListMissingResources() *listMissingResources {
+	out := &listMissingResources{Workloads: []*listMissingResourcesItem{}}
+	for _, mr := range mp.workloads {
+		out.Workloads = append(out.Workloads, mr.listMissingResourcesItem(mp.clusterPods))
+	}
+	out.Totals = mp.total.listMissingResourcesItem(mp.clusterPods)
+	out.Totals.Namespace, out.Totals.Workload = "", ""
+	out.Totals.ClusterPods = mp.clusterPods
+	return out
+}
+
+func (mr *missingResources) listMissingResourcesItem(clusterPods int) *listMissingResourcesItem {
+	percent := 0
+	if clusterPods > 0 {
+		percent = int(float64(mr.pods) * 100 / float64(clusterPods))
+	}
+	return &listMissingResourcesItem{
+		Namespace:   mr.namespace,
+		Workload:    mr.workload,
+		Pods:        mr.pods,
+		PodsPercent: fmt.Sprintf("%d%%", percent),
+		Containers:  mr.containers,
+		Missing: &listMissingCounts{
+			CPURequests:    mr.cpuRequests,
+			MemoryRequests: mr.memoryRequests,
+			CPULimits:      mr.cpuLimits,
+			MemoryLimits:   mr.memoryLimits,
+		},
+	}
+}
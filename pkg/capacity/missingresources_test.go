@@ -0,0 +1,74 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestMissingResources(t *testing.T) {
+	run := newTestRun(nil)
+	full := corev1.ResourceList{
+		"cpu":    resource.MustParse("100m"),
+		"memory": resource.MustParse("128Mi"),
+	}
+	controlled := func(node, name, kind, owner string, containers ...corev1.Container) corev1.Pod {
+		controller := true
+		p := pod(node, "default", name, map[string]string{"pod-template-hash": "5f7b9c"})
+		p.OwnerReferences = []metav1.OwnerReference{{Kind: kind, Name: owner, Controller: &controller}}
+		p.Spec.Containers = containers
+		return *p
+	}
+	proxy := corev1.Container{Name: "proxy", Resources: corev1.ResourceRequirements{Requests: full, Limits: full}}
+
+	// mypod and mypod2 set requests without limits
+	snap := getTestSnapshot()
+	snap.Pods.Items = append(snap.Pods.Items,
+		controlled("mynode", "web-5f7b9c-a", "ReplicaSet", "web-5f7b9c", corev1.Container{Name: "app"}, proxy),
+		controlled("mynode2", "web-5f7b9c-b", "ReplicaSet", "web-5f7b9c", corev1.Container{Name: "app"}, proxy),
+		controlled("mynode", "db-0", "StatefulSet", "db", proxy),
+	)
+	cm := buildClusterMetric(snap.Pods, nil, snap.Nodes, nil)
+
+	workloads, total, clusterPods := buildMissingResources(&cm)
+	require.Len(t, workloads, 3)
+	assert.Equal(t, "Deployment/web", workloads[0].workload)
+	assert.Equal(t, 5, clusterPods)
+	assert.Equal(t, 4, total.pods)
+
+	var buf bytes.Buffer
+	mp := &missingResourcesPrinter{run: run, workloads: workloads, total: total, clusterPods: clusterPods}
+	mp.printTable(&buf, ",")
+	assert.Equal(t, strings.Join([]string{
+		"NAMESPACE,WORKLOAD,PODS,CONTAINERS,NO CPU REQUEST,NO MEMORY REQUEST,NO CPU LIMIT,NO MEMORY LIMIT",
+		"*,* (4 of 5 pods),4 (80%),4,2,2,4,4",
+		"default,Deployment/web,2 (40%),2,2,2,2,2",
+		"default,Pod/mypod,1 (20%),1,0,0,1,1",
+		"other,Pod/mypod2,1 (20%),1,0,0,1,1",
+	}, "\n")+"\n", buf.String())
+
+	list := mp.buildListMissingResources()
+	assert.Equal(t, 5, list.Totals.ClusterPods)
+	assert.Empty(t, list.Totals.Workload)
+	assert.Equal(t, 2, list.Workloads[0].Missing.CPURequests)
+}
@@ -0,0 +1,310 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const (
+	// AllocateCostByRequests attributes the cost of a node to the pods on it
+	// by their requests
+	AllocateCostByRequests = "requests"
+	// AllocateCostByUsage attributes the cost of a node to the pods on it by
+	// their utilization
+	AllocateCostByUsage = "usage"
+)
+
+// SupportedCostAllocations returns the values of --allocate-by
+func SupportedCostAllocations() []string {
+	return []string{AllocateCostByRequests, AllocateCostByUsage}
+}
+
+// idleNamespaceName labels the cost of nodes that is not attributed to any
+// namespace
+const idleNamespaceName = "<idle>"
+
+// namespaceCost is the share of the cost of the cluster attributed to a
+// namespace or other group of pods, along with the CPU and memory it was
+// attributed by
+type namespaceCost struct {
+	name     string
+	podCount int64
+	cpu      resource.Quantity
+	memory   resource.Quantity
+	hourly   float64
+}
+
+// listPodGroupCost is the cost attributed to a group of pods and its share
+// of the cost of the cluster
+type listPodGroupCost struct {
+	Hourly  string `json:"hourly"`
+	Monthly string `json:"monthly"`
+	Share   string `json:"share"`
+}
+
+type listNamespaceCosts struct {
+	ClusterTotals *listNamespaceCost   `json:"clusterTotals"`
+	Namespaces    []*listNamespaceCost `json:"namespaces"`
+	Idle          *listNamespaceCost   `json:"idle,omitempty"`
+}
+
+type listNamespaceCost struct {
+	Name    string `json:"name,omitempty"`
+	Pods    int64  `json:"pods"`
+	CPU     string `json:"cpu,omitempty"`
+	Memory  string `json:"memory,omitempty"`
+	Hourly  string `json:"hourly"`
+	Monthly string `json:"monthly"`
+	Share   string `json:"share"`
+}
+
+// FetchAndPrintNamespaceCosts gathers cluster resource data and prints the
+// cost of the cluster attributed to each namespace
+func FetchAndPrintNamespaceCosts(opts Options) error {
+	run := newRun(opts)
+
+	opts.ShowCost = true
+	opts.ShowUtil = opts.CostAllocation == AllocateCostByUsage
+	opts.ShowQuotas = false
+	opts.ShowVPA = false
+	opts.ShowHeadroom = false
+	opts.ShowKarpenter = false
+	opts.ShowCapacityType = false
+	opts.ShowArch = false
+	opts.ShowZoneBalance = false
+	opts.ShowGPU = false
+	opts.SimulateRemoveNodes = ""
+	opts.ShowHPAHeadroom = false
+	opts.AllocateByPodLabel = ""
+	opts.ShowPending = false
+	opts.ShowDaemonSetOverhead = false
+	opts.ShowAnomalies = false
+	opts.ShowStuckPods = false
+	opts.Preemption = ""
+	opts.ShowMissingResources = false
+	opts.Stream = false
+	cm, ferr := fetchClusterMetric(run, opts)
+	if ferr != nil {
+		return ferr
+	}
+
+	ncp := &namespaceCostPrinter{run: run, opts: opts}
+	ncp.totals, ncp.namespaces, ncp.idle = buildNamespaceCosts(&cm, opts.CostAllocation, showIdleCost(opts))
+	if err := ncp.Print(opts.OutputFormat); err != nil {
+		return err
+	}
+	return run.closeOutput()
+}
+
+// showIdleCost is false when pods are filtered, since the cost of the pods
+// left out would otherwise be counted as idle
+func showIdleCost(opts Options) bool {
+	return opts.Namespace == "" && opts.NamespaceLabels == "" && opts.PodLabels == "" && opts.PodFieldSelector == ""
+}
+
+// buildNamespaceCosts attributes the cost of the cluster to namespaces,
+// returned by cost, highest first
+func buildNamespaceCosts(cm *clusterMetric, allocateBy string, withIdle bool) (*namespaceCost, []*namespaceCost, *namespaceCost) {
+	totals, namespaces, idle := buildPodGroupCosts(cm, allocateBy, withIdle, func(pm *podMetric) string {
+		return pm.namespace
+	})
+	sort.Slice(namespaces, func(i, j int) bool {
+		if namespaces[i].hourly != namespaces[j].hourly {
+			return namespaces[i].hourly > namespaces[j].hourly
+		}
+		return namespaces[i].name < namespaces[j].name
+	})
+	return totals, namespaces, idle
+}
+
+// buildPodGroupCosts splits the price of each priced node evenly between CPU
+// and memory, as newNodeCost does, and attributes each half to the pods on
+// the node by their share of its allocatable. Pods are grouped by the name
+// key returns for each. What no pod is attributed is idle, which is nil when
+// withIdle is false.
+func buildPodGroupCosts(cm *clusterMetric, allocateBy string, withIdle bool, key func(pm *podMetric) string) (*namespaceCost, []*namespaceCost, *namespaceCost) {
+	totals := &namespaceCost{}
+	byName := map[string]*namespaceCost{}
+	for _, nm := range cm.nodeMetrics {
+		if nm.cost == nil {
+			continue
+		}
+		totals.hourly += nm.cost.hourly
+
+		shares := map[*podMetric]float64{}
+		nodeShare := 0.0
+		for _, pm := range nm.podMetrics {
+			cpu, memory := costBasis(pm, allocateBy)
+			shares[pm] = (allocatableFraction(cpu, nm.cpu) + allocatableFraction(memory, nm.memory)) / 2
+			nodeShare += shares[pm]
+		}
+		// Usage can add up to more than allocatable, and a node can not be
+		// attributed more than it costs
+		scale := 1.0
+		if nodeShare > 1 {
+			scale = 1 / nodeShare
+		}
+
+		for pm, share := range shares {
+			name := key(pm)
+			nc, ok := byName[name]
+			if !ok {
+				nc = &namespaceCost{name: name}
+				byName[name] = nc
+			}
+			cpu, memory := costBasis(pm, allocateBy)
+			hourly := nm.cost.hourly * share * scale
+			for _, c := range []*namespaceCost{totals, nc} {
+				c.podCount++
+				c.cpu.Add(cpu)
+				c.memory.Add(memory)
+				c.hourly += hourly
+			}
+		}
+	}
+
+	groups := []*namespaceCost{}
+	attributed := 0.0
+	for _, nc := range byName {
+		groups = append(groups, nc)
+		attributed += nc.hourly
+	}
+
+	var idle *namespaceCost
+	if withIdle {
+		idle = &namespaceCost{name: idleNamespaceName, hourly: math.Max(totals.hourly-attributed, 0)}
+	} else {
+		totals.hourly = attributed
+	}
+	return totals, groups, idle
+}
+
+// costBasis returns the CPU and memory of a pod that its share of the cost
+// of its node is attributed by
+func costBasis(pm *podMetric, allocateBy string) (resource.Quantity, resource.Quantity) {
+	if allocateBy == AllocateCostByUsage {
+		return pm.cpu.utilization, pm.memory.utilization
+	}
+	return pm.cpu.request, pm.memory.request
+}
+
+func allocatableFraction(q resource.Quantity, rm *resourceMetric) float64 {
+	if rm.allocatable.MilliValue() == 0 {
+		return 0
+	}
+	return float64(q.MilliValue()) / float64(rm.allocatable.MilliValue())
+}
+
+type namespaceCostPrinter struct {
+	run        *reportRun
+	totals     *namespaceCost
+	namespaces []*namespaceCost
+	idle       *namespaceCost
+	opts       Options
+}
+
+func (ncp *namespaceCostPrinter) Print(outputType string) error {
+	switch outputType {
+	case JSONOutput, YAMLOutput:
+		printListOutput(ncp.run, NamespaceCostReportKind, ncp.buildListNamespaceCosts(), outputType)
+	case TableOutput:
+		w := tabwriter.NewWriter(ncp.run.output, 0, 8, 2, ' ', 0)
+		ncp.printTable(w, "\t ")
+		if err := w.Flush(); err != nil {
+			ncp.run.logErrorf("Error writing to table: %s", err)
+		}
+	case CSVOutput:
+		ncp.printTable(ncp.run.output, ",")
+	case TSVOutput:
+		ncp.printTable(ncp.run.output, "\t")
+	default:
+		return fmt.Errorf("Called with an unsupported output type: %s", outputType)
+	}
+	return nil
+}
+
+func (ncp *namespaceCostPrinter) printTable(w io.Writer, separator string) {
+	basis := "REQUESTS"
+	if ncp.opts.CostAllocation == AllocateCostByUsage {
+		basis = "UTIL"
+	}
+	headers := []string{"NAMESPACE", "PODS", "CPU " + basis, "MEMORY " + basis, "COST/HOUR", "COST/MONTH", "SHARE"}
+	_, _ = fmt.Fprintln(w, strings.Join(headers, separator))
+
+	list := ncp.buildListNamespaceCosts()
+	list.ClusterTotals.Name = VoidValue
+	rows := append([]*listNamespaceCost{list.ClusterTotals}, list.Namespaces...)
+	if list.Idle != nil {
+		rows = append(rows, list.Idle)
+	}
+	for _, lc := range rows {
+		pods := fmt.Sprintf("%d", lc.Pods)
+		cpu, memory := lc.CPU, lc.Memory
+		if lc == list.Idle {
+			pods, cpu, memory = VoidValue, VoidValue, VoidValue
+		}
+		items := []string{lc.Name, pods, cpu, memory, lc.Hourly, lc.Monthly, lc.Share}
+		_, _ = fmt.Fprintln(w, strings.Join(items, separator))
+	}
+}
+
+func (ncp *namespaceCostPrinter) buildListNamespaceCosts() *listNamespaceCosts {
+	out := &listNamespaceCosts{
+		ClusterTotals: ncp.listNamespaceCost(ncp.totals),
+		Namespaces:    []*listNamespaceCost{},
+	}
+	for _, nc := range ncp.namespaces {
+		out.Namespaces = append(out.Namespaces, ncp.listNamespaceCost(nc))
+	}
+	if ncp.idle != nil {
+		out.Idle = ncp.listNamespaceCost(ncp.idle)
+		out.Idle.CPU, out.Idle.Memory = "", ""
+	}
+	return out
+}
+
+func (ncp *namespaceCostPrinter) listNamespaceCost(nc *namespaceCost) *listNamespaceCost {
+	cost := newListPodGroupCost(nc.hourly, ncp.totals.hourly)
+	return &listNamespaceCost{
+		Name:    nc.name,
+		Pods:    nc.podCount,
+		CPU:     ncp.run.formatQuantity("cpu", nc.cpu),
+		Memory:  ncp.run.formatQuantity("memory", nc.memory),
+		Hourly:  cost.Hourly,
+		Monthly: cost.Monthly,
+		Share:   cost.Share,
+	}
+}
+
+func newListPodGroupCost(hourly, totalHourly float64) *listPodGroupCost {
+	share := 0.0
+	if totalHourly > 0 {
+		share = hourly / totalHourly * 100
+	}
+	return &listPodGroupCost{
+		Hourly:  strconv.FormatFloat(hourly, 'f', 3, 64),
+		Monthly: strconv.FormatFloat(hourly*hoursPerMonth, 'f', 2, 64),
+		Share:   strconv.FormatFloat(share, 'f', 1, 64) + "%",
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func getTestCostClusterMetric(t *testing.T) clusterMetric {
+	snap := getTestSnapshot()
+	for i := range snap.Nodes.Items {
+		snap.Nodes.Items[i].Labels["node.kubernetes.io/instance-type"] = "m5.large"
+	}
+	cm := buildClusterMetric(snap.Pods, snap.PodMetrics, snap.Nodes, snap.NodeMetrics)
+	require.Empty(t, cm.addCosts(map[string]float64{"m5.large": 1}))
+	return cm
+}
+
+func TestNamespaceCostsByRequests(t *testing.T) {
+	run := newTestRun(nil)
+	cm := getTestCostClusterMetric(t)
+
+	ncp := &namespaceCostPrinter{run: run, opts: Options{CostAllocation: AllocateCostByRequests}}
+	ncp.totals, ncp.namespaces, ncp.idle = buildNamespaceCosts(&cm, AllocateCostByRequests, true)
+	require.Len(t, ncp.namespaces, 2)
+	require.NotNil(t, ncp.idle)
+
+	var buf bytes.Buffer
+	ncp.printTable(&buf, ",")
+	assert.Equal(t, strings.Join([]string{
+		"NAMESPACE,PODS,CPU REQUESTS,MEMORY REQUESTS,COST/HOUR,COST/MONTH,SHARE",
+		"*,2,200m,256Mi,2.000,1460.00,100.0%",
+		"default,1,100m,128Mi,0.066,48.18,3.3%",
+		"other,1,100m,128Mi,0.066,48.18,3.3%",
+		"<idle>,*,*,*,1.868,1363.64,93.4%",
+	}, "\n")+"\n", buf.String())
+}
+
+func TestNamespaceCostsByUsage(t *testing.T) {
+	run := newTestRun(nil)
+	cm := getTestCostClusterMetric(t)
+
+	ncp := &namespaceCostPrinter{run: run, opts: Options{CostAllocation: AllocateCostByUsage}}
+	ncp.totals, ncp.namespaces, ncp.idle = buildNamespaceCosts(&cm, AllocateCostByUsage, false)
+	assert.Nil(t, ncp.idle)
+
+	list := ncp.buildListNamespaceCosts()
+	require.Len(t, list.Namespaces, 2)
+	assert.Equal(t, &listNamespaceCost{
+		Name:    "other",
+		Pods:    1,
+		CPU:     "70m",
+		Memory:  "96Mi",
+		Hourly:  "0.047",
+		Monthly: "34.31",
+		Share:   "58.8%",
+	}, list.Namespaces[0])
+	assert.Equal(t, "default", list.Namespaces[1].Name)
+	assert.Equal(t, "0.080", list.ClusterTotals.Hourly)
+}
+
+func TestNamespaceCostsCappedAtNodeCost(t *testing.T) {
+	cm := getTestCostClusterMetric(t)
+	for _, nm := range cm.nodeMetrics {
+		for _, pm := range nm.podMetrics {
+			pm.cpu.utilization = nm.cpu.allocatable.DeepCopy()
+			pm.cpu.utilization.Add(nm.cpu.allocatable)
+			pm.memory.utilization = nm.memory.allocatable.DeepCopy()
+		}
+	}
+
+	totals, namespaces, idle := buildNamespaceCosts(&cm, AllocateCostByUsage, true)
+	require.Len(t, namespaces, 2)
+	assert.InDelta(t, 1, namespaces[0].hourly, 0.0001)
+	assert.InDelta(t, 2, totals.hourly, 0.0001)
+	assert.InDelta(t, 0, idle.hourly, 0.0001)
+}
@@ -0,0 +1,72 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"path"
+	"regexp"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// ValidateNodeName returns an error for a --node-name pattern that can not
+// be parsed
+func ValidateNodeName(pattern string) error {
+	_, err := parseNodeNamePattern(pattern)
+	return err
+}
+
+// parseNodeNamePattern returns a function matching node names against a
+// glob, or against a regular expression when the pattern is wrapped in
+// slashes like /^gpu-[0-9]+$/
+func parseNodeNamePattern(pattern string) (func(name string) bool, error) {
+	if len(pattern) > 1 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+		re, err := regexp.Compile(pattern[1 : len(pattern)-1])
+		if err != nil {
+			return nil, err
+		}
+		return re.MatchString, nil
+	}
+
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+	return func(name string) bool {
+		matched, _ := path.Match(pattern, name)
+		return matched
+	}, nil
+}
+
+// filterNodesByName removes the nodes whose name does not match a
+// --node-name pattern
+func filterNodesByName(nodeList *corev1.NodeList, pattern string) error {
+	if pattern == "" {
+		return nil
+	}
+	matches, err := parseNodeNamePattern(pattern)
+	if err != nil {
+		return err
+	}
+
+	nodes := []corev1.Node{}
+	for _, node := range nodeList.Items {
+		if matches(node.Name) {
+			nodes = append(nodes, node)
+		}
+	}
+	nodeList.Items = nodes
+	return nil
+}
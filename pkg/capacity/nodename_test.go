@@ -0,0 +1,70 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestValidateNodeName(t *testing.T) {
+	assert.NoError(t, ValidateNodeName("gpu-*"))
+	assert.NoError(t, ValidateNodeName("/^gpu-[0-9]+$/"))
+	assert.Error(t, ValidateNodeName("gpu-["))
+	assert.Error(t, ValidateNodeName("/gpu-(/"))
+}
+
+func TestParseNodeNamePattern(t *testing.T) {
+	glob, err := parseNodeNamePattern("gpu-*")
+	require.NoError(t, err)
+	assert.True(t, glob("gpu-1"))
+	assert.False(t, glob("cpu-1"))
+	assert.False(t, glob("my-gpu-1"))
+
+	re, err := parseNodeNamePattern("/^gpu-[0-9]+$/")
+	require.NoError(t, err)
+	assert.True(t, re("gpu-12"))
+	assert.False(t, re("gpu-a"))
+
+	// A single slash is a glob, not an empty regular expression
+	slash, err := parseNodeNamePattern("/")
+	require.NoError(t, err)
+	assert.False(t, slash("gpu-1"))
+}
+
+func TestGetPodsAndNodesWithNodeName(t *testing.T) {
+	run := newTestRun(nil)
+	clientset := fake.NewSimpleClientset(
+		node("gpu-1", map[string]string{}, false),
+		node("gpu-2", map[string]string{}, false),
+		node("cpu-1", map[string]string{}, false),
+		pod("gpu-1", "default", "mypod", map[string]string{}),
+		pod("cpu-1", "default", "mypod2", map[string]string{}),
+	)
+
+	podList, nodeList, _ := getPodsAndNodes(run, clientset, listFilters{nodeName: "gpu-*"})
+	assert.ElementsMatch(t, []string{"gpu-1", "gpu-2"}, listNodes(nodeList))
+	assert.Equal(t, []string{"default/mypod"}, listPods(podList))
+
+	_, nodeList, _ = getPodsAndNodes(run, clientset, listFilters{nodeName: "/-1$/"})
+	assert.ElementsMatch(t, []string{"cpu-1", "gpu-1"}, listNodes(nodeList))
+
+	_, _, ferr := getPodsAndNodes(run, clientset, listFilters{nodeName: "/[/"})
+	require.NotNil(t, ferr)
+	assert.Equal(t, 2, ferr.code)
+}
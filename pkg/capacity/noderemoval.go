@@ -0,0 +1,289 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// nodeRemoval is the nodes --simulate-remove-nodes takes away, either a
+// number of the least requested nodes or the nodes matching a selector
+type nodeRemoval struct {
+	count    int
+	selector labels.Selector
+}
+
+type listNodeRemoval struct {
+	Current      *listWhatIfTotals  `json:"current"`
+	AfterRemoval *listWhatIfTotals  `json:"afterRemoval"`
+	RemovedNodes []*listRemovedNode `json:"removedNodes"`
+	Fits         bool               `json:"fits"`
+	Pods         []*listDrainPod    `json:"pods"`
+}
+
+// listWhatIfTotals is the cluster before or after a simulated change
+type listWhatIfTotals struct {
+	Nodes  int64               `json:"nodes"`
+	CPU    *listWhatIfResource `json:"cpu"`
+	Memory *listWhatIfResource `json:"memory"`
+}
+
+type listWhatIfResource struct {
+	Allocatable    string `json:"allocatable"`
+	Requests       string `json:"requests"`
+	RequestsPct    string `json:"requestsPercent"`
+	Utilization    string `json:"utilization,omitempty"`
+	UtilizationPct string `json:"utilizationPercent,omitempty"`
+}
+
+type listRemovedNode struct {
+	Name   string `json:"name"`
+	Pods   int64  `json:"pods"`
+	CPU    string `json:"cpu"`
+	Memory string `json:"memory"`
+}
+
+// ValidateSimulateRemoveNodes returns an error for a --simulate-remove-nodes
+// that is neither a number of nodes nor a label selector
+func ValidateSimulateRemoveNodes(value string) error {
+	_, err := parseNodeRemoval(value)
+	return err
+}
+
+func parseNodeRemoval(value string) (*nodeRemoval, error) {
+	if count, err := strconv.Atoi(value); err == nil {
+		if count < 1 {
+			return nil, fmt.Errorf("must be a positive number of nodes or a label selector")
+		}
+		return &nodeRemoval{count: count}, nil
+	}
+	selector, err := labels.Parse(value)
+	if err != nil {
+		return nil, err
+	}
+	return &nodeRemoval{selector: selector}, nil
+}
+
+// removedNodes returns the nodes matching the selector, or the given number
+// of nodes with the least CPU and then memory requested, in name order
+func (r *nodeRemoval) removedNodes(cm *clusterMetric) ([]*nodeMetric, error) {
+	nodes := cm.getSortedNodeMetrics("name")
+	removed := []*nodeMetric{}
+	if r.selector != nil {
+		for _, nm := range nodes {
+			if r.selector.Matches(labels.Set(nm.labels)) {
+				removed = append(removed, nm)
+			}
+		}
+		if len(removed) == 0 {
+			return nil, fmt.Errorf("no nodes match %s", r.selector)
+		}
+	} else {
+		sort.SliceStable(nodes, func(i, j int) bool {
+			if c := nodes[i].cpu.request.Cmp(nodes[j].cpu.request); c != 0 {
+				return c < 0
+			}
+			return nodes[i].memory.request.Cmp(nodes[j].memory.request) < 0
+		})
+		if r.count < len(nodes) {
+			removed = nodes[:r.count]
+		} else {
+			removed = nodes
+		}
+		sort.Slice(removed, func(i, j int) bool {
+			return removed[i].name < removed[j].name
+		})
+	}
+
+	if len(removed) == len(nodes) {
+		return nil, fmt.Errorf("can not remove all %d nodes", len(nodes))
+	}
+	return removed, nil
+}
+
+func printNodeRemoval(run *reportRun, cm *clusterMetric, opts Options) error {
+	removal, err := parseNodeRemoval(opts.SimulateRemoveNodes)
+	if err != nil {
+		return fmt.Errorf("Error parsing --simulate-remove-nodes: %v", err)
+	}
+	removed, err := removal.removedNodes(cm)
+	if err != nil {
+		return fmt.Errorf("Error: %v", err)
+	}
+
+	np := &nodeRemovalPrinter{run: run, cm: cm, removed: removed, opts: opts}
+	names := []string{}
+	for _, nm := range removed {
+		names = append(names, nm.name)
+	}
+	np.pods = simulateDrain(run, cm, names...)
+	return np.Print(opts.OutputFormat)
+}
+
+type nodeRemovalPrinter struct {
+	run     *reportRun
+	cm      *clusterMetric
+	removed []*nodeMetric
+	pods    []*drainPod
+	opts    Options
+}
+
+func (np *nodeRemovalPrinter) Print(outputType string) error {
+	switch outputType {
+	case JSONOutput, YAMLOutput:
+		printListOutput(np.run, NodeRemovalReportKind, np.buildListNodeRemoval(), outputType)
+	case TableOutput:
+		w := tabwriter.NewWriter(np.run.output, 0, 8, 2, ' ', 0)
+		np.printTable(w, "\t ")
+		if err := w.Flush(); err != nil {
+			np.run.logErrorf("Error writing to table: %s", err)
+		}
+	case CSVOutput:
+		np.printTable(np.run.output, ",")
+	case TSVOutput:
+		np.printTable(np.run.output, "\t")
+	default:
+		return fmt.Errorf("Called with an unsupported output type: %s", outputType)
+	}
+	return nil
+}
+
+// printTable prints the cluster before and after the removal, the removed
+// nodes, and the pods that would not be rescheduled
+func (np *nodeRemovalPrinter) printTable(w io.Writer, separator string) {
+	list := np.buildListNodeRemoval()
+
+	printWhatIfTotals(w, separator, np.opts, []string{"current", "after removal"}, list.Current, list.AfterRemoval)
+
+	_, _ = fmt.Fprintln(w)
+	_, _ = fmt.Fprintln(w, strings.Join([]string{"REMOVED NODE", "PODS", "CPU REQUESTS", "MEMORY REQUESTS"}, separator))
+	for _, ln := range list.RemovedNodes {
+		_, _ = fmt.Fprintln(w, strings.Join([]string{ln.Name, fmt.Sprintf("%d", ln.Pods), ln.CPU, ln.Memory}, separator))
+	}
+
+	_, _ = fmt.Fprintln(w)
+	_, _ = fmt.Fprintln(w, strings.Join([]string{"NAMESPACE", "POD", "CPU REQUESTS", "MEMORY REQUESTS", "OUTCOME"}, separator))
+	for _, lp := range list.Pods {
+		_, _ = fmt.Fprintln(w, strings.Join([]string{lp.Namespace, lp.Name, lp.CPU, lp.Memory, lp.Outcome}, separator))
+	}
+}
+
+// buildListNodeRemoval compares the cluster with and without the removed
+// nodes. Their requests and utilization leave with them, apart from the
+// pods rescheduled on the remaining nodes, and only the pods that would not
+// be rescheduled are listed.
+func (np *nodeRemovalPrinter) buildListNodeRemoval() *listNodeRemoval {
+	cpu := &resourceMetric{resourceType: "cpu"}
+	memory := &resourceMetric{resourceType: "memory"}
+	cpu.addMetric(np.cm.cpu)
+	memory.addMetric(np.cm.memory)
+
+	out := &listNodeRemoval{
+		Current:      newListWhatIfTotals(np.run, int64(len(np.cm.nodeMetrics)), np.cm.cpu, np.cm.memory, np.opts),
+		RemovedNodes: []*listRemovedNode{},
+		Fits:         true,
+		Pods:         []*listDrainPod{},
+	}
+	for _, nm := range np.removed {
+		for _, r := range []struct{ after, node *resourceMetric }{{cpu, nm.cpu}, {memory, nm.memory}} {
+			r.after.allocatable.Sub(r.node.allocatable)
+			r.after.request.Sub(r.node.request)
+			r.after.limit.Sub(r.node.limit)
+			r.after.utilization.Sub(r.node.utilization)
+		}
+		out.RemovedNodes = append(out.RemovedNodes, &listRemovedNode{
+			Name:   nm.name,
+			Pods:   int64(len(nm.podMetrics)),
+			CPU:    nm.cpu.valueFunction(np.run)(nm.cpu.request),
+			Memory: nm.memory.valueFunction(np.run)(nm.memory.request),
+		})
+	}
+
+	for _, p := range np.pods {
+		if p.outcome == drainRescheduled {
+			for _, r := range []struct{ after, pod *resourceMetric }{{cpu, p.pm.cpu}, {memory, p.pm.memory}} {
+				r.after.request.Add(r.pod.request)
+				r.after.limit.Add(r.pod.limit)
+				r.after.utilization.Add(r.pod.utilization)
+			}
+			continue
+		}
+		if p.outcome == drainPending {
+			out.Fits = false
+		}
+		out.Pods = append(out.Pods, &listDrainPod{
+			Namespace: p.pm.namespace,
+			Name:      p.pm.name,
+			CPU:       cpu.valueFunction(np.run)(p.pm.cpu.request),
+			Memory:    memory.valueFunction(np.run)(p.pm.memory.request),
+			Outcome:   p.outcome,
+		})
+	}
+
+	out.AfterRemoval = newListWhatIfTotals(np.run, int64(len(np.cm.nodeMetrics)-len(np.removed)), cpu, memory, np.opts)
+	return out
+}
+
+// printWhatIfTotals prints the cluster before and after a simulated change,
+// one row for each of the named totals
+func printWhatIfTotals(w io.Writer, separator string, opts Options, names []string, totals ...*listWhatIfTotals) {
+	headers := []string{"CLUSTER", "NODES"}
+	for _, prefix := range []string{"CPU", "MEMORY"} {
+		headers = append(headers, prefix+" ALLOCATABLE", prefix+" REQUESTS")
+		if opts.ShowUtil {
+			headers = append(headers, prefix+" UTIL")
+		}
+	}
+	_, _ = fmt.Fprintln(w, strings.Join(headers, separator))
+	for i, t := range totals {
+		items := []string{names[i], fmt.Sprintf("%d", t.Nodes)}
+		for _, r := range []*listWhatIfResource{t.CPU, t.Memory} {
+			items = append(items, r.Allocatable, fmt.Sprintf("%s (%s)", r.Requests, r.RequestsPct))
+			if opts.ShowUtil {
+				items = append(items, fmt.Sprintf("%s (%s)", r.Utilization, r.UtilizationPct))
+			}
+		}
+		_, _ = fmt.Fprintln(w, strings.Join(items, separator))
+	}
+}
+
+func newListWhatIfTotals(run *reportRun, nodes int64, cpu, memory *resourceMetric, opts Options) *listWhatIfTotals {
+	return &listWhatIfTotals{
+		Nodes:  nodes,
+		CPU:    newListWhatIfResource(run, cpu, opts),
+		Memory: newListWhatIfResource(run, memory, opts),
+	}
+}
+
+func newListWhatIfResource(run *reportRun, rm *resourceMetric, opts Options) *listWhatIfResource {
+	valueCalculator := rm.valueFunction(run)
+	out := &listWhatIfResource{
+		Allocatable: valueCalculator(rm.allocatable),
+		Requests:    valueCalculator(rm.request),
+		RequestsPct: percentString(run, rm.request, rm.allocatable),
+	}
+	if opts.ShowUtil {
+		out.Utilization = valueCalculator(rm.utilization)
+		out.UtilizationPct = percentString(run, rm.utilization, rm.utilBase(opts.UtilPercent))
+	}
+	return out
+}
@@ -0,0 +1,86 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateSimulateRemoveNodes(t *testing.T) {
+	assert.NoError(t, ValidateSimulateRemoveNodes("3"))
+	assert.NoError(t, ValidateSimulateRemoveNodes("node.kubernetes.io/instance-type=m5.large"))
+	assert.Error(t, ValidateSimulateRemoveNodes("0"))
+	assert.Error(t, ValidateSimulateRemoveNodes("pool in (small"))
+}
+
+func TestRemovedNodes(t *testing.T) {
+	cm := drainTestClusterMetric(1)
+	cm.nodeMetrics["c"].labels = map[string]string{"pool": "small"}
+
+	removal, err := parseNodeRemoval("2")
+	require.NoError(t, err)
+	removed, err := removal.removedNodes(cm)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b", "c"}, []string{removed[0].name, removed[1].name})
+
+	removal, err = parseNodeRemoval("pool=small")
+	require.NoError(t, err)
+	removed, err = removal.removedNodes(cm)
+	require.NoError(t, err)
+	require.Len(t, removed, 1)
+	assert.Equal(t, "c", removed[0].name)
+
+	removal, _ = parseNodeRemoval("3")
+	_, err = removal.removedNodes(cm)
+	assert.Error(t, err)
+
+	removal, _ = parseNodeRemoval("pool=large")
+	_, err = removal.removedNodes(cm)
+	assert.Error(t, err)
+}
+
+func TestNodeRemovalReport(t *testing.T) {
+	run := newTestRun(nil)
+	cm := drainTestClusterMetric(1)
+	cm.podDisruptionBudgets = nil
+
+	np := &nodeRemovalPrinter{run: run, cm: cm, removed: []*nodeMetric{cm.nodeMetrics["a"]}}
+	np.pods = simulateDrain(run, cm, "a")
+
+	list := np.buildListNodeRemoval()
+	assert.False(t, list.Fits)
+
+	// Only web-1 fits on b, so its requests are all that is added back
+	var buf bytes.Buffer
+	np.printTable(&buf, ",")
+	assert.Equal(t, strings.Join([]string{
+		"CLUSTER,NODES,CPU ALLOCATABLE,CPU REQUESTS,MEMORY ALLOCATABLE,MEMORY REQUESTS",
+		"current,3,6000m,3100m (51%),12000Mi,0Mi (0%)",
+		"after removal,2,2000m,1800m (90%),8000Mi,0Mi (0%)",
+		"",
+		"REMOVED NODE,PODS,CPU REQUESTS,MEMORY REQUESTS",
+		"a,5,1900m,0Mi",
+		"",
+		"NAMESPACE,POD,CPU REQUESTS,MEMORY REQUESTS,OUTCOME",
+		"default,web-2,600m,0Mi,pending",
+		"default,web-3,500m,0Mi,pending",
+		"default,bare,100m,0Mi,deleted",
+	}, "\n")+"\n", buf.String())
+}
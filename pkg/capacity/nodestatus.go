@@ -0,0 +1,168 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/duration"
+)
+
+const (
+	// nodeRoleLabelPrefix is followed by the role in labels like
+	// node-role.kubernetes.io/control-plane
+	nodeRoleLabelPrefix = "node-role.kubernetes.io/"
+
+	// nodeRoleLabel is the legacy label holding the role as its value
+	nodeRoleLabel = "kubernetes.io/role"
+
+	// defaultNodeRole is shown for nodes without a role label
+	defaultNodeRole = "worker"
+)
+
+// nodeStatusCount counts how many nodes are Ready and schedulable
+type nodeStatusCount struct {
+	nodes       int64
+	ready       int64
+	schedulable int64
+}
+
+type listNodeStatus struct {
+	Roles       []string `json:"roles"`
+	Ready       string   `json:"ready"`
+	Schedulable bool     `json:"schedulable"`
+	Age         string   `json:"age"`
+}
+
+type listNodeStatusCount struct {
+	Nodes       int64 `json:"nodes"`
+	Ready       int64 `json:"ready"`
+	Schedulable int64 `json:"schedulable"`
+}
+
+// nodeRoles returns the sorted roles of a node from its role labels
+func nodeRoles(labels map[string]string) []string {
+	roles := []string{}
+	for key, value := range labels {
+		if role := strings.TrimPrefix(key, nodeRoleLabelPrefix); role != key && role != "" {
+			roles = append(roles, role)
+		} else if key == nodeRoleLabel && value != "" {
+			roles = append(roles, value)
+		}
+	}
+	if len(roles) == 0 {
+		return []string{defaultNodeRole}
+	}
+	sort.Strings(roles)
+	return roles
+}
+
+// nodeReadyCondition returns the status of the Ready condition of a node, or
+// an empty string if the node has not reported one
+func nodeReadyCondition(node *corev1.Node) corev1.ConditionStatus {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == corev1.NodeReady {
+			return condition.Status
+		}
+	}
+	return ""
+}
+
+func (nm *nodeMetric) isReady() bool {
+	return nm.ready == corev1.ConditionTrue
+}
+
+func (nm *nodeMetric) rolesString() string {
+	return strings.Join(nm.roles, ",")
+}
+
+// statusString formats the Ready condition and schedulability of a node the
+// way kubectl does, example: "NotReady,SchedulingDisabled"
+func (nm *nodeMetric) statusString() string {
+	status := "Unknown"
+	if nm.ready != "" {
+		status = "NotReady"
+		if nm.isReady() {
+			status = "Ready"
+		}
+	}
+	if nm.unschedulable {
+		status += ",SchedulingDisabled"
+	}
+	return status
+}
+
+// ageString returns how long the node had existed when the report was
+// observed
+func (nm *nodeMetric) ageString(observedAt time.Time) string {
+	if nm.created.IsZero() {
+		return VoidValue
+	}
+	if observedAt.IsZero() {
+		observedAt = time.Now()
+	}
+	return duration.HumanDuration(observedAt.Sub(nm.created))
+}
+
+func (nm *nodeMetric) listNodeStatus(observedAt time.Time) *listNodeStatus {
+	ready := string(nm.ready)
+	if ready == "" {
+		ready = string(corev1.ConditionUnknown)
+	}
+	return &listNodeStatus{
+		Roles:       nm.roles,
+		Ready:       ready,
+		Schedulable: !nm.unschedulable,
+		Age:         nm.ageString(observedAt),
+	}
+}
+
+func (nc *nodeStatusCount) addNode(nm *nodeMetric) {
+	nc.nodes++
+	if nm.isReady() {
+		nc.ready++
+	}
+	if !nm.unschedulable {
+		nc.schedulable++
+	}
+}
+
+func (nc *nodeStatusCount) add(other *nodeStatusCount) {
+	nc.nodes += other.nodes
+	nc.ready += other.ready
+	nc.schedulable += other.schedulable
+}
+
+// statusString summarizes the nodes of a cluster, example:
+// "4/5 Ready, 1 SchedulingDisabled"
+func (nc *nodeStatusCount) statusString() string {
+	status := fmt.Sprintf("%d/%d Ready", nc.ready, nc.nodes)
+	if cordoned := nc.nodes - nc.schedulable; cordoned > 0 {
+		status += fmt.Sprintf(", %d SchedulingDisabled", cordoned)
+	}
+	return status
+}
+
+func (nc *nodeStatusCount) listNodeStatusCount() *listNodeStatusCount {
+	return &listNodeStatusCount{
+		Nodes:       nc.nodes,
+		Ready:       nc.ready,
+		Schedulable: nc.schedulable,
+	}
+}
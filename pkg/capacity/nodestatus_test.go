@@ -0,0 +1,74 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestNodeRoles(t *testing.T) {
+	assert.Equal(t, []string{"control-plane", "master"}, nodeRoles(map[string]string{
+		"node-role.kubernetes.io/master":        "",
+		"node-role.kubernetes.io/control-plane": "",
+	}))
+	assert.Equal(t, []string{"ingress"}, nodeRoles(map[string]string{"kubernetes.io/role": "ingress"}))
+	assert.Equal(t, []string{defaultNodeRole}, nodeRoles(map[string]string{"kubernetes.io/os": "linux"}))
+	assert.Equal(t, []string{defaultNodeRole}, nodeRoles(nil))
+}
+
+func TestNodeStatus(t *testing.T) {
+	created := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	observedAt := created.Add(72 * time.Hour)
+
+	withReady := func(n *corev1.Node, status corev1.ConditionStatus) *corev1.Node {
+		n.CreationTimestamp = metav1.NewTime(created)
+		n.Status.Conditions = []corev1.NodeCondition{{Type: corev1.NodeReady, Status: status}}
+		return n
+	}
+
+	ready := withReady(node("ready", map[string]string{"node-role.kubernetes.io/control-plane": ""}, false), corev1.ConditionTrue)
+	cordoned := withReady(node("cordoned", map[string]string{}, false), corev1.ConditionTrue)
+	cordoned.Spec.Unschedulable = true
+	notReady := withReady(node("not-ready", map[string]string{}, false), corev1.ConditionUnknown)
+	unknown := node("unknown", map[string]string{}, false)
+
+	cm := buildClusterMetric(&corev1.PodList{}, nil, &corev1.NodeList{
+		Items: []corev1.Node{*ready, *cordoned, *notReady, *unknown},
+	}, nil)
+
+	assert.Equal(t, "Ready", cm.nodeMetrics["ready"].statusString())
+	assert.Equal(t, "control-plane", cm.nodeMetrics["ready"].rolesString())
+	assert.Equal(t, "3d", cm.nodeMetrics["ready"].ageString(observedAt))
+	assert.Equal(t, "Ready,SchedulingDisabled", cm.nodeMetrics["cordoned"].statusString())
+	assert.Equal(t, "NotReady", cm.nodeMetrics["not-ready"].statusString())
+	assert.Equal(t, "Unknown", cm.nodeMetrics["unknown"].statusString())
+	assert.Equal(t, VoidValue, cm.nodeMetrics["unknown"].ageString(observedAt))
+
+	assert.Equal(t, &listNodeStatus{
+		Roles:       []string{defaultNodeRole},
+		Ready:       "True",
+		Schedulable: false,
+		Age:         "3d",
+	}, cm.nodeMetrics["cordoned"].listNodeStatus(observedAt))
+	assert.Equal(t, "Unknown", cm.nodeMetrics["unknown"].listNodeStatus(observedAt).Ready)
+
+	assert.Equal(t, "2/4 Ready, 1 SchedulingDisabled", cm.nodeStatus.statusString())
+	assert.Equal(t, &listNodeStatusCount{Nodes: 4, Ready: 2, Schedulable: 3}, cm.nodeStatus.listNodeStatusCount())
+}
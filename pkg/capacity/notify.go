@@ -0,0 +1,197 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// AutoNotifyFormat sends Slack messages to Slack incoming webhooks and
+	// JSON to any other URL
+	AutoNotifyFormat = "auto"
+	// JSONNotifyFormat sends the breaches as a JSON document
+	JSONNotifyFormat = "json"
+	// SlackNotifyFormat sends a message for a Slack incoming webhook
+	SlackNotifyFormat = "slack"
+)
+
+// SupportedNotifyFormats returns the payloads --notify-webhook can send
+func SupportedNotifyFormats() []string {
+	return []string{AutoNotifyFormat, JSONNotifyFormat, SlackNotifyFormat}
+}
+
+// notifyTimeout bounds each notification so that an unreachable webhook
+// does not hold up the report
+const notifyTimeout = 30 * time.Second
+
+// webhookPayload is the body sent with --notify-format=json
+type webhookPayload struct {
+	ObservedAt time.Time       `json:"observedAt"`
+	Breaches   []webhookBreach `json:"breaches"`
+}
+
+type webhookBreach struct {
+	Cluster   string `json:"cluster"`
+	Node      string `json:"node,omitempty"`
+	Field     string `json:"field"`
+	Value     string `json:"value"`
+	Threshold string `json:"threshold"`
+	Message   string `json:"message"`
+}
+
+// slackPayload is the body of a Slack incoming webhook message
+type slackPayload struct {
+	Text string `json:"text"`
+}
+
+// notifyThresholds posts the --fail-on and --notify-on thresholds newly
+// breached by any of the clusters to --notify-webhook. A notification that
+// can not be sent is logged without failing the run.
+func notifyThresholds(run *reportRun, clusters []*clusterMetric, opts Options) {
+	if opts.NotifyWebhook == "" {
+		return
+	}
+
+	expressions := append(append([]string{}, opts.FailOn...), opts.NotifyOn...)
+	breaches := []thresholdBreach{}
+	for _, cm := range clusters {
+		for _, b := range findBreaches(run, cm, expressions, opts.UtilPercent) {
+			if b.cluster == "" {
+				b.cluster, _ = clusterName(opts)
+			}
+			breaches = append(breaches, b)
+		}
+	}
+
+	breached := map[string]bool{}
+	newBreaches := []thresholdBreach{}
+	for _, b := range breaches {
+		key := b.cluster + "/" + b.node + "/" + b.expression
+		if breached[key] {
+			continue
+		}
+		breached[key] = true
+		if !run.notifiedBreaches[key] {
+			newBreaches = append(newBreaches, b)
+		}
+	}
+	// The breaches are kept in the map the run was set up with, which each
+	// run of --interval shares
+	clear(run.notifiedBreaches)
+	maps.Copy(run.notifiedBreaches, breached)
+	if len(newBreaches) == 0 {
+		return
+	}
+
+	observedAt := time.Now()
+	if len(clusters) > 0 && !clusters[0].observedAt.IsZero() {
+		observedAt = clusters[0].observedAt
+	}
+	body, err := notificationBody(newBreaches, notifyFormat(opts.NotifyFormat, opts.NotifyWebhook), observedAt)
+	if err != nil {
+		run.logErrorf("Error building notification: %v", err)
+		return
+	}
+	if err := postNotification(opts.NotifyWebhook, body); err != nil {
+		run.logErrorf("Error sending notification: %v", err)
+		return
+	}
+	run.logInfof("Notified %s of %d breached thresholds", redactWebhook(opts.NotifyWebhook), len(newBreaches))
+}
+
+// notifyFormat resolves --notify-format=auto from the host of the webhook
+func notifyFormat(format, webhook string) string {
+	if format != AutoNotifyFormat {
+		return format
+	}
+	if u, err := url.Parse(webhook); err == nil && u.Host == "hooks.slack.com" {
+		return SlackNotifyFormat
+	}
+	return JSONNotifyFormat
+}
+
+func notificationBody(breaches []thresholdBreach, format string, observedAt time.Time) ([]byte, error) {
+	if format == SlackNotifyFormat {
+		summary := fmt.Sprintf(":warning: kube-capacity: %d thresholds breached", len(breaches))
+		if len(breaches) == 1 {
+			summary = ":warning: kube-capacity: 1 threshold breached"
+		}
+		lines := []string{summary}
+		for _, b := range breaches {
+			lines = append(lines, "• "+b.String())
+		}
+		return json.Marshal(slackPayload{Text: strings.Join(lines, "\n")})
+	}
+
+	payload := webhookPayload{ObservedAt: observedAt.UTC(), Breaches: []webhookBreach{}}
+	for _, b := range breaches {
+		payload.Breaches = append(payload.Breaches, webhookBreach{
+			Cluster:   b.cluster,
+			Node:      b.node,
+			Field:     b.field,
+			Value:     b.actual,
+			Threshold: b.expression,
+			Message:   b.String(),
+		})
+	}
+	return json.Marshal(payload)
+}
+
+func postNotification(webhook string, body []byte) error {
+	ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		// Errors of the client quote the URL, which would log the secret
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			return urlErr.Err
+		}
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	return nil
+}
+
+// redactWebhook leaves only the host of a webhook URL in logs, as the path
+// of a Slack webhook is its secret
+func redactWebhook(webhook string) string {
+	u, err := url.Parse(webhook)
+	if err != nil {
+		return "webhook"
+	}
+	return u.Host
+}
@@ -0,0 +1,99 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestNotifyFormat(t *testing.T) {
+	assert.Equal(t, SlackNotifyFormat, notifyFormat(AutoNotifyFormat, "https://hooks.slack.com/services/T000/B000/XXXX"))
+	assert.Equal(t, JSONNotifyFormat, notifyFormat(AutoNotifyFormat, "https://alerts.example.com/kube-capacity"))
+	assert.Equal(t, SlackNotifyFormat, notifyFormat(SlackNotifyFormat, "https://chat.example.com/hooks/capacity"))
+}
+
+func TestNotifyThresholds(t *testing.T) {
+	run := newTestRun(nil)
+	received := []webhookPayload{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload webhookPayload
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		received = append(received, payload)
+	}))
+	defer server.Close()
+
+	metric := func(resourceType, allocatable, request string) *resourceMetric {
+		return &resourceMetric{
+			resourceType: resourceType,
+			allocatable:  resource.MustParse(allocatable),
+			request:      resource.MustParse(request),
+		}
+	}
+	cm := &clusterMetric{
+		name:   "prod",
+		cpu:    metric("cpu", "4", "3"),
+		memory: metric("memory", "8Gi", "2Gi"),
+		nodeMetrics: map[string]*nodeMetric{
+			"busy": {name: "busy", cpu: metric("cpu", "2", "1900m"), memory: metric("memory", "4Gi", "1Gi")},
+		},
+	}
+	opts := Options{
+		NotifyOn:      []string{"cpu.requests>70%", "node.cpu.requests>90%"},
+		NotifyWebhook: server.URL,
+		NotifyFormat:  AutoNotifyFormat,
+	}
+
+	notifyThresholds(run, []*clusterMetric{cm}, opts)
+	require.Len(t, received, 1)
+	require.Len(t, received[0].Breaches, 2)
+	assert.Equal(t, webhookBreach{
+		Cluster:   "prod",
+		Node:      "busy",
+		Field:     "cpu.requests",
+		Value:     "95.0%",
+		Threshold: "node.cpu.requests>90%",
+		Message:   "prod: node busy cpu.requests is 95.0%, breaching node.cpu.requests>90%",
+	}, received[0].Breaches[1])
+
+	// Thresholds that stay breached are not notified about again
+	notifyThresholds(run, []*clusterMetric{cm}, opts)
+	assert.Len(t, received, 1)
+
+	// Once the node clears, it is notified about when breached again
+	cm.nodeMetrics["busy"].cpu = metric("cpu", "2", "1")
+	notifyThresholds(run, []*clusterMetric{cm}, opts)
+	assert.Len(t, received, 1)
+	cm.nodeMetrics["busy"].cpu = metric("cpu", "2", "1900m")
+	notifyThresholds(run, []*clusterMetric{cm}, opts)
+	require.Len(t, received, 2)
+	require.Len(t, received[1].Breaches, 1)
+	assert.Equal(t, "busy", received[1].Breaches[0].Node)
+}
+
+func TestSlackNotificationBody(t *testing.T) {
+	body, err := notificationBody([]thresholdBreach{
+		{cluster: "prod", field: "cpu.requests", actual: "75.0%", expression: "cpu.requests>70%"},
+	}, SlackNotifyFormat, time.Now())
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"text":":warning: kube-capacity: 1 threshold breached\n• prod: cluster cpu.requests is 75.0%, breaching cpu.requests>70%"}`, string(body))
+}
@@ -1,32 +1,196 @@
 package capacity
 
+import (
+	"io"
+	"time"
+
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	metrics "k8s.io/metrics/pkg/client/clientset/versioned"
+)
+
 // Options is a struct containing the command line options
 // FetchAndPrint depends on
 type Options struct {
-	ShowContainers        bool
-	ShowPods              bool
-	ShowUtil              bool
-	ShowPodCount          bool
-	ShowLabels            bool
-	HideRequests          bool
-	HideLimits            bool
-	PodLabels             string
-	NodeLabels            string
-	NodeTaints            string
-	ExcludeTainted        bool
-	NamespaceLabels       string
-	Namespace             string
-	KubeContext           string
-	KubeConfig            string
-	InsecureSkipTLSVerify bool
-	OutputFormat          string
-	SortBy                string
-	AvailableFormat       bool
-	ImpersonateUser       string
-	ImpersonateGroup      string
-	UsePrometheus         bool
-	PrometheusEndpoint    string
-	PrometheusWindow      string
-	PrometheusAggregation string
-	UtilPercent           string
+	ShowContainers           bool
+	ShowPods                 bool
+	ShowUtil                 bool
+	ShowPodCount             bool
+	ShowLabels               bool
+	ShowNodeStatus           bool
+	ShowTaints               bool
+	FailOn                   []string
+	NotifyOn                 []string
+	NotifyWebhook            string
+	NotifyFormat             string
+	Verbose                  bool
+	Quiet                    bool
+	HideRequests             bool
+	HideLimits               bool
+	Columns                  []string
+	PodLabels                string
+	PodFieldSelector         string
+	NodeName                 string
+	Filter                   string
+	NodeLabels               string
+	NodeTaints               string
+	ExcludeTainted           bool
+	VirtualNodes             string
+	NamespaceLabels          string
+	Namespace                string
+	KubeContext              string
+	KubeConfig               string
+	ChunkSize                int64
+	ListPodsByNode           bool
+	KubeAPIQPS               float32
+	KubeAPIBurst             int
+	InsecureSkipTLSVerify    bool
+	OutputFormat             string
+	OutputFile               string
+	AlsoOutput               []string
+	OutputPlugin             string
+	ShowCharts               bool
+	SortBy                   string
+	AvailableFormat          bool
+	Compact                  bool
+	Stream                   bool
+	DisplayUnits             []string
+	Precision                int
+	PercentPrecision         int
+	NumberFormat             string
+	ThousandsSeparator       string
+	ImpersonateUser          string
+	ImpersonateGroup         string
+	UsePrometheus            bool
+	MetricsSource            string
+	MetricsPlugin            string
+	SpecSource               string
+	PrometheusEndpoint       string
+	PrometheusWindow         string
+	PrometheusAggregation    string
+	PrometheusAuth           string
+	PrometheusUsername       string
+	PrometheusPassword       string
+	PrometheusPasswordFile   string
+	PrometheusHeaders        []string
+	PrometheusCert           string
+	PrometheusKey            string
+	PrometheusCA             string
+	PrometheusSkipTLSVerify  bool
+	PrometheusProxyURL       string
+	PrometheusNodeLabel      string
+	PrometheusPodLabel       string
+	PrometheusNamespaceLabel string
+	PrometheusContainerLabel string
+	PrometheusQueryShards    int
+	PrometheusRecordingRules bool
+	ShowQueries              bool
+	CheckAccess              bool
+	DatadogAPIKey            string
+	DatadogAppKey            string
+	DatadogSite              string
+	DatadogScope             string
+	DatadogWindow            time.Duration
+	CloudWatchCluster        string
+	CloudWatchWindow         time.Duration
+	CustomMetricsCPU         string
+	CustomMetricsMemory      string
+	Region                   string
+	UtilPercent              string
+	ShowMetricsAge           bool
+	MetricsMaxAge            time.Duration
+	CacheTTL                 time.Duration
+	UsageAggregation         string
+	UsageWindow              time.Duration
+	CompareOffset            time.Duration
+	ShowDelta                bool
+	ShowSparkline            bool
+	ShowThrottling           bool
+	ShowRestarts             bool
+	ShowCapacity             bool
+	ShowReservedBreakdown    bool
+	ShowDisk                 bool
+	SnapshotIn               string
+	FromKubectlDump          []string
+	SnapshotOut              string
+	HistoryFile              string
+	HistoryRetention         time.Duration
+	HistorySince             time.Duration
+	HistoryKind              string
+	HistoryName              string
+	Interval                 time.Duration
+	Upload                   string
+	S3Endpoint               string
+	Contexts                 []string
+	AllContexts              bool
+	ShowQuotas               bool
+	ApplyLimitRangeDefaults  bool
+	IgnoreInitContainers     bool
+	IncludeTerminated        bool
+	ExcludeDaemonSets        bool
+	ShowVPA                  bool
+	ShowHeadroom             bool
+	ShowKarpenter            bool
+	ShowCapacityType         bool
+	ShowArch                 bool
+	ShowZoneBalance          bool
+	ShowGPU                  bool
+	SimulateRemoveNodes      string
+	ShowHPAHeadroom          bool
+	AllocateByPodLabel       string
+	ShowPending              bool
+	ShowDaemonSetOverhead    bool
+	ShowAnomalies            bool
+	AnomalyOverRequest       float64
+	AnomalyUnderRequest      float64
+	ShowStuckPods            bool
+	StuckAfter               time.Duration
+	Preemption               string
+	ShowMissingResources     bool
+	Heatmap                  string
+	SchedulableBy            string
+	ShowCost                 bool
+	ShowOvercommit           bool
+	ShowOverhead             bool
+	ShowResize               bool
+	PricingFile              string
+	CostByNamespace          bool
+	CostAllocation           string
+	FitCPU                   string
+	FitMemory                string
+	FitReplicas              int64
+	FitFromFile              string
+	FitFromDir               string
+	DrainNode                string
+	ScaleWorkload            string
+	ScaleReplicas            int64
+	RecommendWindow          string
+	RecommendPercentile      float64
+	ForecastWindow           string
+	ForecastHorizon          string
+	ForecastThresholds       []float64
+	SeriesWindow             string
+	SeriesStep               string
+	SeriesBy                 string
+
+	// Clientset, MetricsClientset, and DynamicClient are used instead of
+	// clients connected with the kubeconfig when set, by programs embedding
+	// kube-capacity with clients of their own. ClusterName is what their
+	// cluster is called in snapshots, history, and uploads in place of the
+	// kube context.
+	Clientset        kubernetes.Interface
+	MetricsClientset metrics.Interface
+	DynamicClient    dynamic.Interface
+	ClusterName      string
+
+	// In, Out, and ErrOut are the streams manifests are read from, reports
+	// are printed to, and messages are logged to. os.Stdin, os.Stdout, and
+	// os.Stderr are used for those that are not set.
+	In     io.Reader
+	Out    io.Writer
+	ErrOut io.Writer
+
+	// usageOffset shifts Prometheus queries back in time, for the earlier
+	// utilization that --compare-offset compares against
+	usageOffset time.Duration
 }
@@ -0,0 +1,217 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// openOutput returns where a report with opts is printed: stdout, the
+// --output-file, or the --output-plugin it is sent to. It must be followed
+// by closeOutput once the report is printed.
+func (r *reportRun) openOutput(opts Options) io.Writer {
+	var w io.Writer = r.stdout
+	if opts.OutputFile != "" {
+		w = &outputFile{path: opts.OutputFile}
+	}
+	if opts.OutputPlugin != "" {
+		w = &outputPlugin{path: opts.OutputPlugin, env: pluginEnv(opts), dest: w, errOut: r.logOutput}
+	}
+	return w
+}
+
+// closeOutput runs the printer plugin on the report when there is one, and
+// moves the report into place when it was printed to a file
+func (r *reportRun) closeOutput() error {
+	w := r.output
+	if op, ok := w.(*outputPlugin); ok {
+		w = op.dest
+		if err := op.run(); err != nil {
+			return fmt.Errorf("Error running output plugin %s: %v", filepath.Base(op.path), err)
+		}
+	}
+
+	of, ok := w.(*outputFile)
+	if !ok {
+		return nil
+	}
+	if err := of.Close(); err != nil {
+		return fmt.Errorf("Error writing output file: %v", err)
+	}
+	return nil
+}
+
+// OutputFormatForFile returns the output format matching the extension of
+// path, ignoring a .gz suffix, or an empty string when there is none
+func OutputFormatForFile(path string) string {
+	ext := filepath.Ext(strings.TrimSuffix(path, ".gz"))
+	switch strings.ToLower(ext) {
+	case ".csv":
+		return CSVOutput
+	case ".tsv":
+		return TSVOutput
+	case ".json":
+		return JSONOutput
+	case ".yaml", ".yml":
+		return YAMLOutput
+	case ".html", ".htm":
+		return HTMLOutput
+	case ".svg":
+		return SVGOutput
+	case ".txt":
+		return TableOutput
+	}
+	return ""
+}
+
+// alsoOutput is a file the report is also written to in another format
+type alsoOutput struct {
+	format string
+	path   string
+}
+
+// parseAlsoOutput parses an --also-output of <format>=<path>, or of a path
+// whose extension picks the format
+func parseAlsoOutput(value string) (alsoOutput, error) {
+	format, path, ok := strings.Cut(value, "=")
+	if !ok {
+		path = value
+		if format = OutputFormatForFile(path); format == "" {
+			return alsoOutput{}, fmt.Errorf("%q needs a format, as <format>=<path>, or a .csv, .tsv, .json, .yaml, .html, .svg, or .txt extension", value)
+		}
+	}
+	if path == "" {
+		return alsoOutput{}, fmt.Errorf("%q has no path", value)
+	}
+	return alsoOutput{format: format, path: path}, nil
+}
+
+// ValidateAlsoOutputs checks that every --also-output has a path and one of
+// the supported formats
+func ValidateAlsoOutputs(values []string, supported []string) error {
+	for _, value := range values {
+		ao, err := parseAlsoOutput(value)
+		if err != nil {
+			return err
+		}
+		found := false
+		for _, format := range supported {
+			if ao.format == format {
+				found = true
+			}
+		}
+		if !found {
+			return fmt.Errorf("unsupported format %q in %q, expected one of %v", ao.format, value, supported)
+		}
+	}
+	return nil
+}
+
+// printAlsoOutputs prints the report again to each --also-output file, in
+// its format, once the report itself has been printed
+func printAlsoOutputs(run *reportRun, opts Options, print func(*reportRun, Options) error) error {
+	for _, value := range opts.AlsoOutput {
+		ao, err := parseAlsoOutput(value)
+		if err != nil {
+			return fmt.Errorf("Error: --also-output %v", err)
+		}
+		aoOpts := opts
+		aoOpts.OutputFormat = ao.format
+		aoOpts.OutputFile = ao.path
+		aoOpts.OutputPlugin = ""
+		aoRun := run.withOutput(aoOpts)
+		if err := print(aoRun, aoOpts); err != nil {
+			return err
+		}
+		if err := aoRun.closeOutput(); err != nil {
+			return err
+		}
+		run.logDebugf("Wrote %s output to %s", ao.format, ao.path)
+	}
+	return nil
+}
+
+// outputFile writes a report to a temporary file next to path, gzip
+// compressed when path ends in .gz, and renames it into place once the
+// report is complete. The temporary file is only created on the first write
+// so that a run that fails before printing leaves nothing behind and an
+// earlier report at path untouched.
+type outputFile struct {
+	path string
+	f    *os.File
+	w    io.Writer
+	gw   *gzip.Writer
+	err  error
+}
+
+func (of *outputFile) open() error {
+	f, err := os.CreateTemp(filepath.Dir(of.path), filepath.Base(of.path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	of.f = f
+	of.w = f
+	if strings.HasSuffix(of.path, ".gz") {
+		of.gw = gzip.NewWriter(f)
+		of.w = of.gw
+	}
+	return nil
+}
+
+// Write keeps the first error so that it is returned by Close, since
+// printers do not check the errors of each line they write
+func (of *outputFile) Write(p []byte) (int, error) {
+	if of.err == nil && of.f == nil {
+		of.err = of.open()
+	}
+	if of.err != nil {
+		return 0, of.err
+	}
+	n, err := of.w.Write(p)
+	if err != nil {
+		of.err = err
+	}
+	return n, err
+}
+
+// Close finishes the report and renames it to path
+func (of *outputFile) Close() error {
+	if of.err == nil && of.f == nil {
+		of.err = of.open()
+	}
+	if of.f == nil {
+		return of.err
+	}
+
+	err := of.err
+	if err == nil && of.gw != nil {
+		err = of.gw.Close()
+	}
+	if closeErr := of.f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(of.f.Name(), of.path)
+	}
+	if err != nil {
+		_ = os.Remove(of.f.Name())
+	}
+	return err
+}
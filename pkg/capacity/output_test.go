@@ -0,0 +1,126 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutputFormatForFile(t *testing.T) {
+	for path, format := range map[string]string{
+		"report.csv":         CSVOutput,
+		"report.TSV":         TSVOutput,
+		"out/report.json.gz": JSONOutput,
+		"report.yml":         YAMLOutput,
+		"report.yaml":        YAMLOutput,
+		"report.txt.gz":      TableOutput,
+		"report.html":        HTMLOutput,
+		"nodes.svg":          SVGOutput,
+		"report":             "",
+		"report.gz":          "",
+	} {
+		assert.Equal(t, format, OutputFormatForFile(path), path)
+	}
+}
+
+func TestOutputFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "report.csv")
+	require.NoError(t, os.WriteFile(path, []byte("old"), 0644))
+
+	of := &outputFile{path: path}
+	entries, _ := os.ReadDir(dir)
+	assert.Len(t, entries, 1, "nothing is created before the first write")
+
+	_, _ = fmt.Fprintln(of, "a,b")
+	data, _ := os.ReadFile(path)
+	assert.Equal(t, "old", string(data), "the report is renamed into place on close")
+
+	_, _ = fmt.Fprintln(of, "1,2")
+	require.NoError(t, of.Close())
+
+	data, _ = os.ReadFile(path)
+	assert.Equal(t, "a,b\n1,2\n", string(data))
+	entries, _ = os.ReadDir(dir)
+	assert.Len(t, entries, 1)
+}
+
+func TestOutputFileGzip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "report.json.gz")
+
+	of := &outputFile{path: path}
+	_, _ = fmt.Fprint(of, "{}")
+	require.NoError(t, of.Close())
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+	gr, err := gzip.NewReader(f)
+	require.NoError(t, err)
+	data, err := io.ReadAll(gr)
+	require.NoError(t, err)
+	assert.Equal(t, "{}", string(data))
+}
+
+func TestOutputFileError(t *testing.T) {
+	of := &outputFile{path: filepath.Join(t.TempDir(), "missing", "report.csv")}
+
+	_, err := fmt.Fprintln(of, "a,b")
+	assert.Error(t, err)
+	assert.Equal(t, err, of.Close())
+}
+
+func TestValidateAlsoOutputs(t *testing.T) {
+	assert.NoError(t, ValidateAlsoOutputs([]string{"json=report.json", "csv=out/report", "nodes.yaml.gz"}, SupportedOutputs()))
+	assert.EqualError(t, ValidateAlsoOutputs([]string{"report"}, SupportedOutputs()),
+		`"report" needs a format, as <format>=<path>, or a .csv, .tsv, .json, .yaml, .html, .svg, or .txt extension`)
+	assert.EqualError(t, ValidateAlsoOutputs([]string{"json="}, SupportedOutputs()), `"json=" has no path`)
+	assert.EqualError(t, ValidateAlsoOutputs([]string{"html=report.html"}, SupportedOutputs()),
+		`unsupported format "html" in "html=report.html", expected one of [table csv tsv json yaml]`)
+}
+
+func TestPrintAlsoOutputs(t *testing.T) {
+	run := newTestRun(nil)
+	dir := t.TempDir()
+	opts := Options{
+		OutputFormat: TableOutput,
+		AlsoOutput:   []string{"json=" + filepath.Join(dir, "report"), filepath.Join(dir, "report.csv")},
+	}
+
+	formats := []string{}
+	err := printAlsoOutputs(run, opts, func(run *reportRun, opts Options) error {
+		formats = append(formats, opts.OutputFormat)
+		_, _ = fmt.Fprint(run.output, opts.OutputFormat)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{JSONOutput, CSVOutput}, formats)
+	assert.Equal(t, os.Stdout, run.output)
+
+	data, err := os.ReadFile(filepath.Join(dir, "report"))
+	require.NoError(t, err)
+	assert.Equal(t, "json", string(data))
+	data, err = os.ReadFile(filepath.Join(dir, "report.csv"))
+	require.NoError(t, err)
+	assert.Equal(t, "csv", string(data))
+}
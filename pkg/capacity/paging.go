@@ -0,0 +1,134 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"context"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/kubernetes"
+)
+
+// DefaultChunkSize is the number of pods or nodes requested per list call,
+// the same default kubectl uses
+const DefaultChunkSize int64 = 500
+
+// listRetries is how many times a list call throttled with 429 Too Many
+// Requests is retried before giving up
+const listRetries = 5
+
+// waitToRetry sleeps before a throttled list call is retried
+var waitToRetry = time.Sleep
+
+// listAllPods lists pods a page at a time
+func listAllPods(ctx context.Context, run *reportRun, clientset kubernetes.Interface, namespace string, listOpts metav1.ListOptions) (*corev1.PodList, error) {
+	podList := &corev1.PodList{}
+	err := listPages(run, listOpts, func(listOpts metav1.ListOptions) (metav1.ListInterface, error) {
+		page, err := clientset.CoreV1().Pods(namespace).List(ctx, listOpts)
+		if err != nil {
+			return nil, err
+		}
+		podList.Items = append(podList.Items, page.Items...)
+		return page, nil
+	}, func() {
+		podList.Items = nil
+	})
+	return podList, err
+}
+
+// listPodsOnNodes lists the pods on each of the given nodes with one paged
+// list per node, so that no single response holds every pod in the cluster.
+// The node is added to any field selector already in listOpts.
+func listPodsOnNodes(ctx context.Context, run *reportRun, clientset kubernetes.Interface, namespace string, nodeList *corev1.NodeList, listOpts metav1.ListOptions) (*corev1.PodList, error) {
+	podList := &corev1.PodList{}
+	fieldSelector := listOpts.FieldSelector
+	for _, node := range nodeList.Items {
+		listOpts.FieldSelector = fields.OneTermEqualSelector("spec.nodeName", node.Name).String()
+		if fieldSelector != "" {
+			listOpts.FieldSelector = fieldSelector + "," + listOpts.FieldSelector
+		}
+		nodePods, err := listAllPods(ctx, run, clientset, namespace, listOpts)
+		if err != nil {
+			return nil, err
+		}
+		podList.Items = append(podList.Items, nodePods.Items...)
+	}
+	return podList, nil
+}
+
+// listAllNodes lists nodes a page at a time
+func listAllNodes(ctx context.Context, run *reportRun, clientset kubernetes.Interface, listOpts metav1.ListOptions) (*corev1.NodeList, error) {
+	nodeList := &corev1.NodeList{}
+	err := listPages(run, listOpts, func(listOpts metav1.ListOptions) (metav1.ListInterface, error) {
+		page, err := clientset.CoreV1().Nodes().List(ctx, listOpts)
+		if err != nil {
+			return nil, err
+		}
+		nodeList.Items = append(nodeList.Items, page.Items...)
+		return page, nil
+	}, func() {
+		nodeList.Items = nil
+	})
+	return nodeList, err
+}
+
+// listPages calls list until the API server stops returning a continue
+// token. If the token expires part way through, the items collected so far
+// are reset and everything is listed again in a single call.
+func listPages(run *reportRun, listOpts metav1.ListOptions, list func(metav1.ListOptions) (metav1.ListInterface, error), reset func()) error {
+	listOpts.Limit = run.chunkSize
+	for {
+		page, err := listWithRetry(run, listOpts, list)
+		if apierrors.IsResourceExpired(err) && listOpts.Continue != "" {
+			run.logDebugf("Continue token expired, listing everything at once")
+			reset()
+			listOpts.Limit = 0
+			listOpts.Continue = ""
+			_, err = listWithRetry(run, listOpts, list)
+			return err
+		}
+		if err != nil {
+			return err
+		}
+		if page.GetContinue() == "" {
+			return nil
+		}
+		listOpts.Continue = page.GetContinue()
+	}
+}
+
+// listWithRetry retries a list call the API server throttled with 429 Too
+// Many Requests, after the delay it asks for with Retry-After, or after 1s
+// doubling with each retry when it asks for none. client-go retries
+// responses with Retry-After on its own first, so this covers throttling
+// that outlasts those retries.
+func listWithRetry(run *reportRun, listOpts metav1.ListOptions, list func(metav1.ListOptions) (metav1.ListInterface, error)) (metav1.ListInterface, error) {
+	for retry := 0; ; retry++ {
+		page, err := list(listOpts)
+		if !apierrors.IsTooManyRequests(err) || retry == listRetries {
+			return page, err
+		}
+		delay := time.Second << retry
+		if seconds, ok := apierrors.SuggestsClientDelay(err); ok && seconds > 0 {
+			delay = time.Duration(seconds) * time.Second
+		}
+		run.logDebugf("API server is throttling requests, retrying in %s", delay)
+		waitToRetry(delay)
+	}
+}
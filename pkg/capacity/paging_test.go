@@ -0,0 +1,164 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestListPages(t *testing.T) {
+	run := newTestRun(nil)
+	run.chunkSize = 2
+
+	items := []string{"a", "b", "c", "d", "e"}
+	requests := []metav1.ListOptions{}
+	got := []string{}
+	err := listPages(run, metav1.ListOptions{LabelSelector: "app=web"}, func(listOpts metav1.ListOptions) (metav1.ListInterface, error) {
+		requests = append(requests, listOpts)
+		start, _ := strconv.Atoi(listOpts.Continue)
+		end := start + int(listOpts.Limit)
+		page := &corev1.PodList{}
+		if end < len(items) {
+			page.Continue = strconv.Itoa(end)
+		} else {
+			end = len(items)
+		}
+		got = append(got, items[start:end]...)
+		return page, nil
+	}, func() {
+		got = nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, items, got)
+	assert.Equal(t, []metav1.ListOptions{
+		{LabelSelector: "app=web", Limit: 2},
+		{LabelSelector: "app=web", Limit: 2, Continue: "2"},
+		{LabelSelector: "app=web", Limit: 2, Continue: "4"},
+	}, requests)
+}
+
+func TestListPagesExpiredContinue(t *testing.T) {
+	run := newTestRun(nil)
+	run.chunkSize = 2
+
+	requests := []metav1.ListOptions{}
+	got := []string{}
+	err := listPages(run, metav1.ListOptions{}, func(listOpts metav1.ListOptions) (metav1.ListInterface, error) {
+		requests = append(requests, listOpts)
+		switch {
+		case listOpts.Continue != "":
+			return nil, apierrors.NewResourceExpired("continue token expired")
+		case listOpts.Limit == 0:
+			got = append(got, "a", "b", "c")
+			return &corev1.PodList{}, nil
+		default:
+			got = append(got, "a", "b")
+			return &corev1.PodList{ListMeta: metav1.ListMeta{Continue: "2"}}, nil
+		}
+	}, func() {
+		got = nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b", "c"}, got)
+	assert.Equal(t, []metav1.ListOptions{
+		{Limit: 2},
+		{Limit: 2, Continue: "2"},
+		{},
+	}, requests)
+}
+
+func TestListPagesError(t *testing.T) {
+	run := newTestRun(nil)
+	defer func() { waitToRetry = time.Sleep }()
+	waits := []time.Duration{}
+	waitToRetry = func(d time.Duration) { waits = append(waits, d) }
+
+	calls := 0
+	err := listPages(run, metav1.ListOptions{}, func(listOpts metav1.ListOptions) (metav1.ListInterface, error) {
+		calls++
+		return nil, apierrors.NewTooManyRequests("slow down", 1)
+	}, func() {})
+
+	assert.True(t, apierrors.IsTooManyRequests(err))
+	assert.Equal(t, listRetries+1, calls)
+	assert.Equal(t, []time.Duration{time.Second, time.Second, time.Second, time.Second, time.Second}, waits)
+}
+
+func TestListPagesRetriesThrottled(t *testing.T) {
+	run := newTestRun(nil)
+	defer func() { waitToRetry = time.Sleep }()
+	waits := []time.Duration{}
+	waitToRetry = func(d time.Duration) { waits = append(waits, d) }
+
+	// Without Retry-After the wait doubles from 1s
+	calls := 0
+	err := listPages(run, metav1.ListOptions{}, func(listOpts metav1.ListOptions) (metav1.ListInterface, error) {
+		calls++
+		if calls < 4 {
+			return nil, apierrors.NewTooManyRequests("slow down", 0)
+		}
+		return &corev1.PodList{}, nil
+	}, func() {})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []time.Duration{time.Second, 2 * time.Second, 4 * time.Second}, waits)
+
+	// Other errors are returned at once
+	waits = nil
+	err = listPages(run, metav1.ListOptions{}, func(listOpts metav1.ListOptions) (metav1.ListInterface, error) {
+		return nil, apierrors.NewForbidden(corev1.Resource("pods"), "", nil)
+	}, func() {})
+	assert.True(t, apierrors.IsForbidden(err))
+	assert.Empty(t, waits)
+}
+
+func TestGetPodsAndNodesByNode(t *testing.T) {
+	run := newTestRun(nil)
+
+	clientset := fake.NewSimpleClientset(
+		node("mynode", map[string]string{"hello": "world"}, false),
+		node("mynode2", map[string]string{}, false),
+		pod("mynode", "default", "mypod", map[string]string{"a": "test"}),
+		pod("mynode2", "default", "mypod2", map[string]string{"a": "test"}),
+		pod("mynode2", "other", "mypod3", map[string]string{"b": "test"}),
+	)
+
+	fieldSelectors := []string{}
+	clientset.PrependReactor("list", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		restrictions := action.(k8stesting.ListAction).GetListRestrictions()
+		fieldSelectors = append(fieldSelectors, restrictions.Fields.String())
+		return false, nil, nil
+	})
+
+	podList, nodeList, _ := getPodsAndNodes(run, clientset, listFilters{nodeLabels: "hello=world", podsByNode: true})
+	assert.Equal(t, []string{"mynode"}, listNodes(nodeList))
+	assert.Equal(t, []string{"spec.nodeName=mynode"}, fieldSelectors)
+
+	// The fake clientset ignores field selectors, so every pod comes back
+	// and only the ones on listed nodes are kept
+	assert.Equal(t, []string{"default/mypod"}, listPods(podList))
+}
@@ -0,0 +1,240 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	resourcehelper "k8s.io/kubectl/pkg/util/resource"
+)
+
+// pendingReasonUnknown is shown for pods the scheduler has not reported on
+const pendingReasonUnknown = "Unknown"
+
+// pendingPodFieldSelector selects the pods that have not been scheduled to
+// a node, so that the API server only returns those
+const pendingPodFieldSelector = "spec.nodeName=,status.phase=Pending"
+
+type pendingPodMetric struct {
+	namespace string
+	name      string
+	cpu       resource.Quantity
+	memory    resource.Quantity
+	reason    string
+}
+
+type listPendingPods struct {
+	Pods   []*listPendingPod `json:"pods"`
+	Totals *listPendingPod   `json:"totals"`
+}
+
+type listPendingPod struct {
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name,omitempty"`
+	Pods      int    `json:"pods,omitempty"`
+	CPU       string `json:"cpu"`
+	Memory    string `json:"memory"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// getPendingPods lists pods that have not been scheduled to a node, using
+// the same pod and namespace filters as the rest of the report
+func getPendingPods(run *reportRun, clientset kubernetes.Interface, filters listFilters) (*corev1.PodList, *fetchError) {
+	fieldSelector := pendingPodFieldSelector
+	if filters.podFieldSelector != "" {
+		fieldSelector += "," + filters.podFieldSelector
+	}
+
+	start := time.Now()
+	podList, err := listAllPods(context.TODO(), run, clientset, filters.namespace, metav1.ListOptions{
+		LabelSelector: filters.podLabels,
+		FieldSelector: fieldSelector,
+	})
+	if err != nil {
+		return nil, newFetchError(3, "Error listing Pods: %v", err)
+	}
+	run.logTiming(start, "Listed %d pending pods", len(podList.Items))
+	if err := filterPodsByFields(podList, fieldSelector); err != nil {
+		return nil, newFetchError(3, "Error parsing pod field selector: %v", err)
+	}
+
+	if filters.namespace == "" && filters.namespaceLabels != "" {
+		namespaces, ferr := listNamespaceNames(context.TODO(), run, clientset, filters.namespaceLabels)
+		if ferr != nil {
+			return nil, ferr
+		}
+		pendingPods := []corev1.Pod{}
+		for _, pod := range podList.Items {
+			if namespaces[pod.Namespace] {
+				pendingPods = append(pendingPods, pod)
+			}
+		}
+		podList.Items = pendingPods
+	}
+
+	return podList, nil
+}
+
+// buildPendingPodMetrics sums the requests of each pending pod, sorted by
+// namespace and name
+func buildPendingPodMetrics(podList *corev1.PodList) []*pendingPodMetric {
+	pendingPods := []*pendingPodMetric{}
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		req, _ := resourcehelper.PodRequestsAndLimits(pod)
+		pendingPods = append(pendingPods, &pendingPodMetric{
+			namespace: pod.Namespace,
+			name:      pod.Name,
+			cpu:       req["cpu"],
+			memory:    req["memory"],
+			reason:    unschedulableReason(pod),
+		})
+	}
+
+	sort.Slice(pendingPods, func(i, j int) bool {
+		if pendingPods[i].namespace != pendingPods[j].namespace {
+			return pendingPods[i].namespace < pendingPods[j].namespace
+		}
+		return pendingPods[i].name < pendingPods[j].name
+	})
+
+	return pendingPods
+}
+
+// unschedulableReason returns the reason the scheduler gave for the most
+// nodes in the PodScheduled condition of the pod
+func unschedulableReason(pod *corev1.Pod) string {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type != corev1.PodScheduled || condition.Status != corev1.ConditionFalse {
+			continue
+		}
+		if reason := dominantSchedulerReason(condition.Message); reason != "" {
+			return reason
+		}
+		if condition.Reason != "" {
+			return condition.Reason
+		}
+	}
+	return pendingReasonUnknown
+}
+
+// dominantSchedulerReason returns the entry of a scheduler message that
+// applies to the most nodes. Messages look like "0/5 nodes are available:
+// 3 Insufficient cpu, 2 node(s) had untolerated taint {gpu: true}.
+// preemption: ...", which gives "Insufficient cpu (3 nodes)".
+func dominantSchedulerReason(message string) string {
+	_, reasons, found := strings.Cut(message, "nodes are available: ")
+	if !found {
+		return ""
+	}
+	reasons, _, _ = strings.Cut(reasons, " preemption:")
+	reasons = strings.TrimSuffix(strings.TrimSpace(reasons), ".")
+
+	dominant, dominantCount := "", 0
+	for _, entry := range strings.Split(reasons, ", ") {
+		countStr, reason, found := strings.Cut(entry, " ")
+		count, err := strconv.Atoi(countStr)
+		if !found || err != nil || count <= dominantCount {
+			continue
+		}
+		dominant, dominantCount = strings.TrimPrefix(reason, "node(s) "), count
+	}
+	if dominant == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s (%d nodes)", dominant, dominantCount)
+}
+
+func printPending(run *reportRun, cm *clusterMetric, opts Options) error {
+	pp := &pendingPrinter{run: run, pods: buildPendingPodMetrics(cm.pendingPods)}
+	return pp.Print(opts.OutputFormat)
+}
+
+type pendingPrinter struct {
+	run  *reportRun
+	pods []*pendingPodMetric
+}
+
+func (pp *pendingPrinter) Print(outputType string) error {
+	switch outputType {
+	case JSONOutput, YAMLOutput:
+		printListOutput(pp.run, PendingPodsReportKind, pp.buildListPendingPods(), outputType)
+	case TableOutput:
+		w := tabwriter.NewWriter(pp.run.output, 0, 8, 2, ' ', 0)
+		pp.printTable(w, "\t ")
+		if err := w.Flush(); err != nil {
+			pp.run.logErrorf("Error writing to table: %s", err)
+		}
+	case CSVOutput:
+		pp.printTable(pp.run.output, ",")
+	case TSVOutput:
+		pp.printTable(pp.run.output, "\t")
+	default:
+		return fmt.Errorf("Called with an unsupported output type: %s", outputType)
+	}
+	return nil
+}
+
+func (pp *pendingPrinter) printTable(w io.Writer, separator string) {
+	_, _ = fmt.Fprintln(w, strings.Join([]string{"NAMESPACE", "POD", "CPU REQUESTS", "MEMORY REQUESTS", "REASON"}, separator))
+
+	list := pp.buildListPendingPods()
+	totals := list.Totals
+	_, _ = fmt.Fprintln(w, strings.Join([]string{
+		VoidValue, fmt.Sprintf("%s (%d pods)", VoidValue, totals.Pods), totals.CPU, totals.Memory, VoidValue,
+	}, separator))
+	for _, lp := range list.Pods {
+		reason := lp.Reason
+		if separator == "," {
+			reason = fmt.Sprintf("%q", reason)
+		}
+		_, _ = fmt.Fprintln(w, strings.Join([]string{lp.Namespace, lp.Name, lp.CPU, lp.Memory, reason}, separator))
+	}
+}
+
+func (pp *pendingPrinter) buildListPendingPods() *listPendingPods {
+	cpu := resourceMetric{resourceType: "cpu"}
+	memory := resourceMetric{resourceType: "memory"}
+
+	out := &listPendingPods{Pods: []*listPendingPod{}}
+	for _, pm := range pp.pods {
+		cpu.request.Add(pm.cpu)
+		memory.request.Add(pm.memory)
+		out.Pods = append(out.Pods, &listPendingPod{
+			Namespace: pm.namespace,
+			Name:      pm.name,
+			CPU:       cpu.valueFunction(pp.run)(pm.cpu),
+			Memory:    memory.valueFunction(pp.run)(pm.memory),
+			Reason:    pm.reason,
+		})
+	}
+	out.Totals = &listPendingPod{
+		Pods:   len(pp.pods),
+		CPU:    cpu.valueFunction(pp.run)(cpu.request),
+		Memory: memory.valueFunction(pp.run)(memory.request),
+	}
+	return out
+}
@@ -0,0 +1,85 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestDominantSchedulerReason(t *testing.T) {
+	assert.Equal(t, "Insufficient cpu (3 nodes)", dominantSchedulerReason(
+		"0/5 nodes are available: 3 Insufficient cpu, 2 node(s) had untolerated taint {gpu: true}. "+
+			"preemption: 0/5 nodes are available: 5 No preemption victims found for incoming pod."))
+	assert.Equal(t, "had untolerated taint {gpu: true} (4 nodes)", dominantSchedulerReason(
+		"0/5 nodes are available: 1 Insufficient memory, 4 node(s) had untolerated taint {gpu: true}."))
+	assert.Equal(t, "", dominantSchedulerReason("pod has unbound immediate PersistentVolumeClaims"))
+}
+
+func TestPendingPods(t *testing.T) {
+	run := newTestRun(nil)
+	waiting := pod("", "default", "waiting", map[string]string{})
+	waiting.Status.Phase = corev1.PodPending
+	waiting.Status.Conditions = []corev1.PodCondition{{
+		Type:    corev1.PodScheduled,
+		Status:  corev1.ConditionFalse,
+		Reason:  corev1.PodReasonUnschedulable,
+		Message: "0/2 nodes are available: 2 Insufficient memory.",
+	}}
+	waiting.Spec.Containers = []corev1.Container{{
+		Name: "app",
+		Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{
+			"cpu":    resource.MustParse("500m"),
+			"memory": resource.MustParse("8Gi"),
+		}},
+	}}
+
+	unscheduled := pod("", "batch", "new", map[string]string{})
+	unscheduled.Status.Phase = corev1.PodPending
+
+	pulling := pod("mynode", "default", "pulling", map[string]string{})
+	pulling.Status.Phase = corev1.PodPending
+
+	clientset := fake.NewSimpleClientset(waiting, unscheduled, pulling)
+	podList, ferr := getPendingPods(run, clientset, listFilters{})
+	require.Nil(t, ferr)
+	require.Len(t, podList.Items, 2)
+
+	// Only unscheduled pods are asked for, along with the user's selector
+	clientset.ClearActions()
+	podList, ferr = getPendingPods(run, clientset, listFilters{podFieldSelector: "metadata.namespace=batch"})
+	require.Nil(t, ferr)
+	require.Len(t, podList.Items, 1)
+	list := clientset.Actions()[0].(k8stesting.ListAction)
+	assert.Equal(t, "spec.nodeName=,status.phase=Pending,metadata.namespace=batch", list.GetListRestrictions().Fields.String())
+
+	podList, ferr = getPendingPods(run, clientset, listFilters{})
+	require.Nil(t, ferr)
+
+	pp := &pendingPrinter{run: run, pods: buildPendingPodMetrics(podList)}
+	assert.Equal(t, &listPendingPods{
+		Pods: []*listPendingPod{
+			{Namespace: "batch", Name: "new", CPU: "0m", Memory: "0Mi", Reason: pendingReasonUnknown},
+			{Namespace: "default", Name: "waiting", CPU: "500m", Memory: "8192Mi", Reason: "Insufficient memory (2 nodes)"},
+		},
+		Totals: &listPendingPod{Pods: 2, CPU: "500m", Memory: "8192Mi"},
+	}, pp.buildListPendingPods())
+}
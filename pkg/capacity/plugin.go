@@ -0,0 +1,186 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	v1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+)
+
+const (
+	// PluginPrefix is the prefix of the executables on PATH that are run as
+	// plugins, such as kube-capacity-slack for --output slack
+	PluginPrefix = "kube-capacity-"
+	// PluginProtocolVersion is passed to plugins in
+	// KUBE_CAPACITY_PLUGIN_VERSION, so they can tell what they are sent and
+	// what they are expected to print
+	PluginProtocolVersion = "v1"
+
+	// A plugin is run with print to print a report, or with metrics to print
+	// utilization
+	printPluginCommand   = "print"
+	metricsPluginCommand = "metrics"
+
+	// PluginMetricsKind is the kind of what metrics plugins print
+	PluginMetricsKind = "PluginMetrics"
+
+	// pluginMetricsSource is the metrics source of a --metrics-source that
+	// is read from a plugin
+	pluginMetricsSource = "plugin"
+)
+
+var pluginNamePattern = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9_-]*$`)
+
+// FindPlugin returns the path of the kube-capacity-<name> executable on
+// PATH
+func FindPlugin(name string) (string, error) {
+	if !pluginNamePattern.MatchString(name) {
+		return "", fmt.Errorf("invalid plugin name %q", name)
+	}
+	return exec.LookPath(PluginPrefix + name)
+}
+
+// ListPlugins returns the names of the kube-capacity-<name> executables on
+// PATH, without the prefix
+func ListPlugins() []string {
+	seen := map[string]bool{}
+	names := []string{}
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		if dir == "" {
+			continue
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			if !strings.HasPrefix(entry.Name(), PluginPrefix) {
+				continue
+			}
+			name := strings.TrimPrefix(entry.Name(), PluginPrefix)
+			if runtime.GOOS == "windows" {
+				name = strings.TrimSuffix(name, filepath.Ext(name))
+			}
+			if seen[name] {
+				continue
+			}
+			if _, err := FindPlugin(name); err != nil {
+				continue
+			}
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// pluginEnv is the environment a plugin is run with, on top of that of
+// kube-capacity, so it can read from the same cluster
+func pluginEnv(opts Options) []string {
+	return append(os.Environ(),
+		"KUBE_CAPACITY_PLUGIN_VERSION="+PluginProtocolVersion,
+		"KUBE_CAPACITY_CONTEXT="+opts.KubeContext,
+		"KUBE_CAPACITY_KUBECONFIG="+opts.KubeConfig,
+		"KUBE_CAPACITY_NAMESPACE="+opts.Namespace,
+		"KUBE_CAPACITY_NODE_LABELS="+opts.NodeLabels,
+	)
+}
+
+// outputPlugin collects the JSON report so that it can be sent to a
+// printer plugin, which prints it in place of kube-capacity
+type outputPlugin struct {
+	path   string
+	env    []string
+	report bytes.Buffer
+	dest   io.Writer
+	errOut io.Writer
+}
+
+func (op *outputPlugin) Write(p []byte) (int, error) {
+	return op.report.Write(p)
+}
+
+// run runs the plugin with the report on stdin, printing what it prints
+// where the report would have been printed
+func (op *outputPlugin) run() error {
+	cmd := exec.Command(op.path, printPluginCommand)
+	cmd.Env = op.env
+	cmd.Stdin = &op.report
+	cmd.Stdout = op.dest
+	cmd.Stderr = op.errOut
+	return cmd.Run()
+}
+
+// pluginMetrics is what a metrics plugin prints, the usage of pods and
+// optionally nodes in the format of the metrics.k8s.io API
+type pluginMetrics struct {
+	listTypeMeta
+	Pods  []v1beta1.PodMetrics  `json:"pods"`
+	Nodes []v1beta1.NodeMetrics `json:"nodes"`
+}
+
+// getPluginMetrics runs the metrics plugin of --metrics-source and reads
+// the utilization it prints. Nodes are summed from their pods when the
+// plugin leaves them out.
+func getPluginMetrics(ctx context.Context, run *reportRun, opts Options) (*v1beta1.PodMetricsList, *v1beta1.NodeMetricsList, error) {
+	start := time.Now()
+	cmd := exec.CommandContext(ctx, opts.MetricsPlugin, metricsPluginCommand)
+	cmd.Env = pluginEnv(opts)
+	out, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+			return nil, nil, fmt.Errorf("%s", strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, nil, err
+	}
+
+	metrics := &pluginMetrics{}
+	if err := json.Unmarshal(out, metrics); err != nil {
+		return nil, nil, fmt.Errorf("parsing output: %w", err)
+	}
+	if metrics.APIVersion != "" && metrics.APIVersion != ReportAPIVersion {
+		return nil, nil, fmt.Errorf("unsupported apiVersion %q, expected %s", metrics.APIVersion, ReportAPIVersion)
+	}
+	if metrics.Kind != "" && metrics.Kind != PluginMetricsKind {
+		return nil, nil, fmt.Errorf("unsupported kind %q, expected %s", metrics.Kind, PluginMetricsKind)
+	}
+
+	pmList := &v1beta1.PodMetricsList{}
+	for _, pm := range metrics.Pods {
+		if opts.Namespace == "" || pm.Namespace == opts.Namespace {
+			pmList.Items = append(pmList.Items, pm)
+		}
+	}
+	var nmList *v1beta1.NodeMetricsList
+	if len(metrics.Nodes) > 0 {
+		nmList = &v1beta1.NodeMetricsList{Items: metrics.Nodes}
+	}
+	run.logTiming(start, "Read %d pod metrics from plugin %s", len(pmList.Items), filepath.Base(opts.MetricsPlugin))
+	return pmList, nmList, nil
+}
@@ -0,0 +1,115 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writePlugin writes a shell script plugin to dir
+func writePlugin(t *testing.T, dir, name, script string) string {
+	path := filepath.Join(dir, PluginPrefix+name)
+	require.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0o700))
+	return path
+}
+
+func TestFindPlugin(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses shell scripts as plugins")
+	}
+	dir := t.TempDir()
+	path := writePlugin(t, dir, "slack", "exit 0\n")
+	writePlugin(t, dir, "datadog_v2", "exit 0\n")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, PluginPrefix+"notes"), []byte("not a plugin"), 0o600))
+	t.Setenv("PATH", dir)
+
+	found, err := FindPlugin("slack")
+	require.NoError(t, err)
+	assert.Equal(t, path, found)
+
+	_, err = FindPlugin("notes")
+	assert.Error(t, err)
+	_, err = FindPlugin("missing")
+	assert.Error(t, err)
+	_, err = FindPlugin("../slack")
+	assert.EqualError(t, err, `invalid plugin name "../slack"`)
+
+	assert.Equal(t, []string{"datadog_v2", "slack"}, ListPlugins())
+}
+
+func TestOutputPlugin(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses shell scripts as plugins")
+	}
+	path := writePlugin(t, t.TempDir(), "upper", `echo "$1 $KUBE_CAPACITY_PLUGIN_VERSION $KUBE_CAPACITY_NAMESPACE"
+tr a-z A-Z
+`)
+
+	var buf bytes.Buffer
+	run := newRun(Options{OutputPlugin: path, Namespace: "default", Out: &buf})
+	fmt.Fprint(run.output, `{"kind": "ClusterReport"}`)
+	assert.Empty(t, buf.String())
+
+	require.NoError(t, run.closeOutput())
+	assert.Equal(t, "print v1 default\n{\"KIND\": \"CLUSTERREPORT\"}", buf.String())
+}
+
+func TestPluginMetrics(t *testing.T) {
+	run := newTestRun(nil)
+	if runtime.GOOS == "windows" {
+		t.Skip("uses shell scripts as plugins")
+	}
+	dir := t.TempDir()
+	path := writePlugin(t, dir, "usage", `if [ "$1" != metrics ]; then
+  echo "unexpected command $1" >&2
+  exit 1
+fi
+cat <<'JSON'
+{
+  "apiVersion": "kubecapacity.io/v1",
+  "kind": "PluginMetrics",
+  "pods": [
+    {"metadata": {"name": "mypod", "namespace": "default"}, "containers": [{"name": "app", "usage": {"cpu": "50m", "memory": "64Mi"}}]},
+    {"metadata": {"name": "mypod2", "namespace": "other"}, "containers": [{"name": "app", "usage": {"cpu": "70m", "memory": "96Mi"}}]}
+  ]
+}
+JSON
+`)
+
+	pmList, nmList, err := getPluginMetrics(context.Background(), run, Options{MetricsSource: "usage", MetricsPlugin: path, Namespace: "default"})
+	require.NoError(t, err)
+	assert.Nil(t, nmList)
+	require.Len(t, pmList.Items, 1)
+	assert.Equal(t, "mypod", pmList.Items[0].Name)
+	cpu := pmList.Items[0].Containers[0].Usage["cpu"]
+	assert.Equal(t, "50m", cpu.String())
+
+	path = writePlugin(t, dir, "broken", "echo 'no credentials for the metrics API' >&2\nexit 1\n")
+	_, _, err = getPluginMetrics(context.Background(), run, Options{MetricsPlugin: path})
+	assert.EqualError(t, err, "no credentials for the metrics API")
+
+	path = writePlugin(t, dir, "future", `echo '{"apiVersion": "kubecapacity.io/v2", "pods": []}'`+"\n")
+	_, _, err = getPluginMetrics(context.Background(), run, Options{MetricsPlugin: path})
+	assert.EqualError(t, err, `unsupported apiVersion "kubecapacity.io/v2", expected kubecapacity.io/v1`)
+}
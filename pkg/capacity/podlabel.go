@@ -0,0 +1,226 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// unlabeledPodGroup collects the pods without the label of
+// --allocate-by-pod-label
+const unlabeledPodGroup = "<unlabeled>"
+
+type listPodLabelAllocation struct {
+	Label         string               `json:"label"`
+	ClusterTotals *listPodLabelValue   `json:"clusterTotals"`
+	Values        []*listPodLabelValue `json:"values"`
+	Idle          *listPodGroupCost    `json:"idle,omitempty"`
+}
+
+type listPodLabelValue struct {
+	Name   string                `json:"name,omitempty"`
+	Pods   int64                 `json:"pods"`
+	CPU    *listPodLabelResource `json:"cpu"`
+	Memory *listPodLabelResource `json:"memory"`
+	Cost   *listPodGroupCost     `json:"cost,omitempty"`
+}
+
+type listPodLabelResource struct {
+	Requests       string `json:"requests"`
+	RequestsPct    string `json:"requestsPercent"`
+	Limits         string `json:"limits"`
+	LimitsPct      string `json:"limitsPercent"`
+	Utilization    string `json:"utilization,omitempty"`
+	UtilizationPct string `json:"utilizationPercent,omitempty"`
+}
+
+// podLabelValue returns the value of the label of --allocate-by-pod-label
+// on a pod, or unlabeledPodGroup without it
+func podLabelValue(pm *podMetric, label string) string {
+	if value := pm.labels[label]; value != "" {
+		return value
+	}
+	return unlabeledPodGroup
+}
+
+// buildPodLabelMetrics sums the pods of the cluster by the value of a label,
+// returning the cluster totals followed by each value in name order, with
+// unlabeled pods last
+func buildPodLabelMetrics(cm *clusterMetric, label string) (*namespaceMetric, []*namespaceMetric) {
+	totals := &namespaceMetric{
+		cpu:    &resourceMetric{resourceType: "cpu", allocatable: cm.cpu.allocatable},
+		memory: &resourceMetric{resourceType: "memory", allocatable: cm.memory.allocatable},
+	}
+	values := []*namespaceMetric{}
+	for _, lm := range cm.getPodGroupMetrics(func(pm *podMetric) string {
+		return podLabelValue(pm, label)
+	}) {
+		for _, r := range []struct{ total, value *resourceMetric }{{totals.cpu, lm.cpu}, {totals.memory, lm.memory}} {
+			r.total.request.Add(r.value.request)
+			r.total.limit.Add(r.value.limit)
+			r.total.utilization.Add(r.value.utilization)
+		}
+		totals.podCount += lm.podCount
+		values = append(values, lm)
+	}
+	sort.Slice(values, func(i, j int) bool {
+		if (values[i].name == unlabeledPodGroup) != (values[j].name == unlabeledPodGroup) {
+			return values[j].name == unlabeledPodGroup
+		}
+		return values[i].name < values[j].name
+	})
+	return totals, values
+}
+
+func printPodLabelAllocation(run *reportRun, cm *clusterMetric, opts Options) error {
+	plp := &podLabelPrinter{run: run, opts: opts}
+	plp.totals, plp.values = buildPodLabelMetrics(cm, opts.AllocateByPodLabel)
+	if opts.ShowCost {
+		plp.costTotals, plp.costs, plp.idle = buildPodGroupCosts(cm, AllocateCostByRequests, showIdleCost(opts), func(pm *podMetric) string {
+			return podLabelValue(pm, opts.AllocateByPodLabel)
+		})
+	}
+	return plp.Print(opts.OutputFormat)
+}
+
+type podLabelPrinter struct {
+	run    *reportRun
+	totals *namespaceMetric
+	values []*namespaceMetric
+
+	// costTotals, costs, and idle are only set with --cost
+	costTotals *namespaceCost
+	costs      []*namespaceCost
+	idle       *namespaceCost
+
+	opts Options
+}
+
+func (plp *podLabelPrinter) Print(outputType string) error {
+	switch outputType {
+	case JSONOutput, YAMLOutput:
+		printListOutput(plp.run, PodLabelAllocationReportKind, plp.buildListPodLabelAllocation(), outputType)
+	case TableOutput:
+		w := tabwriter.NewWriter(plp.run.output, 0, 8, 2, ' ', 0)
+		plp.printTable(w, "\t ")
+		if err := w.Flush(); err != nil {
+			plp.run.logErrorf("Error writing to table: %s", err)
+		}
+	case CSVOutput:
+		plp.printTable(plp.run.output, ",")
+	case TSVOutput:
+		plp.printTable(plp.run.output, "\t")
+	default:
+		return fmt.Errorf("Called with an unsupported output type: %s", outputType)
+	}
+	return nil
+}
+
+func (plp *podLabelPrinter) printTable(w io.Writer, separator string) {
+	headers := []string{strings.ToUpper(plp.opts.AllocateByPodLabel), "PODS"}
+	for _, prefix := range []string{"CPU", "MEMORY"} {
+		headers = append(headers, prefix+" REQUESTS", prefix+" LIMITS")
+		if plp.opts.ShowUtil {
+			headers = append(headers, prefix+" UTIL")
+		}
+	}
+	if plp.opts.ShowCost {
+		headers = append(headers, "COST/HOUR", "COST/MONTH", "SHARE")
+	}
+	_, _ = fmt.Fprintln(w, strings.Join(headers, separator))
+
+	list := plp.buildListPodLabelAllocation()
+	list.ClusterTotals.Name = VoidValue
+	for _, lv := range append([]*listPodLabelValue{list.ClusterTotals}, list.Values...) {
+		items := []string{lv.Name, fmt.Sprintf("%d", lv.Pods)}
+		for _, r := range []*listPodLabelResource{lv.CPU, lv.Memory} {
+			items = append(items,
+				fmt.Sprintf("%s (%s)", r.Requests, r.RequestsPct),
+				fmt.Sprintf("%s (%s)", r.Limits, r.LimitsPct))
+			if plp.opts.ShowUtil {
+				items = append(items, fmt.Sprintf("%s (%s)", r.Utilization, r.UtilizationPct))
+			}
+		}
+		if lv.Cost != nil {
+			items = append(items, lv.Cost.Hourly, lv.Cost.Monthly, lv.Cost.Share)
+		}
+		_, _ = fmt.Fprintln(w, strings.Join(items, separator))
+	}
+
+	if list.Idle != nil {
+		items := []string{idleNamespaceName}
+		for i := 1; i < len(headers)-3; i++ {
+			items = append(items, VoidValue)
+		}
+		items = append(items, list.Idle.Hourly, list.Idle.Monthly, list.Idle.Share)
+		_, _ = fmt.Fprintln(w, strings.Join(items, separator))
+	}
+}
+
+func (plp *podLabelPrinter) buildListPodLabelAllocation() *listPodLabelAllocation {
+	costs := map[string]*namespaceCost{}
+	for _, nc := range plp.costs {
+		costs[nc.name] = nc
+	}
+
+	out := &listPodLabelAllocation{
+		Label:         plp.opts.AllocateByPodLabel,
+		ClusterTotals: plp.listPodLabelValue(plp.totals, plp.costTotals),
+		Values:        []*listPodLabelValue{},
+	}
+	for _, lm := range plp.values {
+		out.Values = append(out.Values, plp.listPodLabelValue(lm, costs[lm.name]))
+	}
+	if plp.idle != nil {
+		out.Idle = newListPodGroupCost(plp.idle.hourly, plp.costTotals.hourly)
+	}
+	return out
+}
+
+func (plp *podLabelPrinter) listPodLabelValue(lm *namespaceMetric, nc *namespaceCost) *listPodLabelValue {
+	out := &listPodLabelValue{
+		Name:   lm.name,
+		Pods:   lm.podCount,
+		CPU:    plp.listPodLabelResource(lm.cpu),
+		Memory: plp.listPodLabelResource(lm.memory),
+	}
+	if plp.opts.ShowCost {
+		hourly := 0.0
+		if nc != nil {
+			hourly = nc.hourly
+		}
+		out.Cost = newListPodGroupCost(hourly, plp.costTotals.hourly)
+	}
+	return out
+}
+
+func (plp *podLabelPrinter) listPodLabelResource(rm *resourceMetric) *listPodLabelResource {
+	valueCalculator := rm.valueFunction(plp.run)
+	out := &listPodLabelResource{
+		Requests:    valueCalculator(rm.request),
+		RequestsPct: percentString(plp.run, rm.request, rm.allocatable),
+		Limits:      valueCalculator(rm.limit),
+		LimitsPct:   percentString(plp.run, rm.limit, rm.allocatable),
+	}
+	if plp.opts.ShowUtil {
+		out.Utilization = valueCalculator(rm.utilization)
+		out.UtilizationPct = percentString(plp.run, rm.utilization, rm.utilBase(plp.opts.UtilPercent))
+	}
+	return out
+}
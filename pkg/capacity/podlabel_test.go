@@ -0,0 +1,72 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPodLabelAllocation(t *testing.T) {
+	run := newTestRun(nil)
+	snap := getTestSnapshot()
+	cm := buildClusterMetric(snap.Pods, snap.PodMetrics, snap.Nodes, snap.NodeMetrics)
+
+	plp := &podLabelPrinter{run: run, opts: Options{AllocateByPodLabel: "a", ShowUtil: true}}
+	plp.totals, plp.values = buildPodLabelMetrics(&cm, "a")
+	require.Len(t, plp.values, 2)
+
+	list := plp.buildListPodLabelAllocation()
+	assert.Equal(t, "a", list.Label)
+	assert.Equal(t, "test", list.Values[0].Name)
+	assert.Equal(t, unlabeledPodGroup, list.Values[1].Name)
+	assert.Equal(t, int64(2), list.ClusterTotals.Pods)
+	assert.Equal(t, &listPodLabelResource{
+		Requests:       "100m",
+		RequestsPct:    "5%",
+		Limits:         "0m",
+		LimitsPct:      "0%",
+		Utilization:    "70m",
+		UtilizationPct: "3%",
+	}, list.Values[1].CPU)
+	assert.Nil(t, list.Values[0].Cost)
+	assert.Nil(t, list.Idle)
+}
+
+func TestPodLabelAllocationWithCost(t *testing.T) {
+	run := newTestRun(nil)
+	cm := getTestCostClusterMetric(t)
+	opts := Options{AllocateByPodLabel: "a", ShowCost: true}
+
+	plp := &podLabelPrinter{run: run, opts: opts}
+	plp.totals, plp.values = buildPodLabelMetrics(&cm, "a")
+	plp.costTotals, plp.costs, plp.idle = buildPodGroupCosts(&cm, AllocateCostByRequests, showIdleCost(opts), func(pm *podMetric) string {
+		return podLabelValue(pm, "a")
+	})
+
+	var buf bytes.Buffer
+	plp.printTable(&buf, ",")
+	assert.Equal(t, strings.Join([]string{
+		"A,PODS,CPU REQUESTS,CPU LIMITS,MEMORY REQUESTS,MEMORY LIMITS,COST/HOUR,COST/MONTH,SHARE",
+		"*,2,200m (10%),0m (0%),256Mi (3%),0Mi (0%),2.000,1460.00,100.0%",
+		"test,1,100m (5%),0m (0%),128Mi (1%),0Mi (0%),0.066,48.18,3.3%",
+		"<unlabeled>,1,100m (5%),0m (0%),128Mi (1%),0Mi (0%),0.066,48.18,3.3%",
+		"<idle>,*,*,*,*,*,1.868,1363.64,93.4%",
+	}, "\n")+"\n", buf.String())
+}
@@ -0,0 +1,355 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// preemptionTarget is the pod --preemption makes room for
+type preemptionTarget struct {
+	cpu      resource.Quantity
+	memory   resource.Quantity
+	priority int32
+}
+
+// preemptionNode is a node the target could be scheduled on once its
+// victims, lower priority pods, are preempted. Nodes the target fits on
+// without preempting anything have no victims.
+type preemptionNode struct {
+	nm      *nodeMetric
+	victims []*podMetric
+}
+
+type listPreemption struct {
+	Pod   *listPreemptionTarget `json:"pod"`
+	Nodes []*listPreemptionNode `json:"nodes"`
+}
+
+type listPreemptionTarget struct {
+	CPU      string `json:"cpu"`
+	Memory   string `json:"memory"`
+	Priority int32  `json:"priority"`
+}
+
+type listPreemptionNode struct {
+	Name            string               `json:"name"`
+	Victims         int                  `json:"victims"`
+	HighestPriority *int32               `json:"highestPriority,omitempty"`
+	CPU             string               `json:"cpu"`
+	Memory          string               `json:"memory"`
+	Pods            []*listPreemptionPod `json:"pods"`
+}
+
+type listPreemptionPod struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	Priority  int32  `json:"priority"`
+	CPU       string `json:"cpu"`
+	Memory    string `json:"memory"`
+}
+
+// ValidatePreemption returns an error for a --preemption that is not a pod
+// shape and priority
+func ValidatePreemption(value string) error {
+	_, err := parsePreemptionTarget(value)
+	return err
+}
+
+// parsePreemptionTarget parses a pod shape and priority in the form
+// cpu=2,memory=4Gi,priority=1000. The priority is required along with at
+// least one of cpu and memory.
+func parsePreemptionTarget(value string) (*preemptionTarget, error) {
+	target := &preemptionTarget{}
+	seen := map[string]bool{}
+	for _, field := range strings.Split(value, ",") {
+		key, val, found := strings.Cut(strings.TrimSpace(field), "=")
+		if !found || val == "" {
+			return nil, fmt.Errorf("expected key=value, got %q", field)
+		}
+		if seen[key] {
+			return nil, fmt.Errorf("%s is given more than once", key)
+		}
+		seen[key] = true
+
+		switch key {
+		case "cpu", "memory":
+			q, err := resource.ParseQuantity(val)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s %q: %w", key, val, err)
+			}
+			if q.Sign() < 0 {
+				return nil, fmt.Errorf("%s can not be negative", key)
+			}
+			if key == "cpu" {
+				target.cpu = q
+			} else {
+				target.memory = q
+			}
+		case "priority":
+			priority, err := strconv.ParseInt(val, 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("invalid priority %q, expected a 32-bit integer", val)
+			}
+			target.priority = int32(priority)
+		default:
+			return nil, fmt.Errorf("unsupported key %q, expected cpu, memory, or priority", key)
+		}
+	}
+	if !seen["priority"] {
+		return nil, fmt.Errorf("priority is required, such as cpu=2,memory=4Gi,priority=1000")
+	}
+	if !seen["cpu"] && !seen["memory"] {
+		return nil, fmt.Errorf("at least one of cpu and memory is required")
+	}
+	return target, nil
+}
+
+// buildPreemptionNodes returns the nodes the target could be scheduled on,
+// with the pods that would be preempted to make room, in the order the
+// scheduler prefers them
+func buildPreemptionNodes(cm *clusterMetric, target *preemptionTarget) []*preemptionNode {
+	nodes := []*preemptionNode{}
+	for _, nm := range cm.getSortedNodeMetrics("name") {
+		// The target has no constraints of its own, so only cordoned and
+		// tainted nodes are ruled out
+		if !(&schedulingConstraints{}).allows(nm.name, nm.labels, nm.taints, nm.unschedulable) {
+			continue
+		}
+		if victims, ok := selectVictims(nm, target); ok {
+			nodes = append(nodes, &preemptionNode{nm: nm, victims: victims})
+		}
+	}
+
+	sort.SliceStable(nodes, func(i, j int) bool {
+		return nodes[i].preferredTo(nodes[j])
+	})
+	return nodes
+}
+
+// selectVictims picks the pods to preempt on a node as the scheduler does:
+// every lower priority pod is removed, and then as many as still leave
+// room are added back, most important first. It returns false when the
+// target would not fit even with all of them removed.
+func selectVictims(nm *nodeMetric, target *preemptionTarget) ([]*podMetric, bool) {
+	cpu := nm.cpu.allocatable.DeepCopy()
+	cpu.Sub(nm.cpu.request)
+	memory := nm.memory.allocatable.DeepCopy()
+	memory.Sub(nm.memory.request)
+	pods := nm.podCount.allocatable - nm.podCount.current
+	fits := func() bool {
+		return pods >= 1 && cpu.Cmp(target.cpu) >= 0 && memory.Cmp(target.memory) >= 0
+	}
+	if fits() {
+		return []*podMetric{}, true
+	}
+
+	candidates := []*podMetric{}
+	for _, pm := range nm.podMetrics {
+		if pm.priority < target.priority {
+			candidates = append(candidates, pm)
+		}
+	}
+	for _, pm := range candidates {
+		cpu.Add(pm.cpu.request)
+		memory.Add(pm.memory.request)
+		pods++
+	}
+	if !fits() {
+		return nil, false
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].priority != candidates[j].priority {
+			return candidates[i].priority > candidates[j].priority
+		}
+		if !candidates[i].started.Equal(candidates[j].started) {
+			return candidates[i].started.Before(candidates[j].started)
+		}
+		if candidates[i].namespace != candidates[j].namespace {
+			return candidates[i].namespace < candidates[j].namespace
+		}
+		return candidates[i].name < candidates[j].name
+	})
+
+	victims := []*podMetric{}
+	for _, pm := range candidates {
+		cpu.Sub(pm.cpu.request)
+		memory.Sub(pm.memory.request)
+		pods--
+		if fits() {
+			continue
+		}
+		cpu.Add(pm.cpu.request)
+		memory.Add(pm.memory.request)
+		pods++
+		victims = append(victims, pm)
+	}
+
+	// Victims are listed least important first, the order they would go in
+	for i, j := 0, len(victims)-1; i < j; i, j = i+1, j-1 {
+		victims[i], victims[j] = victims[j], victims[i]
+	}
+	return victims, true
+}
+
+// preferredTo orders nodes as the scheduler picks one to preempt on: the
+// lowest highest victim priority, then the lowest sum of victim priorities,
+// then the fewest victims. PodDisruptionBudgets are not taken into account.
+func (pn *preemptionNode) preferredTo(other *preemptionNode) bool {
+	if len(pn.victims) == 0 || len(other.victims) == 0 {
+		if len(pn.victims) != len(other.victims) {
+			return len(pn.victims) == 0
+		}
+		return pn.nm.name < other.nm.name
+	}
+	if a, b := pn.highestPriority(), other.highestPriority(); a != b {
+		return a < b
+	}
+	if a, b := pn.prioritySum(), other.prioritySum(); a != b {
+		return a < b
+	}
+	if len(pn.victims) != len(other.victims) {
+		return len(pn.victims) < len(other.victims)
+	}
+	return pn.nm.name < other.nm.name
+}
+
+func (pn *preemptionNode) highestPriority() int32 {
+	highest := int32(math.MinInt32)
+	for _, pm := range pn.victims {
+		if pm.priority > highest {
+			highest = pm.priority
+		}
+	}
+	return highest
+}
+
+// prioritySum offsets every priority to be positive, so that more victims
+// never sum to less, as the scheduler does
+func (pn *preemptionNode) prioritySum() int64 {
+	sum := int64(0)
+	for _, pm := range pn.victims {
+		sum += int64(pm.priority) + int64(math.MaxInt32) + 1
+	}
+	return sum
+}
+
+func printPreemption(run *reportRun, cm *clusterMetric, opts Options) error {
+	target, err := parsePreemptionTarget(opts.Preemption)
+	if err != nil {
+		return fmt.Errorf("Error parsing --preemption: %v", err)
+	}
+
+	pp := &preemptionPrinter{run: run, target: target, nodes: buildPreemptionNodes(cm, target)}
+	if len(pp.nodes) == 0 {
+		run.logWarnf("No node has room for the pod even after preempting every pod with a priority below %d", target.priority)
+	}
+	return pp.Print(opts.OutputFormat)
+}
+
+type preemptionPrinter struct {
+	run    *reportRun
+	target *preemptionTarget
+	nodes  []*preemptionNode
+}
+
+func (pp *preemptionPrinter) Print(outputType string) error {
+	switch outputType {
+	case JSONOutput, YAMLOutput:
+		printListOutput(pp.run, PreemptionReportKind, pp.buildListPreemption(), outputType)
+	case TableOutput:
+		w := tabwriter.NewWriter(pp.run.output, 0, 8, 2, ' ', 0)
+		pp.printTable(w, "\t ")
+		if err := w.Flush(); err != nil {
+			pp.run.logErrorf("Error writing to table: %s", err)
+		}
+	case CSVOutput:
+		pp.printTable(pp.run.output, ",")
+	case TSVOutput:
+		pp.printTable(pp.run.output, "\t")
+	default:
+		return fmt.Errorf("Called with an unsupported output type: %s", outputType)
+	}
+	return nil
+}
+
+// printTable prints a row for each node with the victims in total, followed
+// by a row for each victim
+func (pp *preemptionPrinter) printTable(w io.Writer, separator string) {
+	_, _ = fmt.Fprintln(w, strings.Join([]string{"NODE", "NAMESPACE", "POD", "PRIORITY", "CPU REQUESTS", "MEMORY REQUESTS"}, separator))
+
+	for _, ln := range pp.buildListPreemption().Nodes {
+		priority := VoidValue
+		if ln.HighestPriority != nil {
+			priority = fmt.Sprintf("%d", *ln.HighestPriority)
+		}
+		_, _ = fmt.Fprintln(w, strings.Join([]string{
+			ln.Name, VoidValue, fmt.Sprintf("%s (%d pods)", VoidValue, ln.Victims), priority, ln.CPU, ln.Memory,
+		}, separator))
+		for _, lp := range ln.Pods {
+			_, _ = fmt.Fprintln(w, strings.Join([]string{
+				ln.Name, lp.Namespace, lp.Name, fmt.Sprintf("%d", lp.Priority), lp.CPU, lp.Memory,
+			}, separator))
+		}
+	}
+}
+
+// buildListPreemption lists the nodes in the order the scheduler prefers
+// them, with the requests their victims would free
+func (pp *preemptionPrinter) buildListPreemption() *listPreemption {
+	cpuFunc := resourceMetric{resourceType: "cpu"}.valueFunction(pp.run)
+	memoryFunc := resourceMetric{resourceType: "memory"}.valueFunction(pp.run)
+
+	out := &listPreemption{
+		Pod: &listPreemptionTarget{
+			CPU:      cpuFunc(pp.target.cpu),
+			Memory:   memoryFunc(pp.target.memory),
+			Priority: pp.target.priority,
+		},
+		Nodes: []*listPreemptionNode{},
+	}
+	for _, pn := range pp.nodes {
+		var cpu, memory resource.Quantity
+		ln := &listPreemptionNode{Name: pn.nm.name, Victims: len(pn.victims), Pods: []*listPreemptionPod{}}
+		for _, pm := range pn.victims {
+			cpu.Add(pm.cpu.request)
+			memory.Add(pm.memory.request)
+			ln.Pods = append(ln.Pods, &listPreemptionPod{
+				Namespace: pm.namespace,
+				Name:      pm.name,
+				Priority:  pm.priority,
+				CPU:       cpuFunc(pm.cpu.request),
+				Memory:    memoryFunc(pm.memory.request),
+			})
+		}
+		if len(pn.victims) > 0 {
+			highest := pn.highestPriority()
+			ln.HighestPriority = &highest
+		}
+		ln.CPU = cpuFunc(cpu)
+		ln.Memory = memoryFunc(memory)
+		out.Nodes = append(out.Nodes, ln)
+	}
+	return out
+}
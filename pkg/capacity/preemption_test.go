@@ -0,0 +1,133 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestParsePreemptionTarget(t *testing.T) {
+	target, err := parsePreemptionTarget("cpu=2, memory=4Gi, priority=-5")
+	require.NoError(t, err)
+	assert.Equal(t, "2", target.cpu.String())
+	assert.Equal(t, "4Gi", target.memory.String())
+	assert.Equal(t, int32(-5), target.priority)
+
+	target, err = parsePreemptionTarget("memory=512Mi,priority=1000")
+	require.NoError(t, err)
+	assert.True(t, target.cpu.IsZero())
+
+	for value, expected := range map[string]string{
+		"cpu=2":                     "priority is required, such as cpu=2,memory=4Gi,priority=1000",
+		"priority=1000":             "at least one of cpu and memory is required",
+		"cpu=2,gpu=1,priority=1":    `unsupported key "gpu", expected cpu, memory, or priority`,
+		"cpu=2,cpu=3,priority=1":    "cpu is given more than once",
+		"cpu,priority=1":            `expected key=value, got "cpu"`,
+		"cpu=-1,priority=1":         "cpu can not be negative",
+		"cpu=2,priority=high":       `invalid priority "high", expected a 32-bit integer`,
+		"cpu=2,priority=3000000000": `invalid priority "3000000000", expected a 32-bit integer`,
+	} {
+		_, err := parsePreemptionTarget(value)
+		assert.EqualError(t, err, expected, value)
+	}
+
+	_, err = parsePreemptionTarget("memory=lots,priority=10")
+	assert.ErrorContains(t, err, `invalid memory "lots"`)
+}
+
+func TestPreemption(t *testing.T) {
+	run := newTestRun(nil)
+	priorityPod := func(node, name string, priority int32, cpu, memory string) corev1.Pod {
+		p := pod(node, "default", name, nil)
+		p.Spec.Priority = &priority
+		p.Spec.Containers = []corev1.Container{{
+			Name: "app",
+			Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{
+				"cpu":    resource.MustParse(cpu),
+				"memory": resource.MustParse(memory),
+			}},
+		}}
+		return *p
+	}
+
+	// mynode2 is tainted, so the target can not be scheduled on it
+	snap := getTestSnapshot()
+	mynode3 := snap.Nodes.Items[0].DeepCopy()
+	mynode3.Name = "mynode3"
+	snap.Nodes.Items = append(snap.Nodes.Items, *mynode3)
+	snap.Pods.Items = []corev1.Pod{
+		priorityPod("mynode", "low", 0, "600m", "1Gi"),
+		priorityPod("mynode", "mid", 100, "300m", "1Gi"),
+		priorityPod("mynode2", "tainted", 0, "100m", "128Mi"),
+		priorityPod("mynode3", "batch", 50, "500m", "512Mi"),
+		priorityPod("mynode3", "web", 2000, "200m", "512Mi"),
+	}
+	cm := buildClusterMetric(snap.Pods, nil, snap.Nodes, nil)
+
+	victims := func(nodes []*preemptionNode) []string {
+		out := []string{}
+		for _, pn := range nodes {
+			names := []string{}
+			for _, pm := range pn.victims {
+				names = append(names, pm.name)
+			}
+			out = append(out, pn.nm.name+": "+strings.Join(names, ","))
+		}
+		return out
+	}
+	target := func(value string) *preemptionTarget {
+		target, err := parsePreemptionTarget(value)
+		require.NoError(t, err)
+		return target
+	}
+
+	// mid is spared since preempting low alone makes room, and mynode is
+	// preferred for its lower priority victim
+	nodes := buildPreemptionNodes(&cm, target("cpu=700m,priority=1000"))
+	assert.Equal(t, []string{"mynode: low", "mynode3: batch"}, victims(nodes))
+
+	// A node the target fits on without preempting anything comes first
+	assert.Equal(t, []string{"mynode3: ", "mynode: low"}, victims(buildPreemptionNodes(&cm, target("cpu=200m,priority=1000"))))
+
+	// Pods of the same or higher priority are never preempted
+	assert.Equal(t, []string{"mynode: low,mid"}, victims(buildPreemptionNodes(&cm, target("cpu=1,priority=101"))))
+	assert.Empty(t, buildPreemptionNodes(&cm, target("cpu=700m,priority=0")))
+	assert.Empty(t, buildPreemptionNodes(&cm, target("cpu=2,priority=1000")))
+
+	var buf bytes.Buffer
+	pp := &preemptionPrinter{run: run, target: target("cpu=700m,priority=1000"), nodes: nodes}
+	pp.printTable(&buf, ",")
+	assert.Equal(t, strings.Join([]string{
+		"NODE,NAMESPACE,POD,PRIORITY,CPU REQUESTS,MEMORY REQUESTS",
+		"mynode,*,* (1 pods),0,600m,1024Mi",
+		"mynode,default,low,0,600m,1024Mi",
+		"mynode3,*,* (1 pods),50,500m,512Mi",
+		"mynode3,default,batch,50,500m,512Mi",
+	}, "\n")+"\n", buf.String())
+
+	list := pp.buildListPreemption()
+	assert.Equal(t, "700m", list.Pod.CPU)
+	assert.Equal(t, int32(1000), list.Pod.Priority)
+	require.Len(t, list.Nodes, 2)
+	require.NotNil(t, list.Nodes[1].HighestPriority)
+	assert.Equal(t, int32(50), *list.Nodes[1].HighestPriority)
+}
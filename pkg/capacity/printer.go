@@ -16,8 +16,6 @@ package capacity
 
 import (
 	"fmt"
-	"os"
-	"text/tabwriter"
 )
 
 const (
@@ -31,6 +29,12 @@ const (
 	JSONOutput string = "json"
 	//YAMLOutput is the constant value for output type YAML
 	YAMLOutput string = "yaml"
+	//HTMLOutput is the constant value for output type HTML
+	HTMLOutput string = "html"
+	//SVGOutput is the constant value for output type SVG
+	SVGOutput string = "svg"
+	//TopOutput is the constant value for output in the layout of kubectl top
+	TopOutput string = "top"
 )
 
 // SupportedOutputs returns a string list of output formats supposed by this package
@@ -44,38 +48,93 @@ func SupportedOutputs() []string {
 	}
 }
 
-func printList(cm *clusterMetric, opts Options) {
+// SupportedReportOutputs returns the output formats of the node report,
+// which can also be rendered as an HTML page, SVG charts, or kubectl top
+// output
+func SupportedReportOutputs() []string {
+	return append(SupportedOutputs(), HTMLOutput, SVGOutput, TopOutput)
+}
+
+func printList(run *reportRun, cm *clusterMetric, opts Options) error {
 	output := opts.OutputFormat
 	if output == JSONOutput || output == YAMLOutput {
 		lp := &listPrinter{
+			run:  run,
 			cm:   cm,
 			opts: opts,
 		}
 		lp.Print(output)
 	} else if output == TableOutput {
 		tp := &tablePrinter{
+			run:  run,
 			cm:   cm,
-			w:    new(tabwriter.Writer),
 			opts: opts,
 		}
-		if !tp.hasVisibleColumns() {
-			fmt.Fprintln(os.Stderr, "Error: No data columns selected for display. At least one of the following must be enabled:")
-			fmt.Fprintln(os.Stderr, "- Resource requests (enabled by default, disabled with --hide-requests)")
-			fmt.Fprintln(os.Stderr, "- Resource limits (enabled by default, disabled with --hide-limits)")
-			fmt.Fprintln(os.Stderr, "- Resource utilization (enabled with --util)")
-			fmt.Fprintln(os.Stderr, "- Pod count (enabled with --pod-count)")
-			fmt.Fprintln(os.Stderr, "- Node labels (enabled with --show-labels)")
-			os.Exit(1)
+		if ferr := tp.checkVisibleColumns(); ferr != nil {
+			return ferr
 		}
 		tp.Print()
 	} else if output == CSVOutput || output == TSVOutput {
 		cp := &csvPrinter{
+			run:  run,
 			cm:   cm,
 			opts: opts,
 		}
 		cp.Print(output)
+	} else if output == HTMLOutput {
+		hp := &htmlPrinter{
+			run:  run,
+			opts: opts,
+		}
+		hp.Print(cm)
+	} else if output == SVGOutput {
+		printCharts(run, nodeChartSubjects(cm, opts.SortBy), opts)
+	} else if output == TopOutput {
+		tp := &topPrinter{
+			run:  run,
+			cm:   cm,
+			opts: opts,
+		}
+		tp.Print()
+	} else {
+		return fmt.Errorf("Called with an unsupported output type: %s", output)
+	}
+	return nil
+}
+
+func printFleet(run *reportRun, fm *fleetMetric, opts Options) error {
+	output := opts.OutputFormat
+	if output == JSONOutput || output == YAMLOutput {
+		lp := &listPrinter{
+			run:  run,
+			opts: opts,
+		}
+		lp.PrintFleet(fm, output)
+	} else if output == TableOutput {
+		tp := &tablePrinter{
+			run:  run,
+			opts: opts,
+		}
+		if ferr := tp.checkVisibleColumns(); ferr != nil {
+			return ferr
+		}
+		tp.PrintFleet(fm)
+	} else if output == CSVOutput || output == TSVOutput {
+		cp := &csvPrinter{
+			run:  run,
+			opts: opts,
+		}
+		cp.PrintFleet(fm)
+	} else if output == HTMLOutput {
+		hp := &htmlPrinter{
+			run:  run,
+			opts: opts,
+		}
+		hp.PrintFleet(fm)
+	} else if output == SVGOutput {
+		printCharts(run, fleetChartSubjects(fm), opts)
 	} else {
-		fmt.Fprintf(os.Stderr, "Called with an unsupported output type: %s\n", output)
-		os.Exit(1)
+		return fmt.Errorf("Called with an unsupported output type: %s", output)
 	}
+	return nil
 }
@@ -5,12 +5,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"os"
 	"math"
 	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
@@ -32,22 +32,73 @@ type prometheusData struct {
 type prometheusResult struct {
 	Metric map[string]string `json:"metric"`
 	Value  []interface{}     `json:"value"`
+
+	// Values is only set in the matrix returned by range queries
+	Values [][]interface{} `json:"values,omitempty"`
+}
+
+// prometheusReplicaLabel tells apart the copies of a series that each
+// replica of an HA Prometheus pair scrapes, when they are queried together
+// through Thanos or federation
+const prometheusReplicaLabel = "prometheus_replica"
+
+// dedupReplicas collapses the copies of a series from HA Prometheus replicas
+// so that they are not summed twice, and is a no-op for a single Prometheus
+func dedupReplicas(expr string) string {
+	return fmt.Sprintf("max without (%s) (%s)", prometheusReplicaLabel, expr)
+}
+
+// promSubquery is the range of a subquery over window, shifted back by
+// offset when it is set
+func promSubquery(window string, offset time.Duration) string {
+	if offset > 0 {
+		return fmt.Sprintf("[%s:] offset %s", window, PromQLDuration(offset))
+	}
+	return fmt.Sprintf("[%s:]", window)
+}
+
+func containerCPUQuery(labels prometheusLabels, agg, window string, offset time.Duration) string {
+	cpu, _ := labels.containerUsage("")
+	return containerUsageQuery(agg, cpu, window, offset)
+}
+
+func containerMemQuery(labels prometheusLabels, agg, window string, offset time.Duration) string {
+	_, memory := labels.containerUsage("")
+	return containerUsageQuery(agg, memory, window, offset)
+}
+
+// containerUsageQuery aggregates the usage of each container over window
+func containerUsageQuery(agg, usage, window string, offset time.Duration) string {
+	return fmt.Sprintf(`%s_over_time(sum by (namespace, pod, container) (%s)%s)`, agg, usage, promSubquery(window, offset))
 }
 
-func containerCPUQuery(agg, window string) string {
-	return fmt.Sprintf(`%s_over_time(sum by (namespace, pod, container) (rate(container_cpu_usage_seconds_total{container!="",container!="POD"}[5m]))[%s:])`, agg, window)
+func containerCPUQuantileQuery(labels prometheusLabels, quantile float64, window string) string {
+	cpu, _ := labels.containerUsage("")
+	return containerUsageQuantileQuery(quantile, cpu, window)
 }
 
-func containerMemQuery(agg, window string) string {
-	return fmt.Sprintf(`%s_over_time(sum by (namespace, pod, container) (container_memory_working_set_bytes{container!="",container!="POD"})[%s:])`, agg, window)
+func containerMemQuantileQuery(labels prometheusLabels, quantile float64, window string) string {
+	_, memory := labels.containerUsage("")
+	return containerUsageQuantileQuery(quantile, memory, window)
 }
 
-func nodeCPUQuery(agg, window string) string {
-	return fmt.Sprintf(`%s_over_time(sum by (node) (rate(container_cpu_usage_seconds_total{container!=""}[5m]))[%s:])`, agg, window)
+func containerUsageQuantileQuery(quantile float64, usage, window string) string {
+	return fmt.Sprintf(`quantile_over_time(%g, sum by (namespace, pod, container) (%s)[%s:])`, quantile, usage, window)
 }
 
-func nodeMemQuery(agg, window string) string {
-	return fmt.Sprintf(`%s_over_time(sum by (node) (container_memory_working_set_bytes{container!=""})[%s:])`, agg, window)
+func nodeCPUQuery(labels prometheusLabels, agg, window string, offset time.Duration) string {
+	cpu, _ := labels.nodeUsage()
+	return nodeUsageQuery(agg, cpu, window, offset)
+}
+
+func nodeMemQuery(labels prometheusLabels, agg, window string, offset time.Duration) string {
+	_, memory := labels.nodeUsage()
+	return nodeUsageQuery(agg, memory, window, offset)
+}
+
+// nodeUsageQuery aggregates the usage of each node over window
+func nodeUsageQuery(agg, usage, window string, offset time.Duration) string {
+	return fmt.Sprintf(`%s_over_time(sum by (node) (%s)%s)`, agg, usage, promSubquery(window, offset))
 }
 
 var prometheusLabelSelectors = []string{
@@ -60,9 +111,68 @@ type promCandidate struct {
 	namespace string
 	name      string
 	port      int32
+	headless  bool
+	pods      map[string]bool
 }
 
-func discoverPrometheusEndpoint(clientset kubernetes.Interface) (string, error) {
+// samePrometheus reports whether two services front any of the same pods,
+// like the prometheus-k8s and prometheus-operated services that kube-prometheus
+// puts in front of one HA pair
+func (c promCandidate) samePrometheus(other promCandidate) bool {
+	for pod := range c.pods {
+		if other.pods[pod] {
+			return true
+		}
+	}
+	return false
+}
+
+// prometheusServicePods returns the pods behind a service, or nil if its
+// endpoints can not be read
+func prometheusServicePods(clientset kubernetes.Interface, namespace, name string) map[string]bool {
+	endpoints, err := clientset.CoreV1().Endpoints(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+	if err != nil {
+		return nil
+	}
+	pods := map[string]bool{}
+	for _, subset := range endpoints.Subsets {
+		for _, addresses := range [][]corev1.EndpointAddress{subset.Addresses, subset.NotReadyAddresses} {
+			for _, address := range addresses {
+				if address.TargetRef != nil && address.TargetRef.Kind == "Pod" {
+					pods[address.TargetRef.Namespace+"/"+address.TargetRef.Name] = true
+				}
+			}
+		}
+	}
+	return pods
+}
+
+// dedupPrometheusCandidates keeps one service per Prometheus, preferring one
+// with a cluster IP over a headless one so the API server proxy spreads queries
+// across the replicas
+func dedupPrometheusCandidates(run *reportRun, candidates []promCandidate) []promCandidate {
+	deduped := []promCandidate{}
+	for _, c := range candidates {
+		merged := false
+		for i, kept := range deduped {
+			if !kept.samePrometheus(c) {
+				continue
+			}
+			run.logDebugf("Prometheus services %s/%s and %s/%s front the same pods", kept.namespace, kept.name, c.namespace, c.name)
+			if kept.headless && !c.headless {
+				deduped[i] = c
+			}
+			merged = true
+			break
+		}
+		if !merged {
+			deduped = append(deduped, c)
+		}
+	}
+	return deduped
+}
+
+func discoverPrometheusEndpoint(run *reportRun, clientset kubernetes.Interface) (string, error) {
 	seen := map[string]bool{}
 	var candidates []promCandidate
 
@@ -99,106 +209,238 @@ func discoverPrometheusEndpoint(clientset kubernetes.Interface) (string, error)
 				namespace: svc.Namespace,
 				name:      svc.Name,
 				port:      port,
+				headless:  svc.Spec.ClusterIP == corev1.ClusterIPNone,
+				pods:      prometheusServicePods(clientset, svc.Namespace, svc.Name),
 			})
 		}
 	}
+	candidates = dedupPrometheusCandidates(run, candidates)
 
 	if len(candidates) == 0 {
 		return "", fmt.Errorf("no Prometheus service found (searched labels: %v)", prometheusLabelSelectors)
 	}
 
 	if len(candidates) > 1 {
-		fmt.Fprintf(os.Stderr, "Warning: found %d Prometheus services, using first match. Use --prometheus-endpoint to specify explicitly:\n", len(candidates))
+		services := []string{}
 		for _, c := range candidates {
-			fmt.Fprintf(os.Stderr, "  - %s/%s:%d\n", c.namespace, c.name, c.port)
+			services = append(services, fmt.Sprintf("  - %s/%s:%d", c.namespace, c.name, c.port))
 		}
+		run.logWarnf("found %d Prometheus services, using first match. Use --prometheus-endpoint to specify explicitly:\n%s", len(candidates), strings.Join(services, "\n"))
 	}
 
 	c := candidates[0]
 	return fmt.Sprintf("%s/%s:%d", c.namespace, c.name, c.port), nil
 }
 
-func getPrometheusMetrics(clientset kubernetes.Interface, opts Options) (*v1beta1.PodMetricsList, *v1beta1.NodeMetricsList, error) {
-	endpoint := opts.PrometheusEndpoint
-	if endpoint == "" {
-		var err error
-		endpoint, err = discoverPrometheusEndpoint(clientset)
-		if err != nil {
-			return nil, nil, fmt.Errorf("auto-discovering Prometheus: %w", err)
-		}
-		fmt.Printf("Discovered Prometheus at %s\n", endpoint)
+// resolvePrometheusEndpoint returns --prometheus-endpoint, or the endpoint
+// of an auto-discovered Prometheus service when it is not set
+func resolvePrometheusEndpoint(run *reportRun, clientset kubernetes.Interface, opts Options) (string, error) {
+	if opts.PrometheusEndpoint != "" {
+		return opts.PrometheusEndpoint, nil
+	}
+	endpoint, err := discoverPrometheusEndpoint(run, clientset)
+	if err != nil {
+		return "", fmt.Errorf("auto-discovering Prometheus: %w", err)
+	}
+	run.logInfof("Discovered Prometheus at %s", endpoint)
+	return endpoint, nil
+}
+
+func getPrometheusMetrics(run *reportRun, clientset kubernetes.Interface, opts Options) (*v1beta1.PodMetricsList, *v1beta1.NodeMetricsList, error) {
+	endpoint, err := resolvePrometheusEndpoint(run, clientset, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	promHTTP, err := newPrometheusHTTP(context.TODO(), run, opts)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	queryFn := func(query string) (*prometheusResponse, error) {
-		return queryPrometheus(clientset, endpoint, query)
+		return queryPrometheus(run, clientset, endpoint, promHTTP, query)
 	}
 
 	window := opts.PrometheusWindow
 	agg := opts.PrometheusAggregation
-
-	// Query container-level CPU and memory
-	cpuResp, err := queryFn(containerCPUQuery(agg, window))
-	if err != nil {
-		return nil, nil, fmt.Errorf("querying container CPU: %w", err)
+	var rules *recordingRules
+	if opts.PrometheusRecordingRules {
+		rules = detectRecordingRules(run, queryFn)
 	}
 
-	memResp, err := queryFn(containerMemQuery(agg, window))
+	// Query container-level CPU and memory
+	cpuResp, memResp, err := queryContainerUsage(run, queryFn, opts.PrometheusQueryShards, rules, func(usage string) string {
+		return containerUsageQuery(agg, usage, window, opts.usageOffset)
+	})
 	if err != nil {
-		return nil, nil, fmt.Errorf("querying container memory: %w", err)
+		return nil, nil, err
 	}
 
-	pmList := buildPodMetricsList(cpuResp, memResp)
+	pmList := buildPodMetricsList(run, cpuResp, memResp)
 
 	// Query node-level CPU and memory
-	nodeCPUResp, err := queryFn(nodeCPUQuery(agg, window))
+	nodeCPU, nodeMemory := rules.nodeUsage(run.promLabels)
+	nodeCPUResp, err := queryFn(nodeUsageQuery(agg, nodeCPU, window, opts.usageOffset))
 	if err != nil {
 		return nil, nil, fmt.Errorf("querying node CPU: %w", err)
 	}
 
-	nodeMemResp, err := queryFn(nodeMemQuery(agg, window))
+	nodeMemResp, err := queryFn(nodeUsageQuery(agg, nodeMemory, window, opts.usageOffset))
 	if err != nil {
 		return nil, nil, fmt.Errorf("querying node memory: %w", err)
 	}
 
-	nmList := buildNodeMetricsList(nodeCPUResp, nodeMemResp)
+	nodeNames, err := getPrometheusNodeNames(run, clientset, endpoint, promHTTP)
+	if err != nil {
+		return nil, nil, err
+	}
+	nodeNames.resolve(nodeCPUResp, nodeMemResp)
+	nmList := buildNodeMetricsList(run, nodeCPUResp, nodeMemResp)
+
+	// Instant queries only return series that were scraped recently, so the
+	// time the query was evaluated stands in for the sample timestamp
+	sample := metav1.NewTime(prometheusEvalTime(nodeCPUResp))
+	sampleWindow := metav1.Duration{}
+	if d, err := time.ParseDuration(window); err == nil {
+		sampleWindow.Duration = d
+	}
+	for i := range pmList.Items {
+		pmList.Items[i].Timestamp = sample
+		pmList.Items[i].Window = sampleWindow
+	}
+	for i := range nmList.Items {
+		nmList.Items[i].Timestamp = sample
+		nmList.Items[i].Window = sampleWindow
+	}
 
 	return pmList, nmList, nil
 }
 
-func queryPrometheus(clientset kubernetes.Interface, endpoint, query string) (*prometheusResponse, error) {
+// prometheusEvalTime returns the time an instant query was evaluated at,
+// or the current time when the response has no results
+func prometheusEvalTime(resp *prometheusResponse) time.Time {
+	for _, r := range resp.Data.Result {
+		if len(r.Value) == 0 {
+			continue
+		}
+		if seconds, ok := r.Value[0].(float64); ok {
+			return time.Unix(0, int64(seconds*float64(time.Second)))
+		}
+	}
+	return time.Now()
+}
+
+// queryPrometheus runs an instant query, sending it with promHTTP when the
+// endpoint is a URL
+func queryPrometheus(run *reportRun, clientset kubernetes.Interface, endpoint string, promHTTP *prometheusHTTP, query string) (*prometheusResponse, error) {
+	return doPrometheusQuery(run, clientset, endpoint, promHTTP, "query", url.Values{"query": {query}})
+}
+
+// queryPrometheusRange runs a range query with points step apart from start
+// to end
+func queryPrometheusRange(run *reportRun, clientset kubernetes.Interface, endpoint string, promHTTP *prometheusHTTP, query string, start, end time.Time, step time.Duration) (*prometheusResponse, error) {
+	return doPrometheusQuery(run, clientset, endpoint, promHTTP, "query_range", url.Values{
+		"query": {query},
+		"start": {strconv.FormatInt(start.Unix(), 10)},
+		"end":   {strconv.FormatInt(end.Unix(), 10)},
+		"step":  {strconv.FormatFloat(step.Seconds(), 'f', -1, 64)},
+	})
+}
+
+func doPrometheusQuery(run *reportRun, clientset kubernetes.Interface, endpoint string, promHTTP *prometheusHTTP, api string, params url.Values) (*prometheusResponse, error) {
 	var body []byte
 	var err error
 
+	run.logDebugf("Querying Prometheus at %s: %s", endpoint, params.Get("query"))
+	start := time.Now()
 	if strings.HasPrefix(endpoint, "http://") || strings.HasPrefix(endpoint, "https://") {
-		body, err = queryPrometheusDirectHTTP(endpoint, query)
+		body, err = queryPrometheusDirectHTTP(endpoint, promHTTP, api, params)
 	} else {
-		body, err = queryPrometheusViaProxy(clientset, endpoint, query)
+		body, err = queryPrometheusViaProxy(clientset, endpoint, api, params)
+	}
+
+	var resp prometheusResponse
+	if err == nil {
+		if jsonErr := json.Unmarshal(body, &resp); jsonErr != nil {
+			err = fmt.Errorf("parsing Prometheus response: %w", jsonErr)
+		} else if resp.Status != "success" {
+			err = fmt.Errorf("Prometheus query failed with status: %s", resp.Status)
+		}
+	}
+	if promHTTP != nil && promHTTP.showQueries {
+		logPrometheusQuery(run, endpoint, api, params.Get("query"), &resp, err, time.Since(start))
 	}
 	if err != nil {
 		return nil, err
 	}
+	run.logTiming(start, "Received %d series from Prometheus", len(resp.Data.Result))
 
-	var resp prometheusResponse
-	if err := json.Unmarshal(body, &resp); err != nil {
-		return nil, fmt.Errorf("parsing Prometheus response: %w", err)
+	return &resp, nil
+}
+
+// prometheusQueryURL returns where a query is sent, through the API server
+// proxy when the endpoint is a namespace/service:port
+func prometheusQueryURL(endpoint, api string) string {
+	if isPrometheusURL(endpoint) {
+		return fmt.Sprintf("%s/api/v1/%s", strings.TrimRight(endpoint, "/"), api)
 	}
+	return fmt.Sprintf("%s/api/v1/%s (API server proxy)", endpoint, api)
+}
 
-	if resp.Status != "success" {
-		return nil, fmt.Errorf("Prometheus query failed with status: %s", resp.Status)
+// logPrometheusQuery logs a query for --show-queries, with the series and
+// samples it returned so that empty utilization can be traced to the query
+// that came back empty
+func logPrometheusQuery(run *reportRun, endpoint, api, query string, resp *prometheusResponse, err error, elapsed time.Duration) {
+	result := ""
+	if err != nil {
+		result = fmt.Sprintf("error: %v", err)
+	} else {
+		series, samples := resp.counts()
+		result = fmt.Sprintf("%d series, %d samples", series, samples)
 	}
+	run.logInfof("Query: %s\n  endpoint: %s\n  result: %s in %s",
+		query, prometheusQueryURL(endpoint, api), result, elapsed.Round(time.Millisecond))
+}
 
-	return &resp, nil
+// counts returns the series in the response and the samples across them,
+// one for each series of an instant query and each point of a range query
+func (resp *prometheusResponse) counts() (int, int) {
+	samples := 0
+	for _, r := range resp.Data.Result {
+		if r.Values != nil {
+			samples += len(r.Values)
+		} else if len(r.Value) > 0 {
+			samples++
+		}
+	}
+	return len(resp.Data.Result), samples
 }
 
-func queryPrometheusDirectHTTP(endpoint, query string) ([]byte, error) {
-	u := fmt.Sprintf("%s/api/v1/query?query=%s", strings.TrimRight(endpoint, "/"), url.QueryEscape(query))
-	resp, err := http.Get(u) //nolint:gosec // user-provided endpoint
+func queryPrometheusDirectHTTP(endpoint string, promHTTP *prometheusHTTP, api string, params url.Values) ([]byte, error) {
+	u := fmt.Sprintf("%s/api/v1/%s?%s", strings.TrimRight(endpoint, "/"), api, params.Encode())
+	req, err := http.NewRequest(http.MethodGet, u, nil) //nolint:gosec // user-provided endpoint
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range promHTTP.header {
+		req.Header[name] = values
+	}
+	// Range queries over long windows return large matrices that compress
+	// well, unless a --prometheus-header asks for another encoding
+	if req.Header.Get("Accept-Encoding") == "" {
+		req.Header.Set("Accept-Encoding", "gzip")
+	}
+	resp, err := promHTTP.client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("HTTP request to Prometheus: %w", err)
 	}
-	defer resp.Body.Close()
+	// The body is read to the end so that the connection can be reused
+	defer func() {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}()
 
-	body, err := io.ReadAll(resp.Body)
+	body, err := readPrometheusBody(resp)
 	if err != nil {
 		return nil, fmt.Errorf("reading Prometheus response: %w", err)
 	}
@@ -210,7 +452,7 @@ func queryPrometheusDirectHTTP(endpoint, query string) ([]byte, error) {
 	return body, nil
 }
 
-func queryPrometheusViaProxy(clientset kubernetes.Interface, endpoint, query string) ([]byte, error) {
+func queryPrometheusViaProxy(clientset kubernetes.Interface, endpoint, api string, params url.Values) ([]byte, error) {
 	// Parse namespace/service:port
 	parts := strings.SplitN(endpoint, "/", 2)
 	if len(parts) != 2 {
@@ -227,14 +469,16 @@ func queryPrometheusViaProxy(clientset kubernetes.Interface, endpoint, query str
 	svc := svcParts[0]
 	port := svcParts[1]
 
-	body, err := clientset.CoreV1().RESTClient().Get().
+	req := clientset.CoreV1().RESTClient().Get().
 		Namespace(ns).
 		Resource("services").
 		Name(svc+":"+port).
 		SubResource("proxy").
-		Suffix("api", "v1", "query").
-		Param("query", query).
-		DoRaw(context.TODO())
+		Suffix("api", "v1", api)
+	for name := range params {
+		req = req.Param(name, params.Get(name))
+	}
+	body, err := req.DoRaw(context.TODO())
 	if err != nil {
 		return nil, fmt.Errorf("K8s API proxy request to Prometheus: %w", err)
 	}
@@ -253,7 +497,7 @@ func parseValue(val []interface{}) (float64, error) {
 	return strconv.ParseFloat(s, 64)
 }
 
-func buildPodMetricsList(cpuResp, memResp *prometheusResponse) *v1beta1.PodMetricsList {
+func buildPodMetricsList(run *reportRun, cpuResp, memResp *prometheusResponse) *v1beta1.PodMetricsList {
 	// Key: namespace/pod/container
 	type containerUsage struct {
 		cpu    *resource.Quantity
@@ -265,6 +509,7 @@ func buildPodMetricsList(cpuResp, memResp *prometheusResponse) *v1beta1.PodMetri
 	}
 
 	containers := map[string]*containerUsage{}
+	duplicates := 0
 
 	for _, r := range cpuResp.Data.Result {
 		ns := r.Metric["namespace"]
@@ -282,7 +527,9 @@ func buildPodMetricsList(cpuResp, memResp *prometheusResponse) *v1beta1.PodMetri
 		if _, ok := containers[key]; !ok {
 			containers[key] = &containerUsage{}
 		}
-		containers[key].cpu = q
+		if keepMax(&containers[key].cpu, q) {
+			duplicates++
+		}
 	}
 
 	for _, r := range memResp.Data.Result {
@@ -301,9 +548,13 @@ func buildPodMetricsList(cpuResp, memResp *prometheusResponse) *v1beta1.PodMetri
 		if _, ok := containers[key]; !ok {
 			containers[key] = &containerUsage{}
 		}
-		containers[key].memory = q
+		if keepMax(&containers[key].memory, q) {
+			duplicates++
+		}
 	}
 
+	logDuplicateSeries(run, duplicates)
+
 	// Group by pod
 	pods := map[podKey][]v1beta1.ContainerMetrics{}
 	for key, usage := range containers {
@@ -341,13 +592,14 @@ func buildPodMetricsList(cpuResp, memResp *prometheusResponse) *v1beta1.PodMetri
 	return pmList
 }
 
-func buildNodeMetricsList(cpuResp, memResp *prometheusResponse) *v1beta1.NodeMetricsList {
+func buildNodeMetricsList(run *reportRun, cpuResp, memResp *prometheusResponse) *v1beta1.NodeMetricsList {
 	type nodeUsage struct {
 		cpu    *resource.Quantity
 		memory *resource.Quantity
 	}
 
 	nodes := map[string]*nodeUsage{}
+	duplicates := 0
 
 	for _, r := range cpuResp.Data.Result {
 		node := r.Metric["node"]
@@ -365,7 +617,9 @@ func buildNodeMetricsList(cpuResp, memResp *prometheusResponse) *v1beta1.NodeMet
 		if _, ok := nodes[node]; !ok {
 			nodes[node] = &nodeUsage{}
 		}
-		nodes[node].cpu = q
+		if keepMax(&nodes[node].cpu, q) {
+			duplicates++
+		}
 	}
 
 	for _, r := range memResp.Data.Result {
@@ -384,9 +638,13 @@ func buildNodeMetricsList(cpuResp, memResp *prometheusResponse) *v1beta1.NodeMet
 		if _, ok := nodes[node]; !ok {
 			nodes[node] = &nodeUsage{}
 		}
-		nodes[node].memory = q
+		if keepMax(&nodes[node].memory, q) {
+			duplicates++
+		}
 	}
 
+	logDuplicateSeries(run, duplicates)
+
 	nmList := &v1beta1.NodeMetricsList{}
 	for name, usage := range nodes {
 		nm := v1beta1.NodeMetrics{
@@ -406,3 +664,24 @@ func buildNodeMetricsList(cpuResp, memResp *prometheusResponse) *v1beta1.NodeMet
 
 	return nmList
 }
+
+// keepMax stores q in *dst unless it already holds a larger value, and
+// reports whether it was already set. Series are only returned more than once
+// when copies from HA Prometheus replicas were not collapsed by the query,
+// and the copies differ only by when each replica scraped.
+func keepMax(dst **resource.Quantity, q *resource.Quantity) bool {
+	if *dst == nil {
+		*dst = q
+		return false
+	}
+	if q.Cmp(**dst) > 0 {
+		*dst = q
+	}
+	return true
+}
+
+func logDuplicateSeries(run *reportRun, duplicates int) {
+	if duplicates > 0 {
+		run.logDebugf("Kept the highest of %d duplicate Prometheus series, likely from HA replicas", duplicates)
+	}
+}
@@ -0,0 +1,132 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func prometheusTestService(name, clusterIP string, labels map[string]string, pods ...string) (*corev1.Service, *corev1.Endpoints) {
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "monitoring", Labels: labels},
+		Spec: corev1.ServiceSpec{
+			ClusterIP: clusterIP,
+			Ports:     []corev1.ServicePort{{Port: 9090}},
+		},
+	}
+	addresses := []corev1.EndpointAddress{}
+	for _, pod := range pods {
+		addresses = append(addresses, corev1.EndpointAddress{
+			TargetRef: &corev1.ObjectReference{Kind: "Pod", Namespace: "monitoring", Name: pod},
+		})
+	}
+	endpoints := &corev1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "monitoring"},
+		Subsets:    []corev1.EndpointSubset{{Addresses: addresses}},
+	}
+	return svc, endpoints
+}
+
+func TestDiscoverPrometheusEndpointHAPair(t *testing.T) {
+	run := newTestRun(nil)
+	operated, operatedEndpoints := prometheusTestService("prometheus-operated", corev1.ClusterIPNone,
+		map[string]string{"app.kubernetes.io/name": "prometheus", "operated-prometheus": "true"},
+		"prometheus-k8s-0", "prometheus-k8s-1")
+	k8s, k8sEndpoints := prometheusTestService("prometheus-k8s", "10.0.0.10",
+		map[string]string{"app": "kube-prometheus-stack-prometheus"},
+		"prometheus-k8s-0", "prometheus-k8s-1")
+	clientset := fake.NewSimpleClientset(operated, operatedEndpoints, k8s, k8sEndpoints)
+
+	endpoint, err := discoverPrometheusEndpoint(run, clientset)
+	require.NoError(t, err)
+	assert.Equal(t, "monitoring/prometheus-k8s:9090", endpoint)
+}
+
+func TestDedupPrometheusCandidates(t *testing.T) {
+	run := newTestRun(nil)
+	candidates := dedupPrometheusCandidates(run, []promCandidate{
+		{name: "prometheus-k8s", pods: map[string]bool{"monitoring/prometheus-k8s-0": true, "monitoring/prometheus-k8s-1": true}},
+		{name: "prometheus-operated", headless: true, pods: map[string]bool{"monitoring/prometheus-k8s-1": true}},
+		{name: "prometheus-user-workload", pods: map[string]bool{"monitoring/prometheus-user-workload-0": true}},
+		{name: "no-endpoints"},
+	})
+
+	names := []string{}
+	for _, c := range candidates {
+		names = append(names, c.name)
+	}
+	assert.Equal(t, []string{"prometheus-k8s", "prometheus-user-workload", "no-endpoints"}, names)
+}
+
+func TestPrometheusQueriesDedupReplicas(t *testing.T) {
+	for _, query := range []string{
+		containerCPUQuery(defaultPrometheusLabels, "avg", "1h", 0),
+		containerMemQuery(defaultPrometheusLabels, "avg", "1h", 0),
+		containerCPUQuantileQuery(defaultPrometheusLabels, 0.95, "7d"),
+		containerMemQuantileQuery(defaultPrometheusLabels, 0.95, "7d"),
+		nodeCPUQuery(defaultPrometheusLabels, "max", "1h", 0),
+		nodeMemQuery(defaultPrometheusLabels, "max", "1h", 0),
+	} {
+		assert.Contains(t, query, "max without (prometheus_replica) (", query)
+	}
+}
+
+func TestPrometheusQueryOffset(t *testing.T) {
+	assert.True(t, strings.HasSuffix(nodeCPUQuery(defaultPrometheusLabels, "avg", "15m", 0), "[15m:])"))
+	assert.True(t, strings.HasSuffix(nodeCPUQuery(defaultPrometheusLabels, "avg", "15m", 24*time.Hour), "[15m:] offset 24h)"))
+	assert.True(t, strings.HasSuffix(containerMemQuery(defaultPrometheusLabels, "max", "1h", 90*time.Minute), "[1h:] offset 90m)"))
+}
+
+func TestBuildMetricsListsDuplicateSeries(t *testing.T) {
+	run := newTestRun(nil)
+	series := func(value string, labels ...string) prometheusResult {
+		metric := map[string]string{}
+		for i := 0; i < len(labels); i += 2 {
+			metric[labels[i]] = labels[i+1]
+		}
+		return prometheusResult{Metric: metric, Value: []interface{}{1767268800.0, value}}
+	}
+	cpuResp := &prometheusResponse{Data: prometheusData{Result: []prometheusResult{
+		series("0.25", "namespace", "default", "pod", "web", "container", "app", "prometheus_replica", "prometheus-k8s-0"),
+		series("0.3", "namespace", "default", "pod", "web", "container", "app", "prometheus_replica", "prometheus-k8s-1"),
+	}}}
+	memResp := &prometheusResponse{Data: prometheusData{Result: []prometheusResult{
+		series("2048", "namespace", "default", "pod", "web", "container", "app", "prometheus_replica", "prometheus-k8s-1"),
+		series("1024", "namespace", "default", "pod", "web", "container", "app", "prometheus_replica", "prometheus-k8s-0"),
+	}}}
+
+	pmList := buildPodMetricsList(run, cpuResp, memResp)
+	require.Len(t, pmList.Items, 1)
+	require.Len(t, pmList.Items[0].Containers, 1)
+	usage := pmList.Items[0].Containers[0].Usage
+	assert.Equal(t, "300m", usage.Cpu().String())
+	assert.Equal(t, "2Ki", usage.Memory().String())
+
+	nodeCPU := &prometheusResponse{Data: prometheusData{Result: []prometheusResult{
+		series("1.5", "node", "node-a", "prometheus_replica", "prometheus-k8s-1"),
+		series("1.2", "node", "node-a", "prometheus_replica", "prometheus-k8s-0"),
+	}}}
+	nmList := buildNodeMetricsList(run, nodeCPU, &prometheusResponse{})
+	require.Len(t, nmList.Items, 1)
+	assert.Equal(t, "1500m", nmList.Items[0].Usage.Cpu().String())
+}
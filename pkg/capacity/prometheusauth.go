@@ -0,0 +1,129 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+const (
+	// NoPrometheusAuth sends Prometheus queries without credentials
+	NoPrometheusAuth = "none"
+	// AzurePrometheusAuth tries workload identity, then managed identity,
+	// then the Azure CLI
+	AzurePrometheusAuth = "azure"
+	// AzureCLIPrometheusAuth uses the account the Azure CLI is logged in as
+	AzureCLIPrometheusAuth = "azure-cli"
+	// AzureMSIPrometheusAuth uses the managed identity of the VM or node
+	AzureMSIPrometheusAuth = "azure-msi"
+)
+
+// SupportedPrometheusAuths returns the ways Prometheus queries can be
+// authenticated
+func SupportedPrometheusAuths() []string {
+	return []string{NoPrometheusAuth, AzurePrometheusAuth, AzureCLIPrometheusAuth, AzureMSIPrometheusAuth}
+}
+
+// PrometheusPasswordEnv is read for the basic auth password when neither
+// --prometheus-password nor --prometheus-password-file is set
+const PrometheusPasswordEnv = "KUBE_CAPACITY_PROMETHEUS_PASSWORD"
+
+// ParsePrometheusHeaders parses --prometheus-header values in the form
+// "Name: Value". A name can be given more than once.
+func ParsePrometheusHeaders(values []string) (http.Header, error) {
+	header := http.Header{}
+	for _, v := range values {
+		name, value, ok := strings.Cut(v, ":")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" || strings.ContainsAny(name, " \t") {
+			return nil, fmt.Errorf("invalid Prometheus header %q, expected \"Name: Value\"", v)
+		}
+		header.Add(name, strings.TrimSpace(value))
+	}
+	return header, nil
+}
+
+// prometheusHeader returns the headers to send with Prometheus queries,
+// the --prometheus-header values along with any credentials
+func prometheusHeader(ctx context.Context, run *reportRun, opts Options) (http.Header, error) {
+	header, err := ParsePrometheusHeaders(opts.PrometheusHeaders)
+	if err != nil {
+		return nil, err
+	}
+	authorization, err := prometheusAuthorization(ctx, run, opts)
+	if err != nil {
+		return nil, err
+	}
+	if authorization != "" {
+		header.Set("Authorization", authorization)
+	}
+	return header, nil
+}
+
+// prometheusAuthorization returns the Authorization header to send with
+// Prometheus queries, or an empty string when there are no credentials
+func prometheusAuthorization(ctx context.Context, run *reportRun, opts Options) (string, error) {
+	if opts.PrometheusUsername != "" {
+		password, err := prometheusPassword(opts)
+		if err != nil {
+			return "", err
+		}
+		credentials := base64.StdEncoding.EncodeToString([]byte(opts.PrometheusUsername + ":" + password))
+		return "Basic " + credentials, nil
+	}
+
+	var token string
+	var err error
+
+	switch opts.PrometheusAuth {
+	case "", NoPrometheusAuth:
+		return "", nil
+	case AzureCLIPrometheusAuth:
+		token, err = getAzureCLIToken(ctx)
+	case AzureMSIPrometheusAuth:
+		token, err = getAzureMSIToken(ctx)
+	case AzurePrometheusAuth:
+		token, err = getAzureToken(ctx, run)
+	default:
+		return "", fmt.Errorf("unsupported Prometheus auth %q", opts.PrometheusAuth)
+	}
+	if err != nil {
+		return "", fmt.Errorf("getting an Azure AD token: %w", err)
+	}
+	return "Bearer " + token, nil
+}
+
+// prometheusPassword returns the basic auth password from
+// --prometheus-password, --prometheus-password-file, or
+// KUBE_CAPACITY_PROMETHEUS_PASSWORD, in that order. A trailing newline in the
+// file is ignored.
+func prometheusPassword(opts Options) (string, error) {
+	if opts.PrometheusPassword != "" {
+		return opts.PrometheusPassword, nil
+	}
+	if opts.PrometheusPasswordFile != "" {
+		b, err := os.ReadFile(opts.PrometheusPasswordFile)
+		if err != nil {
+			return "", fmt.Errorf("reading Prometheus password: %w", err)
+		}
+		return strings.TrimRight(string(b), "\r\n"), nil
+	}
+	return os.Getenv(PrometheusPasswordEnv), nil
+}
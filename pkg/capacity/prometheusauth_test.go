@@ -0,0 +1,127 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrometheusAuthorizationNone(t *testing.T) {
+	run := newTestRun(nil)
+	authorization, err := prometheusAuthorization(context.Background(), run, Options{PrometheusAuth: NoPrometheusAuth})
+	require.NoError(t, err)
+	assert.Empty(t, authorization)
+}
+
+func TestPrometheusAuthorizationBasic(t *testing.T) {
+	run := newTestRun(nil)
+	t.Setenv(PrometheusPasswordEnv, "from-env")
+	passwordFile := filepath.Join(t.TempDir(), "password")
+	require.NoError(t, os.WriteFile(passwordFile, []byte("from-file\n"), 0o600))
+
+	tests := []struct {
+		name     string
+		opts     Options
+		expected string
+	}{
+		{
+			name:     "password flag",
+			opts:     Options{PrometheusUsername: "admin", PrometheusPassword: "secret", PrometheusPasswordFile: passwordFile},
+			expected: "Basic YWRtaW46c2VjcmV0",
+		},
+		{
+			name:     "password file without trailing newline",
+			opts:     Options{PrometheusUsername: "admin", PrometheusPasswordFile: passwordFile},
+			expected: "Basic YWRtaW46ZnJvbS1maWxl",
+		},
+		{
+			name:     "password from environment",
+			opts:     Options{PrometheusUsername: "admin"},
+			expected: "Basic YWRtaW46ZnJvbS1lbnY=",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			authorization, err := prometheusAuthorization(context.Background(), run, tc.opts)
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, authorization)
+		})
+	}
+
+	_, err := prometheusAuthorization(context.Background(), run, Options{
+		PrometheusUsername:     "admin",
+		PrometheusPasswordFile: filepath.Join(t.TempDir(), "missing"),
+	})
+	assert.Error(t, err)
+}
+
+func TestQueryPrometheusAuthorization(t *testing.T) {
+	run := newTestRun(nil)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `{"status": "success", "data": {"resultType": "vector", "result": []}}`)
+	}))
+	defer server.Close()
+
+	_, err := queryPrometheus(run, nil, server.URL, &prometheusHTTP{client: http.DefaultClient, header: http.Header{"Authorization": {"Bearer token"}}}, "up")
+	require.NoError(t, err)
+
+	_, err = queryPrometheus(run, nil, server.URL, &prometheusHTTP{client: http.DefaultClient}, "up")
+	assert.Error(t, err)
+}
+
+func TestParsePrometheusHeaders(t *testing.T) {
+	header, err := ParsePrometheusHeaders([]string{"X-Scope-OrgID: tenant-1", "x-route:  a ", "X-Route:b", "X-Empty:"})
+	require.NoError(t, err)
+	assert.Equal(t, http.Header{
+		"X-Scope-Orgid": {"tenant-1"},
+		"X-Route":       {"a", "b"},
+		"X-Empty":       {""},
+	}, header)
+
+	for _, invalid := range []string{"X-Scope-OrgID", ": value", "X Scope: value"} {
+		_, err := ParsePrometheusHeaders([]string{invalid})
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestPrometheusHeader(t *testing.T) {
+	run := newTestRun(nil)
+	header, err := prometheusHeader(context.Background(), run, Options{
+		PrometheusHeaders:  []string{"X-Scope-OrgID: tenant-1"},
+		PrometheusUsername: "admin",
+		PrometheusPassword: "secret",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "tenant-1", header.Get("X-Scope-OrgID"))
+	assert.Equal(t, "Basic YWRtaW46c2VjcmV0", header.Get("Authorization"))
+
+	header, err = prometheusHeader(context.Background(), run, Options{})
+	require.NoError(t, err)
+	assert.Empty(t, header)
+}
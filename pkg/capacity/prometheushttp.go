@@ -0,0 +1,188 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+)
+
+// prometheusHTTP is how queries are sent to a Prometheus endpoint URL
+type prometheusHTTP struct {
+	client *http.Client
+	header http.Header
+	// showQueries logs every query with its endpoint, result, and latency
+	showQueries bool
+}
+
+func newPrometheusHTTP(ctx context.Context, run *reportRun, opts Options) (*prometheusHTTP, error) {
+	client, err := prometheusHTTPClient(run, opts)
+	if err != nil {
+		return nil, err
+	}
+	header, err := prometheusHeader(ctx, run, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &prometheusHTTP{client: client, header: header, showQueries: opts.ShowQueries}, nil
+}
+
+// prometheusMaxIdleConns is how many idle connections are kept open to a
+// Prometheus host, enough for the queries that fetches send in parallel
+const prometheusMaxIdleConns = 16
+
+// prometheusTransports are shared by every client with the same TLS and
+// proxy settings, so that the queries of a run, and of every context in
+// multi-cluster modes, reuse connections instead of each opening their own
+var (
+	prometheusTransportsMu sync.Mutex
+	prometheusTransports   = map[prometheusTransportKey]*http.Transport{}
+)
+
+// prometheusTransportKey has the contents of the certificate files rather
+// than their paths, so that a certificate rotated between the runs of a
+// daemon gets a transport of its own
+type prometheusTransportKey struct {
+	proxyURL      string
+	cert          string
+	key           string
+	ca            string
+	skipTLSVerify bool
+}
+
+// prometheusHTTPClient returns a client that presents --prometheus-cert and
+// only trusts --prometheus-ca when they are set, or that trusts any server
+// certificate with --prometheus-insecure-skip-tls-verify. Queries go through
+// --prometheus-proxy-url if it is set, and through the proxy from
+// HTTPS_PROXY, HTTP_PROXY, and NO_PROXY otherwise.
+func prometheusHTTPClient(run *reportRun, opts Options) (*http.Client, error) {
+	key := prometheusTransportKey{proxyURL: opts.PrometheusProxyURL, skipTLSVerify: opts.PrometheusSkipTLSVerify}
+	for _, f := range []struct {
+		path string
+		dest *string
+		name string
+	}{
+		{opts.PrometheusCert, &key.cert, "client certificate"},
+		{opts.PrometheusKey, &key.key, "client key"},
+		{opts.PrometheusCA, &key.ca, "CA"},
+	} {
+		if f.path == "" {
+			continue
+		}
+		data, err := os.ReadFile(f.path)
+		if err != nil {
+			return nil, fmt.Errorf("reading Prometheus %s: %w", f.name, err)
+		}
+		*f.dest = string(data)
+	}
+
+	prometheusTransportsMu.Lock()
+	defer prometheusTransportsMu.Unlock()
+	if transport, ok := prometheusTransports[key]; ok {
+		return &http.Client{Transport: transport}, nil
+	}
+	transport, err := newPrometheusTransport(run, key, opts)
+	if err != nil {
+		return nil, err
+	}
+	prometheusTransports[key] = transport
+	return &http.Client{Transport: transport}, nil
+}
+
+// newPrometheusTransport keeps connections alive between queries and
+// negotiates HTTP/2 with servers that support it, which setting a TLS
+// config would otherwise turn off
+func newPrometheusTransport(run *reportRun, key prometheusTransportKey, opts Options) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ForceAttemptHTTP2 = true
+	transport.MaxIdleConnsPerHost = prometheusMaxIdleConns
+	transport.Proxy = http.ProxyFromEnvironment
+	if key.proxyURL != "" {
+		proxyURL, err := ParsePrometheusProxyURL(key.proxyURL)
+		if err != nil {
+			return nil, err
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	if key.cert == "" && key.ca == "" && !key.skipTLSVerify {
+		return transport, nil
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if key.skipTLSVerify {
+		run.logWarnf("Not verifying the certificate of %s", opts.PrometheusEndpoint)
+		tlsConfig.InsecureSkipVerify = true
+	}
+	if key.cert != "" {
+		cert, err := tls.X509KeyPair([]byte(key.cert), []byte(key.key))
+		if err != nil {
+			return nil, fmt.Errorf("loading Prometheus client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	if key.ca != "" {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM([]byte(key.ca)) {
+			return nil, fmt.Errorf("no certificates found in Prometheus CA %s", opts.PrometheusCA)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	transport.TLSClientConfig = tlsConfig
+	return transport, nil
+}
+
+// readPrometheusBody reads a response, decompressing it when the server
+// gzipped it. The transport leaves this to us since queries set
+// Accept-Encoding themselves.
+func readPrometheusBody(resp *http.Response) ([]byte, error) {
+	if !strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
+		return io.ReadAll(resp.Body)
+	}
+	gz, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+	return io.ReadAll(gz)
+}
+
+// ParsePrometheusProxyURL parses --prometheus-proxy-url, which must be an
+// http, https, or socks5 URL
+func ParsePrometheusProxyURL(value string) (*url.URL, error) {
+	proxyURL, err := url.Parse(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL %q: %w", value, err)
+	}
+	switch proxyURL.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return nil, fmt.Errorf("invalid proxy URL %q: scheme must be http, https, or socks5", value)
+	}
+	if proxyURL.Host == "" {
+		return nil, fmt.Errorf("invalid proxy URL %q: missing host", value)
+	}
+	return proxyURL, nil
+}
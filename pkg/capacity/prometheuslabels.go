@@ -0,0 +1,201 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+
+	"k8s.io/client-go/kubernetes"
+)
+
+// prometheusLabels are the labels cAdvisor series identify nodes, pods,
+// namespaces, and containers by. Scrape configs sometimes relabel them, such
+// as the node into instance or kubernetes_node.
+type prometheusLabels struct {
+	node      string
+	pod       string
+	namespace string
+	container string
+}
+
+var defaultPrometheusLabels = prometheusLabels{
+	node:      "node",
+	pod:       "pod",
+	namespace: "namespace",
+	container: "container",
+}
+
+// prometheusLabelName matches the label names Prometheus accepts
+var prometheusLabelName = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// nodeInfoQuery maps the node names and internal IPs kube-state-metrics
+// knows nodes by to the node names
+const nodeInfoQuery = `max by (node, internal_ip) (kube_node_info)`
+
+// ValidatePrometheusLabels returns an error for a --prometheus-*-label that
+// is not a Prometheus label name
+func ValidatePrometheusLabels(opts Options) error {
+	for _, l := range []struct {
+		flag  string
+		value string
+	}{
+		{"prometheus-node-label", opts.PrometheusNodeLabel},
+		{"prometheus-pod-label", opts.PrometheusPodLabel},
+		{"prometheus-namespace-label", opts.PrometheusNamespaceLabel},
+		{"prometheus-container-label", opts.PrometheusContainerLabel},
+	} {
+		if !prometheusLabelName.MatchString(l.value) {
+			return fmt.Errorf("--%s must be a Prometheus label name, got %q", l.flag, l.value)
+		}
+	}
+	return nil
+}
+
+// newPrometheusLabels returns the labels of the --prometheus-*-label flags
+// cAdvisor series are read by. Labels that are not set keep their default.
+func newPrometheusLabels(opts Options) prometheusLabels {
+	labels := defaultPrometheusLabels
+	for _, l := range []struct {
+		dest  *string
+		value string
+	}{
+		{&labels.node, opts.PrometheusNodeLabel},
+		{&labels.pod, opts.PrometheusPodLabel},
+		{&labels.namespace, opts.PrometheusNamespaceLabel},
+		{&labels.container, opts.PrometheusContainerLabel},
+	} {
+		if l.value != "" {
+			*l.dest = l.value
+		}
+	}
+	return labels
+}
+
+// nodeUsage returns the CPU and memory usage of everything running on
+// nodes, labeled by node, namespace, pod, and container whatever labels
+// the series were scraped with
+func (l prometheusLabels) nodeUsage() (cpu, memory string) {
+	c := l.container
+	cpu = orWindows(
+		dedupReplicas(relabelSeries(fmt.Sprintf(`rate(container_cpu_usage_seconds_total{%s!=""}[5m])`, c), l)),
+		windowsNodeContainerSeries(`rate(windows_container_cpu_usage_seconds_total[5m])`))
+	memory = orWindows(
+		dedupReplicas(relabelSeries(fmt.Sprintf(`container_memory_working_set_bytes{%s!=""}`, c), l)),
+		windowsNodeContainerSeries(`windows_container_memory_usage_private_working_set_bytes`))
+	return cpu, memory
+}
+
+// containerUsage returns the CPU and memory usage of containers, without
+// the pause container, only in the namespaces matching a regexp when it is
+// set. Usage of containers on Windows nodes, which have no cAdvisor series,
+// is read from windows_exporter.
+func (l prometheusLabels) containerUsage(namespaces string) (cpu, memory string) {
+	c := l.container
+	matchers := fmt.Sprintf(`%s!="",%s!="POD"`, c, c)
+	if namespaces != "" {
+		matchers += fmt.Sprintf(`,%s=~"%s"`, l.namespace, namespaces)
+	}
+	cpu = orWindows(
+		dedupReplicas(relabelSeries(fmt.Sprintf(`rate(container_cpu_usage_seconds_total{%s}[5m])`, matchers), l)),
+		windowsContainerSeries(`rate(windows_container_cpu_usage_seconds_total[5m])`, namespaces))
+	memory = orWindows(
+		dedupReplicas(relabelSeries(fmt.Sprintf(`container_memory_working_set_bytes{%s}`, matchers), l)),
+		windowsContainerSeries(`windows_container_memory_usage_private_working_set_bytes`, namespaces))
+	return cpu, memory
+}
+
+// relabelSeries copies the labels of a cAdvisor series into the labels the
+// queries group by, when they differ from the defaults
+func relabelSeries(expr string, labels prometheusLabels) string {
+	for _, l := range []struct {
+		to   string
+		from string
+	}{
+		{defaultPrometheusLabels.node, labels.node},
+		{defaultPrometheusLabels.namespace, labels.namespace},
+		{defaultPrometheusLabels.pod, labels.pod},
+		{defaultPrometheusLabels.container, labels.container},
+	} {
+		if l.from != l.to {
+			expr = fmt.Sprintf(`label_replace(%s, "%s", "$1", "%s", "(.*)")`, expr, l.to, l.from)
+		}
+	}
+	return expr
+}
+
+// prometheusNodeNames maps what the node label of cAdvisor series holds,
+// such as the address of an instance, to node names
+type prometheusNodeNames map[string]string
+
+// getPrometheusNodeNames reads the node names and internal IPs of nodes
+// from kube_node_info. It is only needed when series are not labeled with
+// the node name, and returns nil otherwise.
+func getPrometheusNodeNames(run *reportRun, clientset kubernetes.Interface, endpoint string, promHTTP *prometheusHTTP) (prometheusNodeNames, error) {
+	if run.promLabels.node == defaultPrometheusLabels.node {
+		return nil, nil
+	}
+	names, err := queryPrometheusNodeNames(run, clientset, endpoint, promHTTP)
+	if err == nil && len(names) == 0 {
+		run.logWarnf("No kube_node_info series found, %s values are used as node names as they are", run.promLabels.node)
+	}
+	return names, err
+}
+
+// queryPrometheusNodeNames reads the node names and internal IPs of nodes
+// from kube_node_info
+func queryPrometheusNodeNames(run *reportRun, clientset kubernetes.Interface, endpoint string, promHTTP *prometheusHTTP) (prometheusNodeNames, error) {
+	resp, err := queryPrometheus(run, clientset, endpoint, promHTTP, nodeInfoQuery)
+	if err != nil {
+		return nil, fmt.Errorf("querying node info: %w", err)
+	}
+	names := prometheusNodeNames{}
+	for _, r := range resp.Data.Result {
+		node := r.Metric["node"]
+		if node == "" {
+			continue
+		}
+		names[node] = node
+		if ip := r.Metric["internal_ip"]; ip != "" {
+			names[ip] = node
+		}
+	}
+	return names, nil
+}
+
+// resolve replaces the node label of every series with the node name it
+// maps to, with any port of an instance address left off. Values that map
+// to no node are kept.
+func (names prometheusNodeNames) resolve(resps ...*prometheusResponse) {
+	if len(names) == 0 {
+		return
+	}
+	for _, resp := range resps {
+		for _, r := range resp.Data.Result {
+			value := r.Metric["node"]
+			if value == "" {
+				continue
+			}
+			if name, ok := names[value]; ok {
+				r.Metric["node"] = name
+			} else if host, _, err := net.SplitHostPort(value); err == nil {
+				if name, ok := names[host]; ok {
+					r.Metric["node"] = name
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidatePrometheusLabels(t *testing.T) {
+	opts := Options{
+		PrometheusNodeLabel:      "kubernetes_node",
+		PrometheusPodLabel:       "pod_name",
+		PrometheusNamespaceLabel: "namespace",
+		PrometheusContainerLabel: "container_name",
+	}
+	assert.NoError(t, ValidatePrometheusLabels(opts))
+
+	opts.PrometheusNodeLabel = "node-name"
+	assert.EqualError(t, ValidatePrometheusLabels(opts), `--prometheus-node-label must be a Prometheus label name, got "node-name"`)
+	opts.PrometheusNodeLabel = "node"
+	opts.PrometheusContainerLabel = ""
+	assert.EqualError(t, ValidatePrometheusLabels(opts), `--prometheus-container-label must be a Prometheus label name, got ""`)
+}
+
+func TestNewPrometheusLabels(t *testing.T) {
+	// Labels that are not set keep their default
+	assert.Equal(t, defaultPrometheusLabels, newPrometheusLabels(Options{PrometheusNodeLabel: "node"}))
+
+	labels := newPrometheusLabels(Options{PrometheusNodeLabel: "instance", PrometheusContainerLabel: "container_name"})
+	assert.Contains(t, nodeMemQuery(labels, "max", "1h", 0),
+		`max without (prometheus_replica) (label_replace(label_replace(container_memory_working_set_bytes{container_name!=""}, "node", "$1", "instance", "(.*)"), "container", "$1", "container_name", "(.*)"))`)
+	// Windows series are labeled from kube-state-metrics, which keeps its
+	// own labels
+	assert.Contains(t, nodeMemQuery(labels, "max", "1h", 0), `group_left (node) max by (namespace, pod, node) (kube_pod_info{node!=""})`)
+	assert.Contains(t, containerThrottleQuery(labels, "namespace, pod", "15m"),
+		`label_replace(label_replace(rate(container_cpu_cfs_periods_total{container_name!="",container_name!="POD"}[15m]), "node", "$1", "instance", "(.*)"), "container", "$1", "container_name", "(.*)")`)
+}
+
+func TestResolvePrometheusNodeNames(t *testing.T) {
+	body := `{"status":"success","data":{"resultType":"vector","result":[
+		{"metric":{"node":"10.0.0.1:10250"},"value":[1700000000,"0.5"]},
+		{"metric":{"node":"mynode2"},"value":[1700000000,"0.25"]},
+		{"metric":{"node":"10.0.0.9:10250"},"value":[1700000000,"0.125"]},
+		{"metric":{"namespace":"default","pod":"web"},"value":[1700000000,"0.1"]}
+	]}}`
+	var resp prometheusResponse
+	require.NoError(t, json.Unmarshal([]byte(body), &resp))
+
+	names := prometheusNodeNames{"mynode": "mynode", "10.0.0.1": "mynode", "mynode2": "mynode2", "10.0.0.2": "mynode2"}
+	names.resolve(&resp)
+
+	nodes := []string{}
+	for _, r := range resp.Data.Result {
+		nodes = append(nodes, r.Metric["node"])
+	}
+	assert.Equal(t, []string{"mynode", "mynode2", "10.0.0.9:10250", ""}, nodes)
+
+	// Without kube_node_info, values are used as they are
+	prometheusNodeNames(nil).resolve(&resp)
+	assert.Equal(t, "10.0.0.9:10250", resp.Data.Result[2].Metric["node"])
+}
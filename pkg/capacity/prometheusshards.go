@@ -0,0 +1,129 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"fmt"
+	"hash/fnv"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// queryContainerUsage runs query over the CPU and over the memory usage of
+// containers. With more than one shard, each is split into that many
+// queries over the containers of a share of the namespaces, which run at
+// the same time and are merged, so that no single response has to hold
+// every container of a huge cluster. Usage is read from rules when set.
+func queryContainerUsage(run *reportRun, queryFn func(string) (*prometheusResponse, error), shards int, rules *recordingRules, query func(usage string) string) (*prometheusResponse, *prometheusResponse, error) {
+	cpu, memory := rules.containerUsage(run.promLabels, "")
+	if shards <= 1 {
+		cpuResp, err := queryFn(query(cpu))
+		if err != nil {
+			return nil, nil, fmt.Errorf("querying container CPU: %w", err)
+		}
+		memResp, err := queryFn(query(memory))
+		if err != nil {
+			return nil, nil, fmt.Errorf("querying container memory: %w", err)
+		}
+		return cpuResp, memResp, nil
+	}
+
+	// The namespaces are those containers use memory in now, which is a
+	// single instant lookup instead of the whole window. Containers of
+	// namespaces that are gone have no pods in the report to add usage to.
+	nsResp, err := queryFn(fmt.Sprintf(`count by (namespace) (%s)`, memory))
+	if err != nil {
+		return nil, nil, fmt.Errorf("querying container namespaces: %w", err)
+	}
+	namespaces := []string{}
+	for _, r := range nsResp.Data.Result {
+		if ns := r.Metric["namespace"]; ns != "" {
+			namespaces = append(namespaces, ns)
+		}
+	}
+	groups := shardNamespaces(namespaces, shards)
+
+	start := time.Now()
+	queries := []string{}
+	for _, group := range groups {
+		cpu, memory := rules.containerUsage(run.promLabels, namespacesRegexp(group))
+		queries = append(queries, query(cpu), query(memory))
+	}
+	resps := make([]*prometheusResponse, len(queries))
+	errs := make([]error, len(queries))
+	sem := make(chan struct{}, shards)
+	var wg sync.WaitGroup
+	for i := range queries {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			resps[i], errs[i] = queryFn(queries[i])
+		}(i)
+	}
+	wg.Wait()
+
+	cpuResp := &prometheusResponse{Status: "success", Data: prometheusData{ResultType: "vector"}}
+	memResp := &prometheusResponse{Status: "success", Data: prometheusData{ResultType: "vector"}}
+	for i, resp := range resps {
+		resource, merged := "CPU", cpuResp
+		if i%2 == 1 {
+			resource, merged = "memory", memResp
+		}
+		if errs[i] != nil {
+			return nil, nil, fmt.Errorf("querying container %s of shard %d of %d: %w", resource, i/2+1, len(groups), errs[i])
+		}
+		merged.Data.Result = append(merged.Data.Result, resp.Data.Result...)
+	}
+	run.logTiming(start, "Queried container usage of %d namespaces in %d shards", len(namespaces), len(groups))
+
+	return cpuResp, memResp, nil
+}
+
+// shardNamespaces spreads namespaces over shards by a hash of their name,
+// so that a namespace stays in the same shard from one run to the next.
+// Shards that no namespace hashes to are left out.
+func shardNamespaces(namespaces []string, shards int) [][]string {
+	groups := make([][]string, shards)
+	for _, ns := range namespaces {
+		h := fnv.New32a()
+		_, _ = h.Write([]byte(ns))
+		i := h.Sum32() % uint32(shards)
+		groups[i] = append(groups[i], ns)
+	}
+
+	nonEmpty := [][]string{}
+	for _, group := range groups {
+		if len(group) > 0 {
+			sort.Strings(group)
+			nonEmpty = append(nonEmpty, group)
+		}
+	}
+	return nonEmpty
+}
+
+// namespacesRegexp matches exactly the given namespaces, escaped to be
+// quoted in a PromQL label matcher
+func namespacesRegexp(namespaces []string) string {
+	quoted := make([]string, len(namespaces))
+	for i, ns := range namespaces {
+		quoted[i] = strings.ReplaceAll(regexp.QuoteMeta(ns), `\`, `\\`)
+	}
+	return strings.Join(quoted, "|")
+}
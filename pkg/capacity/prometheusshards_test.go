@@ -0,0 +1,103 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShardNamespaces(t *testing.T) {
+	namespaces := []string{"default", "kube-system", "monitoring", "team-a", "team-b", "team-c"}
+	groups := shardNamespaces(namespaces, 3)
+	assert.LessOrEqual(t, len(groups), 3)
+
+	sharded := []string{}
+	for _, group := range groups {
+		assert.NotEmpty(t, group)
+		sharded = append(sharded, group...)
+	}
+	sort.Strings(sharded)
+	assert.Equal(t, namespaces, sharded)
+
+	// A namespace hashes to the same shard whatever else is in the cluster
+	assert.Equal(t, groups, shardNamespaces([]string{"team-c", "team-b", "team-a", "monitoring", "kube-system", "default"}, 3))
+	assert.Len(t, shardNamespaces(namespaces, 1), 1)
+}
+
+func TestNamespacesRegexp(t *testing.T) {
+	assert.Equal(t, "default|kube-system", namespacesRegexp([]string{"default", "kube-system"}))
+	assert.Equal(t, `a\\.b`, namespacesRegexp([]string{"a.b"}))
+}
+
+func TestQueryContainerUsageShards(t *testing.T) {
+	run := newTestRun(nil)
+	var mu sync.Mutex
+	queries := []string{}
+	queryFn := func(query string) (*prometheusResponse, error) {
+		mu.Lock()
+		queries = append(queries, query)
+		mu.Unlock()
+
+		resp := &prometheusResponse{Status: "success"}
+		if strings.HasPrefix(query, "count by (namespace)") {
+			for _, ns := range []string{"default", "kube-system", "monitoring", "team-a"} {
+				resp.Data.Result = append(resp.Data.Result, prometheusResult{Metric: map[string]string{"namespace": ns}})
+			}
+			return resp, nil
+		}
+		resp.Data.Result = []prometheusResult{{Metric: map[string]string{"query": query}}}
+		return resp, nil
+	}
+	query := func(usage string) string {
+		return containerUsageQuery("avg", usage, "15m", 0)
+	}
+
+	cpuResp, memResp, err := queryContainerUsage(run, queryFn, 1, nil, query)
+	require.NoError(t, err)
+	assert.Equal(t, []string{containerCPUQuery(defaultPrometheusLabels, "avg", "15m", 0), containerMemQuery(defaultPrometheusLabels, "avg", "15m", 0)}, queries)
+	assert.Len(t, cpuResp.Data.Result, 1)
+	assert.Len(t, memResp.Data.Result, 1)
+
+	queries = nil
+	cpuResp, memResp, err = queryContainerUsage(run, queryFn, 2, nil, query)
+	require.NoError(t, err)
+	_, containerMemory := defaultPrometheusLabels.containerUsage("")
+	groups := len(shardNamespaces([]string{"default", "kube-system", "monitoring", "team-a"}, 2))
+	assert.Len(t, queries, 1+2*groups)
+	assert.Equal(t, "count by (namespace) ("+containerMemory+")", queries[0])
+	assert.NotContains(t, queries[0], "_over_time")
+	assert.Len(t, cpuResp.Data.Result, groups)
+	assert.Len(t, memResp.Data.Result, groups)
+	for _, r := range cpuResp.Data.Result {
+		assert.Contains(t, r.Metric["query"], `container_cpu_usage_seconds_total{container!="",container!="POD",namespace=~"`)
+		assert.Contains(t, r.Metric["query"], `kube_pod_container_info{container_id!="",namespace=~"`)
+	}
+
+	failing := func(query string) (*prometheusResponse, error) {
+		if strings.HasPrefix(query, "count by (namespace)") {
+			return queryFn(query)
+		}
+		return nil, errors.New("response too large")
+	}
+	_, _, err = queryContainerUsage(run, failing, 2, nil, query)
+	assert.ErrorContains(t, err, "querying container CPU of shard 1 of")
+}
@@ -0,0 +1,338 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// quotaWarningPercent is the share of a quota hard limit in use at which a
+// quota is reported as near its limit
+const quotaWarningPercent = 90
+
+const (
+	quotaStatusNearLimit = "near limit"
+	quotaStatusExhausted = "exhausted"
+)
+
+type quotaMetric struct {
+	namespace string
+	name      string
+	cpu       *quotaResourceMetric
+	memory    *quotaResourceMetric
+}
+
+// quotaResourceMetric holds the quota usage of a single resource. Hard
+// limits are nil when the quota does not constrain them.
+type quotaResourceMetric struct {
+	resourceType string
+	request      resource.Quantity
+	requestHard  *resource.Quantity
+	limit        resource.Quantity
+	limitHard    *resource.Quantity
+	utilization  resource.Quantity
+}
+
+type listQuotas struct {
+	Quotas []*listQuota `json:"quotas"`
+}
+
+type listQuota struct {
+	Namespace string             `json:"namespace"`
+	Name      string             `json:"name"`
+	CPU       *listQuotaResource `json:"cpu"`
+	Memory    *listQuotaResource `json:"memory"`
+	Status    string             `json:"status,omitempty"`
+}
+
+type listQuotaResource struct {
+	Requests    *listQuotaValue `json:"requests,omitempty"`
+	Limits      *listQuotaValue `json:"limits,omitempty"`
+	Utilization string          `json:"utilization,omitempty"`
+}
+
+type listQuotaValue struct {
+	Used    string `json:"used"`
+	Hard    string `json:"hard"`
+	Percent string `json:"percent"`
+}
+
+func getResourceQuotas(run *reportRun, clientset kubernetes.Interface, namespace, namespaceLabels string) (*corev1.ResourceQuotaList, *fetchError) {
+	start := time.Now()
+	quotaList, err := clientset.CoreV1().ResourceQuotas(namespace).List(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		return nil, newFetchError(3, "Error listing ResourceQuotas: %v", err)
+	}
+	run.logTiming(start, "Listed %d ResourceQuotas", len(quotaList.Items))
+
+	if namespace == "" && namespaceLabels != "" {
+		namespaces, ferr := listNamespaceNames(context.TODO(), run, clientset, namespaceLabels)
+		if ferr != nil {
+			return nil, ferr
+		}
+
+		newQuotaItems := []corev1.ResourceQuota{}
+		for _, quota := range quotaList.Items {
+			if namespaces[quota.GetNamespace()] {
+				newQuotaItems = append(newQuotaItems, quota)
+			}
+		}
+		quotaList.Items = newQuotaItems
+	}
+
+	return quotaList, nil
+}
+
+// buildQuotaMetrics compares the usage recorded on each ResourceQuota with
+// its hard limits, adding the utilization of pods in the quota's namespace
+func buildQuotaMetrics(quotaList *corev1.ResourceQuotaList, cm *clusterMetric) []*quotaMetric {
+	namespaceMetrics := cm.getNamespaceMetrics()
+
+	quotaMetrics := []*quotaMetric{}
+	for i := range quotaList.Items {
+		quota := &quotaList.Items[i]
+		qm := &quotaMetric{
+			namespace: quota.Namespace,
+			name:      quota.Name,
+			cpu:       newQuotaResourceMetric("cpu", quota),
+			memory:    newQuotaResourceMetric("memory", quota),
+		}
+		if nsm, ok := namespaceMetrics[quota.Namespace]; ok {
+			qm.cpu.utilization = nsm.cpu.utilization.DeepCopy()
+			qm.memory.utilization = nsm.memory.utilization.DeepCopy()
+		}
+		quotaMetrics = append(quotaMetrics, qm)
+	}
+
+	sort.Slice(quotaMetrics, func(i, j int) bool {
+		if quotaMetrics[i].namespace != quotaMetrics[j].namespace {
+			return quotaMetrics[i].namespace < quotaMetrics[j].namespace
+		}
+		return quotaMetrics[i].name < quotaMetrics[j].name
+	})
+
+	return quotaMetrics
+}
+
+func newQuotaResourceMetric(resourceType string, quota *corev1.ResourceQuota) *quotaResourceMetric {
+	qrm := &quotaResourceMetric{resourceType: resourceType}
+
+	// "cpu" and "requests.cpu" are equivalent in a quota spec
+	for _, name := range []corev1.ResourceName{
+		corev1.ResourceName(resourceType),
+		corev1.ResourceName("requests." + resourceType),
+	} {
+		if hard, ok := quota.Spec.Hard[name]; ok {
+			if qrm.requestHard == nil || hard.Cmp(*qrm.requestHard) < 0 {
+				qrm.requestHard = &hard
+			}
+			qrm.request = quota.Status.Used[name]
+		}
+	}
+
+	name := corev1.ResourceName("limits." + resourceType)
+	if hard, ok := quota.Spec.Hard[name]; ok {
+		qrm.limitHard = &hard
+		qrm.limit = quota.Status.Used[name]
+	}
+
+	return qrm
+}
+
+// status returns whether any hard limit of the quota is close to or fully
+// used up
+func (qm *quotaMetric) status() string {
+	max := int64(0)
+	for _, qrm := range []*quotaResourceMetric{qm.cpu, qm.memory} {
+		if qrm.requestHard != nil && quotaPercent(qrm.request, *qrm.requestHard) > max {
+			max = quotaPercent(qrm.request, *qrm.requestHard)
+		}
+		if qrm.limitHard != nil && quotaPercent(qrm.limit, *qrm.limitHard) > max {
+			max = quotaPercent(qrm.limit, *qrm.limitHard)
+		}
+	}
+
+	if max >= 100 {
+		return quotaStatusExhausted
+	}
+	if max >= quotaWarningPercent {
+		return quotaStatusNearLimit
+	}
+	return ""
+}
+
+func quotaPercent(used, hard resource.Quantity) int64 {
+	if hard.MilliValue() == 0 {
+		if used.MilliValue() > 0 {
+			return 100
+		}
+		return 0
+	}
+	return int64(float64(used.MilliValue()) / float64(hard.MilliValue()) * 100)
+}
+
+func quotaValue(run *reportRun, resourceType string, used resource.Quantity, hard *resource.Quantity) *listQuotaValue {
+	if hard == nil {
+		return nil
+	}
+	rm := resourceMetric{resourceType: resourceType}
+	valueCalculator := rm.valueFunction(run)
+	return &listQuotaValue{
+		Used:    valueCalculator(used),
+		Hard:    valueCalculator(*hard),
+		Percent: fmt.Sprintf("%d%%", quotaPercent(used, *hard)),
+	}
+}
+
+// String formats the value as "used/hard (percent)"
+func (v *listQuotaValue) String() string {
+	if v == nil {
+		return VoidValue
+	}
+	return fmt.Sprintf("%s/%s (%s)", v.Used, v.Hard, v.Percent)
+}
+
+func printQuotas(run *reportRun, cm *clusterMetric, opts Options) error {
+	qp := &quotaPrinter{
+		run:    run,
+		quotas: buildQuotaMetrics(cm.resourceQuotas, cm),
+		opts:   opts,
+	}
+	return qp.Print(opts.OutputFormat)
+}
+
+type quotaPrinter struct {
+	run    *reportRun
+	quotas []*quotaMetric
+	opts   Options
+}
+
+func (qp *quotaPrinter) Print(outputType string) error {
+	switch outputType {
+	case JSONOutput, YAMLOutput:
+		qp.printList(outputType)
+	case TableOutput:
+		w := tabwriter.NewWriter(qp.run.output, 0, 8, 2, ' ', 0)
+		qp.printTable(w, "\t ")
+		if err := w.Flush(); err != nil {
+			qp.run.logErrorf("Error writing to table: %s", err)
+		}
+	case CSVOutput:
+		qp.printTable(qp.run.output, ",")
+	case TSVOutput:
+		qp.printTable(qp.run.output, "\t")
+	default:
+		return fmt.Errorf("Called with an unsupported output type: %s", outputType)
+	}
+	return nil
+}
+
+func (qp *quotaPrinter) printTable(w io.Writer, separator string) {
+	_, _ = fmt.Fprintln(w, strings.Join(qp.headers(), separator))
+	for _, qm := range qp.quotas {
+		_, _ = fmt.Fprintln(w, strings.Join(qp.lineItems(qm), separator))
+	}
+}
+
+func (qp *quotaPrinter) headers() []string {
+	headers := []string{}
+	if qp.opts.Namespace == "" {
+		headers = append(headers, "NAMESPACE")
+	}
+	headers = append(headers, "QUOTA")
+	for _, prefix := range []string{"CPU", "MEMORY"} {
+		if !qp.opts.HideRequests {
+			headers = append(headers, prefix+" REQUESTS")
+		}
+		if !qp.opts.HideLimits {
+			headers = append(headers, prefix+" LIMITS")
+		}
+		if qp.opts.ShowUtil {
+			headers = append(headers, prefix+" UTIL")
+		}
+	}
+	return append(headers, "STATUS")
+}
+
+func (qp *quotaPrinter) lineItems(qm *quotaMetric) []string {
+	lq := qp.listQuota(qm)
+
+	items := []string{}
+	if qp.opts.Namespace == "" {
+		items = append(items, lq.Namespace)
+	}
+	items = append(items, lq.Name)
+	for _, r := range []*listQuotaResource{lq.CPU, lq.Memory} {
+		if !qp.opts.HideRequests {
+			items = append(items, r.Requests.String())
+		}
+		if !qp.opts.HideLimits {
+			items = append(items, r.Limits.String())
+		}
+		if qp.opts.ShowUtil {
+			items = append(items, r.Utilization)
+		}
+	}
+
+	status := lq.Status
+	if status == "" {
+		status = VoidValue
+	}
+	return append(items, status)
+}
+
+func (qp *quotaPrinter) listQuota(qm *quotaMetric) *listQuota {
+	return &listQuota{
+		Namespace: qm.namespace,
+		Name:      qm.name,
+		CPU:       qp.listQuotaResource(qm.cpu),
+		Memory:    qp.listQuotaResource(qm.memory),
+		Status:    qm.status(),
+	}
+}
+
+func (qp *quotaPrinter) listQuotaResource(qrm *quotaResourceMetric) *listQuotaResource {
+	out := &listQuotaResource{}
+	if !qp.opts.HideRequests {
+		out.Requests = quotaValue(qp.run, qrm.resourceType, qrm.request, qrm.requestHard)
+	}
+	if !qp.opts.HideLimits {
+		out.Limits = quotaValue(qp.run, qrm.resourceType, qrm.limit, qrm.limitHard)
+	}
+	if qp.opts.ShowUtil {
+		rm := resourceMetric{resourceType: qrm.resourceType}
+		out.Utilization = rm.valueFunction(qp.run)(qrm.utilization)
+	}
+	return out
+}
+
+func (qp *quotaPrinter) printList(outputType string) {
+	out := listQuotas{Quotas: []*listQuota{}}
+	for _, qm := range qp.quotas {
+		out.Quotas = append(out.Quotas, qp.listQuota(qm))
+	}
+	printListOutput(qp.run, QuotaReportKind, out, outputType)
+}
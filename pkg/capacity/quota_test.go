@@ -0,0 +1,142 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestBuildQuotaMetrics(t *testing.T) {
+	run := newTestRun(nil)
+	snap := getTestSnapshot()
+	cm := buildClusterMetric(snap.Pods, snap.PodMetrics, snap.Nodes, snap.NodeMetrics)
+
+	quotaList := &corev1.ResourceQuotaList{Items: []corev1.ResourceQuota{
+		resourceQuota("other", "compute", corev1.ResourceList{
+			"requests.cpu":    resource.MustParse("200m"),
+			"requests.memory": resource.MustParse("1Gi"),
+		}, corev1.ResourceList{
+			"requests.cpu":    resource.MustParse("100m"),
+			"requests.memory": resource.MustParse("128Mi"),
+		}),
+		resourceQuota("default", "compute", corev1.ResourceList{
+			"cpu":           resource.MustParse("110m"),
+			"limits.memory": resource.MustParse("256Mi"),
+		}, corev1.ResourceList{
+			"cpu":           resource.MustParse("100m"),
+			"limits.memory": resource.MustParse("0"),
+		}),
+		resourceQuota("default", "pods", corev1.ResourceList{
+			"pods": resource.MustParse("10"),
+		}, corev1.ResourceList{
+			"pods": resource.MustParse("10"),
+		}),
+	}}
+
+	quotas := buildQuotaMetrics(quotaList, &cm)
+	assert.Len(t, quotas, 3)
+
+	qp := &quotaPrinter{run: run, quotas: quotas, opts: Options{ShowUtil: true}}
+	assert.Equal(t, []string{
+		"NAMESPACE", "QUOTA",
+		"CPU REQUESTS", "CPU LIMITS", "CPU UTIL",
+		"MEMORY REQUESTS", "MEMORY LIMITS", "MEMORY UTIL",
+		"STATUS",
+	}, qp.headers())
+
+	assert.Equal(t, []string{
+		"default", "compute",
+		"100m/110m (90%)", "*", "50m",
+		"*", "0Mi/256Mi (0%)", "64Mi",
+		"near limit",
+	}, qp.lineItems(quotas[0]))
+
+	// quotas on other resources are listed without a status
+	assert.Equal(t, []string{
+		"default", "pods",
+		"*", "*", "50m",
+		"*", "*", "64Mi",
+		"*",
+	}, qp.lineItems(quotas[1]))
+
+	assert.Equal(t, []string{
+		"other", "compute",
+		"100m/200m (50%)", "*", "70m",
+		"128Mi/1024Mi (12%)", "*", "96Mi",
+		"*",
+	}, qp.lineItems(quotas[2]))
+
+	qp.opts = Options{Namespace: "default", HideLimits: true}
+	assert.Equal(t, []string{"QUOTA", "CPU REQUESTS", "MEMORY REQUESTS", "STATUS"}, qp.headers())
+	assert.Equal(t, []string{"compute", "100m/110m (90%)", "*", "near limit"}, qp.lineItems(quotas[0]))
+}
+
+func TestQuotaStatus(t *testing.T) {
+	quota := resourceQuota("default", "compute", corev1.ResourceList{
+		"limits.cpu": resource.MustParse("1"),
+	}, corev1.ResourceList{
+		"limits.cpu": resource.MustParse("1"),
+	})
+	qm := &quotaMetric{
+		cpu:    newQuotaResourceMetric("cpu", &quota),
+		memory: newQuotaResourceMetric("memory", &quota),
+	}
+	assert.Equal(t, quotaStatusExhausted, qm.status())
+
+	quota.Status.Used["limits.cpu"] = resource.MustParse("500m")
+	qm.cpu = newQuotaResourceMetric("cpu", &quota)
+	assert.Equal(t, "", qm.status())
+
+	// a zero hard limit forbids any usage of the resource
+	quota.Spec.Hard["limits.cpu"] = resource.MustParse("0")
+	qm.cpu = newQuotaResourceMetric("cpu", &quota)
+	assert.Equal(t, quotaStatusExhausted, qm.status())
+}
+
+func TestGetResourceQuotasFromSnapshot(t *testing.T) {
+	run := newTestRun(nil)
+	snap := getTestSnapshot()
+	snap.ResourceQuotas = &corev1.ResourceQuotaList{Items: []corev1.ResourceQuota{
+		resourceQuota("default", "compute", corev1.ResourceList{}, corev1.ResourceList{}),
+		resourceQuota("other", "compute", corev1.ResourceList{}, corev1.ResourceList{}),
+	}}
+
+	quotaList, ferr := getResourceQuotas(run, snap.clientset(), "", "")
+	assert.Nil(t, ferr)
+	assert.Len(t, quotaList.Items, 2)
+
+	quotaList, ferr = getResourceQuotas(run, snap.clientset(), "other", "")
+	assert.Nil(t, ferr)
+	assert.Len(t, quotaList.Items, 1)
+	assert.Equal(t, "other", quotaList.Items[0].Namespace)
+
+	quotaList, ferr = getResourceQuotas(run, snap.clientset(), "", "app=true")
+	assert.Nil(t, ferr)
+	assert.Len(t, quotaList.Items, 1)
+	assert.Equal(t, "default", quotaList.Items[0].Namespace)
+}
+
+func resourceQuota(namespace, name string, hard, used corev1.ResourceList) corev1.ResourceQuota {
+	return corev1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec:       corev1.ResourceQuotaSpec{Hard: hard},
+		Status:     corev1.ResourceQuotaStatus{Hard: hard, Used: used},
+	}
+}
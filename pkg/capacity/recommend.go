@@ -0,0 +1,402 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/client-go/kubernetes"
+	v1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+)
+
+// Recommended requests are never lower than these, so that containers that
+// were idle over the window still get a usable value
+var (
+	minRecommendedCPU    = resource.MustParse("10m")
+	minRecommendedMemory = resource.MustParse("16Mi")
+)
+
+type containerRecommendation struct {
+	node      string
+	namespace string
+	pod       string
+	container string
+	cpu       *recommendation
+	memory    *recommendation
+}
+
+// recommendation compares the current request and limit of a container with
+// the values suggested from its historical usage. Limits are only suggested
+// for containers that currently have one.
+type recommendation struct {
+	resourceType     string
+	usage            resource.Quantity
+	request          resource.Quantity
+	limit            *resource.Quantity
+	suggestedRequest resource.Quantity
+	suggestedLimit   *resource.Quantity
+}
+
+type listRecommendations struct {
+	Containers    []*listContainerRecommendation `json:"containers"`
+	Nodes         []*listReclaimed               `json:"nodes"`
+	ClusterTotals *listReclaimed                 `json:"clusterTotals"`
+}
+
+type listContainerRecommendation struct {
+	Node      string              `json:"node"`
+	Namespace string              `json:"namespace"`
+	Pod       string              `json:"pod"`
+	Container string              `json:"container"`
+	CPU       *listRecommendation `json:"cpu"`
+	Memory    *listRecommendation `json:"memory"`
+}
+
+type listRecommendation struct {
+	Usage    string                `json:"usage"`
+	Requests *listRecommendedValue `json:"requests"`
+	Limits   *listRecommendedValue `json:"limits,omitempty"`
+}
+
+type listRecommendedValue struct {
+	Current   string `json:"current"`
+	Suggested string `json:"suggested"`
+}
+
+// listReclaimed is the capacity that would be freed if the suggested
+// requests were applied. Negative values mean more capacity is needed.
+type listReclaimed struct {
+	Name   string `json:"name,omitempty"`
+	CPU    string `json:"cpu"`
+	Memory string `json:"memory"`
+}
+
+// FetchAndPrintRecommendations compares the historical usage of each
+// container in Prometheus with its current requests and limits and prints
+// suggested values along with the capacity they would reclaim on each node
+func FetchAndPrintRecommendations(opts Options) error {
+	run := newRun(opts)
+
+	clientset, err := newClientSet(run, opts)
+	if err != nil {
+		return fmt.Errorf("Error connecting to Kubernetes: %v", err)
+	}
+
+	podList, _, ferr := getPodsAndNodes(run, clientset, newListFilters(opts))
+	if ferr != nil {
+		return ferr
+	}
+
+	pmList, err := getPrometheusUsageQuantile(run, clientset, opts)
+	if err != nil {
+		return newFetchError(4, "Error getting metrics from Prometheus: %v", err)
+	}
+
+	rp := &recommendPrinter{
+		run:             run,
+		recommendations: buildRecommendations(podList, pmList),
+		opts:            opts,
+	}
+	if err := rp.Print(opts.OutputFormat); err != nil {
+		return err
+	}
+	return run.closeOutput()
+}
+
+// getPrometheusUsageQuantile returns the given percentile of each container's
+// usage over the recommendation window
+func getPrometheusUsageQuantile(run *reportRun, clientset kubernetes.Interface, opts Options) (*v1beta1.PodMetricsList, error) {
+	endpoint, err := resolvePrometheusEndpoint(run, clientset, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	promHTTP, err := newPrometheusHTTP(context.TODO(), run, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	queryFn := func(query string) (*prometheusResponse, error) {
+		return queryPrometheus(run, clientset, endpoint, promHTTP, query)
+	}
+	var rules *recordingRules
+	if opts.PrometheusRecordingRules {
+		rules = detectRecordingRules(run, queryFn)
+	}
+	quantile := opts.RecommendPercentile / 100
+	cpuResp, memResp, err := queryContainerUsage(run, queryFn, opts.PrometheusQueryShards, rules, func(usage string) string {
+		return containerUsageQuantileQuery(quantile, usage, opts.RecommendWindow)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return buildPodMetricsList(run, cpuResp, memResp), nil
+}
+
+// buildRecommendations suggests requests and limits for every container that
+// has usage data. Containers without usage data are skipped.
+func buildRecommendations(podList *corev1.PodList, pmList *v1beta1.PodMetricsList) []*containerRecommendation {
+	usage := map[string]corev1.ResourceList{}
+	for _, pm := range pmList.Items {
+		for _, c := range pm.Containers {
+			usage[fmt.Sprintf("%s/%s/%s", pm.Namespace, pm.Name, c.Name)] = c.Usage
+		}
+	}
+
+	recommendations := []*containerRecommendation{}
+	for _, pod := range podList.Items {
+		containers := []*corev1.Container{}
+		for i := range pod.Spec.Containers {
+			containers = append(containers, &pod.Spec.Containers[i])
+		}
+		containers = append(containers, sidecarContainers(&pod)...)
+
+		for _, container := range containers {
+			containerUsage, ok := usage[fmt.Sprintf("%s/%s/%s", pod.Namespace, pod.Name, container.Name)]
+			if !ok {
+				continue
+			}
+			recommendations = append(recommendations, &containerRecommendation{
+				node:      pod.Spec.NodeName,
+				namespace: pod.Namespace,
+				pod:       pod.Name,
+				container: containerDisplayName(container.Name, isSidecar(container)),
+				cpu:       newRecommendation("cpu", containerUsage, container.Resources, minRecommendedCPU),
+				memory:    newRecommendation("memory", containerUsage, container.Resources, minRecommendedMemory),
+			})
+		}
+	}
+
+	sort.Slice(recommendations, func(i, j int) bool {
+		ri, rj := recommendations[i], recommendations[j]
+		if ri.node != rj.node {
+			return ri.node < rj.node
+		}
+		if ri.namespace != rj.namespace {
+			return ri.namespace < rj.namespace
+		}
+		if ri.pod != rj.pod {
+			return ri.pod < rj.pod
+		}
+		return ri.container < rj.container
+	})
+
+	return recommendations
+}
+
+// newRecommendation suggests a request equal to the usage percentile, and
+// scales any existing limit by the same factor so the container keeps its
+// current burst ratio
+func newRecommendation(resourceType string, usage corev1.ResourceList, resources corev1.ResourceRequirements, minimum resource.Quantity) *recommendation {
+	name := corev1.ResourceName(resourceType)
+	r := &recommendation{
+		resourceType: resourceType,
+		usage:        usage[name],
+		request:      resources.Requests[name],
+	}
+
+	r.suggestedRequest = roundUpQuantity(resourceType, r.usage)
+	if r.suggestedRequest.Cmp(minimum) < 0 {
+		r.suggestedRequest = minimum.DeepCopy()
+	}
+
+	if limit, ok := resources.Limits[name]; ok {
+		r.limit = &limit
+		suggestedLimit := limit.DeepCopy()
+		if r.request.MilliValue() > 0 {
+			ratio := float64(limit.MilliValue()) / float64(r.request.MilliValue())
+			suggestedLimit = roundUpQuantity(resourceType,
+				*resource.NewMilliQuantity(int64(float64(r.suggestedRequest.MilliValue())*ratio), resource.DecimalSI))
+		}
+		if suggestedLimit.Cmp(r.suggestedRequest) < 0 {
+			suggestedLimit = r.suggestedRequest.DeepCopy()
+		}
+		r.suggestedLimit = &suggestedLimit
+	}
+
+	return r
+}
+
+// roundUpQuantity rounds CPU up to whole millicores and memory up to whole
+// mebibytes, matching the units used in the report
+func roundUpQuantity(resourceType string, q resource.Quantity) resource.Quantity {
+	if resourceType == "memory" {
+		return *resource.NewQuantity(formatToMegiBytes(q)*Mebibyte, resource.BinarySI)
+	}
+	return *resource.NewMilliQuantity(q.MilliValue(), resource.DecimalSI)
+}
+
+// reclaimedMetric sums the current and suggested requests of a set of
+// containers
+type reclaimedMetric struct {
+	cpuRequest      resource.Quantity
+	cpuSuggested    resource.Quantity
+	memoryRequest   resource.Quantity
+	memorySuggested resource.Quantity
+}
+
+func (rm *reclaimedMetric) add(r *containerRecommendation) {
+	rm.cpuRequest.Add(r.cpu.request)
+	rm.cpuSuggested.Add(r.cpu.suggestedRequest)
+	rm.memoryRequest.Add(r.memory.request)
+	rm.memorySuggested.Add(r.memory.suggestedRequest)
+}
+
+func (rm *reclaimedMetric) listReclaimed(run *reportRun, name string) *listReclaimed {
+	return &listReclaimed{
+		Name:   name,
+		CPU:    diffValue(run, "cpu", rm.cpuSuggested, rm.cpuRequest).Delta,
+		Memory: diffValue(run, "memory", rm.memorySuggested, rm.memoryRequest).Delta,
+	}
+}
+
+// getReclaimedMetrics returns the capacity freed on each node, and in total,
+// by applying the suggested requests
+func getReclaimedMetrics(recommendations []*containerRecommendation) (map[string]*reclaimedMetric, *reclaimedMetric) {
+	nodes := map[string]*reclaimedMetric{}
+	total := &reclaimedMetric{}
+	for _, r := range recommendations {
+		if _, ok := nodes[r.node]; !ok {
+			nodes[r.node] = &reclaimedMetric{}
+		}
+		nodes[r.node].add(r)
+		total.add(r)
+	}
+	return nodes, total
+}
+
+type recommendPrinter struct {
+	run             *reportRun
+	recommendations []*containerRecommendation
+	opts            Options
+}
+
+func (rp *recommendPrinter) Print(outputType string) error {
+	switch outputType {
+	case JSONOutput, YAMLOutput:
+		printListOutput(rp.run, RecommendationReportKind, rp.buildListRecommendations(), outputType)
+	case TableOutput:
+		w := tabwriter.NewWriter(rp.run.output, 0, 8, 2, ' ', 0)
+		rp.printTable(w, "\t ")
+		if err := w.Flush(); err != nil {
+			rp.run.logErrorf("Error writing to table: %s", err)
+		}
+	case CSVOutput:
+		rp.printTable(rp.run.output, ",")
+	case TSVOutput:
+		rp.printTable(rp.run.output, "\t")
+	default:
+		return fmt.Errorf("Called with an unsupported output type: %s", outputType)
+	}
+	return nil
+}
+
+func (rp *recommendPrinter) printTable(w io.Writer, separator string) {
+	list := rp.buildListRecommendations()
+
+	_, _ = fmt.Fprintln(w, strings.Join(rp.headers(), separator))
+	for _, c := range list.Containers {
+		_, _ = fmt.Fprintln(w, strings.Join(rp.lineItems(c), separator))
+	}
+
+	_, _ = fmt.Fprintln(w)
+	_, _ = fmt.Fprintln(w, strings.Join([]string{"NODE", "CPU RECLAIMED", "MEMORY RECLAIMED"}, separator))
+	for _, r := range append([]*listReclaimed{list.ClusterTotals}, list.Nodes...) {
+		_, _ = fmt.Fprintln(w, strings.Join([]string{r.Name, r.CPU, r.Memory}, separator))
+	}
+}
+
+func (rp *recommendPrinter) headers() []string {
+	usage := fmt.Sprintf("P%g", rp.opts.RecommendPercentile)
+	return []string{
+		"NODE", "NAMESPACE", "POD", "CONTAINER",
+		"CPU " + usage, "CPU REQUESTS", "CPU LIMITS",
+		"MEMORY " + usage, "MEMORY REQUESTS", "MEMORY LIMITS",
+	}
+}
+
+func (rp *recommendPrinter) lineItems(c *listContainerRecommendation) []string {
+	items := []string{c.Node, c.Namespace, c.Pod, c.Container}
+	for _, r := range []*listRecommendation{c.CPU, c.Memory} {
+		items = append(items, r.Usage, r.Requests.String(), r.Limits.String())
+	}
+	return items
+}
+
+// String formats the value as "current -> suggested"
+func (v *listRecommendedValue) String() string {
+	if v == nil {
+		return VoidValue
+	}
+	return fmt.Sprintf("%s -> %s", v.Current, v.Suggested)
+}
+
+func (rp *recommendPrinter) buildListRecommendations() *listRecommendations {
+	out := &listRecommendations{
+		Containers: []*listContainerRecommendation{},
+		Nodes:      []*listReclaimed{},
+	}
+	for _, r := range rp.recommendations {
+		out.Containers = append(out.Containers, &listContainerRecommendation{
+			Node:      r.node,
+			Namespace: r.namespace,
+			Pod:       r.pod,
+			Container: r.container,
+			CPU:       r.cpu.listRecommendation(rp.run),
+			Memory:    r.memory.listRecommendation(rp.run),
+		})
+	}
+
+	nodes, total := getReclaimedMetrics(rp.recommendations)
+	names := make([]string, 0, len(nodes))
+	for name := range nodes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		out.Nodes = append(out.Nodes, nodes[name].listReclaimed(rp.run, name))
+	}
+	out.ClusterTotals = total.listReclaimed(rp.run, VoidValue)
+
+	return out
+}
+
+func (r *recommendation) listRecommendation(run *reportRun) *listRecommendation {
+	rm := resourceMetric{resourceType: r.resourceType}
+	valueCalculator := rm.valueFunction(run)
+
+	out := &listRecommendation{
+		Usage: valueCalculator(r.usage),
+		Requests: &listRecommendedValue{
+			Current:   valueCalculator(r.request),
+			Suggested: valueCalculator(r.suggestedRequest),
+		},
+	}
+	if r.limit != nil {
+		out.Limits = &listRecommendedValue{
+			Current:   valueCalculator(*r.limit),
+			Suggested: valueCalculator(*r.suggestedLimit),
+		}
+	}
+	return out
+}
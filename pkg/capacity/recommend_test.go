@@ -0,0 +1,87 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	v1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+)
+
+func TestBuildRecommendations(t *testing.T) {
+	run := newTestRun(nil)
+	snap := getTestSnapshot()
+	// mypod has a 2x CPU limit, mypod3 is idle and has no usage history
+	snap.Pods.Items[0].Spec.Containers[0].Resources.Limits = corev1.ResourceList{
+		"cpu": resource.MustParse("200m"),
+	}
+	snap.Pods.Items = append(snap.Pods.Items, *pod("mynode", "default", "mypod3", nil))
+	snap.Pods.Items[2].Spec.Containers = []corev1.Container{{Name: "app"}}
+
+	pmList := &v1beta1.PodMetricsList{Items: []v1beta1.PodMetrics{
+		podMetrics("default", "mypod", "app", "40300u", "100Mi"),
+		podMetrics("other", "mypod2", "app", "2m", "1Mi"),
+	}}
+
+	recommendations := buildRecommendations(snap.Pods, pmList)
+	assert.Len(t, recommendations, 2)
+
+	rp := &recommendPrinter{run: run, recommendations: recommendations, opts: Options{RecommendPercentile: 95}}
+	assert.Equal(t, []string{
+		"NODE", "NAMESPACE", "POD", "CONTAINER",
+		"CPU P95", "CPU REQUESTS", "CPU LIMITS",
+		"MEMORY P95", "MEMORY REQUESTS", "MEMORY LIMITS",
+	}, rp.headers())
+
+	list := rp.buildListRecommendations()
+	assert.Equal(t, []string{
+		"mynode", "default", "mypod", "app",
+		"41m", "100m -> 41m", "200m -> 82m",
+		"100Mi", "128Mi -> 100Mi", "*",
+	}, rp.lineItems(list.Containers[0]))
+
+	// usage below the minimums is raised to them
+	assert.Equal(t, []string{
+		"mynode2", "other", "mypod2", "app",
+		"2m", "100m -> 10m", "*",
+		"1Mi", "128Mi -> 16Mi", "*",
+	}, rp.lineItems(list.Containers[1]))
+
+	assert.Equal(t, &listReclaimed{Name: "*", CPU: "+149m", Memory: "+140Mi"}, list.ClusterTotals)
+	assert.Equal(t, []*listReclaimed{
+		{Name: "mynode", CPU: "+59m", Memory: "+28Mi"},
+		{Name: "mynode2", CPU: "+90m", Memory: "+112Mi"},
+	}, list.Nodes)
+}
+
+func TestNewRecommendationWithoutRequest(t *testing.T) {
+	usage := corev1.ResourceList{"memory": resource.MustParse("300Mi")}
+
+	// the current limit is kept when there is no request to scale it by,
+	// unless it is lower than the suggested request
+	r := newRecommendation("memory", usage, corev1.ResourceRequirements{
+		Limits: corev1.ResourceList{"memory": resource.MustParse("1Gi")},
+	}, minRecommendedMemory)
+	assert.Equal(t, "300Mi", r.suggestedRequest.String())
+	assert.Equal(t, "1Gi", r.suggestedLimit.String())
+
+	r = newRecommendation("memory", usage, corev1.ResourceRequirements{
+		Limits: corev1.ResourceList{"memory": resource.MustParse("256Mi")},
+	}, minRecommendedMemory)
+	assert.Equal(t, "300Mi", r.suggestedLimit.String())
+}
@@ -0,0 +1,114 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"fmt"
+	"strings"
+)
+
+// cpuRecordingRules are the kube-prometheus recording rules with the CPU
+// usage of each container, preferring the 5m rate the raw queries use over
+// the irate older releases record
+var cpuRecordingRules = []string{
+	"node_namespace_pod_container:container_cpu_usage_seconds_total:sum_rate5m",
+	"node_namespace_pod_container:container_cpu_usage_seconds_total:sum_irate",
+}
+
+// memoryRecordingRule is the kube-prometheus recording rule with the memory
+// working set of each container
+const memoryRecordingRule = "node_namespace_pod_container:container_memory_working_set_bytes"
+
+// recordingRules name the recording rules usage is read from. The rules
+// are labeled with the node, namespace, pod, and container of every
+// container, and are computed by Prometheus as it scrapes cAdvisor, so
+// reading them costs a fraction of evaluating the raw series again.
+type recordingRules struct {
+	cpu    string
+	memory string
+}
+
+// detectRecordingRules returns the recording rules Prometheus has series
+// for, or nil to read raw cAdvisor series when it has none of them. Rules
+// are labeled as kube-prometheus labels cAdvisor series, so they are not
+// used when the series are relabeled.
+func detectRecordingRules(run *reportRun, queryFn func(string) (*prometheusResponse, error)) *recordingRules {
+	if run.promLabels != defaultPrometheusLabels {
+		return nil
+	}
+	names := append([]string{memoryRecordingRule}, cpuRecordingRules...)
+	resp, err := queryFn(recordingRulesQuery(names))
+	if err != nil {
+		run.logDebugf("Error looking for recording rules, reading raw cAdvisor series: %v", err)
+		return nil
+	}
+	found := map[string]bool{}
+	for _, r := range resp.Data.Result {
+		found[r.Metric["__name__"]] = true
+	}
+	if !found[memoryRecordingRule] {
+		run.logDebugf("No recording rules found, reading raw cAdvisor series")
+		return nil
+	}
+	for _, cpu := range cpuRecordingRules {
+		if found[cpu] {
+			run.logDebugf("Reading usage from recording rules %s and %s", cpu, memoryRecordingRule)
+			return &recordingRules{cpu: cpu, memory: memoryRecordingRule}
+		}
+	}
+	run.logDebugf("No recording rules found, reading raw cAdvisor series")
+	return nil
+}
+
+// recordingRulesQuery counts the series of each of the named rules, so the
+// response holds a series for each rule that has any
+func recordingRulesQuery(names []string) string {
+	return fmt.Sprintf(`count by (__name__) ({__name__=~"%s"})`, strings.Join(names, "|"))
+}
+
+// containerUsage returns the CPU and memory usage of containers, without
+// the pause container, only in the namespaces matching a regexp when it is
+// set. Without rules, usage is read from raw cAdvisor series with labels.
+func (r *recordingRules) containerUsage(labels prometheusLabels, namespaces string) (cpu, memory string) {
+	if r == nil {
+		return labels.containerUsage(namespaces)
+	}
+	matchers := `container!="",container!="POD"`
+	if namespaces != "" {
+		matchers += fmt.Sprintf(`,namespace=~"%s"`, namespaces)
+	}
+	cpu = orWindows(
+		dedupReplicas(fmt.Sprintf(`%s{%s}`, r.cpu, matchers)),
+		windowsContainerSeries(`rate(windows_container_cpu_usage_seconds_total[5m])`, namespaces))
+	memory = orWindows(
+		dedupReplicas(fmt.Sprintf(`%s{%s}`, r.memory, matchers)),
+		windowsContainerSeries(`windows_container_memory_usage_private_working_set_bytes`, namespaces))
+	return cpu, memory
+}
+
+// nodeUsage returns the CPU and memory usage of everything running on
+// nodes, from raw cAdvisor series with labels without rules
+func (r *recordingRules) nodeUsage(labels prometheusLabels) (cpu, memory string) {
+	if r == nil {
+		return labels.nodeUsage()
+	}
+	cpu = orWindows(
+		dedupReplicas(fmt.Sprintf(`%s{container!=""}`, r.cpu)),
+		windowsNodeContainerSeries(`rate(windows_container_cpu_usage_seconds_total[5m])`))
+	memory = orWindows(
+		dedupReplicas(fmt.Sprintf(`%s{container!=""}`, r.memory)),
+		windowsNodeContainerSeries(`windows_container_memory_usage_private_working_set_bytes`))
+	return cpu, memory
+}
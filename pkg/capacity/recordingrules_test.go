@@ -0,0 +1,81 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectRecordingRules(t *testing.T) {
+	run := newTestRun(nil)
+	rulesFound := func(names ...string) func(string) (*prometheusResponse, error) {
+		return func(query string) (*prometheusResponse, error) {
+			assert.Equal(t, `count by (__name__) ({__name__=~"`+
+				`node_namespace_pod_container:container_memory_working_set_bytes|`+
+				`node_namespace_pod_container:container_cpu_usage_seconds_total:sum_rate5m|`+
+				`node_namespace_pod_container:container_cpu_usage_seconds_total:sum_irate"})`, query)
+			resp := &prometheusResponse{Status: "success"}
+			for _, name := range names {
+				resp.Data.Result = append(resp.Data.Result, prometheusResult{Metric: map[string]string{"__name__": name}})
+			}
+			return resp, nil
+		}
+	}
+
+	rules := detectRecordingRules(run, rulesFound(memoryRecordingRule, cpuRecordingRules[1], cpuRecordingRules[0]))
+	require.NotNil(t, rules)
+	assert.Equal(t, cpuRecordingRules[0], rules.cpu)
+
+	rules = detectRecordingRules(run, rulesFound(memoryRecordingRule, cpuRecordingRules[1]))
+	require.NotNil(t, rules)
+	assert.Equal(t, cpuRecordingRules[1], rules.cpu)
+
+	assert.Nil(t, detectRecordingRules(run, rulesFound(cpuRecordingRules[0])))
+	assert.Nil(t, detectRecordingRules(run, rulesFound()))
+	assert.Nil(t, detectRecordingRules(run, func(string) (*prometheusResponse, error) {
+		return nil, errors.New("unavailable")
+	}))
+
+	// Relabeled series are not what the rules are recorded from
+	run.promLabels = newPrometheusLabels(Options{PrometheusNodeLabel: "instance"})
+	assert.Nil(t, detectRecordingRules(run, rulesFound(memoryRecordingRule, cpuRecordingRules[0])))
+}
+
+func TestRecordingRulesUsage(t *testing.T) {
+	labels := defaultPrometheusLabels
+	var raw *recordingRules
+	cpu, memory := raw.containerUsage(labels, "")
+	containerCPU, containerMemory := labels.containerUsage("")
+	assert.Equal(t, containerCPU, cpu)
+	assert.Equal(t, containerMemory, memory)
+	cpu, _ = raw.nodeUsage(labels)
+	nodeCPU, _ := labels.nodeUsage()
+	assert.Equal(t, nodeCPU, cpu)
+
+	rules := &recordingRules{cpu: cpuRecordingRules[0], memory: memoryRecordingRule}
+	cpu, memory = rules.containerUsage(labels, "default|kube-system")
+	assert.Contains(t, cpu, `max without (prometheus_replica) (node_namespace_pod_container:container_cpu_usage_seconds_total:sum_rate5m{container!="",container!="POD",namespace=~"default|kube-system"})`)
+	assert.Contains(t, cpu, `kube_pod_container_info{container_id!="",namespace=~"default|kube-system"}`)
+	assert.Contains(t, memory, `node_namespace_pod_container:container_memory_working_set_bytes{container!="",container!="POD",namespace=~"default|kube-system"}`)
+	assert.NotContains(t, cpu, "container_cpu_usage_seconds_total{")
+
+	_, memory = rules.nodeUsage(labels)
+	assert.Contains(t, nodeUsageQuery("avg", memory, "15m", 0),
+		`avg_over_time(sum by (node) (max without (prometheus_replica) (node_namespace_pod_container:container_memory_working_set_bytes{container!=""}) or on (namespace, pod, container)`)
+}
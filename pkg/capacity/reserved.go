@@ -0,0 +1,119 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// reserved returns what the kubelet, the system, and the eviction threshold
+// hold back from capacity, which is not allocatable to pods
+func (rm *resourceMetric) reserved() resource.Quantity {
+	reserved := rm.capacity.DeepCopy()
+	reserved.Sub(rm.allocatable)
+	return reserved
+}
+
+// nodeCapacityString returns the capacity of a node or the sum of the
+// capacity of nodes, or VoidValue when the nodes did not report it
+func (rm *resourceMetric) nodeCapacityString(run *reportRun) string {
+	if rm.capacity.IsZero() {
+		return VoidValue
+	}
+	return run.formatQuantity(rm.resourceType, rm.capacity)
+}
+
+func (rm *resourceMetric) allocatableString(run *reportRun) string {
+	return run.formatQuantity(rm.resourceType, rm.allocatable)
+}
+
+// reservedString returns the reserved resources with their share of
+// capacity, example: "180m (5%)"
+func (rm *resourceMetric) reservedString(run *reportRun) string {
+	if rm.capacity.IsZero() {
+		return VoidValue
+	}
+	return fmt.Sprintf("%s (%s)", run.formatQuantity(rm.resourceType, rm.reserved()), percentString(run, rm.reserved(), rm.capacity))
+}
+
+func (rm *resourceMetric) nodeCapacityCSVString(run *reportRun) string {
+	if rm.capacity.IsZero() {
+		return VoidValue
+	}
+	return run.resourceCSVString(rm.resourceType, rm.capacity)
+}
+
+func (rm *resourceMetric) reservedCSVString(run *reportRun) string {
+	if rm.capacity.IsZero() {
+		return VoidValue
+	}
+	return run.resourceCSVString(rm.resourceType, rm.reserved())
+}
+
+// addBreakdown adds the reserved breakdown of a node to totals, which only
+// have a breakdown once a node with one has been added
+func (rm *resourceMetric) addBreakdown(rb *reservedBreakdown) {
+	if rb == nil {
+		return
+	}
+	if rm.breakdown == nil {
+		rm.breakdown = &reservedBreakdown{}
+	}
+	rm.breakdown.add(rb)
+}
+
+func (rm *resourceMetric) kubeReservedString(run *reportRun) string {
+	if rm.breakdown == nil {
+		return VoidValue
+	}
+	return run.formatQuantity(rm.resourceType, rm.breakdown.kube)
+}
+
+func (rm *resourceMetric) systemReservedString(run *reportRun) string {
+	if rm.breakdown == nil {
+		return VoidValue
+	}
+	return run.formatQuantity(rm.resourceType, rm.breakdown.system)
+}
+
+func (rm *resourceMetric) evictionThresholdString(run *reportRun) string {
+	if rm.breakdown == nil {
+		return VoidValue
+	}
+	return run.formatQuantity(rm.resourceType, rm.breakdown.eviction)
+}
+
+func (rm *resourceMetric) kubeReservedCSVString(run *reportRun) string {
+	if rm.breakdown == nil {
+		return VoidValue
+	}
+	return run.resourceCSVString(rm.resourceType, rm.breakdown.kube)
+}
+
+func (rm *resourceMetric) systemReservedCSVString(run *reportRun) string {
+	if rm.breakdown == nil {
+		return VoidValue
+	}
+	return run.resourceCSVString(rm.resourceType, rm.breakdown.system)
+}
+
+func (rm *resourceMetric) evictionThresholdCSVString(run *reportRun) string {
+	if rm.breakdown == nil {
+		return VoidValue
+	}
+	return run.resourceCSVString(rm.resourceType, rm.breakdown.eviction)
+}
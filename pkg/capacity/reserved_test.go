@@ -0,0 +1,65 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestReservedStrings(t *testing.T) {
+	run := newTestRun(nil)
+	withCapacity := func(n *corev1.Node, cpu, memory string) corev1.Node {
+		n.Status.Allocatable = corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("3910m"),
+			corev1.ResourceMemory: resource.MustParse("14554Mi"),
+		}
+		if cpu != "" {
+			n.Status.Capacity = corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse(cpu),
+				corev1.ResourceMemory: resource.MustParse(memory),
+			}
+		}
+		return *n
+	}
+
+	cm := buildClusterMetric(&corev1.PodList{}, nil, &corev1.NodeList{Items: []corev1.Node{
+		withCapacity(node("node-1", nil, false), "4", "15930Mi"),
+		withCapacity(node("node-2", nil, false), "4", "15930Mi"),
+		withCapacity(node("old", nil, false), "", ""),
+	}}, nil)
+
+	nm := cm.nodeMetrics["node-1"]
+	assert.Equal(t, "4000m", nm.cpu.nodeCapacityString(run))
+	assert.Equal(t, "3910m", nm.cpu.allocatableString(run))
+	assert.Equal(t, "90m (2%)", nm.cpu.reservedString(run))
+	assert.Equal(t, "1376Mi (8%)", nm.memory.reservedString(run))
+	assert.Equal(t, "1376", nm.memory.reservedCSVString(run))
+
+	assert.Equal(t, VoidValue, cm.nodeMetrics["old"].cpu.nodeCapacityString(run))
+	assert.Equal(t, VoidValue, cm.nodeMetrics["old"].cpu.reservedCSVString(run))
+
+	assert.Equal(t, "8000m", cm.cpu.nodeCapacityString(run))
+	assert.Equal(t, "8000", cm.cpu.nodeCapacityCSVString(run))
+
+	tp := &tablePrinter{run: run, cm: &cm, opts: Options{ShowCapacity: true, HideLimits: true}}
+	assert.Equal(t, []string{
+		"NODE", "CPU CAPACITY", "CPU ALLOCATABLE", "CPU RESERVED", "CPU REQUESTS",
+		"MEMORY CAPACITY", "MEMORY ALLOCATABLE", "MEMORY RESERVED", "MEMORY REQUESTS",
+	}, tp.getLineItems(tp.headers()))
+}
@@ -0,0 +1,102 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// resizePending is shown for pods waiting on a resize when the API server
+// does not report a resize status
+const resizePending = "Pending"
+
+// containerRequests returns the requests of a container the way the
+// scheduler counts them with in-place pod resize. Once the kubelet has
+// allocated resources to a container, the larger of the allocated and
+// desired requests is held on the node, or only the allocated requests when
+// the resize can never be granted.
+func containerRequests(pod *corev1.Pod, container *corev1.Container) corev1.ResourceList {
+	cs := containerStatus(pod, container.Name)
+	if cs == nil || cs.AllocatedResources == nil {
+		return container.Resources.Requests
+	}
+	if pod.Status.Resize == corev1.PodResizeStatusInfeasible {
+		return cs.AllocatedResources
+	}
+
+	requests := corev1.ResourceList{}
+	for name, quantity := range cs.AllocatedResources {
+		requests[name] = quantity
+	}
+	for name, quantity := range container.Resources.Requests {
+		if allocated, ok := requests[name]; !ok || quantity.Cmp(allocated) > 0 {
+			requests[name] = quantity
+		}
+	}
+	return requests
+}
+
+// containerResizePending returns true when the requests in the spec of a
+// container differ from the resources allocated to it on the node
+func containerResizePending(pod *corev1.Pod, container *corev1.Container) bool {
+	cs := containerStatus(pod, container.Name)
+	if cs == nil || cs.AllocatedResources == nil {
+		return false
+	}
+	for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		desired := container.Resources.Requests[name]
+		allocated := cs.AllocatedResources[name]
+		if desired.Cmp(allocated) != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func containerStatus(pod *corev1.Pod, name string) *corev1.ContainerStatus {
+	for i := range pod.Status.ContainerStatuses {
+		if pod.Status.ContainerStatuses[i].Name == name {
+			return &pod.Status.ContainerStatuses[i]
+		}
+	}
+	return nil
+}
+
+// podResizeStatus returns the status of a pending resize of the pod, or
+// "Pending" if containers are waiting on a resize without a status
+func podResizeStatus(pod *corev1.Pod) string {
+	if pod.Status.Resize != "" {
+		return string(pod.Status.Resize)
+	}
+	for i := range pod.Spec.Containers {
+		if containerResizePending(pod, &pod.Spec.Containers[i]) {
+			return resizePending
+		}
+	}
+	return ""
+}
+
+func resizeString(status string) string {
+	if status == "" {
+		return VoidValue
+	}
+	return status
+}
+
+func pendingResizesString(count int64) string {
+	return fmt.Sprintf("%d", count)
+}
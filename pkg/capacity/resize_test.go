@@ -0,0 +1,92 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func resizedPod(name, desiredCPU, allocatedCPU string, status corev1.PodResizeStatus) *corev1.Pod {
+	p := pod("mynode", "default", name, map[string]string{})
+	p.Spec.Containers = []corev1.Container{{
+		Name: "app",
+		Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{
+			"cpu":    resource.MustParse(desiredCPU),
+			"memory": resource.MustParse("256Mi"),
+		}},
+	}}
+	p.Status.Resize = status
+	p.Status.ContainerStatuses = []corev1.ContainerStatus{{
+		Name: "app",
+		AllocatedResources: corev1.ResourceList{
+			"cpu":    resource.MustParse(allocatedCPU),
+			"memory": resource.MustParse("256Mi"),
+		},
+	}}
+	return p
+}
+
+func cpuRequest(p *corev1.Pod) int64 {
+	requests := containerRequests(p, &p.Spec.Containers[0])
+	return requests.Cpu().MilliValue()
+}
+
+func TestContainerRequests(t *testing.T) {
+	up := resizedPod("up", "2", "1", corev1.PodResizeStatusInProgress)
+	assert.Equal(t, int64(2000), cpuRequest(up))
+
+	down := resizedPod("down", "500m", "1", corev1.PodResizeStatusInProgress)
+	assert.Equal(t, int64(1000), cpuRequest(down))
+
+	infeasible := resizedPod("infeasible", "64", "1", corev1.PodResizeStatusInfeasible)
+	assert.Equal(t, int64(1000), cpuRequest(infeasible))
+
+	unset := pod("mynode", "default", "unset", map[string]string{})
+	unset.Spec.Containers = []corev1.Container{{Name: "app"}}
+	assert.Empty(t, containerRequests(unset, &unset.Spec.Containers[0]))
+	assert.Equal(t, "", podResizeStatus(unset))
+}
+
+func TestPendingResizes(t *testing.T) {
+	run := newTestRun(nil)
+	podList := &corev1.PodList{Items: []corev1.Pod{
+		*resizedPod("down", "500m", "1", corev1.PodResizeStatusInProgress),
+		*resizedPod("done", "1", "1", ""),
+		*resizedPod("no-status", "250m", "1", ""),
+	}}
+	nodeList := &corev1.NodeList{Items: []corev1.Node{*node("mynode", map[string]string{}, false)}}
+	cm := buildClusterMetric(podList, nil, nodeList, nil)
+
+	nm := cm.nodeMetrics["mynode"]
+	assert.Equal(t, int64(2), nm.pendingResizes)
+	assert.Equal(t, int64(2), cm.pendingResizes)
+	assert.Equal(t, int64(3000), nm.cpu.request.MilliValue())
+
+	down := nm.podMetrics["default/down"]
+	assert.Equal(t, "InProgress", resizeString(down.resize))
+	assert.Equal(t, "InProgress", down.containerMetrics["app"].resize)
+	assert.Equal(t, int64(1000), down.containerMetrics["app"].cpu.request.MilliValue())
+	assert.Equal(t, VoidValue, resizeString(nm.podMetrics["default/done"].resize))
+	assert.Equal(t, resizePending, nm.podMetrics["default/no-status"].resize)
+
+	lp := &listPrinter{run: run, cm: &cm, opts: Options{ShowResize: true, ShowContainers: true}}
+	list := lp.buildListClusterMetrics()
+	assert.Equal(t, "2", list.ClusterTotals.PendingResizes)
+	assert.Equal(t, "2", list.Nodes[0].PendingResizes)
+}
@@ -17,9 +17,13 @@ package capacity
 import (
 	"fmt"
 	"sort"
+	"time"
 
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
 	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	resourcehelper "k8s.io/kubectl/pkg/util/resource"
 	v1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
 )
@@ -51,36 +55,210 @@ type resourceMetric struct {
 	utilization  resource.Quantity
 	request      resource.Quantity
 	limit        resource.Quantity
+
+	// capacity is the status.capacity of nodes, of which allocatable is
+	// what is left after the reservations of the kubelet and the system. It
+	// is not set on pods and containers.
+	capacity resource.Quantity
+
+	// breakdown is only set on nodes and totals with --reserved-breakdown,
+	// for nodes whose kubelet configuration could be read
+	breakdown *reservedBreakdown
+
+	// overhead is the part of request added by the RuntimeClass of pods,
+	// it is not set on containers
+	overhead resource.Quantity
+
+	// vpa is only set on containers with a VerticalPodAutoscaler recommendation
+	vpa *vpaRange
+
+	// history is only set on nodes, pods, and the cluster with --sparkline,
+	// and holds usage at evenly spaced points over the Prometheus window
+	history []float64
 }
 
 type clusterMetric struct {
+	name        string
 	cpu         *resourceMetric
 	memory      *resourceMetric
 	nodeMetrics map[string]*nodeMetric
 	podCount    *podCount
+
+	// resourceQuotas is only set when comparing against quotas
+	resourceQuotas *corev1.ResourceQuotaList
+
+	// podDisruptionBudgets is only set when checking a drain
+	podDisruptionBudgets *policyv1.PodDisruptionBudgetList
+
+	// horizontalPodAutoscalers is only set with --hpa-headroom
+	horizontalPodAutoscalers *autoscalingv2.HorizontalPodAutoscalerList
+
+	// nodeGroups is only set when estimating node group headroom
+	nodeGroups []*nodeGroupMetric
+
+	// karpenterPools is only set with --karpenter
+	karpenterPools []*karpenterPoolMetric
+
+	// pendingPods is only set when reporting pods waiting to be scheduled
+	pendingPods *corev1.PodList
+
+	// virtualNodes is only set with --virtual-nodes=separate, and holds the
+	// virtual nodes left out of the cluster and the pods on them
+	virtualNodes *clusterMetric
+
+	// earlier is only set with --compare-offset, and holds the same nodes
+	// and pods with the utilization of that long ago
+	earlier *clusterMetric
+
+	// previous is only set with --show-delta, and holds the last report of
+	// the same cluster cached by an earlier run, or nil when there was none
+	previous *lastRun
+
+	// cost is only set when estimating costs
+	cost *nodeCost
+
+	// pendingResizes is the number of pods waiting on an in-place resize
+	pendingResizes int64
+
+	// nodeStatus counts the nodes that are Ready and schedulable
+	nodeStatus *nodeStatusCount
+
+	// disk is only set with --show-disk
+	disk *diskCount
+
+	// observedAt is when the cluster was read, used for node ages
+	observedAt time.Time
 }
 
 type nodeMetric struct {
-	name       string
-	labels     map[string]string
-	cpu        *resourceMetric
-	memory     *resourceMetric
-	podMetrics map[string]*podMetric
-	podCount   *podCount
+	name          string
+	labels        map[string]string
+	taints        []corev1.Taint
+	unschedulable bool
+	cpu           *resourceMetric
+	memory        *resourceMetric
+	podMetrics    map[string]*podMetric
+	podCount      *podCount
+	roles         []string
+	ready         corev1.ConditionStatus
+	created       time.Time
+
+	// cost is nil when costs are not shown or the node could not be priced
+	cost *nodeCost
+
+	// pendingResizes is the number of pods waiting on an in-place resize
+	pendingResizes int64
+
+	// gpus are the NVIDIA extended resources the node advertises or its
+	// pods request, by resource name
+	gpus map[corev1.ResourceName]*gpuMetric
+
+	// usage is when utilization was measured, nil without utilization
+	usage *usageSample
+
+	// ephemeralStorage is only read for --columns
+	ephemeralStorage *resourceMetric
+
+	// disk is only set with --show-disk
+	disk *nodeDisk
 }
 
 type podMetric struct {
 	name             string
 	namespace        string
+	labels           map[string]string
 	cpu              *resourceMetric
 	memory           *resourceMetric
 	containerMetrics map[string]*containerMetric
+
+	// gpu and ephemeralStorage are only read for --columns
+	gpu              *resourceMetric
+	ephemeralStorage *resourceMetric
+
+	// resize is the status of a pending in-place resize
+	resize string
+
+	// daemonSet is true for pods controlled by a DaemonSet
+	daemonSet bool
+
+	// doNotDisrupt is true for pods Karpenter may not evict to consolidate
+	// their node
+	doNotDisrupt bool
+
+	// mirror is true for static pods the kubelet runs from a manifest, which
+	// can not be evicted
+	mirror bool
+
+	// unmanaged is true for pods without a controller to recreate them
+	unmanaged bool
+
+	// priority is the priority the admission controller resolved from the
+	// priority class of the pod, 0 without one
+	priority int32
+
+	// scheduling restricts the nodes the pod could be rescheduled on
+	scheduling schedulingConstraints
+
+	// controllerKind and controllerName are the workload controlling the
+	// pod, with ReplicaSets resolved to their Deployment
+	controllerKind string
+	controllerName string
+
+	// cpuThrottled is the share of CFS periods the pod was throttled in, nil
+	// without --show-throttling or a CPU limit
+	cpuThrottled *float64
+
+	// restarts is the sum of the restarts of the containers of the pod
+	restarts int32
+
+	// lastOOMKill is the most recent OOMKill of a container of the pod, nil
+	// when none was OOMKilled
+	lastOOMKill *time.Time
+
+	// stuckStatus is why the pod is not running, such as Terminating or
+	// ContainerCreating, and stuckSince is since when. Both are empty for
+	// pods that are running.
+	stuckStatus string
+	stuckSince  time.Time
+
+	// started is when the kubelet started the pod, and reportsUsage is true
+	// when the metrics source returned usage for it
+	started      time.Time
+	reportsUsage bool
 }
 
 type containerMetric struct {
 	name   string
 	cpu    *resourceMetric
 	memory *resourceMetric
+
+	// gpu and ephemeralStorage are only read for --columns
+	gpu              *resourceMetric
+	ephemeralStorage *resourceMetric
+
+	// resize is only set when the container is waiting on an in-place resize
+	resize string
+
+	// sidecar is true for init containers that run for the life of the pod
+	sidecar bool
+
+	// cpuThrottled is the share of CFS periods the container was throttled
+	// in, nil without --show-throttling or a CPU limit
+	cpuThrottled *float64
+
+	// restarts is the restart count from the container status
+	restarts int32
+
+	// lastOOMKill is when the container was last OOMKilled, nil when its
+	// current and last termination were not OOMKills
+	lastOOMKill *time.Time
+}
+
+type namespaceMetric struct {
+	name     string
+	cpu      *resourceMetric
+	memory   *resourceMetric
+	podCount int64
 }
 
 type podCount struct {
@@ -95,36 +273,62 @@ func buildClusterMetric(podList *corev1.PodList, pmList *v1beta1.PodMetricsList,
 		memory:      &resourceMetric{resourceType: "memory"},
 		nodeMetrics: map[string]*nodeMetric{},
 		podCount:    &podCount{},
+		nodeStatus:  &nodeStatusCount{},
+	}
+
+	// Pods and their metrics are indexed up front so that building the
+	// report takes a single pass over each list
+	nodePodCounts := map[string]int64{}
+	for i := range podList.Items {
+		nodePodCounts[podList.Items[i].Spec.NodeName]++
+	}
+
+	podMetrics := map[string]*v1beta1.PodMetrics{}
+	if pmList != nil {
+		for i := range pmList.Items {
+			pm := &pmList.Items[i]
+			podMetrics[podKey(pm.Namespace, pm.Name)] = pm
+		}
 	}
 
 	var totalPodAllocatable int64
 	var totalPodCurrent int64
-	for _, node := range nodeList.Items {
-		var tmpPodCount int64
-		for _, pod := range podList.Items {
-			if pod.Spec.NodeName == node.Name && pod.Status.Phase != corev1.PodSucceeded && pod.Status.Phase != corev1.PodFailed {
-				tmpPodCount++
-			}
-		}
+	for i := range nodeList.Items {
+		node := &nodeList.Items[i]
+		tmpPodCount := nodePodCounts[node.Name]
 		totalPodCurrent += tmpPodCount
 		totalPodAllocatable += node.Status.Allocatable.Pods().Value()
 		cm.nodeMetrics[node.Name] = &nodeMetric{
-			name:   node.Name,
-			labels: map[string]string{},
+			name:          node.Name,
+			labels:        map[string]string{},
+			taints:        node.Spec.Taints,
+			unschedulable: node.Spec.Unschedulable,
 			cpu: &resourceMetric{
 				resourceType: "cpu",
 				allocatable:  node.Status.Allocatable["cpu"],
+				capacity:     node.Status.Capacity["cpu"],
 			},
 			memory: &resourceMetric{
 				resourceType: "memory",
 				allocatable:  node.Status.Allocatable["memory"],
+				capacity:     node.Status.Capacity["memory"],
+			},
+			ephemeralStorage: &resourceMetric{
+				resourceType: "ephemeral-storage",
+				allocatable:  node.Status.Allocatable["ephemeral-storage"],
+				capacity:     node.Status.Capacity["ephemeral-storage"],
 			},
 			podMetrics: map[string]*podMetric{},
 			podCount: &podCount{
 				current:     tmpPodCount,
 				allocatable: node.Status.Allocatable.Pods().Value(),
 			},
+			roles:   nodeRoles(node.Labels),
+			ready:   nodeReadyCondition(node),
+			created: node.CreationTimestamp.Time,
 		}
+		cm.nodeMetrics[node.Name].gpus = nodeGPUs(node)
+		cm.nodeStatus.addNode(cm.nodeMetrics[node.Name])
 
 		if node.Labels != nil {
 			cm.nodeMetrics[node.Name].labels = node.Labels
@@ -141,19 +345,16 @@ func buildClusterMetric(podList *corev1.PodList, pmList *v1beta1.PodMetricsList,
 			}
 			cm.nodeMetrics[nm.Name].cpu.utilization = nm.Usage["cpu"]
 			cm.nodeMetrics[nm.Name].memory.utilization = nm.Usage["memory"]
+			cm.nodeMetrics[nm.Name].observeUsage(nm.Timestamp.Time, nm.Window.Duration)
 		}
 	}
 
-	podMetrics := map[string]v1beta1.PodMetrics{}
-	if pmList != nil {
-		for _, pm := range pmList.Items {
-			podMetrics[fmt.Sprintf("%s-%s", pm.GetNamespace(), pm.GetName())] = pm
-		}
-	}
-
-	for _, pod := range podList.Items {
-		if pod.Status.Phase != corev1.PodSucceeded && pod.Status.Phase != corev1.PodFailed {
-			cm.addPodMetric(&pod, podMetrics[fmt.Sprintf("%s-%s", pod.GetNamespace(), pod.GetName())])
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		pm := podMetrics[podKey(pod.Namespace, pod.Name)]
+		cm.addPodMetric(pod, pm)
+		if nm, ok := cm.nodeMetrics[pod.Spec.NodeName]; ok && nmList == nil && pm != nil {
+			nm.observeUsage(pm.Timestamp.Time, pm.Window.Duration)
 		}
 	}
 
@@ -173,35 +374,134 @@ func buildClusterMetric(podList *corev1.PodList, pmList *v1beta1.PodMetricsList,
 
 func (rm *resourceMetric) addMetric(m *resourceMetric) {
 	rm.allocatable.Add(m.allocatable)
+	rm.capacity.Add(m.capacity)
 	rm.utilization.Add(m.utilization)
 	rm.request.Add(m.request)
 	rm.limit.Add(m.limit)
+	rm.overhead.Add(m.overhead)
+	rm.addBreakdown(m.breakdown)
+}
+
+// excludeTerminatedPods removes succeeded and failed pods, which no longer
+// hold any capacity on their nodes, and returns how many were removed
+func excludeTerminatedPods(podList *corev1.PodList) int {
+	pods := []corev1.Pod{}
+	for _, pod := range podList.Items {
+		if pod.Status.Phase != corev1.PodSucceeded && pod.Status.Phase != corev1.PodFailed {
+			pods = append(pods, pod)
+		}
+	}
+	excluded := len(podList.Items) - len(pods)
+	podList.Items = pods
+	return excluded
+}
+
+// ignoreInitContainers removes init containers other than native sidecars
+// from every pod so that pod requests and limits are the sum of the app
+// containers and sidecars alone. By default they follow the scheduler, which
+// uses the larger of that sum and the largest init container.
+func ignoreInitContainers(podList *corev1.PodList) {
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		initContainers := []corev1.Container{}
+		for _, container := range sidecarContainers(pod) {
+			initContainers = append(initContainers, *container)
+		}
+		pod.Spec.InitContainers = initContainers
+	}
 }
 
-func (cm *clusterMetric) addPodMetric(pod *corev1.Pod, podMetrics v1beta1.PodMetrics) {
+// podKey identifies a pod by namespace and name, neither of which can
+// contain a slash
+func podKey(namespace, name string) string {
+	return namespace + "/" + name
+}
+
+// addPodMetric adds a pod to its node, podMetrics is nil when the pod has no
+// utilization data
+func (cm *clusterMetric) addPodMetric(pod *corev1.Pod, podMetrics *v1beta1.PodMetrics) {
 	req, limit := resourcehelper.PodRequestsAndLimits(pod)
-	key := fmt.Sprintf("%s-%s", pod.Namespace, pod.Name)
+	key := podKey(pod.Namespace, pod.Name)
 	nm := cm.nodeMetrics[pod.Spec.NodeName]
 
 	pm := &podMetric{
 		name:      pod.Name,
 		namespace: pod.Namespace,
+		labels:    pod.Labels,
 		cpu: &resourceMetric{
 			resourceType: "cpu",
 			request:      req["cpu"],
 			limit:        limit["cpu"],
+			overhead:     pod.Spec.Overhead["cpu"],
 		},
 		memory: &resourceMetric{
 			resourceType: "memory",
 			request:      req["memory"],
 			limit:        limit["memory"],
+			overhead:     pod.Spec.Overhead["memory"],
+		},
+		gpu: &resourceMetric{
+			resourceType: "gpu",
+			request:      req[nvidiaGPUResource],
+			limit:        limit[nvidiaGPUResource],
+		},
+		ephemeralStorage: &resourceMetric{
+			resourceType: "ephemeral-storage",
+			request:      req["ephemeral-storage"],
+			limit:        limit["ephemeral-storage"],
 		},
 		containerMetrics: map[string]*containerMetric{},
+		resize:           podResizeStatus(pod),
+		daemonSet:        isDaemonSetPod(pod),
+		doNotDisrupt:     pod.Annotations[karpenterDoNotDisruptAnnotation] == "true",
+		mirror:           pod.Annotations[corev1.MirrorPodAnnotationKey] != "",
+		unmanaged:        metav1.GetControllerOf(pod) == nil,
+		scheduling:       newSchedulingConstraints(pod.Spec),
+	}
+	if pod.Spec.Priority != nil {
+		pm.priority = *pod.Spec.Priority
 	}
+	pm.controllerKind, pm.controllerName = podController(pod)
 
-	for _, container := range pod.Spec.Containers {
+	for i := range pod.Spec.Containers {
+		container := &pod.Spec.Containers[i]
+		requests := containerRequests(pod, container)
 		pm.containerMetrics[container.Name] = &containerMetric{
 			name: container.Name,
+			cpu: &resourceMetric{
+				resourceType: "cpu",
+				request:      requests["cpu"],
+				limit:        container.Resources.Limits["cpu"],
+				allocatable:  nm.cpu.allocatable,
+			},
+			memory: &resourceMetric{
+				resourceType: "memory",
+				request:      requests["memory"],
+				limit:        container.Resources.Limits["memory"],
+				allocatable:  nm.memory.allocatable,
+			},
+			gpu: &resourceMetric{
+				resourceType: "gpu",
+				request:      requests[nvidiaGPUResource],
+				limit:        container.Resources.Limits[nvidiaGPUResource],
+				allocatable:  nm.gpuResourceMetric().allocatable,
+			},
+			ephemeralStorage: &resourceMetric{
+				resourceType: "ephemeral-storage",
+				request:      requests["ephemeral-storage"],
+				limit:        container.Resources.Limits["ephemeral-storage"],
+				allocatable:  nm.ephemeralStorage.allocatable,
+			},
+		}
+		if containerResizePending(pod, container) {
+			pm.containerMetrics[container.Name].resize = pm.resize
+		}
+	}
+
+	for _, container := range sidecarContainers(pod) {
+		pm.containerMetrics[container.Name] = &containerMetric{
+			name:    container.Name,
+			sidecar: true,
 			cpu: &resourceMetric{
 				resourceType: "cpu",
 				request:      container.Resources.Requests["cpu"],
@@ -214,21 +514,57 @@ func (cm *clusterMetric) addPodMetric(pod *corev1.Pod, podMetrics v1beta1.PodMet
 				limit:        container.Resources.Limits["memory"],
 				allocatable:  nm.memory.allocatable,
 			},
+			gpu: &resourceMetric{
+				resourceType: "gpu",
+				request:      container.Resources.Requests[nvidiaGPUResource],
+				limit:        container.Resources.Limits[nvidiaGPUResource],
+				allocatable:  nm.gpuResourceMetric().allocatable,
+			},
+			ephemeralStorage: &resourceMetric{
+				resourceType: "ephemeral-storage",
+				request:      container.Resources.Requests["ephemeral-storage"],
+				limit:        container.Resources.Limits["ephemeral-storage"],
+				allocatable:  nm.ephemeralStorage.allocatable,
+			},
 		}
 	}
 
+	pm.addRestarts(pod)
+	pm.addStuckState(pod)
+
 	if nm != nil {
 		nm.podMetrics[key] = pm
 		nm.podMetrics[key].cpu.allocatable = nm.cpu.allocatable
 		nm.podMetrics[key].memory.allocatable = nm.memory.allocatable
+		nm.podMetrics[key].gpu.allocatable = nm.gpuResourceMetric().allocatable
+		nm.podMetrics[key].ephemeralStorage.allocatable = nm.ephemeralStorage.allocatable
 
 		nm.cpu.request.Add(req["cpu"])
 		nm.cpu.limit.Add(limit["cpu"])
 		nm.memory.request.Add(req["memory"])
 		nm.memory.limit.Add(limit["memory"])
+		nm.cpu.overhead.Add(pm.cpu.overhead)
+		nm.memory.overhead.Add(pm.memory.overhead)
+		nm.ephemeralStorage.request.Add(req["ephemeral-storage"])
+		nm.ephemeralStorage.limit.Add(limit["ephemeral-storage"])
+		if pm.resize != "" {
+			nm.pendingResizes++
+		}
+		nm.addGPURequests(req)
 	}
 
+	if podMetrics == nil {
+		return
+	}
+	pm.reportsUsage = true
+
 	for _, container := range podMetrics.Containers {
+		// Sources that only measure whole pods report an unnamed container
+		if container.Name == "" {
+			pm.cpu.utilization.Add(container.Usage["cpu"])
+			pm.memory.utilization.Add(container.Usage["memory"])
+			continue
+		}
 		cm := pm.containerMetrics[container.Name]
 		if cm != nil {
 			pm.containerMetrics[container.Name].cpu.utilization = container.Usage["cpu"]
@@ -242,6 +578,45 @@ func (cm *clusterMetric) addPodMetric(pod *corev1.Pod, podMetrics v1beta1.PodMet
 func (cm *clusterMetric) addNodeMetric(nm *nodeMetric) {
 	cm.cpu.addMetric(nm.cpu)
 	cm.memory.addMetric(nm.memory)
+	cm.pendingResizes += nm.pendingResizes
+}
+
+// getNamespaceMetrics sums pod requests, limits, and utilization by
+// namespace. Percentages are relative to the cluster allocatable.
+func (cm *clusterMetric) getNamespaceMetrics() map[string]*namespaceMetric {
+	return cm.getPodGroupMetrics(func(pm *podMetric) string {
+		return pm.namespace
+	})
+}
+
+// getPodGroupMetrics sums the pods of the cluster by the name key returns
+// for each
+func (cm *clusterMetric) getPodGroupMetrics(key func(pm *podMetric) string) map[string]*namespaceMetric {
+	namespaceMetrics := map[string]*namespaceMetric{}
+
+	for _, nm := range cm.nodeMetrics {
+		for _, pm := range nm.podMetrics {
+			name := key(pm)
+			nsm, ok := namespaceMetrics[name]
+			if !ok {
+				nsm = &namespaceMetric{
+					name:   name,
+					cpu:    &resourceMetric{resourceType: "cpu", allocatable: cm.cpu.allocatable},
+					memory: &resourceMetric{resourceType: "memory", allocatable: cm.memory.allocatable},
+				}
+				namespaceMetrics[name] = nsm
+			}
+			nsm.cpu.request.Add(pm.cpu.request)
+			nsm.cpu.limit.Add(pm.cpu.limit)
+			nsm.cpu.utilization.Add(pm.cpu.utilization)
+			nsm.memory.request.Add(pm.memory.request)
+			nsm.memory.limit.Add(pm.memory.limit)
+			nsm.memory.utilization.Add(pm.memory.utilization)
+			nsm.podCount++
+		}
+	}
+
+	return namespaceMetrics
 }
 
 func (cm *clusterMetric) getSortedNodeMetrics(sortBy string) []*nodeMetric {
@@ -253,7 +628,12 @@ func (cm *clusterMetric) getSortedNodeMetrics(sortBy string) []*nodeMetric {
 		i++
 	}
 
+	// Map order changes from run to run, so nodes are put in name order
+	// before sorting to keep ties in the same order in every output format
 	sort.Slice(sortedNodeMetrics, func(i, j int) bool {
+		return sortedNodeMetrics[i].name < sortedNodeMetrics[j].name
+	})
+	sort.SliceStable(sortedNodeMetrics, func(i, j int) bool {
 		m1 := sortedNodeMetrics[i]
 		m2 := sortedNodeMetrics[j]
 
@@ -301,9 +681,19 @@ func (nm *nodeMetric) getSortedPodMetrics(sortBy string) []*podMetric {
 		i++
 	}
 
+	// Pods in different namespaces can share a name, so ties are broken by
+	// namespace
 	sort.Slice(sortedPodMetrics, func(i, j int) bool {
 		m1 := sortedPodMetrics[i]
 		m2 := sortedPodMetrics[j]
+		if m1.name != m2.name {
+			return m1.name < m2.name
+		}
+		return m1.namespace < m2.namespace
+	})
+	sort.SliceStable(sortedPodMetrics, func(i, j int) bool {
+		m1 := sortedPodMetrics[i]
+		m2 := sortedPodMetrics[j]
 
 		switch sortBy {
 		case "cpu.util":
@@ -355,6 +745,9 @@ func (pm *podMetric) getSortedContainerMetrics(sortBy string) []*containerMetric
 	}
 
 	sort.Slice(sortedContainerMetrics, func(i, j int) bool {
+		return sortedContainerMetrics[i].name < sortedContainerMetrics[j].name
+	})
+	sort.SliceStable(sortedContainerMetrics, func(i, j int) bool {
 		m1 := sortedContainerMetrics[i]
 		m2 := sortedContainerMetrics[j]
 
@@ -379,16 +772,16 @@ func (pm *podMetric) getSortedContainerMetrics(sortBy string) []*containerMetric
 	return sortedContainerMetrics
 }
 
-func (rm *resourceMetric) requestString(availableFormat bool) string {
-	return resourceString(rm.resourceType, rm.request, rm.allocatable, availableFormat)
+func (rm *resourceMetric) requestString(run *reportRun, availableFormat bool) string {
+	return resourceString(run, rm.resourceType, rm.request, rm.allocatable, availableFormat)
 }
 
-func (rm *resourceMetric) limitString(availableFormat bool) string {
-	return resourceString(rm.resourceType, rm.limit, rm.allocatable, availableFormat)
+func (rm *resourceMetric) limitString(run *reportRun, availableFormat bool) string {
+	return resourceString(run, rm.resourceType, rm.limit, rm.allocatable, availableFormat)
 }
 
-func (rm *resourceMetric) utilString(availableFormat bool, utilPercent string) string {
-	return resourceString(rm.resourceType, rm.utilization, rm.utilBase(utilPercent), availableFormat)
+func (rm *resourceMetric) utilString(run *reportRun, availableFormat bool, utilPercent string) string {
+	return resourceString(run, rm.resourceType, rm.utilization, rm.utilBase(utilPercent), availableFormat)
 }
 
 func (rm *resourceMetric) utilBase(utilPercent string) resource.Quantity {
@@ -402,6 +795,23 @@ func (rm *resourceMetric) utilBase(utilPercent string) resource.Quantity {
 	}
 }
 
+// overcommitString returns the sum of limits divided by allocatable, example: "1.50x"
+func (rm *resourceMetric) overcommitString() string {
+	if rm.allocatable.MilliValue() == 0 {
+		return VoidValue
+	}
+	return fmt.Sprintf("%.2fx", rm.overcommitRatio())
+}
+
+func (rm *resourceMetric) overcommitRatio() float64 {
+	return float64(rm.limit.MilliValue()) / float64(rm.allocatable.MilliValue())
+}
+
+// overheadString returns the pod overhead included in requests, example: "250m"
+func (rm *resourceMetric) overheadString(run *reportRun) string {
+	return run.formatQuantity(rm.resourceType, rm.overhead)
+}
+
 // podCountString returns the string representation of podCount struct, example: "15/110"
 func (pc *podCount) podCountString() string {
 	return fmt.Sprintf("%d/%d", pc.current, pc.allocatable)
@@ -420,7 +830,7 @@ func nodeLabelsString(labels map[string]string) string {
 	return labelStr[:len(labelStr)-1]
 }
 
-func resourceString(resourceType string, actual, allocatable resource.Quantity, availableFormat bool) string {
+func resourceString(run *reportRun, resourceType string, actual, allocatable resource.Quantity, availableFormat bool) string {
 	utilPercent := float64(0)
 	if allocatable.MilliValue() > 0 {
 		utilPercent = float64(actual.MilliValue()) / float64(allocatable.MilliValue()) * 100
@@ -430,12 +840,9 @@ func resourceString(resourceType string, actual, allocatable resource.Quantity,
 
 	if availableFormat {
 		switch resourceType {
-		case "cpu":
-			actualStr = fmt.Sprintf("%dm", allocatable.MilliValue()-actual.MilliValue())
-			allocatableStr = fmt.Sprintf("%dm", allocatable.MilliValue())
-		case "memory":
-			actualStr = fmt.Sprintf("%dMi", formatToMegiBytes(allocatable)-formatToMegiBytes(actual))
-			allocatableStr = fmt.Sprintf("%dMi", formatToMegiBytes(allocatable))
+		case "cpu", "memory", "ephemeral-storage":
+			actualStr = run.formatQuantityDifference(resourceType, allocatable, actual)
+			allocatableStr = run.formatQuantity(resourceType, allocatable)
 		default:
 			actualStr = fmt.Sprintf("%d", allocatable.Value()-actual.Value())
 			allocatableStr = fmt.Sprintf("%d", allocatable.Value())
@@ -445,15 +852,18 @@ func resourceString(resourceType string, actual, allocatable resource.Quantity,
 	}
 
 	switch resourceType {
-	case "cpu":
-		actualStr = fmt.Sprintf("%dm", actual.MilliValue())
-	case "memory":
-		actualStr = fmt.Sprintf("%dMi", formatToMegiBytes(actual))
+	case "cpu", "memory", "ephemeral-storage":
+		actualStr = run.formatQuantity(resourceType, actual)
 	default:
 		actualStr = fmt.Sprintf("%d", actual.Value())
 	}
 
-	return fmt.Sprintf("%s (%d%%)", actualStr, int64(utilPercent))
+	return fmt.Sprintf("%s (%s%%)", actualStr, run.formatPercent(utilPercent))
+}
+
+// percentString returns actual as a percentage of allocatable, example: "28%"
+func percentString(run *reportRun, actual, allocatable resource.Quantity) string {
+	return resourceCSVPercentageString(run, actual, allocatable) + "%"
 }
 
 func formatToMegiBytes(actual resource.Quantity) int64 {
@@ -465,24 +875,17 @@ func formatToMegiBytes(actual resource.Quantity) int64 {
 }
 
 // NOTE: This might not be a great place for closures due to the cyclical nature of how resourceType works. Perhaps better implemented another way.
-func (rm resourceMetric) valueFunction() (f func(r resource.Quantity) string) {
-	switch rm.resourceType {
-	case "cpu":
-		f = func(r resource.Quantity) string {
-			return fmt.Sprintf("%dm", r.MilliValue())
-		}
-	case "memory":
-		f = func(r resource.Quantity) string {
-			return fmt.Sprintf("%dMi", formatToMegiBytes(r))
-		}
+func (rm resourceMetric) valueFunction(run *reportRun) (f func(r resource.Quantity) string) {
+	resourceType := rm.resourceType
+	return func(r resource.Quantity) string {
+		return run.formatQuantity(resourceType, r)
 	}
-	return f
 }
 
 // NOTE: This might not be a great place for closures due to the cyclical nature of how resourceType works. Perhaps better implemented another way.
-func (rm resourceMetric) percentFunction() (f func(r resource.Quantity) string) {
+func (rm resourceMetric) percentFunction(run *reportRun) (f func(r resource.Quantity) string) {
 	f = func(r resource.Quantity) string {
-		return fmt.Sprintf("%v%%", rm.percent(r))
+		return resourceCSVPercentageString(run, r, rm.allocatable) + "%"
 	}
 	return f
 }
@@ -491,61 +894,79 @@ func (rm resourceMetric) percent(r resource.Quantity) int64 {
 	return int64(float64(r.MilliValue()) / float64(rm.allocatable.MilliValue()) * 100)
 }
 
-func (rm resourceMetric) utilPercentFunction(utilPercent string) func(r resource.Quantity) string {
+func (rm resourceMetric) utilPercentFunction(run *reportRun, utilPercent string) func(r resource.Quantity) string {
 	base := rm.utilBase(utilPercent)
 	return func(r resource.Quantity) string {
-		pct := int64(0)
-		if base.MilliValue() > 0 {
-			pct = int64(float64(r.MilliValue()) / float64(base.MilliValue()) * 100)
-		}
-		return fmt.Sprintf("%v%%", pct)
+		return resourceCSVPercentageString(run, r, base) + "%"
 	}
 }
 
 // For CSV / TSV formatting Helper Functions
 // -----------------------------------------
 
-func resourceCSVString(resourceType string, actual resource.Quantity) string {
-	if resourceType == "memory" {
-		return fmt.Sprintf("%d", formatToMegiBytes(actual))
+func (r *reportRun) resourceCSVString(resourceType string, actual resource.Quantity) string {
+	if resourceType != "memory" {
+		resourceType = "cpu"
 	}
-	return fmt.Sprintf("%d", actual.MilliValue())
+	return r.formatNumber(r.quantityNumber(resourceType, actual))
 }
 
-func resourceCSVPercentageString(actual, divisor resource.Quantity) string {
+func resourceCSVPercentageString(run *reportRun, actual, divisor resource.Quantity) string {
 	utilPercent := float64(0)
 	if divisor.MilliValue() > 0 {
 		utilPercent = float64(actual.MilliValue()) / float64(divisor.MilliValue()) * 100
 	}
-	return fmt.Sprintf("%d", int64(utilPercent))
+	return run.formatPercent(utilPercent)
 }
 
-func (rm *resourceMetric) capacityString() string {
-	return resourceCSVString(rm.resourceType, rm.allocatable)
+func (rm *resourceMetric) capacityString(run *reportRun) string {
+	return run.resourceCSVString(rm.resourceType, rm.allocatable)
 }
 
-func (rm *resourceMetric) requestActualString() string {
-	return resourceCSVString(rm.resourceType, rm.request)
+func (rm *resourceMetric) requestActualString(run *reportRun) string {
+	return run.resourceCSVString(rm.resourceType, rm.request)
 }
 
-func (rm *resourceMetric) requestPercentageString() string {
-	return resourceCSVPercentageString(rm.request, rm.allocatable)
+func (rm *resourceMetric) requestPercentageString(run *reportRun) string {
+	return resourceCSVPercentageString(run, rm.request, rm.allocatable)
 }
 
-func (rm *resourceMetric) limitActualString() string {
-	return resourceCSVString(rm.resourceType, rm.limit)
+func (rm *resourceMetric) overheadCSVString(run *reportRun) string {
+	return run.resourceCSVString(rm.resourceType, rm.overhead)
 }
 
-func (rm *resourceMetric) limitPercentageString() string {
-	return resourceCSVPercentageString(rm.limit, rm.allocatable)
+// availableString returns the allocatable capacity not yet requested
+func (rm *resourceMetric) availableString(run *reportRun) string {
+	return run.resourceCSVString(rm.resourceType, rm.available())
 }
 
-func (rm *resourceMetric) utilActualString() string {
-	return resourceCSVString(rm.resourceType, rm.utilization)
+func (rm *resourceMetric) available() resource.Quantity {
+	available := rm.allocatable.DeepCopy()
+	available.Sub(rm.request)
+	return available
 }
 
-func (rm *resourceMetric) utilPercentageString(utilPercent string) string {
-	return resourceCSVPercentageString(rm.utilization, rm.utilBase(utilPercent))
+func (rm *resourceMetric) limitActualString(run *reportRun) string {
+	return run.resourceCSVString(rm.resourceType, rm.limit)
+}
+
+func (rm *resourceMetric) limitPercentageString(run *reportRun) string {
+	return resourceCSVPercentageString(run, rm.limit, rm.allocatable)
+}
+
+func (rm *resourceMetric) utilActualString(run *reportRun) string {
+	return run.resourceCSVString(rm.resourceType, rm.utilization)
+}
+
+func (rm *resourceMetric) utilPercentageString(run *reportRun, utilPercent string) string {
+	return resourceCSVPercentageString(run, rm.utilization, rm.utilBase(utilPercent))
+}
+
+func (rm *resourceMetric) overcommitCSVString() string {
+	if rm.allocatable.MilliValue() == 0 {
+		return VoidValue
+	}
+	return fmt.Sprintf("%.2f", rm.overcommitRatio())
 }
 
 func (pc *podCount) podCountCurrentString() string {
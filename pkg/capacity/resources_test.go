@@ -48,6 +48,7 @@ func TestBuildClusterMetricEmpty(t *testing.T) {
 		},
 		nodeMetrics: map[string]*nodeMetric{},
 		podCount:    &podCount{},
+		nodeStatus:  &nodeStatusCount{},
 	}
 
 	assert.EqualValues(t, cm, expected)
@@ -181,11 +182,11 @@ func TestBuildClusterMetricFull(t *testing.T) {
 	cpuExpected.utilization = resource.MustParse("23m")
 	memoryExpected.utilization = resource.MustParse("299Mi")
 
-	assert.NotNil(t, pm["default-example-pod"])
-	assert.NotNil(t, pm["default-example-pod"].cpu)
-	ensureEqualResourceMetric(t, pm["default-example-pod"].cpu, cpuExpected)
-	assert.NotNil(t, pm["default-example-pod"].memory)
-	ensureEqualResourceMetric(t, pm["default-example-pod"].memory, memoryExpected)
+	assert.NotNil(t, pm["default/example-pod"])
+	assert.NotNil(t, pm["default/example-pod"].cpu)
+	ensureEqualResourceMetric(t, pm["default/example-pod"].cpu, cpuExpected)
+	assert.NotNil(t, pm["default/example-pod"].memory)
+	ensureEqualResourceMetric(t, pm["default/example-pod"].memory, memoryExpected)
 
 	node1LabelsExpected := map[string]string{
 		"example.io/os": "example-os-1",
@@ -259,6 +260,34 @@ func TestSortByPodCount(t *testing.T) {
 	assert.Equal(t, int64(1), sortedNodes[1].podCount.current)
 }
 
+func TestSortTiesAreDeterministic(t *testing.T) {
+	nodeList := &corev1.NodeList{}
+	podList := &corev1.PodList{}
+	for _, name := range []string{"node-c", "node-a", "node-d", "node-b"} {
+		nodeList.Items = append(nodeList.Items, *node(name, map[string]string{}, false))
+		for _, namespace := range []string{"ns-b", "ns-c", "ns-a"} {
+			podList.Items = append(podList.Items, *pod(name, namespace, "web", map[string]string{}))
+		}
+	}
+	cm := buildClusterMetric(podList, nil, nodeList, nil)
+
+	// Every node and pod has the same requests, so only the tie break sets
+	// their order
+	for i := 0; i < 20; i++ {
+		nodes := []string{}
+		for _, nm := range cm.getSortedNodeMetrics("cpu.request") {
+			nodes = append(nodes, nm.name)
+		}
+		assert.Equal(t, []string{"node-a", "node-b", "node-c", "node-d"}, nodes)
+
+		pods := []string{}
+		for _, pm := range cm.nodeMetrics["node-a"].getSortedPodMetrics("mem.request") {
+			pods = append(pods, podKey(pm.namespace, pm.name))
+		}
+		assert.Equal(t, []string{"ns-a/web", "ns-b/web", "ns-c/web"}, pods)
+	}
+}
+
 func ensureEqualResourceMetric(t *testing.T, actual *resourceMetric, expected *resourceMetric) {
 	assert.Equal(t, actual.allocatable.MilliValue(), expected.allocatable.MilliValue())
 	assert.Equal(t, actual.utilization.MilliValue(), expected.utilization.MilliValue())
@@ -285,3 +314,176 @@ func listPods(p *corev1.PodList) []string {
 
 	return pods
 }
+
+func TestOvercommitString(t *testing.T) {
+	cpu := &resourceMetric{
+		resourceType: "cpu",
+		allocatable:  resource.MustParse("2"),
+		limit:        resource.MustParse("3500m"),
+	}
+	assert.Equal(t, "1.75x", cpu.overcommitString())
+	assert.Equal(t, "1.75", cpu.overcommitCSVString())
+
+	memory := &resourceMetric{
+		resourceType: "memory",
+		allocatable:  resource.MustParse("4Gi"),
+		limit:        resource.MustParse("1Gi"),
+	}
+	assert.Equal(t, "0.25x", memory.overcommitString())
+
+	empty := &resourceMetric{resourceType: "cpu", limit: resource.MustParse("1")}
+	assert.Equal(t, VoidValue, empty.overcommitString())
+	assert.Equal(t, VoidValue, empty.overcommitCSVString())
+}
+
+func TestInitContainerRequests(t *testing.T) {
+	always := corev1.ContainerRestartPolicyAlways
+	requests := func(cpu, memory string) corev1.ResourceRequirements {
+		return corev1.ResourceRequirements{Requests: corev1.ResourceList{
+			"cpu":    resource.MustParse(cpu),
+			"memory": resource.MustParse(memory),
+		}}
+	}
+
+	p := pod("mynode", "default", "migrate", map[string]string{})
+	p.Spec.InitContainers = []corev1.Container{
+		{Name: "migrate", Resources: requests("1500m", "64Mi")},
+		{Name: "proxy", Resources: requests("100m", "32Mi"), RestartPolicy: &always},
+	}
+	p.Spec.Containers = []corev1.Container{
+		{Name: "app", Resources: requests("200m", "256Mi")},
+		{Name: "worker", Resources: requests("300m", "512Mi")},
+	}
+	nodeList := &corev1.NodeList{Items: []corev1.Node{*node("mynode", map[string]string{}, false)}}
+
+	// The largest init container wins for CPU and the app containers plus
+	// the sidecar win for memory
+	podList := &corev1.PodList{Items: []corev1.Pod{*p}}
+	cm := buildClusterMetric(podList, nil, nodeList, nil)
+	assert.Equal(t, int64(1500), cm.cpu.request.MilliValue())
+	assert.Equal(t, int64(800), cm.memory.request.Value()/Mebibyte)
+
+	podList = &corev1.PodList{Items: []corev1.Pod{*p.DeepCopy()}}
+	ignoreInitContainers(podList)
+	cm = buildClusterMetric(podList, nil, nodeList, nil)
+	assert.Equal(t, int64(600), cm.cpu.request.MilliValue())
+	assert.Equal(t, int64(800), cm.memory.request.Value()/Mebibyte)
+	assert.Len(t, p.Spec.InitContainers, 2)
+}
+
+func TestPodOverhead(t *testing.T) {
+	run := newTestRun(nil)
+	p := pod("mynode", "default", "sandboxed", map[string]string{})
+	p.Spec.Overhead = corev1.ResourceList{
+		"cpu":    resource.MustParse("250m"),
+		"memory": resource.MustParse("160Mi"),
+	}
+	p.Spec.Containers = []corev1.Container{{
+		Name: "app",
+		Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{
+			"cpu":    resource.MustParse("500m"),
+			"memory": resource.MustParse("256Mi"),
+		}},
+	}}
+	podList := &corev1.PodList{Items: []corev1.Pod{*p, *pod("mynode", "default", "plain", map[string]string{})}}
+	nodeList := &corev1.NodeList{Items: []corev1.Node{*node("mynode", map[string]string{}, false)}}
+
+	cm := buildClusterMetric(podList, nil, nodeList, nil)
+	assert.Equal(t, int64(750), cm.cpu.request.MilliValue())
+	assert.Equal(t, "250m", cm.cpu.overheadString(run))
+	assert.Equal(t, "160Mi", cm.nodeMetrics["mynode"].memory.overheadString(run))
+	assert.Equal(t, "160", cm.memory.overheadCSVString(run))
+
+	pm := cm.nodeMetrics["mynode"].podMetrics["default/sandboxed"]
+	assert.Equal(t, int64(416), pm.memory.request.Value()/Mebibyte)
+	assert.Equal(t, "0m", cm.nodeMetrics["mynode"].podMetrics["default/plain"].cpu.overheadString(run))
+
+	tp := &tablePrinter{run: run, cm: &cm, opts: Options{ShowOverhead: true, HideLimits: true}}
+	assert.Equal(t, []string{"NODE", "CPU REQUESTS", "CPU OVERHEAD", "MEMORY REQUESTS", "MEMORY OVERHEAD"},
+		tp.getLineItems(&headerStrings))
+
+	lp := &listPrinter{run: run, cm: &cm, opts: Options{ShowOverhead: true, ShowPods: true}}
+	list := lp.buildListClusterMetrics()
+	assert.Equal(t, "250m", list.ClusterTotals.CPU.Overhead)
+	assert.Equal(t, "160Mi", list.Nodes[0].Memory.Overhead)
+}
+
+func TestExcludeTerminatedPods(t *testing.T) {
+	podList := &corev1.PodList{Items: []corev1.Pod{
+		*pod("mynode", "default", "running", map[string]string{}),
+		*pod("mynode", "default", "succeeded", map[string]string{}),
+		*pod("mynode", "default", "failed", map[string]string{}),
+	}}
+	podList.Items[1].Status.Phase = corev1.PodSucceeded
+	podList.Items[2].Status.Phase = corev1.PodFailed
+	nodeList := &corev1.NodeList{Items: []corev1.Node{*node("mynode", map[string]string{}, false)}}
+
+	cm := buildClusterMetric(podList, nil, nodeList, nil)
+	assert.Equal(t, int64(3), cm.podCount.current)
+
+	assert.Equal(t, 2, excludeTerminatedPods(podList))
+	assert.Equal(t, []string{"default/running"}, listPods(podList))
+
+	cm = buildClusterMetric(podList, nil, nodeList, nil)
+	assert.Equal(t, int64(1), cm.podCount.current)
+}
+
+func TestBuildClusterMetricPodKeys(t *testing.T) {
+	// These pods would share a key if namespace and name were joined with a
+	// dash, which is valid in both
+	podList := &corev1.PodList{Items: []corev1.Pod{
+		*pod("mynode", "team-a", "web", map[string]string{}),
+		*pod("mynode", "team", "a-web", map[string]string{}),
+	}}
+	nodeList := &corev1.NodeList{Items: []corev1.Node{*node("mynode", map[string]string{}, false)}}
+
+	cm := buildClusterMetric(podList, nil, nodeList, nil)
+	assert.Len(t, cm.nodeMetrics["mynode"].podMetrics, 2)
+	assert.Equal(t, "team-a", cm.nodeMetrics["mynode"].podMetrics["team-a/web"].namespace)
+	assert.Equal(t, "team", cm.nodeMetrics["mynode"].podMetrics["team/a-web"].namespace)
+}
+
+// BenchmarkBuildClusterMetric builds a cluster of 1000 nodes running 100
+// pods each, all with utilization data
+func BenchmarkBuildClusterMetric(b *testing.B) {
+	podList := &corev1.PodList{}
+	pmList := &v1beta1.PodMetricsList{}
+	nodeList := &corev1.NodeList{}
+	nmList := &v1beta1.NodeMetricsList{}
+
+	for n := 0; n < 1000; n++ {
+		nodeName := fmt.Sprintf("node-%d", n)
+		nd := node(nodeName, map[string]string{}, false)
+		nd.Status.Allocatable = corev1.ResourceList{
+			"cpu":    resource.MustParse("16"),
+			"memory": resource.MustParse("64Gi"),
+			"pods":   resource.MustParse("110"),
+		}
+		nodeList.Items = append(nodeList.Items, *nd)
+		nmList.Items = append(nmList.Items, nodeMetrics(nodeName, "8", "32Gi"))
+
+		for p := 0; p < 100; p++ {
+			pd := pod(nodeName, fmt.Sprintf("ns-%d", p%20), fmt.Sprintf("pod-%d-%d", n, p), map[string]string{})
+			pd.Spec.Containers = []corev1.Container{{
+				Name: "app",
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{"cpu": resource.MustParse("100m"), "memory": resource.MustParse("256Mi")},
+					Limits:   corev1.ResourceList{"cpu": resource.MustParse("200m"), "memory": resource.MustParse("512Mi")},
+				},
+			}}
+			podList.Items = append(podList.Items, *pd)
+			pmList.Items = append(pmList.Items, v1beta1.PodMetrics{
+				ObjectMeta: metav1.ObjectMeta{Name: pd.Name, Namespace: pd.Namespace},
+				Containers: []v1beta1.ContainerMetrics{{
+					Name:  "app",
+					Usage: corev1.ResourceList{"cpu": resource.MustParse("50m"), "memory": resource.MustParse("200Mi")},
+				}},
+			})
+		}
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		buildClusterMetric(podList, pmList, nodeList, nmList)
+	}
+}
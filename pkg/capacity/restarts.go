@@ -0,0 +1,88 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/duration"
+)
+
+// oomKilledReason is the reason the kubelet gives a container that was
+// killed for running out of memory
+const oomKilledReason = "OOMKilled"
+
+// addRestarts sets the restart count and last OOMKill of each container from
+// the pod status. The pod gets the sum of the restarts and the most recent
+// OOMKill of its containers.
+func (pm *podMetric) addRestarts(pod *corev1.Pod) {
+	statuses := append([]corev1.ContainerStatus{}, pod.Status.ContainerStatuses...)
+	statuses = append(statuses, pod.Status.InitContainerStatuses...)
+
+	for i := range statuses {
+		cs := &statuses[i]
+		c, ok := pm.containerMetrics[cs.Name]
+		if !ok {
+			continue
+		}
+		c.restarts = cs.RestartCount
+		c.lastOOMKill = lastOOMKill(cs)
+
+		pm.restarts += c.restarts
+		if c.lastOOMKill != nil && (pm.lastOOMKill == nil || c.lastOOMKill.After(*pm.lastOOMKill)) {
+			pm.lastOOMKill = c.lastOOMKill
+		}
+	}
+}
+
+// lastOOMKill returns when a container was last killed for running out of
+// memory, or nil when neither its current nor its last termination was an
+// OOMKill
+func lastOOMKill(cs *corev1.ContainerStatus) *time.Time {
+	for _, terminated := range []*corev1.ContainerStateTerminated{cs.State.Terminated, cs.LastTerminationState.Terminated} {
+		if terminated != nil && terminated.Reason == oomKilledReason {
+			finished := terminated.FinishedAt.Time
+			return &finished
+		}
+	}
+	return nil
+}
+
+func restartsString(restarts int32) string {
+	return fmt.Sprintf("%d", restarts)
+}
+
+// oomKillString returns how long before the report was observed the last
+// OOMKill happened, example: "3h"
+func oomKillString(lastOOMKill *time.Time, observedAt time.Time) string {
+	if lastOOMKill == nil {
+		return VoidValue
+	}
+	if observedAt.IsZero() {
+		observedAt = time.Now()
+	}
+	return duration.HumanDuration(observedAt.Sub(*lastOOMKill))
+}
+
+// oomKillTimestamp returns when the last OOMKill happened in RFC 3339, for
+// output that is read by other tools
+func oomKillTimestamp(lastOOMKill *time.Time) string {
+	if lastOOMKill == nil {
+		return VoidValue
+	}
+	return lastOOMKill.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,72 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestAddRestarts(t *testing.T) {
+	observedAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	oomKilled := func(ago time.Duration) *corev1.ContainerStateTerminated {
+		return &corev1.ContainerStateTerminated{
+			Reason:     oomKilledReason,
+			FinishedAt: metav1.NewTime(observedAt.Add(-ago)),
+		}
+	}
+
+	always := corev1.ContainerRestartPolicyAlways
+	p := pod("mynode", "default", "api", nil)
+	p.Spec.Containers = []corev1.Container{{Name: "app"}, {Name: "worker"}, {Name: "new"}}
+	p.Spec.InitContainers = []corev1.Container{{Name: "proxy", RestartPolicy: &always}}
+	p.Status.ContainerStatuses = []corev1.ContainerStatus{
+		{
+			Name:                 "app",
+			RestartCount:         5,
+			LastTerminationState: corev1.ContainerState{Terminated: oomKilled(2 * time.Hour)},
+		},
+		{
+			Name:                 "worker",
+			RestartCount:         2,
+			LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Reason: "Error"}},
+		},
+	}
+	p.Status.InitContainerStatuses = []corev1.ContainerStatus{{
+		Name:         "proxy",
+		RestartCount: 1,
+		State:        corev1.ContainerState{Terminated: oomKilled(10 * time.Minute)},
+	}}
+
+	cm := buildClusterMetric(
+		&corev1.PodList{Items: []corev1.Pod{*p}}, nil,
+		&corev1.NodeList{Items: []corev1.Node{*node("mynode", nil, false)}}, nil)
+	pm := cm.nodeMetrics["mynode"].podMetrics["default/api"]
+
+	assert.Equal(t, "8", restartsString(pm.restarts))
+	assert.Equal(t, "10m", oomKillString(pm.lastOOMKill, observedAt))
+
+	assert.Equal(t, "2h", oomKillString(pm.containerMetrics["app"].lastOOMKill, observedAt))
+	assert.Equal(t, "2026-03-01T10:00:00Z", oomKillTimestamp(pm.containerMetrics["app"].lastOOMKill))
+	assert.Equal(t, int32(2), pm.containerMetrics["worker"].restarts)
+	assert.Nil(t, pm.containerMetrics["worker"].lastOOMKill)
+	assert.Equal(t, VoidValue, oomKillString(pm.containerMetrics["worker"].lastOOMKill, observedAt))
+	assert.Equal(t, int32(0), pm.containerMetrics["new"].restarts)
+	assert.Equal(t, VoidValue, oomKillTimestamp(pm.containerMetrics["new"].lastOOMKill))
+}
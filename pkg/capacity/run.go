@@ -0,0 +1,90 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"io"
+	"os"
+)
+
+// reportRun holds what a report is fetched and printed with that the flags
+// set up once: the units and number format quantities are printed in, where
+// the report is printed and messages are logged, how lists are paged, and
+// the queries utilization is read from Prometheus with. Each report builds
+// its own with newRun and passes it along, so that reports printed at the
+// same time by a program embedding kube-capacity do not share any of it.
+type reportRun struct {
+	units   displayUnits
+	numbers numberFormat
+
+	// output is where the report is printed, stdout unless it is written
+	// to --output-file or sent to --output-plugin
+	output io.Writer
+	stdout io.Writer
+	stdin  io.Reader
+
+	// logThreshold is the lowest level written to logOutput
+	logOutput    io.Writer
+	logThreshold logLevel
+
+	// chunkSize is the limit set on each list call, 0 lists everything in
+	// a single response
+	chunkSize int64
+
+	// promLabels are the labels cAdvisor series are read by, which the
+	// usage queries are built with
+	promLabels prometheusLabels
+
+	// notifiedBreaches holds the breaches notified about by the previous
+	// run of --interval, so that a threshold that stays breached is only
+	// notified about once until it clears
+	notifiedBreaches map[string]bool
+}
+
+// newRun sets up the run of a report from its options, which are expected
+// to be validated already
+func newRun(opts Options) *reportRun {
+	r := &reportRun{
+		units:            newDisplayUnits(opts.DisplayUnits),
+		numbers:          newNumberFormat(opts),
+		stdout:           os.Stdout,
+		stdin:            os.Stdin,
+		logOutput:        os.Stderr,
+		logThreshold:     newLogThreshold(opts.Verbose, opts.Quiet),
+		chunkSize:        opts.ChunkSize,
+		promLabels:       newPrometheusLabels(opts),
+		notifiedBreaches: map[string]bool{},
+	}
+	if opts.In != nil {
+		r.stdin = opts.In
+	}
+	if opts.Out != nil {
+		r.stdout = opts.Out
+	}
+	if opts.ErrOut != nil {
+		r.logOutput = opts.ErrOut
+	}
+	r.output = r.openOutput(opts)
+	return r
+}
+
+// withOutput returns a copy of the run that prints to the output of opts,
+// for a report that is printed again, to an --also-output file or on each
+// run of --interval
+func (r *reportRun) withOutput(opts Options) *reportRun {
+	c := *r
+	c.output = c.openOutput(opts)
+	return &c
+}
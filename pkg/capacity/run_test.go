@@ -0,0 +1,65 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestRun returns the run of a report with the defaults of the flags,
+// which prints to out, or to stdout when out is nil
+func newTestRun(out io.Writer) *reportRun {
+	return newRun(Options{Precision: DefaultPrecision, ChunkSize: DefaultChunkSize, Out: out})
+}
+
+func TestNewRunStreams(t *testing.T) {
+	var out, errOut bytes.Buffer
+	run := newRun(Options{Out: &out, ErrOut: &errOut})
+	assert.Equal(t, os.Stdin, run.stdin, "streams that are not set stay stdin, stdout, and stderr")
+
+	_, _ = fmt.Fprintln(run.output, "report")
+	run.logWarnf("warning")
+	assert.Equal(t, "report\n", out.String())
+	assert.Contains(t, errOut.String(), "warning")
+
+	path := filepath.Join(t.TempDir(), "report.csv")
+	fileRun := run.withOutput(Options{OutputFile: path})
+	_, _ = fmt.Fprintln(fileRun.output, "a,b")
+	require.NoError(t, fileRun.closeOutput())
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "a,b\n", string(data))
+	assert.Same(t, &out, run.output, "the run the output file was opened from still prints to its stream")
+}
+
+func TestRunsDoNotShareUnits(t *testing.T) {
+	cm := getTestClusterMetric()
+	millicores := newTestRun(nil)
+	cores := newRun(Options{Precision: DefaultPrecision, DisplayUnits: []string{"cores"}})
+
+	// Setting up another report does not change how the first is printed
+	lp := listPrinter{run: millicores, cm: &cm}
+	assert.Equal(t, "650m", lp.buildListClusterMetrics().ClusterTotals.CPU.Requests)
+	lp.run = cores
+	assert.Equal(t, "0.65", lp.buildListClusterMetrics().ClusterTotals.CPU.Requests)
+}
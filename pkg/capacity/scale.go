@@ -0,0 +1,369 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/robscott/kube-capacity/pkg/kube"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+	resourcehelper "k8s.io/kubectl/pkg/util/resource"
+)
+
+// scaleKinds maps the kinds, and their plural and short names, that
+// simulate scale accepts to the kind they name
+var scaleKinds = map[string]string{
+	"deployment":   "deployment",
+	"deployments":  "deployment",
+	"deploy":       "deployment",
+	"statefulset":  "statefulset",
+	"statefulsets": "statefulset",
+	"sts":          "statefulset",
+	"replicaset":   "replicaset",
+	"replicasets":  "replicaset",
+	"rs":           "replicaset",
+}
+
+// scaleWorkload is a workload in the cluster and the replicas it runs
+type scaleWorkload struct {
+	kind      string
+	name      string
+	namespace string
+	replicas  int64
+	selector  labels.Selector
+	template  corev1.PodSpec
+}
+
+type listScaleSimulation struct {
+	Workload       string            `json:"workload"`
+	Namespace      string            `json:"namespace"`
+	Replicas       int64             `json:"replicas"`
+	TargetReplicas int64             `json:"targetReplicas"`
+	Additional     int64             `json:"additional"`
+	Fit            int64             `json:"fit"`
+	Fits           bool              `json:"fits"`
+	CPU            string            `json:"cpu"`
+	Memory         string            `json:"memory"`
+	Current        *listWhatIfTotals `json:"current"`
+	AfterScaling   *listWhatIfTotals `json:"afterScaling"`
+	Nodes          []*listScaleNode  `json:"nodes"`
+}
+
+type listScaleNode struct {
+	Name            string `json:"name"`
+	Replicas        int64  `json:"replicas"`
+	CPUAvailable    string `json:"cpuAvailable"`
+	MemoryAvailable string `json:"memoryAvailable"`
+}
+
+// ValidateScaleTarget returns an error for a simulate scale target that is
+// not KIND/NAME of a Deployment, StatefulSet, or ReplicaSet
+func ValidateScaleTarget(target string) error {
+	_, _, err := parseScaleTarget(target)
+	return err
+}
+
+func parseScaleTarget(target string) (string, string, error) {
+	kind, name, ok := strings.Cut(target, "/")
+	if !ok || name == "" || strings.Contains(name, "/") {
+		return "", "", fmt.Errorf("%q is not KIND/NAME, like deployment/web", target)
+	}
+	if _, ok := scaleKinds[strings.ToLower(kind)]; !ok {
+		return "", "", fmt.Errorf("unsupported kind %s, must be a deployment, statefulset, or replicaset", kind)
+	}
+	return scaleKinds[strings.ToLower(kind)], name, nil
+}
+
+// FetchAndPrintScaleSimulation simulates scaling opts.ScaleWorkload to
+// opts.ScaleReplicas and prints whether the additional replicas fit on the
+// nodes of the cluster and how its requests would change
+func FetchAndPrintScaleSimulation(opts Options) error {
+	run := newRun(opts)
+
+	namespace := opts.Namespace
+	if namespace == "" {
+		var err error
+		namespace, err = kube.GetNamespace(opts.KubeContext, opts.KubeConfig)
+		if err != nil {
+			return fmt.Errorf("Error reading namespace from kubeconfig: %v", err)
+		}
+	}
+
+	clientset, err := newClientSet(run, opts)
+	if err != nil {
+		return fmt.Errorf("Error connecting to Kubernetes: %v", err)
+	}
+	sw, err := getScaleWorkload(clientset, namespace, opts.ScaleWorkload)
+	if err != nil {
+		return fmt.Errorf("Error: %v", err)
+	}
+
+	// Every pod counts against a node's capacity, so only node filters apply
+	opts.Namespace = ""
+	opts.NamespaceLabels = ""
+	opts.PodLabels = ""
+	opts.PodFieldSelector = ""
+	opts.ShowQuotas = false
+	opts.ShowVPA = false
+	opts.ShowHeadroom = false
+	opts.ShowKarpenter = false
+	opts.ShowCapacityType = false
+	opts.ShowArch = false
+	opts.ShowZoneBalance = false
+	opts.ShowGPU = false
+	opts.SimulateRemoveNodes = ""
+	opts.ShowHPAHeadroom = false
+	opts.AllocateByPodLabel = ""
+	opts.ShowPending = false
+	opts.ShowDaemonSetOverhead = false
+	opts.ShowAnomalies = false
+	opts.ShowStuckPods = false
+	opts.Preemption = ""
+	opts.ShowMissingResources = false
+	opts.Stream = false
+	cm, ferr := fetchClusterMetric(run, opts)
+	if ferr != nil {
+		return ferr
+	}
+
+	sp := newScalePrinter(run, &cm, sw, opts.ScaleReplicas, opts)
+	if err := sp.Print(opts.OutputFormat); err != nil {
+		return err
+	}
+	return run.closeOutput()
+}
+
+// getScaleWorkload returns the Deployment, StatefulSet, or ReplicaSet named
+// by a KIND/NAME target
+func getScaleWorkload(clientset kubernetes.Interface, namespace, target string) (*scaleWorkload, error) {
+	kind, name, err := parseScaleTarget(target)
+	if err != nil {
+		return nil, err
+	}
+
+	var replicas *int32
+	var selector *metav1.LabelSelector
+	var spec corev1.PodSpec
+	switch kind {
+	case "deployment":
+		d, err := clientset.AppsV1().Deployments(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		replicas, selector, spec = d.Spec.Replicas, d.Spec.Selector, d.Spec.Template.Spec
+	case "statefulset":
+		s, err := clientset.AppsV1().StatefulSets(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		replicas, selector, spec = s.Spec.Replicas, s.Spec.Selector, s.Spec.Template.Spec
+	case "replicaset":
+		r, err := clientset.AppsV1().ReplicaSets(namespace).Get(context.TODO(), name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		replicas, selector, spec = r.Spec.Replicas, r.Spec.Selector, r.Spec.Template.Spec
+	}
+
+	sw := &scaleWorkload{kind: kind, name: name, namespace: namespace, replicas: 1, selector: labels.Nothing(), template: spec}
+	if replicas != nil {
+		sw.replicas = int64(*replicas)
+	}
+	if selector != nil {
+		if sw.selector, err = metav1.LabelSelectorAsSelector(selector); err != nil {
+			return nil, fmt.Errorf("%s/%s has an invalid selector: %w", kind, name, err)
+		}
+	}
+	return sw, nil
+}
+
+type scalePrinter struct {
+	run      *reportRun
+	cm       *clusterMetric
+	workload *scaleWorkload
+	target   int64
+	// cpu and memory are the requests of each replica, and its average
+	// utilization across the pods the workload runs now
+	cpu    *resourceMetric
+	memory *resourceMetric
+	nodes  []*fitNode
+	fit    int64
+	opts   Options
+}
+
+// newScalePrinter places the replicas the workload would add, first-fit on
+// the nodes by name as in fit
+func newScalePrinter(run *reportRun, cm *clusterMetric, sw *scaleWorkload, target int64, opts Options) *scalePrinter {
+	req, _ := resourcehelper.PodRequestsAndLimits(&corev1.Pod{Spec: sw.template})
+	sp := &scalePrinter{
+		run:      run,
+		cm:       cm,
+		workload: sw,
+		target:   target,
+		cpu:      &resourceMetric{resourceType: "cpu", request: req["cpu"]},
+		memory:   &resourceMetric{resourceType: "memory", request: req["memory"]},
+		opts:     opts,
+	}
+
+	var pods int64
+	cpuUsage := resource.Quantity{}
+	memoryUsage := resource.Quantity{}
+	for _, nm := range cm.nodeMetrics {
+		for _, pm := range nm.podMetrics {
+			if pm.namespace == sw.namespace && sw.selector.Matches(labels.Set(pm.labels)) {
+				pods++
+				cpuUsage.Add(pm.cpu.utilization)
+				memoryUsage.Add(pm.memory.utilization)
+			}
+		}
+	}
+	if pods > 0 {
+		sp.cpu.utilization = *resource.NewMilliQuantity(cpuUsage.MilliValue()/pods, resource.DecimalSI)
+		sp.memory.utilization = *resource.NewQuantity(memoryUsage.Value()/pods, resource.BinarySI)
+	}
+
+	w := &fitWorkload{
+		name:                  sw.kind + "/" + sw.name,
+		cpu:                   sp.cpu.request,
+		memory:                sp.memory.request,
+		replicas:              target - sw.replicas,
+		schedulingConstraints: newSchedulingConstraints(sw.template),
+	}
+	nodes, fit := placeWorkloads(cm, []*fitWorkload{w})
+	sp.nodes, sp.fit = nodes, fit[w.name]
+	return sp
+}
+
+// multiplyQuantity returns q multiplied by n, which may be negative
+func multiplyQuantity(resourceType string, q resource.Quantity, n int64) resource.Quantity {
+	if resourceType == "cpu" {
+		return *resource.NewMilliQuantity(q.MilliValue()*n, resource.DecimalSI)
+	}
+	return *resource.NewQuantity(q.Value()*n, resource.BinarySI)
+}
+
+func (sp *scalePrinter) Print(outputType string) error {
+	switch outputType {
+	case JSONOutput, YAMLOutput:
+		printListOutput(sp.run, ScaleSimulationReportKind, sp.buildListScaleSimulation(), outputType)
+	case TableOutput:
+		w := tabwriter.NewWriter(sp.run.output, 0, 8, 2, ' ', 0)
+		sp.printTable(w, "\t ")
+		if err := w.Flush(); err != nil {
+			sp.run.logErrorf("Error writing to table: %s", err)
+		}
+	case CSVOutput:
+		sp.printTable(sp.run.output, ",")
+	case TSVOutput:
+		sp.printTable(sp.run.output, "\t")
+	default:
+		return fmt.Errorf("Called with an unsupported output type: %s", outputType)
+	}
+	return nil
+}
+
+// printTable prints whether the scale-up fits, the cluster before and after
+// scaling, and the nodes the additional replicas would be placed on
+func (sp *scalePrinter) printTable(w io.Writer, separator string) {
+	list := sp.buildListScaleSimulation()
+
+	scale := "fails"
+	if list.Fits {
+		scale = "fits"
+	}
+	_, _ = fmt.Fprintln(w, strings.Join([]string{
+		"WORKLOAD", "NAMESPACE", "REPLICAS", "TARGET", "ADDITIONAL", "FIT", "CPU REQUESTS", "MEMORY REQUESTS", "SCALE",
+	}, separator))
+	_, _ = fmt.Fprintln(w, strings.Join([]string{
+		list.Workload,
+		list.Namespace,
+		fmt.Sprintf("%d", list.Replicas),
+		fmt.Sprintf("%d", list.TargetReplicas),
+		fmt.Sprintf("%d", list.Additional),
+		fmt.Sprintf("%d", list.Fit),
+		list.CPU,
+		list.Memory,
+		scale,
+	}, separator))
+
+	_, _ = fmt.Fprintln(w)
+	printWhatIfTotals(w, separator, sp.opts, []string{"current", "after scaling"}, list.Current, list.AfterScaling)
+
+	if len(list.Nodes) == 0 {
+		return
+	}
+	_, _ = fmt.Fprintln(w)
+	_, _ = fmt.Fprintln(w, strings.Join([]string{"NODE", "REPLICAS", "CPU AVAILABLE", "MEMORY AVAILABLE"}, separator))
+	for _, ln := range list.Nodes {
+		_, _ = fmt.Fprintln(w, strings.Join([]string{
+			ln.Name, fmt.Sprintf("%d", ln.Replicas), ln.CPUAvailable, ln.MemoryAvailable,
+		}, separator))
+	}
+}
+
+// buildListScaleSimulation compares the cluster before and after scaling.
+// Only the replicas that fit add their requests, and the average
+// utilization of the pods the workload runs now, to the cluster; scaling
+// down takes away those of the removed replicas.
+func (sp *scalePrinter) buildListScaleSimulation() *listScaleSimulation {
+	sw := sp.workload
+	out := &listScaleSimulation{
+		Workload:       sw.kind + "/" + sw.name,
+		Namespace:      sw.namespace,
+		Replicas:       sw.replicas,
+		TargetReplicas: sp.target,
+		Additional:     sp.target - sw.replicas,
+		Fit:            sp.fit,
+		Fits:           sp.fit >= sp.target-sw.replicas,
+		CPU:            sp.cpu.valueFunction(sp.run)(sp.cpu.request),
+		Memory:         sp.memory.valueFunction(sp.run)(sp.memory.request),
+		Current:        newListWhatIfTotals(sp.run, int64(len(sp.cm.nodeMetrics)), sp.cm.cpu, sp.cm.memory, sp.opts),
+		Nodes:          []*listScaleNode{},
+	}
+
+	added := sp.fit
+	if out.Additional < 0 {
+		added = out.Additional
+	}
+	cpu := &resourceMetric{resourceType: "cpu"}
+	memory := &resourceMetric{resourceType: "memory"}
+	cpu.addMetric(sp.cm.cpu)
+	memory.addMetric(sp.cm.memory)
+	for _, r := range []struct{ after, replica *resourceMetric }{{cpu, sp.cpu}, {memory, sp.memory}} {
+		r.after.request.Add(multiplyQuantity(r.after.resourceType, r.replica.request, added))
+		r.after.utilization.Add(multiplyQuantity(r.after.resourceType, r.replica.utilization, added))
+	}
+	out.AfterScaling = newListWhatIfTotals(sp.run, int64(len(sp.cm.nodeMetrics)), cpu, memory, sp.opts)
+
+	for _, fn := range sp.nodes {
+		if n := fn.placements[out.Workload]; n > 0 {
+			out.Nodes = append(out.Nodes, &listScaleNode{
+				Name:            fn.nm.name,
+				Replicas:        n,
+				CPUAvailable:    cpu.valueFunction(sp.run)(fn.cpu),
+				MemoryAvailable: memory.valueFunction(sp.run)(fn.memory),
+			})
+		}
+	}
+	return out
+}
@@ -0,0 +1,131 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func scaleTestWorkload() *scaleWorkload {
+	return &scaleWorkload{
+		kind:      "deployment",
+		name:      "web",
+		namespace: "default",
+		replicas:  3,
+		selector:  labels.SelectorFromSet(labels.Set{"app": "web"}),
+		template: corev1.PodSpec{Containers: []corev1.Container{{
+			Name: "app",
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{"cpu": resource.MustParse("600m")},
+			},
+		}}},
+	}
+}
+
+func TestValidateScaleTarget(t *testing.T) {
+	assert.NoError(t, ValidateScaleTarget("deployment/web"))
+	assert.NoError(t, ValidateScaleTarget("sts/db"))
+	assert.NoError(t, ValidateScaleTarget("ReplicaSet/web-7d4b9c"))
+	assert.Error(t, ValidateScaleTarget("web"))
+	assert.Error(t, ValidateScaleTarget("deployment/"))
+	assert.Error(t, ValidateScaleTarget("job/backup"))
+}
+
+func TestGetScaleWorkload(t *testing.T) {
+	replicas := int32(3)
+	clientset := fake.NewSimpleClientset(&appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "web"},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+		},
+	})
+
+	sw, err := getScaleWorkload(clientset, "default", "deploy/web")
+	require.NoError(t, err)
+	assert.Equal(t, "deployment", sw.kind)
+	assert.Equal(t, int64(3), sw.replicas)
+	assert.Equal(t, "app=web", sw.selector.String())
+
+	_, err = getScaleWorkload(clientset, "other", "deployment/web")
+	assert.Error(t, err)
+}
+
+func TestScaleSimulation(t *testing.T) {
+	run := newTestRun(nil)
+	cm := drainTestClusterMetric(1)
+
+	// a has 2100m left for three replicas and b 700m for one, so four of
+	// the five additional replicas fit
+	sp := newScalePrinter(run, cm, scaleTestWorkload(), 8, Options{})
+	list := sp.buildListScaleSimulation()
+	assert.False(t, list.Fits)
+	assert.Equal(t, int64(4), list.Fit)
+
+	var buf bytes.Buffer
+	sp.printTable(&buf, ",")
+	assert.Equal(t, strings.Join([]string{
+		"WORKLOAD,NAMESPACE,REPLICAS,TARGET,ADDITIONAL,FIT,CPU REQUESTS,MEMORY REQUESTS,SCALE",
+		"deployment/web,default,3,8,5,4,600m,0Mi,fails",
+		"",
+		"CLUSTER,NODES,CPU ALLOCATABLE,CPU REQUESTS,MEMORY ALLOCATABLE,MEMORY REQUESTS",
+		"current,3,6000m,3100m (51%),12000Mi,0Mi (0%)",
+		"after scaling,3,6000m,5500m (91%),12000Mi,0Mi (0%)",
+		"",
+		"NODE,REPLICAS,CPU AVAILABLE,MEMORY AVAILABLE",
+		"a,3,300m,4000Mi",
+		"b,1,100m,4000Mi",
+	}, "\n")+"\n", buf.String())
+
+	sp = newScalePrinter(run, drainTestClusterMetric(1), scaleTestWorkload(), 7, Options{})
+	assert.True(t, sp.buildListScaleSimulation().Fits)
+}
+
+func TestScaleSimulationScaleDown(t *testing.T) {
+	run := newTestRun(nil)
+	sp := newScalePrinter(run, drainTestClusterMetric(1), scaleTestWorkload(), 1, Options{})
+	list := sp.buildListScaleSimulation()
+	assert.True(t, list.Fits)
+	assert.Equal(t, int64(-2), list.Additional)
+	assert.Equal(t, "1900m", list.AfterScaling.CPU.Requests)
+	assert.Empty(t, list.Nodes)
+}
+
+func TestScaleSimulationUtilization(t *testing.T) {
+	run := newTestRun(nil)
+	cm := drainTestClusterMetric(1)
+	for _, name := range []string{"default/web-1", "default/web-2", "default/web-3"} {
+		cm.nodeMetrics["a"].podMetrics[name].cpu.utilization = resource.MustParse("300m")
+	}
+	cm.cpu.utilization = resource.MustParse("900m")
+
+	// The two additional replicas are assumed to use the 300m each current
+	// replica does
+	sp := newScalePrinter(run, cm, scaleTestWorkload(), 5, Options{ShowUtil: true})
+	list := sp.buildListScaleSimulation()
+	assert.Equal(t, "900m", list.Current.CPU.Utilization)
+	assert.Equal(t, "1500m", list.AfterScaling.CPU.Utilization)
+}
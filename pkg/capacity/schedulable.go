@@ -0,0 +1,160 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"fmt"
+	"os"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+)
+
+// schedulingConstraints are the parts of a pod spec that restrict which
+// nodes the pod can be scheduled on
+type schedulingConstraints struct {
+	nodeSelector map[string]string
+	nodeAffinity *corev1.NodeSelector
+	tolerations  []corev1.Toleration
+}
+
+func newSchedulingConstraints(spec corev1.PodSpec) schedulingConstraints {
+	sc := schedulingConstraints{
+		nodeSelector: spec.NodeSelector,
+		tolerations:  spec.Tolerations,
+	}
+	if spec.Affinity != nil && spec.Affinity.NodeAffinity != nil {
+		sc.nodeAffinity = spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+	}
+	return sc
+}
+
+// getSchedulingConstraints reads the constraints of the single Pod or
+// workload in a manifest
+func getSchedulingConstraints(run *reportRun, path string) (*schedulingConstraints, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	workloads, err := parseFitWorkloads(run, f)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	if len(workloads) > 1 {
+		return nil, fmt.Errorf("reading %s: expected a single workload, found %d", path, len(workloads))
+	}
+	return &workloads[0].schedulingConstraints, nil
+}
+
+// allows returns whether the pod could be scheduled on the node, ignoring
+// resources. Cordoned nodes, node selectors, required node affinity, and
+// NoSchedule and NoExecute taints are taken into account.
+func (sc *schedulingConstraints) allows(name string, nodeLabels map[string]string, taints []corev1.Taint, unschedulable bool) bool {
+	if unschedulable {
+		return false
+	}
+	for key, value := range sc.nodeSelector {
+		if nodeLabels[key] != value {
+			return false
+		}
+	}
+	if sc.nodeAffinity != nil && !nodeSelectorMatches(sc.nodeAffinity, name, nodeLabels) {
+		return false
+	}
+	for i := range taints {
+		taint := &taints[i]
+		if taint.Effect == corev1.TaintEffectPreferNoSchedule {
+			continue
+		}
+		tolerated := false
+		for _, toleration := range sc.tolerations {
+			if toleration.ToleratesTaint(taint) {
+				tolerated = true
+				break
+			}
+		}
+		if !tolerated {
+			return false
+		}
+	}
+	return true
+}
+
+// nodeSelectorMatches returns whether any of the terms matches the node.
+// Terms without requirements match nothing, as in the scheduler.
+func nodeSelectorMatches(ns *corev1.NodeSelector, name string, nodeLabels map[string]string) bool {
+	for _, term := range ns.NodeSelectorTerms {
+		if len(term.MatchExpressions) == 0 && len(term.MatchFields) == 0 {
+			continue
+		}
+		if requirementsMatch(term.MatchExpressions, labels.Set(nodeLabels)) &&
+			requirementsMatch(term.MatchFields, labels.Set{"metadata.name": name}) {
+			return true
+		}
+	}
+	return false
+}
+
+func requirementsMatch(requirements []corev1.NodeSelectorRequirement, set labels.Set) bool {
+	for _, r := range requirements {
+		var op selection.Operator
+		switch r.Operator {
+		case corev1.NodeSelectorOpIn:
+			op = selection.In
+		case corev1.NodeSelectorOpNotIn:
+			op = selection.NotIn
+		case corev1.NodeSelectorOpExists:
+			op = selection.Exists
+		case corev1.NodeSelectorOpDoesNotExist:
+			op = selection.DoesNotExist
+		case corev1.NodeSelectorOpGt:
+			op = selection.GreaterThan
+		case corev1.NodeSelectorOpLt:
+			op = selection.LessThan
+		default:
+			return false
+		}
+		req, err := labels.NewRequirement(r.Key, op, r.Values)
+		if err != nil || !req.Matches(set) {
+			return false
+		}
+	}
+	return true
+}
+
+// filterSchedulableNodes removes the nodes the pod could not be scheduled
+// on, along with the pods running on them
+func filterSchedulableNodes(podList *corev1.PodList, nodeList *corev1.NodeList, sc *schedulingConstraints) {
+	nodes := map[string]bool{}
+	newNodeItems := []corev1.Node{}
+	for _, node := range nodeList.Items {
+		if sc.allows(node.Name, node.Labels, node.Spec.Taints, node.Spec.Unschedulable) {
+			nodes[node.Name] = true
+			newNodeItems = append(newNodeItems, node)
+		}
+	}
+	nodeList.Items = newNodeItems
+
+	newPodItems := []corev1.Pod{}
+	for _, pod := range podList.Items {
+		if nodes[pod.Spec.NodeName] {
+			newPodItems = append(newPodItems, pod)
+		}
+	}
+	podList.Items = newPodItems
+}
@@ -0,0 +1,112 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const schedulableManifest = `
+apiVersion: v1
+kind: Pod
+metadata:
+  name: gpu-job
+spec:
+  affinity:
+    nodeAffinity:
+      requiredDuringSchedulingIgnoredDuringExecution:
+        nodeSelectorTerms:
+        - matchExpressions:
+          - key: zone
+            operator: In
+            values: ["a", "b"]
+          - key: gpus
+            operator: Gt
+            values: ["1"]
+        - matchFields:
+          - key: metadata.name
+            operator: In
+            values: ["special"]
+  tolerations:
+  - key: gpu
+    operator: Equal
+    value: "true"
+    effect: NoSchedule
+  containers:
+  - name: train
+`
+
+func TestGetSchedulingConstraints(t *testing.T) {
+	run := newTestRun(nil)
+	path := filepath.Join(t.TempDir(), "pod.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(schedulableManifest), 0o600))
+
+	sc, err := getSchedulingConstraints(run, path)
+	require.NoError(t, err)
+	require.NotNil(t, sc.nodeAffinity)
+	assert.Len(t, sc.nodeAffinity.NodeSelectorTerms, 2)
+	assert.Len(t, sc.tolerations, 1)
+
+	require.NoError(t, os.WriteFile(path, []byte(fitManifest), 0o600))
+	_, err = getSchedulingConstraints(run, path)
+	assert.EqualError(t, err, "reading "+path+": expected a single workload, found 3")
+}
+
+func TestFilterSchedulableNodes(t *testing.T) {
+	run := newTestRun(nil)
+	path := filepath.Join(t.TempDir(), "pod.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(schedulableManifest), 0o600))
+	sc, err := getSchedulingConstraints(run, path)
+	require.NoError(t, err)
+
+	gpuTaint := []corev1.Taint{{Key: "gpu", Value: "true", Effect: corev1.TaintEffectNoSchedule}}
+	nodeList := &corev1.NodeList{Items: []corev1.Node{
+		*node("zone-a-gpu", map[string]string{"zone": "a", "gpus": "4"}, false),
+		*node("zone-a-one-gpu", map[string]string{"zone": "a", "gpus": "1"}, false),
+		*node("zone-c-gpu", map[string]string{"zone": "c", "gpus": "4"}, false),
+		*node("special", map[string]string{}, false),
+		*node("tainted", map[string]string{"zone": "b", "gpus": "8"}, true),
+		*node("gpu-tainted", map[string]string{"zone": "b", "gpus": "8"}, false),
+		*node("cordoned", map[string]string{"zone": "b", "gpus": "8"}, false),
+	}}
+	nodeList.Items[5].Spec.Taints = gpuTaint
+	nodeList.Items[6].Spec.Unschedulable = true
+
+	podList := &corev1.PodList{Items: []corev1.Pod{
+		*pod("zone-a-gpu", "default", "kept", map[string]string{}),
+		*pod("zone-c-gpu", "default", "dropped", map[string]string{}),
+	}}
+
+	filterSchedulableNodes(podList, nodeList, sc)
+
+	names := []string{}
+	for _, n := range nodeList.Items {
+		names = append(names, n.Name)
+	}
+	assert.Equal(t, []string{"zone-a-gpu", "special", "gpu-tainted"}, names)
+	require.Len(t, podList.Items, 1)
+	assert.Equal(t, "kept", podList.Items[0].Name)
+}
+
+func TestNodeSelectorMatchesEmptyTerm(t *testing.T) {
+	ns := &corev1.NodeSelector{NodeSelectorTerms: []corev1.NodeSelectorTerm{{}}}
+	assert.False(t, nodeSelectorMatches(ns, "mynode", map[string]string{"a": "b"}))
+}
@@ -0,0 +1,339 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// DefaultSeriesWindow is how far back the series subcommand reads usage
+	DefaultSeriesWindow = "24h"
+	// DefaultSeriesStep is the interval between points of a series
+	DefaultSeriesStep = "1h"
+
+	// maxSeriesPoints is the most points Prometheus returns for each series
+	// of a range query
+	maxSeriesPoints = 11000
+)
+
+// Groupings of the series subcommand
+const (
+	SeriesByNode      = "node"
+	SeriesByNamespace = "namespace"
+)
+
+// SupportedSeriesGroupings returns the values --by supports
+func SupportedSeriesGroupings() []string {
+	return []string{SeriesByNode, SeriesByNamespace}
+}
+
+// ValidateSeries checks the window, step, and grouping of the series
+// subcommand
+func ValidateSeries(opts Options) error {
+	window, err := parsePromQLDuration(opts.SeriesWindow)
+	if err != nil || window <= 0 {
+		return fmt.Errorf("--window must be a duration greater than 0, such as 24h")
+	}
+	step, err := parsePromQLDuration(opts.SeriesStep)
+	if err != nil || step < time.Second {
+		return fmt.Errorf("--step must be a duration of at least 1s, such as 1h")
+	}
+	if step > window {
+		return fmt.Errorf("--step can not be longer than --window")
+	}
+	if window/step+1 > maxSeriesPoints {
+		return fmt.Errorf("--window of %s with a --step of %s has more than %d points, use a longer --step", opts.SeriesWindow, opts.SeriesStep, maxSeriesPoints)
+	}
+	for _, by := range SupportedSeriesGroupings() {
+		if opts.SeriesBy == by {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported --by %q, expected one of %v", opts.SeriesBy, SupportedSeriesGroupings())
+}
+
+// usageSeries is the CPU cores and memory bytes of each node or namespace at
+// every step of the window, NaN where Prometheus has no usage
+type usageSeries struct {
+	start  time.Time
+	step   time.Duration
+	count  int
+	names  []string
+	cpu    map[string][]float64
+	memory map[string][]float64
+}
+
+// FetchAndPrintSeries reads the usage of nodes or namespaces at every step
+// of a window from Prometheus and prints it
+func FetchAndPrintSeries(opts Options) error {
+	run := newRun(opts)
+
+	clientset, err := newClientSet(run, opts)
+	if err != nil {
+		return fmt.Errorf("Error connecting to Kubernetes: %v", err)
+	}
+
+	keep, err := seriesFilter(context.TODO(), run, clientset, opts)
+	if err != nil {
+		return fmt.Errorf("Error listing nodes: %v", err)
+	}
+
+	series, err := getUsageSeries(run, clientset, opts, time.Now(), keep)
+	if err != nil {
+		return newFetchError(4, "Error getting usage from Prometheus: %v", err)
+	}
+
+	sp := &seriesPrinter{run: run, series: series, opts: opts}
+	if err := sp.Print(opts.OutputFormat); err != nil {
+		return err
+	}
+	return run.closeOutput()
+}
+
+// seriesFilter returns whether the series of a node or namespace is kept,
+// matching nodes against --node-name and --node-labels and namespaces
+// against --namespace. Nodes are only listed for --node-labels, so nodes
+// that are gone are kept otherwise.
+func seriesFilter(ctx context.Context, run *reportRun, clientset kubernetes.Interface, opts Options) (func(name string) bool, error) {
+	if opts.SeriesBy == SeriesByNamespace {
+		return func(name string) bool {
+			return opts.Namespace == "" || name == opts.Namespace
+		}, nil
+	}
+
+	matchesName := func(string) bool { return true }
+	if opts.NodeName != "" {
+		// The pattern was validated along with the other flags
+		matchesName, _ = parseNodeNamePattern(opts.NodeName)
+	}
+	if opts.NodeLabels == "" {
+		return matchesName, nil
+	}
+
+	nodeList, err := listAllNodes(ctx, run, clientset, metav1.ListOptions{LabelSelector: opts.NodeLabels})
+	if err != nil {
+		return nil, err
+	}
+	labeled := map[string]bool{}
+	for _, node := range nodeList.Items {
+		labeled[node.Name] = true
+	}
+	return func(name string) bool {
+		return labeled[name] && matchesName(name)
+	}, nil
+}
+
+func getUsageSeries(run *reportRun, clientset kubernetes.Interface, opts Options, now time.Time, keep func(name string) bool) (*usageSeries, error) {
+	window, _ := parsePromQLDuration(opts.SeriesWindow)
+	step, _ := parsePromQLDuration(opts.SeriesStep)
+
+	endpoint, err := resolvePrometheusEndpoint(run, clientset, opts)
+	if err != nil {
+		return nil, err
+	}
+	promHTTP, err := newPrometheusHTTP(context.TODO(), run, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Points fall on whole steps, such as on the hour with a step of 1h
+	end := now.Truncate(step)
+	count := int(window/step) + 1
+	start := end.Add(-step * time.Duration(count-1))
+
+	nodeCPU, nodeMemory := run.promLabels.nodeUsage()
+	cpuQuery := `sum by (node) (` + nodeCPU + `)`
+	memoryQuery := `sum by (node) (` + nodeMemory + `)`
+	if opts.SeriesBy == SeriesByNamespace {
+		containerCPU, containerMemory := run.promLabels.containerUsage("")
+		cpuQuery = `sum by (namespace) (` + containerCPU + `)`
+		memoryQuery = `sum by (namespace) (` + containerMemory + `)`
+	}
+	key := func(metric map[string]string) string {
+		name := metric[opts.SeriesBy]
+		if name == "" || !keep(name) {
+			return ""
+		}
+		return name
+	}
+
+	nodeNames, err := getPrometheusNodeNames(run, clientset, endpoint, promHTTP)
+	if err != nil {
+		return nil, err
+	}
+
+	us := &usageSeries{start: start, step: step, count: count}
+	for _, q := range []struct {
+		name  string
+		query string
+		dest  *map[string][]float64
+	}{
+		{opts.SeriesBy + " CPU", cpuQuery, &us.cpu},
+		{opts.SeriesBy + " memory", memoryQuery, &us.memory},
+	} {
+		resp, err := queryPrometheusRange(run, clientset, endpoint, promHTTP, q.query, start, end, step)
+		if err != nil {
+			return nil, fmt.Errorf("querying %s usage: %w", q.name, err)
+		}
+		nodeNames.resolve(resp)
+		*q.dest = bucketRangeResult(resp, key, start, step, count)
+	}
+	us.addTotals()
+	return us, nil
+}
+
+// addTotals sorts the names of the series and adds the sum of them all
+// first, as the cluster total
+func (us *usageSeries) addTotals() {
+	names := map[string]bool{}
+	for name := range us.cpu {
+		names[name] = true
+	}
+	for name := range us.memory {
+		names[name] = true
+	}
+	us.names = []string{}
+	for name := range names {
+		us.names = append(us.names, name)
+	}
+	sort.Strings(us.names)
+
+	for _, values := range []map[string][]float64{us.cpu, us.memory} {
+		total := make([]float64, us.count)
+		for i := range total {
+			total[i] = math.NaN()
+		}
+		for _, points := range values {
+			addHistory(total, points)
+		}
+		values[VoidValue] = total
+	}
+	us.names = append([]string{VoidValue}, us.names...)
+}
+
+type seriesPrinter struct {
+	run    *reportRun
+	series *usageSeries
+	opts   Options
+}
+
+type listSeries struct {
+	Window string            `json:"window"`
+	Step   string            `json:"step"`
+	By     string            `json:"by"`
+	Series []*listSeriesItem `json:"series"`
+}
+
+type listSeriesItem struct {
+	Name   string             `json:"name"`
+	Points []*listSeriesPoint `json:"points"`
+}
+
+// listSeriesPoint has null CPU or memory where Prometheus has no usage
+type listSeriesPoint struct {
+	Time   time.Time `json:"time"`
+	CPU    *string   `json:"cpu"`
+	Memory *string   `json:"memory"`
+}
+
+func (sp *seriesPrinter) Print(outputType string) error {
+	switch outputType {
+	case JSONOutput, YAMLOutput:
+		printListOutput(sp.run, SeriesReportKind, sp.buildListSeries(), outputType)
+	case TableOutput:
+		w := tabwriter.NewWriter(sp.run.output, 0, 8, 2, ' ', 0)
+		sp.printTable(w, "\t ", sp.run.formatQuantity)
+		if err := w.Flush(); err != nil {
+			sp.run.logErrorf("Error writing to table: %s", err)
+		}
+	case CSVOutput:
+		sp.printTable(sp.run.output, ",", sp.run.resourceCSVString)
+	case TSVOutput:
+		sp.printTable(sp.run.output, "\t", sp.run.resourceCSVString)
+	default:
+		return fmt.Errorf("Called with an unsupported output type: %s", outputType)
+	}
+	return nil
+}
+
+// printTable prints a line for every step of each series, with plain
+// numbers in the units of the headers for CSV and TSV output
+func (sp *seriesPrinter) printTable(w io.Writer, separator string, format func(string, resource.Quantity) string) {
+	us := sp.series
+	headers := []string{"TIME", strings.ToUpper(sp.opts.SeriesBy), "CPU UTIL", "MEMORY UTIL"}
+	if separator != "\t " {
+		headers[2] = fmt.Sprintf("CPU UTIL (%s)", sp.run.csvUnitName("cpu"))
+		headers[3] = fmt.Sprintf("MEMORY UTIL (%s)", sp.run.csvUnitName("memory"))
+	}
+	_, _ = fmt.Fprintln(w, strings.Join(headers, separator))
+
+	for _, name := range us.names {
+		for i := 0; i < us.count; i++ {
+			items := []string{
+				us.start.Add(us.step * time.Duration(i)).UTC().Format(time.RFC3339),
+				name,
+				seriesValue("cpu", us.cpu[name], i, format),
+				seriesValue("memory", us.memory[name], i, format),
+			}
+			_, _ = fmt.Fprintln(w, strings.Join(items, separator))
+		}
+	}
+}
+
+func (sp *seriesPrinter) buildListSeries() *listSeries {
+	us := sp.series
+	out := &listSeries{
+		Window: sp.opts.SeriesWindow,
+		Step:   sp.opts.SeriesStep,
+		By:     sp.opts.SeriesBy,
+		Series: []*listSeriesItem{},
+	}
+	for _, name := range us.names {
+		item := &listSeriesItem{Name: name, Points: []*listSeriesPoint{}}
+		for i := 0; i < us.count; i++ {
+			point := &listSeriesPoint{Time: us.start.Add(us.step * time.Duration(i)).UTC()}
+			if s := seriesValue("cpu", us.cpu[name], i, sp.run.formatQuantity); s != VoidValue {
+				point.CPU = &s
+			}
+			if s := seriesValue("memory", us.memory[name], i, sp.run.formatQuantity); s != VoidValue {
+				point.Memory = &s
+			}
+			item.Points = append(item.Points, point)
+		}
+		out.Series = append(out.Series, item)
+	}
+	return out
+}
+
+// seriesValue formats a point of a series, or VoidValue when it has none
+func seriesValue(resourceType string, points []float64, i int, format func(string, resource.Quantity) string) string {
+	if points == nil || math.IsNaN(points[i]) {
+		return VoidValue
+	}
+	rm := &resourceMetric{resourceType: resourceType}
+	return format(resourceType, rm.historyQuantity(points[i]))
+}
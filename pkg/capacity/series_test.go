@@ -0,0 +1,113 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"bytes"
+	"context"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestValidateSeries(t *testing.T) {
+	assert.NoError(t, ValidateSeries(Options{SeriesWindow: "24h", SeriesStep: "1h", SeriesBy: SeriesByNode}))
+	assert.NoError(t, ValidateSeries(Options{SeriesWindow: "7d", SeriesStep: "15m", SeriesBy: SeriesByNamespace}))
+
+	for _, opts := range []Options{
+		{SeriesWindow: "0h", SeriesStep: "1h", SeriesBy: SeriesByNode},
+		{SeriesWindow: "24h", SeriesStep: "100ms", SeriesBy: SeriesByNode},
+		{SeriesWindow: "1h", SeriesStep: "2h", SeriesBy: SeriesByNode},
+		{SeriesWindow: "30d", SeriesStep: "1m", SeriesBy: SeriesByNode},
+		{SeriesWindow: "24h", SeriesStep: "1h", SeriesBy: "pod"},
+	} {
+		assert.Error(t, ValidateSeries(opts), "%+v", opts)
+	}
+}
+
+func TestSeriesFilter(t *testing.T) {
+	run := newTestRun(nil)
+	clientset := fake.NewSimpleClientset(
+		node("gpu-1", map[string]string{"pool": "gpu"}, false),
+		node("gpu-2", map[string]string{"pool": "gpu"}, false),
+		node("cpu-1", map[string]string{"pool": "general"}, false),
+	)
+
+	keep, err := seriesFilter(context.Background(), run, clientset, Options{SeriesBy: SeriesByNode, NodeName: "gpu-*"})
+	require.NoError(t, err)
+	assert.True(t, keep("gpu-9"), "nodes that are gone are kept without --node-labels")
+	assert.False(t, keep("cpu-1"))
+
+	keep, err = seriesFilter(context.Background(), run, clientset, Options{SeriesBy: SeriesByNode, NodeName: "*-1", NodeLabels: "pool=gpu"})
+	require.NoError(t, err)
+	assert.True(t, keep("gpu-1"))
+	assert.False(t, keep("gpu-2"))
+	assert.False(t, keep("cpu-1"))
+
+	keep, err = seriesFilter(context.Background(), run, clientset, Options{SeriesBy: SeriesByNamespace, Namespace: "default"})
+	require.NoError(t, err)
+	assert.True(t, keep("default"))
+	assert.False(t, keep("kube-system"))
+}
+
+func TestSeriesReport(t *testing.T) {
+	run := newTestRun(nil)
+	start := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	us := &usageSeries{
+		start: start,
+		step:  time.Hour,
+		count: 3,
+		cpu: map[string][]float64{
+			"default":     {0.5, 1, math.NaN()},
+			"kube-system": {0.25, 0.25, 0.25},
+		},
+		memory: map[string][]float64{
+			"default": {1 << 30, 2 << 30, math.NaN()},
+		},
+	}
+	us.addTotals()
+	assert.Equal(t, []string{VoidValue, "default", "kube-system"}, us.names)
+
+	sp := &seriesPrinter{run: run, series: us, opts: Options{SeriesWindow: "2h", SeriesStep: "1h", SeriesBy: SeriesByNamespace}}
+
+	var buf bytes.Buffer
+	sp.printTable(&buf, ",", run.resourceCSVString)
+	assert.Equal(t, strings.Join([]string{
+		"TIME,NAMESPACE,CPU UTIL (milli),MEMORY UTIL (Mi)",
+		"2026-03-01T00:00:00Z,*,750,1024",
+		"2026-03-01T01:00:00Z,*,1250,2048",
+		"2026-03-01T02:00:00Z,*,250,*",
+		"2026-03-01T00:00:00Z,default,500,1024",
+		"2026-03-01T01:00:00Z,default,1000,2048",
+		"2026-03-01T02:00:00Z,default,*,*",
+		"2026-03-01T00:00:00Z,kube-system,250,*",
+		"2026-03-01T01:00:00Z,kube-system,250,*",
+		"2026-03-01T02:00:00Z,kube-system,250,*",
+	}, "\n")+"\n", buf.String())
+
+	list := sp.buildListSeries()
+	require.Len(t, list.Series, 3)
+	assert.Equal(t, "namespace", list.By)
+	point := list.Series[1].Points[1]
+	assert.Equal(t, start.Add(time.Hour), point.Time)
+	assert.Equal(t, "1000m", *point.CPU)
+	assert.Equal(t, "2048Mi", *point.Memory)
+	assert.Nil(t, list.Series[1].Points[2].CPU)
+}
@@ -0,0 +1,51 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// isSidecar returns true for native sidecars, init containers that keep
+// running for the lifetime of the pod
+func isSidecar(container *corev1.Container) bool {
+	return container.RestartPolicy != nil && *container.RestartPolicy == corev1.ContainerRestartPolicyAlways
+}
+
+// sidecarContainers returns the native sidecars of a pod
+func sidecarContainers(pod *corev1.Pod) []*corev1.Container {
+	sidecars := []*corev1.Container{}
+	for i := range pod.Spec.InitContainers {
+		if isSidecar(&pod.Spec.InitContainers[i]) {
+			sidecars = append(sidecars, &pod.Spec.InitContainers[i])
+		}
+	}
+	return sidecars
+}
+
+func (cm *containerMetric) displayName() string {
+	return containerDisplayName(cm.name, cm.sidecar)
+}
+
+// containerDisplayName marks native sidecars in container level output,
+// example: "istio-proxy (sidecar)"
+func containerDisplayName(name string, sidecar bool) string {
+	if sidecar {
+		return fmt.Sprintf("%s (sidecar)", name)
+	}
+	return name
+}
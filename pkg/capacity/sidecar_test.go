@@ -0,0 +1,69 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	v1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+)
+
+func TestSidecarContainers(t *testing.T) {
+	run := newTestRun(nil)
+	always := corev1.ContainerRestartPolicyAlways
+	cpu := func(q string) corev1.ResourceRequirements {
+		return corev1.ResourceRequirements{Requests: corev1.ResourceList{"cpu": resource.MustParse(q)}}
+	}
+
+	p := pod("mynode", "default", "web", map[string]string{})
+	p.Spec.InitContainers = []corev1.Container{
+		{Name: "setup", Resources: cpu("100m")},
+		{Name: "proxy", Resources: cpu("200m"), RestartPolicy: &always},
+	}
+	p.Spec.Containers = []corev1.Container{{Name: "app", Resources: cpu("300m")}}
+
+	pmList := &v1beta1.PodMetricsList{Items: []v1beta1.PodMetrics{{
+		ObjectMeta: p.ObjectMeta,
+		Containers: []v1beta1.ContainerMetrics{
+			{Name: "app", Usage: corev1.ResourceList{"cpu": resource.MustParse("250m")}},
+			{Name: "proxy", Usage: corev1.ResourceList{"cpu": resource.MustParse("50m")}},
+		},
+	}}}
+	podList := &corev1.PodList{Items: []corev1.Pod{*p}}
+	nodeList := &corev1.NodeList{Items: []corev1.Node{*node("mynode", map[string]string{}, false)}}
+
+	cm := buildClusterMetric(podList, pmList, nodeList, nil)
+	pm := cm.nodeMetrics["mynode"].podMetrics["default/web"]
+	require.Len(t, pm.containerMetrics, 2)
+	assert.Equal(t, int64(500), pm.cpu.request.MilliValue())
+	assert.Equal(t, int64(300), pm.cpu.utilization.MilliValue())
+
+	proxy := pm.containerMetrics["proxy"]
+	assert.True(t, proxy.sidecar)
+	assert.Equal(t, "proxy (sidecar)", proxy.displayName())
+	assert.Equal(t, int64(200), proxy.cpu.request.MilliValue())
+	assert.Equal(t, "app", pm.containerMetrics["app"].displayName())
+
+	lp := &listPrinter{run: run, cm: &cm, opts: Options{ShowContainers: true}}
+	list := lp.buildListClusterMetrics()
+	assert.Equal(t, []bool{false, true}, []bool{
+		list.Nodes[0].Pods[0].Containers[0].Sidecar,
+		list.Nodes[0].Pods[0].Containers[1].Sidecar,
+	})
+}
@@ -0,0 +1,254 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// namespaceSlack is the requests of a namespace that went unused over the
+// window, summed from the slack of each of its containers
+type namespaceSlack struct {
+	name            string
+	cpu             slackMetric
+	memory          slackMetric
+	nodeEquivalents float64
+}
+
+// slackMetric sums requests, the usage percentile, and slack, which is the
+// request minus the usage of each container that used less than it requested
+type slackMetric struct {
+	request resource.Quantity
+	usage   resource.Quantity
+	slack   resource.Quantity
+}
+
+type listNamespaceSlack struct {
+	Window        string                    `json:"window"`
+	Percentile    float64                   `json:"percentile"`
+	Namespaces    []*listNamespaceSlackItem `json:"namespaces"`
+	ClusterTotals *listNamespaceSlackItem   `json:"clusterTotals"`
+}
+
+type listNamespaceSlackItem struct {
+	Name            string           `json:"name,omitempty"`
+	CPU             *listSlackMetric `json:"cpu"`
+	Memory          *listSlackMetric `json:"memory"`
+	NodeEquivalents string           `json:"nodeEquivalents"`
+}
+
+type listSlackMetric struct {
+	Requests string `json:"requests"`
+	Usage    string `json:"usage"`
+	Slack    string `json:"slack"`
+}
+
+// FetchAndPrintNamespaceSlack compares the requests of each namespace with
+// a percentile of its usage in Prometheus over a time window and prints the
+// namespaces with the most capacity to reclaim first
+func FetchAndPrintNamespaceSlack(opts Options) error {
+	run := newRun(opts)
+
+	clientset, err := newClientSet(run, opts)
+	if err != nil {
+		return fmt.Errorf("Error connecting to Kubernetes: %v", err)
+	}
+
+	podList, nodeList, ferr := getPodsAndNodes(run, clientset, newListFilters(opts))
+	if ferr != nil {
+		return ferr
+	}
+
+	pmList, err := getPrometheusUsageQuantile(run, clientset, opts)
+	if err != nil {
+		return newFetchError(4, "Error getting metrics from Prometheus: %v", err)
+	}
+
+	namespaces, total := buildNamespaceSlack(buildRecommendations(podList, pmList), nodeList)
+	sp := &slackPrinter{run: run, namespaces: namespaces, total: total, opts: opts}
+	if err := sp.Print(opts.OutputFormat); err != nil {
+		return err
+	}
+	return run.closeOutput()
+}
+
+// buildNamespaceSlack sums the slack of every container with usage history
+// by namespace, and how many average nodes of the cluster it adds up to.
+// Namespaces are ranked by node equivalents, then CPU and memory slack.
+func buildNamespaceSlack(recommendations []*containerRecommendation, nodeList *corev1.NodeList) ([]*namespaceSlack, *namespaceSlack) {
+	byName := map[string]*namespaceSlack{}
+	total := &namespaceSlack{name: VoidValue}
+	for _, r := range recommendations {
+		ns, ok := byName[r.namespace]
+		if !ok {
+			ns = &namespaceSlack{name: r.namespace}
+			byName[r.namespace] = ns
+		}
+		for _, s := range []*namespaceSlack{ns, total} {
+			s.cpu.add(r.cpu)
+			s.memory.add(r.memory)
+		}
+	}
+
+	avgCPU, avgMemory := averageNodeAllocatable(nodeList)
+	namespaces := []*namespaceSlack{}
+	for _, ns := range byName {
+		namespaces = append(namespaces, ns)
+	}
+	for _, ns := range append(namespaces, total) {
+		ns.nodeEquivalents = math.Min(
+			quantityRatio(ns.cpu.slack, avgCPU),
+			quantityRatio(ns.memory.slack, avgMemory),
+		)
+	}
+
+	sort.Slice(namespaces, func(i, j int) bool {
+		a, b := namespaces[i], namespaces[j]
+		if a.nodeEquivalents != b.nodeEquivalents {
+			return a.nodeEquivalents > b.nodeEquivalents
+		}
+		if c := a.cpu.slack.Cmp(b.cpu.slack); c != 0 {
+			return c > 0
+		}
+		if c := a.memory.slack.Cmp(b.memory.slack); c != 0 {
+			return c > 0
+		}
+		return a.name < b.name
+	})
+	return namespaces, total
+}
+
+// add sums the request and usage of a container, and its slack when it used
+// less than it requested. Containers over their request do not make up for
+// the slack of others.
+func (sm *slackMetric) add(r *recommendation) {
+	sm.request.Add(r.request)
+	sm.usage.Add(r.usage)
+	if r.request.Cmp(r.usage) > 0 {
+		slack := r.request.DeepCopy()
+		slack.Sub(r.usage)
+		sm.slack.Add(slack)
+	}
+}
+
+// averageNodeAllocatable returns the CPU and memory allocatable of the
+// average node
+func averageNodeAllocatable(nodeList *corev1.NodeList) (resource.Quantity, resource.Quantity) {
+	var cpu, memory resource.Quantity
+	if nodeList == nil || len(nodeList.Items) == 0 {
+		return cpu, memory
+	}
+	for _, node := range nodeList.Items {
+		cpu.Add(node.Status.Allocatable["cpu"])
+		memory.Add(node.Status.Allocatable["memory"])
+	}
+	count := int64(len(nodeList.Items))
+	return *resource.NewMilliQuantity(cpu.MilliValue()/count, resource.DecimalSI),
+		*resource.NewQuantity(memory.Value()/count, resource.BinarySI)
+}
+
+// quantityRatio returns a / b, or 0 when b is zero
+func quantityRatio(a, b resource.Quantity) float64 {
+	if b.IsZero() {
+		return 0
+	}
+	return float64(a.MilliValue()) / float64(b.MilliValue())
+}
+
+type slackPrinter struct {
+	run        *reportRun
+	namespaces []*namespaceSlack
+	total      *namespaceSlack
+	opts       Options
+}
+
+func (sp *slackPrinter) Print(outputType string) error {
+	switch outputType {
+	case JSONOutput, YAMLOutput:
+		printListOutput(sp.run, NamespaceSlackReportKind, sp.buildListNamespaceSlack(), outputType)
+	case TableOutput:
+		w := tabwriter.NewWriter(sp.run.output, 0, 8, 2, ' ', 0)
+		sp.printTable(w, "\t ")
+		if err := w.Flush(); err != nil {
+			sp.run.logErrorf("Error writing to table: %s", err)
+		}
+	case CSVOutput:
+		sp.printTable(sp.run.output, ",")
+	case TSVOutput:
+		sp.printTable(sp.run.output, "\t")
+	default:
+		return fmt.Errorf("Called with an unsupported output type: %s", outputType)
+	}
+	return nil
+}
+
+func (sp *slackPrinter) printTable(w io.Writer, separator string) {
+	usage := fmt.Sprintf("P%g", sp.opts.RecommendPercentile)
+	_, _ = fmt.Fprintln(w, strings.Join([]string{
+		"NAMESPACE",
+		"CPU REQUESTS", "CPU " + usage, "CPU SLACK",
+		"MEMORY REQUESTS", "MEMORY " + usage, "MEMORY SLACK",
+		"NODES",
+	}, separator))
+
+	list := sp.buildListNamespaceSlack()
+	for _, ns := range append([]*listNamespaceSlackItem{list.ClusterTotals}, list.Namespaces...) {
+		_, _ = fmt.Fprintln(w, strings.Join([]string{
+			ns.Name,
+			ns.CPU.Requests, ns.CPU.Usage, ns.CPU.Slack,
+			ns.Memory.Requests, ns.Memory.Usage, ns.Memory.Slack,
+			ns.NodeEquivalents,
+		}, separator))
+	}
+}
+
+func (sp *slackPrinter) buildListNamespaceSlack() *listNamespaceSlack {
+	out := &listNamespaceSlack{
+		Window:     sp.opts.RecommendWindow,
+		Percentile: sp.opts.RecommendPercentile,
+		Namespaces: []*listNamespaceSlackItem{},
+	}
+	for _, ns := range sp.namespaces {
+		out.Namespaces = append(out.Namespaces, ns.listNamespaceSlackItem(sp.run))
+	}
+	out.ClusterTotals = sp.total.listNamespaceSlackItem(sp.run)
+	return out
+}
+
+func (ns *namespaceSlack) listNamespaceSlackItem(run *reportRun) *listNamespaceSlackItem {
+	return &listNamespaceSlackItem{
+		Name:            ns.name,
+		CPU:             ns.cpu.listSlackMetric(run, "cpu"),
+		Memory:          ns.memory.listSlackMetric(run, "memory"),
+		NodeEquivalents: fmt.Sprintf("%.1f", ns.nodeEquivalents),
+	}
+}
+
+func (sm *slackMetric) listSlackMetric(run *reportRun, resourceType string) *listSlackMetric {
+	valueCalculator := resourceMetric{resourceType: resourceType}.valueFunction(run)
+	return &listSlackMetric{
+		Requests: valueCalculator(sm.request),
+		Usage:    valueCalculator(sm.usage),
+		Slack:    valueCalculator(sm.slack),
+	}
+}
@@ -0,0 +1,74 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	v1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+)
+
+func TestNamespaceSlack(t *testing.T) {
+	run := newTestRun(nil)
+	snap := getTestSnapshot()
+	big := snap.Pods.Items[0].DeepCopy()
+	big.Name = "big"
+	big.Spec.Containers[0].Resources.Requests = corev1.ResourceList{
+		"cpu":    resource.MustParse("1000m"),
+		"memory": resource.MustParse("2000Mi"),
+	}
+	idle := snap.Pods.Items[1].DeepCopy()
+	idle.Name = "idle"
+	snap.Pods.Items = append(snap.Pods.Items, *big, *idle)
+
+	// mypod uses more CPU than it requests, which does not count against
+	// the slack of big, and idle has no usage history
+	pmList := &v1beta1.PodMetricsList{Items: []v1beta1.PodMetrics{
+		podMetrics("default", "mypod", "app", "150m", "64Mi"),
+		podMetrics("default", "big", "app", "200m", "1000Mi"),
+		podMetrics("other", "mypod2", "app", "20m", "32Mi"),
+	}}
+
+	namespaces, total := buildNamespaceSlack(buildRecommendations(snap.Pods, pmList), snap.Nodes)
+	require.Len(t, namespaces, 2)
+	assert.Equal(t, "default", namespaces[0].name)
+	assert.Equal(t, "800m", namespaces[0].cpu.slack.String())
+	assert.InDelta(t, 1064.0/4000, namespaces[0].nodeEquivalents, 0.0001)
+	assert.InDelta(t, 1160.0/4000, total.nodeEquivalents, 0.0001)
+
+	var buf bytes.Buffer
+	sp := &slackPrinter{run: run, namespaces: namespaces, total: total, opts: Options{RecommendWindow: "7d", RecommendPercentile: 95}}
+	sp.printTable(&buf, ",")
+	assert.Equal(t, strings.Join([]string{
+		"NAMESPACE,CPU REQUESTS,CPU P95,CPU SLACK,MEMORY REQUESTS,MEMORY P95,MEMORY SLACK,NODES",
+		"*,1200m,370m,880m,2256Mi,1096Mi,1160Mi,0.3",
+		"default,1100m,350m,800m,2128Mi,1064Mi,1064Mi,0.3",
+		"other,100m,20m,80m,128Mi,32Mi,96Mi,0.0",
+	}, "\n")+"\n", buf.String())
+
+	list := sp.buildListNamespaceSlack()
+	assert.Equal(t, "7d", list.Window)
+	assert.Equal(t, "96Mi", list.Namespaces[1].Memory.Slack)
+
+	// Without nodes there is nothing to measure node equivalents against
+	_, total = buildNamespaceSlack(buildRecommendations(snap.Pods, pmList), &corev1.NodeList{})
+	assert.Zero(t, total.nodeEquivalents)
+}
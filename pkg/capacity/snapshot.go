@@ -0,0 +1,199 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	v1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+)
+
+// snapshotKind identifies files written by --snapshot-out
+const snapshotKind = "KubeCapacitySnapshot"
+
+// snapshot is the on-disk representation of everything needed to render a
+// report without access to the cluster.
+type snapshot struct {
+	Kind                     string                                     `json:"kind"`
+	CreatedAt                time.Time                                  `json:"createdAt"`
+	Context                  string                                     `json:"context,omitempty"`
+	Nodes                    *corev1.NodeList                           `json:"nodes"`
+	Pods                     *corev1.PodList                            `json:"pods"`
+	Namespaces               *corev1.NamespaceList                      `json:"namespaces,omitempty"`
+	PodMetrics               *v1beta1.PodMetricsList                    `json:"podMetrics,omitempty"`
+	NodeMetrics              *v1beta1.NodeMetricsList                   `json:"nodeMetrics,omitempty"`
+	ResourceQuotas           *corev1.ResourceQuotaList                  `json:"resourceQuotas,omitempty"`
+	LimitRanges              *corev1.LimitRangeList                     `json:"limitRanges,omitempty"`
+	VerticalPodAutoscalers   *verticalPodAutoscalerList                 `json:"verticalPodAutoscalers,omitempty"`
+	ClusterAutoscalerStatus  *corev1.ConfigMap                          `json:"clusterAutoscalerStatus,omitempty"`
+	NodePools                *nodePoolList                              `json:"nodePools,omitempty"`
+	PendingPods              *corev1.PodList                            `json:"pendingPods,omitempty"`
+	PodDisruptionBudgets     *policyv1.PodDisruptionBudgetList          `json:"podDisruptionBudgets,omitempty"`
+	HorizontalPodAutoscalers *autoscalingv2.HorizontalPodAutoscalerList `json:"horizontalPodAutoscalers,omitempty"`
+}
+
+// saveSnapshot writes the snapshot as JSON, gzip compressed when the file
+// name ends in .gz. The file is written to a temporary file first and renamed
+// into place so a failed write never clobbers an existing snapshot.
+func saveSnapshot(path string, snap *snapshot) error {
+	snap.Kind = snapshotKind
+
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = f
+	var gw *gzip.Writer
+	if strings.HasSuffix(path, ".gz") {
+		gw = gzip.NewWriter(f)
+		w = gw
+	}
+
+	err = json.NewEncoder(w).Encode(snap)
+	if err == nil && gw != nil {
+		err = gw.Close()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+	}
+	return err
+}
+
+// loadSnapshot reads a snapshot written by saveSnapshot
+func loadSnapshot(path string) (*snapshot, error) {
+	snap := &snapshot{}
+	err := readDecompressed(path, func(r io.Reader) error {
+		if err := json.NewDecoder(r).Decode(snap); err != nil {
+			return fmt.Errorf("decoding snapshot: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if snap.Kind != snapshotKind {
+		return nil, fmt.Errorf("%s is not a kube-capacity snapshot", path)
+	}
+	if snap.Nodes == nil {
+		snap.Nodes = &corev1.NodeList{}
+	}
+	if snap.Pods == nil {
+		snap.Pods = &corev1.PodList{}
+	}
+
+	return snap, nil
+}
+
+// readDecompressed calls read with the contents of a file, detecting gzip
+// compression from the file contents rather than the name
+func readDecompressed(path string, read func(r io.Reader) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	br := bufio.NewReader(f)
+	var r io.Reader = br
+	if magic, err := br.Peek(2); err == nil && bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		gr, err := gzip.NewReader(br)
+		if err != nil {
+			return err
+		}
+		defer gr.Close()
+		r = gr
+	}
+	return read(r)
+}
+
+// clientset returns a fake clientset serving the snapshot contents so that
+// the regular filtering code paths can be reused when rendering offline.
+func (s *snapshot) clientset() kubernetes.Interface {
+	objects := []runtime.Object{}
+	for i := range s.Nodes.Items {
+		objects = append(objects, &s.Nodes.Items[i])
+	}
+	for i := range s.Pods.Items {
+		objects = append(objects, &s.Pods.Items[i])
+	}
+	if s.Namespaces != nil {
+		for i := range s.Namespaces.Items {
+			objects = append(objects, &s.Namespaces.Items[i])
+		}
+	}
+	if s.ResourceQuotas != nil {
+		for i := range s.ResourceQuotas.Items {
+			objects = append(objects, &s.ResourceQuotas.Items[i])
+		}
+	}
+	if s.LimitRanges != nil {
+		for i := range s.LimitRanges.Items {
+			objects = append(objects, &s.LimitRanges.Items[i])
+		}
+	}
+	if s.ClusterAutoscalerStatus != nil {
+		objects = append(objects, s.ClusterAutoscalerStatus)
+	}
+	if s.PendingPods != nil {
+		for i := range s.PendingPods.Items {
+			objects = append(objects, &s.PendingPods.Items[i])
+		}
+	}
+	if s.PodDisruptionBudgets != nil {
+		for i := range s.PodDisruptionBudgets.Items {
+			objects = append(objects, &s.PodDisruptionBudgets.Items[i])
+		}
+	}
+	if s.HorizontalPodAutoscalers != nil {
+		for i := range s.HorizontalPodAutoscalers.Items {
+			objects = append(objects, &s.HorizontalPodAutoscalers.Items[i])
+		}
+	}
+	return fake.NewSimpleClientset(objects...)
+}
+
+// getMetrics returns the utilization data stored in the snapshot. Node
+// metrics are dropped when filtering by namespace, matching live behavior.
+func (s *snapshot) getMetrics(opts Options) (*v1beta1.PodMetricsList, *v1beta1.NodeMetricsList, error) {
+	if s.PodMetrics == nil {
+		return nil, nil, fmt.Errorf("snapshot has no utilization data; re-capture with --util")
+	}
+	if opts.Namespace != "" || opts.NamespaceLabels != "" {
+		return s.PodMetrics, nil, nil
+	}
+	return s.PodMetrics, s.NodeMetrics, nil
+}
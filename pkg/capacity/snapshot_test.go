@@ -0,0 +1,203 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	v1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+)
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	run := newTestRun(nil)
+	snap := getTestSnapshot()
+
+	for _, name := range []string{"snapshot.json", "snapshot.json.gz"} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, name)
+			require.NoError(t, saveSnapshot(path, snap))
+
+			entries, err := os.ReadDir(dir)
+			require.NoError(t, err)
+			assert.Len(t, entries, 1, "temporary file should be renamed into place")
+
+			loaded, err := loadSnapshot(path)
+			require.NoError(t, err)
+			assert.Equal(t, "example", loaded.Context)
+			assert.Equal(t, []string{"mynode", "mynode2"}, listNodes(loaded.Nodes))
+			assert.Equal(t, []string{"default/mypod", "other/mypod2"}, listPods(loaded.Pods))
+
+			podList, nodeList, _ := getPodsAndNodes(run, loaded.clientset(), listFilters{excludeTainted: true, nodeLabels: "hello=world"})
+			assert.Equal(t, []string{"mynode"}, listNodes(nodeList))
+			assert.Equal(t, []string{"default/mypod"}, listPods(podList))
+
+			podList, _, _ = getPodsAndNodes(run, loaded.clientset(), listFilters{namespaceLabels: "app=true"})
+			assert.Equal(t, []string{"default/mypod"}, listPods(podList))
+		})
+	}
+}
+
+func TestSnapshotMatchesLiveMetrics(t *testing.T) {
+	run := newTestRun(nil)
+	snap := getTestSnapshot()
+	live := buildClusterMetric(snap.Pods, snap.PodMetrics, snap.Nodes, snap.NodeMetrics)
+
+	path := filepath.Join(t.TempDir(), "snapshot.json.gz")
+	require.NoError(t, saveSnapshot(path, snap))
+	loaded, err := loadSnapshot(path)
+	require.NoError(t, err)
+
+	podList, nodeList, _ := getPodsAndNodes(run, loaded.clientset(), listFilters{})
+	pmList, nmList, err := loaded.getMetrics(Options{ShowUtil: true})
+	require.NoError(t, err)
+	offline := buildClusterMetric(podList, pmList, nodeList, nmList)
+
+	ensureEqualResourceMetric(t, offline.cpu, live.cpu)
+	ensureEqualResourceMetric(t, offline.memory, live.memory)
+	assert.Equal(t, int64(300), offline.cpu.utilization.MilliValue())
+	for name, nm := range live.nodeMetrics {
+		require.NotNil(t, offline.nodeMetrics[name])
+		ensureEqualResourceMetric(t, offline.nodeMetrics[name].cpu, nm.cpu)
+		ensureEqualResourceMetric(t, offline.nodeMetrics[name].memory, nm.memory)
+	}
+	pm := offline.nodeMetrics["mynode"].podMetrics["default/mypod"]
+	require.NotNil(t, pm)
+	assert.Equal(t, int64(50), pm.cpu.utilization.MilliValue())
+	assert.Equal(t, int64(64*Mebibyte), pm.memory.utilization.Value())
+
+	pmList, nmList, err = loaded.getMetrics(Options{ShowUtil: true, Namespace: "default"})
+	require.NoError(t, err)
+	assert.NotNil(t, pmList)
+	assert.Nil(t, nmList)
+}
+
+func TestSnapshotWithoutMetrics(t *testing.T) {
+	snap := getTestSnapshot()
+	snap.PodMetrics = nil
+	snap.NodeMetrics = nil
+
+	_, _, err := snap.getMetrics(Options{ShowUtil: true})
+	assert.EqualError(t, err, "snapshot has no utilization data; re-capture with --util")
+}
+
+func TestCaptureSnapshotIsUnfiltered(t *testing.T) {
+	run := newTestRun(nil)
+	snap := getTestSnapshot()
+	clientset := fake.NewSimpleClientset(
+		&snap.Nodes.Items[0], &snap.Nodes.Items[1],
+		&snap.Pods.Items[0], &snap.Pods.Items[1],
+		&snap.Namespaces.Items[0], &snap.Namespaces.Items[1],
+	)
+
+	captured, ferr := captureSnapshot(run, clientset, Options{
+		KubeContext: "example",
+		Namespace:   "default",
+		NodeLabels:  "hello=world",
+	})
+	require.Nil(t, ferr)
+	assert.Equal(t, "example", captured.Context)
+	assert.Equal(t, []string{"mynode", "mynode2"}, listNodes(captured.Nodes))
+	assert.Equal(t, []string{"default/mypod", "other/mypod2"}, listPods(captured.Pods))
+	assert.Len(t, captured.Namespaces.Items, 2)
+	assert.Nil(t, captured.PodMetrics)
+}
+
+func TestLoadSnapshotRejectsOtherFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nodes.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"kind":"NodeList","items":[]}`), 0600))
+
+	_, err := loadSnapshot(path)
+	assert.Error(t, err)
+}
+
+func getTestSnapshot() *snapshot {
+	nodes := []corev1.Node{
+		*node("mynode", map[string]string{"hello": "world"}, false),
+		*node("mynode2", map[string]string{}, true),
+	}
+	for i := range nodes {
+		nodes[i].Status.Allocatable = corev1.ResourceList{
+			"cpu":    resource.MustParse("1000m"),
+			"memory": resource.MustParse("4000Mi"),
+			"pods":   resource.MustParse("110"),
+		}
+	}
+
+	pods := []corev1.Pod{
+		*pod("mynode", "default", "mypod", map[string]string{"a": "test"}),
+		*pod("mynode2", "other", "mypod2", map[string]string{"b": "test"}),
+	}
+	for i := range pods {
+		pods[i].Spec.Containers = []corev1.Container{{
+			Name: "app",
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					"cpu":    resource.MustParse("100m"),
+					"memory": resource.MustParse("128Mi"),
+				},
+			},
+		}}
+	}
+
+	return &snapshot{
+		Context: "example",
+		Nodes:   &corev1.NodeList{Items: nodes},
+		Pods:    &corev1.PodList{Items: pods},
+		Namespaces: &corev1.NamespaceList{Items: []corev1.Namespace{
+			*namespace("default", map[string]string{"app": "true"}),
+			*namespace("other", map[string]string{}),
+		}},
+		PodMetrics: &v1beta1.PodMetricsList{Items: []v1beta1.PodMetrics{
+			podMetrics("default", "mypod", "app", "50m", "64Mi"),
+			podMetrics("other", "mypod2", "app", "70m", "96Mi"),
+		}},
+		NodeMetrics: &v1beta1.NodeMetricsList{Items: []v1beta1.NodeMetrics{
+			nodeMetrics("mynode", "120m", "512Mi"),
+			nodeMetrics("mynode2", "180m", "768Mi"),
+		}},
+	}
+}
+
+func podMetrics(namespace, name, container, cpu, memory string) v1beta1.PodMetrics {
+	return v1beta1.PodMetrics{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Containers: []v1beta1.ContainerMetrics{{
+			Name: container,
+			Usage: corev1.ResourceList{
+				"cpu":    resource.MustParse(cpu),
+				"memory": resource.MustParse(memory),
+			},
+		}},
+	}
+}
+
+func nodeMetrics(name, cpu, memory string) v1beta1.NodeMetrics {
+	return v1beta1.NodeMetrics{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Usage: corev1.ResourceList{
+			"cpu":    resource.MustParse(cpu),
+			"memory": resource.MustParse(memory),
+		},
+	}
+}
@@ -0,0 +1,299 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/client-go/kubernetes"
+)
+
+// sparklinePoints is how many points of usage history a sparkline shows
+const sparklinePoints = 12
+
+var sparklineBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// usageHistory is the CPU cores and memory bytes of nodes, keyed by name,
+// and pods, keyed by podKey, at evenly spaced points over the window. Points
+// without usage are NaN.
+type usageHistory struct {
+	nodeCPU, nodeMemory map[string][]float64
+	podCPU, podMemory   map[string][]float64
+}
+
+// fetchUsageHistory starts the range queries of --sparkline. The returned
+// function gives the history once the group has been waited on.
+func fetchUsageHistory(run *reportRun, g *fetchGroup, clientset kubernetes.Interface, opts Options) func() *usageHistory {
+	var history *usageHistory
+	g.run(func(ctx context.Context) *fetchError {
+		var err error
+		history, err = getPrometheusUsageHistory(run, clientset, opts)
+		if err != nil {
+			return newFetchError(4, "Error getting usage history from Prometheus: %v", err)
+		}
+		return nil
+	})
+	return func() *usageHistory {
+		return history
+	}
+}
+
+func getPrometheusUsageHistory(run *reportRun, clientset kubernetes.Interface, opts Options) (*usageHistory, error) {
+	window, err := parsePromQLDuration(opts.PrometheusWindow)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --prometheus-window: %w", err)
+	}
+	endpoint, err := resolvePrometheusEndpoint(run, clientset, opts)
+	if err != nil {
+		return nil, err
+	}
+	promHTTP, err := newPrometheusHTTP(context.TODO(), run, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	end := time.Now().Truncate(time.Second)
+	step := window / (sparklinePoints - 1)
+	if step < time.Second {
+		step = time.Second
+	}
+	start := end.Add(-step * (sparklinePoints - 1))
+
+	containerCPU, containerMemory := run.promLabels.containerUsage("")
+	nodeCPU, nodeMemory := run.promLabels.nodeUsage()
+	queries := []struct {
+		name  string
+		query string
+		by    func(map[string]string) string
+		dest  *map[string][]float64
+	}{
+		{"pod CPU", `sum by (namespace, pod) (` + containerCPU + `)`, seriesPodKey, nil},
+		{"pod memory", `sum by (namespace, pod) (` + containerMemory + `)`, seriesPodKey, nil},
+		{"node CPU", `sum by (node) (` + nodeCPU + `)`, seriesNodeKey, nil},
+		{"node memory", `sum by (node) (` + nodeMemory + `)`, seriesNodeKey, nil},
+	}
+	nodeNames, err := getPrometheusNodeNames(run, clientset, endpoint, promHTTP)
+	if err != nil {
+		return nil, err
+	}
+
+	history := &usageHistory{}
+	queries[0].dest = &history.podCPU
+	queries[1].dest = &history.podMemory
+	queries[2].dest = &history.nodeCPU
+	queries[3].dest = &history.nodeMemory
+
+	for _, q := range queries {
+		resp, err := queryPrometheusRange(run, clientset, endpoint, promHTTP, q.query, start, end, step)
+		if err != nil {
+			return nil, fmt.Errorf("querying %s history: %w", q.name, err)
+		}
+		nodeNames.resolve(resp)
+		*q.dest = bucketRangeResult(resp, q.by, start, step, sparklinePoints)
+	}
+	return history, nil
+}
+
+func seriesPodKey(metric map[string]string) string {
+	if metric["pod"] == "" {
+		return ""
+	}
+	return podKey(metric["namespace"], metric["pod"])
+}
+
+func seriesNodeKey(metric map[string]string) string {
+	return metric["node"]
+}
+
+// bucketRangeResult places the values of each series at the point of the
+// sparkline or series its timestamp falls on, adding up series with the
+// same key
+func bucketRangeResult(resp *prometheusResponse, key func(map[string]string) string, start time.Time, step time.Duration, count int) map[string][]float64 {
+	out := map[string][]float64{}
+	for _, r := range resp.Data.Result {
+		k := key(r.Metric)
+		if k == "" {
+			continue
+		}
+		points := out[k]
+		if points == nil {
+			points = make([]float64, count)
+			for i := range points {
+				points[i] = math.NaN()
+			}
+			out[k] = points
+		}
+		for _, v := range r.Values {
+			seconds, ok := v[0].(float64)
+			if !ok {
+				continue
+			}
+			value, err := parseValue(v)
+			if err != nil {
+				continue
+			}
+			offset := time.Unix(0, int64(seconds*float64(time.Second))).Sub(start)
+			i := int(math.Round(float64(offset) / float64(step)))
+			if i < 0 || i >= count {
+				continue
+			}
+			if math.IsNaN(points[i]) {
+				points[i] = 0
+			}
+			points[i] += value
+		}
+	}
+	return out
+}
+
+// addUsageHistory sets the history of each node and pod, and of the
+// cluster as the sum of its nodes
+func (cm *clusterMetric) addUsageHistory(history *usageHistory) {
+	cm.cpu.history = newHistory()
+	cm.memory.history = newHistory()
+	for name, nm := range cm.nodeMetrics {
+		nm.cpu.history = orNewHistory(history.nodeCPU[name])
+		nm.memory.history = orNewHistory(history.nodeMemory[name])
+		addHistory(cm.cpu.history, nm.cpu.history)
+		addHistory(cm.memory.history, nm.memory.history)
+		for key, pm := range nm.podMetrics {
+			pm.cpu.history = orNewHistory(history.podCPU[key])
+			pm.memory.history = orNewHistory(history.podMemory[key])
+		}
+	}
+}
+
+func newHistory() []float64 {
+	return orNewHistory(nil)
+}
+
+func orNewHistory(points []float64) []float64 {
+	if points != nil {
+		return points
+	}
+	points = make([]float64, sparklinePoints)
+	for i := range points {
+		points[i] = math.NaN()
+	}
+	return points
+}
+
+func addHistory(total, points []float64) {
+	for i, v := range points {
+		if math.IsNaN(v) {
+			continue
+		}
+		if math.IsNaN(total[i]) {
+			total[i] = 0
+		}
+		total[i] += v
+	}
+}
+
+// sparklineString draws the history scaled from its lowest to its highest
+// point, with a space for points without usage
+func (rm *resourceMetric) sparklineString() string {
+	if rm.history == nil {
+		return VoidValue
+	}
+	low, high := math.Inf(1), math.Inf(-1)
+	for _, v := range rm.history {
+		if !math.IsNaN(v) {
+			low = math.Min(low, v)
+			high = math.Max(high, v)
+		}
+	}
+	if math.IsInf(low, 1) {
+		return VoidValue
+	}
+
+	var sb strings.Builder
+	for _, v := range rm.history {
+		switch {
+		case math.IsNaN(v):
+			sb.WriteRune(' ')
+		case high == low:
+			sb.WriteRune(sparklineBlocks[0])
+		default:
+			level := int(math.Round((v - low) / (high - low) * float64(len(sparklineBlocks)-1)))
+			sb.WriteRune(sparklineBlocks[level])
+		}
+	}
+	return sb.String()
+}
+
+// listHistory gives the history in the units of the resource, with null for
+// points without usage
+func (rm *resourceMetric) listHistory(run *reportRun) []*string {
+	if rm.history == nil {
+		return nil
+	}
+	valueCalculator := rm.valueFunction(run)
+	out := make([]*string, len(rm.history))
+	for i, v := range rm.history {
+		if math.IsNaN(v) {
+			continue
+		}
+		s := valueCalculator(rm.historyQuantity(v))
+		out[i] = &s
+	}
+	return out
+}
+
+// historyCSVString gives the history as space separated numbers in the
+// units of the capacity columns, with VoidValue for points without usage
+func (rm *resourceMetric) historyCSVString(run *reportRun) string {
+	if rm.history == nil {
+		return VoidValue
+	}
+	points := make([]string, len(rm.history))
+	for i, v := range rm.history {
+		if math.IsNaN(v) {
+			points[i] = VoidValue
+			continue
+		}
+		points[i] = run.resourceCSVString(rm.resourceType, rm.historyQuantity(v))
+	}
+	return strings.Join(points, " ")
+}
+
+// historyQuantity converts a point of history from cores or bytes
+func (rm *resourceMetric) historyQuantity(v float64) resource.Quantity {
+	if rm.resourceType == "cpu" {
+		return *resource.NewMilliQuantity(int64(math.Round(v*1000)), resource.DecimalSI)
+	}
+	return *resource.NewQuantity(int64(math.Round(v)), resource.BinarySI)
+}
+
+// parsePromQLDuration parses durations like 15m or 1h30m, along with the
+// d and w units PromQL adds
+func parsePromQLDuration(s string) (time.Duration, error) {
+	for unit, d := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, ok := strings.CutSuffix(s, unit); ok {
+			count, err := strconv.Atoi(n)
+			if err != nil {
+				return 0, fmt.Errorf("invalid duration %q", s)
+			}
+			return time.Duration(count) * d, nil
+		}
+	}
+	return time.ParseDuration(s)
+}
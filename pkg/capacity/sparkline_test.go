@@ -0,0 +1,148 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBucketRangeResult(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	body := `{"status":"success","data":{"resultType":"matrix","result":[
+		{"metric":{"namespace":"default","pod":"a"},"values":[[1700000000,"0.5"],[1700000060,"1"],[1700000660,"2"]]},
+		{"metric":{"namespace":"default","pod":"a","container":"sidecar"},"values":[[1700000060,"0.25"]]},
+		{"metric":{"namespace":"default","pod":"b"},"values":[[1700000120,"4"],[1700009999,"8"]]},
+		{"metric":{"node":"node-a"},"values":[[1700000000,"1"]]}
+	]}}`
+	var resp prometheusResponse
+	require.NoError(t, json.Unmarshal([]byte(body), &resp))
+
+	out := bucketRangeResult(&resp, seriesPodKey, start, time.Minute, sparklinePoints)
+
+	require.Len(t, out, 2)
+	a := out["default/a"]
+	require.Len(t, a, sparklinePoints)
+	assert.Equal(t, 0.5, a[0])
+	assert.Equal(t, 1.25, a[1])
+	assert.Equal(t, 2.0, a[11])
+	assert.True(t, math.IsNaN(a[2]))
+
+	// Points past the end of the window are dropped
+	b := out["default/b"]
+	assert.Equal(t, 4.0, b[2])
+	assert.True(t, math.IsNaN(b[11]))
+}
+
+func TestSparklineString(t *testing.T) {
+	nan := math.NaN()
+	tests := []struct {
+		name     string
+		history  []float64
+		expected string
+	}{
+		{"no history", nil, VoidValue},
+		{"no usage", []float64{nan, nan, nan}, VoidValue},
+		{"rising", []float64{0, 1, 2, 3, 4, 5, 6, 7}, "▁▂▃▄▅▆▇█"},
+		{"flat", []float64{2, 2, 2}, "▁▁▁"},
+		{"gap", []float64{1, nan, 3}, "▁ █"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rm := &resourceMetric{resourceType: "cpu", history: tt.history}
+			assert.Equal(t, tt.expected, rm.sparklineString())
+		})
+	}
+}
+
+func TestHistoryOutput(t *testing.T) {
+	run := newTestRun(nil)
+	nan := math.NaN()
+
+	cpu := &resourceMetric{resourceType: "cpu", history: []float64{0.25, nan, 1.5}}
+	assert.Equal(t, "250 * 1500", cpu.historyCSVString(run))
+	list := cpu.listHistory(run)
+	require.Len(t, list, 3)
+	assert.Equal(t, "250m", *list[0])
+	assert.Nil(t, list[1])
+	assert.Equal(t, "1500m", *list[2])
+
+	memory := &resourceMetric{resourceType: "memory", history: []float64{64 * Mebibyte}}
+	assert.Equal(t, "64", memory.historyCSVString(run))
+	assert.Equal(t, "64Mi", *memory.listHistory(run)[0])
+
+	assert.Equal(t, VoidValue, (&resourceMetric{resourceType: "cpu"}).historyCSVString(run))
+	assert.Nil(t, (&resourceMetric{resourceType: "cpu"}).listHistory(run))
+}
+
+func TestAddUsageHistory(t *testing.T) {
+	nan := math.NaN()
+	history := &usageHistory{
+		nodeCPU:    map[string][]float64{"node-a": {1, nan, 2}, "node-b": {nan, nan, 3}},
+		nodeMemory: map[string][]float64{},
+		podCPU:     map[string][]float64{"default/a": {0.5, 0.5, 0.5}},
+		podMemory:  map[string][]float64{},
+	}
+	cm := &clusterMetric{
+		cpu:    &resourceMetric{resourceType: "cpu"},
+		memory: &resourceMetric{resourceType: "memory"},
+		nodeMetrics: map[string]*nodeMetric{
+			"node-a": {
+				cpu:    &resourceMetric{resourceType: "cpu"},
+				memory: &resourceMetric{resourceType: "memory"},
+				podMetrics: map[string]*podMetric{"default/a": {
+					cpu:    &resourceMetric{resourceType: "cpu"},
+					memory: &resourceMetric{resourceType: "memory"},
+				}},
+			},
+			"node-b": {
+				cpu:        &resourceMetric{resourceType: "cpu"},
+				memory:     &resourceMetric{resourceType: "memory"},
+				podMetrics: map[string]*podMetric{},
+			},
+		},
+	}
+
+	cm.addUsageHistory(history)
+
+	assert.Equal(t, 1.0, cm.cpu.history[0])
+	assert.True(t, math.IsNaN(cm.cpu.history[1]))
+	assert.Equal(t, 5.0, cm.cpu.history[2])
+	assert.Len(t, cm.memory.history, sparklinePoints)
+	assert.Equal(t, VoidValue, cm.memory.sparklineString())
+	assert.Equal(t, 0.5, cm.nodeMetrics["node-a"].podMetrics["default/a"].cpu.history[2])
+}
+
+func TestParsePromQLDuration(t *testing.T) {
+	for s, expected := range map[string]time.Duration{
+		"15m":   15 * time.Minute,
+		"1h30m": 90 * time.Minute,
+		"2d":    48 * time.Hour,
+		"1w":    7 * 24 * time.Hour,
+	} {
+		d, err := parsePromQLDuration(s)
+		require.NoError(t, err, s)
+		assert.Equal(t, expected, d, s)
+	}
+
+	_, err := parsePromQLDuration("xd")
+	assert.Error(t, err)
+}
@@ -0,0 +1,221 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"io"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	corev1 "k8s.io/api/core/v1"
+	v1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+)
+
+// streamPrinter prints each node as soon as its metrics are built, followed
+// by the cluster totals once every node has been read
+type streamPrinter interface {
+	printHeader()
+	printNode(cm *clusterMetric, nm *nodeMetric)
+	printTotals(cm *clusterMetric)
+}
+
+func newStreamPrinter(run *reportRun, opts Options) (streamPrinter, *fetchError) {
+	switch opts.OutputFormat {
+	case TableOutput:
+		tp := &tablePrinter{run: run, w: &columnWriter{w: run.output}, opts: opts}
+		if ferr := tp.checkVisibleColumns(); ferr != nil {
+			return nil, ferr
+		}
+		return &tableStreamPrinter{tp}, nil
+	case CSVOutput, TSVOutput:
+		return &csvStreamPrinter{&csvPrinter{run: run, file: run.output, opts: opts}}, nil
+	}
+	return nil, newFetchError(1, "Called with an unsupported output type: %s", opts.OutputFormat)
+}
+
+// streamClusterMetric builds the metrics of one node at a time, in name
+// order, and prints each node before moving on to the next. The pod metrics
+// of a node are dropped once it has been printed so that memory use does not
+// grow with every pod and container in the cluster.
+func streamClusterMetric(podList *corev1.PodList, pmList *v1beta1.PodMetricsList,
+	nodeList *corev1.NodeList, nmList *v1beta1.NodeMetricsList, observedAt time.Time, sp streamPrinter) clusterMetric {
+	cm := clusterMetric{
+		cpu:         &resourceMetric{resourceType: "cpu"},
+		memory:      &resourceMetric{resourceType: "memory"},
+		nodeMetrics: map[string]*nodeMetric{},
+		podCount:    &podCount{},
+		nodeStatus:  &nodeStatusCount{},
+		observedAt:  observedAt,
+	}
+
+	podsByNode := map[string][]corev1.Pod{}
+	podNodes := map[string]string{}
+	for _, pod := range podList.Items {
+		podsByNode[pod.Spec.NodeName] = append(podsByNode[pod.Spec.NodeName], pod)
+		podNodes[podKey(pod.Namespace, pod.Name)] = pod.Spec.NodeName
+	}
+
+	podMetricsByNode := map[string][]v1beta1.PodMetrics{}
+	if pmList != nil {
+		for _, pm := range pmList.Items {
+			nodeName := podNodes[podKey(pm.Namespace, pm.Name)]
+			podMetricsByNode[nodeName] = append(podMetricsByNode[nodeName], pm)
+		}
+	}
+
+	nodeUsage := map[string]v1beta1.NodeMetrics{}
+	if nmList != nil {
+		for _, nm := range nmList.Items {
+			nodeUsage[nm.Name] = nm
+		}
+	}
+
+	nodes := make([]corev1.Node, len(nodeList.Items))
+	copy(nodes, nodeList.Items)
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].Name < nodes[j].Name
+	})
+
+	sp.printHeader()
+
+	for _, node := range nodes {
+		// A nil node metrics list makes buildClusterMetric sum pod
+		// utilization, so only pass one when the cluster had one.
+		var nodeNMList *v1beta1.NodeMetricsList
+		if nmList != nil {
+			nodeNMList = &v1beta1.NodeMetricsList{}
+			if usage, ok := nodeUsage[node.Name]; ok {
+				nodeNMList.Items = []v1beta1.NodeMetrics{usage}
+			}
+		}
+
+		nodeCM := buildClusterMetric(
+			&corev1.PodList{Items: podsByNode[node.Name]},
+			&v1beta1.PodMetricsList{Items: podMetricsByNode[node.Name]},
+			&corev1.NodeList{Items: []corev1.Node{node}},
+			nodeNMList)
+		delete(podsByNode, node.Name)
+		delete(podMetricsByNode, node.Name)
+
+		nm := nodeCM.nodeMetrics[node.Name]
+		sp.printNode(&cm, nm)
+		nm.podMetrics = nil
+
+		cm.nodeMetrics[nm.name] = nm
+		cm.addNodeMetric(nm)
+		cm.podCount.current += nm.podCount.current
+		cm.podCount.allocatable += nm.podCount.allocatable
+		cm.nodeStatus.addNode(nm)
+	}
+
+	sp.printTotals(&cm)
+
+	return cm
+}
+
+type tableStreamPrinter struct {
+	tp *tablePrinter
+}
+
+func (sp *tableStreamPrinter) printHeader() {
+	sp.tp.printLine(sp.tp.headers())
+}
+
+func (sp *tableStreamPrinter) printNode(cm *clusterMetric, nm *nodeMetric) {
+	sp.tp.cm = cm
+	sp.tp.printNodeRows(nm)
+}
+
+func (sp *tableStreamPrinter) printTotals(cm *clusterMetric) {
+	if len(cm.nodeMetrics) < 2 {
+		return
+	}
+	sp.tp.cm = cm
+	if sp.tp.opts.ShowPods || sp.tp.opts.ShowContainers {
+		sp.tp.printLine(&tableLine{})
+	}
+	sp.tp.printClusterLine()
+	if sp.tp.opts.ShowTaints {
+		sp.tp.printTaintedLine()
+	}
+}
+
+type csvStreamPrinter struct {
+	cp *csvPrinter
+}
+
+func (sp *csvStreamPrinter) printHeader() {
+	sp.cp.printLine(csvHeaders(sp.cp.run))
+}
+
+func (sp *csvStreamPrinter) printNode(cm *clusterMetric, nm *nodeMetric) {
+	sp.cp.cm = cm
+	sp.cp.printNodeRows(nm)
+}
+
+func (sp *csvStreamPrinter) printTotals(cm *clusterMetric) {
+	if len(cm.nodeMetrics) < 2 {
+		return
+	}
+	sp.cp.cm = cm
+	sp.cp.printClusterLine()
+	if sp.cp.opts.ShowTaints {
+		sp.cp.printTaintedLine()
+	}
+}
+
+// columnWriter aligns tab separated cells like a tabwriter but writes every
+// line as soon as it is complete. Each column is as wide as its widest cell
+// so far, so a longer cell shifts the columns of the lines that follow it.
+type columnWriter struct {
+	w       io.Writer
+	widths  []int
+	partial string
+}
+
+const columnPadding = 2
+
+func (cw *columnWriter) Write(p []byte) (int, error) {
+	lines := strings.Split(cw.partial+string(p), "\n")
+	cw.partial = lines[len(lines)-1]
+
+	var out strings.Builder
+	for _, line := range lines[:len(lines)-1] {
+		cells := strings.Split(line, "\t")
+		for i, cell := range cells {
+			if i == len(cells)-1 {
+				out.WriteString(cell)
+				break
+			}
+			width := utf8.RuneCountInString(cell)
+			if i == len(cw.widths) {
+				cw.widths = append(cw.widths, 0)
+			}
+			if width > cw.widths[i] {
+				cw.widths[i] = width
+			}
+			out.WriteString(cell)
+			out.WriteString(strings.Repeat(" ", cw.widths[i]-width+columnPadding))
+		}
+		out.WriteString("\n")
+	}
+
+	if _, err := io.WriteString(cw.w, out.String()); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
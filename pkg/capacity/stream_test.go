@@ -0,0 +1,122 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	v1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+)
+
+type recordingStreamPrinter struct {
+	calls []string
+}
+
+func (sp *recordingStreamPrinter) printHeader() {
+	sp.calls = append(sp.calls, "header")
+}
+
+func (sp *recordingStreamPrinter) printNode(cm *clusterMetric, nm *nodeMetric) {
+	sp.calls = append(sp.calls, fmt.Sprintf("%s %s %d pods", nm.name, nm.cpu.request.String(), len(nm.podMetrics)))
+}
+
+func (sp *recordingStreamPrinter) printTotals(cm *clusterMetric) {
+	sp.calls = append(sp.calls, fmt.Sprintf("totals %d nodes", len(cm.nodeMetrics)))
+}
+
+func TestStreamClusterMetric(t *testing.T) {
+	podList := &corev1.PodList{Items: []corev1.Pod{
+		*podWithRequests("node-b", "default", "a", nil, corev1.ResourceList{"cpu": resource.MustParse("500m")}),
+		*podWithRequests("node-a", "default", "b", nil, corev1.ResourceList{"cpu": resource.MustParse("250m")}),
+		*podWithRequests("node-b", "default", "c", nil, corev1.ResourceList{"cpu": resource.MustParse("250m")}),
+	}}
+	pmList := &v1beta1.PodMetricsList{Items: []v1beta1.PodMetrics{{
+		ObjectMeta: metav1.ObjectMeta{Name: "a", Namespace: "default"},
+		Containers: []v1beta1.ContainerMetrics{{
+			Name:  "app",
+			Usage: corev1.ResourceList{"cpu": resource.MustParse("100m")},
+		}},
+	}}}
+	nodeList := &corev1.NodeList{Items: []corev1.Node{
+		*nodeWithAllocatable("node-b", nil, corev1.ResourceList{"cpu": resource.MustParse("2")}),
+		*nodeWithAllocatable("node-a", nil, corev1.ResourceList{"cpu": resource.MustParse("1")}),
+	}}
+
+	observedAt := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	sp := &recordingStreamPrinter{}
+	streamed := streamClusterMetric(podList, pmList, nodeList, nil, observedAt, sp)
+
+	assert.Equal(t, []string{
+		"header",
+		"node-a 250m 1 pods",
+		"node-b 750m 2 pods",
+		"totals 2 nodes",
+	}, sp.calls)
+
+	built := buildClusterMetric(podList, pmList, nodeList, nil)
+	ensureEqualResourceMetric(t, streamed.cpu, built.cpu)
+	ensureEqualResourceMetric(t, streamed.memory, built.memory)
+	assert.Equal(t, built.podCount, streamed.podCount)
+	assert.Equal(t, built.nodeStatus, streamed.nodeStatus)
+	assert.Equal(t, observedAt, streamed.observedAt)
+
+	// Pods are dropped once their node has been printed
+	assert.Len(t, streamed.nodeMetrics, 2)
+	assert.Empty(t, streamed.nodeMetrics["node-b"].podMetrics)
+	assert.Equal(t, "100m", streamed.nodeMetrics["node-b"].cpu.utilization.String())
+}
+
+func TestStreamClusterMetricNodeUsage(t *testing.T) {
+	podList := &corev1.PodList{Items: []corev1.Pod{
+		*podWithRequests("node-a", "default", "a", nil, corev1.ResourceList{"cpu": resource.MustParse("500m")}),
+	}}
+	nodeList := &corev1.NodeList{Items: []corev1.Node{
+		*nodeWithAllocatable("node-a", nil, corev1.ResourceList{"cpu": resource.MustParse("2")}),
+		*nodeWithAllocatable("node-b", nil, corev1.ResourceList{"cpu": resource.MustParse("2")}),
+	}}
+	nmList := &v1beta1.NodeMetricsList{Items: []v1beta1.NodeMetrics{{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-a"},
+		Usage:      corev1.ResourceList{"cpu": resource.MustParse("1200m")},
+	}}}
+
+	cm := streamClusterMetric(podList, &v1beta1.PodMetricsList{}, nodeList, nmList, time.Now(), &recordingStreamPrinter{})
+
+	assert.Equal(t, "1200m", cm.nodeMetrics["node-a"].cpu.utilization.String())
+	assert.True(t, cm.nodeMetrics["node-b"].cpu.utilization.IsZero())
+	assert.Equal(t, "1200m", cm.cpu.utilization.String())
+}
+
+func TestColumnWriter(t *testing.T) {
+	var out bytes.Buffer
+	cw := &columnWriter{w: &out}
+
+	_, _ = fmt.Fprintln(cw, "NODE\t CPU\t MEMORY")
+	_, _ = fmt.Fprint(cw, "node-a\t 1\t ")
+	_, _ = fmt.Fprintln(cw, "2Gi")
+	_, _ = fmt.Fprintln(cw, "n\t 100m\t 1Gi")
+
+	assert.Equal(t, ""+
+		"NODE   CPU   MEMORY\n"+
+		"node-a   1     2Gi\n"+
+		"n        100m   1Gi\n", out.String())
+}
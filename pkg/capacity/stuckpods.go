@@ -0,0 +1,214 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/duration"
+)
+
+// DefaultStuckAfter is how long a pod has to be stuck before --stuck-pods
+// lists it
+const DefaultStuckAfter = 10 * time.Minute
+
+// Statuses of --stuck-pods other than the reason a container is waiting,
+// like ContainerCreating or CrashLoopBackOff
+const (
+	terminatingStatus = "Terminating"
+	pendingStatus     = "Pending"
+	noUsageStatus     = "NoUsage"
+)
+
+// stuckPod is a pod that holds its requests on a node without running
+type stuckPod struct {
+	node   string
+	pod    *podMetric
+	status string
+	since  time.Time
+}
+
+type listStuckPods struct {
+	Pods   []*listStuckPod `json:"pods"`
+	Totals *listStuckPod   `json:"totals"`
+}
+
+type listStuckPod struct {
+	Node      string `json:"node,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name,omitempty"`
+	Pods      int    `json:"pods,omitempty"`
+	Status    string `json:"status,omitempty"`
+	For       string `json:"for,omitempty"`
+	CPU       string `json:"cpu"`
+	Memory    string `json:"memory"`
+}
+
+// addStuckState sets why a pod is not running and since when, from its
+// deletion timestamp or the reason its first waiting container gives. Init
+// containers are looked at first, since they hold up the rest.
+func (pm *podMetric) addStuckState(pod *corev1.Pod) {
+	if pod.Status.StartTime != nil {
+		pm.started = pod.Status.StartTime.Time
+	}
+	if pod.DeletionTimestamp != nil {
+		pm.stuckStatus, pm.stuckSince = terminatingStatus, pod.DeletionTimestamp.Time
+		return
+	}
+
+	status := ""
+	if pod.Status.Phase == corev1.PodPending {
+		status = pendingStatus
+	}
+	statuses := append([]corev1.ContainerStatus{}, pod.Status.InitContainerStatuses...)
+	statuses = append(statuses, pod.Status.ContainerStatuses...)
+	for _, cs := range statuses {
+		if cs.State.Waiting != nil && cs.State.Waiting.Reason != "" {
+			status = cs.State.Waiting.Reason
+			break
+		}
+	}
+	if status == "" {
+		return
+	}
+	pm.stuckStatus, pm.stuckSince = status, notReadySince(pod)
+}
+
+// notReadySince returns when a pod stopped being Ready, or when it was
+// scheduled or created if it never was
+func notReadySince(pod *corev1.Pod) time.Time {
+	for _, conditionType := range []corev1.PodConditionType{corev1.PodReady, corev1.PodScheduled} {
+		for _, condition := range pod.Status.Conditions {
+			if condition.Type == conditionType && !condition.LastTransitionTime.IsZero() {
+				if conditionType == corev1.PodReady && condition.Status == corev1.ConditionTrue {
+					break
+				}
+				return condition.LastTransitionTime.Time
+			}
+		}
+	}
+	return pod.CreationTimestamp.Time
+}
+
+// buildStuckPods returns the pods that have been assigned to a node for
+// longer than stuckAfter without running: terminating past their grace
+// period, waiting on a container, or, with utilization, started but not
+// reporting any usage
+func buildStuckPods(cm *clusterMetric, sortBy string, stuckAfter time.Duration, withUsage bool) []*stuckPod {
+	observedAt := cm.observedAt
+	if observedAt.IsZero() {
+		observedAt = time.Now()
+	}
+
+	stuck := []*stuckPod{}
+	for _, nm := range cm.getSortedNodeMetrics(sortBy) {
+		for _, pm := range nm.getSortedPodMetrics(sortBy) {
+			status, since := pm.stuckStatus, pm.stuckSince
+			if status == "" && withUsage && !pm.reportsUsage && !pm.started.IsZero() {
+				status, since = noUsageStatus, pm.started
+			}
+			if status == "" || observedAt.Sub(since) < stuckAfter {
+				continue
+			}
+			stuck = append(stuck, &stuckPod{node: nm.name, pod: pm, status: status, since: since})
+		}
+	}
+	return stuck
+}
+
+func printStuckPods(run *reportRun, cm *clusterMetric, opts Options) error {
+	sp := &stuckPodsPrinter{
+		run:        run,
+		pods:       buildStuckPods(cm, opts.SortBy, opts.StuckAfter, opts.ShowUtil),
+		observedAt: cm.observedAt,
+	}
+	return sp.Print(opts.OutputFormat)
+}
+
+type stuckPodsPrinter struct {
+	run        *reportRun
+	pods       []*stuckPod
+	observedAt time.Time
+}
+
+func (sp *stuckPodsPrinter) Print(outputType string) error {
+	switch outputType {
+	case JSONOutput, YAMLOutput:
+		printListOutput(sp.run, StuckPodsReportKind, sp.buildListStuckPods(), outputType)
+	case TableOutput:
+		w := tabwriter.NewWriter(sp.run.output, 0, 8, 2, ' ', 0)
+		sp.printTable(w, "\t ")
+		if err := w.Flush(); err != nil {
+			sp.run.logErrorf("Error writing to table: %s", err)
+		}
+	case CSVOutput:
+		sp.printTable(sp.run.output, ",")
+	case TSVOutput:
+		sp.printTable(sp.run.output, "\t")
+	default:
+		return fmt.Errorf("Called with an unsupported output type: %s", outputType)
+	}
+	return nil
+}
+
+func (sp *stuckPodsPrinter) printTable(w io.Writer, separator string) {
+	_, _ = fmt.Fprintln(w, strings.Join([]string{"NODE", "NAMESPACE", "POD", "STATUS", "FOR", "CPU REQUESTS", "MEMORY REQUESTS"}, separator))
+
+	list := sp.buildListStuckPods()
+	totals := list.Totals
+	_, _ = fmt.Fprintln(w, strings.Join([]string{
+		VoidValue, VoidValue, fmt.Sprintf("%s (%d pods)", VoidValue, totals.Pods), VoidValue, VoidValue, totals.CPU, totals.Memory,
+	}, separator))
+	for _, lp := range list.Pods {
+		_, _ = fmt.Fprintln(w, strings.Join([]string{lp.Node, lp.Namespace, lp.Name, lp.Status, lp.For, lp.CPU, lp.Memory}, separator))
+	}
+}
+
+// buildListStuckPods lists every stuck pod with how long it has been stuck,
+// and the requests they hold in total
+func (sp *stuckPodsPrinter) buildListStuckPods() *listStuckPods {
+	observedAt := sp.observedAt
+	if observedAt.IsZero() {
+		observedAt = time.Now()
+	}
+	cpu := resourceMetric{resourceType: "cpu"}
+	memory := resourceMetric{resourceType: "memory"}
+
+	out := &listStuckPods{Pods: []*listStuckPod{}}
+	for _, s := range sp.pods {
+		cpu.request.Add(s.pod.cpu.request)
+		memory.request.Add(s.pod.memory.request)
+		out.Pods = append(out.Pods, &listStuckPod{
+			Node:      s.node,
+			Namespace: s.pod.namespace,
+			Name:      s.pod.name,
+			Status:    s.status,
+			For:       duration.HumanDuration(observedAt.Sub(s.since)),
+			CPU:       cpu.valueFunction(sp.run)(s.pod.cpu.request),
+			Memory:    memory.valueFunction(sp.run)(s.pod.memory.request),
+		})
+	}
+	out.Totals = &listStuckPod{
+		Pods:   len(sp.pods),
+		CPU:    cpu.valueFunction(sp.run)(cpu.request),
+		Memory: memory.valueFunction(sp.run)(memory.request),
+	}
+	return out
+}
@@ -0,0 +1,110 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestStuckPods(t *testing.T) {
+	run := newTestRun(nil)
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	ago := func(d time.Duration) metav1.Time { return metav1.NewTime(now.Add(-d)) }
+	newPod := func(node, name string, phase corev1.PodPhase, waiting string) *corev1.Pod {
+		p := pod(node, "default", name, nil)
+		p.Spec.Containers = []corev1.Container{{
+			Name: "app",
+			Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{
+				"cpu":    resource.MustParse("250m"),
+				"memory": resource.MustParse("512Mi"),
+			}},
+		}}
+		p.Status.Phase = phase
+		if waiting != "" {
+			p.Status.ContainerStatuses = []corev1.ContainerStatus{{
+				Name:  "app",
+				State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: waiting}},
+			}}
+		}
+		return p
+	}
+
+	creating := newPod("mynode", "creating", corev1.PodPending, "ContainerCreating")
+	creating.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodScheduled, Status: corev1.ConditionTrue, LastTransitionTime: ago(30 * time.Minute)}}
+	fresh := newPod("mynode2", "fresh", corev1.PodPending, "ContainerCreating")
+	fresh.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodScheduled, Status: corev1.ConditionTrue, LastTransitionTime: ago(2 * time.Minute)}}
+	crashing := newPod("mynode", "crashing", corev1.PodRunning, "CrashLoopBackOff")
+	crashing.Status.Conditions = []corev1.PodCondition{
+		{Type: corev1.PodScheduled, Status: corev1.ConditionTrue, LastTransitionTime: ago(3 * time.Hour)},
+		{Type: corev1.PodReady, Status: corev1.ConditionFalse, LastTransitionTime: ago(15 * time.Minute)},
+	}
+	terminating := newPod("mynode", "terminating", corev1.PodRunning, "")
+	deleted := ago(20 * time.Minute)
+	terminating.DeletionTimestamp = &deleted
+	running := newPod("mynode2", "running", corev1.PodRunning, "")
+	started := ago(time.Hour)
+	running.Status.StartTime = &started
+	healthy := newPod("mynode", "mypod", corev1.PodRunning, "")
+	healthy.Status.StartTime = &started
+
+	snap := getTestSnapshot()
+	snap.Pods.Items = []corev1.Pod{*creating, *fresh, *crashing, *terminating, *running, *healthy}
+	cm := buildClusterMetric(snap.Pods, snap.PodMetrics, snap.Nodes, nil)
+	cm.observedAt = now
+
+	statuses := func(stuck []*stuckPod) []string {
+		out := []string{}
+		for _, s := range stuck {
+			out = append(out, s.node+"/"+s.pod.name+" "+s.status)
+		}
+		return out
+	}
+	assert.Equal(t, []string{
+		"mynode/crashing CrashLoopBackOff",
+		"mynode/creating ContainerCreating",
+		"mynode/terminating Terminating",
+	}, statuses(buildStuckPods(&cm, "name", DefaultStuckAfter, false)))
+
+	// With utilization, pods that started but report no usage are stuck too
+	stuck := buildStuckPods(&cm, "name", DefaultStuckAfter, true)
+	assert.Equal(t, []string{
+		"mynode/crashing CrashLoopBackOff",
+		"mynode/creating ContainerCreating",
+		"mynode/terminating Terminating",
+		"mynode2/running NoUsage",
+	}, statuses(stuck))
+
+	var buf bytes.Buffer
+	sp := &stuckPodsPrinter{run: run, pods: stuck, observedAt: now}
+	sp.printTable(&buf, ",")
+	assert.Equal(t, strings.Join([]string{
+		"NODE,NAMESPACE,POD,STATUS,FOR,CPU REQUESTS,MEMORY REQUESTS",
+		"*,*,* (4 pods),*,*,1000m,2048Mi",
+		"mynode,default,crashing,CrashLoopBackOff,15m,250m,512Mi",
+		"mynode,default,creating,ContainerCreating,30m,250m,512Mi",
+		"mynode,default,terminating,Terminating,20m,250m,512Mi",
+		"mynode2,default,running,NoUsage,60m,250m,512Mi",
+	}, "\n")+"\n", buf.String())
+
+	assert.Len(t, buildStuckPods(&cm, "name", time.Hour, true), 1)
+}
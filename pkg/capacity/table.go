@@ -16,86 +16,273 @@ package capacity
 
 import (
 	"fmt"
-	"os"
+	"io"
 	"strings"
 	"text/tabwriter"
 )
 
 type tablePrinter struct {
-	cm   *clusterMetric
-	w    *tabwriter.Writer
-	opts Options
+	run     *reportRun
+	cm      *clusterMetric
+	w       io.Writer
+	opts    Options
+	cluster string
+
+	// selectedColumns are parsed from --columns on first use
+	selectedColumns []tableColumn
 }
 
 func (tp *tablePrinter) hasVisibleColumns() bool {
 	// Check if any data columns will be shown
-	return !tp.opts.HideRequests || !tp.opts.HideLimits || tp.opts.ShowUtil || tp.opts.ShowPodCount || tp.opts.ShowLabels
+	return len(tp.opts.Columns) > 0 || !tp.opts.HideRequests || !tp.opts.HideLimits || tp.opts.ShowUtil || tp.opts.ShowCapacity || tp.opts.ShowVPA || tp.opts.ShowOverhead || tp.opts.ShowOvercommit || tp.opts.ShowResize || tp.opts.ShowRestarts || tp.opts.ShowPodCount || tp.opts.ShowCost || tp.opts.ShowNodeStatus || tp.opts.ShowDisk || tp.opts.ShowTaints || tp.opts.ShowLabels
+}
+
+// checkVisibleColumns fails with the options that add columns when none of
+// them are enabled
+func (tp *tablePrinter) checkVisibleColumns() *fetchError {
+	if tp.hasVisibleColumns() {
+		return nil
+	}
+	return &fetchError{code: 1, messages: []string{
+		"Error: No data columns selected for display. At least one of the following must be enabled:",
+		"- Resource columns (selected with --columns)",
+		"- Resource requests (enabled by default, disabled with --hide-requests)",
+		"- Resource limits (enabled by default, disabled with --hide-limits)",
+		"- Resource utilization (enabled with --util)",
+		"- Node capacity and reserved resources (enabled with --show-capacity)",
+		"- VPA recommendations (enabled with --show-vpa)",
+		"- Pod overhead (enabled with --show-overhead)",
+		"- Overcommit ratios (enabled with --overcommit)",
+		"- Pending resizes (enabled with --show-resize)",
+		"- Restarts and OOMKills (enabled with --show-restarts)",
+		"- Pod count (enabled with --pod-count)",
+		"- Cost (enabled with --cost)",
+		"- Node status (enabled with --show-node-status)",
+		"- Node disk usage (enabled with --show-disk)",
+		"- Node taints (enabled with --show-taints)",
+		"- Node labels (enabled with --show-labels)",
+	}}
 }
 
 type tableLine struct {
-	node           string
-	namespace      string
-	pod            string
-	container      string
-	cpuRequests    string
-	cpuLimits      string
-	cpuUtil        string
-	memoryRequests string
-	memoryLimits   string
-	memoryUtil     string
-	podCount       string
-	labels         string
+	cluster          string
+	node             string
+	namespace        string
+	pod              string
+	container        string
+	cpuCapacity      string
+	cpuAlloc         string
+	cpuReserved      string
+	cpuKube          string
+	cpuSystem        string
+	cpuRequests      string
+	cpuOverhead      string
+	cpuLimits        string
+	cpuUtil          string
+	cpuHistory       string
+	cpuVPA           string
+	cpuOvercommit    string
+	cpuThrottle      string
+	memoryCapacity   string
+	memoryAlloc      string
+	memoryReserved   string
+	memoryKube       string
+	memorySystem     string
+	memoryEviction   string
+	memoryRequests   string
+	memoryOverhead   string
+	memoryLimits     string
+	memoryUtil       string
+	memoryHistory    string
+	memoryVPA        string
+	memoryOvercommit string
+	restarts         string
+	lastOOMKill      string
+	utilAge          string
+	resize           string
+	podCount         string
+	costHourly       string
+	costIdle         string
+	costMonthly      string
+	status           string
+	roles            string
+	age              string
+	nodefs           string
+	imagefs          string
+	disk             string
+	taints           string
+	labels           string
+
+	// columns replace the CPU and memory columns with --columns
+	columns []string
 }
 
 var headerStrings = tableLine{
-	node:           "NODE",
-	namespace:      "NAMESPACE",
-	pod:            "POD",
-	container:      "CONTAINER",
-	cpuRequests:    "CPU REQUESTS",
-	cpuLimits:      "CPU LIMITS",
-	cpuUtil:        "CPU UTIL",
-	memoryRequests: "MEMORY REQUESTS",
-	memoryLimits:   "MEMORY LIMITS",
-	memoryUtil:     "MEMORY UTIL",
-	podCount:       "POD COUNT",
-	labels:         "LABELS",
+	cluster:          "CLUSTER",
+	node:             "NODE",
+	namespace:        "NAMESPACE",
+	pod:              "POD",
+	container:        "CONTAINER",
+	cpuCapacity:      "CPU CAPACITY",
+	cpuAlloc:         "CPU ALLOCATABLE",
+	cpuReserved:      "CPU RESERVED",
+	cpuKube:          "CPU KUBE RESERVED",
+	cpuSystem:        "CPU SYSTEM RESERVED",
+	cpuRequests:      "CPU REQUESTS",
+	cpuOverhead:      "CPU OVERHEAD",
+	cpuLimits:        "CPU LIMITS",
+	cpuUtil:          "CPU UTIL",
+	cpuHistory:       "CPU HISTORY",
+	cpuVPA:           "CPU VPA",
+	cpuOvercommit:    "CPU OVERCOMMIT",
+	cpuThrottle:      "THROTTLE%",
+	memoryCapacity:   "MEMORY CAPACITY",
+	memoryAlloc:      "MEMORY ALLOCATABLE",
+	memoryReserved:   "MEMORY RESERVED",
+	memoryKube:       "MEMORY KUBE RESERVED",
+	memorySystem:     "MEMORY SYSTEM RESERVED",
+	memoryEviction:   "MEMORY EVICTION",
+	memoryRequests:   "MEMORY REQUESTS",
+	memoryOverhead:   "MEMORY OVERHEAD",
+	memoryLimits:     "MEMORY LIMITS",
+	memoryUtil:       "MEMORY UTIL",
+	memoryHistory:    "MEMORY HISTORY",
+	memoryVPA:        "MEMORY VPA",
+	memoryOvercommit: "MEMORY OVERCOMMIT",
+	restarts:         "RESTARTS",
+	lastOOMKill:      "LAST OOMKILL",
+	utilAge:          "UTIL AGE",
+	resize:           "RESIZE",
+	podCount:         "POD COUNT",
+	costHourly:       "COST/HOUR",
+	costIdle:         "IDLE COST/HOUR",
+	costMonthly:      "COST/MONTH",
+	status:           "STATUS",
+	roles:            "ROLES",
+	age:              "AGE",
+	nodefs:           "NODEFS USED",
+	imagefs:          "IMAGEFS USED",
+	disk:             "DISK",
+	taints:           "TAINTS",
+	labels:           "LABELS",
+}
+
+// compactHeaderStrings are the shortened headers used with --compact
+var compactHeaderStrings = func() tableLine {
+	headers := headerStrings
+	headers.cpuCapacity = "CPU CAP"
+	headers.cpuAlloc = "CPU ALLOC"
+	headers.cpuReserved = "CPU RSVD"
+	headers.memoryCapacity = "MEM CAP"
+	headers.memoryAlloc = "MEM ALLOC"
+	headers.memoryReserved = "MEM RSVD"
+	headers.cpuKube = "CPU KUBE"
+	headers.cpuSystem = "CPU SYS"
+	headers.memoryKube = "MEM KUBE"
+	headers.memorySystem = "MEM SYS"
+	headers.memoryEviction = "MEM EVICT"
+	headers.cpuRequests = "CPU REQ"
+	headers.cpuLimits = "CPU LIM"
+	headers.cpuOverhead = "CPU OVH"
+	headers.memoryRequests = "MEM REQ"
+	headers.memoryOverhead = "MEM OVH"
+	headers.memoryLimits = "MEM LIM"
+	headers.memoryUtil = "MEM UTIL"
+	headers.memoryHistory = "MEM HISTORY"
+	headers.nodefs = "NODEFS"
+	headers.imagefs = "IMAGEFS"
+	return headers
+}()
+
+func (tp *tablePrinter) headers() *tableLine {
+	if tp.opts.Compact {
+		return &compactHeaderStrings
+	}
+	if len(tp.columns()) > 0 {
+		headers := headerStrings
+		for _, column := range tp.columns() {
+			headers.columns = append(headers.columns, column.header())
+		}
+		return &headers
+	}
+	return &headerStrings
 }
 
 func (tp *tablePrinter) Print() {
-	tp.w.Init(os.Stdout, 0, 8, 2, ' ', 0)
-	sortedNodeMetrics := tp.cm.getSortedNodeMetrics(tp.opts.SortBy)
+	w := tabwriter.NewWriter(tp.run.output, 0, 8, 2, ' ', 0)
+	tp.w = w
 
-	tp.printLine(&headerStrings)
+	tp.printLine(tp.headers())
+	tp.printClusterRows()
 
-	if len(sortedNodeMetrics) > 1 {
-		tp.printClusterLine()
+	err := w.Flush()
+	if err != nil {
+		tp.run.logErrorf("Error writing to table: %s", err)
 	}
+}
 
-	for _, nm := range sortedNodeMetrics {
+// PrintFleet prints fleet-wide totals followed by the rows for each
+// cluster, with a leading CLUSTER column
+func (tp *tablePrinter) PrintFleet(fm *fleetMetric) {
+	w := tabwriter.NewWriter(tp.run.output, 0, 8, 2, ' ', 0)
+	tp.w = w
+
+	tp.cluster = VoidValue
+	tp.printLine(tp.headers())
+	tp.cm = fm.total
+	tp.printClusterLine()
+
+	for _, cm := range fm.clusters {
+		tp.cluster = cm.name
+		tp.cm = cm
 		if tp.opts.ShowPods || tp.opts.ShowContainers {
 			tp.printLine(&tableLine{})
 		}
+		tp.printClusterRows()
+	}
+
+	err := w.Flush()
+	if err != nil {
+		tp.run.logErrorf("Error writing to table: %s", err)
+	}
+}
 
-		tp.printNodeLine(nm.name, nm)
+func (tp *tablePrinter) printClusterRows() {
+	sortedNodeMetrics := tp.cm.getSortedNodeMetrics(tp.opts.SortBy)
 
-		if tp.opts.ShowPods || tp.opts.ShowContainers {
-			podMetrics := nm.getSortedPodMetrics(tp.opts.SortBy)
-			for _, pm := range podMetrics {
-				tp.printPodLine(nm.name, pm)
-				if tp.opts.ShowContainers {
-					containerMetrics := pm.getSortedContainerMetrics(tp.opts.SortBy)
-					for _, containerMetric := range containerMetrics {
-						tp.printContainerLine(nm.name, pm, containerMetric)
-					}
-				}
-			}
+	if len(sortedNodeMetrics) > 1 || tp.cluster != "" {
+		tp.printClusterLine()
+		if tp.opts.ShowTaints {
+			tp.printTaintedLine()
 		}
 	}
 
-	err := tp.w.Flush()
-	if err != nil {
-		fmt.Printf("Error writing to table: %s", err)
+	for _, nm := range sortedNodeMetrics {
+		tp.printNodeRows(nm)
+	}
+	tp.printVirtualRows()
+}
+
+// printNodeRows prints a node followed by its pods and containers when they
+// are shown
+func (tp *tablePrinter) printNodeRows(nm *nodeMetric) {
+	if tp.opts.ShowPods || tp.opts.ShowContainers {
+		tp.printLine(&tableLine{})
+	}
+
+	tp.printNodeLine(nm.tableName(), nm)
+
+	if tp.opts.ShowPods || tp.opts.ShowContainers {
+		podMetrics := nm.getSortedPodMetrics(tp.opts.SortBy)
+		for _, pm := range podMetrics {
+			tp.printPodLine(nm.name, pm)
+			if tp.opts.ShowContainers {
+				containerMetrics := pm.getSortedContainerMetrics(tp.opts.SortBy)
+				for _, containerMetric := range containerMetrics {
+					tp.printContainerLine(nm.name, pm, containerMetric)
+				}
+			}
+		}
 	}
 }
 
@@ -105,7 +292,17 @@ func (tp *tablePrinter) printLine(tl *tableLine) {
 }
 
 func (tp *tablePrinter) getLineItems(tl *tableLine) []string {
-	lineItems := []string{tl.node}
+	lineItems := []string{}
+
+	if tp.cluster != "" {
+		if tl.cluster != "" || tl.node == "" {
+			lineItems = append(lineItems, tl.cluster)
+		} else {
+			lineItems = append(lineItems, tp.cluster)
+		}
+	}
+
+	lineItems = append(lineItems, tl.node)
 
 	if tp.opts.ShowContainers || tp.opts.ShowPods {
 		if tp.opts.Namespace == "" {
@@ -118,9 +315,72 @@ func (tp *tablePrinter) getLineItems(tl *tableLine) []string {
 		lineItems = append(lineItems, tl.container)
 	}
 
+	if len(tp.columns()) > 0 {
+		// Blank lines between nodes have no columns
+		columns := make([]string, len(tp.columns()))
+		copy(columns, tl.columns)
+		lineItems = append(lineItems, columns...)
+	} else {
+		lineItems = tp.appendResourceItems(lineItems, tl)
+	}
+
+	if tp.opts.ShowRestarts {
+		lineItems = append(lineItems, tl.restarts, tl.lastOOMKill)
+	}
+
+	if tp.opts.ShowMetricsAge {
+		lineItems = append(lineItems, tl.utilAge)
+	}
+
+	if tp.opts.ShowResize {
+		lineItems = append(lineItems, tl.resize)
+	}
+
+	if tp.opts.ShowPodCount {
+		lineItems = append(lineItems, tl.podCount)
+	}
+
+	if tp.opts.ShowCost {
+		lineItems = append(lineItems, tl.costHourly, tl.costIdle, tl.costMonthly)
+	}
+
+	if tp.opts.ShowNodeStatus {
+		lineItems = append(lineItems, tl.status, tl.roles, tl.age)
+	}
+
+	if tp.opts.ShowDisk {
+		lineItems = append(lineItems, tl.nodefs, tl.imagefs, tl.disk)
+	}
+
+	if tp.opts.ShowTaints {
+		lineItems = append(lineItems, tl.taints)
+	}
+
+	if tp.opts.ShowLabels {
+		lineItems = append(lineItems, tl.labels)
+	}
+
+	return lineItems
+}
+
+// appendResourceItems appends the default CPU and memory columns
+func (tp *tablePrinter) appendResourceItems(lineItems []string, tl *tableLine) []string {
+	if tp.opts.ShowCapacity {
+		lineItems = append(lineItems, tl.cpuCapacity, tl.cpuAlloc, tl.cpuReserved)
+	}
+
+	if tp.opts.ShowReservedBreakdown {
+		lineItems = append(lineItems, tl.cpuKube, tl.cpuSystem)
+	}
+
 	if !tp.opts.HideRequests {
 		lineItems = append(lineItems, tl.cpuRequests)
 	}
+
+	if tp.opts.ShowOverhead {
+		lineItems = append(lineItems, tl.cpuOverhead)
+	}
+
 	if !tp.opts.HideLimits {
 		lineItems = append(lineItems, tl.cpuLimits)
 	}
@@ -129,9 +389,38 @@ func (tp *tablePrinter) getLineItems(tl *tableLine) []string {
 		lineItems = append(lineItems, tl.cpuUtil)
 	}
 
+	if tp.opts.ShowSparkline {
+		lineItems = append(lineItems, tl.cpuHistory)
+	}
+
+	if tp.opts.ShowVPA {
+		lineItems = append(lineItems, tl.cpuVPA)
+	}
+
+	if tp.opts.ShowOvercommit {
+		lineItems = append(lineItems, tl.cpuOvercommit)
+	}
+
+	if tp.opts.ShowThrottling {
+		lineItems = append(lineItems, tl.cpuThrottle)
+	}
+
+	if tp.opts.ShowCapacity {
+		lineItems = append(lineItems, tl.memoryCapacity, tl.memoryAlloc, tl.memoryReserved)
+	}
+
+	if tp.opts.ShowReservedBreakdown {
+		lineItems = append(lineItems, tl.memoryKube, tl.memorySystem, tl.memoryEviction)
+	}
+
 	if !tp.opts.HideRequests {
 		lineItems = append(lineItems, tl.memoryRequests)
 	}
+
+	if tp.opts.ShowOverhead {
+		lineItems = append(lineItems, tl.memoryOverhead)
+	}
+
 	if !tp.opts.HideLimits {
 		lineItems = append(lineItems, tl.memoryLimits)
 	}
@@ -140,48 +429,136 @@ func (tp *tablePrinter) getLineItems(tl *tableLine) []string {
 		lineItems = append(lineItems, tl.memoryUtil)
 	}
 
-	if tp.opts.ShowPodCount {
-		lineItems = append(lineItems, tl.podCount)
+	if tp.opts.ShowSparkline {
+		lineItems = append(lineItems, tl.memoryHistory)
 	}
 
-	if tp.opts.ShowLabels {
-		lineItems = append(lineItems, tl.labels)
+	if tp.opts.ShowVPA {
+		lineItems = append(lineItems, tl.memoryVPA)
+	}
+
+	if tp.opts.ShowOvercommit {
+		lineItems = append(lineItems, tl.memoryOvercommit)
 	}
 
 	return lineItems
 }
 
 func (tp *tablePrinter) printClusterLine() {
+	tp.printTotalsLine(VoidValue, tp.cm, VoidValue)
+}
+
+// printTaintedLine prints the totals of nodes with NoSchedule or NoExecute
+// taints
+func (tp *tablePrinter) printTaintedLine() {
+	if tainted := buildTaintedClusterMetric(tp.cm); tainted != nil {
+		tp.printTotalsLine(taintedTotalsName, tainted, tainted.taintedNodesString())
+	}
+}
+
+func (tp *tablePrinter) printTotalsLine(name string, cm *clusterMetric, taints string) {
+	nodefs, imagefs := cm.disk.filesystems()
 	tp.printLine(&tableLine{
-		node:           VoidValue,
-		namespace:      VoidValue,
-		pod:            VoidValue,
-		container:      VoidValue,
-		cpuRequests:    tp.cm.cpu.requestString(tp.opts.AvailableFormat),
-		cpuLimits:      tp.cm.cpu.limitString(tp.opts.AvailableFormat),
-		cpuUtil:        tp.cm.cpu.utilString(tp.opts.AvailableFormat, tp.opts.UtilPercent),
-		memoryRequests: tp.cm.memory.requestString(tp.opts.AvailableFormat),
-		memoryLimits:   tp.cm.memory.limitString(tp.opts.AvailableFormat),
-		memoryUtil:     tp.cm.memory.utilString(tp.opts.AvailableFormat, tp.opts.UtilPercent),
-		podCount:       tp.cm.podCount.podCountString(),
-		labels:         VoidValue,
+		node:             name,
+		namespace:        VoidValue,
+		pod:              VoidValue,
+		container:        VoidValue,
+		cpuCapacity:      cm.cpu.nodeCapacityString(tp.run),
+		cpuAlloc:         cm.cpu.allocatableString(tp.run),
+		cpuReserved:      cm.cpu.reservedString(tp.run),
+		cpuKube:          cm.cpu.kubeReservedString(tp.run),
+		cpuSystem:        cm.cpu.systemReservedString(tp.run),
+		cpuRequests:      tp.requestString(cm.cpu),
+		cpuOverhead:      cm.cpu.overheadString(tp.run),
+		cpuLimits:        tp.limitString(cm.cpu),
+		cpuUtil:          tp.utilString(cm.cpu),
+		cpuHistory:       cm.cpu.sparklineString(),
+		cpuVPA:           VoidValue,
+		cpuOvercommit:    cm.cpu.overcommitString(),
+		cpuThrottle:      VoidValue,
+		memoryCapacity:   cm.memory.nodeCapacityString(tp.run),
+		memoryAlloc:      cm.memory.allocatableString(tp.run),
+		memoryReserved:   cm.memory.reservedString(tp.run),
+		memoryKube:       cm.memory.kubeReservedString(tp.run),
+		memorySystem:     cm.memory.systemReservedString(tp.run),
+		memoryEviction:   cm.memory.evictionThresholdString(tp.run),
+		memoryRequests:   tp.requestString(cm.memory),
+		memoryOverhead:   cm.memory.overheadString(tp.run),
+		memoryLimits:     tp.limitString(cm.memory),
+		memoryUtil:       tp.utilString(cm.memory),
+		memoryHistory:    cm.memory.sparklineString(),
+		memoryVPA:        VoidValue,
+		memoryOvercommit: cm.memory.overcommitString(),
+		restarts:         VoidValue,
+		lastOOMKill:      VoidValue,
+		utilAge:          cm.oldestUsage().ageString(cm.observedAt),
+		resize:           pendingResizesString(cm.pendingResizes),
+		podCount:         cm.podCount.podCountString(),
+		costHourly:       cm.cost.hourlyString(),
+		costIdle:         cm.cost.idleString(),
+		costMonthly:      cm.cost.monthlyString(),
+		status:           cm.nodeStatus.statusString(),
+		roles:            VoidValue,
+		age:              VoidValue,
+		nodefs:           nodefs.usedString(tp.run),
+		imagefs:          imagefs.usedString(tp.run),
+		disk:             cm.disk.statusString(),
+		taints:           taints,
+		labels:           VoidValue,
+		columns:          tp.columnValues(cm.columnResources, true),
 	})
 }
 
 func (tp *tablePrinter) printNodeLine(nodeName string, nm *nodeMetric) {
+	nodefs, imagefs := nm.disk.filesystems()
 	tp.printLine(&tableLine{
-		node:           nodeName,
-		namespace:      VoidValue,
-		pod:            VoidValue,
-		container:      VoidValue,
-		cpuRequests:    nm.cpu.requestString(tp.opts.AvailableFormat),
-		cpuLimits:      nm.cpu.limitString(tp.opts.AvailableFormat),
-		cpuUtil:        nm.cpu.utilString(tp.opts.AvailableFormat, tp.opts.UtilPercent),
-		memoryRequests: nm.memory.requestString(tp.opts.AvailableFormat),
-		memoryLimits:   nm.memory.limitString(tp.opts.AvailableFormat),
-		memoryUtil:     nm.memory.utilString(tp.opts.AvailableFormat, tp.opts.UtilPercent),
-		podCount:       nm.podCount.podCountString(),
-		labels:         nodeLabelsString(nm.labels),
+		node:             nodeName,
+		namespace:        VoidValue,
+		pod:              VoidValue,
+		container:        VoidValue,
+		cpuCapacity:      nm.cpu.nodeCapacityString(tp.run),
+		cpuAlloc:         nm.cpu.allocatableString(tp.run),
+		cpuReserved:      nm.cpu.reservedString(tp.run),
+		cpuKube:          nm.cpu.kubeReservedString(tp.run),
+		cpuSystem:        nm.cpu.systemReservedString(tp.run),
+		cpuRequests:      tp.requestString(nm.cpu),
+		cpuOverhead:      nm.cpu.overheadString(tp.run),
+		cpuLimits:        tp.limitString(nm.cpu),
+		cpuUtil:          tp.utilString(nm.cpu),
+		cpuHistory:       nm.cpu.sparklineString(),
+		cpuVPA:           VoidValue,
+		cpuOvercommit:    nm.cpu.overcommitString(),
+		cpuThrottle:      VoidValue,
+		memoryCapacity:   nm.memory.nodeCapacityString(tp.run),
+		memoryAlloc:      nm.memory.allocatableString(tp.run),
+		memoryReserved:   nm.memory.reservedString(tp.run),
+		memoryKube:       nm.memory.kubeReservedString(tp.run),
+		memorySystem:     nm.memory.systemReservedString(tp.run),
+		memoryEviction:   nm.memory.evictionThresholdString(tp.run),
+		memoryRequests:   tp.requestString(nm.memory),
+		memoryOverhead:   nm.memory.overheadString(tp.run),
+		memoryLimits:     tp.limitString(nm.memory),
+		memoryUtil:       tp.utilString(nm.memory),
+		memoryHistory:    nm.memory.sparklineString(),
+		memoryVPA:        VoidValue,
+		memoryOvercommit: nm.memory.overcommitString(),
+		restarts:         VoidValue,
+		lastOOMKill:      VoidValue,
+		utilAge:          nm.usage.ageString(tp.cm.observedAt),
+		resize:           pendingResizesString(nm.pendingResizes),
+		podCount:         nm.podCount.podCountString(),
+		costHourly:       nm.cost.hourlyString(),
+		costIdle:         nm.cost.idleString(),
+		costMonthly:      nm.cost.monthlyString(),
+		status:           nm.statusString(),
+		roles:            nm.rolesString(),
+		age:              nm.ageString(tp.cm.observedAt),
+		nodefs:           nodefs.usedString(tp.run),
+		imagefs:          imagefs.usedString(tp.run),
+		disk:             nm.disk.statusString(),
+		taints:           nm.taintsString(),
+		labels:           nodeLabelsString(nm.labels),
+		columns:          tp.columnValues(nm.columnResources, true),
 	})
 }
 
@@ -191,12 +568,23 @@ func (tp *tablePrinter) printPodLine(nodeName string, pm *podMetric) {
 		namespace:      pm.namespace,
 		pod:            pm.name,
 		container:      VoidValue,
-		cpuRequests:    pm.cpu.requestString(tp.opts.AvailableFormat),
-		cpuLimits:      pm.cpu.limitString(tp.opts.AvailableFormat),
-		cpuUtil:        pm.cpu.utilString(tp.opts.AvailableFormat, tp.opts.UtilPercent),
-		memoryRequests: pm.memory.requestString(tp.opts.AvailableFormat),
-		memoryLimits:   pm.memory.limitString(tp.opts.AvailableFormat),
-		memoryUtil:     pm.memory.utilString(tp.opts.AvailableFormat, tp.opts.UtilPercent),
+		cpuRequests:    tp.requestString(pm.cpu),
+		cpuOverhead:    pm.cpu.overheadString(tp.run),
+		cpuLimits:      tp.limitString(pm.cpu),
+		cpuUtil:        tp.utilString(pm.cpu),
+		cpuHistory:     pm.cpu.sparklineString(),
+		cpuVPA:         VoidValue,
+		cpuThrottle:    throttleString(pm.cpuThrottled),
+		memoryRequests: tp.requestString(pm.memory),
+		memoryOverhead: pm.memory.overheadString(tp.run),
+		memoryLimits:   tp.limitString(pm.memory),
+		memoryUtil:     tp.utilString(pm.memory),
+		memoryHistory:  pm.memory.sparklineString(),
+		memoryVPA:      VoidValue,
+		restarts:       restartsString(pm.restarts),
+		lastOOMKill:    oomKillString(pm.lastOOMKill, tp.cm.observedAt),
+		resize:         resizeString(pm.resize),
+		columns:        tp.columnValues(pm.columnResources, false),
 	})
 }
 
@@ -205,12 +593,44 @@ func (tp *tablePrinter) printContainerLine(nodeName string, pm *podMetric, cm *c
 		node:           nodeName,
 		namespace:      pm.namespace,
 		pod:            pm.name,
-		container:      cm.name,
-		cpuRequests:    cm.cpu.requestString(tp.opts.AvailableFormat),
-		cpuLimits:      cm.cpu.limitString(tp.opts.AvailableFormat),
-		cpuUtil:        cm.cpu.utilString(tp.opts.AvailableFormat, tp.opts.UtilPercent),
-		memoryRequests: cm.memory.requestString(tp.opts.AvailableFormat),
-		memoryLimits:   cm.memory.limitString(tp.opts.AvailableFormat),
-		memoryUtil:     cm.memory.utilString(tp.opts.AvailableFormat, tp.opts.UtilPercent),
+		container:      cm.displayName(),
+		cpuRequests:    tp.requestString(cm.cpu),
+		cpuOverhead:    VoidValue,
+		cpuLimits:      tp.limitString(cm.cpu),
+		cpuUtil:        tp.utilString(cm.cpu),
+		cpuHistory:     cm.cpu.sparklineString(),
+		cpuVPA:         cm.cpu.vpaString(tp.run),
+		cpuThrottle:    throttleString(cm.cpuThrottled),
+		memoryRequests: tp.requestString(cm.memory),
+		memoryOverhead: VoidValue,
+		memoryLimits:   tp.limitString(cm.memory),
+		memoryUtil:     tp.utilString(cm.memory),
+		memoryHistory:  cm.memory.sparklineString(),
+		memoryVPA:      cm.memory.vpaString(tp.run),
+		restarts:       restartsString(cm.restarts),
+		lastOOMKill:    oomKillString(cm.lastOOMKill, tp.cm.observedAt),
+		resize:         resizeString(cm.resize),
+		columns:        tp.columnValues(cm.columnResources, false),
 	})
 }
+
+func (tp *tablePrinter) requestString(rm *resourceMetric) string {
+	if tp.opts.Compact {
+		return percentString(tp.run, rm.request, rm.allocatable)
+	}
+	return rm.requestString(tp.run, tp.opts.AvailableFormat)
+}
+
+func (tp *tablePrinter) limitString(rm *resourceMetric) string {
+	if tp.opts.Compact {
+		return percentString(tp.run, rm.limit, rm.allocatable)
+	}
+	return rm.limitString(tp.run, tp.opts.AvailableFormat)
+}
+
+func (tp *tablePrinter) utilString(rm *resourceMetric) string {
+	if tp.opts.Compact {
+		return percentString(tp.run, rm.utilization, rm.utilBase(tp.opts.UtilPercent))
+	}
+	return rm.utilString(tp.run, tp.opts.AvailableFormat, tp.opts.UtilPercent)
+}
@@ -21,7 +21,9 @@ import (
 )
 
 func TestGetLineItems(t *testing.T) {
+	run := newTestRun(nil)
 	tpNone := &tablePrinter{
+		run: run,
 		opts: Options{
 			ShowPods:       false,
 			ShowUtil:       false,
@@ -32,6 +34,7 @@ func TestGetLineItems(t *testing.T) {
 	}
 
 	tpSome := &tablePrinter{
+		run: run,
 		opts: Options{
 			ShowPods:       false,
 			ShowUtil:       false,
@@ -43,6 +46,7 @@ func TestGetLineItems(t *testing.T) {
 	}
 
 	tpAll := &tablePrinter{
+		run: run,
 		opts: Options{
 			ShowPods:       true,
 			ShowUtil:       true,
@@ -53,19 +57,29 @@ func TestGetLineItems(t *testing.T) {
 		},
 	}
 
+	tpOvercommit := &tablePrinter{
+		run: run,
+		opts: Options{
+			ShowOvercommit: true,
+			Namespace:      "example",
+		},
+	}
+
 	tl := &tableLine{
-		node:           "example-node-1",
-		namespace:      "example-namespace",
-		pod:            "nginx-fsde",
-		container:      "nginx",
-		cpuRequests:    "100m",
-		cpuLimits:      "200m",
-		cpuUtil:        "14m",
-		memoryRequests: "1000Mi",
-		memoryLimits:   "2000Mi",
-		memoryUtil:     "326Mi",
-		podCount:       "1/110",
-		labels:         "zone=example-zone-1",
+		node:             "example-node-1",
+		namespace:        "example-namespace",
+		pod:              "nginx-fsde",
+		container:        "nginx",
+		cpuRequests:      "100m",
+		cpuLimits:        "200m",
+		cpuUtil:          "14m",
+		memoryRequests:   "1000Mi",
+		memoryLimits:     "2000Mi",
+		memoryUtil:       "326Mi",
+		cpuOvercommit:    "2.00x",
+		memoryOvercommit: "2.00x",
+		podCount:         "1/110",
+		labels:           "zone=example-zone-1",
 	}
 
 	var testCases = []struct {
@@ -117,6 +131,19 @@ func TestGetLineItems(t *testing.T) {
 				"1/110",
 				"zone=example-zone-1",
 			},
+		}, {
+			name: "overcommit",
+			tp:   tpOvercommit,
+			tl:   tl,
+			expected: []string{
+				"example-node-1",
+				"100m",
+				"200m",
+				"2.00x",
+				"1000Mi",
+				"2000Mi",
+				"2.00x",
+			},
 		},
 	}
 
@@ -127,3 +154,19 @@ func TestGetLineItems(t *testing.T) {
 		})
 	}
 }
+
+func TestCompactStrings(t *testing.T) {
+	run := newTestRun(nil)
+	snap := getTestSnapshot()
+	cm := buildClusterMetric(snap.Pods, nil, snap.Nodes, nil)
+	nm := cm.nodeMetrics["mynode"]
+
+	tp := &tablePrinter{run: run, cm: &cm, opts: Options{Compact: true}}
+	assert.Equal(t, "10%", tp.requestString(nm.cpu))
+	assert.Equal(t, "3%", tp.requestString(nm.memory))
+	assert.Equal(t, []string{"NODE", "CPU REQ", "CPU LIM", "MEM REQ", "MEM LIM"}, tp.getLineItems(tp.headers()))
+
+	tp.opts.Compact = false
+	assert.Equal(t, "100m (10%)", tp.requestString(nm.cpu))
+	assert.Equal(t, &headerStrings, tp.headers())
+}
@@ -0,0 +1,109 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// taintedTotalsName labels the totals of nodes with NoSchedule or NoExecute
+// taints
+const taintedTotalsName = "* (tainted)"
+
+type listTaintedCapacity struct {
+	Nodes  int64               `json:"nodes"`
+	CPU    *listResourceOutput `json:"cpu"`
+	Memory *listResourceOutput `json:"memory"`
+}
+
+// isSchedulingTaint returns true for taints that keep pods without a
+// matching toleration off a node, as opposed to PreferNoSchedule
+func isSchedulingTaint(taint corev1.Taint) bool {
+	return taint.Effect == corev1.TaintEffectNoSchedule || taint.Effect == corev1.TaintEffectNoExecute
+}
+
+func (nm *nodeMetric) hasSchedulingTaint() bool {
+	for _, taint := range nm.taints {
+		if isSchedulingTaint(taint) {
+			return true
+		}
+	}
+	return false
+}
+
+// taintsString returns the taints of a node, example:
+// "nvidia.com/gpu=true:NoSchedule"
+func (nm *nodeMetric) taintsString() string {
+	if len(nm.taints) == 0 {
+		return VoidValue
+	}
+	return strings.Join(nm.listTaints(), ",")
+}
+
+func (nm *nodeMetric) listTaints() []string {
+	taints := []string{}
+	for i := range nm.taints {
+		taints = append(taints, nm.taints[i].ToString())
+	}
+	return taints
+}
+
+// buildTaintedClusterMetric sums the capacity of nodes with NoSchedule or
+// NoExecute taints, returning nil when no nodes are tainted
+func buildTaintedClusterMetric(cm *clusterMetric) *clusterMetric {
+	tainted := &clusterMetric{
+		name:        cm.name,
+		cpu:         &resourceMetric{resourceType: "cpu"},
+		memory:      &resourceMetric{resourceType: "memory"},
+		nodeMetrics: map[string]*nodeMetric{},
+		podCount:    &podCount{},
+		nodeStatus:  &nodeStatusCount{},
+		observedAt:  cm.observedAt,
+	}
+	for name, nm := range cm.nodeMetrics {
+		if !nm.hasSchedulingTaint() {
+			continue
+		}
+		tainted.nodeMetrics[name] = nm
+		tainted.cpu.addMetric(nm.cpu)
+		tainted.memory.addMetric(nm.memory)
+		tainted.podCount.current += nm.podCount.current
+		tainted.podCount.allocatable += nm.podCount.allocatable
+		tainted.pendingResizes += nm.pendingResizes
+		tainted.nodeStatus.addNode(nm)
+		if nm.cost != nil {
+			if tainted.cost == nil {
+				tainted.cost = &nodeCost{}
+			}
+			tainted.cost.add(nm.cost)
+		}
+	}
+	if len(tainted.nodeMetrics) == 0 {
+		return nil
+	}
+	return tainted
+}
+
+// taintedNodesString returns the number of tainted nodes shown in the
+// TAINTS column of the tainted totals
+func (cm *clusterMetric) taintedNodesString() string {
+	if len(cm.nodeMetrics) == 1 {
+		return "1 node"
+	}
+	return fmt.Sprintf("%d nodes", len(cm.nodeMetrics))
+}
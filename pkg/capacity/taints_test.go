@@ -0,0 +1,68 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestTaintedCapacity(t *testing.T) {
+	allocatable := corev1.ResourceList{
+		"cpu":    resource.MustParse("4"),
+		"memory": resource.MustParse("16Gi"),
+		"pods":   resource.MustParse("110"),
+	}
+
+	gpu := nodeWithTaint("gpu", map[string]string{}, "nvidia.com/gpu", "true")
+	preferred := node("preferred", map[string]string{}, false)
+	preferred.Spec.Taints = []corev1.Taint{{Key: "spot", Effect: corev1.TaintEffectPreferNoSchedule}}
+	plain := node("plain", map[string]string{}, false)
+	for _, n := range []*corev1.Node{gpu, preferred, plain} {
+		n.Status.Allocatable = allocatable
+	}
+
+	training := pod("gpu", "ml", "training", map[string]string{})
+	training.Spec.Containers = []corev1.Container{{
+		Name: "app",
+		Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{
+			"cpu":    resource.MustParse("1"),
+			"memory": resource.MustParse("4Gi"),
+		}},
+	}}
+
+	cm := buildClusterMetric(
+		&corev1.PodList{Items: []corev1.Pod{*training}}, nil,
+		&corev1.NodeList{Items: []corev1.Node{*gpu, *preferred, *plain}}, nil)
+
+	assert.Equal(t, "nvidia.com/gpu=true:NoSchedule", cm.nodeMetrics["gpu"].taintsString())
+	assert.Equal(t, "spot:PreferNoSchedule", cm.nodeMetrics["preferred"].taintsString())
+	assert.Equal(t, VoidValue, cm.nodeMetrics["plain"].taintsString())
+
+	tainted := buildTaintedClusterMetric(&cm)
+	require.NotNil(t, tainted)
+	assert.Equal(t, "1 node", tainted.taintedNodesString())
+	assert.Equal(t, "4", tainted.cpu.allocatable.String())
+	assert.Equal(t, "1", tainted.cpu.request.String())
+	assert.Equal(t, "16Gi", tainted.memory.allocatable.String())
+	assert.Equal(t, "1/110", tainted.podCount.podCountString())
+
+	delete(cm.nodeMetrics, "gpu")
+	assert.Nil(t, buildTaintedClusterMetric(&cm))
+}
@@ -0,0 +1,239 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// thresholdExitCode is returned when a --fail-on threshold is breached
+const thresholdExitCode = 5
+
+// thresholdOperators are checked in order so ">=" is not read as ">"
+var thresholdOperators = []string{">=", "<=", ">", "<"}
+
+// threshold is a --fail-on assertion like "cpu.requests>90%", checked
+// against the cluster totals, or against each node with a "node." prefix
+type threshold struct {
+	expression string
+	perNode    bool
+	resource   string
+	metric     string
+	operator   string
+	percent    float64
+	quantity   *resource.Quantity
+}
+
+// ValidateFailOn returns an error for --fail-on and --notify-on thresholds
+// that can not be parsed or that need utilization without --util
+func ValidateFailOn(opts Options) error {
+	for _, flag := range []struct {
+		name        string
+		expressions []string
+	}{
+		{"fail-on", opts.FailOn},
+		{"notify-on", opts.NotifyOn},
+	} {
+		for _, expression := range flag.expressions {
+			t, err := parseThreshold(expression)
+			if err != nil {
+				return fmt.Errorf("--%s: %w", flag.name, err)
+			}
+			if t.metric == "util" && !opts.ShowUtil {
+				return fmt.Errorf("--%s %s requires --util", flag.name, expression)
+			}
+		}
+	}
+	return nil
+}
+
+// parseThreshold parses expressions in the form
+// [node.]<cpu|memory>.<requests|limits|util><op><value>, where value is a
+// percentage of allocatable or a quantity like 48 or 256Gi
+func parseThreshold(expression string) (*threshold, error) {
+	t := &threshold{expression: expression}
+	invalid := fmt.Errorf("invalid threshold %q, expected a threshold like cpu.requests>90%% or node.memory.limits>=120%%", expression)
+
+	var field, value string
+	for _, operator := range thresholdOperators {
+		if before, after, found := strings.Cut(expression, operator); found {
+			field, value, t.operator = before, after, operator
+			break
+		}
+	}
+	if t.operator == "" {
+		return nil, invalid
+	}
+
+	field, t.perNode = strings.CutPrefix(field, "node.")
+	resourceName, metric, found := strings.Cut(field, ".")
+	if !found {
+		return nil, invalid
+	}
+	switch resourceName {
+	case "cpu":
+		t.resource = "cpu"
+	case "mem", "memory":
+		t.resource = "memory"
+	default:
+		return nil, invalid
+	}
+	switch metric {
+	case "requests", "limits", "util":
+		t.metric = metric
+	default:
+		return nil, invalid
+	}
+
+	if percent, found := strings.CutSuffix(value, "%"); found {
+		p, err := strconv.ParseFloat(percent, 64)
+		if err != nil {
+			return nil, invalid
+		}
+		t.percent = p
+		return t, nil
+	}
+	q, err := resource.ParseQuantity(value)
+	if err != nil {
+		return nil, invalid
+	}
+	t.quantity = &q
+	return t, nil
+}
+
+// check returns a description of the breach if the resource metric breaches
+// the threshold, or an empty string otherwise
+func (t *threshold) check(run *reportRun, rm *resourceMetric, utilPercent string) string {
+	actual, base := rm.request, rm.allocatable
+	switch t.metric {
+	case "limits":
+		actual = rm.limit
+	case "util":
+		actual, base = rm.utilization, rm.utilBase(utilPercent)
+	}
+
+	if t.quantity != nil {
+		if !compareThreshold(float64(actual.MilliValue()), t.operator, float64(t.quantity.MilliValue())) {
+			return ""
+		}
+		return run.formatQuantity(t.resource, actual)
+	}
+
+	if base.MilliValue() == 0 {
+		return ""
+	}
+	percent := float64(actual.MilliValue()) / float64(base.MilliValue()) * 100
+	if !compareThreshold(percent, t.operator, t.percent) {
+		return ""
+	}
+	return fmt.Sprintf("%.1f%%", percent)
+}
+
+func compareThreshold(actual float64, operator string, limit float64) bool {
+	switch operator {
+	case ">=":
+		return actual >= limit
+	case "<=":
+		return actual <= limit
+	case ">":
+		return actual > limit
+	default:
+		return actual < limit
+	}
+}
+
+func (t *threshold) resourceMetric(cpu, memory *resourceMetric) *resourceMetric {
+	if t.resource == "cpu" {
+		return cpu
+	}
+	return memory
+}
+
+// thresholdBreach is a threshold breached by the cluster totals, or by a
+// node when node is set
+type thresholdBreach struct {
+	cluster    string
+	node       string
+	field      string
+	actual     string
+	expression string
+}
+
+func (b thresholdBreach) String() string {
+	prefix := ""
+	if b.cluster != "" {
+		prefix = b.cluster + ": "
+	}
+	if b.node == "" {
+		return fmt.Sprintf("%scluster %s is %s, breaching %s", prefix, b.field, b.actual, b.expression)
+	}
+	return fmt.Sprintf("%snode %s %s is %s, breaching %s", prefix, b.node, b.field, b.actual, b.expression)
+}
+
+// checkThresholds returns a message for each --fail-on threshold breached
+// by the cluster totals or any of its nodes
+func checkThresholds(run *reportRun, cm *clusterMetric, opts Options) []string {
+	breaches := []string{}
+	for _, b := range findBreaches(run, cm, opts.FailOn, opts.UtilPercent) {
+		breaches = append(breaches, b.String())
+	}
+	return breaches
+}
+
+// findBreaches returns each of the thresholds breached by the cluster totals
+// or any of its nodes
+func findBreaches(run *reportRun, cm *clusterMetric, expressions []string, utilPercent string) []thresholdBreach {
+	breaches := []thresholdBreach{}
+	for _, expression := range expressions {
+		t, err := parseThreshold(expression)
+		if err != nil {
+			continue
+		}
+		field := t.resource + "." + t.metric
+		if !t.perNode {
+			if actual := t.check(run, t.resourceMetric(cm.cpu, cm.memory), utilPercent); actual != "" {
+				breaches = append(breaches, thresholdBreach{cluster: cm.name, field: field, actual: actual, expression: expression})
+			}
+			continue
+		}
+		for _, nm := range cm.getSortedNodeMetrics("name") {
+			if actual := t.check(run, t.resourceMetric(nm.cpu, nm.memory), utilPercent); actual != "" {
+				breaches = append(breaches, thresholdBreach{cluster: cm.name, node: nm.name, field: field, actual: actual, expression: expression})
+			}
+		}
+	}
+	return breaches
+}
+
+// thresholdsError returns every breached --fail-on threshold as an error
+// with thresholdExitCode, or nil if there are none
+func thresholdsError(run *reportRun, clusters []*clusterMetric, opts Options) *fetchError {
+	breaches := []string{}
+	for _, cm := range clusters {
+		breaches = append(breaches, checkThresholds(run, cm, opts)...)
+	}
+	if len(breaches) == 0 {
+		return nil
+	}
+	err := &fetchError{code: thresholdExitCode}
+	for _, breach := range breaches {
+		err.messages = append(err.messages, "Threshold breached: "+breach)
+	}
+	return err
+}
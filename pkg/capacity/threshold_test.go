@@ -0,0 +1,87 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestParseThreshold(t *testing.T) {
+	th, err := parseThreshold("node.mem.limits>=120%")
+	require.NoError(t, err)
+	assert.True(t, th.perNode)
+	assert.Equal(t, "memory", th.resource)
+	assert.Equal(t, "limits", th.metric)
+	assert.Equal(t, ">=", th.operator)
+	assert.Equal(t, float64(120), th.percent)
+	assert.Nil(t, th.quantity)
+
+	th, err = parseThreshold("cpu.util<500m")
+	require.NoError(t, err)
+	assert.False(t, th.perNode)
+	assert.Equal(t, "<", th.operator)
+	assert.Equal(t, "500m", th.quantity.String())
+
+	for _, expression := range []string{"cpu.requests", "gpu.requests>1", "cpu.usage>90%", "cpu.requests>ninety%", "cpu>90%"} {
+		_, err := parseThreshold(expression)
+		assert.Error(t, err, expression)
+	}
+
+	assert.Error(t, ValidateFailOn(Options{FailOn: []string{"cpu.util>80%"}}))
+	assert.NoError(t, ValidateFailOn(Options{FailOn: []string{"cpu.util>80%"}, ShowUtil: true}))
+}
+
+func TestCheckThresholds(t *testing.T) {
+	run := newTestRun(nil)
+	metric := func(resourceType, allocatable, request string) *resourceMetric {
+		return &resourceMetric{
+			resourceType: resourceType,
+			allocatable:  resource.MustParse(allocatable),
+			request:      resource.MustParse(request),
+		}
+	}
+
+	cm := &clusterMetric{
+		cpu:    metric("cpu", "4", "3"),
+		memory: metric("memory", "8Gi", "2Gi"),
+		nodeMetrics: map[string]*nodeMetric{
+			"busy": {name: "busy", cpu: metric("cpu", "2", "1900m"), memory: metric("memory", "4Gi", "1Gi")},
+			"idle": {name: "idle", cpu: metric("cpu", "2", "1100m"), memory: metric("memory", "4Gi", "1Gi")},
+		},
+	}
+
+	opts := Options{FailOn: []string{"cpu.requests>70%", "memory.requests>=2Gi", "node.cpu.requests>90%", "memory.requests>50%"}}
+	assert.Equal(t, []string{
+		"cluster cpu.requests is 75.0%, breaching cpu.requests>70%",
+		"cluster memory.requests is 2048Mi, breaching memory.requests>=2Gi",
+		"node busy cpu.requests is 95.0%, breaching node.cpu.requests>90%",
+	}, checkThresholds(run, cm, opts))
+
+	cm.name = "prod"
+	opts.FailOn = []string{"cpu.requests>80%"}
+	assert.Empty(t, checkThresholds(run, cm, opts))
+	opts.FailOn = []string{"node.cpu.requests<60%"}
+	assert.Equal(t, []string{"prod: node idle cpu.requests is 55.0%, breaching node.cpu.requests<60%"}, checkThresholds(run, cm, opts))
+
+	err := thresholdsError(run, []*clusterMetric{cm}, opts)
+	assert.Equal(t, thresholdExitCode, ExitCode(err))
+	assert.EqualError(t, err, "Threshold breached: prod: node idle cpu.requests is 55.0%, breaching node.cpu.requests<60%")
+	opts.FailOn = []string{"cpu.requests>80%"}
+	assert.Nil(t, thresholdsError(run, []*clusterMetric{cm}, opts))
+}
@@ -0,0 +1,139 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"k8s.io/client-go/kubernetes"
+)
+
+// containerThrottleQuery is the share of CFS periods over the window in
+// which each pod or container ran out of CPU quota, grouped by labels
+func containerThrottleQuery(labels prometheusLabels, by, window string) string {
+	c := labels.container
+	throttled := dedupReplicas(relabelSeries(fmt.Sprintf(`rate(container_cpu_cfs_throttled_periods_total{%s!="",%s!="POD"}[%s])`, c, c, window), labels))
+	periods := dedupReplicas(relabelSeries(fmt.Sprintf(`rate(container_cpu_cfs_periods_total{%s!="",%s!="POD"}[%s])`, c, c, window), labels))
+	return fmt.Sprintf(`sum by (%s) (%s) / sum by (%s) (%s)`, by, throttled, by, periods)
+}
+
+// cpuThrottling is the share of CFS periods that pods, keyed by podKey, and
+// containers, keyed by containerKey, were throttled in
+type cpuThrottling struct {
+	pods       map[string]float64
+	containers map[string]float64
+}
+
+func containerKey(namespace, pod, container string) string {
+	return podKey(namespace, pod) + "/" + container
+}
+
+// fetchCPUThrottling starts the queries of --show-throttling. The returned
+// function gives the throttling once the group has been waited on.
+func fetchCPUThrottling(run *reportRun, g *fetchGroup, clientset kubernetes.Interface, opts Options) func() *cpuThrottling {
+	var throttling *cpuThrottling
+	g.run(func(ctx context.Context) *fetchError {
+		var err error
+		throttling, err = getPrometheusCPUThrottling(run, clientset, opts)
+		if err != nil {
+			return newFetchError(4, "Error getting CPU throttling from Prometheus: %v", err)
+		}
+		return nil
+	})
+	return func() *cpuThrottling {
+		return throttling
+	}
+}
+
+func getPrometheusCPUThrottling(run *reportRun, clientset kubernetes.Interface, opts Options) (*cpuThrottling, error) {
+	endpoint, err := resolvePrometheusEndpoint(run, clientset, opts)
+	if err != nil {
+		return nil, err
+	}
+	promHTTP, err := newPrometheusHTTP(context.TODO(), run, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	podResp, err := queryPrometheus(run, clientset, endpoint, promHTTP, containerThrottleQuery(run.promLabels, "namespace, pod", opts.PrometheusWindow))
+	if err != nil {
+		return nil, fmt.Errorf("querying pod CPU throttling: %w", err)
+	}
+	containerResp, err := queryPrometheus(run, clientset, endpoint, promHTTP, containerThrottleQuery(run.promLabels, "namespace, pod, container", opts.PrometheusWindow))
+	if err != nil {
+		return nil, fmt.Errorf("querying container CPU throttling: %w", err)
+	}
+
+	return &cpuThrottling{
+		pods: throttleResult(podResp, func(m map[string]string) string {
+			return podKey(m["namespace"], m["pod"])
+		}),
+		containers: throttleResult(containerResp, func(m map[string]string) string {
+			return containerKey(m["namespace"], m["pod"], m["container"])
+		}),
+	}, nil
+}
+
+// throttleResult reads the ratio of each series. Containers without a CPU
+// limit have no CFS periods, which makes their ratio NaN, so they are left
+// out.
+func throttleResult(resp *prometheusResponse, key func(map[string]string) string) map[string]float64 {
+	out := map[string]float64{}
+	for _, r := range resp.Data.Result {
+		value, err := parseValue(r.Value)
+		if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+			continue
+		}
+		out[key(r.Metric)] = value
+	}
+	return out
+}
+
+// addCPUThrottling sets the throttling of each pod and container that has
+// any CFS periods
+func (cm *clusterMetric) addCPUThrottling(throttling *cpuThrottling) {
+	for _, nm := range cm.nodeMetrics {
+		for key, pm := range nm.podMetrics {
+			if v, ok := throttling.pods[key]; ok {
+				pm.cpuThrottled = &v
+			}
+			for name, c := range pm.containerMetrics {
+				if v, ok := throttling.containers[containerKey(pm.namespace, pm.name, name)]; ok {
+					c.cpuThrottled = &v
+				}
+			}
+		}
+	}
+}
+
+// throttleString returns the share of CFS periods throttled as a
+// percentage, example: "12%"
+func throttleString(throttled *float64) string {
+	if throttled == nil {
+		return VoidValue
+	}
+	return fmt.Sprintf("%d%%", int64(math.Round(*throttled*100)))
+}
+
+// throttleCSVString returns the share of CFS periods throttled as a
+// percentage without the sign
+func throttleCSVString(throttled *float64) string {
+	if throttled == nil {
+		return VoidValue
+	}
+	return fmt.Sprintf("%d", int64(math.Round(*throttled*100)))
+}
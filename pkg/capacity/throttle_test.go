@@ -0,0 +1,62 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestContainerThrottleQuery(t *testing.T) {
+	assert.Equal(t,
+		`sum by (namespace, pod) (max without (prometheus_replica) (rate(container_cpu_cfs_throttled_periods_total{container!="",container!="POD"}[15m]))) / `+
+			`sum by (namespace, pod) (max without (prometheus_replica) (rate(container_cpu_cfs_periods_total{container!="",container!="POD"}[15m])))`,
+		containerThrottleQuery(defaultPrometheusLabels, "namespace, pod", "15m"))
+}
+
+func TestAddCPUThrottling(t *testing.T) {
+	body := `{"status":"success","data":{"resultType":"vector","result":[
+		{"metric":{"namespace":"default","pod":"web","container":"app"},"value":[1700000000,"0.25"]},
+		{"metric":{"namespace":"default","pod":"web","container":"proxy"},"value":[1700000000,"NaN"]}
+	]}}`
+	var resp prometheusResponse
+	require.NoError(t, json.Unmarshal([]byte(body), &resp))
+
+	containers := throttleResult(&resp, func(m map[string]string) string {
+		return containerKey(m["namespace"], m["pod"], m["container"])
+	})
+	assert.Equal(t, map[string]float64{"default/web/app": 0.25}, containers)
+
+	web := pod("mynode", "default", "web", nil)
+	web.Spec.Containers = []corev1.Container{{Name: "app"}, {Name: "proxy"}}
+	cm := buildClusterMetric(
+		&corev1.PodList{Items: []corev1.Pod{*web}}, nil,
+		&corev1.NodeList{Items: []corev1.Node{*node("mynode", nil, false)}}, nil)
+
+	cm.addCPUThrottling(&cpuThrottling{
+		pods:       map[string]float64{"default/web": 0.125},
+		containers: containers,
+	})
+
+	pm := cm.nodeMetrics["mynode"].podMetrics["default/web"]
+	assert.Equal(t, "13%", throttleString(pm.cpuThrottled))
+	assert.Equal(t, "25", throttleCSVString(pm.containerMetrics["app"].cpuThrottled))
+	assert.Nil(t, pm.containerMetrics["proxy"].cpuThrottled)
+	assert.Equal(t, VoidValue, throttleString(pm.containerMetrics["proxy"].cpuThrottled))
+}
@@ -0,0 +1,168 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// topPrinter prints utilization in the layout of kubectl top, nodes by
+// default, pods with --pods, and containers with --containers
+type topPrinter struct {
+	run  *reportRun
+	cm   *clusterMetric
+	opts Options
+	w    io.Writer
+}
+
+// topPodRow is a pod, or a container of a pod with --containers
+type topPodRow struct {
+	namespace string
+	pod       string
+	container string
+	cpu       resource.Quantity
+	memory    resource.Quantity
+}
+
+func (tp *topPrinter) Print() {
+	// kubectl top uses the same tabwriter settings for every resource
+	w := tabwriter.NewWriter(tp.run.output, 10, 4, 3, ' ', 0)
+	tp.w = w
+
+	if tp.opts.ShowPods || tp.opts.ShowContainers {
+		tp.printPods()
+	} else {
+		tp.printNodes()
+	}
+
+	if err := w.Flush(); err != nil {
+		tp.run.logErrorf("Error writing to table: %s", err)
+	}
+}
+
+// printRow ends every cell with a tab, as kubectl top does
+func (tp *topPrinter) printRow(items ...string) {
+	_, _ = fmt.Fprint(tp.w, strings.Join(items, "\t")+"\t\n")
+}
+
+func (tp *topPrinter) printNodes() {
+	tp.printRow("NAME", "CPU(cores)", "CPU%", "MEMORY(bytes)", "MEMORY%")
+	for _, nm := range tp.cm.getSortedNodeMetrics(tp.opts.SortBy) {
+		tp.printRow(
+			nm.name,
+			topQuantity("cpu", nm.cpu.utilization),
+			topPercent(nm.cpu.utilization.MilliValue(), nm.cpu.allocatable.MilliValue()),
+			topQuantity("memory", nm.memory.utilization),
+			topPercent(nm.memory.utilization.MilliValue(), nm.memory.allocatable.MilliValue()),
+		)
+	}
+}
+
+// printPods prints the pods of every node together in namespace and name
+// order, or by usage when sorting by cpu.util or mem.util like kubectl top
+// --sort-by. The namespace column is left out with --namespace, as kubectl
+// top only shows it for --all-namespaces.
+func (tp *topPrinter) printPods() {
+	headers := []string{}
+	if tp.opts.Namespace == "" {
+		headers = append(headers, "NAMESPACE")
+	}
+	if tp.opts.ShowContainers {
+		headers = append(headers, "POD")
+	}
+	tp.printRow(append(headers, "NAME", "CPU(cores)", "MEMORY(bytes)")...)
+
+	for _, row := range tp.podRows() {
+		items := []string{}
+		if tp.opts.Namespace == "" {
+			items = append(items, row.namespace)
+		}
+		items = append(items, row.pod)
+		if tp.opts.ShowContainers {
+			items = append(items, row.container)
+		}
+		tp.printRow(append(items, topQuantity("cpu", row.cpu), topQuantity("memory", row.memory))...)
+	}
+}
+
+func (tp *topPrinter) podRows() []*topPodRow {
+	rows := []*topPodRow{}
+	for _, nm := range tp.cm.nodeMetrics {
+		for _, pm := range nm.podMetrics {
+			if !tp.opts.ShowContainers {
+				rows = append(rows, &topPodRow{
+					namespace: pm.namespace,
+					pod:       pm.name,
+					cpu:       pm.cpu.utilization,
+					memory:    pm.memory.utilization,
+				})
+				continue
+			}
+			for _, cm := range pm.getSortedContainerMetrics("name") {
+				rows = append(rows, &topPodRow{
+					namespace: pm.namespace,
+					pod:       pm.name,
+					container: cm.name,
+					cpu:       cm.cpu.utilization,
+					memory:    cm.memory.utilization,
+				})
+			}
+		}
+	}
+
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].namespace != rows[j].namespace {
+			return rows[i].namespace < rows[j].namespace
+		}
+		if rows[i].pod != rows[j].pod {
+			return rows[i].pod < rows[j].pod
+		}
+		return rows[i].container < rows[j].container
+	})
+	sort.SliceStable(rows, func(i, j int) bool {
+		switch tp.opts.SortBy {
+		case "cpu.util":
+			return rows[i].cpu.MilliValue() > rows[j].cpu.MilliValue()
+		case "mem.util":
+			return rows[i].memory.Value() > rows[j].memory.Value()
+		}
+		return false
+	})
+	return rows
+}
+
+// topQuantity formats CPU in millicores and memory in whole mebibytes,
+// rounded down, as kubectl top does regardless of --display-unit
+func topQuantity(resourceType string, q resource.Quantity) string {
+	if resourceType == "cpu" {
+		return fmt.Sprintf("%dm", q.MilliValue())
+	}
+	return fmt.Sprintf("%dMi", q.Value()/Mebibyte)
+}
+
+// topPercent is the usage as a whole percentage of allocatable, rounded
+// down
+func topPercent(usage, allocatable int64) string {
+	if allocatable <= 0 {
+		return "0%"
+	}
+	return fmt.Sprintf("%d%%", int64(float64(usage)/float64(allocatable)*100))
+}
@@ -0,0 +1,109 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	v1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+)
+
+func topTestClusterMetric() *clusterMetric {
+	n := node("mynode", map[string]string{}, false)
+	n.Status.Allocatable = corev1.ResourceList{
+		"cpu":    resource.MustParse("4"),
+		"memory": resource.MustParse("8Gi"),
+	}
+
+	withContainer := func(p *corev1.Pod) *corev1.Pod {
+		p.Spec.Containers = []corev1.Container{{Name: "app"}}
+		return p
+	}
+	usage := func(namespace, name, cpu, memory string) v1beta1.PodMetrics {
+		return v1beta1.PodMetrics{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Containers: []v1beta1.ContainerMetrics{{
+				Name:  "app",
+				Usage: corev1.ResourceList{"cpu": resource.MustParse(cpu), "memory": resource.MustParse(memory)},
+			}},
+		}
+	}
+
+	cm := buildClusterMetric(
+		&corev1.PodList{Items: []corev1.Pod{
+			*withContainer(pod("mynode", "kube-system", "dns", nil)),
+			*withContainer(pod("mynode", "default", "web", nil)),
+		}},
+		&v1beta1.PodMetricsList{Items: []v1beta1.PodMetrics{
+			usage("kube-system", "dns", "100m", "64Mi"),
+			usage("default", "web", "300m", "300Mi"),
+		}},
+		&corev1.NodeList{Items: []corev1.Node{*n}}, nil)
+	return &cm
+}
+
+func printTop(cm *clusterMetric, opts Options) string {
+	var buf bytes.Buffer
+	tp := &topPrinter{run: newTestRun(&buf), cm: cm, opts: opts}
+	tp.Print()
+	return buf.String()
+}
+
+func TestTopNodes(t *testing.T) {
+	assert.Equal(t, strings.Join([]string{
+		"NAME      CPU(cores)   CPU%      MEMORY(bytes)   MEMORY%   ",
+		"mynode    400m         10%       364Mi           4%        ",
+	}, "\n")+"\n", printTop(topTestClusterMetric(), Options{}))
+}
+
+func TestTopPods(t *testing.T) {
+	cm := topTestClusterMetric()
+
+	assert.Equal(t, strings.Join([]string{
+		"NAMESPACE     NAME      CPU(cores)   MEMORY(bytes)   ",
+		"default       web       300m         300Mi           ",
+		"kube-system   dns       100m         64Mi            ",
+	}, "\n")+"\n", printTop(cm, Options{ShowPods: true}))
+
+	// kubectl top leaves the namespace out unless it lists all namespaces,
+	// and the pods of other namespaces are left out when fetching
+	delete(cm.nodeMetrics["mynode"].podMetrics, "kube-system/dns")
+	assert.Equal(t, strings.Join([]string{
+		"POD       NAME      CPU(cores)   MEMORY(bytes)   ",
+		"web       app       300m         300Mi           ",
+	}, "\n")+"\n", printTop(cm, Options{ShowContainers: true, Namespace: "default"}))
+}
+
+func TestTopPodsSortedByUsage(t *testing.T) {
+	run := newTestRun(nil)
+	cm := topTestClusterMetric()
+	cm.nodeMetrics["mynode"].podMetrics["kube-system/dns"].memory.utilization = resource.MustParse("512Mi")
+
+	tp := &topPrinter{run: run, cm: cm, opts: Options{ShowPods: true, SortBy: "mem.util"}}
+	rows := tp.podRows()
+	assert.Equal(t, "dns", rows[0].pod)
+	assert.Equal(t, "web", rows[1].pod)
+
+	tp.opts.SortBy = "cpu.util"
+	rows = tp.podRows()
+	assert.Equal(t, "web", rows[0].pod)
+	assert.Equal(t, "dns", rows[1].pod)
+}
@@ -0,0 +1,244 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const (
+	unitMillicores = "millicores"
+	unitCores      = "cores"
+	unitBytes      = "bytes"
+)
+
+// Number formats of --number-format
+const (
+	// HumanNumberFormat leaves out trailing zeros of decimals
+	HumanNumberFormat = "human"
+	// FixedNumberFormat always shows every decimal of the precision, so
+	// numbers in a column line up
+	FixedNumberFormat = "fixed"
+
+	// DefaultPrecision is the number of decimals quantities are rounded to
+	DefaultPrecision = 3
+	// maxPrecision is the most decimals float64 quantities are shown with
+	maxPrecision = 9
+)
+
+var cpuDisplayUnits = []string{unitMillicores, unitCores}
+
+// memoryDisplayUnits maps each memory unit to its size in bytes
+var memoryDisplayUnits = map[string]int64{
+	"Ki":      1024,
+	"Mi":      Mebibyte,
+	"Gi":      1024 * Mebibyte,
+	"Ti":      1024 * 1024 * Mebibyte,
+	unitBytes: 1,
+}
+
+// SupportedDisplayUnits returns the units CPU and memory can be displayed in
+func SupportedDisplayUnits() []string {
+	return append(append([]string{}, cpuDisplayUnits...), "Ki", "Mi", "Gi", "Ti", unitBytes)
+}
+
+// displayUnits are the units CPU and memory quantities are formatted in
+type displayUnits struct {
+	cpu    string
+	memory string
+}
+
+var defaultDisplayUnits = displayUnits{cpu: unitMillicores, memory: "Mi"}
+
+// SupportedNumberFormats returns the formats --number-format supports
+func SupportedNumberFormats() []string {
+	return []string{HumanNumberFormat, FixedNumberFormat}
+}
+
+// numberFormat is how the numbers of quantities and percentages are
+// formatted
+type numberFormat struct {
+	precision        int
+	percentPrecision int
+	fixed            bool
+	thousands        string
+}
+
+// ValidateNumberFormat checks the precision and format flags, and that the
+// thousands separator can not be mistaken for part of a number or for the
+// separator of CSV and TSV output
+func ValidateNumberFormat(opts Options) error {
+	if opts.Precision < 0 || opts.Precision > maxPrecision {
+		return fmt.Errorf("--precision must be between 0 and %d", maxPrecision)
+	}
+	if opts.PercentPrecision < 0 || opts.PercentPrecision > maxPrecision {
+		return fmt.Errorf("--percent-precision must be between 0 and %d", maxPrecision)
+	}
+	supported := false
+	for _, format := range SupportedNumberFormats() {
+		if opts.NumberFormat == format {
+			supported = true
+		}
+	}
+	if !supported {
+		return fmt.Errorf("unsupported --number-format %q, expected one of %v", opts.NumberFormat, SupportedNumberFormats())
+	}
+	if strings.ContainsAny(opts.ThousandsSeparator, "0123456789.-+%") {
+		return fmt.Errorf("--thousands-separator can not contain digits, signs, points, or percent signs")
+	}
+	if (opts.OutputFormat == CSVOutput && strings.Contains(opts.ThousandsSeparator, ",")) ||
+		(opts.OutputFormat == TSVOutput && strings.Contains(opts.ThousandsSeparator, "\t")) {
+		return fmt.Errorf("--thousands-separator can not contain the separator of %s output", opts.OutputFormat)
+	}
+	return nil
+}
+
+// newNumberFormat returns the number format of --precision,
+// --percent-precision, --number-format, and --thousands-separator. Values
+// are expected to be validated already.
+func newNumberFormat(opts Options) numberFormat {
+	return numberFormat{
+		precision:        opts.Precision,
+		percentPrecision: opts.PercentPrecision,
+		fixed:            opts.NumberFormat == FixedNumberFormat,
+		thousands:        opts.ThousandsSeparator,
+	}
+}
+
+// newDisplayUnits returns the units of --display-unit. Values are expected
+// to be validated already; the last unit given for a resource wins.
+func newDisplayUnits(values []string) displayUnits {
+	units := defaultDisplayUnits
+	for _, value := range values {
+		if value == unitMillicores || value == unitCores {
+			units.cpu = value
+		} else if _, ok := memoryDisplayUnits[value]; ok {
+			units.memory = value
+		}
+	}
+	return units
+}
+
+// quantityNumber returns the quantity in the display unit of the resource,
+// with ephemeral storage in the unit of memory. Memory is rounded up to the nearest whole unit, or to two decimals for Gi
+// and Ti, so that a non-zero quantity is never shown as zero.
+func (r *reportRun) quantityNumber(resourceType string, q resource.Quantity) float64 {
+	switch resourceType {
+	case "cpu":
+		if r.units.cpu == unitCores {
+			return float64(q.MilliValue()) / 1000
+		}
+		return float64(q.MilliValue())
+	case "memory", "ephemeral-storage":
+		size := memoryDisplayUnits[r.units.memory]
+		switch r.units.memory {
+		case unitBytes:
+			return float64(q.Value())
+		case "Gi", "Ti":
+			return math.Ceil(float64(q.Value())/float64(size)*100) / 100
+		default:
+			value := q.Value() / size
+			if q.Value()%size != 0 {
+				value++
+			}
+			return float64(value)
+		}
+	default:
+		return float64(q.Value())
+	}
+}
+
+// unitSuffix returns the suffix appended to quantities of the resource
+func (r *reportRun) unitSuffix(resourceType string) string {
+	switch resourceType {
+	case "cpu":
+		if r.units.cpu == unitMillicores {
+			return "m"
+		}
+	case "memory", "ephemeral-storage":
+		if r.units.memory != unitBytes {
+			return r.units.memory
+		}
+	}
+	return ""
+}
+
+// csvUnitName returns the unit of the capacity columns of CSV output
+func (r *reportRun) csvUnitName(resourceType string) string {
+	if resourceType == "cpu" {
+		if r.units.cpu == unitCores {
+			return unitCores
+		}
+		return "milli"
+	}
+	return r.units.memory
+}
+
+// formatNumber rounds a quantity to the precision, example: "0.125"
+func (r *reportRun) formatNumber(value float64) string {
+	scale := math.Pow10(r.numbers.precision)
+	return r.formatDecimal(math.Round(value*scale)/scale, r.numbers.precision)
+}
+
+// formatPercent formats a percentage rounded down to the percent
+// precision, without the percent sign, example: "28"
+func (r *reportRun) formatPercent(value float64) string {
+	scale := math.Pow10(r.numbers.percentPrecision)
+	return r.formatDecimal(math.Trunc(value*scale)/scale, r.numbers.percentPrecision)
+}
+
+// formatDecimal formats a rounded number with the decimals of the number
+// format, grouping the thousands of its whole part
+func (r *reportRun) formatDecimal(value float64, precision int) string {
+	s := strconv.FormatFloat(value, 'f', -1, 64)
+	if r.numbers.fixed {
+		s = strconv.FormatFloat(value, 'f', precision, 64)
+	}
+	if r.numbers.thousands == "" {
+		return s
+	}
+
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	whole, fraction, hasFraction := strings.Cut(s, ".")
+	var b strings.Builder
+	for i, digit := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteString(r.numbers.thousands)
+		}
+		b.WriteRune(digit)
+	}
+	if hasFraction {
+		return sign + b.String() + "." + fraction
+	}
+	return sign + b.String()
+}
+
+// formatQuantity formats the quantity with its unit, example: "250m"
+func (r *reportRun) formatQuantity(resourceType string, q resource.Quantity) string {
+	return r.formatNumber(r.quantityNumber(resourceType, q)) + r.unitSuffix(resourceType)
+}
+
+// formatQuantityDifference formats a - b with its unit
+func (r *reportRun) formatQuantityDifference(resourceType string, a, b resource.Quantity) string {
+	return r.formatNumber(r.quantityNumber(resourceType, a)-r.quantityNumber(resourceType, b)) + r.unitSuffix(resourceType)
+}
@@ -0,0 +1,82 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestFormatQuantity(t *testing.T) {
+	run := newTestRun(nil)
+
+	cpu := resource.MustParse("1250m")
+	memory := resource.MustParse("1500Mi")
+
+	assert.Equal(t, "1250m", run.formatQuantity("cpu", cpu))
+	assert.Equal(t, "1500Mi", run.formatQuantity("memory", memory))
+	assert.Equal(t, "MEMORY CAPACITY (Mi)", csvHeaders(run).memoryCapacity)
+
+	run.units = newDisplayUnits([]string{"cores", "Gi"})
+	assert.Equal(t, "1.25", run.formatQuantity("cpu", cpu))
+	assert.Equal(t, "1.47Gi", run.formatQuantity("memory", memory))
+	assert.Equal(t, "-0.53Gi", run.formatQuantityDifference("memory", memory, resource.MustParse("2Gi")))
+	assert.Equal(t, "CPU CAPACITY (cores)", csvHeaders(run).cpuCapacity)
+
+	run.units = newDisplayUnits([]string{"Gi", "bytes"})
+	assert.Equal(t, "1572864000", run.formatQuantity("memory", memory))
+	assert.Equal(t, "1572864000", run.resourceCSVString("memory", memory))
+	assert.Equal(t, "1250m", run.formatQuantity("cpu", cpu))
+
+	run.units = newDisplayUnits([]string{"Ki"})
+	assert.Equal(t, "1Ki", run.formatQuantity("memory", resource.MustParse("10")))
+}
+
+func TestNumberFormat(t *testing.T) {
+	run := newTestRun(nil)
+
+	cpu := resource.MustParse("1250m")
+	assert.Equal(t, "1250m (62%)", resourceString(run, "cpu", cpu, resource.MustParse("2"), false))
+
+	run.numbers = newNumberFormat(Options{Precision: 2, PercentPrecision: 1, NumberFormat: FixedNumberFormat, ThousandsSeparator: ","})
+	assert.Equal(t, "1,250.00m (62.5%)", resourceString(run, "cpu", cpu, resource.MustParse("2"), false))
+	assert.Equal(t, "33.3", resourceCSVPercentageString(run, resource.MustParse("1"), resource.MustParse("3")))
+	assert.Equal(t, "-1,234,567.00", run.formatNumber(-1234567))
+
+	run.units = newDisplayUnits([]string{"cores"})
+	run.numbers = newNumberFormat(Options{Precision: 1, NumberFormat: HumanNumberFormat, ThousandsSeparator: "_"})
+	assert.Equal(t, "1.3", run.formatQuantity("cpu", cpu))
+	assert.Equal(t, "2", run.formatQuantity("cpu", resource.MustParse("2")))
+	assert.Equal(t, "12_000", run.formatNumber(12000))
+}
+
+func TestValidateNumberFormat(t *testing.T) {
+	valid := Options{Precision: DefaultPrecision, NumberFormat: HumanNumberFormat, OutputFormat: TableOutput}
+	assert.NoError(t, ValidateNumberFormat(valid))
+
+	for _, change := range []func(*Options){
+		func(o *Options) { o.Precision = -1 },
+		func(o *Options) { o.PercentPrecision = 10 },
+		func(o *Options) { o.NumberFormat = "scientific" },
+		func(o *Options) { o.ThousandsSeparator = "." },
+		func(o *Options) { o.ThousandsSeparator, o.OutputFormat = ",", CSVOutput },
+	} {
+		opts := valid
+		change(&opts)
+		assert.Error(t, ValidateNumberFormat(opts), "%+v", opts)
+	}
+}
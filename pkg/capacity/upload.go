@@ -0,0 +1,218 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"time"
+)
+
+// uploadTimeout bounds each upload so that an unreachable bucket does not
+// hold up the next scheduled run
+const uploadTimeout = 2 * time.Minute
+
+// uploadDestination is a bucket and key prefix parsed from --upload
+type uploadDestination struct {
+	scheme string
+	bucket string
+	prefix string
+}
+
+// ValidateUpload returns an error for an --upload URL that is not an S3 or
+// GCS bucket
+func ValidateUpload(raw string) error {
+	_, err := parseUploadDestination(raw)
+	return err
+}
+
+// parseUploadDestination parses an --upload URL, such as s3://bucket/prefix
+// or gs://bucket/prefix
+func parseUploadDestination(raw string) (*uploadDestination, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "s3" && u.Scheme != "gs" {
+		return nil, fmt.Errorf("unsupported upload destination %q, use s3://bucket/prefix or gs://bucket/prefix", raw)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("upload destination %q has no bucket", raw)
+	}
+	return &uploadDestination{
+		scheme: u.Scheme,
+		bucket: u.Host,
+		prefix: strings.Trim(u.Path, "/"),
+	}, nil
+}
+
+// key joins the prefix of the destination with name
+func (d *uploadDestination) key(name string) string {
+	return path.Join(d.prefix, name)
+}
+
+func (d *uploadDestination) String() string {
+	return fmt.Sprintf("%s://%s/%s", d.scheme, d.bucket, d.prefix)
+}
+
+// upload writes body to name under the destination
+func (d *uploadDestination) upload(name string, body []byte, opts Options) error {
+	ctx, cancel := context.WithTimeout(context.Background(), uploadTimeout)
+	defer cancel()
+
+	key := d.key(name)
+	if d.scheme == "s3" {
+		return uploadS3(ctx, d.bucket, key, body, opts)
+	}
+	return uploadGCS(ctx, d.bucket, key, body)
+}
+
+// ValidateS3Endpoint returns an error for an --s3-endpoint that is not an
+// http or https URL
+func ValidateS3Endpoint(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("unsupported S3 endpoint %q, use a URL such as https://minio.example.com", raw)
+	}
+	return nil
+}
+
+// s3ObjectURL returns the URL of an object, addressed by virtual host on
+// AWS. Bucket names with dots do not match the wildcard certificate of the
+// virtual host, and S3-compatible stores set with endpoint often only serve
+// buckets by path, so those are addressed by path instead.
+func s3ObjectURL(endpoint, bucket, region, key string) string {
+	if endpoint == "" && !strings.Contains(bucket, ".") {
+		return fmt.Sprintf("https://%s.s3.%s.amazonaws.com%s", bucket, region, awsURIEncodePath(key))
+	}
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", region)
+	}
+	return strings.TrimRight(endpoint, "/") + awsURIEncodePath(bucket+"/"+key)
+}
+
+// uploadS3 puts an object with credentials found the same way as for
+// --metrics-source=cloudwatch. The region is read from --region,
+// AWS_REGION, or AWS_DEFAULT_REGION, and the endpoint from --s3-endpoint,
+// AWS_ENDPOINT_URL_S3, or AWS_ENDPOINT_URL.
+func uploadS3(ctx context.Context, bucket, key string, body []byte, opts Options) error {
+	region := firstNonEmpty(opts.Region, os.Getenv("AWS_REGION"), os.Getenv("AWS_DEFAULT_REGION"))
+	if region == "" {
+		return fmt.Errorf("could not tell which AWS region bucket %s is in, set --region", bucket)
+	}
+	creds, err := getAWSCredentials(ctx, region)
+	if err != nil {
+		return err
+	}
+
+	endpoint := firstNonEmpty(opts.S3Endpoint, os.Getenv("AWS_ENDPOINT_URL_S3"), os.Getenv("AWS_ENDPOINT_URL"))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s3ObjectURL(endpoint, bucket, region, key), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	bodyHash := sha256.Sum256(body)
+	req.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(bodyHash[:]))
+	signAWSRequest(req, body, creds, region, "s3", time.Now())
+
+	if _, err := doAWSRequest(req); err != nil {
+		return fmt.Errorf("S3 PutObject: %w", err)
+	}
+	return nil
+}
+
+// gcpMetadataTimeout keeps the metadata server lookup from holding up
+// uploads outside of GCP
+const gcpMetadataTimeout = 5 * time.Second
+
+var (
+	gcsEndpoint         = "https://storage.googleapis.com"
+	gcpMetadataEndpoint = "http://metadata.google.internal"
+)
+
+// uploadGCS uploads an object with an access token from
+// GOOGLE_OAUTH_ACCESS_TOKEN or, on GKE and Compute Engine, the metadata
+// server, which gives the token of the workload identity of the pod
+func uploadGCS(ctx context.Context, bucket, key string, body []byte) error {
+	token, err := getGCPAccessToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	params := url.Values{}
+	params.Set("uploadType", "media")
+	params.Set("name", key)
+	endpoint := fmt.Sprintf("%s/upload/storage/v1/b/%s/o?%s", gcsEndpoint, url.PathEscape(bucket), params.Encode())
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/octet-stream")
+
+	resp, err := cloudHTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		respBody, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("GCS upload: HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	return nil
+}
+
+func getGCPAccessToken(ctx context.Context) (string, error) {
+	if token := os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN"); token != "" {
+		return token, nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, gcpMetadataTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		gcpMetadataEndpoint+"/computeMetadata/v1/instance/service-accounts/default/token", nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	resp, err := cloudHTTPClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("no GCP credentials found, set GOOGLE_OAUTH_ACCESS_TOKEN or run on GKE with workload identity: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("GCP metadata server: HTTP %d", resp.StatusCode)
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("decoding GCP metadata token: %w", err)
+	}
+	return token.AccessToken, nil
+}
@@ -0,0 +1,123 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseUploadDestination(t *testing.T) {
+	dest, err := parseUploadDestination("s3://my-bucket/capacity/prod/")
+	require.NoError(t, err)
+	assert.Equal(t, "my-bucket", dest.bucket)
+	assert.Equal(t, "capacity/prod/in-cluster/report.txt", dest.key("in-cluster/report.txt"))
+
+	dest, err = parseUploadDestination("gs://my-bucket")
+	require.NoError(t, err)
+	assert.Equal(t, "report.txt", dest.key("report.txt"))
+
+	assert.Error(t, ValidateUpload("https://my-bucket/capacity"))
+	assert.Error(t, ValidateUpload("s3:///capacity"))
+}
+
+func TestUploadFolder(t *testing.T) {
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.FixedZone("CET", 3600))
+	assert.Equal(t, "prod/20260301T110000Z", uploadFolder("prod", start))
+	assert.Equal(t, "arn_aws_eks_us-east-1_123456789012_cluster_prod/20260301T110000Z",
+		uploadFolder("arn:aws:eks:us-east-1:123456789012:cluster/prod", start))
+	assert.Equal(t, "report.json", reportFileName(JSONOutput))
+	assert.Equal(t, "report.txt", reportFileName(TableOutput))
+}
+
+func TestUploadS3(t *testing.T) {
+	var gotPath, gotBody string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPut, r.Method)
+		assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/"))
+		assert.Contains(t, r.Header.Get("Authorization"), "/eu-west-1/s3/aws4_request")
+		assert.NotEmpty(t, r.Header.Get("X-Amz-Content-Sha256"))
+		body, _ := io.ReadAll(r.Body)
+		gotPath, gotBody = r.URL.EscapedPath(), string(body)
+	}))
+	defer server.Close()
+
+	opts := Options{Region: "eu-west-1", S3Endpoint: server.URL}
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKID")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+
+	dest, err := parseUploadDestination("s3://my-bucket/capacity")
+	require.NoError(t, err)
+	require.NoError(t, dest.upload("prod/report.txt", []byte("NODE"), opts))
+	assert.Equal(t, "/my-bucket/capacity/prod/report.txt", gotPath)
+	assert.Equal(t, "NODE", gotBody)
+
+	dest, err = parseUploadDestination("s3://my-bucket/capacity reports")
+	require.NoError(t, err)
+	require.NoError(t, dest.upload("arn:aws:eks:us-east-1:123456789012:cluster/prod/report.txt", []byte("NODE"), opts))
+	assert.Equal(t, "/my-bucket/capacity%20reports/arn%3Aaws%3Aeks%3Aus-east-1%3A123456789012%3Acluster/prod/report.txt", gotPath)
+}
+
+func TestS3ObjectURL(t *testing.T) {
+	assert.Equal(t, "https://my-bucket.s3.eu-west-1.amazonaws.com/capacity/report.txt",
+		s3ObjectURL("", "my-bucket", "eu-west-1", "capacity/report.txt"))
+	assert.Equal(t, "https://s3.eu-west-1.amazonaws.com/reports.example.com/capacity/report.txt",
+		s3ObjectURL("", "reports.example.com", "eu-west-1", "capacity/report.txt"), "dotted buckets are addressed by path")
+	assert.Equal(t, "https://minio.example.com:9000/my-bucket/capacity/report.txt",
+		s3ObjectURL("https://minio.example.com:9000/", "my-bucket", "us-east-1", "capacity/report.txt"))
+
+	assert.NoError(t, ValidateS3Endpoint("https://s3-fips.us-east-1.amazonaws.com"))
+	assert.Error(t, ValidateS3Endpoint("minio.example.com"))
+}
+
+func TestAWSURIEncodePath(t *testing.T) {
+	assert.Equal(t, "/capacity/prod/report.txt", awsURIEncodePath("capacity/prod/report.txt"))
+	assert.Equal(t, "/a%20b/c%2Bd%3De~f", awsURIEncodePath("a b/c+d=e~f"))
+}
+
+func TestUploadGCS(t *testing.T) {
+	var gotName, gotBody string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/computeMetadata/v1/instance/service-accounts/default/token":
+			assert.Equal(t, "Google", r.Header.Get("Metadata-Flavor"))
+			_, _ = w.Write([]byte(`{"access_token":"ya29.token","expires_in":3599,"token_type":"Bearer"}`))
+		case "/upload/storage/v1/b/my-bucket/o":
+			assert.Equal(t, "Bearer ya29.token", r.Header.Get("Authorization"))
+			body, _ := io.ReadAll(r.Body)
+			gotName, gotBody = r.URL.Query().Get("name"), string(body)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	defer func(storage, metadata string) { gcsEndpoint, gcpMetadataEndpoint = storage, metadata }(gcsEndpoint, gcpMetadataEndpoint)
+	gcsEndpoint, gcpMetadataEndpoint = server.URL, server.URL
+	t.Setenv("GOOGLE_OAUTH_ACCESS_TOKEN", "")
+
+	dest, err := parseUploadDestination("gs://my-bucket/capacity")
+	require.NoError(t, err)
+	require.NoError(t, dest.upload("prod/snapshot.json.gz", []byte("{}"), Options{}))
+	assert.Equal(t, "capacity/prod/snapshot.json.gz", gotName)
+	assert.Equal(t, "{}", gotBody)
+}
@@ -0,0 +1,429 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Outcomes of a verify check
+const (
+	verifyPass = "PASS"
+	// verifyFail marks checks that break the report with the flags given
+	verifyFail = "FAIL"
+	// verifyWarn marks checks that only break features the flags given do
+	// not use
+	verifyWarn = "WARN"
+	verifySkip = "SKIP"
+)
+
+// verifyCheck is one line of the checklist printed by verify
+type verifyCheck struct {
+	name   string
+	status string
+	detail string
+}
+
+// verifyPermission is an API request kube-capacity makes, and whether the
+// flags given need it
+type verifyPermission struct {
+	verb        string
+	group       string
+	resource    string
+	subresource string
+	needed      func(opts Options) bool
+	usedFor     string
+}
+
+// verifyQuery is a Prometheus query the report relies on, and what it
+// means when it returns no series
+type verifyQuery struct {
+	name   string
+	query  string
+	empty  string
+	needed bool
+}
+
+type listVerify struct {
+	Passed bool               `json:"passed"`
+	Checks []*listVerifyCheck `json:"checks"`
+}
+
+type listVerifyCheck struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// usesMetricsSource reports whether utilization is shown and read from one
+// of the sources
+func usesMetricsSource(opts Options, sources ...string) bool {
+	if !opts.ShowUtil {
+		return false
+	}
+	for _, source := range sources {
+		if opts.MetricsSource == source {
+			return true
+		}
+	}
+	return false
+}
+
+// usesPrometheus reports whether the flags given read anything from
+// Prometheus
+func usesPrometheus(opts Options) bool {
+	return usesMetricsSource(opts, PrometheusSource, AutoSource) || opts.SpecSource == KubeStateMetricsSpecSource ||
+		(opts.ShowDisk && opts.MetricsSource == PrometheusSource)
+}
+
+func isPrometheusURL(endpoint string) bool {
+	return strings.HasPrefix(endpoint, "http://") || strings.HasPrefix(endpoint, "https://")
+}
+
+var verifyPermissions = []verifyPermission{
+	{verb: "list", resource: "nodes", usedFor: "nodes",
+		needed: func(opts Options) bool { return opts.SpecSource != KubeStateMetricsSpecSource }},
+	{verb: "list", resource: "pods", usedFor: "pods",
+		needed: func(opts Options) bool { return opts.SpecSource != KubeStateMetricsSpecSource }},
+	{verb: "list", resource: "namespaces", usedFor: "--namespace-labels",
+		needed: func(opts Options) bool { return opts.NamespaceLabels != "" }},
+	{verb: "list", group: "metrics.k8s.io", resource: "nodes", usedFor: "utilization from metrics-server",
+		needed: func(opts Options) bool { return usesMetricsSource(opts, MetricsServerSource) }},
+	{verb: "list", group: "metrics.k8s.io", resource: "pods", usedFor: "utilization from metrics-server",
+		needed: func(opts Options) bool { return usesMetricsSource(opts, MetricsServerSource) }},
+	{verb: "list", resource: "services", usedFor: "discovering Prometheus",
+		needed: func(opts Options) bool { return usesPrometheus(opts) && opts.PrometheusEndpoint == "" }},
+	{verb: "get", resource: "services", subresource: "proxy", usedFor: "querying Prometheus through the API server",
+		needed: func(opts Options) bool { return usesPrometheus(opts) && !isPrometheusURL(opts.PrometheusEndpoint) }},
+	{verb: "get", resource: "nodes", subresource: "proxy", usedFor: "utilization from the kubelets",
+		needed: func(opts Options) bool { return usesMetricsSource(opts, KubeletSource) }},
+	{verb: "get", resource: "nodes", subresource: "proxy", usedFor: "disk usage from the kubelets",
+		needed: func(opts Options) bool { return opts.ShowDisk && opts.MetricsSource != PrometheusSource }},
+	{verb: "get", group: "custom.metrics.k8s.io", resource: "pods", usedFor: "utilization from the custom metrics API",
+		needed: func(opts Options) bool { return usesMetricsSource(opts, CustomMetricsSource) }},
+}
+
+// FetchAndPrintVerify checks that the cluster, metrics sources, and
+// permissions the flags given rely on are all available, prints a checklist,
+// and fails when a check fails
+func FetchAndPrintVerify(opts Options) error {
+	run := newRun(opts)
+
+	checks := runVerifyChecks(context.TODO(), run, opts)
+	vp := &verifyPrinter{run: run, checks: checks}
+	if err := vp.Print(opts.OutputFormat); err != nil {
+		return err
+	}
+	if err := run.closeOutput(); err != nil {
+		return err
+	}
+
+	if !verifyPassed(checks) {
+		return fmt.Errorf("Error: not every check passed")
+	}
+	return nil
+}
+
+func runVerifyChecks(ctx context.Context, run *reportRun, opts Options) []*verifyCheck {
+	clientset, err := newClientSet(run, opts)
+	if err != nil {
+		return []*verifyCheck{{name: "kubeconfig", status: verifyFail, detail: err.Error()}}
+	}
+
+	checks := []*verifyCheck{verifyAPIServer(clientset)}
+	if checks[0].status == verifyFail {
+		return checks
+	}
+	checks = append(checks, verifyAccess(ctx, clientset, opts)...)
+	checks = append(checks, verifyMetricsServer(ctx, run, opts))
+	return append(checks, verifyPrometheus(run, clientset, opts)...)
+}
+
+func verifyAPIServer(clientset kubernetes.Interface) *verifyCheck {
+	check := &verifyCheck{name: "API server"}
+	version, err := clientset.Discovery().ServerVersion()
+	if err != nil {
+		check.status = verifyFail
+		check.detail = err.Error()
+		return check
+	}
+	check.status = verifyPass
+	check.detail = "Kubernetes " + version.GitVersion
+	return check
+}
+
+// verifyAccess asks the API server whether the current user, or the one
+// impersonated, can make each request kube-capacity makes
+func verifyAccess(ctx context.Context, clientset kubernetes.Interface, opts Options) []*verifyCheck {
+	checks := []*verifyCheck{}
+	for _, p := range verifyPermissions {
+		check := &verifyCheck{name: permissionName(p)}
+		checks = append(checks, check)
+
+		review, err := reviewAccess(ctx, clientset, p, opts)
+		switch {
+		case err != nil:
+			check.status = verifyWarn
+			check.detail = fmt.Sprintf("can not check access: %v", err)
+		case review.Allowed:
+			check.status = verifyPass
+		default:
+			check.status = verifyWarn
+			if p.needed(opts) {
+				check.status = verifyFail
+			}
+			check.detail = "forbidden, needed for " + p.usedFor
+			if review.Reason != "" {
+				check.detail += ": " + review.Reason
+			}
+		}
+	}
+	return checks
+}
+
+// reviewAccess runs a SelfSubjectAccessReview for the permission. Pods are
+// checked in --namespace when it is set, as only those are listed.
+func reviewAccess(ctx context.Context, clientset kubernetes.Interface, p verifyPermission, opts Options) (*authorizationv1.SubjectAccessReviewStatus, error) {
+	attributes := &authorizationv1.ResourceAttributes{
+		Verb:        p.verb,
+		Group:       p.group,
+		Resource:    p.resource,
+		Subresource: p.subresource,
+	}
+	if p.resource == "pods" && p.group == "" {
+		attributes.Namespace = opts.Namespace
+	}
+	review, err := clientset.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, &authorizationv1.SelfSubjectAccessReview{
+		Spec: authorizationv1.SelfSubjectAccessReviewSpec{ResourceAttributes: attributes},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return &review.Status, nil
+}
+
+// checkAccess reviews the permissions the flags given need before anything
+// is fetched, so that a missing one is named along with the RBAC rule that
+// grants it instead of surfacing as a 403 part way through the report. When
+// access can not be reviewed the report is fetched as usual.
+func checkAccess(ctx context.Context, run *reportRun, clientset kubernetes.Interface, opts Options) *fetchError {
+	start := time.Now()
+	missing := []string{}
+	for _, p := range verifyPermissions {
+		if !p.needed(opts) {
+			continue
+		}
+		review, err := reviewAccess(ctx, clientset, p, opts)
+		if err != nil {
+			run.logWarnf("Could not check access: %v", err)
+			return nil
+		}
+		if !review.Allowed {
+			missing = append(missing, fmt.Sprintf("  %s, needed for %s: grant it with %s", permissionName(p), p.usedFor, permissionRule(p)))
+		}
+	}
+	run.logTiming(start, "Checked access")
+	if len(missing) == 0 {
+		return nil
+	}
+
+	ferr := newFetchError(3, "Error: missing permissions:")
+	for _, m := range missing {
+		ferr.withHint(m)
+	}
+	return ferr.withHint("Run kube-capacity verify for a checklist of everything kube-capacity uses")
+}
+
+// permissionRule returns the RBAC rule that grants the permission
+func permissionRule(p verifyPermission) string {
+	resource := p.resource
+	if p.subresource != "" {
+		resource += "/" + p.subresource
+	}
+	return fmt.Sprintf(`apiGroups: [%q], resources: [%q], verbs: [%q]`, p.group, resource, p.verb)
+}
+
+// permissionName names a permission like kubectl auth can-i, such as list
+// nodes.metrics.k8s.io or get services/proxy
+func permissionName(p verifyPermission) string {
+	resource := p.resource
+	if p.group != "" {
+		resource += "." + p.group
+	}
+	if p.subresource != "" {
+		resource += "/" + p.subresource
+	}
+	return p.verb + " " + resource
+}
+
+func verifyMetricsServer(ctx context.Context, run *reportRun, opts Options) *verifyCheck {
+	check := &verifyCheck{name: "metrics-server", status: verifyWarn}
+	if usesMetricsSource(opts, MetricsServerSource) {
+		check.status = verifyFail
+	}
+
+	mClientset, err := newMetricsClientSet(opts)
+	if err != nil {
+		check.detail = err.Error()
+		return check
+	}
+	nmList, ferr := getNodeMetrics(ctx, run, mClientset, opts.NodeLabels)
+	if ferr != nil {
+		check.detail = strings.Join(ferr.messages, ", ")
+		return check
+	}
+	if len(nmList.Items) == 0 {
+		check.detail = "no nodes have metrics yet"
+		return check
+	}
+	check.status = verifyPass
+	check.detail = fmt.Sprintf("%d nodes have metrics", len(nmList.Items))
+	return check
+}
+
+// verifyPrometheus discovers Prometheus and runs the node CPU query the
+// report runs, along with the capacity query of kube-state-metrics when it
+// is the spec source. Prometheus is skipped unless the flags given use it.
+func verifyPrometheus(run *reportRun, clientset kubernetes.Interface, opts Options) []*verifyCheck {
+	discovery := &verifyCheck{name: "Prometheus endpoint", status: verifyWarn}
+	if usesMetricsSource(opts, PrometheusSource) || opts.SpecSource == KubeStateMetricsSpecSource {
+		discovery.status = verifyFail
+	}
+	if !usesPrometheus(opts) {
+		discovery.status = verifySkip
+		discovery.detail = "not used, check it with --prometheus"
+		return []*verifyCheck{discovery}
+	}
+
+	endpoint, err := resolvePrometheusEndpoint(run, clientset, opts)
+	if err != nil {
+		discovery.detail = err.Error()
+		return []*verifyCheck{discovery}
+	}
+	promHTTP, err := newPrometheusHTTP(context.TODO(), run, opts)
+	if err != nil {
+		discovery.detail = err.Error()
+		return []*verifyCheck{discovery}
+	}
+	discovery.status = verifyPass
+	discovery.detail = endpoint
+
+	queries := []verifyQuery{}
+	if usesMetricsSource(opts, PrometheusSource, AutoSource) {
+		queries = append(queries, verifyQuery{
+			name:   "Prometheus node usage",
+			query:  nodeCPUQuery(run.promLabels, opts.PrometheusAggregation, opts.PrometheusWindow, 0),
+			empty:  fmt.Sprintf("no series, container_cpu_usage_seconds_total may not be scraped or lack a %s label", run.promLabels.node),
+			needed: opts.MetricsSource == PrometheusSource,
+		})
+		if run.promLabels.node != defaultPrometheusLabels.node {
+			queries = append(queries, verifyQuery{
+				name:  "Prometheus node names",
+				query: nodeInfoQuery,
+				empty: fmt.Sprintf("no series, kube-state-metrics may not be scraped and %s values are used as node names as they are", run.promLabels.node),
+			})
+		}
+	}
+	if opts.SpecSource == KubeStateMetricsSpecSource {
+		queries = append(queries, verifyQuery{
+			name:   "kube-state-metrics",
+			query:  ksmNodeCapacityQuery,
+			empty:  "no series, kube-state-metrics may not be scraped",
+			needed: true,
+		})
+	}
+
+	checks := []*verifyCheck{discovery}
+	for _, q := range queries {
+		check := &verifyCheck{name: q.name, status: verifyWarn}
+		if q.needed {
+			check.status = verifyFail
+		}
+		checks = append(checks, check)
+		resp, err := queryPrometheus(run, clientset, endpoint, promHTTP, q.query)
+		switch {
+		case err != nil:
+			check.detail = err.Error()
+		case len(resp.Data.Result) == 0:
+			check.detail = q.empty
+		default:
+			check.status = verifyPass
+			check.detail = fmt.Sprintf("%d series", len(resp.Data.Result))
+		}
+	}
+	return checks
+}
+
+func verifyPassed(checks []*verifyCheck) bool {
+	for _, check := range checks {
+		if check.status == verifyFail {
+			return false
+		}
+	}
+	return true
+}
+
+type verifyPrinter struct {
+	run    *reportRun
+	checks []*verifyCheck
+}
+
+func (vp *verifyPrinter) Print(outputType string) error {
+	switch outputType {
+	case JSONOutput, YAMLOutput:
+		printListOutput(vp.run, VerifyReportKind, vp.buildListVerify(), outputType)
+	case TableOutput:
+		w := tabwriter.NewWriter(vp.run.output, 0, 8, 2, ' ', 0)
+		vp.printTable(w, "\t ")
+		if err := w.Flush(); err != nil {
+			vp.run.logErrorf("Error writing to table: %s", err)
+		}
+	case CSVOutput:
+		vp.printTable(vp.run.output, ",")
+	case TSVOutput:
+		vp.printTable(vp.run.output, "\t")
+	default:
+		return fmt.Errorf("Called with an unsupported output type: %s", outputType)
+	}
+	return nil
+}
+
+func (vp *verifyPrinter) printTable(w io.Writer, separator string) {
+	_, _ = fmt.Fprintln(w, strings.Join([]string{"CHECK", "STATUS", "DETAIL"}, separator))
+	for _, check := range vp.checks {
+		_, _ = fmt.Fprintln(w, strings.Join([]string{check.name, check.status, check.detail}, separator))
+	}
+}
+
+func (vp *verifyPrinter) buildListVerify() *listVerify {
+	out := &listVerify{Passed: verifyPassed(vp.checks), Checks: []*listVerifyCheck{}}
+	for _, check := range vp.checks {
+		out.Checks = append(out.Checks, &listVerifyCheck{
+			Name:   check.name,
+			Status: check.status,
+			Detail: check.detail,
+		})
+	}
+	return out
+}
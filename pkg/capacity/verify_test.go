@@ -0,0 +1,124 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// verifyTestClientset allows every request except listing metrics.k8s.io
+// resources and proxying to services
+func verifyTestClientset() *fake.Clientset {
+	clientset := fake.NewSimpleClientset()
+	clientset.PrependReactor("create", "selfsubjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+		attributes := review.Spec.ResourceAttributes
+		review.Status.Allowed = attributes.Group != "metrics.k8s.io" && attributes.Subresource != "proxy"
+		return true, review, nil
+	})
+	return clientset
+}
+
+func TestVerifyAccess(t *testing.T) {
+	status := func(checks []*verifyCheck) map[string]string {
+		out := map[string]string{}
+		for _, check := range checks {
+			out[check.name] = check.status
+		}
+		return out
+	}
+
+	// Without --util nothing reads metrics, so a missing permission only
+	// warns
+	checks := verifyAccess(context.Background(), verifyTestClientset(), Options{MetricsSource: MetricsServerSource})
+	assert.Equal(t, map[string]string{
+		"list nodes":                     verifyPass,
+		"list pods":                      verifyPass,
+		"list namespaces":                verifyPass,
+		"list nodes.metrics.k8s.io":      verifyWarn,
+		"list pods.metrics.k8s.io":       verifyWarn,
+		"list services":                  verifyPass,
+		"get services/proxy":             verifyWarn,
+		"get nodes/proxy":                verifyWarn,
+		"get pods.custom.metrics.k8s.io": verifyPass,
+	}, status(checks))
+	assert.True(t, verifyPassed(checks))
+
+	checks = verifyAccess(context.Background(), verifyTestClientset(), Options{MetricsSource: MetricsServerSource, ShowUtil: true})
+	assert.Equal(t, verifyFail, status(checks)["list nodes.metrics.k8s.io"])
+	assert.Equal(t, verifyWarn, status(checks)["get services/proxy"])
+	assert.False(t, verifyPassed(checks))
+
+	// A Prometheus URL is queried directly rather than through the proxy
+	checks = verifyAccess(context.Background(), verifyTestClientset(), Options{MetricsSource: PrometheusSource, ShowUtil: true})
+	assert.Equal(t, verifyFail, status(checks)["get services/proxy"])
+	checks = verifyAccess(context.Background(), verifyTestClientset(), Options{
+		MetricsSource:      PrometheusSource,
+		ShowUtil:           true,
+		PrometheusEndpoint: "https://prometheus.example.com",
+	})
+	assert.True(t, verifyPassed(checks))
+}
+
+func TestVerifyReport(t *testing.T) {
+	run := newTestRun(nil)
+	vp := &verifyPrinter{run: run, checks: []*verifyCheck{
+		{name: "API server", status: verifyPass, detail: "Kubernetes v1.30.2"},
+		{name: "list pods", status: verifyFail, detail: "forbidden, needed for pods"},
+		{name: "Prometheus endpoint", status: verifySkip, detail: "not used, check it with --prometheus"},
+	}}
+
+	var buf bytes.Buffer
+	vp.printTable(&buf, ",")
+	assert.Equal(t, strings.Join([]string{
+		"CHECK,STATUS,DETAIL",
+		"API server,PASS,Kubernetes v1.30.2",
+		"list pods,FAIL,forbidden, needed for pods",
+		"Prometheus endpoint,SKIP,not used, check it with --prometheus",
+	}, "\n")+"\n", buf.String())
+
+	list := vp.buildListVerify()
+	assert.False(t, list.Passed)
+	require.Len(t, list.Checks, 3)
+	assert.Equal(t, "list pods", list.Checks[1].Name)
+}
+
+func TestCheckAccess(t *testing.T) {
+	run := newTestRun(nil)
+	assert.Nil(t, checkAccess(context.Background(), run, verifyTestClientset(), Options{MetricsSource: MetricsServerSource}))
+
+	ferr := checkAccess(context.Background(), run, verifyTestClientset(), Options{MetricsSource: MetricsServerSource, ShowUtil: true})
+	require.NotNil(t, ferr)
+	assert.Equal(t, []string{
+		"Error: missing permissions:",
+		`  list nodes.metrics.k8s.io, needed for utilization from metrics-server: grant it with apiGroups: ["metrics.k8s.io"], resources: ["nodes"], verbs: ["list"]`,
+		`  list pods.metrics.k8s.io, needed for utilization from metrics-server: grant it with apiGroups: ["metrics.k8s.io"], resources: ["pods"], verbs: ["list"]`,
+		"Run kube-capacity verify for a checklist of everything kube-capacity uses",
+	}, ferr.messages)
+
+	ferr = checkAccess(context.Background(), run, verifyTestClientset(), Options{MetricsSource: PrometheusSource, ShowUtil: true})
+	require.NotNil(t, ferr)
+	assert.Contains(t, ferr.messages[1], `resources: ["services/proxy"], verbs: ["get"]`)
+}
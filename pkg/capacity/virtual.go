@@ -0,0 +1,143 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// IncludeVirtualNodes counts virtual nodes like any other node
+	IncludeVirtualNodes = "include"
+	// ExcludeVirtualNodes leaves virtual nodes and their pods out of the
+	// report
+	ExcludeVirtualNodes = "exclude"
+	// SeparateVirtualNodes reports virtual nodes after the other nodes,
+	// with totals of their own
+	SeparateVirtualNodes = "separate"
+)
+
+// SupportedVirtualNodeModes returns the values of --virtual-nodes
+func SupportedVirtualNodeModes() []string {
+	return []string{IncludeVirtualNodes, ExcludeVirtualNodes, SeparateVirtualNodes}
+}
+
+// virtualTotalsName labels the totals of virtual nodes with
+// --virtual-nodes=separate
+const virtualTotalsName = "* (virtual)"
+
+// Labels and taints of nodes backed by a serverless container service
+// rather than a machine, whose allocatable is a synthetic limit
+const (
+	virtualKubeletTypeLabel = "type"
+	virtualKubeletTypeValue = "virtual-kubelet"
+	virtualKubeletTaintKey  = "virtual-kubelet.io/provider"
+	fargateComputeTypeLabel = "eks.amazonaws.com/compute-type"
+	fargateComputeTypeValue = "fargate"
+)
+
+type listVirtualNodes struct {
+	Nodes  []*listNodeMetric  `json:"nodes"`
+	Totals *listClusterTotals `json:"totals"`
+}
+
+// isVirtualNode reports whether a node is a virtual-kubelet, like Azure
+// Container Instances virtual nodes, or an EKS Fargate node
+func isVirtualNode(node *corev1.Node) bool {
+	if node.Labels[virtualKubeletTypeLabel] == virtualKubeletTypeValue {
+		return true
+	}
+	if node.Labels[fargateComputeTypeLabel] == fargateComputeTypeValue {
+		return true
+	}
+	for _, taint := range node.Spec.Taints {
+		if taint.Key == virtualKubeletTaintKey {
+			return true
+		}
+	}
+	return false
+}
+
+// splitVirtualNodes removes virtual nodes and the pods on them from the
+// lists, and returns what was removed
+func splitVirtualNodes(podList *corev1.PodList, nodeList *corev1.NodeList) (*corev1.PodList, *corev1.NodeList) {
+	virtualPods := &corev1.PodList{Items: []corev1.Pod{}}
+	virtualNodes := &corev1.NodeList{Items: []corev1.Node{}}
+
+	nodes := []corev1.Node{}
+	virtual := map[string]bool{}
+	for _, node := range nodeList.Items {
+		if isVirtualNode(&node) {
+			virtual[node.Name] = true
+			virtualNodes.Items = append(virtualNodes.Items, node)
+			continue
+		}
+		nodes = append(nodes, node)
+	}
+	if len(virtual) == 0 {
+		return virtualPods, virtualNodes
+	}
+	nodeList.Items = nodes
+
+	pods := []corev1.Pod{}
+	for _, pod := range podList.Items {
+		if virtual[pod.Spec.NodeName] {
+			virtualPods.Items = append(virtualPods.Items, pod)
+			continue
+		}
+		pods = append(pods, pod)
+	}
+	podList.Items = pods
+
+	return virtualPods, virtualNodes
+}
+
+// printVirtualRows prints the totals of virtual nodes followed by their
+// rows, set apart from the other nodes by an empty line
+func (tp *tablePrinter) printVirtualRows() {
+	virtual := tp.cm.virtualNodes
+	if virtual == nil {
+		return
+	}
+	tp.printLine(&tableLine{})
+	tp.printTotalsLine(virtualTotalsName, virtual, VoidValue)
+	for _, nm := range virtual.getSortedNodeMetrics(tp.opts.SortBy) {
+		tp.printNodeRows(nm)
+	}
+}
+
+// printVirtualRows prints the totals of virtual nodes followed by their
+// rows
+func (cp *csvPrinter) printVirtualRows() {
+	virtual := cp.cm.virtualNodes
+	if virtual == nil {
+		return
+	}
+	cp.printTotalsLine(virtualTotalsName, virtual, VoidValue)
+	for _, nm := range virtual.getSortedNodeMetrics(cp.opts.SortBy) {
+		cp.printNodeRows(nm)
+	}
+}
+
+// buildListVirtualNodes returns the virtual nodes of the cluster, or nil
+// when they are not reported separately
+func (lp *listPrinter) buildListVirtualNodes() *listVirtualNodes {
+	if lp.cm.virtualNodes == nil {
+		return nil
+	}
+	vp := &listPrinter{run: lp.run, cm: lp.cm.virtualNodes, opts: lp.opts}
+	virtual := vp.buildListClusterMetrics()
+	return &listVirtualNodes{Nodes: virtual.Nodes, Totals: virtual.ClusterTotals}
+}
@@ -0,0 +1,86 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestIsVirtualNode(t *testing.T) {
+	aci := node("virtual-node-aci-linux", map[string]string{"type": "virtual-kubelet"}, false)
+	fargate := node("fargate-ip-10-0-1-2", map[string]string{"eks.amazonaws.com/compute-type": "fargate"}, false)
+	tainted := node("vk", map[string]string{}, false)
+	tainted.Spec.Taints = []corev1.Taint{{Key: "virtual-kubelet.io/provider", Value: "azure", Effect: corev1.TaintEffectNoSchedule}}
+
+	assert.True(t, isVirtualNode(aci))
+	assert.True(t, isVirtualNode(fargate))
+	assert.True(t, isVirtualNode(tainted))
+	assert.False(t, isVirtualNode(node("mynode", map[string]string{"type": "worker"}, true)))
+}
+
+func TestSplitVirtualNodes(t *testing.T) {
+	run := newTestRun(nil)
+	snap := getTestSnapshot()
+	snap.Nodes.Items[1].Labels["type"] = "virtual-kubelet"
+
+	virtualPods, virtualNodes := splitVirtualNodes(snap.Pods, snap.Nodes)
+	require.Len(t, virtualNodes.Items, 1)
+	assert.Equal(t, "mynode2", virtualNodes.Items[0].Name)
+	require.Len(t, virtualPods.Items, 1)
+	assert.Equal(t, "mypod2", virtualPods.Items[0].Name)
+	require.Len(t, snap.Nodes.Items, 1)
+	assert.Equal(t, "mynode", snap.Nodes.Items[0].Name)
+	require.Len(t, snap.Pods.Items, 1)
+	assert.Equal(t, "mypod", snap.Pods.Items[0].Name)
+
+	cm := buildClusterMetric(snap.Pods, nil, snap.Nodes, nil)
+	virtual := buildClusterMetric(virtualPods, nil, virtualNodes, nil)
+	cm.virtualNodes = &virtual
+	assert.Equal(t, "1000m", cm.cpu.allocatable.String())
+
+	lp := &listPrinter{run: run, cm: &cm, opts: Options{SortBy: "name"}}
+	list := lp.buildListClusterMetrics()
+	require.Len(t, list.Nodes, 1)
+	require.NotNil(t, list.VirtualNodes)
+	require.Len(t, list.VirtualNodes.Nodes, 1)
+	assert.Equal(t, "mynode2", list.VirtualNodes.Nodes[0].Name)
+	assert.Equal(t, "100m", list.VirtualNodes.Totals.CPU.Requests)
+
+	var buf bytes.Buffer
+	tp := &tablePrinter{run: newTestRun(&buf), cm: &cm, opts: Options{SortBy: "name"}}
+	tp.Print()
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 5)
+	assert.True(t, strings.HasPrefix(lines[1], "mynode "), lines[1])
+	assert.Empty(t, strings.TrimSpace(lines[2]))
+	assert.True(t, strings.HasPrefix(lines[3], "* (virtual) "), lines[3])
+	assert.True(t, strings.HasPrefix(lines[4], "mynode2 "), lines[4])
+}
+
+func TestSplitVirtualNodesWithoutVirtualNodes(t *testing.T) {
+	snap := getTestSnapshot()
+	virtualPods, virtualNodes := splitVirtualNodes(snap.Pods, snap.Nodes)
+	assert.Empty(t, virtualPods.Items)
+	assert.Empty(t, virtualNodes.Items)
+	assert.Len(t, snap.Nodes.Items, 2)
+	assert.Len(t, snap.Pods.Items, 2)
+}
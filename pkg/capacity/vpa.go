@@ -0,0 +1,204 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+var vpaResource = schema.GroupVersionResource{
+	Group:    "autoscaling.k8s.io",
+	Version:  "v1",
+	Resource: "verticalpodautoscalers",
+}
+
+// verticalPodAutoscaler holds the fields of an autoscaling.k8s.io/v1
+// VerticalPodAutoscaler used by the report, avoiding a dependency on the
+// VPA client libraries
+type verticalPodAutoscaler struct {
+	metav1.ObjectMeta `json:"metadata"`
+	Spec              struct {
+		TargetRef *autoscalingv1.CrossVersionObjectReference `json:"targetRef,omitempty"`
+	} `json:"spec"`
+	Status struct {
+		Recommendation *struct {
+			ContainerRecommendations []vpaContainerRecommendation `json:"containerRecommendations,omitempty"`
+		} `json:"recommendation,omitempty"`
+	} `json:"status"`
+}
+
+type vpaContainerRecommendation struct {
+	ContainerName string              `json:"containerName"`
+	Target        corev1.ResourceList `json:"target"`
+	LowerBound    corev1.ResourceList `json:"lowerBound,omitempty"`
+	UpperBound    corev1.ResourceList `json:"upperBound,omitempty"`
+}
+
+type verticalPodAutoscalerList struct {
+	Items []verticalPodAutoscaler `json:"items"`
+}
+
+// vpaRange is the VPA recommendation for a single resource of a container
+type vpaRange struct {
+	target     resource.Quantity
+	lowerBound resource.Quantity
+	upperBound resource.Quantity
+}
+
+func getVerticalPodAutoscalers(run *reportRun, opts Options, namespace string) (*verticalPodAutoscalerList, *fetchError) {
+	dynamicClient, err := newDynamicClient(opts)
+	if err != nil {
+		return nil, newFetchError(1, "Error connecting to Kubernetes: %v", err)
+	}
+	return listVerticalPodAutoscalers(run, dynamicClient, namespace)
+}
+
+// listVerticalPodAutoscalers returns an empty list when the VPA custom
+// resource is not installed in the cluster
+func listVerticalPodAutoscalers(run *reportRun, dynamicClient dynamic.Interface, namespace string) (*verticalPodAutoscalerList, *fetchError) {
+	start := time.Now()
+	uList, err := dynamicClient.Resource(vpaResource).Namespace(namespace).List(context.TODO(), metav1.ListOptions{})
+	if apierrors.IsNotFound(err) {
+		run.logWarnf("VerticalPodAutoscaler resource not found in the cluster")
+		return &verticalPodAutoscalerList{}, nil
+	}
+	if err != nil {
+		return nil, newFetchError(3, "Error listing VerticalPodAutoscalers: %v", err)
+	}
+	run.logTiming(start, "Listed %d VerticalPodAutoscalers", len(uList.Items))
+
+	vpaList := &verticalPodAutoscalerList{Items: []verticalPodAutoscaler{}}
+	for _, item := range uList.Items {
+		var vpa verticalPodAutoscaler
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(item.Object, &vpa); err != nil {
+			return nil, newFetchError(3, "Error parsing VerticalPodAutoscaler %s/%s: %v", item.GetNamespace(), item.GetName(), err)
+		}
+		vpaList.Items = append(vpaList.Items, vpa)
+	}
+	return vpaList, nil
+}
+
+// addVPARecommendations attaches the recommendations of the VPA targeting
+// each pod's controller to the pod's containers
+func (cm *clusterMetric) addVPARecommendations(podList *corev1.PodList, vpaList *verticalPodAutoscalerList) {
+	type target struct {
+		namespace string
+		kind      string
+		name      string
+	}
+	vpas := map[target]*verticalPodAutoscaler{}
+	for i := range vpaList.Items {
+		vpa := &vpaList.Items[i]
+		if vpa.Spec.TargetRef == nil || vpa.Status.Recommendation == nil {
+			continue
+		}
+		vpas[target{vpa.Namespace, vpa.Spec.TargetRef.Kind, vpa.Spec.TargetRef.Name}] = vpa
+	}
+
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		kind, name := podController(pod)
+		vpa, ok := vpas[target{pod.Namespace, kind, name}]
+		if !ok {
+			continue
+		}
+
+		nm := cm.nodeMetrics[pod.Spec.NodeName]
+		if nm == nil {
+			continue
+		}
+		pm := nm.podMetrics[podKey(pod.Namespace, pod.Name)]
+		if pm == nil {
+			continue
+		}
+
+		for _, rec := range vpa.Status.Recommendation.ContainerRecommendations {
+			if c := pm.containerMetrics[rec.ContainerName]; c != nil {
+				c.cpu.vpa = newVPARange(corev1.ResourceCPU, rec)
+				c.memory.vpa = newVPARange(corev1.ResourceMemory, rec)
+			}
+		}
+	}
+}
+
+func newVPARange(name corev1.ResourceName, rec vpaContainerRecommendation) *vpaRange {
+	if _, ok := rec.Target[name]; !ok {
+		return nil
+	}
+	return &vpaRange{
+		target:     rec.Target[name],
+		lowerBound: rec.LowerBound[name],
+		upperBound: rec.UpperBound[name],
+	}
+}
+
+// podController returns the kind and name of the workload controlling the
+// pod. ReplicaSets created by a Deployment are resolved to the Deployment
+// using the pod-template-hash label.
+func podController(pod *corev1.Pod) (string, string) {
+	ref := metav1.GetControllerOf(pod)
+	if ref == nil {
+		return "", ""
+	}
+	if ref.Kind == "ReplicaSet" {
+		if hash, ok := pod.Labels["pod-template-hash"]; ok && strings.HasSuffix(ref.Name, "-"+hash) {
+			return "Deployment", strings.TrimSuffix(ref.Name, "-"+hash)
+		}
+	}
+	return ref.Kind, ref.Name
+}
+
+// vpaString returns the VPA recommendation as "target (lowerBound-upperBound)"
+func (rm *resourceMetric) vpaString(run *reportRun) string {
+	if rm.vpa == nil {
+		return VoidValue
+	}
+	valueCalculator := rm.valueFunction(run)
+	return fmt.Sprintf("%s (%s-%s)", valueCalculator(rm.vpa.target),
+		valueCalculator(rm.vpa.lowerBound), valueCalculator(rm.vpa.upperBound))
+}
+
+func (rm *resourceMetric) vpaTargetString(run *reportRun) string {
+	if rm.vpa == nil {
+		return VoidValue
+	}
+	return run.resourceCSVString(rm.resourceType, rm.vpa.target)
+}
+
+func (rm *resourceMetric) vpaLowerBoundString(run *reportRun) string {
+	if rm.vpa == nil {
+		return VoidValue
+	}
+	return run.resourceCSVString(rm.resourceType, rm.vpa.lowerBound)
+}
+
+func (rm *resourceMetric) vpaUpperBoundString(run *reportRun) string {
+	if rm.vpa == nil {
+		return VoidValue
+	}
+	return run.resourceCSVString(rm.resourceType, rm.vpa.upperBound)
+}
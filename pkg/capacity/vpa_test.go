@@ -0,0 +1,153 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+func TestListVerticalPodAutoscalers(t *testing.T) {
+	run := newTestRun(nil)
+	dynamicClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{vpaResource: "VerticalPodAutoscalerList"},
+		unstructuredVPA("default", "web", "Deployment", "web", "app", "250m", "512Mi"),
+		unstructuredVPA("other", "db", "StatefulSet", "db", "postgres", "1", "2Gi"),
+	)
+
+	vpaList, ferr := listVerticalPodAutoscalers(run, dynamicClient, "")
+	require.Nil(t, ferr)
+	require.Len(t, vpaList.Items, 2)
+
+	vpaList, ferr = listVerticalPodAutoscalers(run, dynamicClient, "default")
+	require.Nil(t, ferr)
+	require.Len(t, vpaList.Items, 1)
+	vpa := vpaList.Items[0]
+	assert.Equal(t, "web", vpa.Name)
+	assert.Equal(t, "Deployment", vpa.Spec.TargetRef.Kind)
+	require.NotNil(t, vpa.Status.Recommendation)
+	rec := vpa.Status.Recommendation.ContainerRecommendations[0]
+	assert.Equal(t, "app", rec.ContainerName)
+	assert.Equal(t, "250m", rec.Target.Cpu().String())
+	assert.Equal(t, "512Mi", rec.Target.Memory().String())
+	assert.Equal(t, "125m", rec.LowerBound.Cpu().String())
+}
+
+func TestAddVPARecommendations(t *testing.T) {
+	run := newTestRun(nil)
+	snap := getTestSnapshot()
+	snap.Pods.Items[0].Labels = map[string]string{"pod-template-hash": "5d4f8"}
+	snap.Pods.Items[0].OwnerReferences = controllerRef("ReplicaSet", "web-5d4f8")
+	snap.Pods.Items[1].OwnerReferences = controllerRef("StatefulSet", "db")
+
+	var vpaList verticalPodAutoscalerList
+	for _, u := range []*unstructured.Unstructured{
+		unstructuredVPA("default", "web", "Deployment", "web", "app", "250m", "512Mi"),
+		// targets a workload in another namespace
+		unstructuredVPA("default", "db", "StatefulSet", "db", "app", "1", "2Gi"),
+	} {
+		var vpa verticalPodAutoscaler
+		require.NoError(t, runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, &vpa))
+		vpaList.Items = append(vpaList.Items, vpa)
+	}
+
+	cm := buildClusterMetric(snap.Pods, snap.PodMetrics, snap.Nodes, snap.NodeMetrics)
+	cm.addVPARecommendations(snap.Pods, &vpaList)
+
+	web := cm.nodeMetrics["mynode"].podMetrics["default/mypod"].containerMetrics["app"]
+	assert.Equal(t, "250m (125m-500m)", web.cpu.vpaString(run))
+	assert.Equal(t, "512Mi (256Mi-1024Mi)", web.memory.vpaString(run))
+	assert.Equal(t, "250", web.cpu.vpaTargetString(run))
+	assert.Equal(t, "256", web.memory.vpaLowerBoundString(run))
+	assert.Equal(t, "1024", web.memory.vpaUpperBoundString(run))
+
+	db := cm.nodeMetrics["mynode2"].podMetrics["other/mypod2"].containerMetrics["app"]
+	assert.Nil(t, db.cpu.vpa)
+	assert.Equal(t, VoidValue, db.cpu.vpaString(run))
+	assert.Equal(t, VoidValue, db.memory.vpaTargetString(run))
+
+	tp := &tablePrinter{run: run, cm: &cm, opts: Options{ShowContainers: true, ShowVPA: true}}
+	assert.Equal(t, []string{
+		"NODE", "NAMESPACE", "POD", "CONTAINER",
+		"CPU REQUESTS", "CPU LIMITS", "CPU VPA",
+		"MEMORY REQUESTS", "MEMORY LIMITS", "MEMORY VPA",
+	}, tp.getLineItems(&headerStrings))
+}
+
+func TestPodController(t *testing.T) {
+	p := pod("mynode", "default", "web-5d4f8-abcde", map[string]string{"pod-template-hash": "5d4f8"})
+	kind, name := podController(p)
+	assert.Equal(t, "", kind)
+	assert.Equal(t, "", name)
+
+	p.OwnerReferences = controllerRef("ReplicaSet", "web-5d4f8")
+	kind, name = podController(p)
+	assert.Equal(t, "Deployment", kind)
+	assert.Equal(t, "web", name)
+
+	// ReplicaSets that were not created by a Deployment are kept as is
+	p.Labels = nil
+	kind, name = podController(p)
+	assert.Equal(t, "ReplicaSet", kind)
+	assert.Equal(t, "web-5d4f8", name)
+}
+
+func controllerRef(kind, name string) []metav1.OwnerReference {
+	controller := true
+	return []metav1.OwnerReference{{Kind: kind, Name: name, Controller: &controller}}
+}
+
+func unstructuredVPA(namespace, name, targetKind, targetName, container, cpu, memory string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "autoscaling.k8s.io/v1",
+		"kind":       "VerticalPodAutoscaler",
+		"metadata": map[string]interface{}{
+			"name":      name,
+			"namespace": namespace,
+		},
+		"spec": map[string]interface{}{
+			"targetRef": map[string]interface{}{
+				"apiVersion": "apps/v1",
+				"kind":       targetKind,
+				"name":       targetName,
+			},
+		},
+		"status": map[string]interface{}{
+			"recommendation": map[string]interface{}{
+				"containerRecommendations": []interface{}{
+					map[string]interface{}{
+						"containerName": container,
+						"target":        map[string]interface{}{"cpu": cpu, "memory": memory},
+						"lowerBound":    map[string]interface{}{"cpu": scaleQuantity(cpu, 0.5), "memory": scaleQuantity(memory, 0.5)},
+						"upperBound":    map[string]interface{}{"cpu": scaleQuantity(cpu, 2), "memory": scaleQuantity(memory, 2)},
+					},
+				},
+			},
+		},
+	}}
+}
+
+func scaleQuantity(value string, factor float64) string {
+	q := resource.MustParse(value)
+	return resource.NewMilliQuantity(int64(float64(q.MilliValue())*factor), q.Format).String()
+}
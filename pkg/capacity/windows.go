@@ -0,0 +1,95 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// windowsOS is the kubernetes.io/os label of Windows nodes
+const windowsOS = "windows"
+
+// windowsContainerInfo maps the container IDs of windows_exporter series to
+// the namespace, pod, and container kube-state-metrics knows them by, only
+// for the namespaces matching a regexp when it is set
+func windowsContainerInfo(namespaces string) string {
+	matchers := `container_id!=""`
+	if namespaces != "" {
+		matchers += fmt.Sprintf(`,namespace=~"%s"`, namespaces)
+	}
+	return fmt.Sprintf(`max by (container_id, namespace, pod, container) (kube_pod_container_info{%s})`, matchers)
+}
+
+// windowsPodNode maps pods to the node they run on
+const windowsPodNode = `max by (namespace, pod, node) (kube_pod_info{node!=""})`
+
+// operatingSystem returns the kubernetes.io/os label of the node, which is
+// empty on nodes that do not set it
+func (nm *nodeMetric) operatingSystem() string {
+	return nm.labels[corev1.LabelOSStable]
+}
+
+func (nm *nodeMetric) isWindows() bool {
+	return nm.operatingSystem() == windowsOS
+}
+
+// tableName is the name of the node in table output, which marks Windows
+// nodes so that they stand out in mixed-OS clusters
+func (nm *nodeMetric) tableName() string {
+	if nm.isWindows() {
+		return nm.name + " (windows)"
+	}
+	return nm.name
+}
+
+// windowsContainerSeries returns the usage of Windows containers from
+// windows_exporter, labeled with the namespace, pod, and container of the
+// cAdvisor series that the kubelet on Windows does not serve, only for the
+// namespaces matching a regexp when it is set
+func windowsContainerSeries(expr, namespaces string) string {
+	return fmt.Sprintf(`%s * on (container_id) group_left (namespace, pod, container) %s`, dedupReplicas(expr), windowsContainerInfo(namespaces))
+}
+
+// windowsNodeContainerSeries adds the node each Windows container runs on,
+// as windows_exporter series are labeled with the instance scraped instead
+func windowsNodeContainerSeries(expr string) string {
+	return fmt.Sprintf(`(%s) * on (namespace, pod) group_left (node) %s`, windowsContainerSeries(expr, ""), windowsPodNode)
+}
+
+// orWindows adds Windows series for the containers cAdvisor has no series
+// for, so that containers with both are not counted twice
+func orWindows(linux, windows string) string {
+	return fmt.Sprintf(`%s or on (namespace, pod, container) (%s)`, linux, windows)
+}
+
+// warnMissingWindowsUsage points at windows_exporter when Windows nodes have
+// no usage in Prometheus, which would otherwise be shown as idle
+func warnMissingWindowsUsage(run *reportRun, cm *clusterMetric) {
+	missing := []string{}
+	for _, nm := range cm.nodeMetrics {
+		if nm.isWindows() && nm.cpu.utilization.IsZero() && nm.memory.utilization.IsZero() {
+			missing = append(missing, nm.name)
+		}
+	}
+	if len(missing) == 0 {
+		return
+	}
+	sort.Strings(missing)
+	run.logWarnf("no usage for Windows nodes %s; Prometheus needs windows_exporter with the container collector and kube-state-metrics for Windows usage", strings.Join(missing, ", "))
+}
@@ -0,0 +1,79 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestWindowsNodes(t *testing.T) {
+	windows := &nodeMetric{name: "win-1", labels: map[string]string{"kubernetes.io/os": "windows"}}
+	linux := &nodeMetric{name: "linux-1", labels: map[string]string{"kubernetes.io/os": "linux"}}
+	unlabeled := &nodeMetric{name: "old-1", labels: map[string]string{}}
+
+	assert.True(t, windows.isWindows())
+	assert.Equal(t, "win-1 (windows)", windows.tableName())
+	assert.False(t, linux.isWindows())
+	assert.Equal(t, "linux-1", linux.tableName())
+	assert.Equal(t, "linux", linux.operatingSystem())
+	assert.Equal(t, "", unlabeled.operatingSystem())
+}
+
+func TestPrometheusQueriesIncludeWindows(t *testing.T) {
+	_, containerMemory := defaultPrometheusLabels.containerUsage("")
+	assert.Equal(t,
+		`sum by (namespace, pod) (max without (prometheus_replica) (container_memory_working_set_bytes{container!="",container!="POD"}) or on (namespace, pod, container) (`+
+			`max without (prometheus_replica) (windows_container_memory_usage_private_working_set_bytes) * on (container_id) group_left (namespace, pod, container) `+
+			`max by (container_id, namespace, pod, container) (kube_pod_container_info{container_id!=""})))`,
+		`sum by (namespace, pod) (`+containerMemory+`)`)
+
+	for _, query := range []string{
+		containerCPUQuery(defaultPrometheusLabels, "avg", "1h", 0),
+		containerCPUQuantileQuery(defaultPrometheusLabels, 0.95, "7d"),
+		nodeCPUQuery(defaultPrometheusLabels, "max", "1h", 0),
+	} {
+		assert.Contains(t, query, "rate(windows_container_cpu_usage_seconds_total[5m])", query)
+	}
+	assert.Contains(t, nodeMemQuery(defaultPrometheusLabels, "max", "1h", 0), `group_left (node) max by (namespace, pod, node) (kube_pod_info{node!=""})`)
+}
+
+func TestWarnMissingWindowsUsage(t *testing.T) {
+	var buf bytes.Buffer
+	run := newTestRun(nil)
+	run.logOutput = &buf
+
+	windowsLabels := map[string]string{"kubernetes.io/os": "windows"}
+	cm := &clusterMetric{
+		nodeMetrics: map[string]*nodeMetric{
+			"win-2": {name: "win-2", labels: windowsLabels, cpu: &resourceMetric{}, memory: &resourceMetric{}},
+			"win-1": {name: "win-1", labels: windowsLabels, cpu: &resourceMetric{}, memory: &resourceMetric{}},
+			"win-3": {name: "win-3", labels: windowsLabels, cpu: &resourceMetric{utilization: resource.MustParse("250m")}, memory: &resourceMetric{}},
+			"linux": {name: "linux", labels: map[string]string{}, cpu: &resourceMetric{}, memory: &resourceMetric{}},
+		},
+	}
+
+	warnMissingWindowsUsage(run, cm)
+	assert.Equal(t, "Warning: no usage for Windows nodes win-1, win-2; Prometheus needs windows_exporter with the container collector and kube-state-metrics for Windows usage\n", buf.String())
+
+	buf.Reset()
+	delete(cm.nodeMetrics, "win-1")
+	delete(cm.nodeMetrics, "win-2")
+	warnMissingWindowsUsage(run, cm)
+	assert.Empty(t, buf.String())
+}
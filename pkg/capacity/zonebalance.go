@@ -0,0 +1,326 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// zoneOverloaded marks zones whose requests would exceed their allocatable
+// if another zone failed
+const zoneOverloaded = "overloaded"
+
+type listZoneBalance struct {
+	ClusterTotals *listZone         `json:"clusterTotals"`
+	Zones         []*listZone       `json:"zones"`
+	Imbalance     []*listZoneSpread `json:"imbalance"`
+}
+
+type listZone struct {
+	Name   string            `json:"name,omitempty"`
+	Nodes  int64             `json:"nodes"`
+	CPU    *listZoneResource `json:"cpu"`
+	Memory *listZoneResource `json:"memory"`
+	Status string            `json:"status,omitempty"`
+}
+
+type listZoneResource struct {
+	Allocatable         string `json:"allocatable"`
+	Requests            string `json:"requests"`
+	RequestsPct         string `json:"requestsPercent"`
+	Utilization         string `json:"utilization,omitempty"`
+	UtilizationPct      string `json:"utilizationPercent,omitempty"`
+	FailoverRequests    string `json:"failoverRequests,omitempty"`
+	FailoverRequestsPct string `json:"failoverRequestsPercent,omitempty"`
+	FailedZone          string `json:"failedZone,omitempty"`
+}
+
+// listZoneSpread is how unevenly one value is spread across zones
+type listZoneSpread struct {
+	Resource               string `json:"resource"`
+	Value                  string `json:"value"`
+	Min                    string `json:"min"`
+	Max                    string `json:"max"`
+	Spread                 string `json:"spread"`
+	CoefficientOfVariation string `json:"coefficientOfVariation"`
+}
+
+// zoneValue is a value of each zone compared by the imbalance of the
+// zone balance report
+type zoneValue struct {
+	name     string
+	quantity func(rm *resourceMetric) resource.Quantity
+}
+
+// zoneFailover is the requests a zone would have once failedZone fails
+type zoneFailover struct {
+	failedZone string
+	requests   resource.Quantity
+}
+
+// zone returns the topology.kubernetes.io/zone label of the node, falling
+// back to the beta label older clusters still set
+func (nm *nodeMetric) zone() string {
+	for _, label := range []string{corev1.LabelTopologyZone, corev1.LabelFailureDomainBetaZone} {
+		if zone := nm.labels[label]; zone != "" {
+			return zone
+		}
+	}
+	return ungroupedNodeGroup
+}
+
+// buildZoneMetrics groups the nodes of the cluster by zone, returning the
+// cluster totals followed by each zone in name order
+func buildZoneMetrics(cm *clusterMetric) (*nodeSetMetric, []*nodeSetMetric) {
+	totals, sets := splitNodes(cm, (*nodeMetric).zone)
+
+	zones := []*nodeSetMetric{}
+	for _, ns := range sets {
+		zones = append(zones, ns)
+	}
+	sort.Slice(zones, func(i, j int) bool {
+		return zones[i].name < zones[j].name
+	})
+	return totals, zones
+}
+
+// worstFailover returns the requests a zone would have after the failure
+// of whichever other zone leaves it with the most, assuming the requests of
+// the failed zone are rescheduled onto the remaining zones in proportion to
+// their allocatable. It returns false when there is no other zone to fail.
+func worstFailover(zone *resourceMetric, total *resourceMetric, others []*resourceMetric, names []string) (zoneFailover, bool) {
+	var worst zoneFailover
+	found := false
+	for i, failed := range others {
+		remaining := total.allocatable.MilliValue() - failed.allocatable.MilliValue()
+		if remaining <= 0 {
+			continue
+		}
+		moved := float64(failed.request.MilliValue()) * float64(zone.allocatable.MilliValue()) / float64(remaining)
+		requests := *resource.NewMilliQuantity(zone.request.MilliValue()+int64(math.Round(moved)), resource.DecimalSI)
+		if !found || requests.Cmp(worst.requests) > 0 {
+			worst = zoneFailover{failedZone: names[i], requests: requests}
+			found = true
+		}
+	}
+	return worst, found
+}
+
+func printZoneBalance(run *reportRun, cm *clusterMetric, opts Options) error {
+	zp := &zoneBalancePrinter{run: run, opts: opts}
+	zp.totals, zp.zones = buildZoneMetrics(cm)
+	return zp.Print(opts.OutputFormat)
+}
+
+type zoneBalancePrinter struct {
+	run    *reportRun
+	totals *nodeSetMetric
+	zones  []*nodeSetMetric
+	opts   Options
+}
+
+func (zp *zoneBalancePrinter) Print(outputType string) error {
+	switch outputType {
+	case JSONOutput, YAMLOutput:
+		printListOutput(zp.run, ZoneBalanceReportKind, zp.buildListZoneBalance(), outputType)
+	case TableOutput:
+		w := tabwriter.NewWriter(zp.run.output, 0, 8, 2, ' ', 0)
+		zp.printTable(w, "\t ")
+		if err := w.Flush(); err != nil {
+			zp.run.logErrorf("Error writing to table: %s", err)
+		}
+	case CSVOutput:
+		zp.printTable(zp.run.output, ",")
+	case TSVOutput:
+		zp.printTable(zp.run.output, "\t")
+	default:
+		return fmt.Errorf("Called with an unsupported output type: %s", outputType)
+	}
+	return nil
+}
+
+// printTable prints each zone with the requests it would have if another
+// zone failed, followed by how unevenly each value is spread across zones
+func (zp *zoneBalancePrinter) printTable(w io.Writer, separator string) {
+	headers := []string{"ZONE", "NODES"}
+	for _, prefix := range []string{"CPU", "MEMORY"} {
+		headers = append(headers, prefix+" ALLOCATABLE", prefix+" REQUESTS")
+		if zp.opts.ShowUtil {
+			headers = append(headers, prefix+" UTIL")
+		}
+		headers = append(headers, prefix+" IF A ZONE FAILS")
+	}
+	headers = append(headers, "STATUS")
+	_, _ = fmt.Fprintln(w, strings.Join(headers, separator))
+
+	list := zp.buildListZoneBalance()
+	list.ClusterTotals.Name = VoidValue
+	for _, lz := range append([]*listZone{list.ClusterTotals}, list.Zones...) {
+		items := []string{lz.Name, fmt.Sprintf("%d", lz.Nodes)}
+		for _, r := range []*listZoneResource{lz.CPU, lz.Memory} {
+			items = append(items, r.Allocatable, fmt.Sprintf("%s (%s)", r.Requests, r.RequestsPct))
+			if zp.opts.ShowUtil {
+				items = append(items, fmt.Sprintf("%s (%s)", r.Utilization, r.UtilizationPct))
+			}
+			failover := VoidValue
+			if r.FailedZone != "" {
+				failover = fmt.Sprintf("%s (%s without %s)", r.FailoverRequests, r.FailoverRequestsPct, r.FailedZone)
+			}
+			items = append(items, failover)
+		}
+		_, _ = fmt.Fprintln(w, strings.Join(append(items, lz.Status), separator))
+	}
+
+	_, _ = fmt.Fprintln(w)
+	_, _ = fmt.Fprintln(w, strings.Join([]string{"RESOURCE", "VALUE", "MIN", "MAX", "SPREAD", "CV"}, separator))
+	for _, s := range list.Imbalance {
+		_, _ = fmt.Fprintln(w, strings.Join([]string{s.Resource, s.Value, s.Min, s.Max, s.Spread, s.CoefficientOfVariation}, separator))
+	}
+}
+
+func (zp *zoneBalancePrinter) buildListZoneBalance() *listZoneBalance {
+	out := &listZoneBalance{
+		ClusterTotals: zp.listZone(zp.totals),
+		Zones:         []*listZone{},
+		Imbalance:     []*listZoneSpread{},
+	}
+	for _, ns := range zp.zones {
+		out.Zones = append(out.Zones, zp.listZone(ns))
+	}
+
+	values := []zoneValue{
+		{"allocatable", func(rm *resourceMetric) resource.Quantity { return rm.allocatable }},
+		{"requests", func(rm *resourceMetric) resource.Quantity { return rm.request }},
+	}
+	if zp.opts.ShowUtil {
+		values = append(values, zoneValue{"utilization", func(rm *resourceMetric) resource.Quantity { return rm.utilization }})
+	}
+	for _, resourceType := range []string{"cpu", "memory"} {
+		for _, v := range values {
+			quantities := []resource.Quantity{}
+			for _, ns := range zp.zones {
+				rm := ns.cpu
+				if resourceType == "memory" {
+					rm = ns.memory
+				}
+				quantities = append(quantities, v.quantity(rm))
+			}
+			out.Imbalance = append(out.Imbalance, zoneSpread(zp.run, resourceType, v.name, quantities))
+		}
+	}
+	return out
+}
+
+// zoneSpread gives the spread between the largest and smallest zone and
+// the coefficient of variation, the standard deviation as a percentage of
+// the mean, of one value across zones
+func zoneSpread(run *reportRun, resourceType, value string, quantities []resource.Quantity) *listZoneSpread {
+	var min, max resource.Quantity
+	sum := 0.0
+	for i, q := range quantities {
+		if i == 0 || q.Cmp(min) < 0 {
+			min = q
+		}
+		if i == 0 || q.Cmp(max) > 0 {
+			max = q
+		}
+		sum += float64(q.MilliValue())
+	}
+
+	cv := 0.0
+	if len(quantities) > 0 && sum > 0 {
+		mean := sum / float64(len(quantities))
+		variance := 0.0
+		for _, q := range quantities {
+			variance += math.Pow(float64(q.MilliValue())-mean, 2)
+		}
+		cv = math.Sqrt(variance/float64(len(quantities))) / mean * 100
+	}
+
+	return &listZoneSpread{
+		Resource:               resourceType,
+		Value:                  value,
+		Min:                    run.formatQuantity(resourceType, min),
+		Max:                    run.formatQuantity(resourceType, max),
+		Spread:                 run.formatQuantityDifference(resourceType, max, min),
+		CoefficientOfVariation: fmt.Sprintf("%.1f%%", cv),
+	}
+}
+
+func (zp *zoneBalancePrinter) listZone(ns *nodeSetMetric) *listZone {
+	lz := &listZone{
+		Name:   ns.name,
+		Nodes:  ns.nodes,
+		CPU:    zp.listZoneResource(ns.cpu),
+		Memory: zp.listZoneResource(ns.memory),
+	}
+	// The cluster totals have no zone to fail over from
+	if ns == zp.totals {
+		return lz
+	}
+
+	names := []string{}
+	others := map[string][]*resourceMetric{}
+	for _, other := range zp.zones {
+		if other == ns {
+			continue
+		}
+		names = append(names, other.name)
+		others["cpu"] = append(others["cpu"], other.cpu)
+		others["memory"] = append(others["memory"], other.memory)
+	}
+	for _, r := range []struct {
+		out   *listZoneResource
+		zone  *resourceMetric
+		total *resourceMetric
+	}{
+		{lz.CPU, ns.cpu, zp.totals.cpu},
+		{lz.Memory, ns.memory, zp.totals.memory},
+	} {
+		failover, ok := worstFailover(r.zone, r.total, others[r.zone.resourceType], names)
+		if !ok {
+			continue
+		}
+		r.out.FailoverRequests = r.zone.valueFunction(zp.run)(failover.requests)
+		r.out.FailoverRequestsPct = percentString(zp.run, failover.requests, r.zone.allocatable)
+		r.out.FailedZone = failover.failedZone
+		if failover.requests.Cmp(r.zone.allocatable) > 0 {
+			lz.Status = zoneOverloaded
+		}
+	}
+	return lz
+}
+
+func (zp *zoneBalancePrinter) listZoneResource(rm *resourceMetric) *listZoneResource {
+	valueCalculator := rm.valueFunction(zp.run)
+	out := &listZoneResource{
+		Allocatable: valueCalculator(rm.allocatable),
+		Requests:    valueCalculator(rm.request),
+		RequestsPct: percentString(zp.run, rm.request, rm.allocatable),
+	}
+	if zp.opts.ShowUtil {
+		out.Utilization = valueCalculator(rm.utilization)
+		out.UtilizationPct = percentString(zp.run, rm.utilization, rm.utilBase(zp.opts.UtilPercent))
+	}
+	return out
+}
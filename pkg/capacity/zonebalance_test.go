@@ -0,0 +1,127 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package capacity
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func TestZoneBalanceReport(t *testing.T) {
+	run := newTestRun(nil)
+	snap := getTestSnapshot()
+	snap.Nodes.Items[0].Labels["topology.kubernetes.io/zone"] = "us-east-1a"
+	snap.Nodes.Items[1].Labels["topology.kubernetes.io/zone"] = "us-east-1b"
+	cm := buildClusterMetric(snap.Pods, nil, snap.Nodes, nil)
+
+	zp := &zoneBalancePrinter{run: run}
+	zp.totals, zp.zones = buildZoneMetrics(&cm)
+	require.Len(t, zp.zones, 2)
+	assert.Equal(t, "us-east-1a", zp.zones[0].name)
+	assert.Equal(t, "us-east-1b", zp.zones[1].name)
+
+	list := zp.buildListZoneBalance()
+	assert.Equal(t, &listZoneResource{
+		Allocatable:         "1000m",
+		Requests:            "100m",
+		RequestsPct:         "10%",
+		FailoverRequests:    "200m",
+		FailoverRequestsPct: "20%",
+		FailedZone:          "us-east-1b",
+	}, list.Zones[0].CPU)
+	assert.Empty(t, list.ClusterTotals.CPU.FailedZone)
+
+	var buf bytes.Buffer
+	zp.printTable(&buf, ",")
+	assert.Equal(t, strings.Join([]string{
+		"ZONE,NODES,CPU ALLOCATABLE,CPU REQUESTS,CPU IF A ZONE FAILS,MEMORY ALLOCATABLE,MEMORY REQUESTS,MEMORY IF A ZONE FAILS,STATUS",
+		"*,2,2000m,200m (10%),*,8000Mi,256Mi (3%),*,",
+		"us-east-1a,1,1000m,100m (10%),200m (20% without us-east-1b),4000Mi,128Mi (3%),256Mi (6% without us-east-1b),",
+		"us-east-1b,1,1000m,100m (10%),200m (20% without us-east-1a),4000Mi,128Mi (3%),256Mi (6% without us-east-1a),",
+		"",
+		"RESOURCE,VALUE,MIN,MAX,SPREAD,CV",
+		"cpu,allocatable,1000m,1000m,0m,0.0%",
+		"cpu,requests,100m,100m,0m,0.0%",
+		"memory,allocatable,4000Mi,4000Mi,0Mi,0.0%",
+		"memory,requests,128Mi,128Mi,0Mi,0.0%",
+	}, "\n")+"\n", buf.String())
+}
+
+func TestWorstFailover(t *testing.T) {
+	zone := func(allocatable, request string) *resourceMetric {
+		return &resourceMetric{
+			resourceType: "cpu",
+			allocatable:  resource.MustParse(allocatable),
+			request:      resource.MustParse(request),
+		}
+	}
+	a, b, c := zone("1000m", "800m"), zone("1000m", "900m"), zone("500m", "100m")
+	total := zone("2500m", "1800m")
+
+	// Losing b moves 900m onto 1500m of allocatable, 600m of it onto a
+	failover, ok := worstFailover(a, total, []*resourceMetric{b, c}, []string{"b", "c"})
+	require.True(t, ok)
+	assert.Equal(t, "b", failover.failedZone)
+	assert.Equal(t, int64(1400), failover.requests.MilliValue())
+
+	_, ok = worstFailover(a, a, []*resourceMetric{}, []string{})
+	assert.False(t, ok)
+}
+
+func TestZoneBalanceFlagsOverloadedZones(t *testing.T) {
+	run := newTestRun(nil)
+	snap := getTestSnapshot()
+	snap.Nodes.Items[0].Labels["topology.kubernetes.io/zone"] = "us-east-1a"
+	snap.Nodes.Items[1].Labels["topology.kubernetes.io/zone"] = "us-east-1b"
+	// us-east-1b has four times the cpu of us-east-1a, so 1a can not take
+	// on its requests while 1b can take on the requests of 1a
+	snap.Nodes.Items[1].Status.Allocatable["cpu"] = resource.MustParse("4000m")
+	snap.Pods.Items[1].Spec.Containers[0].Resources.Requests["cpu"] = resource.MustParse("1000m")
+	cm := buildClusterMetric(snap.Pods, nil, snap.Nodes, nil)
+
+	zp := &zoneBalancePrinter{run: run}
+	zp.totals, zp.zones = buildZoneMetrics(&cm)
+	list := zp.buildListZoneBalance()
+	assert.Equal(t, zoneOverloaded, list.Zones[0].Status)
+	assert.Equal(t, "1100m", list.Zones[0].CPU.FailoverRequests)
+	assert.Equal(t, "", list.Zones[1].Status)
+}
+
+func TestZoneSpread(t *testing.T) {
+	run := newTestRun(nil)
+	spread := zoneSpread(run, "cpu", "allocatable", []resource.Quantity{
+		resource.MustParse("1000m"), resource.MustParse("1000m"), resource.MustParse("500m"),
+	})
+	assert.Equal(t, &listZoneSpread{
+		Resource:               "cpu",
+		Value:                  "allocatable",
+		Min:                    "500m",
+		Max:                    "1000m",
+		Spread:                 "500m",
+		CoefficientOfVariation: "28.3%",
+	}, spread)
+}
+
+func TestZoneFallsBackToBetaLabel(t *testing.T) {
+	nm := &nodeMetric{labels: map[string]string{"failure-domain.beta.kubernetes.io/zone": "us-east-1c"}}
+	assert.Equal(t, "us-east-1c", nm.zone())
+	nm = &nodeMetric{labels: map[string]string{}}
+	assert.Equal(t, ungroupedNodeGroup, nm.zone())
+}
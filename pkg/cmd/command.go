@@ -0,0 +1,143 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/robscott/kube-capacity/pkg/capacity"
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	metrics "k8s.io/metrics/pkg/client/clientset/versioned"
+)
+
+// IOStreams are the streams kube-capacity reads manifests from, prints
+// reports to, and logs to. Streams that are nil stay stdin, stdout, and
+// stderr.
+type IOStreams struct {
+	In     io.Reader
+	Out    io.Writer
+	ErrOut io.Writer
+}
+
+// Option configures the command built by NewKubeCapacityCommand
+type Option func(*commandConfig)
+
+type commandConfig struct {
+	clientset        kubernetes.Interface
+	metricsClientset metrics.Interface
+	dynamicClient    dynamic.Interface
+	clusterName      string
+	streams          *IOStreams
+}
+
+// WithClientset lists nodes, pods, and every other resource with clientset
+// instead of a clientset connected with the kubeconfig. The kubeconfig is
+// not used for any other client either, so utilization from metrics-server
+// needs WithMetricsClientset, and --show-vpa, --headroom, and --karpenter
+// need WithDynamicClient.
+func WithClientset(clientset kubernetes.Interface) Option {
+	return func(c *commandConfig) {
+		c.clientset = clientset
+	}
+}
+
+// WithMetricsClientset reads utilization from metrics-server with
+// clientset instead of one connected with the kubeconfig
+func WithMetricsClientset(clientset metrics.Interface) Option {
+	return func(c *commandConfig) {
+		c.metricsClientset = clientset
+	}
+}
+
+// WithDynamicClient lists VerticalPodAutoscalers and Karpenter NodePools
+// with client instead of one connected with the kubeconfig
+func WithDynamicClient(client dynamic.Interface) Option {
+	return func(c *commandConfig) {
+		c.dynamicClient = client
+	}
+}
+
+// WithClusterName names the cluster of the clients passed as options in
+// snapshots, history, and uploads, where the kube context would otherwise
+// be used. Without it, the host of the API server the clientset connects
+// to is used.
+func WithClusterName(name string) Option {
+	return func(c *commandConfig) {
+		c.clusterName = name
+	}
+}
+
+// WithIOStreams reads manifests from, prints reports to, and logs to
+// streams instead of stdin, stdout, and stderr
+func WithIOStreams(streams IOStreams) Option {
+	return func(c *commandConfig) {
+		c.streams = &streams
+	}
+}
+
+// NewKubeCapacityCommand builds the kube-capacity command with all of its
+// flags and subcommands, to be run on its own or added as a subcommand of
+// another CLI. Clients passed as options are used for the cluster instead
+// of clients connected with the kubeconfig.
+//
+// Each command binds its flags to its own options, and errors are returned
+// from Execute rather than exiting the process; capacity.ExitCode gives the
+// exit code kube-capacity would exit with. Commands do not share their
+// output, units, or logging, so they can be run at the same time.
+func NewKubeCapacityCommand(options ...Option) *cobra.Command {
+	config := &commandConfig{}
+	for _, option := range options {
+		option(config)
+	}
+
+	cmd := newRootCmd(&capacity.Options{
+		Clientset:        config.clientset,
+		MetricsClientset: config.metricsClientset,
+		DynamicClient:    config.dynamicClient,
+		ClusterName:      config.clusterName,
+	})
+
+	if config.streams != nil {
+		if config.streams.In != nil {
+			cmd.SetIn(config.streams.In)
+		}
+		if config.streams.Out != nil {
+			cmd.SetOut(config.streams.Out)
+		}
+		if config.streams.ErrOut != nil {
+			cmd.SetErr(config.streams.ErrOut)
+		}
+	}
+	return cmd
+}
+
+// injectedClientFlags pick the cluster and credentials from the kubeconfig,
+// which clients passed to NewKubeCapacityCommand are already connected with
+var injectedClientFlags = []string{"context", "kubeconfig", "insecure-skip-tls-verify", "as", "as-group", "contexts", "all-contexts"}
+
+func validateInjectedClients(cmd *cobra.Command, opts *capacity.Options) error {
+	if opts.Clientset == nil && opts.MetricsClientset == nil && opts.DynamicClient == nil {
+		return nil
+	}
+	for _, name := range injectedClientFlags {
+		if cmd.Flags().Changed(name) {
+			return fmt.Errorf("--%s can not be used when kube-capacity is given clients to connect with", name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/robscott/kube-capacity/pkg/capacity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestNewKubeCapacityCommand(t *testing.T) {
+	// Keep a config file in the user's config directory out of the test
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	var jsonOut, tableOut bytes.Buffer
+	jsonCmd := NewKubeCapacityCommand(
+		WithClientset(fake.NewSimpleClientset(testNode("json-node"))),
+		WithClusterName("json"),
+		WithIOStreams(IOStreams{Out: &jsonOut, ErrOut: &bytes.Buffer{}}),
+	)
+	tableCmd := NewKubeCapacityCommand(
+		WithClientset(fake.NewSimpleClientset(testNode("table-node"))),
+		WithClusterName("table"),
+		WithIOStreams(IOStreams{Out: &tableOut, ErrOut: &bytes.Buffer{}}),
+	)
+
+	jsonCmd.SetArgs([]string{"--output", "json"})
+	require.NoError(t, jsonCmd.Execute())
+	tableCmd.SetArgs([]string{})
+	require.NoError(t, tableCmd.Execute())
+
+	// Each command prints with its own flags and clients to its own stream
+	assert.True(t, json.Valid(jsonOut.Bytes()), jsonOut.String())
+	assert.Contains(t, jsonOut.String(), "json-node")
+	assert.NotContains(t, jsonOut.String(), "table-node")
+
+	assert.False(t, json.Valid(tableOut.Bytes()), tableOut.String())
+	assert.Contains(t, tableOut.String(), "NODE")
+	assert.Contains(t, tableOut.String(), "table-node")
+	assert.NotContains(t, tableOut.String(), "json-node")
+}
+
+func TestNewKubeCapacityCommandError(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	var out, errOut bytes.Buffer
+	cmd := NewKubeCapacityCommand(
+		WithClientset(fake.NewSimpleClientset()),
+		WithIOStreams(IOStreams{Out: &out, ErrOut: &errOut}),
+	)
+
+	// Errors are returned to the program kube-capacity is embedded in
+	// instead of being printed or exiting
+	cmd.SetArgs([]string{"--verbose", "--quiet"})
+	err := cmd.Execute()
+	require.EqualError(t, err, "--verbose and --quiet can not be used together")
+	assert.Equal(t, 1, capacity.ExitCode(err))
+	assert.Empty(t, out.String())
+	assert.Empty(t, errOut.String())
+
+	cmd = NewKubeCapacityCommand(
+		WithClientset(fake.NewSimpleClientset()),
+		WithIOStreams(IOStreams{Out: &out, ErrOut: &errOut}),
+	)
+	cmd.SetArgs([]string{"--context", "staging"})
+	assert.EqualError(t, cmd.Execute(), "--context can not be used when kube-capacity is given clients to connect with")
+}
+
+func TestNewKubeCapacityCommandClientsetOnly(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+	var out bytes.Buffer
+	cmd := NewKubeCapacityCommand(
+		WithClientset(fake.NewSimpleClientset(testNode("node"))),
+		WithIOStreams(IOStreams{Out: &out, ErrOut: &bytes.Buffer{}}),
+	)
+
+	// Usage from metrics-server can not be read without a metrics clientset,
+	// rather than from whatever cluster the kubeconfig points at
+	cmd.SetArgs([]string{"--util"})
+	assert.EqualError(t, cmd.Execute(), "utilization is read from metrics-server, but kube-capacity was given a clientset without a metrics clientset")
+	assert.Empty(t, out.String())
+}
+
+func testNode(name string) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status: corev1.NodeStatus{
+			Allocatable: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("4"),
+				corev1.ResourceMemory: resource.MustParse("16Gi"),
+			},
+		},
+	}
+}
@@ -0,0 +1,170 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/robscott/kube-capacity/pkg/capacity"
+	"github.com/robscott/kube-capacity/pkg/kube"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// completionTimeout bounds the API calls made while completing a flag so a
+// slow or unreachable cluster does not hang the shell
+const completionTimeout = 5 * time.Second
+
+// registerCompletions adds dynamic completion to flags whose values come
+// from the cluster, the kubeconfig, or a fixed list
+func registerCompletions(cmd *cobra.Command, opts *capacity.Options) {
+	completions := map[string]func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective){
+		"namespace":         completeNamespaces(opts),
+		"context":           completeContexts(opts),
+		"contexts":          completeContextList(opts),
+		"node-labels":       completeNodeLabels(opts),
+		"sort":              completeValues(capacity.SupportedSortAttributes[:]),
+		"output":            completeValuesAndPlugins(capacity.SupportedOutputs()),
+		"display-unit":      completeValues(capacity.SupportedDisplayUnits()),
+		"number-format":     completeValues(capacity.SupportedNumberFormats()),
+		"metrics-source":    completeValuesAndPlugins(capacity.SupportedMetricsSources()),
+		"prometheus-auth":   completeValues(capacity.SupportedPrometheusAuths()),
+		"usage-aggregation": completeValues(capacity.SupportedUsageAggregations()),
+	}
+	for name, f := range completions {
+		if err := cmd.RegisterFlagCompletionFunc(name, f); err != nil {
+			panic(fmt.Sprintf("registering completion for --%s: %v", name, err))
+		}
+	}
+}
+
+func completeValues(values []string) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return values, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// completeValuesAndPlugins completes values along with the plugins on PATH,
+// which are only looked for once completion is asked for
+func completeValuesAndPlugins(values []string) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return append(append([]string{}, values...), capacity.ListPlugins()...), cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+func completionClientSet(opts *capacity.Options) (kubernetes.Interface, error) {
+	if opts.Clientset != nil {
+		return opts.Clientset, nil
+	}
+	return kube.NewClientSet(opts.KubeContext, opts.KubeConfig, opts.InsecureSkipTLSVerify, opts.ImpersonateUser, opts.ImpersonateGroup,
+		kube.RateLimit{QPS: opts.KubeAPIQPS, Burst: opts.KubeAPIBurst})
+}
+
+func completeNamespaces(opts *capacity.Options) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		clientset, err := completionClientSet(opts)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), completionTimeout)
+		defer cancel()
+		namespaceList, err := clientset.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+
+		names := []string{}
+		for _, ns := range namespaceList.Items {
+			if strings.HasPrefix(ns.Name, toComplete) {
+				names = append(names, ns.Name)
+			}
+		}
+		return names, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+func completeContexts(opts *capacity.Options) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		names, err := kube.GetContextNames(opts.KubeConfig)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+		return names, cobra.ShellCompDirectiveNoFileComp
+	}
+}
+
+// completeContextList completes the last entry of a comma separated list of
+// contexts, leaving out contexts that are already listed
+func completeContextList(opts *capacity.Options) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		names, err := kube.GetContextNames(opts.KubeConfig)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+
+		prefix := ""
+		listed := map[string]bool{}
+		if i := strings.LastIndex(toComplete, ","); i >= 0 {
+			prefix = toComplete[:i+1]
+			for _, name := range strings.Split(toComplete[:i], ",") {
+				listed[name] = true
+			}
+		}
+
+		completions := []string{}
+		for _, name := range names {
+			if !listed[name] {
+				completions = append(completions, prefix+name)
+			}
+		}
+		return completions, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveNoSpace
+	}
+}
+
+// completeNodeLabels completes the key=value pairs of labels found on nodes
+func completeNodeLabels(opts *capacity.Options) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		clientset, err := completionClientSet(opts)
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), completionTimeout)
+		defer cancel()
+		nodeList, err := clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveError
+		}
+
+		seen := map[string]bool{}
+		for _, node := range nodeList.Items {
+			for key, value := range node.Labels {
+				seen[key+"="+value] = true
+			}
+		}
+		labels := []string{}
+		for label := range seen {
+			if strings.HasPrefix(label, toComplete) {
+				labels = append(labels, label)
+			}
+		}
+		sort.Strings(labels)
+		return labels, cobra.ShellCompDirectiveNoFileComp
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+)
+
+// defaultConfigFile returns the path of the config file in the user's config
+// directory, following XDG_CONFIG_HOME when it is set
+func defaultConfigFile() string {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	return filepath.Join(dir, "kube-capacity", "config.yaml")
+}
+
+// applyConfigFile sets flags from the config file at path, or at
+// defaultConfigFile when path is empty. Keys are the names of global flags,
+// and flags given on the command line take precedence. Values from the file
+// are marked as changed, so they are validated the same as flags given on
+// the command line.
+func applyConfigFile(cmd *cobra.Command, path string) error {
+	if path == "" {
+		path = defaultConfigFile()
+		if _, err := os.Stat(path); path == "" || errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("Error reading config file: %v", err)
+	}
+
+	// Numbers are kept as written, so that 1000000 is not passed on as 1e+06
+	config := map[string]interface{}{}
+	if err := yaml.Unmarshal(data, &config, func(d *json.Decoder) *json.Decoder {
+		d.UseNumber()
+		return d
+	}); err != nil {
+		return fmt.Errorf("Error parsing config file %s: %v", path, err)
+	}
+
+	names := []string{}
+	for name := range config {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if name == "config" || cmd.Root().PersistentFlags().Lookup(name) == nil {
+			return fmt.Errorf("Error in config file %s: unknown flag %q", path, name)
+		}
+		flag := cmd.Flags().Lookup(name)
+		if flag == nil || flag.Changed {
+			continue
+		}
+
+		values := []interface{}{config[name]}
+		if list, ok := config[name].([]interface{}); ok {
+			values = list
+		}
+		for _, value := range values {
+			if err := cmd.Flags().Set(name, fmt.Sprint(value)); err != nil {
+				return fmt.Errorf("Error in config file %s: invalid value %v for %q: %v", path, value, name, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/robscott/kube-capacity/pkg/capacity"
+	"github.com/spf13/cobra"
+)
+
+func newCostCmd(opts *capacity.Options) *cobra.Command {
+	costCmd := &cobra.Command{
+		Use:   "cost",
+		Short: "Attribute the cost of the cluster to namespaces",
+		Long: "Price each node by its instance type, split its cost between the pods on it by their requests or usage, " +
+			"and report the cost of each namespace along with the idle cost no pod accounts for.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := validateOutputType(opts.OutputFormat); err != nil {
+				return err
+			}
+
+			if err := validateDisplayUnits(opts.DisplayUnits); err != nil {
+				return err
+			}
+
+			if err := capacity.ValidateNumberFormat(*opts); err != nil {
+				return err
+			}
+
+			if err := validateSnapshotFlags(cmd, opts); err != nil {
+				return err
+			}
+
+			if err := validatePodFieldSelector(opts); err != nil {
+				return err
+			}
+
+			if err := validateNodeName(opts); err != nil {
+				return err
+			}
+
+			if !opts.CostByNamespace {
+				return fmt.Errorf("--by-namespace is required, use --cost for the cost of each node")
+			}
+			if err := validateCostAllocation(opts); err != nil {
+				return err
+			}
+
+			for _, name := range []string{"contexts", "all-contexts"} {
+				if cmd.Flags().Changed(name) {
+					return fmt.Errorf("--%s can not be used with cost", name)
+				}
+			}
+
+			return capacity.FetchAndPrintNamespaceCosts(*opts)
+		},
+	}
+	costCmd.Flags().BoolVarP(&opts.CostByNamespace,
+		"by-namespace", "", false, "attribute the cost of nodes to the namespaces of the pods on them")
+	costCmd.Flags().StringVarP(&opts.CostAllocation,
+		"allocate-by", "", capacity.AllocateCostByRequests,
+		fmt.Sprintf("what the cost of a node is attributed by, one of %v", capacity.SupportedCostAllocations()))
+	return costCmd
+}
+
+func validateCostAllocation(opts *capacity.Options) error {
+	for _, allocation := range capacity.SupportedCostAllocations() {
+		if opts.CostAllocation == allocation {
+			return nil
+		}
+	}
+	return fmt.Errorf("--allocate-by must be one of %v", capacity.SupportedCostAllocations())
+}
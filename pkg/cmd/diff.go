@@ -0,0 +1,63 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/robscott/kube-capacity/pkg/capacity"
+	"github.com/spf13/cobra"
+)
+
+func newDiffCmd(opts *capacity.Options) *cobra.Command {
+	diffCmd := &cobra.Command{
+		Use:   "diff file:<snapshot>|context:<name> file:<snapshot>|context:<name>",
+		Short: "Show per-node and per-namespace changes between two snapshots or contexts",
+		Long: "Show per-node and per-namespace changes in requests, limits, and utilization between two reports. " +
+			"Each argument is either file: and a file saved with --snapshot-out, or context: and the name of a kube context.",
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := validateOutputType(opts.OutputFormat); err != nil {
+				return err
+			}
+
+			if err := validateDisplayUnits(opts.DisplayUnits); err != nil {
+				return err
+			}
+
+			if err := capacity.ValidateNumberFormat(*opts); err != nil {
+				return err
+			}
+
+			for _, arg := range args {
+				snapshot, _, err := capacity.ParseDiffSource(arg)
+				if err != nil {
+					return err
+				}
+				// Every context would be read with the same injected clients
+				if !snapshot && opts.Clientset != nil {
+					return fmt.Errorf("%s can not be compared, only snapshots can be when kube-capacity is given clients to connect with", arg)
+				}
+			}
+
+			if opts.UsePrometheus || cmd.Flags().Changed("metrics-source") {
+				opts.ShowUtil = true
+			}
+
+			return capacity.FetchAndPrintDiff(args[0], args[1], *opts)
+		},
+	}
+	return diffCmd
+}
@@ -0,0 +1,70 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/robscott/kube-capacity/pkg/capacity"
+	"github.com/spf13/cobra"
+)
+
+func newDrainCheckCmd(opts *capacity.Options) *cobra.Command {
+	drainCheckCmd := &cobra.Command{
+		Use:   "drain-check NODE",
+		Short: "Check whether the pods of a node could be rescheduled if it were drained",
+		Long: "Simulate evicting the pods of a node and placing them first-fit-decreasing on the capacity left on the " +
+			"remaining nodes, respecting PodDisruptionBudgets, and report whether the drain would succeed and which pods " +
+			"would go pending.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := validateOutputType(opts.OutputFormat); err != nil {
+				return err
+			}
+
+			if err := validateDisplayUnits(opts.DisplayUnits); err != nil {
+				return err
+			}
+
+			if err := capacity.ValidateNumberFormat(*opts); err != nil {
+				return err
+			}
+
+			if err := validateSnapshotFlags(cmd, opts); err != nil {
+				return err
+			}
+
+			if err := validateNodeName(opts); err != nil {
+				return err
+			}
+
+			// kube-state-metrics does not export the node selectors, affinity,
+			// and tolerations that decide where a pod can be rescheduled
+			if opts.SpecSource == capacity.KubeStateMetricsSpecSource {
+				return fmt.Errorf("--spec-source %s can not be used with drain-check", capacity.KubeStateMetricsSpecSource)
+			}
+
+			for _, name := range []string{"contexts", "all-contexts"} {
+				if cmd.Flags().Changed(name) {
+					return fmt.Errorf("--%s can not be used with drain-check", name)
+				}
+			}
+
+			opts.DrainNode = args[0]
+			return capacity.FetchAndPrintDrainCheck(*opts)
+		},
+	}
+	return drainCheckCmd
+}
@@ -0,0 +1,86 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/robscott/kube-capacity/pkg/capacity"
+	"github.com/spf13/cobra"
+)
+
+func newFitCmd(opts *capacity.Options) *cobra.Command {
+	fitCmd := &cobra.Command{
+		Use:   "fit",
+		Short: "Check how many replicas of a workload fit on the current nodes",
+		Long: "Simulate first-fit-decreasing placement of replicas against the allocatable capacity left on each node " +
+			"after existing requests, and report how many replicas fit and which nodes would host them. " +
+			"Exits with code 5 when any replicas do not fit.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := validateOutputType(opts.OutputFormat); err != nil {
+				return err
+			}
+
+			if err := validateDisplayUnits(opts.DisplayUnits); err != nil {
+				return err
+			}
+
+			if err := capacity.ValidateNumberFormat(*opts); err != nil {
+				return err
+			}
+
+			if err := validateSnapshotFlags(cmd, opts); err != nil {
+				return err
+			}
+
+			fromManifests := opts.FitFromFile != "" || opts.FitFromDir != ""
+			if !fromManifests && opts.FitCPU == "" && opts.FitMemory == "" {
+				return fmt.Errorf("--cpu, --memory, --from-file, or --from-dir is required")
+			}
+			if fromManifests && (opts.FitCPU != "" || opts.FitMemory != "") {
+				return fmt.Errorf("--cpu and --memory can not be used with --from-file or --from-dir")
+			}
+			if opts.FitFromFile != "" && opts.FitFromDir != "" {
+				return fmt.Errorf("--from-file and --from-dir can not be used together")
+			}
+			if opts.FitReplicas < 0 {
+				return fmt.Errorf("--replicas must not be negative")
+			}
+			if !fromManifests && opts.FitReplicas == 0 {
+				opts.FitReplicas = 1
+			}
+
+			for _, name := range []string{"contexts", "all-contexts"} {
+				if cmd.Flags().Changed(name) {
+					return fmt.Errorf("--%s can not be used with fit", name)
+				}
+			}
+
+			return capacity.FetchAndPrintFit(*opts)
+		},
+	}
+	fitCmd.Flags().StringVarP(&opts.FitCPU,
+		"cpu", "", "", "CPU request of each replica (e.g. 2 or 500m)")
+	fitCmd.Flags().StringVarP(&opts.FitMemory,
+		"memory", "", "", "memory request of each replica (e.g. 4Gi)")
+	fitCmd.Flags().Int64VarP(&opts.FitReplicas,
+		"replicas", "", 0, "number of replicas to place (default 1, or the replicas in --from-file or --from-dir)")
+	fitCmd.Flags().StringVarP(&opts.FitFromFile,
+		"from-file", "f", "", "manifest with the Pods, Deployments, StatefulSets, ReplicaSets, or Jobs to place, or - to read it from stdin (e.g. helm template output)")
+	fitCmd.Flags().StringVarP(&opts.FitFromDir,
+		"from-dir", "", "", "directory with the .yaml, .yml, and .json manifests of the workloads to place, read recursively")
+	return fitCmd
+}
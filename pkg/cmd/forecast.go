@@ -0,0 +1,65 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/robscott/kube-capacity/pkg/capacity"
+	"github.com/spf13/cobra"
+)
+
+func newForecastCmd(opts *capacity.Options) *cobra.Command {
+	forecastCmd := &cobra.Command{
+		Use:   "forecast",
+		Short: "Estimate when requests will reach a share of allocatable from Prometheus history",
+		Long: "Fit a trend to the CPU and memory requests and utilization of each cluster and node pool over a window of " +
+			"Prometheus history, and estimate when requests will reach each --threshold of the current allocatable. " +
+			"Requests and allocatable are read from kube-state-metrics.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := validateOutputType(opts.OutputFormat); err != nil {
+				return err
+			}
+
+			if err := validateDisplayUnits(opts.DisplayUnits); err != nil {
+				return err
+			}
+
+			if err := capacity.ValidateNumberFormat(*opts); err != nil {
+				return err
+			}
+
+			if err := capacity.ValidateForecast(*opts); err != nil {
+				return err
+			}
+
+			for _, name := range []string{"snapshot-in", "from-kubectl-dump", "snapshot-out"} {
+				if cmd.Flags().Changed(name) {
+					return fmt.Errorf("--%s can not be used with forecast, history is read from Prometheus", name)
+				}
+			}
+
+			return capacity.FetchAndPrintForecast(*opts)
+		},
+	}
+	forecastCmd.Flags().StringVarP(&opts.ForecastWindow,
+		"window", "", capacity.DefaultForecastWindow, "time window of Prometheus history to fit trends to, such as 30d")
+	forecastCmd.Flags().StringVarP(&opts.ForecastHorizon,
+		"horizon", "", capacity.DefaultForecastHorizon, "how far ahead to project trends, such as 60d")
+	forecastCmd.Flags().Float64SliceVarP(&opts.ForecastThresholds,
+		"threshold", "", capacity.DefaultForecastThresholds, "percentage of allocatable to estimate when requests reach (can be repeated)")
+	return forecastCmd
+}
@@ -0,0 +1,74 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/robscott/kube-capacity/pkg/capacity"
+	"github.com/spf13/cobra"
+)
+
+func newHistoryCmd(opts *capacity.Options) *cobra.Command {
+	historyCmd := &cobra.Command{
+		Use:   "history",
+		Short: "Show capacity trends recorded with --history-file",
+		Long: "Show how the requests and utilization of the cluster, each node, and each namespace changed across the runs " +
+			"recorded in --history-file. CSV and TSV output list every recorded run for plotting in other tools.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := validateOutputType(opts.OutputFormat); err != nil {
+				return err
+			}
+
+			if err := validateDisplayUnits(opts.DisplayUnits); err != nil {
+				return err
+			}
+
+			if err := capacity.ValidateNumberFormat(*opts); err != nil {
+				return err
+			}
+
+			if opts.HistoryFile == "" {
+				return fmt.Errorf("--history-file is required")
+			}
+
+			if opts.HistorySince < 0 {
+				return fmt.Errorf("--since can not be negative")
+			}
+
+			if opts.HistoryKind != "" {
+				supported := false
+				for _, kind := range capacity.SupportedHistoryKinds() {
+					if kind == opts.HistoryKind {
+						supported = true
+					}
+				}
+				if !supported {
+					return fmt.Errorf("Unsupported kind %q. We only support: %v", opts.HistoryKind, capacity.SupportedHistoryKinds())
+				}
+			}
+
+			return capacity.PrintHistory(*opts)
+		},
+	}
+	historyCmd.Flags().DurationVarP(&opts.HistorySince,
+		"since", "", 0, "only show runs recorded within this long, such as 168h, 0 shows every run")
+	historyCmd.Flags().StringVarP(&opts.HistoryKind,
+		"kind", "", "", fmt.Sprintf("only show rows of this kind (supports: %v)", capacity.SupportedHistoryKinds()))
+	historyCmd.Flags().StringVarP(&opts.HistoryName,
+		"name", "", "", "only show the cluster, node, or namespace with this name")
+	return historyCmd
+}
@@ -0,0 +1,70 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/robscott/kube-capacity/pkg/capacity"
+	"github.com/spf13/cobra"
+)
+
+func newRecommendCmd(opts *capacity.Options) *cobra.Command {
+	recommendCmd := &cobra.Command{
+		Use:   "recommend",
+		Short: "Suggest container requests and limits from Prometheus usage history",
+		Long: "Compare the usage of each container in Prometheus over a time window with its current requests and limits, " +
+			"and suggest new values along with the capacity they would reclaim on each node.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := validateOutputType(opts.OutputFormat); err != nil {
+				return err
+			}
+
+			if err := validateDisplayUnits(opts.DisplayUnits); err != nil {
+				return err
+			}
+
+			if err := capacity.ValidateNumberFormat(*opts); err != nil {
+				return err
+			}
+
+			if err := validatePodFieldSelector(opts); err != nil {
+				return err
+			}
+
+			if err := validateNodeName(opts); err != nil {
+				return err
+			}
+
+			if opts.RecommendPercentile <= 0 || opts.RecommendPercentile > 100 {
+				return fmt.Errorf("--percentile must be greater than 0 and at most 100")
+			}
+
+			for _, name := range []string{"snapshot-in", "from-kubectl-dump", "snapshot-out", "contexts", "all-contexts"} {
+				if cmd.Flags().Changed(name) {
+					return fmt.Errorf("--%s can not be used with recommend, usage history is read from Prometheus", name)
+				}
+			}
+
+			return capacity.FetchAndPrintRecommendations(*opts)
+		},
+	}
+	recommendCmd.Flags().StringVarP(&opts.RecommendWindow,
+		"window", "", "7d", "time window of Prometheus usage history to base recommendations on")
+	recommendCmd.Flags().Float64VarP(&opts.RecommendPercentile,
+		"percentile", "", 95, "percentile of usage over the window to recommend as the request")
+	return recommendCmd
+}
@@ -16,37 +16,241 @@ package cmd
 
 import (
 	"fmt"
+	"net/url"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/robscott/kube-capacity/pkg/capacity"
+	"github.com/robscott/kube-capacity/pkg/kube"
 	"github.com/spf13/cobra"
 )
 
-var opts capacity.Options
+// newRootCmd builds the kube-capacity command with its flags bound to opts
+// and every subcommand added to it
+func newRootCmd(opts *capacity.Options) *cobra.Command {
+	// configFile is set with --config, otherwise defaultConfigFile is used
+	// if it exists
+	var configFile string
 
-var rootCmd = &cobra.Command{
-	Use:   "kube-capacity",
-	Short: "kube-capacity provides an overview of the resource requests, limits, and utilization in a Kubernetes cluster.",
-	Long:  "kube-capacity provides an overview of the resource requests, limits, and utilization in a Kubernetes cluster.",
-	Run: func(cmd *cobra.Command, args []string) {
-		if err := cmd.ParseFlags(args); err != nil {
-			fmt.Printf("Error parsing flags: %v", err)
-		}
+	rootCmd := &cobra.Command{
+		Use:   "kube-capacity",
+		Short: "kube-capacity provides an overview of the resource requests, limits, and utilization in a Kubernetes cluster.",
+		Long:  "kube-capacity provides an overview of the resource requests, limits, and utilization in a Kubernetes cluster.",
+		// Errors are printed by Execute, or by the program kube-capacity
+		// is embedded in
+		SilenceErrors: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			// The flags and arguments were accepted, so errors from here on
+			// are not about usage
+			cmd.SilenceUsage = true
+			opts.In, opts.Out, opts.ErrOut = cmd.InOrStdin(), cmd.OutOrStdout(), cmd.ErrOrStderr()
 
-		if err := validateOutputType(opts.OutputFormat); err != nil {
-			fmt.Println(err)
-			os.Exit(1)
-		}
+			if err := applyConfigFile(cmd, configFile); err != nil {
+				return err
+			}
 
-		if opts.UsePrometheus {
-			opts.ShowUtil = true
-		}
+			if opts.Verbose && opts.Quiet {
+				return fmt.Errorf("--verbose and --quiet can not be used together")
+			}
 
-		capacity.FetchAndPrint(opts)
-	},
-}
+			if opts.ShowQueries && opts.Quiet {
+				return fmt.Errorf("--show-queries and --quiet can not be used together")
+			}
+
+			if opts.ChunkSize < 0 {
+				return fmt.Errorf("--chunk-size can not be negative")
+			}
+
+			if opts.HistoryRetention < 0 {
+				return fmt.Errorf("--history-retention can not be negative")
+			}
+
+			if opts.KubeAPIQPS <= 0 || opts.KubeAPIBurst < 1 {
+				return fmt.Errorf("--kube-api-qps must be greater than 0 and --kube-api-burst at least 1")
+			}
+
+			if err := validateMetricsSource(cmd, opts); err != nil {
+				return err
+			}
+
+			if err := validateInjectedClients(cmd, opts); err != nil {
+				return err
+			}
+
+			// The extension of --output-file picks the format unless -o is given
+			if opts.OutputFile != "" && !cmd.Flags().Changed("output") {
+				if format := capacity.OutputFormatForFile(opts.OutputFile); format != "" {
+					opts.OutputFormat = format
+				}
+			}
+			resolveOutputPlugin(opts)
+			return nil
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := cmd.ParseFlags(args); err != nil {
+				fmt.Fprintf(cmd.ErrOrStderr(), "Error parsing flags: %v\n", err)
+			}
+
+			if err := validateReportOutputType(cmd, opts); err != nil {
+				return err
+			}
+
+			if err := validateDisplayUnits(opts.DisplayUnits); err != nil {
+				return err
+			}
+
+			if err := capacity.ValidateNumberFormat(*opts); err != nil {
+				return err
+			}
+
+			if err := validateSnapshotFlags(cmd, opts); err != nil {
+				return err
+			}
+
+			if err := validateContextFlags(cmd, opts); err != nil {
+				return err
+			}
+
+			if opts.Compact && (opts.AvailableFormat || opts.OutputFormat != capacity.TableOutput) {
+				return fmt.Errorf("--compact can only be used with table output and without --available")
+			}
+
+			if err := validateViewFlags(cmd); err != nil {
+				return err
+			}
+
+			if err := validateStreamFlags(opts); err != nil {
+				return err
+			}
+
+			if err := validateAlsoOutput(cmd, opts); err != nil {
+				return err
+			}
+
+			if err := validateCompareOffset(opts); err != nil {
+				return err
+			}
+
+			if err := validateShowDelta(opts); err != nil {
+				return err
+			}
+
+			if err := validateSparkline(opts); err != nil {
+				return err
+			}
+
+			if err := validateAnomalyFlags(opts); err != nil {
+				return err
+			}
+
+			if err := validateSpecSource(cmd, opts); err != nil {
+				return err
+			}
+
+			if err := validateDaemonFlags(cmd, opts); err != nil {
+				return err
+			}
+
+			if err := validateNotifyFlags(opts); err != nil {
+				return err
+			}
+
+			if err := validateHeatmap(opts); err != nil {
+				return err
+			}
+
+			if err := validateColumns(cmd, opts); err != nil {
+				return err
+			}
+
+			if err := validateFilter(cmd, opts); err != nil {
+				return err
+			}
+
+			if err := validateVirtualNodes(opts); err != nil {
+				return err
+			}
+
+			if err := validatePodFieldSelector(opts); err != nil {
+				return err
+			}
+
+			if err := validateNodeName(opts); err != nil {
+				return err
+			}
 
-func init() {
+			if err := validateSimulateRemoveNodes(opts); err != nil {
+				return err
+			}
+
+			if err := validateHPAHeadroom(opts); err != nil {
+				return err
+			}
+
+			if err := validatePreemption(opts); err != nil {
+				return err
+			}
+
+			if err := validateKarpenter(opts); err != nil {
+				return err
+			}
+
+			if opts.ExcludeDaemonSets && opts.ShowDaemonSetOverhead {
+				return fmt.Errorf("--exclude-daemonsets can not be used with --daemonset-overhead")
+			}
+
+			if opts.UsePrometheus || cmd.Flags().Changed("metrics-source") {
+				opts.ShowUtil = true
+			}
+
+			if opts.ShowMetricsAge || opts.CompareOffset > 0 || opts.ShowSparkline || opts.ShowAnomalies || capacity.HeatmapNeedsUtil(opts.Heatmap) || capacity.ColumnsNeedUtil(opts.Columns) {
+				opts.ShowUtil = true
+			}
+
+			// kubectl top only shows usage
+			if opts.OutputFormat == capacity.TopOutput {
+				opts.ShowUtil = true
+			}
+
+			if opts.ShowVPA {
+				opts.ShowContainers = true
+			}
+
+			if (opts.ShowThrottling || opts.ShowRestarts) && !opts.ShowContainers {
+				opts.ShowPods = true
+			}
+
+			if err := capacity.ValidateFailOn(*opts); err != nil {
+				return err
+			}
+
+			if opts.PricingFile != "" {
+				opts.ShowCost = true
+			}
+
+			if opts.ShowReservedBreakdown {
+				opts.ShowCapacity = true
+			}
+
+			if err := capacity.ValidateInjectedClients(*opts); err != nil {
+				return err
+			}
+
+			if opts.Interval > 0 || opts.Upload != "" {
+				return capacity.RunDaemon(*opts)
+			}
+			return capacity.FetchAndPrint(*opts)
+		},
+	}
+
+	rootCmd.PersistentFlags().BoolVarP(&opts.Verbose,
+		"verbose", "v", false, "log API calls, Prometheus queries, and timings to stderr")
+	rootCmd.PersistentFlags().BoolVarP(&opts.Quiet,
+		"quiet", "q", false, "only log errors to stderr")
+	rootCmd.PersistentFlags().StringVarP(&configFile,
+		"config", "", "",
+		"config file of default flag values (default ~/.config/kube-capacity/config.yaml)")
 	rootCmd.PersistentFlags().BoolVarP(&opts.ShowContainers,
 		"containers", "c", false, "includes containers in output")
 	rootCmd.PersistentFlags().BoolVarP(&opts.ShowPods,
@@ -56,15 +260,55 @@ func init() {
 	rootCmd.PersistentFlags().BoolVarP(&opts.ShowPodCount,
 		"pod-count", "", false, "includes pod count per node in output")
 	rootCmd.PersistentFlags().BoolVarP(&opts.AvailableFormat,
-		"available", "a", false, "includes quantity available instead of percentage used")
+		"available", "a", false, "includes quantity available instead of percentage used, or an available column with csv, tsv, json, or yaml output")
+	rootCmd.PersistentFlags().BoolVarP(&opts.Compact,
+		"compact", "", false, "only show percentages for requests, limits, and utilization with shorter headers in table output")
+	rootCmd.PersistentFlags().BoolVarP(&opts.Stream,
+		"stream", "", false,
+		"print each node as soon as it is read and the totals last, nodes are printed by name and --sort only orders pods and containers")
+	rootCmd.PersistentFlags().StringSliceVarP(&opts.DisplayUnits,
+		"display-unit", "", []string{},
+		fmt.Sprintf("units to display CPU and memory in, may be given once for each (supports: %v)", capacity.SupportedDisplayUnits()))
+	rootCmd.PersistentFlags().IntVarP(&opts.Precision,
+		"precision", "", capacity.DefaultPrecision, "number of decimals to round CPU and memory quantities to")
+	rootCmd.PersistentFlags().IntVarP(&opts.PercentPrecision,
+		"percent-precision", "", 0, "number of decimals to show percentages with, rounded down")
+	rootCmd.PersistentFlags().StringVarP(&opts.NumberFormat,
+		"number-format", "", capacity.HumanNumberFormat,
+		fmt.Sprintf("human leaves out trailing zeros, fixed shows every decimal of the precision so numbers line up (supports: %v)", capacity.SupportedNumberFormats()))
+	rootCmd.PersistentFlags().StringVarP(&opts.ThousandsSeparator,
+		"thousands-separator", "", "", `separator to group the thousands of numbers with, such as "," or "_"`)
 	rootCmd.PersistentFlags().StringVarP(&opts.PodLabels,
 		"pod-labels", "l", "", "labels to filter pods with")
+	rootCmd.PersistentFlags().StringVarP(&opts.PodFieldSelector,
+		"pod-field-selector", "", "",
+		"field selector to filter pods with on the API server (e.g. spec.nodeName=node-12 or status.phase=Running)")
 	rootCmd.PersistentFlags().StringVarP(&opts.NodeLabels,
 		"node-labels", "", "", "labels to filter nodes with")
+	rootCmd.PersistentFlags().StringVarP(&opts.NodeName,
+		"node-name", "", "", "glob or /regex/ to filter nodes by name with (e.g. 'gpu-*')")
+	rootCmd.PersistentFlags().StringVarP(&opts.Filter,
+		"filter", "", "",
+		"expression to filter nodes, pods, and containers with (e.g. 'node.cpu.util > 80 || pod.mem.requests == 0')")
 	rootCmd.PersistentFlags().BoolVarP(&opts.ExcludeTainted,
 		"no-taint", "", false, "exclude nodes with taints")
+	rootCmd.PersistentFlags().StringVarP(&opts.VirtualNodes,
+		"virtual-nodes", "", capacity.IncludeVirtualNodes,
+		fmt.Sprintf("how to count virtual-kubelet and Fargate nodes (supports: %v)", capacity.SupportedVirtualNodeModes()))
 	rootCmd.PersistentFlags().StringVarP(&opts.NodeTaints,
 		"node-taints", "t", "", "comma seperated list of taints to filter nodes with, prefix taint with '!' to filter out")
+	rootCmd.PersistentFlags().StringArrayVarP(&opts.FailOn,
+		"fail-on", "", []string{},
+		"exit with code 5 when a threshold like cpu.requests>90% or node.memory.util>=85% is breached, may be given more than once")
+	rootCmd.PersistentFlags().StringArrayVarP(&opts.NotifyOn,
+		"notify-on", "", []string{},
+		"notify --notify-webhook when a threshold like --fail-on is breached without failing, may be given more than once")
+	rootCmd.PersistentFlags().StringVarP(&opts.NotifyWebhook,
+		"notify-webhook", "", "",
+		"URL to post breached --fail-on and --notify-on thresholds to")
+	rootCmd.PersistentFlags().StringVarP(&opts.NotifyFormat,
+		"notify-format", "", capacity.AutoNotifyFormat,
+		fmt.Sprintf("payload to post to --notify-webhook, auto sends Slack messages to Slack webhooks and JSON otherwise (supports: %v)", capacity.SupportedNotifyFormats()))
 	rootCmd.PersistentFlags().StringVarP(&opts.NamespaceLabels,
 		"namespace-labels", "", "", "labels to filter namespaces with")
 	rootCmd.PersistentFlags().StringVarP(&opts.Namespace,
@@ -73,6 +317,18 @@ func init() {
 		"context", "", "", "context to use for Kubernetes config")
 	rootCmd.PersistentFlags().StringVarP(&opts.KubeConfig,
 		"kubeconfig", "", "", "kubeconfig file to use for Kubernetes config")
+	rootCmd.PersistentFlags().Int64VarP(&opts.ChunkSize,
+		"chunk-size", "", capacity.DefaultChunkSize,
+		"number of pods and nodes to request per list call, 0 lists everything in a single response")
+	rootCmd.PersistentFlags().BoolVarP(&opts.ListPodsByNode,
+		"pods-by-node", "", false,
+		"list the pods of each node separately with a spec.nodeName field selector instead of across the cluster")
+	rootCmd.PersistentFlags().Float32VarP(&opts.KubeAPIQPS,
+		"kube-api-qps", "", kube.DefaultQPS,
+		"queries per second to the Kubernetes API server, past which requests wait for the client-side rate limit")
+	rootCmd.PersistentFlags().IntVarP(&opts.KubeAPIBurst,
+		"kube-api-burst", "", kube.DefaultBurst,
+		"requests to the Kubernetes API server allowed in a burst above --kube-api-qps")
 	rootCmd.PersistentFlags().BoolVarP(&opts.InsecureSkipTLSVerify,
 		"insecure-skip-tls-verify", "", false, "If true, the server's certificate will not be checked for validity. This will make your HTTPS connections insecure")
 	rootCmd.PersistentFlags().StringVarP(&opts.SortBy,
@@ -80,7 +336,16 @@ func init() {
 		fmt.Sprintf("attribute to sort results by (supports: %v)", capacity.SupportedSortAttributes))
 	rootCmd.PersistentFlags().StringVarP(&opts.OutputFormat,
 		"output", "o", capacity.TableOutput,
-		fmt.Sprintf("output format for information (supports: %v)", capacity.SupportedOutputs()))
+		fmt.Sprintf("output format for information, or the name of a %s<name> plugin on PATH to print the JSON report with (supports: %v)", capacity.PluginPrefix, capacity.SupportedReportOutputs()))
+	rootCmd.PersistentFlags().BoolVarP(&opts.ShowCharts,
+		"charts", "", false,
+		"include bar charts of requests against allocatable and of utilization in html output")
+	rootCmd.PersistentFlags().StringVarP(&opts.OutputFile,
+		"output-file", "", "",
+		"write output to this file instead of stdout, the format is picked from a .csv, .tsv, .json, .yaml, .html, .svg, or .txt extension unless --output is given (gzip compressed if it ends in .gz)")
+	rootCmd.PersistentFlags().StringSliceVarP(&opts.AlsoOutput,
+		"also-output", "", []string{},
+		"also write the report to these files from the same run, as <format>=<path> or a path with the extension of a format (e.g. json=report.json,csv=report.csv)")
 	rootCmd.PersistentFlags().StringVarP(&opts.ImpersonateUser,
 		"as", "", "", "user to impersonate kube-capacity with")
 	rootCmd.PersistentFlags().StringVarP(&opts.ImpersonateGroup,
@@ -89,10 +354,24 @@ func init() {
 		"hide-requests", "", false, "hide requests from output")
 	rootCmd.PersistentFlags().BoolVarP(&opts.HideLimits,
 		"hide-limits", "", false, "hide limits from output")
+	rootCmd.PersistentFlags().StringSliceVarP(&opts.Columns,
+		"columns", "", []string{},
+		"resource columns to show in table output in place of CPU and memory, as <resource> or <resource>.<requests|limits|util|available> "+
+			"for cpu, memory, gpu, ephemeral-storage, and pods (e.g. cpu.requests,gpu,pods.available)")
 	rootCmd.PersistentFlags().BoolVarP(&opts.ShowLabels,
 		"show-labels", "", false, "includes node labels in output")
+	rootCmd.PersistentFlags().BoolVarP(&opts.ShowNodeStatus,
+		"show-node-status", "", false, "includes the roles, Ready condition, schedulability, and age of each node in output")
+	rootCmd.PersistentFlags().BoolVarP(&opts.ShowTaints,
+		"show-taints", "", false, "includes node taints in output along with the totals of nodes with NoSchedule or NoExecute taints")
+	rootCmd.PersistentFlags().StringVarP(&opts.MetricsSource,
+		"metrics-source", "", capacity.MetricsServerSource,
+		fmt.Sprintf("where to read utilization data from, auto tries each source in turn, or the name of a %s<name> plugin on PATH to run (supports: %v, implies --util)", capacity.PluginPrefix, capacity.SupportedMetricsSources()))
+	rootCmd.PersistentFlags().StringVarP(&opts.SpecSource,
+		"spec-source", "", capacity.APISpecSource,
+		fmt.Sprintf("where to read requests, limits, and allocatable from, kube-state-metrics queries the Prometheus of --prometheus-endpoint instead of listing pods and nodes (supports: %v)", capacity.SupportedSpecSources()))
 	rootCmd.PersistentFlags().BoolVarP(&opts.UsePrometheus,
-		"prometheus", "", false, "use Prometheus instead of metrics-server for utilization data (implies --util)")
+		"prometheus", "", false, "use Prometheus instead of metrics-server for utilization data, the same as --metrics-source=prometheus (implies --util)")
 	rootCmd.PersistentFlags().StringVarP(&opts.PrometheusEndpoint,
 		"prometheus-endpoint", "", "",
 		"Prometheus endpoint as namespace/service:port or direct URL; auto-discovered via app.kubernetes.io/name=prometheus label if not set")
@@ -102,16 +381,277 @@ func init() {
 	rootCmd.PersistentFlags().StringVarP(&opts.PrometheusAggregation,
 		"prometheus-aggregation", "", "avg",
 		"aggregation function for Prometheus metrics over the window: avg (default) or max")
+	rootCmd.PersistentFlags().StringVarP(&opts.UsageAggregation,
+		"usage-aggregation", "", capacity.AvgUsageAggregation,
+		fmt.Sprintf("how to aggregate utilization over --usage-window, max shows the peak (supports: %v)", capacity.SupportedUsageAggregations()))
+	rootCmd.PersistentFlags().DurationVarP(&opts.CompareOffset,
+		"compare-offset", "", 0,
+		"show how node and namespace utilization changed since this long ago, such as 24h (needs --prometheus)")
+	rootCmd.PersistentFlags().BoolVarP(&opts.ShowDelta,
+		"show-delta", "", false,
+		"show how the requests, limits, and utilization of each node and namespace changed since the last run against the same cluster")
+	rootCmd.PersistentFlags().BoolVarP(&opts.ShowSparkline,
+		"sparkline", "", false,
+		"includes a sparkline of CPU and memory usage over --prometheus-window for the cluster, nodes, and pods in output (needs --prometheus, implies --util)")
+	rootCmd.PersistentFlags().BoolVarP(&opts.ShowThrottling,
+		"show-throttling", "", false,
+		"includes the share of CFS periods each pod and container was CPU throttled in over --prometheus-window in output (needs --prometheus, implies --pods)")
+	rootCmd.PersistentFlags().DurationVarP(&opts.UsageWindow,
+		"usage-window", "", 0,
+		"time window to aggregate utilization over, such as 24h, with a prometheus, datadog, or cloudwatch --metrics-source")
+	rootCmd.PersistentFlags().StringVarP(&opts.PrometheusAuth,
+		"prometheus-auth", "", capacity.NoPrometheusAuth,
+		fmt.Sprintf("how to authenticate Prometheus queries, azure tries workload identity, managed identity, then the Azure CLI (supports: %v)", capacity.SupportedPrometheusAuths()))
+	rootCmd.PersistentFlags().StringVarP(&opts.PrometheusUsername,
+		"prometheus-username", "", "",
+		"username for basic auth to a --prometheus-endpoint URL")
+	rootCmd.PersistentFlags().StringVarP(&opts.PrometheusPassword,
+		"prometheus-password", "", "",
+		fmt.Sprintf("password for basic auth to a --prometheus-endpoint URL, read from %s if not set", capacity.PrometheusPasswordEnv))
+	rootCmd.PersistentFlags().StringVarP(&opts.PrometheusPasswordFile,
+		"prometheus-password-file", "", "",
+		"file to read the password for basic auth to a --prometheus-endpoint URL from")
+	rootCmd.PersistentFlags().StringArrayVarP(&opts.PrometheusHeaders,
+		"prometheus-header", "", nil,
+		`header to send with queries to a --prometheus-endpoint URL, as "Name: Value" (can be repeated)`)
+	rootCmd.PersistentFlags().StringVarP(&opts.PrometheusCert,
+		"prometheus-cert", "", "",
+		"client certificate file to present to an https:// --prometheus-endpoint, needs --prometheus-key")
+	rootCmd.PersistentFlags().StringVarP(&opts.PrometheusKey,
+		"prometheus-key", "", "",
+		"private key file of --prometheus-cert")
+	rootCmd.PersistentFlags().StringVarP(&opts.PrometheusCA,
+		"prometheus-ca", "", "",
+		"CA certificate file to verify an https:// --prometheus-endpoint with instead of the system roots")
+	rootCmd.PersistentFlags().BoolVarP(&opts.PrometheusSkipTLSVerify,
+		"prometheus-insecure-skip-tls-verify", "", false,
+		"skip verifying the certificate of an https:// --prometheus-endpoint, for self-signed lab setups only")
+	rootCmd.PersistentFlags().StringVarP(&opts.PrometheusProxyURL,
+		"prometheus-proxy-url", "", "",
+		"proxy to send queries to a --prometheus-endpoint URL through, instead of the one from HTTPS_PROXY and HTTP_PROXY")
+	rootCmd.PersistentFlags().StringVarP(&opts.PrometheusNodeLabel,
+		"prometheus-node-label", "", "node",
+		"label cAdvisor series name the node by, such as instance or kubernetes_node, resolved to node names through kube_node_info")
+	rootCmd.PersistentFlags().StringVarP(&opts.PrometheusPodLabel,
+		"prometheus-pod-label", "", "pod",
+		"label cAdvisor series name the pod by, such as pod_name")
+	rootCmd.PersistentFlags().StringVarP(&opts.PrometheusNamespaceLabel,
+		"prometheus-namespace-label", "", "namespace",
+		"label cAdvisor series name the namespace by, such as kubernetes_namespace")
+	rootCmd.PersistentFlags().StringVarP(&opts.PrometheusContainerLabel,
+		"prometheus-container-label", "", "container",
+		"label cAdvisor series name the container by, such as container_name")
+	rootCmd.PersistentFlags().BoolVarP(&opts.PrometheusRecordingRules,
+		"prometheus-recording-rules", "", true,
+		"read container usage from the kube-prometheus recording rules when Prometheus has them, instead of raw cAdvisor series")
+	rootCmd.PersistentFlags().IntVarP(&opts.PrometheusQueryShards,
+		"prometheus-query-shards", "", 1,
+		"split the container usage queries by namespace into this many queries run at the same time, for clusters too large for one Prometheus response")
+	rootCmd.PersistentFlags().BoolVarP(&opts.ShowQueries,
+		"show-queries", "", false,
+		"log each Prometheus query with the endpoint it is sent to, the series and samples it returned, and how long it took to stderr")
+	rootCmd.PersistentFlags().StringVarP(&opts.DatadogAPIKey,
+		"datadog-api-key", "", "",
+		"Datadog API key for --metrics-source=datadog, read from DD_API_KEY if not set")
+	rootCmd.PersistentFlags().StringVarP(&opts.DatadogAppKey,
+		"datadog-app-key", "", "",
+		"Datadog application key for --metrics-source=datadog, read from DD_APP_KEY if not set")
+	rootCmd.PersistentFlags().StringVarP(&opts.DatadogSite,
+		"datadog-site", "", "",
+		fmt.Sprintf("Datadog site to query, read from DD_SITE if not set (default %q)", capacity.DefaultDatadogSite))
+	rootCmd.PersistentFlags().StringVarP(&opts.DatadogScope,
+		"datadog-scope", "", "",
+		"tags to filter Datadog metrics with, such as kube_cluster_name:prod (default all)")
+	rootCmd.PersistentFlags().DurationVarP(&opts.DatadogWindow,
+		"datadog-window", "", capacity.DefaultDatadogWindow,
+		"time window Datadog metrics are averaged over")
+	rootCmd.PersistentFlags().StringVarP(&opts.CloudWatchCluster,
+		"cloudwatch-cluster", "", "",
+		"EKS cluster name for --metrics-source=cloudwatch, read from the kube context if it is a cluster ARN")
+	rootCmd.PersistentFlags().StringVarP(&opts.Region,
+		"region", "", "",
+		"AWS region for --metrics-source=cloudwatch and S3 uploads, read from AWS_REGION or the kube context if not set")
+	rootCmd.PersistentFlags().DurationVarP(&opts.CloudWatchWindow,
+		"cloudwatch-window", "", capacity.DefaultCloudWatchWindow,
+		"time window CloudWatch metrics are averaged over")
+	rootCmd.PersistentFlags().StringVarP(&opts.CustomMetricsCPU,
+		"custom-metrics-cpu", "", capacity.DefaultCustomMetricsCPU,
+		"metric with the CPU usage in cores for --metrics-source=custom-metrics or external-metrics")
+	rootCmd.PersistentFlags().StringVarP(&opts.CustomMetricsMemory,
+		"custom-metrics-memory", "", capacity.DefaultCustomMetricsMemory,
+		"metric with the memory usage in bytes for --metrics-source=custom-metrics or external-metrics")
 	rootCmd.PersistentFlags().StringVarP(&opts.UtilPercent,
 		"util-percent", "", "node",
 		"base for utilization percentage: node (default, % of node allocatable), request (% of resource request), limit (% of resource limit)")
+	rootCmd.PersistentFlags().BoolVarP(&opts.ShowMetricsAge,
+		"show-metrics-age", "", false,
+		"includes how long ago the utilization of each node was measured in output (implies --util)")
+	rootCmd.PersistentFlags().DurationVarP(&opts.MetricsMaxAge,
+		"metrics-max-age", "", capacity.DefaultMetricsMaxAge,
+		"warn when the utilization of a node was measured longer ago than this, 0 disables the warning")
+	rootCmd.PersistentFlags().DurationVarP(&opts.CacheTTL,
+		"cache-ttl", "", 0,
+		"reuse metrics fetched by an earlier run for the same cluster and query within this long, such as 5m, 0 disables the cache")
+	rootCmd.PersistentFlags().BoolVarP(&opts.CheckAccess,
+		"check-access", "", false,
+		"check that the current user can make every request the report needs before fetching, and name any permission that is missing")
+	rootCmd.PersistentFlags().StringVarP(&opts.SnapshotOut,
+		"snapshot-out", "", "",
+		"save fetched nodes, pods, and metrics to this file (gzip compressed if it ends in .gz)")
+	rootCmd.PersistentFlags().StringVarP(&opts.HistoryFile,
+		"history-file", "", "",
+		"append the requests, limits, and utilization of the cluster, each node, and each namespace to this file, see kube-capacity history")
+	rootCmd.PersistentFlags().DurationVarP(&opts.HistoryRetention,
+		"history-retention", "", 90*24*time.Hour,
+		"drop runs recorded longer ago than this from --history-file, such as 720h, 0 keeps every run")
+	rootCmd.PersistentFlags().DurationVarP(&opts.Interval,
+		"interval", "", 0,
+		"keep running and print or upload a new report this often, such as 1h, 0 runs once")
+	rootCmd.PersistentFlags().StringVarP(&opts.Upload,
+		"upload", "", "",
+		"upload the report and a snapshot of each run to s3://bucket/prefix or gs://bucket/prefix")
+	rootCmd.PersistentFlags().StringVarP(&opts.S3Endpoint,
+		"s3-endpoint", "", "",
+		"endpoint of an S3-compatible store for s3:// uploads, such as MinIO or a FIPS endpoint, read from AWS_ENDPOINT_URL_S3 or AWS_ENDPOINT_URL if not set")
+	rootCmd.PersistentFlags().StringVarP(&opts.SnapshotIn,
+		"snapshot-in", "", "",
+		"render output from a file saved with --snapshot-out instead of querying the cluster")
+	rootCmd.PersistentFlags().StringSliceVarP(&opts.FromKubectlDump,
+		"from-kubectl-dump", "", nil,
+		"render output from comma separated files written by kubectl get nodes, pods, and optionally podmetrics -o json instead of querying the cluster")
+	rootCmd.PersistentFlags().StringSliceVarP(&opts.Contexts,
+		"contexts", "", nil,
+		"comma separated list of contexts to aggregate into a single report with a CLUSTER column")
+	rootCmd.PersistentFlags().BoolVarP(&opts.AllContexts,
+		"all-contexts", "", false, "aggregate every context in the kubeconfig into a single report")
+	rootCmd.PersistentFlags().BoolVarP(&opts.ShowQuotas,
+		"quotas", "", false, "compare namespace requests and limits against ResourceQuota hard limits")
+	rootCmd.PersistentFlags().BoolVarP(&opts.ApplyLimitRangeDefaults,
+		"apply-limitrange-defaults", "", false,
+		"apply LimitRange default requests and limits to containers that do not set them")
+	rootCmd.PersistentFlags().BoolVarP(&opts.IncludeTerminated,
+		"include-terminated", "", false,
+		"include succeeded and failed pods, which the scheduler no longer counts, in requests, limits, and pod counts")
+	rootCmd.PersistentFlags().BoolVarP(&opts.ExcludeDaemonSets,
+		"exclude-daemonsets", "", false,
+		"leave DaemonSet pods out of requests, limits, and pod counts to see the capacity left for application workloads")
+	rootCmd.PersistentFlags().BoolVarP(&opts.IgnoreInitContainers,
+		"ignore-init-containers", "", false,
+		"sum the requests and limits of app containers and sidecars only instead of accounting for init containers the way the scheduler does")
+	rootCmd.PersistentFlags().BoolVarP(&opts.ShowVPA,
+		"show-vpa", "", false, "includes VerticalPodAutoscaler recommendations for containers in output (implies --containers)")
+	rootCmd.PersistentFlags().BoolVarP(&opts.ShowOvercommit,
+		"overcommit", "", false, "includes the ratio of limits to allocatable for CPU and memory in output")
+	rootCmd.PersistentFlags().BoolVarP(&opts.ShowOverhead,
+		"show-overhead", "", false,
+		"includes the pod overhead from RuntimeClasses, which is already counted in requests, as separate columns in output")
+	rootCmd.PersistentFlags().BoolVarP(&opts.ShowResize,
+		"show-resize", "", false,
+		"includes pods waiting on an in-place resize of their requests in output")
+	rootCmd.PersistentFlags().BoolVarP(&opts.ShowRestarts,
+		"show-restarts", "", false,
+		"includes the restart count and last OOMKill of each pod and container from its status in output (implies --pods)")
+	rootCmd.PersistentFlags().BoolVarP(&opts.ShowCapacity,
+		"show-capacity", "", false,
+		"includes the capacity, allocatable, and reserved CPU and memory of each node in output")
+	rootCmd.PersistentFlags().BoolVarP(&opts.ShowReservedBreakdown,
+		"reserved-breakdown", "", false,
+		"splits reserved CPU and memory into kube-reserved, system-reserved, and the eviction threshold from the configuration of each kubelet (implies --show-capacity)")
+	rootCmd.PersistentFlags().BoolVarP(&opts.ShowDisk,
+		"show-disk", "", false,
+		"includes the nodefs and imagefs usage of each node from its kubelet, or the root filesystem from node_exporter with --metrics-source=prometheus, and flags nodes near their disk eviction thresholds")
+	rootCmd.PersistentFlags().BoolVarP(&opts.ShowCost,
+		"cost", "", false, "includes the hourly, idle, and monthly cost of each node based on its instance type")
+	rootCmd.PersistentFlags().StringVarP(&opts.PricingFile,
+		"pricing-file", "", "",
+		"CSV file of instance_type,hourly_price rows that add to or override the bundled prices (implies --cost)")
+	rootCmd.PersistentFlags().StringVarP(&opts.SchedulableBy,
+		"schedulable-by", "", "",
+		"only include nodes that the pod in this manifest could be scheduled on based on its node selector, required node affinity, and tolerations")
+	rootCmd.PersistentFlags().BoolVarP(&opts.ShowPending,
+		"pending", "", false,
+		"list pods waiting to be scheduled with their requests and the most common reason the scheduler gave")
+	rootCmd.PersistentFlags().BoolVarP(&opts.ShowDaemonSetOverhead,
+		"daemonset-overhead", "", false,
+		"split the requests on each node between DaemonSet pods and other workloads and show the capacity left for workloads")
+	rootCmd.PersistentFlags().BoolVarP(&opts.ShowAnomalies,
+		"anomalies", "", false,
+		"only list containers whose usage is over their limit, or far over or under their request (implies --util)")
+	rootCmd.PersistentFlags().Float64VarP(&opts.AnomalyOverRequest,
+		"anomaly-over-request", "", capacity.DefaultAnomalyOverRequest,
+		"with --anomalies, flag containers using more than this multiple of their request")
+	rootCmd.PersistentFlags().Float64VarP(&opts.AnomalyUnderRequest,
+		"anomaly-under-request", "", capacity.DefaultAnomalyUnderRequest,
+		"with --anomalies, flag containers using less than this percent of their request")
+	rootCmd.PersistentFlags().BoolVarP(&opts.ShowStuckPods,
+		"stuck-pods", "", false,
+		"only list pods that hold requests on a node without running, such as pods stuck in ContainerCreating or Terminating, or with --util pods reporting no usage")
+	rootCmd.PersistentFlags().DurationVarP(&opts.StuckAfter,
+		"stuck-after", "", capacity.DefaultStuckAfter,
+		"with --stuck-pods, how long a pod has to be stuck before it is listed")
+	rootCmd.PersistentFlags().StringVarP(&opts.Preemption,
+		"preemption", "", "",
+		"list the lower priority pods on each node that would be preempted to schedule a pod of this shape and priority, such as cpu=2,memory=4Gi,priority=1000")
+	rootCmd.PersistentFlags().BoolVarP(&opts.ShowMissingResources,
+		"missing-resources", "", false,
+		"only list containers that set no CPU or memory request or no limit, counted by namespace and workload")
+	rootCmd.PersistentFlags().StringVarP(&opts.Heatmap,
+		"heatmap", "", "",
+		"draw nodes as a grid of cells colored by cpu.requests, cpu.limits, cpu.util, memory.requests, memory.limits, or memory.util (util implies --util)")
+	rootCmd.PersistentFlags().BoolVarP(&opts.ShowHeadroom,
+		"headroom", "", false,
+		"estimate the capacity of each node group scaled to the maximum allowed by the Cluster Autoscaler or Karpenter")
+	rootCmd.PersistentFlags().BoolVarP(&opts.ShowKarpenter,
+		"karpenter", "", false,
+		"group nodes by Karpenter NodePool against the NodePool limits and flag nodes that could be consolidated")
+	rootCmd.PersistentFlags().BoolVarP(&opts.ShowCapacityType,
+		"capacity-type", "", false,
+		"split allocatable, requests, and utilization between spot and on-demand nodes")
+	rootCmd.PersistentFlags().BoolVarP(&opts.ShowArch,
+		"arch", "", false,
+		"summarize allocatable, requests, and available capacity for each CPU architecture")
+	rootCmd.PersistentFlags().BoolVarP(&opts.ShowZoneBalance,
+		"zone-balance", "", false,
+		"compare capacity across availability zones and flag zones that would be overloaded if another zone failed")
+	rootCmd.PersistentFlags().BoolVarP(&opts.ShowGPU,
+		"gpu", "", false,
+		"report the NVIDIA GPU resources of each node, including MIG profiles and time-sliced replicas, advertised and requested")
+	rootCmd.PersistentFlags().StringVarP(&opts.SimulateRemoveNodes,
+		"simulate-remove-nodes", "", "",
+		"number of least requested nodes, or a label selector for the nodes, to simulate removing and report the pods that would no longer fit")
+	rootCmd.PersistentFlags().BoolVarP(&opts.ShowHPAHeadroom,
+		"hpa-headroom", "", false,
+		"check whether each HPA could scale to maxReplicas at the current requests of its pods and flag those that would exceed capacity")
+	rootCmd.PersistentFlags().StringVarP(&opts.AllocateByPodLabel,
+		"allocate-by-pod-label", "", "",
+		"sum requests, limits, utilization, and cost by the value of this pod label, such as team")
+
+	registerCompletions(rootCmd, opts)
+
+	rootCmd.AddCommand(
+		newCostCmd(opts),
+		newDiffCmd(opts),
+		newDrainCheckCmd(opts),
+		newFitCmd(opts),
+		newForecastCmd(opts),
+		newHistoryCmd(opts),
+		newRecommendCmd(opts),
+		newSeriesCmd(opts),
+		newSimulateCmd(opts),
+		newSlackCmd(opts),
+		newVerifyCmd(opts),
+		newVersionCmd(),
+	)
+	return rootCmd
 }
 
-// Execute is the primary entrypoint for this CLI
+// Execute is the primary entrypoint for this CLI. It prints the error of a
+// failed run and exits with its exit code.
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Println(err)
-		os.Exit(1)
+	cmd := NewKubeCapacityCommand()
+	if err := cmd.Execute(); err != nil {
+		fmt.Fprintln(cmd.ErrOrStderr(), err)
+		os.Exit(capacity.ExitCode(err))
 	}
 }
 
@@ -121,5 +661,726 @@ func validateOutputType(outputType string) error {
 			return nil
 		}
 	}
-	return fmt.Errorf("Unsupported Output Type. We only support: %v", capacity.SupportedOutputs())
+	return fmt.Errorf("Unsupported Output Type. We only support: %v, or a %s<name> plugin on PATH", capacity.SupportedOutputs(), capacity.PluginPrefix)
+}
+
+// resolveOutputPlugin looks up a printer plugin for an --output that is not
+// built in, which is then sent the JSON report to print. Without one, the
+// output type is left to be rejected by the command.
+func resolveOutputPlugin(opts *capacity.Options) {
+	for _, format := range capacity.SupportedReportOutputs() {
+		if format == opts.OutputFormat {
+			return
+		}
+	}
+	path, err := capacity.FindPlugin(opts.OutputFormat)
+	if err != nil {
+		return
+	}
+	opts.OutputPlugin = path
+	opts.OutputFormat = capacity.JSONOutput
+}
+
+// validateReportOutputType checks --output of the node report, which can
+// also be html, svg or top, and --charts
+func validateReportOutputType(cmd *cobra.Command, opts *capacity.Options) error {
+	supported := false
+	for _, format := range capacity.SupportedReportOutputs() {
+		if format == opts.OutputFormat {
+			supported = true
+		}
+	}
+	if !supported {
+		return fmt.Errorf("Unsupported Output Type. We only support: %v, or a %s<name> plugin on PATH", capacity.SupportedReportOutputs(), capacity.PluginPrefix)
+	}
+	if opts.ShowCharts && opts.OutputFormat != capacity.HTMLOutput {
+		return fmt.Errorf("--charts can only be used with html output, svg output is the charts alone")
+	}
+	if opts.OutputFormat == capacity.TopOutput && (len(opts.Contexts) > 0 || opts.AllContexts) {
+		return fmt.Errorf("top output can not be used with --contexts or --all-contexts")
+	}
+	if opts.OutputFormat != capacity.HTMLOutput && opts.OutputFormat != capacity.SVGOutput && opts.OutputFormat != capacity.TopOutput {
+		return nil
+	}
+	for _, name := range viewFlags {
+		if cmd.Flags().Changed(name) {
+			return fmt.Errorf("--%s can not be used with %s output", name, opts.OutputFormat)
+		}
+	}
+	return nil
+}
+
+// validateAlsoOutput checks --also-output, whose files can be in any format
+// the report printed can be, except for kubectl top output
+func validateAlsoOutput(cmd *cobra.Command, opts *capacity.Options) error {
+	if len(opts.AlsoOutput) == 0 {
+		return nil
+	}
+	supported := capacity.SupportedOutputs()
+	viewSet := false
+	for _, name := range viewFlags {
+		if cmd.Flags().Changed(name) {
+			viewSet = true
+		}
+	}
+	if !viewSet {
+		supported = append(supported, capacity.HTMLOutput, capacity.SVGOutput)
+	}
+	// --heatmap and --columns are only printed as tables
+	if opts.Heatmap != "" || len(opts.Columns) > 0 {
+		supported = []string{capacity.TableOutput}
+	}
+	if err := capacity.ValidateAlsoOutputs(opts.AlsoOutput, supported); err != nil {
+		return fmt.Errorf("--also-output: %w", err)
+	}
+	return nil
+}
+
+// validateMetricsSource checks --metrics-source and makes --prometheus set it
+func validateMetricsSource(cmd *cobra.Command, opts *capacity.Options) error {
+	if opts.UsePrometheus {
+		if cmd.Flags().Changed("metrics-source") && opts.MetricsSource != capacity.PrometheusSource {
+			return fmt.Errorf("--prometheus can not be used with --metrics-source=%s", opts.MetricsSource)
+		}
+		opts.MetricsSource = capacity.PrometheusSource
+	}
+	if err := validateUsageFlags(cmd, opts); err != nil {
+		return err
+	}
+	if opts.PrometheusQueryShards < 1 {
+		return fmt.Errorf("--prometheus-query-shards must be at least 1")
+	}
+	if opts.MetricsSource == capacity.DatadogSource && opts.DatadogWindow <= 0 {
+		return fmt.Errorf("--datadog-window must be greater than 0")
+	}
+	if opts.MetricsSource == capacity.CloudWatchSource && opts.CloudWatchWindow < time.Minute {
+		return fmt.Errorf("--cloudwatch-window must be at least 1m")
+	}
+	if opts.CacheTTL < 0 {
+		return fmt.Errorf("--cache-ttl can not be negative")
+	}
+	if err := validatePrometheusAuth(opts); err != nil {
+		return err
+	}
+	if err := capacity.ValidatePrometheusLabels(*opts); err != nil {
+		return err
+	}
+	for _, source := range capacity.SupportedMetricsSources() {
+		if source == opts.MetricsSource {
+			return nil
+		}
+	}
+	if path, err := capacity.FindPlugin(opts.MetricsSource); err == nil {
+		opts.MetricsPlugin = path
+		return nil
+	}
+	return fmt.Errorf("Unsupported Metrics Source %q. We only support: %v, or a %s<name> plugin on PATH", opts.MetricsSource, capacity.SupportedMetricsSources(), capacity.PluginPrefix)
+}
+
+// validateUsageFlags checks --usage-aggregation and --usage-window and
+// applies them to the window and aggregation of the metrics source
+func validateUsageFlags(cmd *cobra.Command, opts *capacity.Options) error {
+	aggregationChanged, windowChanged := cmd.Flags().Changed("usage-aggregation"), cmd.Flags().Changed("usage-window")
+	if !aggregationChanged && !windowChanged {
+		return nil
+	}
+
+	supported := false
+	for _, aggregation := range capacity.SupportedUsageAggregations() {
+		if aggregation == opts.UsageAggregation {
+			supported = true
+		}
+	}
+	if !supported {
+		return fmt.Errorf("Unsupported Usage Aggregation %q. We only support: %v", opts.UsageAggregation, capacity.SupportedUsageAggregations())
+	}
+	if windowChanged && opts.UsageWindow <= 0 {
+		return fmt.Errorf("--usage-window must be greater than 0")
+	}
+
+	var sourceWindow string
+	switch opts.MetricsSource {
+	case capacity.PrometheusSource:
+		sourceWindow = "prometheus-window"
+		if aggregationChanged {
+			if cmd.Flags().Changed("prometheus-aggregation") {
+				return fmt.Errorf("--usage-aggregation can not be used with --prometheus-aggregation")
+			}
+			opts.PrometheusAggregation = opts.UsageAggregation
+		}
+		if windowChanged {
+			opts.PrometheusWindow = capacity.PromQLDuration(opts.UsageWindow)
+		}
+	case capacity.DatadogSource:
+		sourceWindow = "datadog-window"
+		if windowChanged {
+			opts.DatadogWindow = opts.UsageWindow
+		}
+	case capacity.CloudWatchSource:
+		sourceWindow = "cloudwatch-window"
+		if windowChanged {
+			opts.CloudWatchWindow = opts.UsageWindow
+		}
+	default:
+		return fmt.Errorf("--usage-aggregation and --usage-window need a metrics source with usage history (supports: %v)", capacity.WindowedMetricsSources())
+	}
+	if windowChanged && cmd.Flags().Changed(sourceWindow) {
+		return fmt.Errorf("--usage-window can not be used with --%s", sourceWindow)
+	}
+	return nil
+}
+
+// validatePrometheusAuth checks --prometheus-auth, the basic auth flags,
+// --prometheus-header, and the TLS and proxy flags, which need an endpoint
+// URL since they can not be applied through the API server proxy
+func validatePrometheusAuth(opts *capacity.Options) error {
+	supported := false
+	for _, auth := range capacity.SupportedPrometheusAuths() {
+		if auth == opts.PrometheusAuth {
+			supported = true
+		}
+	}
+	if !supported {
+		return fmt.Errorf("Unsupported Prometheus Auth %q. We only support: %v", opts.PrometheusAuth, capacity.SupportedPrometheusAuths())
+	}
+	if opts.PrometheusAuth != capacity.NoPrometheusAuth && !strings.HasPrefix(opts.PrometheusEndpoint, "https://") {
+		return fmt.Errorf("--prometheus-auth=%s needs an https:// --prometheus-endpoint", opts.PrometheusAuth)
+	}
+
+	if (opts.PrometheusCert == "") != (opts.PrometheusKey == "") {
+		return fmt.Errorf("--prometheus-cert and --prometheus-key must be used together")
+	}
+	if (opts.PrometheusCert != "" || opts.PrometheusCA != "") && !strings.HasPrefix(opts.PrometheusEndpoint, "https://") {
+		return fmt.Errorf("--prometheus-cert and --prometheus-ca need an https:// --prometheus-endpoint")
+	}
+	if opts.PrometheusSkipTLSVerify {
+		if !strings.HasPrefix(opts.PrometheusEndpoint, "https://") {
+			return fmt.Errorf("--prometheus-insecure-skip-tls-verify needs an https:// --prometheus-endpoint")
+		}
+		if opts.PrometheusCA != "" {
+			return fmt.Errorf("--prometheus-insecure-skip-tls-verify and --prometheus-ca cannot be used together")
+		}
+	}
+
+	if opts.PrometheusProxyURL != "" {
+		if _, err := capacity.ParsePrometheusProxyURL(opts.PrometheusProxyURL); err != nil {
+			return fmt.Errorf("--prometheus-proxy-url: %v", err)
+		}
+		if !strings.HasPrefix(opts.PrometheusEndpoint, "http://") && !strings.HasPrefix(opts.PrometheusEndpoint, "https://") {
+			return fmt.Errorf("--prometheus-proxy-url needs an http:// or https:// --prometheus-endpoint")
+		}
+	}
+
+	if len(opts.PrometheusHeaders) > 0 {
+		header, err := capacity.ParsePrometheusHeaders(opts.PrometheusHeaders)
+		if err != nil {
+			return err
+		}
+		if !strings.HasPrefix(opts.PrometheusEndpoint, "http://") && !strings.HasPrefix(opts.PrometheusEndpoint, "https://") {
+			return fmt.Errorf("--prometheus-header needs an http:// or https:// --prometheus-endpoint")
+		}
+		if header.Get("Authorization") != "" && (opts.PrometheusUsername != "" || opts.PrometheusAuth != capacity.NoPrometheusAuth) {
+			return fmt.Errorf("an Authorization --prometheus-header can not be used with --prometheus-username or --prometheus-auth")
+		}
+	}
+
+	if opts.PrometheusUsername == "" {
+		if opts.PrometheusPassword != "" || opts.PrometheusPasswordFile != "" {
+			return fmt.Errorf("--prometheus-password and --prometheus-password-file need --prometheus-username")
+		}
+		return nil
+	}
+	if opts.PrometheusAuth != capacity.NoPrometheusAuth {
+		return fmt.Errorf("--prometheus-username can not be used with --prometheus-auth=%s", opts.PrometheusAuth)
+	}
+	if opts.PrometheusPassword != "" && opts.PrometheusPasswordFile != "" {
+		return fmt.Errorf("--prometheus-password can not be used with --prometheus-password-file")
+	}
+	if !strings.HasPrefix(opts.PrometheusEndpoint, "http://") && !strings.HasPrefix(opts.PrometheusEndpoint, "https://") {
+		return fmt.Errorf("--prometheus-username needs an http:// or https:// --prometheus-endpoint")
+	}
+	return nil
+}
+
+func validateDisplayUnits(displayUnits []string) error {
+	for _, unit := range displayUnits {
+		supported := false
+		for _, s := range capacity.SupportedDisplayUnits() {
+			if s == unit {
+				supported = true
+			}
+		}
+		if !supported {
+			return fmt.Errorf("Unsupported Display Unit %q. We only support: %v", unit, capacity.SupportedDisplayUnits())
+		}
+	}
+	return nil
+}
+
+// snapshotConflictingFlags are flags that only apply when querying a live
+// cluster. --context is checked against the snapshot itself.
+var snapshotConflictingFlags = []string{
+	"kubeconfig",
+	"as",
+	"as-group",
+	"insecure-skip-tls-verify",
+	"chunk-size",
+	"kube-api-qps",
+	"kube-api-burst",
+	"pods-by-node",
+	"metrics-source",
+	"prometheus",
+	"spec-source",
+	"prometheus-endpoint",
+	"prometheus-window",
+	"prometheus-aggregation",
+	"prometheus-auth",
+	"prometheus-username",
+	"prometheus-password",
+	"prometheus-password-file",
+	"prometheus-header",
+	"prometheus-cert",
+	"prometheus-key",
+	"prometheus-ca",
+	"prometheus-insecure-skip-tls-verify",
+	"prometheus-proxy-url",
+	"prometheus-node-label",
+	"prometheus-pod-label",
+	"prometheus-namespace-label",
+	"prometheus-container-label",
+	"prometheus-recording-rules",
+	"prometheus-query-shards",
+	"show-queries",
+	"datadog-api-key",
+	"datadog-app-key",
+	"datadog-site",
+	"datadog-scope",
+	"datadog-window",
+	"cloudwatch-cluster",
+	"cloudwatch-window",
+	"custom-metrics-cpu",
+	"custom-metrics-memory",
+	"region",
+	"usage-aggregation",
+	"usage-window",
+	"compare-offset",
+	"sparkline",
+	"show-throttling",
+	"cache-ttl",
+	"check-access",
+}
+
+func validateSnapshotFlags(cmd *cobra.Command, opts *capacity.Options) error {
+	if len(opts.FromKubectlDump) > 0 {
+		if opts.SnapshotIn != "" {
+			return fmt.Errorf("--from-kubectl-dump and --snapshot-in can not be used together")
+		}
+		for _, name := range append([]string{"context"}, snapshotConflictingFlags...) {
+			if cmd.Flags().Changed(name) {
+				return fmt.Errorf("--%s can not be used with --from-kubectl-dump, the files are rendered without cluster access", name)
+			}
+		}
+		return nil
+	}
+	if opts.SnapshotIn == "" {
+		return nil
+	}
+	for _, name := range snapshotConflictingFlags {
+		if cmd.Flags().Changed(name) {
+			return fmt.Errorf("--%s can not be used with --snapshot-in, the snapshot is rendered without cluster access", name)
+		}
+	}
+	return nil
+}
+
+func validateContextFlags(cmd *cobra.Command, opts *capacity.Options) error {
+	if len(opts.Contexts) == 0 && !opts.AllContexts {
+		return nil
+	}
+	if len(opts.Contexts) > 0 && opts.AllContexts {
+		return fmt.Errorf("--contexts and --all-contexts can not be used together")
+	}
+	for _, name := range []string{"context", "snapshot-in", "from-kubectl-dump", "snapshot-out", "quotas", "headroom", "karpenter", "capacity-type", "arch", "zone-balance", "gpu", "simulate-remove-nodes", "hpa-headroom", "allocate-by-pod-label", "pending", "daemonset-overhead", "anomalies", "stuck-pods", "preemption", "missing-resources", "show-delta", "heatmap"} {
+		if cmd.Flags().Changed(name) {
+			return fmt.Errorf("--%s can not be used with --contexts or --all-contexts", name)
+		}
+	}
+	return nil
+}
+
+// viewFlags replace the node report with a different report
+var viewFlags = []string{"quotas", "headroom", "karpenter", "capacity-type", "arch", "zone-balance", "gpu", "simulate-remove-nodes", "hpa-headroom", "allocate-by-pod-label", "pending", "daemonset-overhead", "anomalies", "stuck-pods", "preemption", "missing-resources", "compare-offset", "show-delta", "heatmap"}
+
+func validateViewFlags(cmd *cobra.Command) error {
+	changed := []string{}
+	for _, name := range viewFlags {
+		if cmd.Flags().Changed(name) {
+			changed = append(changed, "--"+name)
+		}
+	}
+	if len(changed) > 1 {
+		return fmt.Errorf("%s can not be used together", strings.Join(changed, " and "))
+	}
+	return nil
+}
+
+// validateCompareOffset checks that --compare-offset has a Prometheus to
+// query and a single cluster to compare
+func validateCompareOffset(opts *capacity.Options) error {
+	if opts.CompareOffset < 0 {
+		return fmt.Errorf("--compare-offset can not be negative")
+	}
+	if opts.CompareOffset == 0 {
+		return nil
+	}
+	if opts.MetricsSource != capacity.PrometheusSource {
+		return fmt.Errorf("--compare-offset needs Prometheus, use it with --prometheus")
+	}
+	if len(opts.Contexts) > 0 || opts.AllContexts {
+		return fmt.Errorf("--compare-offset can not be used with --contexts or --all-contexts")
+	}
+	return nil
+}
+
+// validateShowDelta checks that --show-delta reads a live cluster, as only
+// reports of live clusters are cached to compare with
+func validateShowDelta(opts *capacity.Options) error {
+	if !opts.ShowDelta {
+		return nil
+	}
+	if opts.SnapshotIn != "" || len(opts.FromKubectlDump) > 0 {
+		return fmt.Errorf("--show-delta can not be used with --snapshot-in or --from-kubectl-dump, only reports of a live cluster are compared")
+	}
+	return nil
+}
+
+// kubeStateMetricsConflictingFlags filter on labels, taints, or objects
+// that kube-state-metrics does not export by default
+var kubeStateMetricsConflictingFlags = []string{
+	"pod-labels",
+	"pod-field-selector",
+	"node-labels",
+	"no-taint",
+	"node-taints",
+	"namespace-labels",
+	"pods-by-node",
+	"schedulable-by",
+	"virtual-nodes",
+	"karpenter",
+	"capacity-type",
+	"arch",
+	"zone-balance",
+	"gpu",
+	"simulate-remove-nodes",
+	"hpa-headroom",
+	"allocate-by-pod-label",
+	"preemption",
+	"missing-resources",
+	"exclude-daemonsets",
+	"snapshot-out",
+}
+
+// validateSpecSource checks --spec-source and the flags that need pods and
+// nodes from the API server
+func validateSpecSource(cmd *cobra.Command, opts *capacity.Options) error {
+	switch opts.SpecSource {
+	case capacity.APISpecSource:
+		return nil
+	case capacity.KubeStateMetricsSpecSource:
+	default:
+		return fmt.Errorf("Unsupported Spec Source %q. We only support: %v", opts.SpecSource, capacity.SupportedSpecSources())
+	}
+	for _, name := range kubeStateMetricsConflictingFlags {
+		if cmd.Flags().Changed(name) {
+			return fmt.Errorf("--%s can not be used with --spec-source=%s", name, capacity.KubeStateMetricsSpecSource)
+		}
+	}
+	return nil
+}
+
+// daemonConflictingFlags stop a run or write files that --upload writes
+// itself
+var daemonConflictingFlags = []struct {
+	name   string
+	upload bool
+}{
+	{"fail-on", false},
+	{"snapshot-in", false},
+	{"from-kubectl-dump", false},
+	{"output-file", true},
+	{"also-output", true},
+	{"snapshot-out", true},
+	{"contexts", true},
+	{"all-contexts", true},
+}
+
+// validateDaemonFlags checks --interval and --upload
+func validateDaemonFlags(cmd *cobra.Command, opts *capacity.Options) error {
+	if opts.Interval < 0 {
+		return fmt.Errorf("--interval can not be negative")
+	}
+	if opts.Interval > 0 && opts.Interval < capacity.MinInterval {
+		return fmt.Errorf("--interval must be at least %s", capacity.MinInterval)
+	}
+	if opts.Upload != "" {
+		if err := capacity.ValidateUpload(opts.Upload); err != nil {
+			return err
+		}
+	}
+	if opts.S3Endpoint != "" {
+		if !strings.HasPrefix(opts.Upload, "s3://") {
+			return fmt.Errorf("--s3-endpoint can only be used with an s3:// --upload")
+		}
+		if err := capacity.ValidateS3Endpoint(opts.S3Endpoint); err != nil {
+			return err
+		}
+	}
+	for _, conflict := range daemonConflictingFlags {
+		if !cmd.Flags().Changed(conflict.name) {
+			continue
+		}
+		if opts.Upload != "" && conflict.upload {
+			return fmt.Errorf("--%s can not be used with --upload", conflict.name)
+		}
+		if (opts.Interval > 0 || opts.Upload != "") && !conflict.upload {
+			return fmt.Errorf("--%s can not be used with --interval or --upload", conflict.name)
+		}
+	}
+	return nil
+}
+
+// validateNotifyFlags checks that --notify-webhook has thresholds to notify
+// about and that --notify-on has somewhere to notify
+func validateNotifyFlags(opts *capacity.Options) error {
+	switch opts.NotifyFormat {
+	case capacity.AutoNotifyFormat, capacity.JSONNotifyFormat, capacity.SlackNotifyFormat:
+	default:
+		return fmt.Errorf("Unsupported Notify Format %q. We only support: %v", opts.NotifyFormat, capacity.SupportedNotifyFormats())
+	}
+	if len(opts.NotifyOn) > 0 && opts.NotifyWebhook == "" {
+		return fmt.Errorf("--notify-on needs a --notify-webhook to notify")
+	}
+	if opts.NotifyWebhook == "" {
+		return nil
+	}
+	if len(opts.NotifyOn) == 0 && len(opts.FailOn) == 0 {
+		return fmt.Errorf("--notify-webhook needs thresholds to notify about, set --notify-on or --fail-on")
+	}
+	u, err := url.Parse(opts.NotifyWebhook)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("--notify-webhook must be an http:// or https:// URL")
+	}
+	return nil
+}
+
+// validateSparkline checks that --sparkline and --show-throttling have a
+// Prometheus to query
+func validateSparkline(opts *capacity.Options) error {
+	if opts.ShowSparkline && opts.MetricsSource != capacity.PrometheusSource {
+		return fmt.Errorf("--sparkline needs Prometheus, use it with --prometheus")
+	}
+	if opts.ShowThrottling && opts.MetricsSource != capacity.PrometheusSource {
+		return fmt.Errorf("--show-throttling needs Prometheus, use it with --prometheus")
+	}
+	return nil
+}
+
+// validateAnomalyFlags checks the thresholds of --anomalies and
+// --stuck-pods
+func validateAnomalyFlags(opts *capacity.Options) error {
+	if opts.AnomalyOverRequest <= 1 {
+		return fmt.Errorf("--anomaly-over-request must be greater than 1")
+	}
+	if opts.AnomalyUnderRequest <= 0 || opts.AnomalyUnderRequest >= 100 {
+		return fmt.Errorf("--anomaly-under-request must be greater than 0 and less than 100")
+	}
+	if opts.StuckAfter <= 0 {
+		return fmt.Errorf("--stuck-after must be greater than 0")
+	}
+	return nil
+}
+
+// validateHeatmap checks the --heatmap metric, the heatmap is only drawn
+// as text
+func validateHeatmap(opts *capacity.Options) error {
+	if opts.Heatmap == "" {
+		return nil
+	}
+	if err := capacity.ValidateHeatmap(opts.Heatmap); err != nil {
+		return fmt.Errorf("--heatmap: %w", err)
+	}
+	if opts.OutputFormat != capacity.TableOutput {
+		return fmt.Errorf("--heatmap can only be used with table output")
+	}
+	return nil
+}
+
+// validateColumns checks --columns, which replace the CPU and memory columns
+// of the table and so can not be combined with the flags that add to them
+func validateColumns(cmd *cobra.Command, opts *capacity.Options) error {
+	if len(opts.Columns) == 0 {
+		return nil
+	}
+	if err := capacity.ValidateColumns(opts.Columns); err != nil {
+		return fmt.Errorf("--columns: %w", err)
+	}
+	if opts.OutputFormat != capacity.TableOutput {
+		return fmt.Errorf("--columns can only be used with table output")
+	}
+	for _, name := range append([]string{
+		"contexts", "all-contexts", "stream", "compact", "available", "hide-requests", "hide-limits",
+		"show-capacity", "reserved-breakdown", "show-overhead", "show-vpa", "overcommit", "sparkline", "show-throttling",
+	}, viewFlags...) {
+		if cmd.Flags().Changed(name) {
+			return fmt.Errorf("--columns can not be used with --%s", name)
+		}
+	}
+	return nil
+}
+
+// validateFilter checks the --filter expression, which filters the rows of
+// the default report
+func validateFilter(cmd *cobra.Command, opts *capacity.Options) error {
+	if opts.Filter == "" {
+		return nil
+	}
+	if err := capacity.ValidateFilter(*opts); err != nil {
+		return fmt.Errorf("--filter: %w", err)
+	}
+	for _, name := range append([]string{"stream"}, viewFlags...) {
+		if cmd.Flags().Changed(name) {
+			return fmt.Errorf("--filter can not be used with --%s", name)
+		}
+	}
+	return nil
+}
+
+// validateVirtualNodes checks the --virtual-nodes mode
+func validateVirtualNodes(opts *capacity.Options) error {
+	for _, mode := range capacity.SupportedVirtualNodeModes() {
+		if opts.VirtualNodes == mode {
+			return nil
+		}
+	}
+	return fmt.Errorf("--virtual-nodes must be one of %v", capacity.SupportedVirtualNodeModes())
+}
+
+// validateKarpenter rejects pod filters with --karpenter, since the pods
+// left out would still need room on the nodes a candidate is drained onto
+func validateKarpenter(opts *capacity.Options) error {
+	if !opts.ShowKarpenter {
+		return nil
+	}
+	if opts.Namespace != "" || opts.NamespaceLabels != "" || opts.PodLabels != "" || opts.PodFieldSelector != "" {
+		return fmt.Errorf("--karpenter can not be used with --namespace, --namespace-labels, --pod-labels, or --pod-field-selector")
+	}
+	return nil
+}
+
+func validatePodFieldSelector(opts *capacity.Options) error {
+	if opts.PodFieldSelector == "" {
+		return nil
+	}
+	if err := capacity.ValidatePodFieldSelector(opts.PodFieldSelector); err != nil {
+		return fmt.Errorf("--pod-field-selector: %w", err)
+	}
+	return nil
+}
+
+func validateNodeName(opts *capacity.Options) error {
+	if opts.NodeName == "" {
+		return nil
+	}
+	if err := capacity.ValidateNodeName(opts.NodeName); err != nil {
+		return fmt.Errorf("--node-name: %w", err)
+	}
+	return nil
+}
+
+func validateSimulateRemoveNodes(opts *capacity.Options) error {
+	if opts.SimulateRemoveNodes == "" {
+		return nil
+	}
+	if err := capacity.ValidateSimulateRemoveNodes(opts.SimulateRemoveNodes); err != nil {
+		return fmt.Errorf("--simulate-remove-nodes: %w", err)
+	}
+	if opts.Namespace != "" || opts.NamespaceLabels != "" || opts.PodLabels != "" || opts.PodFieldSelector != "" {
+		return fmt.Errorf("--simulate-remove-nodes can not be used with --namespace, --namespace-labels, --pod-labels, or --pod-field-selector")
+	}
+	return nil
+}
+
+func validateHPAHeadroom(opts *capacity.Options) error {
+	if !opts.ShowHPAHeadroom {
+		return nil
+	}
+	if opts.Namespace != "" || opts.NamespaceLabels != "" || opts.PodLabels != "" || opts.PodFieldSelector != "" {
+		return fmt.Errorf("--hpa-headroom can not be used with --namespace, --namespace-labels, --pod-labels, or --pod-field-selector")
+	}
+	return nil
+}
+
+// validatePreemption checks the pod shape of --preemption and rejects pod
+// filters, since the pods left out could be preempted too
+func validatePreemption(opts *capacity.Options) error {
+	if opts.Preemption == "" {
+		return nil
+	}
+	if err := capacity.ValidatePreemption(opts.Preemption); err != nil {
+		return fmt.Errorf("--preemption: %w", err)
+	}
+	if opts.Namespace != "" || opts.NamespaceLabels != "" || opts.PodLabels != "" || opts.PodFieldSelector != "" {
+		return fmt.Errorf("--preemption can not be used with --namespace, --namespace-labels, --pod-labels, or --pod-field-selector")
+	}
+	return nil
+}
+
+// validateStreamFlags rejects options that need every node to be read
+// before the first row can be printed. Values are checked instead of flags
+// so that options from the config file are caught too.
+func validateStreamFlags(opts *capacity.Options) error {
+	if !opts.Stream {
+		return nil
+	}
+	if opts.OutputFormat != capacity.TableOutput && opts.OutputFormat != capacity.CSVOutput && opts.OutputFormat != capacity.TSVOutput {
+		return fmt.Errorf("--stream can only be used with table, csv, or tsv output")
+	}
+	for _, conflict := range []struct {
+		name string
+		set  bool
+	}{
+		{"contexts", len(opts.Contexts) > 0},
+		{"all-contexts", opts.AllContexts},
+		{"show-vpa", opts.ShowVPA},
+		{"cost", opts.ShowCost || opts.PricingFile != ""},
+		{"quotas", opts.ShowQuotas},
+		{"headroom", opts.ShowHeadroom},
+		{"karpenter", opts.ShowKarpenter},
+		{"capacity-type", opts.ShowCapacityType},
+		{"arch", opts.ShowArch},
+		{"zone-balance", opts.ShowZoneBalance},
+		{"gpu", opts.ShowGPU},
+		{"simulate-remove-nodes", opts.SimulateRemoveNodes != ""},
+		{"hpa-headroom", opts.ShowHPAHeadroom},
+		{"allocate-by-pod-label", opts.AllocateByPodLabel != ""},
+		{"pending", opts.ShowPending},
+		{"daemonset-overhead", opts.ShowDaemonSetOverhead},
+		{"anomalies", opts.ShowAnomalies},
+		{"stuck-pods", opts.ShowStuckPods},
+		{"preemption", opts.Preemption != ""},
+		{"missing-resources", opts.ShowMissingResources},
+		{"compare-offset", opts.CompareOffset > 0},
+		{"show-delta", opts.ShowDelta},
+		{"heatmap", opts.Heatmap != ""},
+		{"virtual-nodes", opts.VirtualNodes == capacity.SeparateVirtualNodes},
+		{"sparkline", opts.ShowSparkline},
+		{"show-throttling", opts.ShowThrottling},
+		{"show-disk", opts.ShowDisk},
+		{"also-output", len(opts.AlsoOutput) > 0},
+	} {
+		if conflict.set {
+			return fmt.Errorf("--%s can not be used with --stream", conflict.name)
+		}
+	}
+	return nil
 }
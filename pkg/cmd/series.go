@@ -0,0 +1,79 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/robscott/kube-capacity/pkg/capacity"
+	"github.com/spf13/cobra"
+)
+
+func newSeriesCmd(opts *capacity.Options) *cobra.Command {
+	seriesCmd := &cobra.Command{
+		Use:   "series",
+		Short: "Print the usage of nodes or namespaces at every step of a window from Prometheus",
+		Long: "Read the CPU and memory usage of each node or namespace from Prometheus at every --step of a --window, " +
+			"to look at daily patterns of usage without a dashboard. CSV and TSV output give plain numbers for plotting in other tools.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := validateOutputType(opts.OutputFormat); err != nil {
+				return err
+			}
+
+			if err := validateDisplayUnits(opts.DisplayUnits); err != nil {
+				return err
+			}
+
+			if err := capacity.ValidateNumberFormat(*opts); err != nil {
+				return err
+			}
+
+			if err := capacity.ValidateSeries(*opts); err != nil {
+				return err
+			}
+
+			if err := validateNodeName(opts); err != nil {
+				return err
+			}
+
+			for _, name := range []string{"snapshot-in", "from-kubectl-dump", "snapshot-out", "contexts", "all-contexts"} {
+				if cmd.Flags().Changed(name) {
+					return fmt.Errorf("--%s can not be used with series, usage is read from Prometheus", name)
+				}
+			}
+
+			// Filters only apply to what the series are of
+			unsupported := []string{"node-name", "node-labels"}
+			if opts.SeriesBy == capacity.SeriesByNode {
+				unsupported = []string{"namespace"}
+			}
+			for _, name := range unsupported {
+				if cmd.Flags().Changed(name) {
+					return fmt.Errorf("--%s can not be used with series --by %s", name, opts.SeriesBy)
+				}
+			}
+
+			return capacity.FetchAndPrintSeries(*opts)
+		},
+	}
+	seriesCmd.Flags().StringVarP(&opts.SeriesWindow,
+		"window", "", capacity.DefaultSeriesWindow, "how far back to read usage from Prometheus, such as 7d")
+	seriesCmd.Flags().StringVarP(&opts.SeriesStep,
+		"step", "", capacity.DefaultSeriesStep, "interval between the points of each series, such as 15m")
+	seriesCmd.Flags().StringVarP(&opts.SeriesBy,
+		"by", "", capacity.SeriesByNode, fmt.Sprintf("what to print usage of (supports: %v)", capacity.SupportedSeriesGroupings()))
+	return seriesCmd
+}
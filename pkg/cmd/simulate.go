@@ -0,0 +1,89 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/robscott/kube-capacity/pkg/capacity"
+	"github.com/spf13/cobra"
+)
+
+func newSimulateCmd(opts *capacity.Options) *cobra.Command {
+	simulateCmd := &cobra.Command{
+		Use:   "simulate",
+		Short: "Simulate changes to the workloads of the cluster",
+		Args:  cobra.NoArgs,
+	}
+	simulateCmd.AddCommand(newSimulateScaleCmd(opts))
+	return simulateCmd
+}
+
+func newSimulateScaleCmd(opts *capacity.Options) *cobra.Command {
+	simulateScaleCmd := &cobra.Command{
+		Use:   "scale KIND/NAME --replicas N",
+		Short: "Check whether a Deployment, StatefulSet, or ReplicaSet could scale to a number of replicas",
+		Long: "Multiply the requests of a workload's pod template by the replicas it would add, place them " +
+			"first-fit-decreasing on the capacity left on each node, and report whether the scale-up fits and how " +
+			"the requests of the cluster would change.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := validateOutputType(opts.OutputFormat); err != nil {
+				return err
+			}
+
+			if err := validateDisplayUnits(opts.DisplayUnits); err != nil {
+				return err
+			}
+
+			if err := capacity.ValidateNumberFormat(*opts); err != nil {
+				return err
+			}
+
+			if err := validateNodeName(opts); err != nil {
+				return err
+			}
+
+			if err := capacity.ValidateScaleTarget(args[0]); err != nil {
+				return err
+			}
+
+			if !cmd.Flags().Changed("replicas") {
+				return fmt.Errorf("--replicas is required")
+			}
+			if opts.ScaleReplicas < 0 {
+				return fmt.Errorf("--replicas must not be negative")
+			}
+
+			// kube-state-metrics does not export the node selectors, affinity,
+			// and tolerations that decide where a replica can be placed
+			if opts.SpecSource == capacity.KubeStateMetricsSpecSource {
+				return fmt.Errorf("--spec-source %s can not be used with simulate scale", capacity.KubeStateMetricsSpecSource)
+			}
+
+			for _, name := range []string{"snapshot-in", "from-kubectl-dump", "contexts", "all-contexts"} {
+				if cmd.Flags().Changed(name) {
+					return fmt.Errorf("--%s can not be used with simulate scale, the workload is read from the cluster", name)
+				}
+			}
+
+			opts.ScaleWorkload = args[0]
+			return capacity.FetchAndPrintScaleSimulation(*opts)
+		},
+	}
+	simulateScaleCmd.Flags().Int64VarP(&opts.ScaleReplicas,
+		"replicas", "", 0, "number of replicas to scale the workload to")
+	return simulateScaleCmd
+}
@@ -0,0 +1,70 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/robscott/kube-capacity/pkg/capacity"
+	"github.com/spf13/cobra"
+)
+
+func newSlackCmd(opts *capacity.Options) *cobra.Command {
+	slackCmd := &cobra.Command{
+		Use:   "slack",
+		Short: "Rank namespaces by requests left unused over a window of Prometheus usage history",
+		Long: "Compare the requests of each namespace with a percentile of its usage in Prometheus over a time window, " +
+			"and rank namespaces by the capacity that could be reclaimed, in CPU, memory, and the number of average nodes it adds up to.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := validateOutputType(opts.OutputFormat); err != nil {
+				return err
+			}
+
+			if err := validateDisplayUnits(opts.DisplayUnits); err != nil {
+				return err
+			}
+
+			if err := capacity.ValidateNumberFormat(*opts); err != nil {
+				return err
+			}
+
+			if err := validatePodFieldSelector(opts); err != nil {
+				return err
+			}
+
+			if err := validateNodeName(opts); err != nil {
+				return err
+			}
+
+			if opts.RecommendPercentile <= 0 || opts.RecommendPercentile > 100 {
+				return fmt.Errorf("--percentile must be greater than 0 and at most 100")
+			}
+
+			for _, name := range []string{"snapshot-in", "from-kubectl-dump", "snapshot-out", "contexts", "all-contexts"} {
+				if cmd.Flags().Changed(name) {
+					return fmt.Errorf("--%s can not be used with slack, usage history is read from Prometheus", name)
+				}
+			}
+
+			return capacity.FetchAndPrintNamespaceSlack(*opts)
+		},
+	}
+	slackCmd.Flags().StringVarP(&opts.RecommendWindow,
+		"window", "", "7d", "time window of Prometheus usage history to compare requests with")
+	slackCmd.Flags().Float64VarP(&opts.RecommendPercentile,
+		"percentile", "", 95, "percentile of usage over the window to count as used")
+	return slackCmd
+}
@@ -0,0 +1,51 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/robscott/kube-capacity/pkg/capacity"
+	"github.com/spf13/cobra"
+)
+
+func newVerifyCmd(opts *capacity.Options) *cobra.Command {
+	verifyCmd := &cobra.Command{
+		Use:   "verify",
+		Short: "Check that kube-capacity can reach the cluster, its metrics sources, and the APIs it needs",
+		Long: "Check that the API server is reachable, that the current user can list the resources kube-capacity " +
+			"reads, and that metrics-server and Prometheus answer, and print a checklist. Checks needed by the flags " +
+			"given fail and exit with 1, and checks of features those flags do not use only warn.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := validateOutputType(opts.OutputFormat); err != nil {
+				return err
+			}
+
+			for _, name := range []string{"snapshot-in", "from-kubectl-dump", "snapshot-out", "contexts", "all-contexts"} {
+				if cmd.Flags().Changed(name) {
+					return fmt.Errorf("--%s can not be used with verify", name)
+				}
+			}
+
+			if opts.UsePrometheus || cmd.Flags().Changed("metrics-source") {
+				opts.ShowUtil = true
+			}
+
+			return capacity.FetchAndPrintVerify(*opts)
+		},
+	}
+	return verifyCmd
+}
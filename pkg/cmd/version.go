@@ -22,14 +22,13 @@ import (
 
 var version string = "development"
 
-func init() {
-	rootCmd.AddCommand(versionCmd)
-}
-
-var versionCmd = &cobra.Command{
-	Use:   "version",
-	Short: "Print the version number of kube-capacity",
-	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Printf("kube-capacity version %s\n", version)
-	},
+func newVersionCmd() *cobra.Command {
+	versionCmd := &cobra.Command{
+		Use:   "version",
+		Short: "Print the version number of kube-capacity",
+		Run: func(cmd *cobra.Command, args []string) {
+			fmt.Fprintf(cmd.OutOrStdout(), "kube-capacity version %s\n", version)
+		},
+	}
+	return versionCmd
 }
@@ -15,6 +15,10 @@
 package kube
 
 import (
+	"os"
+	"sort"
+
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
@@ -26,8 +30,8 @@ import (
 )
 
 // NewClientSet returns a new Kubernetes clientset
-func NewClientSet(kubeContext, kubeConfig string, FlagInsecure bool, impersonateUser string, impersonateGroup string) (*kubernetes.Clientset, error) {
-	config, err := getKubeConfig(kubeContext, kubeConfig, FlagInsecure)
+func NewClientSet(kubeContext, kubeConfig string, FlagInsecure bool, impersonateUser string, impersonateGroup string, limit RateLimit) (*kubernetes.Clientset, error) {
+	config, err := getKubeConfig(kubeContext, kubeConfig, FlagInsecure, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -42,20 +46,92 @@ func NewClientSet(kubeContext, kubeConfig string, FlagInsecure bool, impersonate
 		}
 	}
 
-	return kubernetes.NewForConfig(config)
+	client, err := newHTTPClient(config)
+	if err != nil {
+		return nil, err
+	}
+	return kubernetes.NewForConfigAndClient(config, client)
 }
 
 // NewMetricsClientSet returns a new clientset for Kubernetes metrics
-func NewMetricsClientSet(kubeContext, kubeConfig string, FlagInsecure bool) (*metrics.Clientset, error) {
-	config, err := getKubeConfig(kubeContext, kubeConfig, FlagInsecure)
+func NewMetricsClientSet(kubeContext, kubeConfig string, FlagInsecure bool, limit RateLimit) (*metrics.Clientset, error) {
+	config, err := getKubeConfig(kubeContext, kubeConfig, FlagInsecure, limit)
 	if err != nil {
 		return nil, err
 	}
 
-	return metrics.NewForConfig(config)
+	client, err := newHTTPClient(config)
+	if err != nil {
+		return nil, err
+	}
+	return metrics.NewForConfigAndClient(config, client)
 }
 
-func getKubeConfig(kubeContext, kubeConfig string, insecureSkipTLSVerify bool) (*rest.Config, error) {
+// NewDynamicClient returns a new dynamic client for custom resources
+func NewDynamicClient(kubeContext, kubeConfig string, FlagInsecure bool, limit RateLimit) (dynamic.Interface, error) {
+	config, err := getKubeConfig(kubeContext, kubeConfig, FlagInsecure, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := newHTTPClient(config)
+	if err != nil {
+		return nil, err
+	}
+	return dynamic.NewForConfigAndClient(config, client)
+}
+
+// Defaults for the client-side rate limit of a client, the same as
+// client-go uses when none is set
+const (
+	DefaultQPS   float32 = 5
+	DefaultBurst int     = 10
+)
+
+// RateLimit is the client-side rate limit of a client. Zero values leave
+// the defaults of client-go in place.
+type RateLimit struct {
+	QPS   float32
+	Burst int
+}
+
+func getKubeConfig(kubeContext, kubeConfig string, insecureSkipTLSVerify bool, limit RateLimit) (*rest.Config, error) {
+	var config *rest.Config
+	var err error
+	if InCluster(kubeContext, kubeConfig) {
+		config, err = rest.InClusterConfig()
+		if err != nil {
+			return nil, err
+		}
+		if insecureSkipTLSVerify {
+			config.Insecure = true
+			config.TLSClientConfig.CAFile = ""
+			config.TLSClientConfig.CAData = nil
+		}
+	} else {
+		config, err = newClientConfig(kubeContext, kubeConfig, insecureSkipTLSVerify).ClientConfig()
+		if err != nil {
+			return nil, err
+		}
+	}
+	config.QPS = limit.QPS
+	config.Burst = limit.Burst
+	return config, nil
+}
+
+// InCluster reports whether clients for the given context and kubeconfig
+// flags connect with the service account of the pod we are running in,
+// which is the case when neither flag is set and no kubeconfig can be found
+func InCluster(kubeContext, kubeConfig string) bool {
+	if kubeContext != "" || kubeConfig != "" || os.Getenv("KUBERNETES_SERVICE_HOST") == "" {
+		return false
+	}
+	rawConfig, err := clientcmd.NewDefaultClientConfigLoadingRules().Load()
+	return err == nil && clientcmdapi.IsConfigEmpty(rawConfig)
+}
+
+// newClientConfig returns a client config for the given flags
+func newClientConfig(kubeContext, kubeConfig string, insecureSkipTLSVerify bool) clientcmd.ClientConfig {
 	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
 	if kubeConfig != "" {
 		loadingRules.ExplicitPath = kubeConfig
@@ -63,5 +139,47 @@ func getKubeConfig(kubeContext, kubeConfig string, insecureSkipTLSVerify bool) (
 	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
 		loadingRules,
 		&clientcmd.ConfigOverrides{ClusterInfo: clientcmdapi.Cluster{InsecureSkipTLSVerify: insecureSkipTLSVerify}, CurrentContext: kubeContext},
-	).ClientConfig()
+	)
+}
+
+// InClusterContextName is the context name reported when running inside a
+// pod without a kubeconfig
+const InClusterContextName = "in-cluster"
+
+// GetContextName returns the name of the context that will be used for the
+// given context and kubeconfig flags
+func GetContextName(kubeContext, kubeConfig string) (string, error) {
+	if kubeContext != "" {
+		return kubeContext, nil
+	}
+	if InCluster(kubeContext, kubeConfig) {
+		return InClusterContextName, nil
+	}
+	rawConfig, err := newClientConfig("", kubeConfig, false).RawConfig()
+	if err != nil {
+		return "", err
+	}
+	return rawConfig.CurrentContext, nil
+}
+
+// GetContextNames returns the sorted names of all contexts in the kubeconfig
+func GetContextNames(kubeConfig string) ([]string, error) {
+	rawConfig, err := newClientConfig("", kubeConfig, false).RawConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	names := []string{}
+	for name := range rawConfig.Contexts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// GetNamespace returns the namespace of the context that will be used for
+// the given context and kubeconfig flags, default when it sets none
+func GetNamespace(kubeContext, kubeConfig string) (string, error) {
+	namespace, _, err := newClientConfig(kubeContext, kubeConfig, false).Namespace()
+	return namespace, err
 }
@@ -0,0 +1,80 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kube
+
+import (
+	"io"
+	"net/http"
+
+	"k8s.io/client-go/rest"
+)
+
+// newHTTPClient returns the client a clientset sends its requests with,
+// retrying a request once when the API server rejects its credentials
+func newHTTPClient(config *rest.Config) (*http.Client, error) {
+	client, err := rest.HTTPClientFor(config)
+	if err != nil {
+		return nil, err
+	}
+	client.Transport = &reauthRoundTripper{rt: client.Transport}
+	return client, nil
+}
+
+// reauthRoundTripper retries a request that is rejected as unauthorized.
+// Exec plugins such as aws eks get-token and kubelogin are run again by
+// client-go once a token is rejected, and token files are read again, so
+// the retry goes out with fresh credentials. Without it, a token that
+// expires or is revoked during a run of --interval fails the run and ends
+// the daemon.
+type reauthRoundTripper struct {
+	rt http.RoundTripper
+}
+
+func (r *reauthRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := r.rt.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	retry, ok := rewindRequest(req)
+	if !ok {
+		return resp, nil
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	return r.rt.RoundTrip(retry)
+}
+
+// rewindRequest returns a copy of a request to send again, or false when
+// its body has been read and can not be read again
+func rewindRequest(req *http.Request) (*http.Request, bool) {
+	retry := req.Clone(req.Context())
+	if req.Body == nil || req.Body == http.NoBody {
+		return retry, true
+	}
+	if req.GetBody == nil {
+		return nil, false
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, false
+	}
+	retry.Body = body
+	return retry, true
+}
+
+// WrappedRoundTripper lets client-go unwrap the transport for debugging
+func (r *reauthRoundTripper) WrappedRoundTripper() http.RoundTripper {
+	return r.rt
+}
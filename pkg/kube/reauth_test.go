@@ -0,0 +1,108 @@
+// Copyright 2026 Kube Capacity Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kube
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// statusServer answers requests with statuses in turn, repeating the last
+// one, and records the body of each request
+type statusServer struct {
+	*httptest.Server
+	mu       sync.Mutex
+	statuses []int
+	bodies   []string
+}
+
+func newStatusServer(t *testing.T, statuses ...int) *statusServer {
+	s := &statusServer{statuses: statuses}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+
+		s.mu.Lock()
+		status := s.statuses[len(s.statuses)-1]
+		if len(s.bodies) < len(s.statuses) {
+			status = s.statuses[len(s.bodies)]
+		}
+		s.bodies = append(s.bodies, string(body))
+		s.mu.Unlock()
+
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *statusServer) requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string{}, s.bodies...)
+}
+
+func TestReauthRoundTripperRetriesOnce(t *testing.T) {
+	server := newStatusServer(t, http.StatusUnauthorized, http.StatusOK)
+	rt := &reauthRoundTripper{rt: http.DefaultTransport}
+
+	// The body of a request built from a strings.Reader can be read again
+	// through GetBody
+	req, err := http.NewRequest(http.MethodPost, server.URL, strings.NewReader(`{"kind":"SelfSubjectAccessReview"}`))
+	require.NoError(t, err)
+	require.NotNil(t, req.GetBody)
+
+	resp, err := rt.RoundTrip(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, []string{`{"kind":"SelfSubjectAccessReview"}`, `{"kind":"SelfSubjectAccessReview"}`}, server.requests())
+}
+
+func TestReauthRoundTripperReturnsSecondUnauthorized(t *testing.T) {
+	server := newStatusServer(t, http.StatusUnauthorized)
+	rt := &reauthRoundTripper{rt: http.DefaultTransport}
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+
+	resp, err := rt.RoundTrip(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Len(t, server.requests(), 2, "the request is only retried once")
+}
+
+func TestReauthRoundTripperKeepsUnrewindableRequest(t *testing.T) {
+	server := newStatusServer(t, http.StatusUnauthorized, http.StatusOK)
+	rt := &reauthRoundTripper{rt: http.DefaultTransport}
+
+	req, err := http.NewRequest(http.MethodPost, server.URL, io.NopCloser(strings.NewReader("payload")))
+	require.NoError(t, err)
+	require.Nil(t, req.GetBody)
+
+	resp, err := rt.RoundTrip(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Equal(t, []string{"payload"}, server.requests())
+}